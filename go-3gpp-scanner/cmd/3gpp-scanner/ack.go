@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/monitor"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Ack command flags
+	ackDB        string
+	ackFQDNs     []string
+	ackOperators []string
+	ackFile      string
+	ackNote      string
+	ackExpires   dayDuration
+	ackFinding   string
+	ackFormat    string
+)
+
+// ackFindings lists the findings that can be acknowledged
+var ackFindings = []string{
+	database.FindingAll,
+	database.FindingNewFQDN,
+	monitor.KindUnresolved,
+	monitor.KindIPChanged,
+	monitor.KindCertExpiring,
+	monitor.KindCertExpired,
+	monitor.KindMassChange,
+}
+
+func ackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ack",
+		Short: "Acknowledge reviewed findings so reports stop repeating them",
+		Long: `Acknowledge findings a team has reviewed, in bulk, so recurring reports
+stay focused on new items. A finding is one of:
+
+  new-fqdn        the FQDN is new: query and export --since skip it
+  unresolved, ip-changed, cert-expiring, cert-expired, mass-change
+                  monitor and alerts alerts of that kind are not reported
+  all             every finding above
+
+Acknowledgments apply to FQDNs or to all FQDNs of an operator, and lapse
+after --expires if given. The daemons re-read them at every check.
+Acknowledging a finding again replaces its note and expiry.`,
+		Example: `  # GeoDNS names change addresses all the time
+  3gpp-scanner ack add ip-changed --file=geodns.txt --note="round-robin DNS"
+
+  # Known expiring certificate of a test network, for two weeks
+  3gpp-scanner ack add cert-expiring --fqdn=xcap.ims.mnc099.mcc262.pub.3gppnetwork.org --expires=14d
+
+  # Reviewed all new FQDNs of an operator; list and withdraw acknowledgments
+  3gpp-scanner ack add new-fqdn --operator="Example Mobile"
+  3gpp-scanner ack list --finding=new-fqdn
+  3gpp-scanner ack remove new-fqdn --operator="Example Mobile"`,
+	}
+
+	cmd.PersistentFlags().StringVar(&ackDB, "db", "database.db", "Database file path")
+
+	add := &cobra.Command{
+		Use:   "add FINDING",
+		Short: "Acknowledge a finding on FQDNs or operators",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAckAdd,
+	}
+	add.Flags().StringVar(&ackNote, "note", "", "Why the finding is acknowledged")
+	add.Flags().Var(&ackExpires, "expires", "Acknowledgment lapses after this time, e.g. 30d or 72h; 0 never lapses")
+	remove := &cobra.Command{
+		Use:   "remove FINDING",
+		Short: "Withdraw the acknowledgment of a finding",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAckRemove,
+	}
+	for _, c := range []*cobra.Command{add, remove} {
+		c.Flags().StringSliceVar(&ackFQDNs, "fqdn", nil, "FQDNs (comma-separated)")
+		c.Flags().StringSliceVar(&ackOperators, "operator", nil, "Operators, covering all their FQDNs (comma-separated)")
+		c.Flags().StringVarP(&ackFile, "file", "f", "", "File containing FQDNs (one per line)")
+		cmd.AddCommand(c)
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List acknowledgments",
+		Args:  cobra.NoArgs,
+		RunE:  runAckList,
+	}
+	list.Flags().StringVar(&ackFinding, "finding", "", "Only acknowledgments of this finding")
+	list.Flags().StringVar(&ackFormat, "format", "text", "Output format: text or json")
+	cmd.AddCommand(list)
+
+	return cmd
+}
+
+// validateFinding checks a finding name
+func validateFinding(finding string) error {
+	if !slices.Contains(ackFindings, finding) {
+		return fmt.Errorf("invalid finding: %s (must be one of %s)", finding, strings.Join(ackFindings, ", "))
+	}
+	return nil
+}
+
+// validateAckFlags validates ack add and remove flags
+func validateAckFlags(finding string) error {
+	if err := validateFinding(finding); err != nil {
+		return err
+	}
+	if len(ackFQDNs) == 0 && len(ackOperators) == 0 && ackFile == "" {
+		return fmt.Errorf("at least one of --fqdn, --operator or --file required")
+	}
+	if ackExpires < 0 {
+		return fmt.Errorf("--expires cannot be negative")
+	}
+	return nil
+}
+
+// validateAckListFlags validates ack list flags
+func validateAckListFlags() error {
+	if ackFinding != "" {
+		if err := validateFinding(ackFinding); err != nil {
+			return fmt.Errorf("--finding: %w", err)
+		}
+	}
+	if ackFormat != "text" && ackFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", ackFormat)
+	}
+	return nil
+}
+
+func runAckAdd(cmd *cobra.Command, args []string) error {
+	var expires *time.Time
+	if ackExpires > 0 {
+		t := time.Now().Add(time.Duration(ackExpires))
+		expires = &t
+	}
+	return changeAcks(args[0], func(db *database.DB, kind string, targets []string) (int, error) {
+		return db.AddAcks(args[0], kind, targets, ackNote, expires)
+	}, "Acknowledged")
+}
+
+func runAckRemove(cmd *cobra.Command, args []string) error {
+	return changeAcks(args[0], func(db *database.DB, kind string, targets []string) (int, error) {
+		return db.RemoveAcks(args[0], kind, targets)
+	}, "Withdrew")
+}
+
+// changeAcks applies an add or remove to the FQDNs and operators selected
+// by the flags
+func changeAcks(finding string, change func(*database.DB, string, []string) (int, error), verb string) error {
+	if err := validateAckFlags(finding); err != nil {
+		return err
+	}
+
+	names := ackFQDNs
+	if ackFile != "" {
+		lines, err := readFQDNsFromFile(ackFile)
+		if err != nil {
+			return fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+		names = append(append([]string(nil), names...), lines...)
+	}
+	// Alerts and the database carry FQDNs in lower case without the root
+	fqdns := make([]string, len(names))
+	for i, fqdn := range names {
+		fqdns[i] = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(fqdn), "."))
+	}
+
+	db, err := openDB(ackDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	fqdnCount, err := change(db, database.TagKindFQDN, fqdns)
+	if err != nil {
+		return err
+	}
+	operatorCount, err := change(db, database.TagKindOperator, ackOperators)
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("%s %s: %d FQDNs, %d operators\n", verb, finding, fqdnCount, operatorCount)
+	}
+	return nil
+}
+
+func runAckList(cmd *cobra.Command, args []string) error {
+	if err := validateAckListFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(ackDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	acks, err := db.QueryAcks(ackFinding)
+	if err != nil {
+		return err
+	}
+	if ackFormat == "json" {
+		if acks == nil {
+			acks = []database.Ack{}
+		}
+		return output.ExportJSON(acks, "/dev/stdout")
+	}
+
+	now := time.Now()
+	for _, a := range acks {
+		expires := "never"
+		if a.Expires != nil {
+			expires = a.Expires.UTC().Format("2006-01-02 15:04")
+			if !a.Active(now) {
+				expires += " (expired)"
+			}
+		}
+		line := fmt.Sprintf("%-13s %-8s  %-34s  %s", a.Finding, a.Kind, "expires "+expires, a.Target)
+		if a.Note != "" {
+			line += "  # " + a.Note
+		}
+		fmt.Println(line)
+	}
+	if !quiet {
+		if len(acks) == 0 {
+			fmt.Println("No acknowledgments")
+		} else {
+			fmt.Printf("\nFound %d acknowledgments\n", len(acks))
+		}
+	}
+	return nil
+}
+
+// loadAcks reads the active acknowledgments of a database
+func loadAcks(path string) (*database.AckSet, error) {
+	db, err := openDB(path)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+	return db.ActiveAcks(time.Now())
+}
+
+// dropAcked removes the alerts acknowledged in acks and returns the rest
+// with the number removed. Acknowledging unresolved also silences the
+// resolved alert that follows it.
+func dropAcked(alerts []monitor.Alert, acks *database.AckSet) ([]monitor.Alert, int) {
+	if acks.Len() == 0 {
+		return alerts, 0
+	}
+	var kept []monitor.Alert
+	for _, alert := range alerts {
+		finding := alert.Kind
+		if finding == monitor.KindResolved {
+			finding = monitor.KindUnresolved
+		}
+		if !acks.Covers(finding, alert.FQDN, alert.Operator) {
+			kept = append(kept, alert)
+		}
+	}
+	return kept, len(alerts) - len(kept)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/monitor"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Alerts command flags
+	alertsDB        string
+	alertsWithin    = dayDuration(monitor.DefaultConfig().CertWithin)
+	alertsWatchlist string
+	alertsInterval  time.Duration
+	alertsWebhook   string
+	alertsFormat    string
+)
+
+func alertsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alerts",
+		Short: "Alert on findings stored in the database",
+	}
+
+	certExpiry := &cobra.Command{
+		Use:   "cert-expiry",
+		Short: "Alert on stored TLS certificates close to expiry",
+		Long: `Report the certificates recorded by the tls probe (probe --probes=tls --db=...)
+that have expired or expire within --within, most urgent first. The latest
+stored result of each FQDN counts, so probe again after renewals.
+
+With --watchlist only the networks listed are reported (one PLMN as MCC-MNC
+or operator name per line, as for monitor). With --interval the command runs
+as a daemon, re-reading the database at that interval and alerting once per
+certificate: a certificate is reported again when it expires, or when a
+renewed one comes close to expiry in turn. Certificates acknowledged with
+'ack add cert-expiring' (or cert-expired) are not reported. Alerts are printed and, with
+--webhook, posted as JSON (with a "text" field for Slack and Mattermost).`,
+		Example: `  # Certificates expiring within 30 days
+  3gpp-scanner probe --file=results.json --probes=tls --db=database.db
+  3gpp-scanner alerts cert-expiry --db=database.db --within=30d
+
+  # Our own networks only, checked hourly and posted to a chat webhook
+  3gpp-scanner alerts cert-expiry --db=database.db --watchlist=our-plmns.txt \
+    --interval=1h --webhook=https://hooks.slack.com/services/...`,
+		RunE: runAlertsCertExpiry,
+	}
+	certExpiry.Flags().StringVar(&alertsDB, "db", "database.db", "Database file path")
+	certExpiry.Flags().Var(&alertsWithin, "within", "Alert for certificates expiring within this time, e.g. 30d or 72h")
+	certExpiry.Flags().StringVar(&alertsWatchlist, "watchlist", "", "Only report these PLMNs (MCC-MNC) and operator names, one per line")
+	certExpiry.Flags().DurationVar(&alertsInterval, "interval", 0, "Check again at this interval until stopped (default: check once)")
+	certExpiry.Flags().StringVar(&alertsWebhook, "webhook", "", "URL alerts are posted to as JSON")
+	certExpiry.Flags().StringVar(&alertsFormat, "format", "text", "Alert output format: text or json (one alert per line)")
+	cmd.AddCommand(certExpiry)
+
+	return cmd
+}
+
+// validateAlertsFlags validates alerts command flags
+func validateAlertsFlags() error {
+	if alertsWithin < 0 {
+		return fmt.Errorf("--within cannot be negative")
+	}
+	if alertsInterval < 0 {
+		return fmt.Errorf("--interval cannot be negative")
+	}
+	if alertsInterval > 0 && alertsInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+	return validateAlertOutput(alertsWebhook, alertsFormat)
+}
+
+// certExpiryAlerts returns alerts for the latest stored tls probe result of
+// each FQDN whose certificate has expired or expires within the given time,
+// soonest expiry first. A non-nil watchlist restricts them to its networks.
+func certExpiryAlerts(db *database.DB, list *monitor.Watchlist, within time.Duration, now time.Time) ([]monitor.Alert, error) {
+	results, err := db.QueryResults("", "tls")
+	if err != nil {
+		return nil, err
+	}
+	expiries := make(map[string]time.Time)
+	for _, r := range results {
+		// Oldest first: later results replace earlier ones
+		notAfter, err := time.Parse(time.RFC3339, r.Metadata["not_after"])
+		if !r.Success || err != nil {
+			delete(expiries, r.Target)
+			continue
+		}
+		expiries[r.Target] = notAfter
+	}
+
+	var fqdns []string
+	if list != nil {
+		targets, err := watchTargets(db, list)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			if _, ok := expiries[target.FQDN]; ok {
+				fqdns = append(fqdns, target.FQDN)
+			}
+		}
+	} else {
+		for fqdn := range expiries {
+			fqdns = append(fqdns, fqdn)
+		}
+	}
+
+	operators := make(map[string]string)
+	details, err := db.QueryDetails(fqdns)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		operators[detail.FQDN] = detail.Operator
+	}
+
+	var alerts []monitor.Alert
+	for _, fqdn := range fqdns {
+		if alert, ok := monitor.CertAlert(fqdn, operators[fqdn], expiries[fqdn], now, within); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CertExpiry.Equal(*alerts[j].CertExpiry) {
+			return alerts[i].CertExpiry.Before(*alerts[j].CertExpiry)
+		}
+		return alerts[i].FQDN < alerts[j].FQDN
+	})
+	return alerts, nil
+}
+
+func runAlertsCertExpiry(cmd *cobra.Command, args []string) error {
+	if err := validateAlertsFlags(); err != nil {
+		return err
+	}
+	var list *monitor.Watchlist
+	if alertsWatchlist != "" {
+		var err error
+		if list, err = monitor.LoadWatchlist(alertsWatchlist); err != nil {
+			return err
+		}
+	}
+	db, err := openDB(alertsDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// Kind and expiry last reported per FQDN, so a daemon alerts once per change
+	reported := make(map[string]string)
+	for {
+		alerts, err := certExpiryAlerts(db, list, time.Duration(alertsWithin), time.Now())
+		if err != nil {
+			return err
+		}
+		acks, err := db.ActiveAcks(time.Now())
+		if err != nil {
+			return err
+		}
+		alerts, acked := dropAcked(alerts, acks)
+		var fresh []monitor.Alert
+		for _, alert := range alerts {
+			key := alert.Kind + " " + alert.CertExpiry.String()
+			if reported[alert.FQDN] != key {
+				reported[alert.FQDN] = key
+				fresh = append(fresh, alert)
+			}
+		}
+		if err := reportAlerts(ctx, fresh, alertsFormat, alertsWebhook); err != nil {
+			// Certificates are still checked at the next interval; only
+			// a single run fails on an undelivered report
+			if alertsInterval == 0 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if alertsInterval == 0 {
+			if len(alerts) == 0 && !quiet && alertsFormat == "text" {
+				fmt.Printf("No certificates expired or expiring within %s\n", alertsWithin.String())
+			}
+			if acked > 0 && !quiet && alertsFormat == "text" {
+				fmt.Printf("%d acknowledged certificates not reported\n", acked)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(alertsInterval):
+		}
+	}
+}
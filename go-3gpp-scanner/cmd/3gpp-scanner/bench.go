@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Bench command flags
+	benchResolvers []string
+	benchFile      string
+	benchDB        string
+	benchSample    int
+	benchWorkers   int
+	benchSourceIP  string
+	benchInterface string
+	benchFormat    string
+)
+
+func benchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark the infrastructure a scan depends on",
+	}
+
+	resolvers := &cobra.Command{
+		Use:   "resolvers",
+		Short: "Measure resolver latency, timeouts and throughput",
+		Long: `Query a sample of FQDNs against each resolver in turn and report latency
+percentiles, the timeout rate and the throughput reached. Resolvers are then
+ordered by the expected time per query (a timeout costs the full 5s query
+timeout) and a --delay and --concurrency are recommended for scans: half the
+throughput of the fastest resolver (at most 100 qps), with enough workers
+to sustain it.
+
+The sample defaults to a built-in mix of existing and nonexistent 3GPP names;
+--file or --db benchmark against names from a previous scan instead.`,
+		Example: `  # Benchmark the default resolvers
+  3gpp-scanner bench resolvers
+
+  # Compare local and public resolvers on 100 names from the database
+  3gpp-scanner bench resolvers --resolvers=10.0.0.53,1.1.1.1,8.8.8.8 --db=database.db --sample=100
+
+  # Apply the recommendation
+  3gpp-scanner scan --mode=epdg --resolvers=10.0.0.53:53,1.1.1.1:53 --delay=40 --concurrency=3`,
+		Args: cobra.NoArgs,
+		RunE: runBenchResolvers,
+	}
+	resolvers.Flags().StringSliceVar(&benchResolvers, "resolvers", nil, "Resolvers to benchmark, as IP or IP:port (comma-separated; default: the scan resolvers)")
+	resolvers.Flags().StringVarP(&benchFile, "file", "f", "", "File containing FQDNs to sample (one per line)")
+	resolvers.Flags().StringVar(&benchDB, "db", "", "Database to sample FQDNs from")
+	resolvers.Flags().IntVar(&benchSample, "sample", 50, "Number of FQDNs queried per resolver")
+	resolvers.Flags().IntVarP(&benchWorkers, "workers", "w", 4, "Number of concurrent queries per resolver")
+	resolvers.Flags().StringVar(&benchSourceIP, "source-ip", "", "Local IP address to send DNS queries from")
+	resolvers.Flags().StringVar(&benchInterface, "interface", "", "Network interface to send DNS queries from")
+	resolvers.Flags().StringVar(&benchFormat, "format", "text", "Output format: text or json")
+	cmd.AddCommand(resolvers)
+
+	return cmd
+}
+
+// validateBenchFlags validates bench resolvers flags
+func validateBenchFlags() error {
+	if benchFile != "" && benchDB != "" {
+		return fmt.Errorf("cannot specify both --file and --db")
+	}
+	if benchSample <= 0 {
+		return fmt.Errorf("--sample must be positive")
+	}
+	if benchWorkers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if _, err := dns.ParseResolvers(benchResolvers); err != nil {
+		return err
+	}
+	if err := (netbind.Binding{SourceIP: benchSourceIP, Interface: benchInterface}).Validate(); err != nil {
+		return err
+	}
+	if benchFormat != "text" && benchFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", benchFormat)
+	}
+	return nil
+}
+
+// benchReport is the JSON output of bench resolvers
+type benchReport struct {
+	Sample         int                 `json:"sample"`
+	Workers        int                 `json:"workers"`
+	Results        []dns.ResolverBench `json:"results"`
+	Recommendation dns.Recommendation  `json:"recommendation"`
+}
+
+// Bench resolvers command implementation
+func runBenchResolvers(cmd *cobra.Command, args []string) error {
+	if err := validateBenchFlags(); err != nil {
+		return err
+	}
+
+	servers, _ := dns.ParseResolvers(benchResolvers)
+	if len(servers) == 0 {
+		servers = dns.DefaultServers
+	}
+
+	fqdns, err := benchSampleFQDNs()
+	if err != nil {
+		return err
+	}
+	if len(fqdns) == 0 {
+		return fmt.Errorf("no FQDNs to sample")
+	}
+
+	if !quiet {
+		fmt.Printf("Benchmarking %d resolvers with %d FQDNs and %d workers each\n", len(servers), len(fqdns), benchWorkers)
+	}
+
+	scanner := dns.NewScanner(&models.ScanConfig{
+		SourceIP:  benchSourceIP,
+		Interface: benchInterface,
+	})
+	if !quiet {
+		scanner.SetProgressCallback(func(current, total, found int) {
+			fmt.Printf("  %d/%d resolvers done\n", current, total)
+		})
+	}
+	results := scanner.BenchResolvers(context.Background(), servers, fqdns, benchWorkers)
+	rec := dns.Recommend(results)
+
+	if benchFormat == "json" {
+		report := benchReport{Sample: len(fqdns), Workers: benchWorkers, Results: results, Recommendation: rec}
+		if err := output.ExportJSON(report, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	if !quiet {
+		fmt.Println()
+	}
+	fmt.Print(dns.FormatBench(results, rec))
+	return nil
+}
+
+// benchSampleFQDNs picks up to --sample FQDNs at random from --file or
+// --db, or returns the built-in sample
+func benchSampleFQDNs() ([]string, error) {
+	var fqdns []string
+	switch {
+	case benchFile != "":
+		var err error
+		if fqdns, err = readFQDNsFromFile(benchFile); err != nil {
+			return nil, fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+	case benchDB != "":
+		db, err := openDB(benchDB)
+		if err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+
+		if fqdns, err = db.GetAllFQDNs(); err != nil {
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+	default:
+		fqdns = dns.DefaultBenchFQDNs
+	}
+
+	if len(fqdns) > benchSample {
+		sample := append([]string(nil), fqdns...)
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rng.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+		fqdns = sample[:benchSample]
+	}
+	return fqdns, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/bgp"
+	"3gpp-scanner/internal/ipclass"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// BGP command flags
+	bgpFile      string
+	bgpDB        string
+	bgpRIB       string
+	bgpASNames   string
+	bgpSubdomain string
+	bgpRefresh   bool
+	bgpWorkers   int
+	bgpTop       int
+	bgpFormat    string
+)
+
+func bgpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bgp",
+		Short: "Look up the announcing prefix and origin AS of discovered addresses",
+		Long: `Look up the BGP prefix each public address is announced in and the AS
+originating it, then count FQDNs per origin AS: which operators, transit or
+cloud providers host the endpoints, e.g. the ePDGs. Addresses in the space
+of large cloud providers (AWS, Google Cloud, Azure, ...) are listed
+separately.
+
+Origins come from the RIPEstat Data API (stat.ripe.net) unless a local
+routing table is given with --rib: a CAIDA prefix-to-AS file or lines of
+"PREFIX ASN" such as bgp.tools table.txt, optionally with AS names from a
+CSV file of AS number and name (--as-names, e.g. bgp.tools asns.csv).
+
+With --db the origins are stored in the database and reused on later runs;
+--refresh looks all addresses up again.`,
+		Example: `  # Origins of a scan export via RIPEstat
+  3gpp-scanner bgp --file=results.json
+
+  # Which networks host ePDGs, from a local table, stored in the database
+  3gpp-scanner bgp --db=database.db --subdomain=epdg.epc --rib=table.txt --as-names=asns.csv`,
+		RunE: runBGP,
+	}
+
+	cmd.Flags().StringVarP(&bgpFile, "file", "f", "", "JSON scan export to analyze")
+	cmd.Flags().StringVar(&bgpDB, "db", "", "Database to analyze; origins are stored in it")
+	cmd.Flags().StringVar(&bgpRIB, "rib", "", "Local routing table instead of RIPEstat (pfx2as or \"PREFIX ASN\" lines)")
+	cmd.Flags().StringVar(&bgpASNames, "as-names", "", "CSV file of AS numbers and names for --rib")
+	cmd.Flags().StringVar(&bgpSubdomain, "subdomain", "", "Only FQDNs of this subdomain (e.g. epdg.epc)")
+	cmd.Flags().BoolVar(&bgpRefresh, "refresh", false, "Look up addresses again even if origins are stored (--db)")
+	cmd.Flags().IntVar(&bgpWorkers, "workers", 4, "Parallel lookups")
+	cmd.Flags().IntVar(&bgpTop, "top", stats.DefaultTop, "Origin ASes to list (0 for all)")
+	cmd.Flags().StringVar(&bgpFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateBGPFlags validates bgp command flags
+func validateBGPFlags() error {
+	if bgpFile == "" && bgpDB == "" {
+		return fmt.Errorf("either --file or --db required")
+	}
+	if bgpFile != "" && bgpDB != "" {
+		return fmt.Errorf("cannot specify both --file and --db")
+	}
+	if bgpASNames != "" && bgpRIB == "" {
+		return fmt.Errorf("--as-names requires --rib")
+	}
+	if bgpRefresh && bgpDB == "" {
+		return fmt.Errorf("--refresh requires --db")
+	}
+	if bgpWorkers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	if bgpTop < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+	if bgpFormat != "text" && bgpFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", bgpFormat)
+	}
+	return nil
+}
+
+// BGP command implementation
+func runBGP(cmd *cobra.Command, args []string) error {
+	if err := validateBGPFlags(); err != nil {
+		return err
+	}
+	status := !quiet && bgpFormat == "text"
+
+	var results []models.DNSResult
+	var err error
+	if bgpFile != "" {
+		if results, err = stats.LoadResults(bgpFile); err != nil {
+			return fmt.Errorf("failed to read results: %w", err)
+		}
+	} else if results, err = storedResults(bgpDB); err != nil {
+		return err
+	}
+	if bgpSubdomain != "" {
+		results = filterSubdomain(results, bgpSubdomain)
+	}
+
+	// Only public addresses are announced
+	seen := make(map[string]bool)
+	var ips []string
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			if addr, ok := ipclass.Parse(ip); ok && ipclass.Classify(addr) == ipclass.Public && !seen[ip] {
+				seen[ip] = true
+				ips = append(ips, ip)
+			}
+		}
+	}
+	sort.Strings(ips)
+
+	origins := make(map[string]models.IPOrigin)
+	if bgpDB != "" && !bgpRefresh {
+		db, err := openDB(bgpDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		stored, err := db.QueryIPOrigins()
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read origins: %w", err)
+		}
+		for _, ip := range ips {
+			if origin, ok := stored[ip]; ok {
+				origins[ip] = origin
+			}
+		}
+	}
+
+	var missing []string
+	for _, ip := range ips {
+		if _, ok := origins[ip]; !ok {
+			missing = append(missing, ip)
+		}
+	}
+
+	if len(missing) > 0 {
+		source, err := originSource()
+		if err != nil {
+			return err
+		}
+		if status {
+			fmt.Printf("Looking up origins of %d addresses (%d stored)...\n", len(missing), len(ips)-len(missing))
+		}
+		found, failed, err := bgp.LookupAll(context.Background(), source, missing, bgpWorkers)
+		if failed > 0 && !quiet {
+			slog.Warn("origin lookups failed", "count", failed, "error", err)
+		}
+		for _, origin := range found {
+			origins[origin.IP] = origin
+		}
+
+		if bgpDB != "" && len(found) > 0 {
+			db, err := openDB(bgpDB)
+			if err != nil {
+				return fmt.Errorf("database error: %w", err)
+			}
+			err = db.SaveIPOrigins(found)
+			db.Close()
+			if err != nil {
+				return fmt.Errorf("failed to store origins: %w", err)
+			}
+			if status {
+				fmt.Printf("Stored %d origins in %s\n", len(found), bgpDB)
+			}
+		}
+	}
+
+	summaries := stats.SummarizeOrigins(results, origins)
+	endpoints := stats.CloudEndpoints(results, origins)
+
+	if bgpFormat == "json" {
+		report := struct {
+			Origins        []models.OriginSummary `json:"origins"`
+			CloudEndpoints []models.CloudEndpoint `json:"cloud_endpoints"`
+		}{summaries, endpoints}
+		if err := output.ExportJSON(report, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	withIPs := 0
+	for _, result := range results {
+		if len(result.IPs) > 0 {
+			withIPs++
+		}
+	}
+	fmt.Print(stats.FormatOrigins(summaries, endpoints, withIPs, bgpTop))
+	return nil
+}
+
+// originSource returns the local routing table of --rib, or RIPEstat
+func originSource() (bgp.Source, error) {
+	if bgpRIB == "" {
+		return bgp.NewRIPEstat(), nil
+	}
+	rib, err := bgp.OpenRIB(bgpRIB)
+	if err != nil {
+		return nil, err
+	}
+	if bgpASNames != "" {
+		if err := rib.LoadNames(bgpASNames); err != nil {
+			return nil, err
+		}
+	}
+	if !quiet && bgpFormat == "text" {
+		fmt.Printf("Loaded %d routes from %s\n", rib.Len(), bgpRIB)
+	}
+	return rib, nil
+}
+
+// filterSubdomain keeps the results of FQDNs under a subdomain, e.g.
+// "epdg.epc" for epdg.epc.mncXXX.mccYYY...
+func filterSubdomain(results []models.DNSResult, subdomain string) []models.DNSResult {
+	prefix := strings.ToLower(subdomain) + ".mnc"
+	var selected []models.DNSResult
+	for _, result := range results {
+		if strings.HasPrefix(strings.ToLower(result.FQDN), prefix) {
+			selected = append(selected, result)
+		}
+	}
+	return selected
+}
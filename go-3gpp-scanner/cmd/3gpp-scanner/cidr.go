@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// CIDR command flags
+	cidrFile     string
+	cidrDB       string
+	cidrOperator string
+	cidrIPv4Bits int
+	cidrIPv6Bits int
+	cidrFormat   string
+)
+
+func cidrCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cidr",
+		Short: "Aggregate discovered addresses into prefixes per operator",
+		Long: `Group the addresses each operator's FQDNs resolved to into /24 (IPv4) and
+/48 (IPv6) prefixes, and merge neighbouring prefixes into larger ones where
+both halves are in use. The result is the smallest set of prefixes covering
+the operator's endpoints, with the number of addresses and FQDNs in each:
+a starting point for firewall or monitoring rules, e.g. for ePDG ranges.`,
+		Example: `  # Prefixes of every operator in a scan export
+  3gpp-scanner cidr --file=results.json
+
+  # Host routes of one operator from the database, as CSV
+  3gpp-scanner cidr --db=database.db --operator="Telekom" --ipv4-prefix=32 --ipv6-prefix=128 --format=csv`,
+		RunE: runCIDR,
+	}
+
+	cmd.Flags().StringVarP(&cidrFile, "file", "f", "", "JSON scan export to analyze")
+	cmd.Flags().StringVar(&cidrDB, "db", "", "Database to analyze (addresses stored by scan --db and import)")
+	cmd.Flags().StringVar(&cidrOperator, "operator", "", "Only this operator")
+	cmd.Flags().IntVar(&cidrIPv4Bits, "ipv4-prefix", 24, "Prefix length IPv4 addresses are grouped by before merging")
+	cmd.Flags().IntVar(&cidrIPv6Bits, "ipv6-prefix", 48, "Prefix length IPv6 addresses are grouped by before merging")
+	cmd.Flags().StringVar(&cidrFormat, "format", "text", "Output format: text, json or csv")
+
+	return cmd
+}
+
+// validateCIDRFlags validates cidr command flags
+func validateCIDRFlags() error {
+	if cidrFile == "" && cidrDB == "" {
+		return fmt.Errorf("either --file or --db required")
+	}
+	if cidrFile != "" && cidrDB != "" {
+		return fmt.Errorf("cannot specify both --file and --db")
+	}
+	if cidrIPv4Bits < 0 || cidrIPv4Bits > 32 {
+		return fmt.Errorf("--ipv4-prefix must be between 0 and 32")
+	}
+	if cidrIPv6Bits < 0 || cidrIPv6Bits > 128 {
+		return fmt.Errorf("--ipv6-prefix must be between 0 and 128")
+	}
+	if cidrFormat != "text" && cidrFormat != "json" && cidrFormat != "csv" {
+		return fmt.Errorf("invalid format: %s (must be text, json or csv)", cidrFormat)
+	}
+	return nil
+}
+
+// CIDR command implementation
+func runCIDR(cmd *cobra.Command, args []string) error {
+	if err := validateCIDRFlags(); err != nil {
+		return err
+	}
+
+	var results []models.DNSResult
+	var err error
+	if cidrFile != "" {
+		if results, err = stats.LoadResults(cidrFile); err != nil {
+			return fmt.Errorf("failed to read results: %w", err)
+		}
+	} else if results, err = storedResults(cidrDB); err != nil {
+		return err
+	}
+
+	if cidrOperator != "" {
+		var selected []models.DNSResult
+		for _, result := range results {
+			if result.Operator == cidrOperator {
+				selected = append(selected, result)
+			}
+		}
+		results = selected
+	}
+
+	prefixes := stats.AggregatePrefixes(results, cidrIPv4Bits, cidrIPv6Bits)
+
+	switch cidrFormat {
+	case "json":
+		if err := output.ExportJSON(prefixes, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+	case "csv":
+		if err := output.ExportPrefixesCSV(prefixes, "/dev/stdout"); err != nil {
+			return fmt.Errorf("CSV export failed: %w", err)
+		}
+	default:
+		fmt.Print(stats.FormatOperatorPrefixes(prefixes))
+	}
+	return nil
+}
+
+// storedResults reads the FQDNs of a database with their operators and
+// stored addresses
+func storedResults(path string) ([]models.DNSResult, error) {
+	db, err := openDB(path)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryRows(database.FQDNFilter{}, database.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	ips, err := db.FQDNIPs()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	results := make([]models.DNSResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.DNSResult{FQDN: row.FQDN, Operator: row.Operator, IPs: ips[row.FQDN]})
+	}
+	return results, nil
+}
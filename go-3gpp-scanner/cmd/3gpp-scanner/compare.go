@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/profile"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Compare command flags
+	compareOperators []string
+	compareDB        string
+	compareResults   string
+	comparePings     string
+	compareFormat    string
+)
+
+func compareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare exposed services of several operators side by side",
+		Long: `Compare operators side by side: FQDN counts per service type from the
+database, IP counts from an optional JSON scan export and reachability from an
+optional JSON ping export. Operator names must match the database exactly
+(see 'query --operator').`,
+		Example: `  # Services per operator from the database
+  3gpp-scanner compare --operators="Vodafone DE,O2 DE" --db=database.db
+
+  # Including IP counts and reachability
+  3gpp-scanner compare --operators="Vodafone DE,O2 DE" --results=results.json --pings=ping.json`,
+		RunE: runCompare,
+	}
+
+	cmd.Flags().StringSliceVar(&compareOperators, "operators", nil, "Comma-separated operator names to compare")
+	cmd.Flags().StringVar(&compareDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&compareResults, "results", "", "JSON scan export for IP counts")
+	cmd.Flags().StringVar(&comparePings, "pings", "", "JSON ping export for reachability")
+	cmd.Flags().StringVar(&compareFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateCompareFlags validates compare command flags
+func validateCompareFlags() error {
+	seen := make(map[string]bool)
+	for _, op := range compareOperators {
+		op = strings.TrimSpace(op)
+		if op == "" {
+			return fmt.Errorf("--operators contains an empty name")
+		}
+		if seen[op] {
+			return fmt.Errorf("operator listed twice: %s", op)
+		}
+		seen[op] = true
+	}
+	if len(seen) < 2 {
+		return fmt.Errorf("--operators needs at least two operator names")
+	}
+	if compareFormat != "text" && compareFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", compareFormat)
+	}
+	return nil
+}
+
+// Compare command implementation
+func runCompare(cmd *cobra.Command, args []string) error {
+	if err := validateCompareFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(compareDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	var results []models.DNSResult
+	if compareResults != "" {
+		if results, err = stats.LoadResults(compareResults); err != nil {
+			return fmt.Errorf("failed to read results: %w", err)
+		}
+	}
+	var pings []models.PingResult
+	if comparePings != "" {
+		if pings, err = stats.LoadPingResults(comparePings); err != nil {
+			return fmt.Errorf("failed to read ping results: %w", err)
+		}
+	}
+
+	var rows []models.OperatorComparison
+	for _, op := range compareOperators {
+		op = strings.TrimSpace(op)
+		fqdns, err := db.QueryByOperator(op)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		row := profile.Compare(op, fqdns, results, pings)
+		if row.TotalFQDNs == 0 {
+			slog.Warn("no FQDNs found for operator", "operator", op)
+		}
+		rows = append(rows, row)
+	}
+
+	if compareFormat == "json" {
+		if err := output.ExportJSON(rows, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(profile.FormatComparison(rows, compareResults != "", comparePings != ""))
+	return nil
+}
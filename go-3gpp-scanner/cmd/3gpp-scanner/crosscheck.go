@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Crosscheck command flags
+	crossResolvers []string
+	crossFile      string
+	crossDB        string
+	crossLimit     int
+	crossPrefix    int
+	crossWorkers   int
+	crossDelay     int
+	crossSourceIP  string
+	crossInterface string
+	crossAll       bool
+	crossFormat    string
+	crossOutput    string
+)
+
+func crosscheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crosscheck",
+		Short: "Compare answers of independent resolvers to detect DNS tampering",
+		Long: `Resolve each hit through several independent resolvers and compare their
+A records, to spot DNS hijacking, poisoned caches, filtering resolvers and
+stale secondaries serving outdated zones.
+
+Answers are judged per FQDN:
+  consistent    every resolver returned the same addresses or denied the name
+  geodns        the addresses differ, but every pair of answers shares a
+                network (--prefix, default /24): expected GeoDNS variance
+  suspicious    some resolvers return addresses while others deny the name,
+                only some return private or bogon addresses, or two answers
+                share no network
+  inconclusive  fewer than two resolvers answered (timeouts, SERVFAIL)
+
+The report lists suspicious results with every resolver's answer; --all
+includes the others.`,
+		Example: `  # Cross-check all hits of the database against the default resolvers
+  3gpp-scanner crosscheck --db=database.db
+
+  # Include the local resolver and report as JSON
+  3gpp-scanner crosscheck --db=database.db --resolvers=10.0.0.53,8.8.8.8,1.1.1.1,9.9.9.9 --output=suspicious.json
+
+  # FQDNs from a file, tolerating GeoDNS answers within the same /16
+  3gpp-scanner crosscheck --file=fqdns.txt --prefix=16`,
+		Args: cobra.NoArgs,
+		RunE: runCrosscheck,
+	}
+
+	cmd.Flags().StringSliceVar(&crossResolvers, "resolvers", nil, "Resolvers to compare, as IP or IP:port (comma-separated; default: Google, Cloudflare, OpenDNS, Quad9)")
+	cmd.Flags().StringVarP(&crossFile, "file", "f", "", "File containing FQDNs to check (one per line)")
+	cmd.Flags().StringVar(&crossDB, "db", "", "Database whose FQDNs are checked")
+	cmd.Flags().IntVar(&crossLimit, "limit", 0, "Check at most this many FQDNs (0 for all)")
+	cmd.Flags().IntVar(&crossPrefix, "prefix", 24, "IPv4 prefix length within which differing addresses count as GeoDNS variance")
+	cmd.Flags().IntVarP(&crossWorkers, "workers", "w", 5, "Number of FQDNs checked concurrently")
+	cmd.Flags().IntVar(&crossDelay, "delay", 50, "Delay between queries in milliseconds")
+	cmd.Flags().StringVar(&crossSourceIP, "source-ip", "", "Local IP address to send DNS queries from")
+	cmd.Flags().StringVar(&crossInterface, "interface", "", "Network interface to send DNS queries from")
+	cmd.Flags().BoolVar(&crossAll, "all", false, "Report every FQDN, not only suspicious ones")
+	cmd.Flags().StringVar(&crossFormat, "format", "text", "Output format: text or json")
+	cmd.Flags().StringVarP(&crossOutput, "output", "o", "", "Write the report to a JSON file")
+
+	return cmd
+}
+
+// validateCrosscheckFlags validates crosscheck command flags
+func validateCrosscheckFlags() error {
+	if crossFile == "" && crossDB == "" {
+		return fmt.Errorf("either --file or --db required")
+	}
+	if crossFile != "" && crossDB != "" {
+		return fmt.Errorf("cannot specify both --file and --db")
+	}
+	servers, err := dns.ParseResolvers(crossResolvers)
+	if err != nil {
+		return err
+	}
+	if len(crossResolvers) > 0 && len(servers) < 2 {
+		return fmt.Errorf("--resolvers needs at least two resolvers to compare")
+	}
+	if crossLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	if crossPrefix < 8 || crossPrefix > 32 {
+		return fmt.Errorf("--prefix must be between 8 and 32")
+	}
+	if crossWorkers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if crossDelay < 0 {
+		return fmt.Errorf("--delay must not be negative")
+	}
+	if err := (netbind.Binding{SourceIP: crossSourceIP, Interface: crossInterface}).Validate(); err != nil {
+		return err
+	}
+	if crossFormat != "text" && crossFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", crossFormat)
+	}
+	return nil
+}
+
+// crosscheckReport is the JSON output of crosscheck
+type crosscheckReport struct {
+	Resolvers []string               `json:"resolvers"`
+	Prefix    int                    `json:"prefix"`
+	Checked   int                    `json:"checked"`
+	Verdicts  map[string]int         `json:"verdicts"`
+	Results   []dns.CrossCheckResult `json:"results"`
+}
+
+// newCrosscheckReport counts the verdicts of results and keeps the
+// suspicious ones, or all with all
+func newCrosscheckReport(servers []string, prefix int, results []dns.CrossCheckResult, all bool) crosscheckReport {
+	report := crosscheckReport{Resolvers: servers, Prefix: prefix, Checked: len(results), Verdicts: make(map[string]int)}
+	report.Results = []dns.CrossCheckResult{}
+	for _, r := range results {
+		report.Verdicts[r.Verdict]++
+		if all || r.Verdict == dns.VerdictSuspicious {
+			report.Results = append(report.Results, r)
+		}
+	}
+	return report
+}
+
+// formatCrosscheck renders a report as text: one block per result with
+// the answer of each resolver, then the verdict counts
+func formatCrosscheck(report crosscheckReport) string {
+	var b strings.Builder
+	for _, r := range report.Results {
+		fmt.Fprintf(&b, "%-12s %s", strings.ToUpper(r.Verdict), r.FQDN)
+		if r.Reason != "" {
+			fmt.Fprintf(&b, ": %s", r.Reason)
+		}
+		b.WriteString("\n")
+		for _, a := range r.Answers {
+			answer := string(a.Status)
+			if len(a.IPs) > 0 {
+				answer = strings.Join(a.IPs, ", ")
+			}
+			fmt.Fprintf(&b, "  %-22s %s\n", a.Server, answer)
+		}
+	}
+	if len(report.Results) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Checked %d FQDNs against %d resolvers:", report.Checked, len(report.Resolvers))
+	for _, verdict := range []string{dns.VerdictConsistent, dns.VerdictGeoDNS, dns.VerdictSuspicious, dns.VerdictInconclusive} {
+		fmt.Fprintf(&b, " %d %s,", report.Verdicts[verdict], verdict)
+	}
+	return strings.TrimSuffix(b.String(), ",") + "\n"
+}
+
+// Crosscheck command implementation
+func runCrosscheck(cmd *cobra.Command, args []string) error {
+	if err := validateCrosscheckFlags(); err != nil {
+		return err
+	}
+
+	servers, _ := dns.ParseResolvers(crossResolvers)
+	if len(servers) == 0 {
+		servers = dns.DefaultCrossCheckServers
+	}
+
+	var fqdns []string
+	if crossFile != "" {
+		var err error
+		if fqdns, err = readFQDNsFromFile(crossFile); err != nil {
+			return fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+	} else {
+		db, err := openDB(crossDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		fqdns, err = db.GetAllFQDNs()
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+	}
+	if crossLimit > 0 && len(fqdns) > crossLimit {
+		fqdns = fqdns[:crossLimit]
+	}
+	if len(fqdns) == 0 {
+		return fmt.Errorf("no FQDNs to check")
+	}
+
+	text := crossFormat == "text"
+	if !quiet && text {
+		fmt.Printf("Cross-checking %d FQDNs against %d resolvers\n", len(fqdns), len(servers))
+	}
+
+	scanner := dns.NewScanner(&models.ScanConfig{
+		QueryDelay: time.Duration(crossDelay) * time.Millisecond,
+		SourceIP:   crossSourceIP,
+		Interface:  crossInterface,
+	})
+	if showProgress() && text {
+		scanner.SetProgressCallback(func(current, total, found int) {
+			if current%100 == 0 || current == total {
+				fmt.Printf("  %d/%d FQDNs checked\n", current, total)
+			}
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := scanner.CrossCheck(ctx, servers, fqdns, crossWorkers, crossPrefix)
+	report := newCrosscheckReport(servers, crossPrefix, results, crossAll)
+
+	if crossOutput != "" {
+		if err := output.ExportJSON(report, crossOutput); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		if !quiet && text {
+			fmt.Printf("Report written to %s\n", crossOutput)
+		}
+	}
+	if crossFormat == "json" {
+		if err := output.ExportJSON(report, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	if !quiet {
+		fmt.Println()
+	}
+	fmt.Print(formatCrosscheck(report))
+	return nil
+}
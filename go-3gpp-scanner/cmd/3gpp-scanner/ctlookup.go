@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"3gpp-scanner/internal/ctlog"
+	"3gpp-scanner/internal/dns"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// CT lookup command flags
+	ctOutput string
+	ctDomain string
+	ctMCC    int
+	ctSource string
+	ctAPIKey string
+	ctDB     string
+)
+
+func ctlookupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ctlookup",
+		Short: "Find FQDNs in certificate transparency logs",
+		Long: `Search certificate transparency logs for certificates naming hosts below
+pub.3gppnetwork.org and merge the FQDNs in operator zones into a candidate
+list. Certificates reveal names no wordlist contains, such as numbered or
+site-specific ePDGs; scan the list with scan --targets to resolve them.
+
+Names already in the list, or in the database given with --db, are not
+added again; the list keeps its existing lines and comments. crt.sh is
+searched by default; Cert Spotter (--source=certspotter) is an alternative
+whose limits an API key raises.`,
+		Example: `  # Collect candidates from crt.sh and scan them
+  3gpp-scanner ctlookup --output=candidates.txt
+  3gpp-scanner scan --targets=candidates.txt --db=database.db
+
+  # Only German operators, skipping FQDNs already in the database
+  3gpp-scanner ctlookup --mcc=262 --db=database.db --output=candidates.txt`,
+		RunE: runCTLookup,
+	}
+
+	cmd.Flags().StringVarP(&ctOutput, "output", "o", "", "Candidate FQDN list to merge new names into (created if missing)")
+	cmd.Flags().StringVar(&ctDomain, "domain", "pub.3gppnetwork.org", "Parent domain to search")
+	cmd.Flags().IntVar(&ctMCC, "mcc", 0, "Only search the zones of this MCC (0 for all)")
+	cmd.Flags().StringVar(&ctSource, "source", "crtsh", "CT search service: crtsh or certspotter")
+	cmd.Flags().StringVar(&ctAPIKey, "api-key", "", "Cert Spotter API key")
+	cmd.Flags().StringVar(&ctDB, "db", "", "Database whose FQDNs are not added again")
+
+	return cmd
+}
+
+// validateCTLookupFlags validates ctlookup command flags
+func validateCTLookupFlags() error {
+	if ctOutput == "" {
+		return fmt.Errorf("--output required")
+	}
+	if strings.Trim(ctDomain, ".") == "" {
+		return fmt.Errorf("--domain cannot be empty")
+	}
+	if ctMCC < 0 || ctMCC > 999 {
+		return fmt.Errorf("--mcc must be between 0 and 999")
+	}
+	if ctSource != "crtsh" && ctSource != "certspotter" {
+		return fmt.Errorf("invalid source: %s (must be crtsh or certspotter)", ctSource)
+	}
+	if ctAPIKey != "" && ctSource != "certspotter" {
+		return fmt.Errorf("--api-key requires --source=certspotter")
+	}
+	return nil
+}
+
+// CT lookup command implementation
+func runCTLookup(cmd *cobra.Command, args []string) error {
+	if err := validateCTLookupFlags(); err != nil {
+		return err
+	}
+
+	parent := strings.ToLower(strings.Trim(ctDomain, "."))
+	search := parent
+	if ctMCC > 0 {
+		search = fmt.Sprintf("mcc%03d.%s", ctMCC, parent)
+	}
+
+	var source ctlog.Source = ctlog.NewCrtSh()
+	if ctSource == "certspotter" {
+		source = ctlog.NewCertSpotter(ctAPIKey)
+	}
+	if !quiet {
+		fmt.Printf("Searching %s for certificates below %s...\n", ctSource, search)
+	}
+	certs, err := source.Search(context.Background(), search)
+	if err != nil {
+		return fmt.Errorf("CT search failed: %w", err)
+	}
+
+	// The scanner only takes FQDNs below an operator zone
+	var candidates []string
+	outside := 0
+	for _, name := range ctlog.Names(certs, search) {
+		zone, _, _, ok := dns.ZoneFromFQDN(name, parent)
+		if !ok || !strings.HasSuffix(name, "."+zone) {
+			outside++
+			continue
+		}
+		candidates = append(candidates, name)
+	}
+	if !quiet {
+		fmt.Printf("Found %d certificates naming %d FQDNs in operator zones (%d other names)\n", len(certs), len(candidates), outside)
+	}
+
+	if ctDB != "" {
+		db, err := openDB(ctDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		stored, err := db.GetAllFQDNs()
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+		known := make(map[string]bool, len(stored))
+		for _, fqdn := range stored {
+			known[strings.ToLower(fqdn)] = true
+		}
+		var unknown []string
+		for _, name := range candidates {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if !quiet {
+			fmt.Printf("%d FQDNs already in %s\n", len(candidates)-len(unknown), ctDB)
+		}
+		candidates = unknown
+	}
+
+	added, err := ctlog.MergeFile(ctOutput, candidates)
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Added %d new FQDNs to %s\n", len(added), ctOutput)
+	}
+	for _, name := range added {
+		fmt.Printf("  + %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Db command flags
+	dbPath         string
+	noteFQDN       string
+	noteOperator   string
+	noteListFormat string
+	runListFormat  string
+)
+
+func dbCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Edit analyst data and list runs stored in the database",
+	}
+	cmd.PersistentFlags().StringVar(&dbPath, "db", "database.db", "Database file path")
+
+	note := &cobra.Command{
+		Use:   "note",
+		Short: "Attach free-text notes to FQDNs and operators",
+		Long: `Keep research notes next to the scan data. A note belongs to one FQDN or
+operator; profile lists the notes of the operator and its FQDNs, and query
+lists the notes of the FQDNs and operator it returns. Notes are numbered so
+they can be edited or removed.`,
+		Example: `  # Note an FQDN and an operator
+  3gpp-scanner db note add --fqdn=epdg.epc.mnc001.mcc310.pub.3gppnetwork.org "answers IKE_SA_INIT, vendor unknown"
+  3gpp-scanner db note add --operator="Example Mobile" "report findings via PSIRT"
+
+  # List, correct and drop notes
+  3gpp-scanner db note list
+  3gpp-scanner db note edit 1 "answers IKE_SA_INIT, Ericsson"
+  3gpp-scanner db note remove 2`,
+	}
+
+	add := &cobra.Command{
+		Use:   "add TEXT",
+		Short: "Add a note to an FQDN or operator",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runNoteAdd,
+	}
+	add.Flags().StringVar(&noteFQDN, "fqdn", "", "FQDN the note is about")
+	add.Flags().StringVar(&noteOperator, "operator", "", "Operator the note is about")
+	note.AddCommand(add)
+
+	note.AddCommand(&cobra.Command{
+		Use:   "edit ID TEXT",
+		Short: "Replace the text of a note",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runNoteEdit,
+	})
+
+	note.AddCommand(&cobra.Command{
+		Use:   "remove ID",
+		Short: "Remove a note",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runNoteRemove,
+	})
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List notes, oldest first",
+		Args:  cobra.NoArgs,
+		RunE:  runNoteList,
+	}
+	list.Flags().StringVar(&noteFQDN, "fqdn", "", "Only notes on this FQDN")
+	list.Flags().StringVar(&noteOperator, "operator", "", "Only notes on this operator")
+	list.Flags().StringVar(&noteListFormat, "format", "text", "Output format: text or json")
+	note.AddCommand(list)
+
+	cmd.AddCommand(note)
+
+	runs := &cobra.Command{
+		Use:   "runs",
+		Short: "List the scans and imports that stored results, oldest first",
+		Long: `List the runs that stored results in the database: scans with --db
+(kind "scan", with the scan mode as source) and imports (kind "imported",
+with the imported file as source), with the number of results each stored.`,
+		Args: cobra.NoArgs,
+		RunE: runRunList,
+	}
+	runs.Flags().StringVar(&runListFormat, "format", "text", "Output format: text or json")
+	cmd.AddCommand(runs)
+
+	return cmd
+}
+
+// noteTarget returns the kind and target selected by --fqdn or --operator;
+// required says whether one of them must be given
+func noteTarget(required bool) (kind, target string, err error) {
+	switch {
+	case noteFQDN != "" && noteOperator != "":
+		return "", "", fmt.Errorf("cannot specify both --fqdn and --operator")
+	case noteFQDN != "":
+		return database.TagKindFQDN, strings.ToLower(strings.TrimSuffix(noteFQDN, ".")), nil
+	case noteOperator != "":
+		return database.TagKindOperator, noteOperator, nil
+	case required:
+		return "", "", fmt.Errorf("either --fqdn or --operator required")
+	}
+	return "", "", nil
+}
+
+// parseNoteID parses a note number argument
+func parseNoteID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid note ID: %s", arg)
+	}
+	return id, nil
+}
+
+func runNoteAdd(cmd *cobra.Command, args []string) error {
+	kind, target, err := noteTarget(true)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	note, err := db.AddNote(kind, target, args[0])
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Added note #%d to %s %s\n", note.ID, kind, target)
+	}
+	return nil
+}
+
+func runNoteEdit(cmd *cobra.Command, args []string) error {
+	id, err := parseNoteID(args[0])
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.EditNote(id, args[1]); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Updated note #%d\n", id)
+	}
+	return nil
+}
+
+func runNoteRemove(cmd *cobra.Command, args []string) error {
+	id, err := parseNoteID(args[0])
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.DeleteNote(id); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Removed note #%d\n", id)
+	}
+	return nil
+}
+
+func runNoteList(cmd *cobra.Command, args []string) error {
+	kind, target, err := noteTarget(false)
+	if err != nil {
+		return err
+	}
+	if noteListFormat != "text" && noteListFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", noteListFormat)
+	}
+
+	db, err := openDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	notes, err := db.QueryNotes(kind, target)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if noteListFormat == "json" {
+		if notes == nil {
+			notes = []models.Note{}
+		}
+		return output.ExportJSON(notes, "/dev/stdout")
+	}
+	for _, note := range notes {
+		fmt.Println(formatNote(note))
+	}
+	if !quiet && len(notes) == 0 {
+		fmt.Println("No notes")
+	}
+	return nil
+}
+
+func runRunList(cmd *cobra.Command, args []string) error {
+	if runListFormat != "text" && runListFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", runListFormat)
+	}
+
+	db, err := openDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	runs, err := db.QueryRuns()
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if runListFormat == "json" {
+		if runs == nil {
+			runs = []models.Run{}
+		}
+		return output.ExportJSON(runs, "/dev/stdout")
+	}
+	for _, run := range runs {
+		fmt.Printf("#%-4d %s  %-8s %6d results  %s\n",
+			run.ID, run.Started.Format("2006-01-02 15:04"), run.Kind, run.Results, run.Source)
+	}
+	if !quiet && len(runs) == 0 {
+		fmt.Println("No runs")
+	}
+	return nil
+}
+
+// formatNote renders a note on one line: number, creation time, target
+// and text
+func formatNote(note models.Note) string {
+	return fmt.Sprintf("#%-4d %s  %s %s: %s",
+		note.ID, note.Created.Format("2006-01-02 15:04"), note.Kind, note.Target, note.Text)
+}
+
+// relevantNotes keeps the notes on the given FQDNs and operators
+func relevantNotes(notes []models.Note, fqdns, operators []string) []models.Note {
+	targets := make(map[string]bool, len(fqdns)+len(operators))
+	for _, fqdn := range fqdns {
+		targets[database.TagKindFQDN+"\x00"+fqdn] = true
+	}
+	for _, operator := range operators {
+		targets[database.TagKindOperator+"\x00"+operator] = true
+	}
+
+	var kept []models.Note
+	for _, note := range notes {
+		if targets[note.Kind+"\x00"+note.Target] {
+			kept = append(kept, note)
+		}
+	}
+	return kept
+}
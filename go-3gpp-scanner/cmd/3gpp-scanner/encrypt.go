@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"3gpp-scanner/internal/crypt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// passphraseEnv names the environment variable read for a passphrase when
+// --passphrase-file is not set
+const passphraseEnv = "SCANNER_PASSPHRASE"
+
+var (
+	// Export encryption flags (scan and ping)
+	encryptOutput bool
+
+	// Passphrase flag (scan, ping, encrypt and decrypt)
+	passphraseFile string
+
+	// Encrypt command flags
+	encryptKeep bool
+
+	// Decrypt command flags
+	decryptOutput string
+)
+
+func encryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encrypt FILE...",
+		Short: "Encrypt databases and exported results with a passphrase",
+		Long: `Encrypt files at rest with AES-256-GCM under a key derived from a
+passphrase. Each FILE is replaced by FILE.enc; decrypt restores it. Use it for
+databases and exports carried on laptops; the scanner cannot read a database
+while it is encrypted.
+
+The passphrase is read from --passphrase-file, the SCANNER_PASSPHRASE
+environment variable, or the terminal, in that order.`,
+		Example: `  # Encrypt the database before travelling
+  3gpp-scanner encrypt database.db
+
+  # Encrypt exports with a passphrase kept in a file
+  3gpp-scanner encrypt results.json results.csv --passphrase-file=~/.scanner-pass`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runEncrypt,
+	}
+
+	addPassphraseFlag(cmd)
+	cmd.Flags().BoolVar(&encryptKeep, "keep", false, "Keep the plaintext files next to the encrypted copies")
+
+	return cmd
+}
+
+func decryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decrypt FILE.enc...",
+		Short: "Decrypt files written by encrypt or --encrypt",
+		Long: `Decrypt files encrypted with encrypt or the --encrypt flag of scan and
+ping. FILE.enc is written to FILE, which must not exist yet; the encrypted
+file is kept.`,
+		Example: `  # Restore the database
+  3gpp-scanner decrypt database.db.enc
+
+  # Decrypt an export under another name
+  3gpp-scanner decrypt results.json.enc --output=shared.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runDecrypt,
+	}
+
+	addPassphraseFlag(cmd)
+	cmd.Flags().StringVarP(&decryptOutput, "output", "o", "", "Output file (one input only; default: FILE.enc without .enc)")
+
+	return cmd
+}
+
+// addEncryptFlags adds --encrypt and --passphrase-file to a command
+// exporting results
+func addEncryptFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&encryptOutput, "encrypt", false, "Encrypt the --output files with a passphrase, writing FILE.enc instead of FILE")
+	addPassphraseFlag(cmd)
+}
+
+func addPassphraseFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the encryption passphrase on its first line (default: $"+passphraseEnv+" or a prompt)")
+}
+
+// validateEncryptFlags checks that --encrypt has files to encrypt
+func validateEncryptFlags(paths []string) error {
+	if encryptOutput && len(paths) == 0 {
+		return fmt.Errorf("--encrypt requires --output")
+	}
+	if passphraseFile != "" && !encryptOutput {
+		return fmt.Errorf("--passphrase-file requires --encrypt")
+	}
+	return nil
+}
+
+// validateDecryptFlags validates decrypt command arguments
+func validateDecryptFlags(args []string) error {
+	if decryptOutput != "" && len(args) > 1 {
+		return fmt.Errorf("--output requires a single file")
+	}
+	for _, path := range args {
+		if decryptOutput == "" && (!strings.HasSuffix(path, crypt.Suffix) || path == crypt.Suffix) {
+			return fmt.Errorf("%s does not end in %s (set --output)", path, crypt.Suffix)
+		}
+	}
+	return nil
+}
+
+// readPassphrase returns the passphrase of --passphrase-file, the
+// environment or the terminal. New passphrases are asked for twice.
+func readPassphrase(confirmNew bool) ([]byte, error) {
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			return nil, fmt.Errorf("passphrase file %s is empty", passphraseFile)
+		}
+		return line, nil
+	}
+	if value := os.Getenv(passphraseEnv); value != "" {
+		return []byte(value), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no passphrase (stdin is not a terminal; use --passphrase-file or set %s)", passphraseEnv)
+	}
+	passphrase, err := promptPassphrase(fd, "Passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+	if confirmNew {
+		again, err := promptPassphrase(fd, "Repeat passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+func promptPassphrase(fd int, prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
+
+// exportPassphrase asks for the --encrypt passphrase before a run starts,
+// so a long scan does not wait at a prompt at the end; nil without --encrypt
+func exportPassphrase() ([]byte, error) {
+	if !encryptOutput {
+		return nil, nil
+	}
+	return readPassphrase(true)
+}
+
+// encryptExports replaces each exported file by its encrypted copy and
+// returns the paths of the copies; without a passphrase it returns paths
+func encryptExports(passphrase []byte, paths []string) ([]string, error) {
+	if passphrase == nil {
+		return paths, nil
+	}
+	encrypted := make([]string, len(paths))
+	for i, path := range paths {
+		encrypted[i] = path + crypt.Suffix
+		if err := encryptFile(path, encrypted[i], passphrase, false); err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
+// encryptFile writes the encrypted copy of a file and, unless keep is
+// set, removes the plaintext
+func encryptFile(path, dst string, passphrase []byte, keep bool) error {
+	if err := crypt.EncryptFile(path, dst, passphrase); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	if !keep {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("encrypted %s, but failed to remove the plaintext: %w", path, err)
+		}
+	}
+	if !quiet {
+		fmt.Printf("Encrypted: %s\n", dst)
+	}
+	return nil
+}
+
+// checkEncryptable refuses files that are already encrypted, and databases
+// still in use, whose recent writes sit in a separate write-ahead log
+func checkEncryptable(path string) error {
+	encrypted, err := crypt.IsEncrypted(path)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+	if _, err := os.Stat(path + "-wal"); err == nil {
+		return fmt.Errorf("%s has a write-ahead log (%s-wal); close the programs using the database first", path, path)
+	}
+	if _, err := os.Stat(path + crypt.Suffix); err == nil {
+		return fmt.Errorf("%s already exists", path+crypt.Suffix)
+	}
+	return nil
+}
+
+func runEncrypt(cmd *cobra.Command, args []string) error {
+	for _, path := range args {
+		if err := checkEncryptable(path); err != nil {
+			return err
+		}
+	}
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		return err
+	}
+	for _, path := range args {
+		if err := encryptFile(path, path+crypt.Suffix, passphrase, encryptKeep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	if err := validateDecryptFlags(args); err != nil {
+		return err
+	}
+	dsts := make([]string, len(args))
+	for i, path := range args {
+		dsts[i] = decryptOutput
+		if dsts[i] == "" {
+			dsts[i] = strings.TrimSuffix(path, crypt.Suffix)
+		}
+		if _, err := os.Stat(dsts[i]); err == nil {
+			return fmt.Errorf("%s already exists", dsts[i])
+		}
+	}
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return err
+	}
+	for i, path := range args {
+		if err := crypt.DecryptFile(path, dsts[i], passphrase); err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		if !quiet {
+			fmt.Printf("Decrypted: %s\n", dsts[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Export command flags
+	exportDB        string
+	exportFor       string
+	exportMCC       string
+	exportMNC       string
+	exportOperator  string
+	exportTag       string
+	exportSubdomain string
+	exportSince     string
+	exportPorts     []int
+	exportOutput    string
+)
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export discovered endpoints as targets for ZGrab2 or Nuclei",
+		Long: `Write the resolved endpoints of the database as a target list for a
+follow-up scanner, so they can be fed into deeper protocol scans without
+reshaping:
+
+  zgrab2  CSV lines "ip,domain,tag,port", one per address and service; the
+          tag names the service for --trigger in multiple-module runs
+  nuclei  one target per line for -list: https://host for HTTPS, host:port
+          for other services
+
+Each FQDN gets the TCP services of its kind: HTTPS (443) and IKE over TCP
+(4500) on ePDGs, SIP (5060) and SIP over TLS (5061) on IMS hosts, HTTPS on
+everything else; --ports replaces them. Names and addresses excluded by
+--exclude-file or outside --scope are left out.`,
+		Example: `  # Feed every endpoint to ZGrab2
+  3gpp-scanner export --for=zgrab2 | zgrab2 multiple -c services.ini
+
+  # Nuclei targets of one operator, written to a file
+  3gpp-scanner export --for=nuclei --mcc=262 --mnc=01 --output=targets.txt
+  nuclei -list targets.txt
+
+  # Only endpoints new since the last export, on custom ports
+  3gpp-scanner export --for=zgrab2 --since=run:12 --ports=443,8443 --output=new.csv`,
+		Args: cobra.NoArgs,
+		RunE: runExport,
+	}
+
+	cmd.Flags().StringVar(&exportDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&exportFor, "for", "", "Follow-up scanner: "+strings.Join(output.FollowUpFormats, " or "))
+	cmd.Flags().StringVar(&exportMCC, "mcc", "", "Only endpoints of this Mobile Country Code")
+	cmd.Flags().StringVar(&exportMNC, "mnc", "", "Only endpoints of this Mobile Network Code (with --mcc)")
+	cmd.Flags().StringVar(&exportOperator, "operator", "", "Only endpoints of this operator")
+	cmd.Flags().StringVar(&exportTag, "tag", "", "Only endpoints with this tag")
+	cmd.Flags().StringVar(&exportSubdomain, "subdomain", "", "Only endpoints below this subdomain, e.g. epdg or xcap.ims")
+	cmd.Flags().StringVar(&exportSince, "since", "", "Only endpoints new since run:N, a date (YYYY-MM-DD) or an RFC 3339 time")
+	cmd.Flags().IntSliceVar(&exportPorts, "ports", nil, "TCP ports of every endpoint, replacing the services of its kind (comma-separated)")
+	cmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: stdout)")
+	addExcludeFlag(cmd)
+	addScopeFlag(cmd)
+
+	return cmd
+}
+
+// validateExportFlags validates export command flags
+func validateExportFlags() error {
+	if exportFor == "" {
+		return fmt.Errorf("--for required (%s)", strings.Join(output.FollowUpFormats, " or "))
+	}
+	if !slices.Contains(output.FollowUpFormats, exportFor) {
+		return fmt.Errorf("invalid --for: %s (must be %s)", exportFor, strings.Join(output.FollowUpFormats, " or "))
+	}
+	if exportMNC != "" && exportMCC == "" {
+		return fmt.Errorf("--mnc requires --mcc")
+	}
+	if err := validateCodeFlags(exportMCC, exportMNC); err != nil {
+		return err
+	}
+	if exportSince != "" {
+		if _, err := database.ParseSince(exportSince); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	}
+	for _, port := range exportPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port in --ports: %d", port)
+		}
+	}
+	return nil
+}
+
+// Export command implementation
+func runExport(cmd *cobra.Command, args []string) error {
+	if err := validateExportFlags(); err != nil {
+		return err
+	}
+	// Targets on stdout are piped into the scanner: keep it to them
+	path := exportOutput
+	if path == "" {
+		path = "/dev/stdout"
+		quiet = true
+	}
+
+	list, err := loadExcludeList()
+	if err != nil {
+		return err
+	}
+	engagement, err := loadScope(func() []models.MCCMNCEntry { return loadMCCMNCList("") })
+	if err != nil {
+		return err
+	}
+	checker := targetChecker(list, engagement)
+
+	db, err := openDB(exportDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	since, _ := database.ParseSince(exportSince)
+	rows, err := db.QueryRows(database.FQDNFilter{
+		MCC:       models.NormalizeMCC(exportMCC),
+		MNC:       models.NormalizeMNC(exportMNC),
+		Operator:  exportOperator,
+		Subdomain: exportSubdomain,
+		Tag:       exportTag,
+		Since:     since,
+	}, database.QueryOptions{})
+	if err != nil {
+		return err
+	}
+	var fqdns []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !seen[row.FQDN] {
+			seen[row.FQDN] = true
+			fqdns = append(fqdns, row.FQDN)
+		}
+	}
+	details, err := db.QueryDetails(fqdns)
+	if err != nil {
+		return err
+	}
+
+	skipped := 0
+	if checker != nil {
+		kept := details[:0]
+		for _, d := range details {
+			if checker.CheckName(d.FQDN) != nil {
+				skipped++
+				continue
+			}
+			var ips []string
+			for _, ip := range d.IPs {
+				if parsed := net.ParseIP(ip); parsed != nil && checker.CheckIP(parsed) == nil {
+					ips = append(ips, ip)
+				}
+			}
+			if d.IPs = ips; len(ips) > 0 {
+				kept = append(kept, d)
+			} else {
+				skipped++
+			}
+		}
+		details = kept
+	}
+
+	targets := output.FollowUpTargets(details, exportPorts)
+	if err := output.ExportFollowUp(targets, exportFor, path); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Exported %d %s targets of %d FQDNs to: %s\n", len(targets), exportFor, len(details), exportOutput)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d excluded or out-of-scope FQDNs\n", skipped)
+		}
+	}
+	return nil
+}
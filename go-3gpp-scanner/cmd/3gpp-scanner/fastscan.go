@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/fetcher"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/ping"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Fastscan command flags
+	fastCountries   []string
+	fastMode        string
+	fastQPS         int
+	fastConcurrency int
+	fastQueryBudget time.Duration
+	fastResolvers   []string
+	fastPorts       []int
+	fastTimeout     int
+	fastWorkers     int
+	fastMCCMNCFile  string
+	fastOutput      string
+	fastDB          string
+)
+
+func fastscanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fastscan",
+		Short: "Quick ePDG inventory with inline TCP reachability, for triage",
+		Long: `A scan preset tuned for speed: the SOA of every operator zone is checked
+first, subdomains are only queried in zones that exist, queries go out at a
+high rate without a retry pass, and every hit is checked for TCP
+reachability as soon as it is found. Hits are printed as they arrive, and a
+per-country summary follows, so a country's ePDG inventory is ready in
+minutes. Follow up with a full scan for timeouts and other modes.
+
+--output writes DNS and reachability results in the common result envelope.`,
+		Example: `  # ePDG inventory of two countries
+  3gpp-scanner fastscan --country=DE,AT
+
+  # IMS hosts of one country, saved for a later deep scan
+  3gpp-scanner fastscan --country=France --mode=ims --db=database.db --output=fr.json`,
+		RunE: runFastscan,
+	}
+
+	cmd.Flags().StringSliceVar(&fastCountries, "country", nil, "Countries to scan, by ISO code or name (comma-separated; default: all)")
+	cmd.Flags().StringVarP(&fastMode, "mode", "m", "epdg", "Scan mode: all, epdg, ims, bsf, gan, xcap, ntp")
+	cmd.Flags().IntVar(&fastQPS, "qps", 200, "DNS queries per second")
+	cmd.Flags().IntVarP(&fastConcurrency, "concurrency", "c", 50, "Number of concurrent DNS queries")
+	cmd.Flags().DurationVar(&fastQueryBudget, "query-budget", 2*time.Second, "Wall-clock limit for one query across all resolvers")
+	cmd.Flags().StringSliceVar(&fastResolvers, "resolvers", nil, "Recursive resolvers tried in order, as IP or IP:port (comma-separated; default: Google, Cloudflare, OpenDNS)")
+	cmd.Flags().IntSliceVar(&fastPorts, "ports", []int{443, 4500}, "TCP ports tried for reachability (comma-separated)")
+	cmd.Flags().IntVar(&fastTimeout, "timeout", 1000, "TCP connect timeout in milliseconds")
+	cmd.Flags().IntVarP(&fastWorkers, "workers", "w", 20, "Number of concurrent reachability checks")
+	cmd.Flags().StringVar(&fastMCCMNCFile, "mccmnc-file", "", "Use local MCC-MNC JSON file instead of fetching")
+	cmd.Flags().StringVarP(&fastOutput, "output", "o", "", "Output file (json or csv) in the common result envelope")
+	cmd.Flags().StringVar(&fastDB, "db", "", "Database to save the hits (as a scan run) and reachability results to")
+	addExcludeFlag(cmd)
+	addScopeFlag(cmd)
+	addStreamFlags(cmd)
+
+	return cmd
+}
+
+// validateFastscanFlags validates fastscan command flags
+func validateFastscanFlags() error {
+	if err := validateStreamFlags(); err != nil {
+		return err
+	}
+	if _, ok := modeSubdomains[fastMode]; !ok {
+		return fmt.Errorf("invalid mode: %s (must be all, epdg, ims, bsf, gan, xcap or ntp)", fastMode)
+	}
+	if fastQPS <= 0 {
+		return fmt.Errorf("--qps must be positive")
+	}
+	if fastConcurrency <= 0 {
+		return fmt.Errorf("--concurrency must be positive")
+	}
+	if fastQueryBudget < 0 {
+		return fmt.Errorf("--query-budget must not be negative")
+	}
+	if _, err := dns.ParseResolvers(fastResolvers); err != nil {
+		return err
+	}
+	if len(fastPorts) == 0 {
+		return fmt.Errorf("--ports cannot be empty")
+	}
+	for _, port := range fastPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port: %d", port)
+		}
+	}
+	if fastTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	if fastWorkers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if fastOutput != "" {
+		if err := validateOutputPaths([]string{fastOutput}, ".json", ".csv"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fastHit is a DNS hit with the outcome of its reachability check
+type fastHit struct {
+	dns  models.DNSResult
+	ping models.PingResult
+}
+
+// Fastscan command implementation
+func runFastscan(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	if err := validateFastscanFlags(); err != nil {
+		return err
+	}
+
+	f := fetcher.NewFetcher("", ".", 24*time.Hour)
+	f.UserAgent = userAgent()
+	var entries []models.MCCMNCEntry
+	var err error
+	if fastMCCMNCFile != "" {
+		entries, err = f.FetchFromFile(fastMCCMNCFile)
+	} else {
+		entries, err = f.Fetch()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch MCC-MNC list: %w", err)
+	}
+
+	if len(fastCountries) > 0 {
+		entries = filterCountries(entries, fastCountries)
+		if len(entries) == 0 {
+			return fmt.Errorf("no MCC-MNC entries for --country %s", strings.Join(fastCountries, ","))
+		}
+	}
+
+	allEntries := entries
+	engagement, err := loadScope(func() []models.MCCMNCEntry { return allEntries })
+	if err != nil {
+		return err
+	}
+	if engagement != nil {
+		entries = engagement.FilterEntries(entries)
+	}
+	excludeList, err := loadExcludeList()
+	if err != nil {
+		return err
+	}
+	checker := targetChecker(excludeList, engagement)
+
+	subdomains := modeSubdomains[fastMode]
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		Subdomains:   subdomains,
+		QueryDelay:   time.Second / time.Duration(fastQPS),
+		Concurrency:  fastConcurrency,
+		NoRetry:      true,
+		QueryBudget:  fastQueryBudget,
+	}
+	config.Resolvers, _ = dns.ParseResolvers(fastResolvers)
+	scanner := dns.NewScanner(config)
+	scanner.SetExclude(checker)
+
+	pinger := ping.NewPinger(&models.PingConfig{
+		Method:   "tcp",
+		Timeout:  time.Duration(fastTimeout) * time.Millisecond,
+		Workers:  fastWorkers,
+		TCPPorts: fastPorts,
+	})
+	pinger.SetExclude(checker)
+
+	publisher, err := openPublisher()
+	if err != nil {
+		return err
+	}
+	defer closePublisher(publisher)
+
+	ctx := context.Background()
+	if !quiet {
+		fmt.Printf("Fast scan of %d operators, mode=%s, subdomains=%v\n", len(entries), fastMode, subdomains)
+	}
+	checked := len(entries)
+	entries = scanner.PrecheckZones(ctx, entries)
+	if !quiet {
+		fmt.Printf("Zone pre-check: %d of %d zones exist, %d queries to send\n",
+			len(entries), checked, len(entries)*len(subdomains))
+	}
+
+	// Hits are checked for reachability while the scan goes on; a single
+	// collector prints them in arrival order
+	jobs := make(chan models.DNSResult, fastWorkers)
+	done := make(chan fastHit, fastWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < fastWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range jobs {
+				done <- fastHit{dns: result, ping: pinger.PingOne(result.FQDN)}
+			}
+		}()
+	}
+
+	var hits []fastHit
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for hit := range done {
+			hits = append(hits, hit)
+			if publisher != nil {
+				publisher.PublishPing(hit.ping)
+			}
+			if !quiet {
+				fmt.Println(fastscanLine(hit))
+			}
+		}
+	}()
+
+	scanner.SetResultCallback(func(result models.DNSResult) {
+		if publisher != nil {
+			publisher.PublishDNS(result)
+		}
+		jobs <- result
+	})
+	source := dns.ListSource{Entries: entries, Subdomains: subdomains, Order: dns.OrderList}
+	results, scanErr := scanner.ScanTargets(ctx, source)
+	close(jobs)
+	wg.Wait()
+	close(done)
+	<-collected
+	if scanErr != nil {
+		return fmt.Errorf("scan failed: %w", scanErr)
+	}
+
+	reportExcluded(excludeList)
+	reportOutOfScope(engagement)
+	if !quiet {
+		printFastscanSummary(hits, time.Since(started))
+	}
+
+	pings := make([]models.PingResult, len(hits))
+	for i, hit := range hits {
+		pings[i] = hit.ping
+	}
+
+	if fastDB != "" {
+		db, err := openDB(fastDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+
+		run := &models.Run{Kind: database.RunKindScan, Source: "fastscan " + fastMode, Started: started}
+		if err := db.InsertResultsBatch(results, database.InsertOptions{Run: run}); err != nil {
+			return fmt.Errorf("failed to save results: %w", err)
+		}
+		if err := db.SaveResults(models.Results(pings)); err != nil {
+			return fmt.Errorf("failed to save reachability: %w", err)
+		}
+		if !quiet {
+			fmt.Printf("Saved %d results to %s as run %d\n", len(results), fastDB, run.ID)
+		}
+	}
+
+	if fastOutput != "" {
+		inventory := append(models.Results(results), models.Results(pings)...)
+		if err := exportUnifiedResults(inventory, fastOutput); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if !quiet {
+			fmt.Printf("Exported results to: %s\n", fastOutput)
+		}
+	}
+
+	return nil
+}
+
+// filterCountries keeps the entries of the given countries, matched by
+// ISO code or name, case-insensitive
+func filterCountries(entries []models.MCCMNCEntry, countries []string) []models.MCCMNCEntry {
+	wanted := make(map[string]bool, len(countries))
+	for _, c := range countries {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			wanted[c] = true
+		}
+	}
+	var kept []models.MCCMNCEntry
+	for _, entry := range entries {
+		if wanted[strings.ToLower(entry.CountryCode)] || wanted[strings.ToLower(entry.CountryName)] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// fastscanLine renders a hit as it arrives: FQDN, country, addresses and
+// the port that answered
+func fastscanLine(hit fastHit) string {
+	country := hit.dns.CountryCode
+	if country == "" {
+		country = "--"
+	}
+	line := fmt.Sprintf("%s  %s  %s  ", hit.dns.FQDN, country, strings.Join(hit.dns.IPs, ","))
+	if hit.ping.Success {
+		addr := net.JoinHostPort(hit.ping.IP, strconv.Itoa(hit.ping.Port))
+		return line + fmt.Sprintf("reachable %s (%.2f ms)", addr, float64(hit.ping.Latency.Microseconds())/1000.0)
+	}
+	return line + fmt.Sprintf("unreachable (%s)", hit.ping.Status)
+}
+
+// printFastscanSummary prints the hits and reachable hosts per country
+func printFastscanSummary(hits []fastHit, elapsed time.Duration) {
+	type counts struct{ hits, reachable int }
+	byCountry := make(map[string]*counts)
+	reachable := 0
+	for _, hit := range hits {
+		name := hit.dns.Country
+		if name == "" {
+			name = "Unknown"
+		}
+		c := byCountry[name]
+		if c == nil {
+			c = &counts{}
+			byCountry[name] = c
+		}
+		c.hits++
+		if hit.ping.Success {
+			c.reachable++
+			reachable++
+		}
+	}
+
+	fmt.Printf("\nFast scan complete in %s: %d FQDNs, %d reachable over TCP\n",
+		elapsed.Round(time.Second), len(hits), reachable)
+	names := make([]string, 0, len(byCountry))
+	for name := range byCountry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s: %d FQDNs, %d reachable\n", name, byCountry[name].hits, byCountry[name].reachable)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"3gpp-scanner/internal/fingerprint"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Fingerprint command flags
+	fingerprintFile          string
+	fingerprintPings         string
+	fingerprintProbes        string
+	fingerprintRDNS          bool
+	fingerprintRules         string
+	fingerprintMinConfidence float64
+	fingerprintDB            string
+	fingerprintFormat        string
+)
+
+func fingerprintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fingerprint",
+		Short: "Label endpoints with their probable vendor or platform",
+		Long: `Classify discovered endpoints by probable vendor (Ericsson, Nokia, Cisco,
+Mavenir, ...) or hosting platform (AWS-hosted, ...). Evidence comes from:
+
+  cert    certificate issuer, subject and SANs    ping --method=https --output=x.json
+  banner  HTTP Server, SIP Server/User-Agent,     ping --method=https, probe --probes=sip,stun
+          STUN SOFTWARE headers
+  ike     IKE Vendor IDs                          probe --probes=ike
+  cname   CNAME targets                           the scan export itself
+  rdns    PTR names of the endpoint IP            --rdns
+
+Each source that matches a vendor rule adds its weight to that vendor's
+confidence (capped at 1); the strongest vendor is reported. With --db the
+labels are stored and show up in query and stats.`,
+		Example: `  # Classify from a scan export, HTTPS pings and probe results
+  3gpp-scanner fingerprint --file=results.json --pings=https.json --probes=probes.json
+
+  # Add reverse DNS and store the labels for query and stats
+  3gpp-scanner fingerprint --file=results.json --probes=probes.json --rdns --db=database.db
+
+  # Extra rules, e.g. {"vendor": "Acme", "source": "banner", "pattern": "acme-sbc"}
+  3gpp-scanner fingerprint --file=results.json --probes=probes.json --rules=rules.json --format=json`,
+		RunE: runFingerprint,
+	}
+
+	cmd.Flags().StringVarP(&fingerprintFile, "file", "f", "", "JSON scan export to classify")
+	cmd.Flags().StringVar(&fingerprintPings, "pings", "", "JSON ping export (https method) with certificates and Server headers")
+	cmd.Flags().StringVar(&fingerprintProbes, "probes", "", "JSON probe export with banners and IKE Vendor IDs")
+	cmd.Flags().BoolVar(&fingerprintRDNS, "rdns", false, "Look up PTR names of endpoint IPs")
+	cmd.Flags().StringVar(&fingerprintRules, "rules", "", "JSON file with additional fingerprint rules")
+	cmd.Flags().Float64Var(&fingerprintMinConfidence, "min-confidence", 0.3, "Minimum confidence (0-1) of reported labels")
+	cmd.Flags().StringVar(&fingerprintDB, "db", "", "Store the labels in this database")
+	cmd.Flags().StringVar(&fingerprintFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateFingerprintFlags validates fingerprint command flags
+func validateFingerprintFlags() error {
+	if fingerprintFile == "" {
+		return fmt.Errorf("--file required")
+	}
+	if fingerprintMinConfidence < 0 || fingerprintMinConfidence > 1 {
+		return fmt.Errorf("--min-confidence must be between 0 and 1")
+	}
+	if fingerprintFormat != "text" && fingerprintFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", fingerprintFormat)
+	}
+	return nil
+}
+
+// Fingerprint command implementation
+func runFingerprint(cmd *cobra.Command, args []string) error {
+	if err := validateFingerprintFlags(); err != nil {
+		return err
+	}
+
+	results, err := stats.LoadResults(fingerprintFile)
+	if err != nil {
+		return fmt.Errorf("failed to read results: %w", err)
+	}
+	var pings []models.PingResult
+	if fingerprintPings != "" {
+		if pings, err = stats.LoadPingResults(fingerprintPings); err != nil {
+			return fmt.Errorf("failed to read ping results: %w", err)
+		}
+	}
+	var probes []models.ProbeResult
+	if fingerprintProbes != "" {
+		if probes, err = stats.LoadProbeResults(fingerprintProbes); err != nil {
+			return fmt.Errorf("failed to read probe results: %w", err)
+		}
+	}
+
+	rules := fingerprint.DefaultRules
+	if fingerprintRules != "" {
+		extra, err := fingerprint.LoadRules(fingerprintRules)
+		if err != nil {
+			return err
+		}
+		rules = append(append([]fingerprint.Rule{}, rules...), extra...)
+	}
+	engine, err := fingerprint.NewEngine(rules)
+	if err != nil {
+		return fmt.Errorf("invalid rules: %w", err)
+	}
+
+	evidence := fingerprint.Collect(results, pings, probes)
+	if fingerprintRDNS {
+		if !quiet {
+			fmt.Printf("Looking up PTR names for %d endpoints...\n", len(evidence))
+		}
+		fingerprint.AddRDNS(context.Background(), evidence, 10, net.DefaultResolver.LookupAddr)
+	}
+	matches := engine.ClassifyAll(evidence, fingerprintMinConfidence)
+
+	if fingerprintDB != "" {
+		db, err := openDB(fingerprintDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+		if err := db.SaveVendorMatches(matches); err != nil {
+			return fmt.Errorf("failed to store vendors: %w", err)
+		}
+		if !quiet && fingerprintFormat == "text" {
+			fmt.Printf("Stored %d vendor labels in %s\n", len(matches), fingerprintDB)
+		}
+	}
+
+	if fingerprintFormat == "json" {
+		if err := output.ExportJSON(matches, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(fingerprint.FormatMatches(matches))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"3gpp-scanner/internal/geoip"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Geo command flags
+	geoFile   string
+	geoDB     string
+	geoOutput string
+)
+
+func geoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "geo",
+		Short: "Export discovered endpoint locations as GeoJSON or KML",
+		Long: `Locate every IP address of a JSON scan export with a GeoIP database and
+export the endpoints as GeoJSON (for QGIS and web maps) or KML (for Google
+Earth). Each point lists the FQDNs and operators resolving to it.
+
+The GeoIP database is a CSV file with network, latitude and longitude
+columns, such as MaxMind's GeoLite2-City-Blocks-IPv4.csv.`,
+		Example: `  # GeoJSON for QGIS
+  3gpp-scanner geo --file=results.json --geoip=GeoLite2-City-Blocks-IPv4.csv --output=endpoints.geojson
+
+  # KML for Google Earth
+  3gpp-scanner geo --file=results.json --geoip=GeoLite2-City-Blocks-IPv4.csv --output=endpoints.kml`,
+		RunE: runGeo,
+	}
+
+	cmd.Flags().StringVarP(&geoFile, "file", "f", "", "JSON scan export to locate")
+	cmd.Flags().StringVar(&geoDB, "geoip", "", "GeoIP CSV database (network, latitude, longitude columns)")
+	cmd.Flags().StringVarP(&geoOutput, "output", "o", "", "Output file (.geojson, .json or .kml)")
+
+	return cmd
+}
+
+// validateGeoFlags validates geo command flags
+func validateGeoFlags() error {
+	if geoFile == "" {
+		return fmt.Errorf("--file required")
+	}
+	if geoDB == "" {
+		return fmt.Errorf("--geoip required")
+	}
+	if geoOutput == "" {
+		return fmt.Errorf("--output required")
+	}
+	switch strings.ToLower(filepath.Ext(geoOutput)) {
+	case ".geojson", ".json", ".kml":
+	default:
+		return fmt.Errorf("unsupported format (use .geojson, .json or .kml)")
+	}
+	return nil
+}
+
+// Geo command implementation
+func runGeo(cmd *cobra.Command, args []string) error {
+	if err := validateGeoFlags(); err != nil {
+		return err
+	}
+
+	results, err := stats.LoadResults(geoFile)
+	if err != nil {
+		return fmt.Errorf("failed to read results: %w", err)
+	}
+
+	db, err := geoip.Open(geoDB)
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Loaded %d networks from %s\n", db.Len(), geoDB)
+	}
+
+	endpoints, unlocated := db.Locate(results)
+	if !quiet {
+		fmt.Printf("Located %d endpoints (%d IPs not in the database)\n", len(endpoints), unlocated)
+	}
+
+	if err := exportGeo(endpoints, geoOutput); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Exported endpoints to: %s\n", geoOutput)
+	}
+
+	return nil
+}
+
+func exportGeo(endpoints []models.GeoEndpoint, filePath string) error {
+	if strings.ToLower(filepath.Ext(filePath)) == ".kml" {
+		return output.ExportKML(endpoints, filePath)
+	}
+	return output.ExportGeoJSON(endpoints, filePath)
+}
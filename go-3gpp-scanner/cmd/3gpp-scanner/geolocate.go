@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/geoip"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Geolocate command flags
+	geolocVantages []string
+	geolocGeoIP    string
+	geolocFlagged  bool
+	geolocFormat   string
+	geolocOutput   string
+)
+
+func geolocateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "geolocate",
+		Short: "Estimate endpoint regions from ping latencies of several vantage points",
+		Long: `Combine ping exports taken from several vantage points into a rough location
+of every endpoint. A round-trip time bounds the distance to the endpoint (at
+most 100 km per millisecond, light in fiber there and back), so each vantage
+point rules out everything farther away. The report lists the nearest vantage
+point and the countries within reach of all of them.
+
+When no single place is within reach of every vantage point, several
+instances answer: the endpoint is anycast, typically cloud-fronted. With
+--geoip, endpoints whose GeoIP location is farther from a vantage point than
+its latency allows are flagged, e.g. a "Brazilian" ePDG answering in 3 ms
+from Frankfurt.
+
+Run the same ping export (ping --output=ping.json) on hosts in different
+regions and pass each file with the coordinates of its host.`,
+		Example: `  # Pings from Frankfurt, Ashburn and Singapore
+  3gpp-scanner geolocate --vantage=ping-fra.json@50.11,8.68 \
+    --vantage=ping-iad.json@39.04,-77.49 --vantage=ping-sin.json@1.35,103.82
+
+  # Flag anycast endpoints and GeoIP countries the latencies contradict
+  3gpp-scanner geolocate --vantage=ping-fra.json@50.11,8.68 --vantage=ping-iad.json@39.04,-77.49 \
+    --geoip=GeoLite2-City-Blocks-IPv4.csv --flagged --output=locations.json`,
+		Args: cobra.NoArgs,
+		RunE: runGeolocate,
+	}
+
+	cmd.Flags().StringArrayVar(&geolocVantages, "vantage", nil, "Ping export of a vantage point and its location, as FILE@LATITUDE,LONGITUDE (repeatable)")
+	cmd.Flags().StringVar(&geolocGeoIP, "geoip", "", "GeoIP CSV database whose locations are checked against the latencies")
+	cmd.Flags().BoolVar(&geolocFlagged, "flagged", false, "Only report anycast endpoints and GeoIP contradictions")
+	cmd.Flags().StringVar(&geolocFormat, "format", "text", "Output format: text or json")
+	cmd.Flags().StringVarP(&geolocOutput, "output", "o", "", "Write the estimates to a JSON file")
+
+	return cmd
+}
+
+// parseVantage parses FILE@LATITUDE,LONGITUDE; the vantage point is named
+// after the file
+func parseVantage(s string) (geoip.Vantage, string, error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 {
+		return geoip.Vantage{}, "", fmt.Errorf("invalid --vantage %q: must be FILE@LATITUDE,LONGITUDE", s)
+	}
+	file, coords := s[:i], s[i+1:]
+	latStr, lonStr, ok := strings.Cut(coords, ",")
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if !ok || errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return geoip.Vantage{}, "", fmt.Errorf("invalid --vantage %q: bad coordinates %q", s, coords)
+	}
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return geoip.Vantage{Name: name, Latitude: lat, Longitude: lon}, file, nil
+}
+
+// validateGeolocateFlags validates geolocate command flags
+func validateGeolocateFlags() error {
+	if len(geolocVantages) == 0 {
+		return fmt.Errorf("at least one --vantage required")
+	}
+	names := make(map[string]bool)
+	for _, s := range geolocVantages {
+		v, _, err := parseVantage(s)
+		if err != nil {
+			return err
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate vantage point %q (name the files differently)", v.Name)
+		}
+		names[v.Name] = true
+	}
+	if geolocFormat != "text" && geolocFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", geolocFormat)
+	}
+	return nil
+}
+
+// formatGeolocate renders estimates as text, one endpoint per block
+func formatGeolocate(estimates []geoip.LatencyEstimate) string {
+	var b strings.Builder
+	for _, e := range estimates {
+		fmt.Fprintf(&b, "%s  %s\n", e.IP, e.Summary())
+		if e.Contradiction != "" {
+			fmt.Fprintf(&b, "  CONTRADICTION: %s\n", e.Contradiction)
+		}
+		for _, s := range e.Samples {
+			fmt.Fprintf(&b, "  %-12s %8.1f ms  <= %.0f km\n", s.Vantage, float64(s.RTT.Microseconds())/1000, s.MaxKm)
+		}
+		fmt.Fprintf(&b, "  %s\n", strings.Join(e.FQDNs, ", "))
+	}
+	return b.String()
+}
+
+// Geolocate command implementation
+func runGeolocate(cmd *cobra.Command, args []string) error {
+	if err := validateGeolocateFlags(); err != nil {
+		return err
+	}
+
+	var vantages []geoip.Vantage
+	pings := make(map[string][]models.PingResult)
+	for _, s := range geolocVantages {
+		v, file, _ := parseVantage(s)
+		results, err := stats.LoadPingResults(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		vantages = append(vantages, v)
+		pings[v.Name] = results
+	}
+
+	var db *geoip.DB
+	if geolocGeoIP != "" {
+		var err error
+		if db, err = geoip.Open(geolocGeoIP); err != nil {
+			return err
+		}
+	}
+
+	estimates := geoip.EstimateLocations(vantages, pings, db)
+	located, anycast, contradicted := len(estimates), 0, 0
+	var flagged []geoip.LatencyEstimate
+	for _, e := range estimates {
+		if e.Anycast {
+			anycast++
+		}
+		if e.Contradiction != "" {
+			contradicted++
+		}
+		if e.Anycast || e.Contradiction != "" {
+			flagged = append(flagged, e)
+		}
+	}
+	if geolocFlagged {
+		estimates = flagged
+	}
+	if estimates == nil {
+		estimates = []geoip.LatencyEstimate{}
+	}
+
+	if geolocOutput != "" {
+		if err := output.ExportJSON(estimates, geolocOutput); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+	}
+	if geolocFormat == "json" {
+		if err := output.ExportJSON(estimates, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(formatGeolocate(estimates))
+	if !quiet {
+		if len(estimates) > 0 {
+			fmt.Println()
+		}
+		summary := fmt.Sprintf("Located %d endpoints from %d vantage points: %d anycast", located, len(vantages), anycast)
+		if db != nil {
+			summary += fmt.Sprintf(", %d contradicting GeoIP", contradicted)
+		}
+		fmt.Println(summary)
+		if geolocOutput != "" {
+			fmt.Printf("Estimates written to %s\n", geolocOutput)
+		}
+	}
+	return nil
+}
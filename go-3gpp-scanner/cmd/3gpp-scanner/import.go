@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/importer"
+	"3gpp-scanner/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Import command flags
+	importDB        string
+	importMapping   string
+	importDelimiter string
+	importNoHeader  bool
+	importDryRun    bool
+)
+
+func importCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import FQDN datasets from other tools into the database",
+		Long: `Consolidate FQDN data from spreadsheets and other tools in the database.
+FQDNs and operators already in the database are not added twice; new IP
+addresses of known FQDNs are. Every import is recorded as a run of kind
+"imported" (see db runs); ZGrab2 results are attached to the FQDNs already
+stored. Take a snapshot first to be able to roll an import back.`,
+	}
+	cmd.PersistentFlags().StringVar(&importDB, "db", "database.db", "Database file path")
+	cmd.PersistentFlags().BoolVar(&importDryRun, "dry-run", false, "Read and check the input without writing to the database")
+
+	csvCmd := &cobra.Command{
+		Use:   "csv FILE",
+		Short: "Import FQDNs, operators and IPs from a CSV file",
+		Long: `Import FQDNs from a CSV file of any layout. --mapping assigns columns to
+fields as field=column pairs, where a column is a header name
+(case-insensitive) or a 1-based position:
+
+  fqdn       FQDN (required)
+  operator   Operator name
+  ip         IP addresses; several per cell separated by ; , | or spaces.
+             Repeat to read more columns, e.g. ip=IPv4,ip=IPv6
+  mcc, mnc   Mobile country and network code
+  subdomain  Service label, e.g. epdg.epc
+  country    Country name of the operator
+  country_code
+             ISO 3166 country code of the operator
+  timestamp  When the FQDN was seen (RFC 3339, YYYY-MM-DD [HH:MM:SS]
+             or Unix seconds)
+
+MCC, MNC and subdomain default to the values in 3GPP FQDNs (mncXXX.mccYYY).
+Rows of the same FQDN are merged. Rows without a valid FQDN, or without an
+MCC/MNC when the FQDN does not carry them, are skipped and reported.`,
+		Example: `  # Spreadsheet export with named columns
+  3gpp-scanner import csv hosts.csv --mapping=fqdn=Hostname,operator=Carrier,ip=Address
+
+  # Headerless, semicolon-separated file, checked first
+  3gpp-scanner import csv legacy.csv --no-header --delimiter=';' --mapping=fqdn=1,ip=3 --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportCSV,
+	}
+	csvCmd.Flags().StringVar(&importMapping, "mapping", "", "Column mapping, e.g. fqdn=Host,operator=Carrier,ip=IP (required)")
+	csvCmd.Flags().StringVar(&importDelimiter, "delimiter", ",", `Field delimiter (one character, or "tab")`)
+	csvCmd.Flags().BoolVar(&importNoHeader, "no-header", false, "The file has no header row; map columns by position")
+	cmd.AddCommand(csvCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "legacy-db FILE",
+		Short: "Import the database of the Python version",
+		Long: `Migrate the FQDNs of a database written by the Python version into this
+database. Both its early layout (operators and available_fqdns with operator
+and fqdn only) and the later one with MCC/MNC, resolved IPs and first/last
+seen times are read, as is the fiveg_fqdns table of its 5G discovery script.
+Values are converted whatever type they were stored as, and the A and AAAA
+rows of an FQDN are merged. Databases of this version are read too, with
+their IPs, e.g. to merge those of scan shards. The legacy database is opened
+read-only.`,
+		Example: `  3gpp-scanner import legacy-db old.db --db=database.db --dry-run
+  3gpp-scanner import legacy-db old.db --db=database.db`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportLegacyDB,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "zgrab2 FILE",
+		Short: "Attach ZGrab2 TLS, HTTP and IKE results to the hosts in the database",
+		Long: `Import the JSON output of ZGrab2 (one host per line) as probe results of the
+FQDNs in the database, for protocol details the built-in probes do not
+collect. Results of the tls, http and ike modules are read, also under other
+names in multiple-module runs; other modules are counted and ignored.
+
+  tls   TLS version, cipher suite and the leaf certificate: subject,
+        issuer, validity and SANs, as the tls probe reports them, so
+        alerts cert-expiry and the API see them
+  http  Status code and Server header; an HTTPS handshake is also stored
+        as a tls result
+  ike   IKE version, notify types and Vendor IDs of the response
+
+A line with a domain (as export --for=zgrab2 writes) is attached to that
+FQDN; a line with only an IP address to every FQDN resolving to it. Hosts
+not in the database are counted and skipped. Results are added to the
+stored ones, so repeated imports keep their history.`,
+		Example: `  3gpp-scanner export --for=zgrab2 --output=targets.csv
+  zgrab2 multiple -c services.ini -f targets.csv -o zgrab2.json
+  3gpp-scanner import zgrab2 zgrab2.json --db=database.db`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportZGrab2,
+	})
+
+	return cmd
+}
+
+// importComma parses --delimiter
+func importComma() (rune, error) {
+	if importDelimiter == "tab" || importDelimiter == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(importDelimiter)
+	if size == 0 || size != len(importDelimiter) || r == '"' || r == '\n' || r == '\r' {
+		return 0, fmt.Errorf("invalid --delimiter: %q (must be one character)", importDelimiter)
+	}
+	return r, nil
+}
+
+// validateImportCSVFlags validates import csv flags
+func validateImportCSVFlags() (importer.Mapping, rune, error) {
+	if importMapping == "" {
+		return nil, 0, fmt.Errorf("--mapping required")
+	}
+	mapping, err := importer.ParseMapping(importMapping)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid --mapping: %w", err)
+	}
+	comma, err := importComma()
+	if err != nil {
+		return nil, 0, err
+	}
+	return mapping, comma, nil
+}
+
+// Import csv command implementation
+func runImportCSV(cmd *cobra.Command, args []string) error {
+	mapping, comma, err := validateImportCSVFlags()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer f.Close()
+
+	results, summary, err := importer.ReadCSV(f, mapping, importer.CSVOptions{Comma: comma, NoHeader: importNoHeader})
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	printImportSummary(summary, "line")
+	return storeImport(results, args[0])
+}
+
+// Import legacy-db command implementation
+func runImportLegacyDB(cmd *cobra.Command, args []string) error {
+	results, summary, err := importer.ReadLegacyDB(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	printImportSummary(summary, "row")
+	return storeImport(results, args[0])
+}
+
+// Import zgrab2 command implementation
+func runImportZGrab2(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open ZGrab2 output: %w", err)
+	}
+	defer f.Close()
+
+	results, summary, err := importer.ReadZGrab2(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	db, err := openDB(importDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	fqdns, err := db.GetAllFQDNs()
+	if err != nil {
+		return err
+	}
+	ips, err := db.FQDNIPs()
+	if err != nil {
+		return err
+	}
+	attached, unmatched := importer.AttachZGrab2(results, fqdns, ips)
+
+	for _, skip := range summary.Skipped {
+		slog.Debug("skipped line", "line", skip.Line, "reason", skip.Reason)
+	}
+	if !quiet {
+		fmt.Printf("Read %d hosts: %d results, %d lines skipped\n", summary.Lines, summary.Results, len(summary.Skipped))
+		for i, skip := range summary.Skipped {
+			if i == 5 {
+				fmt.Printf("  ... %d more (see --verbose)\n", len(summary.Skipped)-i)
+				break
+			}
+			fmt.Printf("  line %d: %s\n", skip.Line, skip.Reason)
+		}
+		if len(summary.Unsupported) > 0 {
+			var modules []string
+			for protocol, n := range summary.Unsupported {
+				modules = append(modules, fmt.Sprintf("%s %d", protocol, n))
+			}
+			sort.Strings(modules)
+			fmt.Printf("Ignored results of other modules: %s\n", strings.Join(modules, ", "))
+		}
+		if unmatched > 0 {
+			fmt.Printf("Skipped %d results of hosts not in the database\n", unmatched)
+		}
+	}
+
+	if importDryRun {
+		if !quiet {
+			fmt.Printf("Dry run: %d results would be attached, database not changed\n", len(attached))
+		}
+		return nil
+	}
+	if len(attached) == 0 {
+		return nil
+	}
+	if err := db.SaveResults(models.Results(attached)); err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Attached %d results to %s\n", len(attached), importDB)
+	}
+	return nil
+}
+
+// printImportSummary reports what an import read and skipped; unit names
+// what Skipped.Line counts
+func printImportSummary(summary *importer.Summary, unit string) {
+	for _, skip := range summary.Skipped {
+		slog.Debug("skipped row", unit, skip.Line, "reason", skip.Reason)
+	}
+	if !quiet {
+		fmt.Printf("Read %d rows: %d FQDNs, %d merged duplicates, %d skipped\n",
+			summary.Rows, summary.Results, summary.Merged, len(summary.Skipped))
+		for i, skip := range summary.Skipped {
+			if i == 5 {
+				fmt.Printf("  ... %d more (see --verbose)\n", len(summary.Skipped)-i)
+				break
+			}
+			fmt.Printf("  %s %d: %s\n", unit, skip.Line, skip.Reason)
+		}
+		if summary.BadIPs > 0 {
+			fmt.Printf("Ignored %d values in IP columns that are not IP addresses\n", summary.BadIPs)
+		}
+	}
+}
+
+// storeImport writes imported results unless --dry-run is set, recording
+// them as an imported run from source
+func storeImport(results []models.DNSResult, source string) error {
+	if importDryRun {
+		if !quiet {
+			fmt.Println("Dry run: database not changed")
+		}
+		return nil
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	db, err := openDB(importDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+	run := &models.Run{Kind: database.RunKindImported, Source: source}
+	if err := db.InsertResultsBatch(results, database.InsertOptions{SkipExisting: true, Run: run}); err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Merged %d FQDNs into %s as run %d\n", len(results), importDB, run.ID)
+	}
+	return nil
+}
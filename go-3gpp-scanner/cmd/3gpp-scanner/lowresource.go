@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Limits of --low-resource, sized for a Raspberry Pi class node
+const (
+	lowResourceMemoryLimit = 256 << 20 // Soft heap limit, unless GOMEMLIMIT is set
+	lowResourceBatchSize   = 50        // Rows per INSERT when saving to --db
+)
+
+// Global low-resource flag
+var lowResource bool
+
+// addLowResourceFlag adds --low-resource to the root command
+func addLowResourceFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&lowResource, "low-resource", false,
+		"Run on small machines: one worker per CPU unless set, a 256 MiB soft memory limit, no progress bar, and scan results checkpointed to disk as they arrive")
+}
+
+// applyLowResource lowers the --concurrency and --workers defaults of a
+// command to the number of usable CPUs and bounds the heap. Values given
+// on the command line are kept.
+func applyLowResource(cmd *cobra.Command) error {
+	if !lowResource {
+		return nil
+	}
+	if os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(lowResourceMemoryLimit)
+	}
+
+	limit := lowResourceWorkers()
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || (flag.Name != "concurrency" && flag.Name != "workers") {
+			return
+		}
+		n, convErr := strconv.Atoi(flag.Value.String())
+		if convErr != nil || n <= limit {
+			return
+		}
+		err = flag.Value.Set(strconv.Itoa(limit))
+	})
+	return err
+}
+
+// lowResourceWorkers is the concurrency of --low-resource runs: one per
+// CPU the scheduler may use
+func lowResourceWorkers() int {
+	return max(1, runtime.GOMAXPROCS(0))
+}
+
+// showProgress reports whether long runs draw a progress bar: not when
+// quiet, verbose or short of resources
+func showProgress() bool {
+	return !quiet && !verbose && !lowResource
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of --machine runs, following the diff convention
+const (
+	exitNoFindings = 0
+	exitFindings   = 1
+	exitError      = 2
+)
+
+// machineReport is the single JSON document a --machine run writes to
+// stdout
+type machineReport struct {
+	Command    string                      `json:"command"`
+	Status     string                      `json:"status"` // findings, no_findings or error
+	ExitCode   int                         `json:"exit_code"`
+	Error      string                      `json:"error,omitempty"`
+	Summary    map[string]int              `json:"summary,omitempty"`
+	Outcomes   map[models.ResultStatus]int `json:"outcomes,omitempty"`
+	Scope      string                      `json:"scope,omitempty"`        // Name of the --scope engagement
+	Excluded   []exclude.Skip              `json:"excluded,omitempty"`     // Targets skipped by --exclude-file
+	OutOfScope []exclude.Skip              `json:"out_of_scope,omitempty"` // Targets skipped by --scope
+	Results    string                      `json:"results,omitempty"`      // First --output file
+	Outputs    []string                    `json:"outputs,omitempty"`      // All --output files, when several
+	AuditLog   string                      `json:"audit_log,omitempty"`    // --audit-log file
+	Database   string                      `json:"database,omitempty"`     // --db file
+	Seed       int64                       `json:"seed,omitempty"`         // Seed of scan --order=shuffle
+	Shard      string                      `json:"shard,omitempty"`        // scan --shard
+	StartedAt  time.Time                   `json:"started_at"`
+	Duration   float64                     `json:"duration_seconds"`
+}
+
+var (
+	// Machine mode flag (scan and ping)
+	machine bool
+
+	// report collects the outcome of the running command for --machine
+	report = &machineReport{}
+)
+
+// addMachineFlag adds --machine to a single-run command
+func addMachineFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&machine, "machine", false,
+		"Print only a JSON summary and exit 0 without findings, 1 with findings, 2 on errors")
+}
+
+// startMachine silences human output before a --machine run
+func startMachine(cmd *cobra.Command) {
+	if !machine {
+		return
+	}
+	quiet = true
+	verbose = false
+	setupLogging()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	report.StartedAt = time.Now().UTC()
+}
+
+// finishMachine writes the report of a --machine run to w and returns
+// its exit code
+func finishMachine(w io.Writer, cmd *cobra.Command, err error) int {
+	if cmd != nil {
+		report.Command = cmd.Name()
+	}
+	if !report.StartedAt.IsZero() {
+		report.Duration = time.Since(report.StartedAt).Seconds()
+	}
+
+	switch {
+	case err != nil:
+		report.Status = "error"
+		report.ExitCode = exitError
+		report.Error = err.Error()
+	case report.Summary["findings"] > 0:
+		report.Status = "findings"
+		report.ExitCode = exitFindings
+	default:
+		report.Status = "no_findings"
+		report.ExitCode = exitNoFindings
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if encErr := encoder.Encode(report); encErr != nil {
+		return exitError
+	}
+	return report.ExitCode
+}
@@ -3,19 +3,40 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/countries"
+	"3gpp-scanner/internal/crypt"
 	"3gpp-scanner/internal/database"
 	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/exclude"
 	"3gpp-scanner/internal/fetcher"
+	"3gpp-scanner/internal/logging"
 	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
 	"3gpp-scanner/internal/output"
 	"3gpp-scanner/internal/ping"
+	"3gpp-scanner/internal/policy"
+	"3gpp-scanner/internal/redact"
+	"3gpp-scanner/internal/schedule"
+	"3gpp-scanner/internal/schema"
+	"3gpp-scanner/internal/scope"
+	"3gpp-scanner/internal/server"
 	"3gpp-scanner/internal/stats"
+	"3gpp-scanner/internal/wordlist"
 
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
@@ -25,36 +46,124 @@ var (
 	version = "1.0.0"
 
 	// Global flags
-	verbose bool
-	quiet   bool
+	verbose       bool
+	quiet         bool
+	jsonLogs      bool
+	dbJournalMode string
+	dbBusyTimeout time.Duration
+	utcTimes      bool
 
 	// Scan command flags
 	scanMode        string
 	scanSubdomains  string
 	scanDB          string
-	scanOutput      string
+	scanOutputs     []string
+	scanFailures    bool
 	scanConcurrency int
 	scanDelay       int
 	scanMCCMNCFile  string
+	scanSourceIP    string
+	scanInterface   string
+	scanResolvers   []string
+	scanNoNegCache  bool
+	scanZoneCache   string
+	scanZoneTTL     time.Duration
+	scanPrecheck    bool
+	scanRatePolicy  string
+	scanExcludeMCC  []string
+	scanExcludeCC   []string
+	scanPLMNs       []string
+	scanRunWindow   string
+	scanStateFile   string
+	scanForceResume bool
+	scanYes         bool
+	scanConfirmOver time.Duration
+	scanBrute       bool
+	scanWordlist    string
+	scanSignKey     string
+	scanRedact      []string
+	scanDBNoSync    bool
+	scanOrder       string
+	scanSeed        int64
+	scanShard       string
+	scanNoRetry     bool
+	scanQueryBudget time.Duration
+	scanTargets     string
+	scanRescan      bool
 
 	// Ping command flags
-	pingFile    string
-	pingMethod  string
-	pingTimeout int
-	pingWorkers int
-	pingOutput  string
+	pingFile      string
+	pingMethod    string
+	pingTimeout   int
+	pingWorkers   int
+	pingOutputs   []string
+	pingSourceIP  string
+	pingInterface string
+	pingSignKey   string
+	pingRedact    []string
+	pingFailures  bool
+	pingSummary   bool
+	pingMTU       bool
+	pingDual      bool
+	pingDedupe    bool
+	pingFallback  string
+	pingMCCMNC    string
+
+	// Export redaction flags (scan and ping)
+	redactMethod string
+	redactSalt   string
+
+	// Exclusion list and engagement scope flags (scan, ping and probe)
+	excludeFile string
+	scopeFile   string
+
+	// Audit log flag (ping and probe)
+	auditLogFile string
+
+	// Common result envelope export flag (scan, ping and probe)
+	unifiedOutput bool
+
+	// Live result streaming flags (scan and ping)
+	streamURL         string
+	streamKeyFile     string
+	streamCA          string
+	streamFingerprint string
 
 	// Query command flags
-	queryMNC      int
-	queryMCC      int
-	queryOperator string
-	queryDB       string
-	queryExport   string
+	queryMNC       string
+	queryMCC       string
+	queryOperator  string
+	queryDB        string
+	queryExport    string
+	queryFile      string
+	queryLimit     int
+	queryOffset    int
+	queryOrderBy   string
+	queryCount     bool
+	queryDistinct  string
+	querySubdomain string
+	queryTag       string
+	queryPrivate   bool
+	querySince     string
+	queryNames     string
 
 	// Stats command flags
 	statsFile   string
 	statsDB     string
 	statsFormat string
+	statsChart  string
+	statsMap    bool
+	statsMCCMNC string
+	statsTop    int
+	statsByOp   bool
+	statsNames  string
+
+	// Fetch MCC-MNC command flags
+	fetchOutput    string
+	fetchFormat    string
+	fetchCountries []string
+	fetchStatus    string
+	fetchDiff      bool
 )
 
 func main() {
@@ -63,22 +172,86 @@ func main() {
 		Short: "3GPP network discovery and analysis tool",
 		Long: `A unified toolkit for discovering and analyzing ePDG and 3GPP mobile
 network infrastructure through DNS reconnaissance.`,
-		Version: version,
+		Version: versionString(),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if jsonLogs {
+				// The error is logged as a JSON record instead
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			if err := validateOutputFlags(); err != nil {
+				return err
+			}
+			setupLogging()
+			return applyLowResource(cmd)
+		},
 	}
 
 	// Global flags
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
-	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress output except errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Write debug logs to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress, status messages and warnings; only errors and results are printed")
+	rootCmd.PersistentFlags().BoolVar(&jsonLogs, "json-logs", false, "Write logs to stderr as JSON lines")
+	rootCmd.PersistentFlags().StringVar(&dbJournalMode, "db-journal-mode", database.DefaultJournalMode, "SQLite journal mode: wal, delete, truncate, persist, memory or off")
+	rootCmd.PersistentFlags().DurationVar(&dbBusyTimeout, "db-busy-timeout", database.DefaultBusyTimeout, "How long database access waits for a lock held by another process")
+	rootCmd.PersistentFlags().BoolVar(&utcTimes, "utc", false, "Write export timestamps in UTC instead of local time")
+	addLowResourceFlag(rootCmd)
 
 	// Add subcommands
 	rootCmd.AddCommand(scanCmd())
+	rootCmd.AddCommand(fastscanCmd())
 	rootCmd.AddCommand(pingCmd())
 	rootCmd.AddCommand(queryCmd())
+	rootCmd.AddCommand(exportCmd())
 	rootCmd.AddCommand(statsCmd())
 	rootCmd.AddCommand(fetchMCCMNCCmd())
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	rootCmd.AddCommand(zoneinfoCmd())
+	rootCmd.AddCommand(geoCmd())
+	rootCmd.AddCommand(geolocateCmd())
+	rootCmd.AddCommand(profileCmd())
+	rootCmd.AddCommand(reportCmd())
+	rootCmd.AddCommand(compareCmd())
+	rootCmd.AddCommand(mvnoCmd())
+	rootCmd.AddCommand(cidrCmd())
+	rootCmd.AddCommand(bgpCmd())
+	rootCmd.AddCommand(whoisCmd())
+	rootCmd.AddCommand(ctlookupCmd())
+	rootCmd.AddCommand(pdnsCmd())
+	rootCmd.AddCommand(metricsCmd())
+	rootCmd.AddCommand(monitorCmd())
+	rootCmd.AddCommand(alertsCmd())
+	rootCmd.AddCommand(probeCmd())
+	rootCmd.AddCommand(fingerprintCmd())
+	rootCmd.AddCommand(scoreCmd())
+	rootCmd.AddCommand(snapshotCmd())
+	rootCmd.AddCommand(syncCmd())
+	rootCmd.AddCommand(importCmd())
+	rootCmd.AddCommand(tagCmd())
+	rootCmd.AddCommand(ackCmd())
+	rootCmd.AddCommand(dbCmd())
+	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(wordlistCmd())
+	rootCmd.AddCommand(schemaCmd())
+	rootCmd.AddCommand(verifyCmd())
+	rootCmd.AddCommand(keygenCmd())
+	rootCmd.AddCommand(encryptCmd())
+	rootCmd.AddCommand(decryptCmd())
+	rootCmd.AddCommand(benchCmd())
+	rootCmd.AddCommand(crosscheckCmd())
+	rootCmd.AddCommand(versionCmd())
+
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		if jsonLogs {
+			setupLogging()
+			slog.Error(err.Error(), "command", cmd.Name())
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if machine {
+		os.Exit(finishMachine(os.Stdout, cmd, err))
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -96,17 +269,79 @@ global MCC-MNC combinations to identify exposed telecom infrastructure.`,
   3gpp-scanner scan --mode=all --db=database.db --concurrency=20
 
   # Scan custom subdomains with rate limiting
-  3gpp-scanner scan --mode=custom --subdomains=ims,bsf --delay=250`,
+  3gpp-scanner scan --mode=custom --subdomains=ims,bsf --delay=250
+
+  # Send queries from a specific egress interface
+  3gpp-scanner scan --mode=epdg --interface=eth1
+
+  # Apply per-country rate limits and skip selected countries
+  3gpp-scanner scan --mode=all --rate-policy=policy.json --exclude-mcc=460 --exclude-country=DE
+
+  # Run only off-peak, pausing outside the window and resuming from state
+  3gpp-scanner scan --mode=all --db=database.db --run-window="22:00-06:00"
+
+  # Follow up hits by brute-forcing common labels inside those zones
+  3gpp-scanner scan --mode=epdg --brute --wordlist=labels.txt
+
+  # Spread queries over countries, or shuffle them reproducibly
+  3gpp-scanner scan --mode=all --order=by-mcc
+  3gpp-scanner scan --mode=all --order=shuffle --seed=42
+
+  # Scan every service name of two operators by PLMN ID
+  3gpp-scanner scan --plmn=26201 --plmn=310260
+
+  # Split a full scan across three machines, each saving to the shared database
+  3gpp-scanner scan --mode=all --shard=1/3 --db=database.db
+
+  # Export every query with its outcome, e.g. to review timeouts
+  3gpp-scanner scan --plmn=26201 --include-failures -o outcomes.csv`,
 		RunE: runScan,
 	}
 
-	cmd.Flags().StringVarP(&scanMode, "mode", "m", "all", "Scan mode: all, epdg, ims, bsf, gan, xcap, custom")
-	cmd.Flags().StringVar(&scanSubdomains, "subdomains", "", "Custom subdomain list (comma-separated, for mode=custom)")
+	cmd.Flags().StringVarP(&scanMode, "mode", "m", "all", "Scan mode: all, epdg, ims, bsf, gan, xcap, ntp, custom")
+	cmd.Flags().StringVar(&scanSubdomains, "subdomains", "", "Custom subdomain list (comma-separated, for mode=custom; or use --wordlist)")
 	cmd.Flags().StringVar(&scanDB, "db", "", "Database file path (if set, results will be saved to SQLite)")
-	cmd.Flags().StringVarP(&scanOutput, "output", "o", "", "Output file (json, csv, or txt)")
+	cmd.Flags().StringVar(&scanTargets, "targets", "", `File of candidate FQDNs to query instead of the subdomains of --mode, one per line ("-" for stdin)`)
+	cmd.Flags().BoolVar(&scanRescan, "rescan", false, "Query the FQDNs stored in --db again instead of the subdomains of --mode")
+	cmd.Flags().BoolVar(&scanDBNoSync, "db-no-sync", false, "Skip fsync while saving to --db (faster; a power loss during the save can corrupt the database)")
+	cmd.Flags().StringSliceVarP(&scanOutputs, "output", "o", nil, "Output file (json, csv, or txt); repeat for several files")
+	cmd.Flags().BoolVar(&scanFailures, "include-failures", false, "Also write queries that found no addresses to --output json and csv files, with their status and error")
 	cmd.Flags().IntVarP(&scanConcurrency, "concurrency", "c", 10, "Number of concurrent DNS queries")
 	cmd.Flags().IntVar(&scanDelay, "delay", 500, "Delay between queries in milliseconds")
 	cmd.Flags().StringVar(&scanMCCMNCFile, "mccmnc-file", "", "Use local MCC-MNC JSON file instead of fetching")
+	cmd.Flags().StringVar(&scanSourceIP, "source-ip", "", "Local IP address to send DNS queries from")
+	cmd.Flags().StringVar(&scanInterface, "interface", "", "Network interface to send DNS queries from")
+	cmd.Flags().StringSliceVar(&scanResolvers, "resolvers", nil, "Recursive resolvers tried in order, as IP or IP:port (comma-separated; default: Google, Cloudflare, OpenDNS)")
+	cmd.Flags().BoolVar(&scanPrecheck, "precheck", false, "First query the SOA of every operator zone and only enumerate subdomains in zones that exist")
+	cmd.Flags().DurationVar(&scanQueryBudget, "query-budget", 0, "Wall-clock limit for one query across all resolvers, e.g. 3s (0 = each resolver gets its full timeout)")
+	cmd.Flags().BoolVar(&scanNoRetry, "no-retry", false, "Do not retry queries that timed out or failed with SERVFAIL at the end of the scan")
+	cmd.Flags().BoolVar(&scanNoNegCache, "no-negative-cache", false, "Query every subdomain, even in zones proven not to exist")
+	cmd.Flags().StringVar(&scanZoneCache, "zone-cache", "", "File to keep zone existence in between runs (JSON)")
+	cmd.Flags().DurationVar(&scanZoneTTL, "zone-cache-ttl", 7*24*time.Hour, "How long --zone-cache entries are trusted (0 = forever)")
+	cmd.Flags().StringVar(&scanRatePolicy, "rate-policy", "", "JSON policy file mapping MCC to max QPS and exclusions")
+	cmd.Flags().StringSliceVar(&scanPLMNs, "plmn", nil, "Only scan these operators, by PLMN ID, e.g. 26201 or 310260 (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&scanExcludeMCC, "exclude-mcc", nil, "MCCs to skip entirely (comma-separated)")
+	cmd.Flags().StringSliceVar(&scanExcludeCC, "exclude-country", nil, "Countries to skip, by ISO code or name (comma-separated)")
+	cmd.Flags().StringVar(&scanOrder, "order", dns.OrderList, "Query order: list, shuffle, by-mcc (one country at a time in turn) or by-operator (one zone at a time in turn)")
+	cmd.Flags().Int64Var(&scanSeed, "seed", 0, "Seed for --order=shuffle, to repeat an order (default: random, printed at start)")
+	cmd.Flags().StringVar(&scanShard, "shard", "", "Only scan part i of n of the operator zones, e.g. 3/10; the n shards together cover all zones once")
+	cmd.Flags().StringVar(&scanRunWindow, "run-window", "", "Only scan during this daily local time window, e.g. 22:00-06:00")
+	cmd.Flags().StringVar(&scanStateFile, "state-file", "", "Checkpoint file for resuming scans (default scan-state.jsonl with --run-window)")
+	cmd.Flags().BoolVar(&scanForceResume, "force-resume", false, "Resume from --state-file even if it was written by a scan with another mode, MCC-MNC list or resolvers")
+	cmd.Flags().BoolVarP(&scanYes, "yes", "y", false, "Skip the confirmation prompt for long scans")
+	cmd.Flags().BoolVar(&scanBrute, "brute", false, "After the scan, brute-force wordlist labels inside zones with hits")
+	cmd.Flags().StringVar(&scanWordlist, "wordlist", "", "Wordlist file or stored name for --brute or custom mode (default: built-in list)")
+	cmd.Flags().StringVar(&scanSignKey, "sign-key", "", "Ed25519 private key (PEM) to sign the --output file with")
+	cmd.Flags().StringSliceVar(&scanRedact, "redact", nil, "Redact fields in the --output file: ips, operators (comma-separated)")
+	addRedactFlags(cmd)
+	addEncryptFlags(cmd)
+	addUnifiedFlag(cmd)
+	addValidateOutputFlag(cmd)
+	addMachineFlag(cmd)
+	addExcludeFlag(cmd)
+	addScopeFlag(cmd)
+	addStreamFlags(cmd)
+	cmd.Flags().DurationVar(&scanConfirmOver, "confirm-threshold", 2*time.Hour, "Ask for confirmation when the estimated duration exceeds this")
 
 	return cmd
 }
@@ -115,20 +350,46 @@ func pingCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ping",
 		Short: "Test connectivity to discovered FQDNs",
-		Long:  `Ping FQDNs using ICMP (requires root) or TCP connectivity checks.`,
+		Long:  `Ping FQDNs using ICMP (requires root, except on Windows) or TCP connectivity checks.`,
 		Example: `  # TCP connectivity check (no root required)
   3gpp-scanner ping --file=results.txt --method=tcp
 
   # ICMP ping with custom timeout and workers, export to JSON
-  sudo 3gpp-scanner ping --file=fqdns.txt --method=icmp --timeout=500 --workers=20 --output=results.json`,
-		RunE:  runPing,
+  sudo 3gpp-scanner ping --file=fqdns.txt --method=icmp --timeout=500 --workers=20 --output=results.json
+
+  # TCP check from a specific source address on a multi-homed host
+  3gpp-scanner ping --file=fqdns.txt --method=tcp --source-ip=192.0.2.10
+
+  # Probe each address once, however many FQDNs share it
+  3gpp-scanner ping --file=fqdns.txt --method=tcp --dedupe-by-ip`,
+		RunE: runPing,
 	}
 
 	cmd.Flags().StringVarP(&pingFile, "file", "f", "", "File containing FQDNs (one per line)")
-	cmd.Flags().StringVar(&pingMethod, "method", "icmp", "Ping method: icmp or tcp")
+	cmd.Flags().StringVar(&pingMethod, "method", "icmp", "Ping method: icmp, tcp or https")
 	cmd.Flags().IntVar(&pingTimeout, "timeout", 300, "Timeout in milliseconds")
 	cmd.Flags().IntVarP(&pingWorkers, "workers", "w", 10, "Number of concurrent ping workers")
-	cmd.Flags().StringVarP(&pingOutput, "output", "o", "", "Output file (json or csv)")
+	cmd.Flags().StringSliceVarP(&pingOutputs, "output", "o", nil, "Output file (json or csv); repeat for several files")
+	cmd.Flags().StringVar(&pingSourceIP, "source-ip", "", "Local IP address to send probes from")
+	cmd.Flags().StringVar(&pingInterface, "interface", "", "Network interface to send probes from")
+	cmd.Flags().StringVar(&pingSignKey, "sign-key", "", "Ed25519 private key (PEM) to sign the --output file with")
+	cmd.Flags().BoolVar(&pingFailures, "include-failures", false, "Keep unreachable hosts in the results and export")
+	cmd.Flags().BoolVar(&pingMTU, "mtu", false, "Probe the path MTU of reachable hosts with DF-flagged ICMP (Linux, needs root)")
+	cmd.Flags().BoolVar(&pingDual, "dual-stack", false, "Probe A and AAAA targets separately and compare IPv4 and IPv6")
+	cmd.Flags().StringVar(&pingFallback, "fallback", "", "Method to switch to when raw ICMP sockets are unavailable: tcp or https")
+	cmd.Flags().BoolVar(&pingDedupe, "dedupe-by-ip", false, "Resolve all FQDNs first and probe each address once, sharing its result (icmp and tcp)")
+	cmd.Flags().BoolVar(&pingSummary, "summary-only", false, "Print only the latency summary, not per-host lines")
+	cmd.Flags().StringVar(&pingMCCMNC, "mccmnc-file", "", "MCC-MNC JSON file for country names in the summary (default: cached list if present)")
+	cmd.Flags().StringSliceVar(&pingRedact, "redact", nil, "Redact fields in the --output file: ips, operators (comma-separated)")
+	addRedactFlags(cmd)
+	addEncryptFlags(cmd)
+	addUnifiedFlag(cmd)
+	addValidateOutputFlag(cmd)
+	addMachineFlag(cmd)
+	addExcludeFlag(cmd)
+	addScopeFlag(cmd)
+	addAuditFlag(cmd)
+	addStreamFlags(cmd)
 
 	return cmd
 }
@@ -137,20 +398,62 @@ func queryCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "query",
 		Short: "Query the database for operator information",
-		Long:  `Query FQDNs by MNC/MCC or operator name from the SQLite database.`,
+		Long: `Query FQDNs by MNC/MCC, operator name or tag from the SQLite database.
+Each FQDN is shown with its operator, country, stored addresses and the last
+time a recorded scan found it, followed by its vendor label and tags where
+set. Notes added with db note are listed after the results.
+
+--export=json or --export=csv prints the same rows in that format instead,
+without headers or notes, for piping into other tools. --export-file writes
+them to a file; its extension picks the format when --export is not set.
+
+--since=run:ID or --since=DATE keeps only FQDNs first stored by a run after
+that one, or first seen on or after that date, so incremental pipelines get
+new results instead of full dumps. FQDNs acknowledged with 'ack add new-fqdn'
+are skipped. Exports to a file print the newest run ID to pass next time
+(see db runs).`,
 		Example: `  # Query by MNC and MCC
   3gpp-scanner query --mnc=001 --mcc=310 --db=database.db
 
   # Query by operator name and export as CSV
-  3gpp-scanner query --operator="Verizon" --db=database.db --export=csv`,
+  3gpp-scanner query --operator="Verizon" --db=database.db --export=csv > verizon.csv
+  3gpp-scanner query --operator="Verizon" --db=database.db --export-file=verizon.json
+
+  # How many ePDG FQDNs are there for MCC 310, and from which operators?
+  3gpp-scanner query --mcc=310 --subdomain=epdg --count --db=database.db
+  3gpp-scanner query --mcc=310 --subdomain=epdg --distinct=operators --db=database.db
+
+  # FQDNs published with private addresses, with those addresses
+  3gpp-scanner query --private-ips --db=database.db
+
+  # FQDNs tagged confirmed-live, directly or through their operator
+  3gpp-scanner query --tag=confirmed-live --db=database.db
+
+  # FQDNs new since run 42, or since a date, for an incremental pipeline
+  3gpp-scanner query --since=run:42 --db=database.db --export-file=new.json
+  3gpp-scanner query --since=2024-01-01 --mcc=310 --mnc=410 --db=database.db --export=csv
+
+  # Second page of 100 FQDNs, alphabetically
+  3gpp-scanner query --mnc=001 --mcc=310 --db=database.db --order-by=fqdn --limit=100 --offset=100`,
 		RunE:  runQuery,
 	}
 
-	cmd.Flags().IntVar(&queryMNC, "mnc", 0, "Mobile Network Code")
-	cmd.Flags().IntVar(&queryMCC, "mcc", 0, "Mobile Country Code")
+	cmd.Flags().StringVar(&queryMNC, "mnc", "", "Mobile Network Code as listed, e.g. 01 or 001 (different networks)")
+	cmd.Flags().StringVar(&queryMCC, "mcc", "", "Mobile Country Code")
 	cmd.Flags().StringVar(&queryOperator, "operator", "", "Operator name")
 	cmd.Flags().StringVar(&queryDB, "db", "database.db", "Database file path")
-	cmd.Flags().StringVar(&queryExport, "export", "", "Export format: json or csv")
+	cmd.Flags().StringVar(&queryExport, "export", "", "Print rows as json or csv instead of a table")
+	cmd.Flags().StringVar(&queryFile, "export-file", "", "Write the exported rows to this file instead of stdout (format from the extension without --export)")
+	cmd.Flags().IntVar(&queryLimit, "limit", 0, "Maximum number of FQDNs to return (0: all)")
+	cmd.Flags().IntVar(&queryOffset, "offset", 0, "Number of FQDNs to skip")
+	cmd.Flags().StringVar(&queryOrderBy, "order-by", "", "Sort by fqdn, operator or inserted (default); prefix with - to reverse")
+	cmd.Flags().StringVar(&querySubdomain, "subdomain", "", "Only FQDNs below this subdomain, e.g. epdg or xcap.ims")
+	cmd.Flags().StringVar(&queryTag, "tag", "", "Only FQDNs carrying this tag, directly or through their operator")
+	cmd.Flags().BoolVar(&queryPrivate, "private-ips", false, "Only FQDNs resolving to a private (RFC 1918, CGNAT or IPv6 ULA) address")
+	cmd.Flags().StringVar(&querySince, "since", "", "Only FQDNs new since a run (run:ID) or date (YYYY-MM-DD or RFC 3339); alone selects all new FQDNs")
+	cmd.Flags().BoolVar(&queryCount, "count", false, "Print only the number of distinct FQDNs (--mcc alone allowed)")
+	cmd.Flags().StringVar(&queryDistinct, "distinct", "", "Print distinct values with FQDN counts instead of FQDNs: operators (--mcc alone allowed)")
+	cmd.Flags().StringVar(&queryNames, "country-names", "", "Show the country column as iso, english or local names with the MCC, e.g. Germany (262) (table output)")
 
 	return cmd
 }
@@ -159,18 +462,48 @@ func statsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "stats",
 		Short: "Generate statistics from scan results",
-		Long:  `Analyze FQDN files or database and generate statistics.`,
+		Long: `Analyze FQDN files, JSON scan exports or a database and generate statistics.
+
+--chart emits a ready-to-render Vega-Lite or gnuplot specification with an
+MCC bar chart, a subdomain chart and, for JSON exports spanning several days,
+a discovery time series.
+
+--country-names names MCCs by their country in text output, from the ISO
+code (DE), English (Germany) or local name (Deutschland) embedded in the
+binary, e.g. "Germany (262)" instead of "MCC 262".`,
 		Example: `  # Analyze FQDN file with text output
   3gpp-scanner stats --file=epdg-fqdn-raw.txt
 
+  # Show the 20 largest MCCs, countries and subdomains
+  3gpp-scanner stats --db=database.db --top=20
+
+  # Top operators by FQDN count
+  3gpp-scanner stats --db=database.db --by-operator
+
+  # Countries by name instead of bare MCCs
+  3gpp-scanner stats --db=database.db --country-names=english
+
   # Analyze database and export as JSON
-  3gpp-scanner stats --db=database.db --format=json`,
+  3gpp-scanner stats --db=database.db --format=json
+
+  # World heat map in the terminal, or as a Leaflet web page
+  3gpp-scanner stats --db=database.db --map
+  3gpp-scanner stats --db=database.db --map --format=html > map.html
+
+  # Vega-Lite charts from a JSON scan export (adds a time series)
+  3gpp-scanner stats --file=results.json --chart=vega > charts.vl.json`,
 		RunE:  runStats,
 	}
 
 	cmd.Flags().StringVarP(&statsFile, "file", "f", "", "FQDN file to analyze")
 	cmd.Flags().StringVar(&statsDB, "db", "", "Database to analyze")
 	cmd.Flags().StringVar(&statsFormat, "format", "text", "Output format: text, json, or csv")
+	cmd.Flags().StringVar(&statsChart, "chart", "", "Emit a chart specification instead: vega or gnuplot")
+	cmd.Flags().BoolVar(&statsMap, "map", false, "Show FQDN density per country on a world map (ASCII, or HTML with --format=html)")
+	cmd.Flags().StringVar(&statsMCCMNC, "mccmnc-file", "", "MCC-MNC JSON file mapping MCCs to countries (default: cached list)")
+	cmd.Flags().BoolVar(&statsByOp, "by-operator", false, "Show a table of the top operators by FQDN count instead (text output)")
+	cmd.Flags().IntVar(&statsTop, "top", stats.DefaultTop, "Rows of each distribution in text output, the rest summed up as others (0 for all)")
+	cmd.Flags().StringVar(&statsNames, "country-names", "", "Name MCCs and countries in text output: iso, english or local, e.g. Germany (262)")
 
 	return cmd
 }
@@ -181,19 +514,38 @@ func fetchMCCMNCCmd() *cobra.Command {
 		Short: "Download MCC-MNC list",
 		Long:  `Download the latest MCC-MNC list from GitHub and save locally.`,
 		Example: `  # Download latest MCC-MNC list
-  3gpp-scanner fetch-mccmnc`,
+  3gpp-scanner fetch-mccmnc
+
+  # Write operational German and Austrian operators to a CSV file
+  3gpp-scanner fetch-mccmnc --country=DE,AT --status=operational --format=csv --output=dach.csv
+
+  # See which networks appeared or changed since the last fetch
+  3gpp-scanner fetch-mccmnc --diff
+
+  # Produce a curated list for a targeted scan
+  3gpp-scanner fetch-mccmnc --country=Germany --output=de.json
+  3gpp-scanner scan --mode=all --mccmnc-file=de.json`,
 		RunE:  runFetchMCCMNC,
 	}
 
+	cmd.Flags().StringVarP(&fetchOutput, "output", "o", "", "Write the (filtered) list to this path instead of only refreshing the cache")
+	cmd.Flags().StringVar(&fetchFormat, "format", "json", "Output format: json or csv")
+	cmd.Flags().StringSliceVar(&fetchCountries, "country", nil, "Only include these countries, by ISO code or name (comma-separated)")
+	cmd.Flags().StringVar(&fetchStatus, "status", "", "Only include entries with this status (e.g. operational)")
+	cmd.Flags().BoolVar(&fetchDiff, "diff", false, "Report operators added, removed or renamed since the cached copy")
+
 	return cmd
 }
 
 // validateScanFlags validates scan command flags
 func validateScanFlags() error {
-	if scanMode == "custom" && scanSubdomains == "" {
+	if err := validateStreamFlags(); err != nil {
+		return err
+	}
+	if scanMode == "custom" && scanSubdomains == "" && scanWordlist == "" {
 		return fmt.Errorf("--subdomains required for custom mode")
 	}
-	validModes := map[string]bool{"all": true, "epdg": true, "ims": true, "bsf": true, "gan": true, "xcap": true, "custom": true}
+	validModes := map[string]bool{"all": true, "epdg": true, "ims": true, "bsf": true, "gan": true, "xcap": true, "ntp": true, "custom": true}
 	if !validModes[scanMode] {
 		return fmt.Errorf("invalid mode: %s", scanMode)
 	}
@@ -203,16 +555,113 @@ func validateScanFlags() error {
 	if scanDelay < 0 {
 		return fmt.Errorf("--delay cannot be negative")
 	}
+	if err := (netbind.Binding{SourceIP: scanSourceIP, Interface: scanInterface}).Validate(); err != nil {
+		return err
+	}
+	if _, err := dns.ParseResolvers(scanResolvers); err != nil {
+		return err
+	}
+	for _, mcc := range scanExcludeMCC {
+		if _, err := strconv.Atoi(strings.TrimSpace(mcc)); err != nil {
+			return fmt.Errorf("invalid --exclude-mcc value: %s", mcc)
+		}
+	}
+	if scanRunWindow != "" {
+		if _, err := schedule.ParseWindow(scanRunWindow); err != nil {
+			return err
+		}
+	}
+	if err := dns.ValidateOrder(scanOrder); err != nil {
+		return err
+	}
+	if scanShard != "" {
+		if _, err := dns.ParseShard(scanShard); err != nil {
+			return err
+		}
+	}
+	if scanQueryBudget < 0 {
+		return fmt.Errorf("--query-budget must not be negative")
+	}
+	if scanTargets != "" && scanRescan {
+		return fmt.Errorf("cannot specify both --targets and --rescan")
+	}
+	for _, id := range scanPLMNs {
+		if _, err := dns.ParsePLMN(id); err != nil {
+			return err
+		}
+	}
+	if len(scanPLMNs) > 0 && (scanTargets != "" || scanRescan) {
+		return fmt.Errorf("--plmn cannot be used with --targets or --rescan")
+	}
+	if scanRescan && scanDB == "" {
+		return fmt.Errorf("--rescan requires --db")
+	}
+	if scanTargets != "" || scanRescan {
+		if scanOrder != dns.OrderList {
+			return fmt.Errorf("--order cannot be used with --targets or --rescan")
+		}
+		if scanPrecheck {
+			return fmt.Errorf("--precheck cannot be used with --targets or --rescan")
+		}
+	}
+	if scanSeed != 0 && scanOrder != dns.OrderShuffle {
+		return fmt.Errorf("--seed requires --order=shuffle")
+	}
+	if scanWordlist != "" && !scanBrute && scanMode != "custom" {
+		return fmt.Errorf("--wordlist requires --brute or --mode=custom")
+	}
+	if err := validateOutputPaths(scanOutputs, ".json", ".csv", ".txt"); err != nil {
+		return err
+	}
+	if err := validateUnifiedFlag(scanOutputs); err != nil {
+		return err
+	}
+	if err := validateValidateOutputFlag(scanOutputs...); err != nil {
+		return err
+	}
+	if scanSignKey != "" && len(scanOutputs) == 0 {
+		return fmt.Errorf("--sign-key requires --output")
+	}
+	if scanFailures && len(scanOutputs) == 0 {
+		return fmt.Errorf("--include-failures requires --output")
+	}
+	if err := validateEncryptFlags(scanOutputs); err != nil {
+		return err
+	}
+	if scanDBNoSync && scanDB == "" {
+		return fmt.Errorf("--db-no-sync requires --db")
+	}
+	if scanNoNegCache && scanZoneCache != "" {
+		return fmt.Errorf("--zone-cache cannot be used with --no-negative-cache")
+	}
+	if scanNoNegCache && scanPrecheck {
+		return fmt.Errorf("--precheck cannot be used with --no-negative-cache")
+	}
+	if scanZoneTTL < 0 {
+		return fmt.Errorf("--zone-cache-ttl cannot be negative")
+	}
+	if machine && len(scanOutputs) == 0 && scanDB == "" {
+		return fmt.Errorf("--machine requires --output or --db")
+	}
+	if scanConfirmOver < 0 {
+		return fmt.Errorf("--confirm-threshold cannot be negative")
+	}
+	if err := validateRedactFlags(scanRedact, scanOutputs); err != nil {
+		return err
+	}
 	return nil
 }
 
 // validatePingFlags validates ping command flags
 func validatePingFlags() error {
+	if err := validateStreamFlags(); err != nil {
+		return err
+	}
 	if pingFile == "" {
 		return fmt.Errorf("--file required")
 	}
-	if pingMethod != "icmp" && pingMethod != "tcp" {
-		return fmt.Errorf("invalid method: %s (must be icmp or tcp)", pingMethod)
+	if pingMethod != "icmp" && pingMethod != "tcp" && pingMethod != "https" {
+		return fmt.Errorf("invalid method: %s (must be icmp, tcp or https)", pingMethod)
 	}
 	if pingTimeout <= 0 {
 		return fmt.Errorf("--timeout must be positive")
@@ -220,23 +669,162 @@ func validatePingFlags() error {
 	if pingWorkers <= 0 {
 		return fmt.Errorf("--workers must be positive")
 	}
+	switch pingFallback {
+	case "":
+	case "tcp", "https":
+		if pingMethod != "icmp" {
+			return fmt.Errorf("--fallback requires --method=icmp")
+		}
+	default:
+		return fmt.Errorf("invalid --fallback: %s (must be tcp or https)", pingFallback)
+	}
+	if pingDedupe && (pingMethod == "https" || pingFallback == "https") {
+		return fmt.Errorf("--dedupe-by-ip cannot be used with --method=https (certificates and HTTP answers depend on the name)")
+	}
+	if err := (netbind.Binding{SourceIP: pingSourceIP, Interface: pingInterface}).Validate(); err != nil {
+		return err
+	}
+	if err := validateOutputPaths(pingOutputs, ".json", ".csv"); err != nil {
+		return err
+	}
+	if err := validateUnifiedFlag(pingOutputs); err != nil {
+		return err
+	}
+	if err := validateValidateOutputFlag(pingOutputs...); err != nil {
+		return err
+	}
+	if pingSignKey != "" && len(pingOutputs) == 0 {
+		return fmt.Errorf("--sign-key requires --output")
+	}
+	if err := validateEncryptFlags(pingOutputs); err != nil {
+		return err
+	}
+	if machine && len(pingOutputs) == 0 {
+		return fmt.Errorf("--machine requires --output")
+	}
+	if err := validateRedactFlags(pingRedact, pingOutputs); err != nil {
+		return err
+	}
 	return nil
 }
 
+// icmpMethod returns the ping method to use given the outcome of the raw
+// ICMP socket check: the method itself, or the fallback when ICMP is
+// unavailable. MTU probing has no fallback.
+func icmpMethod(method, fallback string, mtu bool, icmpErr error) (string, error) {
+	if icmpErr == nil {
+		return method, nil
+	}
+	if mtu {
+		return "", fmt.Errorf("--mtu: %w", icmpErr)
+	}
+	if fallback == "" {
+		return "", fmt.Errorf("%w (use --method=tcp, or --fallback=tcp to switch automatically)", icmpErr)
+	}
+	return fallback, nil
+}
+
 // validateQueryFlags validates query command flags
 func validateQueryFlags() error {
+	// Aggregate modes size whole MCCs or the whole database
+	aggregate := queryCount || queryDistinct != ""
+
 	// MNC and MCC must be used together (check this first)
-	if (queryMNC > 0 && queryMCC == 0) || (queryMNC == 0 && queryMCC > 0) {
+	if (queryMNC != "" && queryMCC == "") || (queryMNC == "" && queryMCC != "" && !aggregate) {
 		return fmt.Errorf("--mnc and --mcc must be used together")
 	}
+	if err := validateCodeFlags(queryMCC, queryMNC); err != nil {
+		return err
+	}
 
-	hasMNCMCC := queryMNC > 0 && queryMCC > 0
+	hasMNCMCC := queryMNC != "" && queryMCC != ""
 	hasOperator := queryOperator != ""
+	hasTag := queryTag != ""
+
+	if !hasMNCMCC && !hasOperator && !hasTag && !queryPrivate && querySince == "" && !aggregate {
+		return fmt.Errorf("either --mnc/--mcc, --operator, --tag, --private-ips or --since required")
+	}
+	if querySince != "" {
+		if _, err := database.ParseSince(querySince); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	}
+	if hasTag {
+		if err := database.ValidateTag(queryTag); err != nil {
+			return fmt.Errorf("--tag: %w", err)
+		}
+	}
+	if _, err := countries.ParseNameStyle(queryNames); err != nil {
+		return fmt.Errorf("--country-names: %w", err)
+	}
+
+	if queryCount && queryDistinct != "" {
+		return fmt.Errorf("cannot specify both --count and --distinct")
+	}
+	switch queryDistinct {
+	case "", "operators":
+	case "ips":
+		return fmt.Errorf("--distinct=ips: the database does not store IPs (use stats --file on a JSON scan export)")
+	default:
+		return fmt.Errorf("invalid --distinct: %s (must be operators or ips)", queryDistinct)
+	}
+	if aggregate && (queryLimit != 0 || queryOffset != 0 || queryOrderBy != "") {
+		return fmt.Errorf("--limit, --offset and --order-by cannot be used with --count or --distinct")
+	}
+	if queryFile != "" && strings.TrimSpace(queryFile) == "" {
+		return fmt.Errorf("--export-file cannot be empty")
+	}
+	if format := queryExportFormat(); format != "" {
+		if _, err := output.WriterFor(format); err != nil {
+			if queryExport == "" {
+				return fmt.Errorf("--export-file: %w (or set --export)", err)
+			}
+			return fmt.Errorf("--export: %w", err)
+		}
+	} else if queryFile != "" {
+		return fmt.Errorf("--export-file %s has no extension (set --export)", queryFile)
+	}
+	if aggregate && (queryExport != "" || queryFile != "") {
+		return fmt.Errorf("--export cannot be used with --count or --distinct")
+	}
+
+	if queryLimit < 0 {
+		return fmt.Errorf("--limit cannot be negative")
+	}
+	if queryOffset < 0 {
+		return fmt.Errorf("--offset cannot be negative")
+	}
+	if err := database.ValidateOrderBy(queryOrderBy); err != nil {
+		return fmt.Errorf("--order-by: %w", err)
+	}
+
+	return nil
+}
+
+// querySincePoint returns the parsed --since reference point, zero when unset
+func querySincePoint() database.Since {
+	since, _ := database.ParseSince(querySince)
+	return since
+}
 
-	if !hasMNCMCC && !hasOperator {
-		return fmt.Errorf("either --mnc/--mcc or --operator required")
+// queryExportFormat returns the --export format, or the extension of
+// --export-file without it
+func queryExportFormat() string {
+	if queryExport != "" || queryFile == "" {
+		return queryExport
 	}
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(queryFile)), ".")
+}
 
+// validateCodeFlags checks that --mcc and --mnc, where given, have one to
+// three digits
+func validateCodeFlags(mcc, mnc string) error {
+	if mcc != "" && !models.IsCode(mcc) {
+		return fmt.Errorf("invalid --mcc: %s (must be 1 to 3 digits)", mcc)
+	}
+	if mnc != "" && !models.IsCode(mnc) {
+		return fmt.Errorf("invalid --mnc: %s (must be 1 to 3 digits)", mnc)
+	}
 	return nil
 }
 
@@ -248,47 +836,103 @@ func validateStatsFlags() error {
 	if statsFile != "" && statsDB != "" {
 		return fmt.Errorf("cannot specify both --file and --db")
 	}
-	validFormats := map[string]bool{"text": true, "json": true, "csv": true}
+	validFormats := map[string]bool{"text": true, "json": true, "csv": true, "html": true}
 	if !validFormats[statsFormat] {
 		return fmt.Errorf("invalid format: %s (must be text, json, or csv)", statsFormat)
 	}
+	if statsFormat == "html" && !statsMap {
+		return fmt.Errorf("--format=html requires --map")
+	}
+	if statsMap && statsChart != "" {
+		return fmt.Errorf("cannot specify both --map and --chart")
+	}
+	if statsChart != "" && statsChart != "vega" && statsChart != "gnuplot" {
+		return fmt.Errorf("invalid chart: %s (must be vega or gnuplot)", statsChart)
+	}
+	if statsTop < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+	if statsByOp && (statsMap || statsChart != "") {
+		return fmt.Errorf("--by-operator cannot be used with --map or --chart")
+	}
+	if _, err := countries.ParseNameStyle(statsNames); err != nil {
+		return fmt.Errorf("--country-names: %w", err)
+	}
+	return nil
+}
+
+// validateFetchFlags validates fetch-mccmnc command flags
+func validateFetchFlags() error {
+	if fetchFormat != "json" && fetchFormat != "csv" {
+		return fmt.Errorf("invalid format: %s (must be json or csv)", fetchFormat)
+	}
+	filtered := len(fetchCountries) > 0 || fetchStatus != ""
+	if fetchOutput == "" && (filtered || fetchFormat != "json") {
+		return fmt.Errorf("--output required when filtering or using --format=csv")
+	}
 	return nil
 }
 
+// modeSubdomains maps the scan modes to the subdomains they query; custom
+// mode takes --subdomains or a wordlist instead
+var modeSubdomains = map[string][]string{
+	"all":  {"ims", "epdg.epc", "bsf", "gan", "xcap.ims"},
+	"epdg": {"epdg.epc"},
+	"ims":  {"ims"},
+	"bsf":  {"bsf"},
+	"gan":  {"gan"},
+	"xcap": {"xcap.ims"},
+	"ntp":  {"ntp"},
+}
+
 // Scan command implementation
 func runScan(cmd *cobra.Command, args []string) error {
+	startMachine(cmd)
+	started := time.Now()
+
 	// Validate flags
 	if err := validateScanFlags(); err != nil {
 		return err
 	}
 
+	// Ask for the --encrypt passphrase now, not after a long scan
+	passphrase, err := exportPassphrase()
+	if err != nil {
+		return err
+	}
+
 	// Determine subdomains based on mode
-	var subdomains []string
-	switch scanMode {
-	case "all":
-		subdomains = []string{"ims", "epdg.epc", "bsf", "gan", "xcap.ims"}
-	case "epdg":
-		subdomains = []string{"epdg.epc"}
-	case "ims":
-		subdomains = []string{"ims"}
-	case "bsf":
-		subdomains = []string{"bsf"}
-	case "gan":
-		subdomains = []string{"gan"}
-	case "xcap":
-		subdomains = []string{"xcap.ims"}
-	case "custom":
-		subdomains = strings.Split(scanSubdomains, ",")
+	subdomains := modeSubdomains[scanMode]
+	if scanMode == "custom" {
+		if scanSubdomains != "" {
+			subdomains = strings.Split(scanSubdomains, ",")
+		} else {
+			store, err := wordlist.NewStore("")
+			if err != nil {
+				return err
+			}
+			subdomains, err = store.Resolve(scanWordlist)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if !quiet {
-		fmt.Printf("Starting scan with mode=%s, subdomains=%v\n", scanMode, subdomains)
+		switch {
+		case scanRescan:
+			fmt.Printf("Starting rescan of the FQDNs in %s\n", scanDB)
+		case scanTargets != "":
+			fmt.Printf("Starting scan of the FQDNs in %s\n", targetsName())
+		default:
+			fmt.Printf("Starting scan with mode=%s, subdomains=%v\n", scanMode, subdomains)
+		}
 	}
 
 	// Fetch MCC-MNC list
-	f := fetcher.NewFetcher("", ".", 24*time.Hour, verbose)
+	f := fetcher.NewFetcher("", ".", 24*time.Hour)
+	f.UserAgent = userAgent()
 	var entries []models.MCCMNCEntry
-	var err error
 
 	if scanMCCMNCFile != "" {
 		entries, err = f.FetchFromFile(scanMCCMNCFile)
@@ -304,123 +948,427 @@ func runScan(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Loaded %d MCC-MNC entries\n", len(entries))
 	}
 
-	// Configure scanner
-	config := &models.ScanConfig{
-		ParentDomain: "pub.3gppnetwork.org",
-		Subdomains:   subdomains,
-		QueryDelay:   time.Duration(scanDelay) * time.Millisecond,
-		Concurrency:  scanConcurrency,
-		Verbose:      verbose,
+	// Only the operators named by --plmn, listed or not
+	if len(scanPLMNs) > 0 {
+		plmns := make([]dns.PLMN, len(scanPLMNs))
+		for i, id := range scanPLMNs {
+			plmns[i], _ = dns.ParsePLMN(id)
+		}
+		entries = dns.SelectPLMNs(entries, plmns)
+		if !quiet {
+			for _, entry := range entries {
+				name := entry.Operator
+				if name == "" {
+					name = "not in the MCC-MNC list"
+				}
+				fmt.Printf("Selected PLMN %s-%s (%s)\n", entry.MCC, entry.MNC, name)
+			}
+		}
 	}
 
-	scanner := dns.NewScanner(config)
-
-	// Setup progress bar if not quiet/verbose
-	totalQueries := len(entries) * len(subdomains)
-	var bar *progressbar.ProgressBar
-	if !quiet && !verbose {
-		bar = progressbar.NewOptions(totalQueries,
-			progressbar.OptionSetDescription("Scanning DNS"),
-			progressbar.OptionSetWriter(os.Stderr),
-			progressbar.OptionShowCount(),
-			progressbar.OptionShowIts(),
-			progressbar.OptionSetPredictTime(true),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "[green]=[reset]",
-				SaucerHead:    "[green]>[reset]",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}),
-			progressbar.OptionOnCompletion(func() {
-				fmt.Fprintf(os.Stderr, "\n")
-			}),
-		)
-
-		scanner.SetProgressCallback(func(current, total int, found int) {
-			bar.Set(current)
-		})
+	// Apply rate-limit policy and exclusions
+	ratePolicy, err := loadRatePolicy()
+	if err != nil {
+		return err
+	}
+	entries, excluded := ratePolicy.Filter(entries)
+	if !quiet && excluded > 0 {
+		fmt.Printf("Excluded %d entries by policy, %d remaining\n", excluded, len(entries))
 	}
 
-	// Run scan
-	ctx := context.Background()
-	results, err := scanner.Scan(ctx, entries)
+	// Restrict the scan to the engagement scope
+	allEntries := entries
+	engagement, err := loadScope(func() []models.MCCMNCEntry { return allEntries })
 	if err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+		return err
+	}
+	if engagement != nil {
+		entries = engagement.FilterEntries(entries)
+		if !quiet {
+			fmt.Printf("Scope allows %d of %d entries\n", len(entries), len(allEntries))
+		}
 	}
 
-	if !quiet {
-		fmt.Printf("Scan complete! Found %d FQDNs\n", len(results))
+	// Keep this machine's part of the MCC-MNC space
+	runSource := scanMode
+	switch {
+	case scanRescan:
+		runSource = "rescan"
+	case scanTargets != "":
+		runSource = "targets " + targetsName()
+	case len(scanPLMNs) > 0:
+		runSource = fmt.Sprintf("%s plmn %s", scanMode, strings.Join(scanPLMNs, ","))
+	}
+	if scanShard != "" {
+		shard, _ := dns.ParseShard(scanShard)
+		total := len(entries)
+		entries = shard.Filter(entries)
+		runSource = fmt.Sprintf("%s shard %s", runSource, shard)
+		report.Shard = shard.String()
+		if !quiet {
+			fmt.Printf("Shard %s covers %d of %d entries\n", shard, len(entries), total)
+		}
 	}
 
-	// Print to stdout if not quiet
-	if !quiet && scanOutput == "" && scanDB == "" {
-		output.PrintResults(results)
+	// Configure scanner
+	config := &models.ScanConfig{
+		ParentDomain:  "pub.3gppnetwork.org",
+		Subdomains:    subdomains,
+		QueryDelay:    time.Duration(scanDelay) * time.Millisecond,
+		Concurrency:   scanConcurrency,
+		SourceIP:      scanSourceIP,
+		Interface:     scanInterface,
+		MCCRateLimits: ratePolicy.RateLimits(),
+		Order:         scanOrder,
+		Seed:          scanSeed,
+		NoRetry:       scanNoRetry,
+		QueryBudget:   scanQueryBudget,
+
+		IncludeFailures: scanFailures,
 	}
+	config.Resolvers, _ = dns.ParseResolvers(scanResolvers)
 
-	// Save to database if requested
-	if scanDB != "" {
+	// A shuffle is repeatable with the seed it used
+	if config.Order == dns.OrderShuffle {
+		if config.Seed == 0 {
+			config.Seed = time.Now().UnixNano()
+		}
+		report.Seed = config.Seed
 		if !quiet {
-			fmt.Printf("Saving results to database: %s\n", scanDB)
+			fmt.Printf("Shuffling queries with seed %d\n", config.Seed)
 		}
-		db, err := database.NewDB(scanDB)
+	}
+
+	excludeList, err := loadExcludeList()
+	if err != nil {
+		return err
+	}
+	setup := scanSetup{checker: targetChecker(excludeList, engagement)}
+
+	// Live results for serve --stream clients
+	publisher, err := openPublisher()
+	if err != nil {
+		return err
+	}
+	defer closePublisher(publisher)
+	setup.publisher = publisher
+
+	// Negative cache of nonexistent zones
+	var zoneCache *dns.ZoneCache
+	if !scanNoNegCache {
+		zoneCache, err = loadZoneCache()
 		if err != nil {
-			return fmt.Errorf("database error: %w", err)
+			return err
 		}
-		defer db.Close()
+		setup.zoneCache = zoneCache
+	}
 
-		if err := db.InsertResults(results); err != nil {
-			return fmt.Errorf("failed to save results: %w", err)
+	// Off-peak scheduling
+	if scanRunWindow != "" {
+		setup.window, err = schedule.ParseWindow(scanRunWindow)
+		if err != nil {
+			return err
 		}
-		if !quiet {
-			fmt.Printf("Saved %d results to database\n", len(results))
+		if scanStateFile == "" {
+			scanStateFile = "scan-state.jsonl"
 		}
 	}
 
-	// Export to file if requested
-	if scanOutput != "" {
-		if err := exportScanResults(results, scanOutput); err != nil {
-			return fmt.Errorf("export failed: %w", err)
+	// Low-resource nodes keep results on disk as they arrive, so a run
+	// killed for memory or power resumes instead of starting over
+	if lowResource && scanStateFile == "" {
+		scanStateFile = "scan-state.jsonl"
+	}
+
+	// Resumable state
+	var checkpoint *dns.Checkpoint
+	if scanStateFile != "" {
+		fingerprint := dns.ScanFingerprint(runSource, entries, subdomains, scanResolvers)
+		checkpoint, err = dns.OpenCheckpoint(scanStateFile, fingerprint, scanForceResume)
+		if errors.Is(err, dns.ErrCheckpointMismatch) {
+			return fmt.Errorf("%w; remove it to start over, or use --force-resume to keep its completed queries", err)
 		}
-		if !quiet {
-			fmt.Printf("Exported results to: %s\n", scanOutput)
+		if err != nil {
+			return err
 		}
+		defer checkpoint.Close()
+		if !quiet && checkpoint.Completed() > 0 {
+			fmt.Printf("Resuming from %s (%d queries already done)\n", scanStateFile, checkpoint.Completed())
+		}
+		setup.checkpoint = checkpoint
 	}
 
-	return nil
-}
+	scanner := dns.NewScanner(config)
+	configureScanner(scanner, setup)
+
+	// Preview the query budget before committing to a long scan; target
+	// files are only counted as they are read
+	fromTargets := scanTargets != "" || scanRescan
+	if !fromTargets {
+		if err := confirmScanEstimate(config, entries, checkpoint); err != nil {
+			return err
+		}
+	}
 
-// Ping command implementation
-func runPing(cmd *cobra.Command, args []string) error {
-	// Validate flags
-	if err := validatePingFlags(); err != nil {
-		return err
+	ctx := context.Background()
+
+	// First phase: drop entries whose operator zone does not exist. It
+	// runs before any source captures the entries, so every source scans
+	// only the zones that passed.
+	scanDesc := "Scanning DNS"
+	if scanPrecheck {
+		if showProgress() {
+			bar := newScanProgressBar(len(entries), "[1/2] Checking zones")
+			scanner.SetProgressCallback(func(current, total int, found int) {
+				bar.Set(current)
+			})
+		}
+		checked := len(entries)
+		entries = scanner.PrecheckZones(ctx, entries)
+		if !quiet {
+			fmt.Printf("Zone pre-check: %d of %d zones exist, %d queries to send\n",
+				len(entries), checked, len(entries)*len(subdomains))
+		}
+		scanDesc = "[2/2] Scanning DNS"
 	}
 
-	// Read FQDNs from file
-	fqdns, err := readFQDNsFromFile(pingFile)
-	if err != nil {
-		return fmt.Errorf("failed to read FQDNs: %w", err)
+	// Candidate FQDNs instead of the subdomains of every entry; the
+	// entries still bound which zones may be queried
+	var source dns.TargetSource
+	var targetSkips atomic.Int64
+	if fromTargets {
+		fqdns := dns.FQDNSource{
+			ParentDomain: config.ParentDomain,
+			Entries:      entries,
+			Skip: func(fqdn, reason string) {
+				targetSkips.Add(1)
+				slog.Debug("skipped target", "fqdn", fqdn, "reason", reason)
+			},
+		}
+		switch {
+		case scanRescan:
+			fqdns.FQDNs, err = readStoredFQDNs(scanDB)
+		case scanTargets == "-":
+			fqdns.Reader = os.Stdin
+		default:
+			file, openErr := os.Open(scanTargets)
+			if openErr != nil {
+				return fmt.Errorf("failed to open targets: %w", openErr)
+			}
+			defer file.Close()
+			fqdns.Reader = file
+		}
+		if err != nil {
+			return err
+		}
+		source = fqdns
 	}
 
-	if !quiet {
-		fmt.Printf("Pinging %d FQDNs using %s method\n", len(fqdns), pingMethod)
+	if source == nil {
+		source = dns.ListSource{Entries: entries, Subdomains: subdomains, Order: config.Order, Seed: config.Seed}
 	}
 
-	// Configure pinger
+	// Setup progress bar if not quiet/verbose
+	showScanProgress(scanner, source.Len(), scanDesc)
+
+	// Run scan
+	results, err := scanner.ScanTargets(ctx, source)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	totalQueries := source.Len()
+	if totalQueries < 0 {
+		totalQueries = 0
+		for _, n := range scanner.StatusCounts() {
+			totalQueries += n
+		}
+	}
+	if n := targetSkips.Load(); n > 0 && !quiet {
+		fmt.Printf("Skipped %d FQDNs outside the zones of the MCC-MNC list (see --verbose)\n", n)
+	}
+
+	retried, recovered := scanner.RetryStats()
+	if !quiet {
+		fmt.Printf("Scan complete! Found %d FQDNs\n", len(results))
+		if retried > 0 {
+			fmt.Printf("Retried %d queries: %d recovered, %d failed again\n", retried, recovered, retried-recovered)
+		}
+		if summary := stats.FormatStatusCounts(scanner.StatusCounts()); summary != "" {
+			fmt.Printf("Query outcomes: %s\n", summary)
+		}
+	}
+	if verbose {
+		reportTimings(scanner)
+	}
+	cacheSkips, zoneChecks := scanner.CacheStats()
+	if zoneCache != nil {
+		reportZoneCache(scanner, zoneCache)
+	}
+
+	// Second stage: brute-force extra labels inside zones with hits
+	if scanBrute && len(results) > 0 {
+		bruteResults, err := runBruteForce(ctx, config, results, setup)
+		if err != nil {
+			return err
+		}
+		results = append(results, bruteResults...)
+	}
+
+	// A finished scan no longer needs its checkpoint
+	if checkpoint != nil {
+		if err := checkpoint.Remove(); err != nil {
+			slog.Warn("failed to remove checkpoint", "error", err)
+		}
+	}
+
+	reportExcluded(excludeList)
+	reportOutOfScope(engagement)
+
+	// Print to stdout if not quiet
+	if !quiet && len(scanOutputs) == 0 && scanDB == "" {
+		output.PrintResults(results)
+	}
+
+	// Save to database if requested
+	if scanDB != "" {
+		if !quiet {
+			fmt.Printf("Saving results to database: %s\n", scanDB)
+		}
+		db, err := openDB(scanDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+
+		run := &models.Run{Kind: database.RunKindScan, Source: runSource, Started: started}
+		opts := database.InsertOptions{NoSync: scanDBNoSync, Run: run}
+		if lowResource {
+			opts.BatchSize = lowResourceBatchSize
+		}
+		if err := db.InsertResultsBatch(results, opts); err != nil {
+			return fmt.Errorf("failed to save results: %w", err)
+		}
+		if !quiet {
+			fmt.Printf("Saved %d results to database as run %d\n", len(results), run.ID)
+		}
+	}
+
+	// Export to files if requested
+	if len(scanOutputs) > 0 {
+		redactor, err := newRedactor(scanRedact)
+		if err != nil {
+			return err
+		}
+		exported := redactor.DNSResults(results)
+		withFailures := append(slices.Clone(exported), redactor.DNSResults(scanner.Failures())...)
+		if err := exportAll(scanOutputs, func(path string) error {
+			// An FQDN list only names the hits
+			if strings.ToLower(filepath.Ext(path)) == ".txt" {
+				return exportScanResults(exported, path)
+			}
+			return exportScanResults(withFailures, path)
+		}); err != nil {
+			return err
+		}
+		scanOutputs, err = encryptExports(passphrase, scanOutputs)
+		if err != nil {
+			return err
+		}
+		if err := signExports(scanSignKey, scanOutputs); err != nil {
+			return err
+		}
+	}
+
+	report.Summary = map[string]int{"queries": totalQueries, "findings": len(results), "excluded": len(report.Excluded), "out_of_scope": len(report.OutOfScope), "negative_cache_skips": cacheSkips, "zone_checks": zoneChecks, "retried": retried, "recovered": recovered}
+	report.Outcomes = scanner.StatusCounts()
+	setReportOutputs(scanOutputs)
+	report.Database = scanDB
+	return nil
+}
+
+// Ping command implementation
+func runPing(cmd *cobra.Command, args []string) error {
+	startMachine(cmd)
+
+	// Validate flags
+	if err := validatePingFlags(); err != nil {
+		return err
+	}
+
+	// Read FQDNs from file
+	fqdns, err := readFQDNsFromFile(pingFile)
+	if err != nil {
+		return fmt.Errorf("failed to read FQDNs: %w", err)
+	}
+	passphrase, err := exportPassphrase()
+	if err != nil {
+		return err
+	}
+
+	// Without raw sockets every ICMP probe would fail alike
+	if pingMethod == "icmp" || pingMTU {
+		icmpErr := ping.ICMPAvailable()
+		method, err := icmpMethod(pingMethod, pingFallback, pingMTU, icmpErr)
+		if err != nil {
+			return err
+		}
+		if method != pingMethod {
+			slog.Warn("ICMP unavailable, switching method", "method", method, "error", icmpErr)
+			pingMethod = method
+		}
+	}
+
+	if !quiet {
+		fmt.Printf("Pinging %d FQDNs using %s method\n", len(fqdns), pingMethod)
+	}
+
+	// Configure pinger
 	config := &models.PingConfig{
-		Method:   pingMethod,
-		Timeout:  time.Duration(pingTimeout) * time.Millisecond,
-		Workers:  pingWorkers,
-		TCPPorts: []int{443, 4500},
-		Verbose:  verbose,
+		Method:          pingMethod,
+		Timeout:         time.Duration(pingTimeout) * time.Millisecond,
+		Workers:         pingWorkers,
+		TCPPorts:        []int{443, 4500},
+		SourceIP:        pingSourceIP,
+		Interface:       pingInterface,
+		IncludeFailures: pingFailures,
+		ProbeMTU:        pingMTU,
+		DualStack:       pingDual,
+		DedupeByIP:      pingDedupe,
+		UserAgent:       userAgent(),
 	}
 
+	excludeList, err := loadExcludeList()
+	if err != nil {
+		return err
+	}
+	engagement, err := loadScope(func() []models.MCCMNCEntry { return loadMCCMNCList(pingMCCMNC) })
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := openAuditLog("ping")
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
 	pinger := ping.NewPinger(config)
+	pinger.SetExclude(targetChecker(excludeList, engagement))
+	pinger.SetAudit(auditLog)
+
+	// Live results for serve --stream clients
+	publisher, err := openPublisher()
+	if err != nil {
+		return err
+	}
+	defer closePublisher(publisher)
+	if publisher != nil {
+		pinger.SetResultCallback(publisher.PublishPing)
+	}
 
 	// Setup progress bar if not quiet/verbose
 	var bar *progressbar.ProgressBar
-	if !quiet && !verbose {
+	if showProgress() {
 		bar = progressbar.NewOptions(len(fqdns),
 			progressbar.OptionSetDescription(fmt.Sprintf("Pinging (%s)", pingMethod)),
 			progressbar.OptionSetWriter(os.Stderr),
@@ -450,30 +1398,61 @@ func runPing(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("ping failed: %w", err)
 	}
+	if pingDedupe && !quiet {
+		fmt.Printf("Probed %d distinct addresses for %d FQDNs\n", pinger.AddressesProbed(), len(fqdns))
+	}
+
+	successCount := 0
+	for _, r := range results {
+		if r.Success {
+			successCount++
+		}
+	}
+	reportExcluded(excludeList)
+	reportOutOfScope(engagement)
+	if err := closeAuditLog(auditLog); err != nil {
+		return err
+	}
 
 	// Print results
 	if !quiet {
-		output.PrintPingResults(results)
-		successCount := 0
-		for _, r := range results {
-			if r.Success {
-				successCount++
-			}
+		if !pingSummary {
+			output.PrintPingResults(results)
 		}
 		fmt.Printf("\nTotal: %d, Success: %d, Failed: %d\n",
-			len(results), successCount, len(results)-successCount)
+			len(fqdns), successCount, len(fqdns)-successCount)
+		if pingFailures {
+			fmt.Printf("Outcomes: %s\n", stats.FormatStatusCounts(stats.PingStatusCounts(results)))
+		}
+
+		summary := stats.SummarizeLatency(results, len(fqdns), loadCountryNames(pingMCCMNC))
+		fmt.Print("\n" + stats.FormatLatencySummary(summary))
 	}
 
 	// Export if requested
-	if pingOutput != "" {
-		if err := exportPingResults(results, pingOutput); err != nil {
-			return fmt.Errorf("export failed: %w", err)
+	if len(pingOutputs) > 0 {
+		redactor, err := newRedactor(pingRedact)
+		if err != nil {
+			return err
 		}
-		if !quiet {
-			fmt.Printf("Exported results to: %s\n", pingOutput)
+		exported := redactor.PingResults(results)
+		if err := exportAll(pingOutputs, func(path string) error {
+			return exportPingResults(exported, path)
+		}); err != nil {
+			return err
+		}
+		pingOutputs, err = encryptExports(passphrase, pingOutputs)
+		if err != nil {
+			return err
+		}
+		if err := signExports(pingSignKey, pingOutputs); err != nil {
+			return err
 		}
 	}
 
+	report.Summary = map[string]int{"targets": len(fqdns), "findings": successCount, "failed": len(fqdns) - successCount, "excluded": len(report.Excluded), "out_of_scope": len(report.OutOfScope)}
+	report.Outcomes = stats.PingStatusCounts(results)
+	setReportOutputs(pingOutputs)
 	return nil
 }
 
@@ -484,44 +1463,267 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	db, err := database.NewDB(queryDB)
+	db, err := openDB(queryDB)
 	if err != nil {
 		return fmt.Errorf("database error: %w", err)
 	}
 	defer db.Close()
 
-	var fqdns []string
+	if queryCount || queryDistinct != "" {
+		return runQueryAggregate(db)
+	}
 
-	if queryMNC > 0 && queryMCC > 0 {
-		fqdns, err = db.QueryByMNCMCC(queryMNC, queryMCC)
+	// Exports to stdout keep it to the rows alone
+	format := queryExportFormat()
+	toStdout := format != "" && queryFile == ""
+	verbose := !quiet && !toStdout
+
+	var fqdns, operators []string
+	opts := database.QueryOptions{Limit: queryLimit, Offset: queryOffset, OrderBy: queryOrderBy, Subdomain: querySubdomain, Tag: queryTag, PrivateIPs: queryPrivate, Since: querySincePoint()}
+
+	if queryMNC != "" && queryMCC != "" {
+		mnc, mcc := models.NormalizeMNC(queryMNC), models.NormalizeMCC(queryMCC)
+		fqdns, err = db.QueryByMNCMCCPage(mnc, mcc, opts)
 		if err != nil {
 			return fmt.Errorf("query failed: %w", err)
 		}
-		if !quiet {
-			fmt.Printf("Results for MNC=%d, MCC=%d:\n", queryMNC, queryMCC)
+		if operators, err = db.QueryOperatorNames(mnc, mcc); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if verbose {
+			fmt.Printf("Results for MNC=%s, MCC=%s:\n", mnc, mcc)
+			if err := printOperatorDetails(db.QueryOperatorsByMNCMCC(mnc, mcc)); err != nil {
+				return err
+			}
 		}
 	} else if queryOperator != "" {
-		fqdns, err = db.QueryByOperator(queryOperator)
+		fqdns, err = db.QueryByOperatorPage(queryOperator, opts)
 		if err != nil {
 			return fmt.Errorf("query failed: %w", err)
 		}
-		if !quiet {
+		operators = []string{queryOperator}
+		if verbose {
 			fmt.Printf("Results for operator=%s:\n", queryOperator)
+			if err := printOperatorDetails(db.QueryOperatorsByName(queryOperator)); err != nil {
+				return err
+			}
+		}
+	} else if queryTag != "" {
+		fqdns, err = db.QueryByTagPage(queryTag, opts)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if verbose {
+			fmt.Printf("Results for tag=%s:\n", queryTag)
+		}
+	} else if queryPrivate {
+		fqdns, err = db.QueryPrivateIPPage(opts)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if verbose {
+			fmt.Println("FQDNs resolving to private addresses:")
+		}
+	} else {
+		fqdns, err = db.QuerySincePage(opts)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if verbose {
+			fmt.Printf("FQDNs new since %s:\n", querySince)
 		}
 	}
 
-	// Print results
-	for _, fqdn := range fqdns {
-		fmt.Println(fqdn)
+	details, err := db.QueryDetails(fqdns)
+	if err != nil {
+		return err
 	}
 
-	if !quiet {
-		fmt.Printf("\nFound %d FQDNs\n", len(fqdns))
+	// Vendor labels stored by the fingerprint command
+	vendors, err := db.QueryVendors()
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	tags, err := db.FQDNTags()
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	for i := range details {
+		details[i].Vendor = vendors[details[i].FQDN]
+		details[i].Tags = tags[details[i].FQDN]
+	}
+
+	if format != "" {
+		writer, _ := output.WriterFor(format)
+		path := queryFile
+		if toStdout {
+			path = "/dev/stdout"
+		}
+		if err := writer(details, path); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if toStdout {
+			return nil
+		}
+		if verbose {
+			fmt.Printf("Exported %d FQDNs to: %s\n", len(details), queryFile)
+			if querySince != "" {
+				latest, err := db.LatestRunID()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Newest run: %d (next time: --since=run:%d)\n", latest, latest)
+			}
+		}
+	} else {
+		names, _ := countries.ParseNameStyle(queryNames)
+		fmt.Print(formatQueryTable(details, names))
+	}
+
+	if verbose {
+		notes, err := db.QueryNotes("", "")
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if notes = relevantNotes(notes, fqdns, operators); len(notes) > 0 {
+			fmt.Println("\nNotes:")
+			for _, note := range notes {
+				fmt.Println("  " + formatNote(note))
+			}
+		}
+	}
+
+	if verbose {
+		if queryLimit > 0 || queryOffset > 0 {
+			fmt.Printf("\nFound %d FQDNs (offset %d)\n", len(fqdns), queryOffset)
+		} else {
+			fmt.Printf("\nFound %d FQDNs\n", len(fqdns))
+		}
 	}
 
 	return nil
 }
 
+// formatQueryTable renders query rows as an aligned table. The vendor and
+// tags columns are only shown when a row has them. With a name style the
+// country column names the country of the FQDN's MCC, e.g. Germany (262).
+func formatQueryTable(details []models.FQDNDetail, names countries.NameStyle) string {
+	var withVendor, withTags bool
+	for _, d := range details {
+		withVendor = withVendor || d.Vendor != ""
+		withTags = withTags || len(d.Tags) > 0
+	}
+
+	header := []string{"FQDN", "Operator", "Country", "IPs", "Last Seen"}
+	if withVendor {
+		header = append(header, "Vendor")
+	}
+	if withTags {
+		header = append(header, "Tags")
+	}
+	table := [][]string{header}
+	for _, d := range details {
+		country, lastSeen := countries.CodeLabel(d.CountryCode, names), "-"
+		if _, _, mcc, ok := dns.ZoneFromFQDN(d.FQDN, ""); ok && names != "" {
+			if label := countries.MCCLabel(mcc, names); label != mcc {
+				country = label
+			}
+		}
+		if country == "" {
+			country = "-"
+		}
+		if d.LastSeen != nil {
+			lastSeen = d.LastSeen.Format(time.RFC3339)
+		}
+		ips := strings.Join(d.IPs, ",")
+		if ips == "" {
+			ips = "-"
+		}
+		row := []string{d.FQDN, d.Operator, country, ips, lastSeen}
+		if withVendor {
+			row = append(row, d.Vendor)
+		}
+		if withTags {
+			row = append(row, strings.Join(d.Tags, ","))
+		}
+		table = append(table, row)
+	}
+
+	// Pad by runes, as local country names are not ASCII
+	widths := make([]int, len(header))
+	for _, row := range table {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	var sb strings.Builder
+	for _, row := range table {
+		line := ""
+		for i, cell := range row {
+			line += cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)
+		}
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// printOperatorDetails prints the stored country, brand and status of the
+// operators a query covers
+func printOperatorDetails(operators []models.MCCMNCEntry, err error) error {
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	for _, op := range operators {
+		fields := []string{fmt.Sprintf("%s (MCC %s, MNC %s)", op.Operator, op.MCC, op.MNC)}
+		if op.Brand != "" && op.Brand != op.Operator {
+			fields = append(fields, "brand "+op.Brand)
+		}
+		switch {
+		case op.CountryName != "" && op.CountryCode != "":
+			fields = append(fields, fmt.Sprintf("%s (%s)", op.CountryName, op.CountryCode))
+		case op.CountryName != "" || op.CountryCode != "":
+			fields = append(fields, op.CountryName+op.CountryCode)
+		}
+		if op.Status != "" {
+			fields = append(fields, op.Status)
+		}
+		fmt.Println("  " + strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// runQueryAggregate answers --count and --distinct with aggregate SQL
+func runQueryAggregate(db *database.DB) error {
+	filter := database.FQDNFilter{MCC: models.NormalizeMCC(queryMCC), MNC: models.NormalizeMNC(queryMNC), Operator: queryOperator, Subdomain: querySubdomain, Tag: queryTag, PrivateIPs: queryPrivate, Since: querySincePoint()}
+
+	if queryCount {
+		count, err := db.CountFQDNs(filter)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		fmt.Println(count)
+		return nil
+	}
+
+	counts, err := db.CountByOperator(filter)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	operators := make([]string, 0, len(counts))
+	for operator := range counts {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+	for _, operator := range operators {
+		fmt.Printf("%s\t%d\n", operator, counts[operator])
+	}
+	if !quiet {
+		fmt.Printf("\nFound %d operators\n", len(operators))
+	}
+	return nil
+}
+
 // Stats command implementation
 func runStats(cmd *cobra.Command, args []string) error {
 	// Validate flags
@@ -531,15 +1733,23 @@ func runStats(cmd *cobra.Command, args []string) error {
 
 	analyzer := stats.NewAnalyzer()
 	var st *models.Stats
+	var series []stats.SeriesPoint
 	var err error
 
-	if statsFile != "" {
+	if statsFile != "" && strings.EqualFold(filepath.Ext(statsFile), ".json") {
+		results, err := stats.LoadResults(statsFile)
+		if err != nil {
+			return fmt.Errorf("analysis failed: %w", err)
+		}
+		st = analyzer.AnalyzeResults(results)
+		series = stats.TimeSeries(results)
+	} else if statsFile != "" {
 		st, err = analyzer.AnalyzeFile(statsFile)
 		if err != nil {
 			return fmt.Errorf("analysis failed: %w", err)
 		}
 	} else if statsDB != "" {
-		db, err := database.NewDB(statsDB)
+		db, err := openDB(statsDB)
 		if err != nil {
 			return fmt.Errorf("database error: %w", err)
 		}
@@ -551,13 +1761,63 @@ func runStats(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Country counts are stored with the results of this version; older
+	// data derives them from MCCs via the MCC-MNC list, as FQDN files do
+	// operators from the networks of their FQDNs
+	if len(st.CountryCounts) == 0 || len(st.OperatorCounts) == 0 {
+		entries := loadMCCMNCList(statsMCCMNC)
+		if entries == nil && len(st.CountryCounts) == 0 && statsMap {
+			return fmt.Errorf("--map needs an MCC-MNC list: run fetch-mccmnc or pass --mccmnc-file")
+		}
+		if entries != nil && len(st.CountryCounts) == 0 {
+			st.CountryCounts = stats.CountryCounts(st.MCCDistribution, stats.MCCCountries(entries))
+		}
+		if entries != nil && len(st.OperatorCounts) == 0 {
+			st.OperatorCounts, st.OperatorSubdomainCounts = stats.OperatorCounts(st.SubdomainNetworkCounts, stats.NetworkOperators(entries))
+			for operator := range st.OperatorCounts {
+				if operator != "" {
+					st.UniqueOperators++
+				}
+			}
+		}
+	}
+
+	if statsMap {
+		if statsFormat == "html" {
+			page, err := stats.WorldMapHTML(st.CountryCounts)
+			if err != nil {
+				return fmt.Errorf("map generation failed: %w", err)
+			}
+			fmt.Print(page)
+		} else {
+			fmt.Print(stats.FormatWorldMap(st.CountryCounts))
+		}
+		return nil
+	}
+
 	// Output stats
+	switch statsChart {
+	case "vega":
+		spec, err := stats.VegaLiteSpec(st, series)
+		if err != nil {
+			return fmt.Errorf("chart generation failed: %w", err)
+		}
+		fmt.Println(string(spec))
+		return nil
+	case "gnuplot":
+		fmt.Print(stats.GnuplotScript(st, series))
+		return nil
+	}
+
 	if statsFormat == "json" {
 		if err := output.ExportJSON(st, "/dev/stdout"); err != nil {
 			return fmt.Errorf("JSON export failed: %w", err)
 		}
+	} else if statsByOp {
+		fmt.Print(stats.FormatOperatorStats(st, statsTop))
 	} else {
-		fmt.Print(stats.FormatStats(st))
+		names, _ := countries.ParseNameStyle(statsNames)
+		fmt.Print(stats.FormatStatsNames(st, statsTop, names))
 	}
 
 	return nil
@@ -565,11 +1825,29 @@ func runStats(cmd *cobra.Command, args []string) error {
 
 // Fetch MCC-MNC command implementation
 func runFetchMCCMNC(cmd *cobra.Command, args []string) error {
+	if err := validateFetchFlags(); err != nil {
+		return err
+	}
+
 	if !quiet {
 		fmt.Println("Fetching MCC-MNC list from GitHub...")
 	}
 
-	f := fetcher.NewFetcher("", ".", 0, verbose) // No cache TTL for forced fetch
+	f := fetcher.NewFetcher("", ".", 0) // No cache TTL for forced fetch
+	f.UserAgent = userAgent()
+
+	// Keep the previous copy around for comparison
+	var previous []models.MCCMNCEntry
+	if fetchDiff {
+		cachePath := filepath.Join(f.CacheDir, fetcher.CacheFileName)
+		if _, err := os.Stat(cachePath); err == nil {
+			previous, err = f.FetchFromFile(cachePath)
+			if err != nil {
+				return fmt.Errorf("failed to read cached list: %w", err)
+			}
+		}
+	}
+
 	entries, err := f.Fetch()
 	if err != nil {
 		return fmt.Errorf("fetch failed: %w", err)
@@ -580,17 +1858,617 @@ func runFetchMCCMNC(cmd *cobra.Command, args []string) error {
 		fmt.Println("Saved to: mcc-mnc-list.json")
 	}
 
+	if problems := fetcher.Validate(entries); len(problems) > 0 {
+		slog.Warn("malformed or duplicate MCC-MNC entries", "count", len(problems))
+		for _, p := range problems {
+			slog.Debug("invalid MCC-MNC entry", "problem", p)
+		}
+	}
+
+	if fetchDiff {
+		if previous == nil {
+			fmt.Println("No cached list to compare against")
+		} else {
+			fmt.Print(fetcher.FormatDiff(fetcher.DiffEntries(previous, entries)))
+		}
+	}
+
+	if fetchOutput == "" {
+		return nil
+	}
+
+	filter := fetcher.Filter{Countries: fetchCountries, Status: fetchStatus}
+	selected := filter.Apply(entries)
+
+	if fetchFormat == "csv" {
+		err = output.ExportMCCMNCCSV(selected, fetchOutput)
+	} else {
+		err = output.ExportJSON(selected, fetchOutput)
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	if !quiet {
+		fmt.Printf("Wrote %d entries to: %s\n", len(selected), fetchOutput)
+	}
+
 	return nil
 }
 
 // Helper functions
 
+// userAgent identifies the scanner honestly in outgoing HTTP requests
+func userAgent() string {
+	return fmt.Sprintf("3gpp-scanner/%s (+https://github.com/asnd/sec100)", version)
+}
+
+// showScanProgress draws a progress bar for a scan (see showProgress), and
+// announces its retry pass with a bar of its own
+func showScanProgress(scanner *dns.Scanner, total int, description string) {
+	if quiet {
+		return
+	}
+	var bar *progressbar.ProgressBar
+	if showProgress() {
+		bar = newScanProgressBar(total, description)
+		scanner.SetProgressCallback(func(current, total int, found int) {
+			bar.Set(current)
+		})
+	}
+	scanner.SetRetryCallback(func(queries, concurrency int) {
+		fmt.Printf("Retrying %d queries that timed out or failed with SERVFAIL (concurrency %d)\n", queries, concurrency)
+		if bar != nil {
+			bar = newScanProgressBar(queries, "Retrying")
+		}
+	})
+}
+
+// newScanProgressBar creates the progress bar used for DNS scan stages
+func newScanProgressBar(total int, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions(total,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetPredictTime(true),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[green]=[reset]",
+			SaucerHead:    "[green]>[reset]",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprintf(os.Stderr, "\n")
+		}),
+	)
+}
+
+// confirmScanEstimate prints the estimated queries and duration of a scan
+// and asks to go on when it runs longer than --confirm-threshold
+func confirmScanEstimate(config *models.ScanConfig, entries []models.MCCMNCEntry, checkpoint *dns.Checkpoint) error {
+	skip := 0
+	if checkpoint != nil {
+		skip = checkpoint.Completed()
+	}
+	estimate := dns.EstimateScan(config, entries, skip)
+	if !quiet {
+		fmt.Printf("Estimated %d queries, ~%s (bounded by %s)\n",
+			estimate.TotalQueries, estimate.Duration.Round(time.Second), estimate.Bottleneck)
+		if scanPrecheck {
+			fmt.Printf("Upper bound: --precheck first sends %d zone queries and skips zones that do not exist\n", len(entries))
+		}
+	}
+	if !scanYes && scanConfirmOver > 0 && estimate.Duration > scanConfirmOver {
+		ok, err := confirm(fmt.Sprintf("Estimated duration %s exceeds %s. Continue?",
+			estimate.Duration.Round(time.Minute), scanConfirmOver))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("scan aborted")
+		}
+	}
+	return nil
+}
+
+// targetsName names the --targets file in messages and run records
+func targetsName() string {
+	if scanTargets == "-" {
+		return "stdin"
+	}
+	if abs, err := filepath.Abs(scanTargets); err == nil {
+		return abs
+	}
+	return scanTargets
+}
+
+// readStoredFQDNs reads every FQDN of a database for --rescan
+func readStoredFQDNs(dbPath string) ([]string, error) {
+	db, err := openDB(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+	return db.GetAllFQDNs()
+}
+
+// scanSetup holds what every stage of a scan applies to its scanner
+type scanSetup struct {
+	checker    exclude.Checker
+	publisher  *server.Publisher
+	zoneCache  *dns.ZoneCache
+	window     *schedule.Window
+	checkpoint *dns.Checkpoint
+}
+
+// configureScanner applies the exclusions, live publishing, negative
+// cache, run window and checkpoint of a scan to the scanner of one stage
+func configureScanner(scanner *dns.Scanner, setup scanSetup) {
+	scanner.SetExclude(setup.checker)
+	if setup.publisher != nil {
+		scanner.SetResultCallback(setup.publisher.PublishDNS)
+	}
+	if setup.zoneCache != nil {
+		scanner.SetZoneCache(setup.zoneCache)
+	}
+	if window := setup.window; window != nil {
+		scanner.SetRunWindow(window, func(resumeAt time.Time) {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "\nOutside run window %s, pausing until %s\n",
+					window, resumeAt.Format("2006-01-02 15:04"))
+			}
+		})
+	}
+	if setup.checkpoint != nil {
+		scanner.SetCheckpoint(setup.checkpoint)
+	}
+}
+
+// runBruteForce queries wordlist labels inside every zone that produced a
+// hit in the first stage, so effort is concentrated where infrastructure exists
+func runBruteForce(ctx context.Context, base *models.ScanConfig, hits []models.DNSResult, setup scanSetup) ([]models.DNSResult, error) {
+	labels := wordlist.Default()
+	if scanWordlist != "" {
+		store, err := wordlist.NewStore("")
+		if err != nil {
+			return nil, err
+		}
+		labels, err = store.Resolve(scanWordlist)
+		if err != nil {
+			return nil, err
+		}
+	}
+	labels = wordlist.Exclude(labels, base.Subdomains)
+
+	targets := dns.HitEntries(hits)
+	if len(labels) == 0 || len(targets) == 0 {
+		return nil, nil
+	}
+
+	if !quiet {
+		fmt.Printf("Brute-forcing %d labels in %d zones with hits\n", len(labels), len(targets))
+	}
+
+	config := *base
+	config.Subdomains = labels
+	scanner := dns.NewScanner(&config)
+	configureScanner(scanner, setup)
+
+	showScanProgress(scanner, len(labels)*len(targets), "Brute-forcing labels")
+
+	found, err := scanner.Scan(ctx, targets)
+	if err != nil {
+		return nil, fmt.Errorf("brute-force stage failed: %w", err)
+	}
+
+	// Both stages restore every result of a shared checkpoint
+	known := make(map[string]bool, len(hits))
+	for _, hit := range hits {
+		known[hit.FQDN] = true
+	}
+	var results []models.DNSResult
+	for _, result := range found {
+		if !known[result.FQDN] {
+			results = append(results, result)
+		}
+	}
+
+	if !quiet {
+		fmt.Printf("Brute-force stage found %d additional FQDNs\n", len(results))
+	}
+	return results, nil
+}
+
+// addExcludeFlag adds --exclude-file to a command that contacts targets
+func addExcludeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&excludeFile, "exclude-file", "", "File of FQDNs, *.domains, IPs and CIDRs that must never be queried or probed")
+}
+
+// loadExcludeList reads --exclude-file; without it nothing is excluded
+func loadExcludeList() (*exclude.List, error) {
+	if excludeFile == "" {
+		return nil, nil
+	}
+	list, err := exclude.Load(excludeFile)
+	if err != nil {
+		return nil, err
+	}
+	if !quiet {
+		fmt.Printf("Loaded %d exclusions from %s\n", list.Len(), excludeFile)
+	}
+	return list, nil
+}
+
+// reportExcluded prints and records the targets an exclusion list kept
+// out of the run
+func reportExcluded(list *exclude.List) {
+	skipped := list.Skipped()
+	report.Excluded = skipped
+	if !quiet && len(skipped) > 0 {
+		fmt.Printf("Skipped %d excluded targets (--exclude-file)\n", len(skipped))
+	}
+	for _, skip := range skipped {
+		slog.Debug("excluded target", "target", skip.Target, "rule", skip.Rule)
+	}
+}
+
+// addScopeFlag adds --scope to a command that contacts targets
+func addScopeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&scopeFile, "scope", "", "Engagement scope file (YAML) with the MCCs, PLMNs, operators and CIDRs that may be contacted")
+}
+
+// loadScope reads --scope; without it every target is in scope. The
+// MCC-MNC list is only fetched when the scope names operators.
+func loadScope(mccmnc func() []models.MCCMNCEntry) (*scope.Scope, error) {
+	if scopeFile == "" {
+		return nil, nil
+	}
+	engagement, err := scope.Load(scopeFile)
+	if err != nil {
+		return nil, err
+	}
+	if engagement.NeedsOperators() {
+		entries := mccmnc()
+		if entries == nil {
+			return nil, fmt.Errorf("--scope lists operators, but no MCC-MNC list is available to resolve them (run a scan first to cache it)")
+		}
+		engagement.SetOperators(entries)
+	}
+
+	report.Scope = engagement.Name
+	if !quiet {
+		name := engagement.Name
+		if name == "" {
+			name = scopeFile
+		}
+		fmt.Printf("Enforcing scope %q: %s\n", name, engagement.Summary())
+	}
+	return engagement, nil
+}
+
+// targetChecker combines the exclusion list and the engagement scope;
+// it is nil when neither is in use
+func targetChecker(list *exclude.List, engagement *scope.Scope) exclude.Checker {
+	var chain exclude.Chain
+	if list != nil {
+		chain = append(chain, list)
+	}
+	if engagement != nil {
+		chain = append(chain, engagement)
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain
+}
+
+// reportOutOfScope prints and records the targets the engagement scope
+// kept out of the run
+func reportOutOfScope(engagement *scope.Scope) {
+	skipped := engagement.Skipped()
+	report.OutOfScope = skipped
+	if !quiet && len(skipped) > 0 {
+		fmt.Printf("Skipped %d out-of-scope targets (--scope)\n", len(skipped))
+	}
+	for _, skip := range skipped {
+		slog.Debug("out-of-scope target", "target", skip.Target, "reason", skip.Rule)
+	}
+}
+
+// addAuditFlag adds --audit-log to a command that sends probes
+func addAuditFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&auditLogFile, "audit-log", "", "Append every probe sent (time, target, probe, source IP, result) to this JSON lines file")
+}
+
+// openAuditLog opens --audit-log; without it nothing is recorded
+func openAuditLog(command string) (*audit.Log, error) {
+	if auditLogFile == "" {
+		return nil, nil
+	}
+	return audit.Open(auditLogFile, command)
+}
+
+// closeAuditLog flushes the audit log once all probes are done
+func closeAuditLog(log *audit.Log) error {
+	if log == nil {
+		return nil
+	}
+	if err := log.Close(); err != nil {
+		return err
+	}
+	report.AuditLog = auditLogFile
+	if !quiet {
+		fmt.Printf("Recorded %d probes in audit log: %s\n", log.Len(), auditLogFile)
+	}
+	return nil
+}
+
+// loadRatePolicy builds the scan rate policy from --rate-policy and the exclusion flags
+func loadRatePolicy() (*policy.RatePolicy, error) {
+	ratePolicy := policy.NewRatePolicy()
+	if scanRatePolicy != "" {
+		var err error
+		ratePolicy, err = policy.LoadRatePolicy(scanRatePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ratePolicy.ExcludeMCCs(scanExcludeMCC)
+	ratePolicy.ExcludeCountries(scanExcludeCC)
+	return ratePolicy, nil
+}
+
+// addRedactFlags registers the redaction tuning flags shared by scan and ping
+func addRedactFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&redactMethod, "redact-method", redact.MethodHash, "IP redaction method: hash or truncate (/24, /48)")
+	cmd.Flags().StringVar(&redactSalt, "redact-salt", "", "Key for redaction hashes, to keep pseudonyms stable across exports (default: random per run)")
+}
+
+// validateRedactFlags checks a --redact value and the shared redaction flags
+func validateRedactFlags(fields []string, outputPaths []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(outputPaths) == 0 {
+		return fmt.Errorf("--redact requires --output")
+	}
+	if _, err := redact.ParseFields(fields); err != nil {
+		return err
+	}
+	if redactMethod != redact.MethodHash && redactMethod != redact.MethodTruncate {
+		return fmt.Errorf("invalid redact method: %s (must be hash or truncate)", redactMethod)
+	}
+	return nil
+}
+
+// newRedactor builds the redactor for an export; with no fields selected it
+// returns a nil redactor, which passes results through unchanged
+func newRedactor(fields []string) (*redact.Redactor, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	opts, err := redact.ParseFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	opts.Method = redactMethod
+	opts.Salt = redactSalt
+	return redact.New(opts)
+}
+
+// loadZoneCache opens the --zone-cache file, or an empty cache for this run
+func loadZoneCache() (*dns.ZoneCache, error) {
+	if scanZoneCache == "" {
+		return dns.NewZoneCache(), nil
+	}
+	cache, err := dns.LoadZoneCache(scanZoneCache, scanZoneTTL)
+	if err != nil {
+		return nil, err
+	}
+	if existing, nonexistent := cache.Len(); !quiet && existing+nonexistent > 0 {
+		fmt.Printf("Loaded %d known zones from %s (%d nonexistent)\n", existing+nonexistent, scanZoneCache, nonexistent)
+	}
+	return cache, nil
+}
+
+// reportZoneCache prints what the negative cache saved and writes the
+// --zone-cache file
+func reportZoneCache(scanner *dns.Scanner, cache *dns.ZoneCache) {
+	skipped, checks := scanner.CacheStats()
+	_, nonexistent := cache.Len()
+	if !quiet && (skipped > 0 || checks > 0) {
+		fmt.Printf("Negative cache: skipped %d queries, %d zones known not to exist (%d zone checks)\n", skipped, nonexistent, checks)
+	}
+	if scanZoneCache != "" {
+		if err := cache.Save(scanZoneCache); err != nil {
+			slog.Warn("failed to save zone cache", "error", err)
+		}
+	}
+}
+
+// reportTimings prints the slowest operator zones and the query times of
+// each resolver, to show what slowed a scan down
+func reportTimings(scanner *dns.Scanner) {
+	if n := scanner.BudgetOverruns(); n > 0 {
+		fmt.Printf("%d queries ran out of the %s query budget\n", n, scanQueryBudget)
+	}
+	printTimings := func(title string, timings []dns.QueryTiming) {
+		if len(timings) == 0 {
+			return
+		}
+		fmt.Println(title)
+		for _, t := range timings {
+			fmt.Printf("  %-45s %6d queries  mean %8s  max %8s  %d failed\n",
+				t.Name, t.Queries, t.Mean().Round(time.Millisecond), t.Max.Round(time.Millisecond), t.Failures)
+		}
+	}
+	printTimings("Slowest zones:", scanner.SlowestZones(10))
+	printTimings("Resolvers:", scanner.ResolverTimings())
+}
+
+// validateOutputFlags validates the global output flags
+func validateOutputFlags() error {
+	if quiet && verbose {
+		return fmt.Errorf("--quiet and --verbose are mutually exclusive")
+	}
+	return nil
+}
+
+// setupLogging routes log records to stderr at the level selected by
+// --verbose and --quiet
+func setupLogging() {
+	logging.Setup(os.Stderr, logging.Level(verbose, quiet), jsonLogs)
+}
+
+// validateDBFlags validates the global database connection flags
+func validateDBFlags() error {
+	if err := database.ValidateJournalMode(dbJournalMode); err != nil {
+		return fmt.Errorf("--db-journal-mode: %w", err)
+	}
+	if dbBusyTimeout < 0 {
+		return fmt.Errorf("--db-busy-timeout cannot be negative")
+	}
+	return nil
+}
+
+// dbOptions returns the connection options selected by the global flags
+func dbOptions() []database.Option {
+	return []database.Option{
+		database.WithJournalMode(dbJournalMode),
+		database.WithBusyTimeout(dbBusyTimeout),
+	}
+}
+
+// openDB opens a database with the global connection flags
+func openDB(path string) (*database.DB, error) {
+	if err := validateDBFlags(); err != nil {
+		return nil, err
+	}
+	if encrypted, _ := crypt.IsEncrypted(path); encrypted {
+		return nil, fmt.Errorf("%s is encrypted (decrypt it first)", path)
+	}
+	return database.NewDB(path, dbOptions()...)
+}
+
+// loadMCCMNCList reads an MCC-MNC list file, falling back to the
+// fetch-mccmnc cache. Country data is optional for most commands, so a
+// missing or unreadable list yields nil.
+func loadMCCMNCList(path string) []models.MCCMNCEntry {
+	if path == "" {
+		path = fetcher.CacheFileName
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+	}
+
+	entries, err := fetcher.NewFetcher("", ".", 0).FetchFromFile(path)
+	if err != nil {
+		slog.Warn("country data unavailable", "error", err)
+		return nil
+	}
+	return entries
+}
+
+// loadCountryNames maps zero-padded MCCs to country names from an MCC-MNC
+// list (see loadMCCMNCList)
+func loadCountryNames(path string) map[string]string {
+	entries := loadMCCMNCList(path)
+	if entries == nil {
+		return nil
+	}
+
+	countries := make(map[string]string)
+	for _, entry := range entries {
+		mcc, err := strconv.Atoi(entry.MCC)
+		if err != nil || entry.CountryName == "" {
+			continue
+		}
+		countries[fmt.Sprintf("%03d", mcc)] = entry.CountryName
+	}
+	return countries
+}
+
+// validateOutputPaths checks that every --output path has one of the
+// supported extensions and is given only once
+func validateOutputPaths(paths []string, exts ...string) error {
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "" {
+			return fmt.Errorf("--output cannot be empty")
+		}
+		if !slices.Contains(exts, strings.ToLower(filepath.Ext(path))) {
+			return fmt.Errorf("unsupported format for %s (use %s)", path, formatExtList(exts))
+		}
+		clean := filepath.Clean(path)
+		if seen[clean] {
+			return fmt.Errorf("--output %s given more than once", path)
+		}
+		seen[clean] = true
+	}
+	return nil
+}
+
+// formatExtList renders extensions as ".json, .csv, or .txt"
+func formatExtList(exts []string) string {
+	switch len(exts) {
+	case 1:
+		return exts[0]
+	case 2:
+		return exts[0] + " or " + exts[1]
+	default:
+		return strings.Join(exts[:len(exts)-1], ", ") + ", or " + exts[len(exts)-1]
+	}
+}
+
+// exportAll fans results out to every --output path at once. Each file is
+// written independently, so one failing destination does not stop the
+// others; all failures are reported together.
+func exportAll(paths []string, export func(path string) error) error {
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := export(path); err != nil {
+				errs[i] = fmt.Errorf("export to %s failed: %w", path, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, path := range paths {
+		if errs[i] == nil && !quiet {
+			fmt.Printf("Exported results to: %s\n", path)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setReportOutputs records the --output files in the --machine report: the
+// first under results, and all of them under outputs when there are several
+func setReportOutputs(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	report.Results = paths[0]
+	if len(paths) > 1 {
+		report.Outputs = paths
+	}
+}
+
 func exportScanResults(results []models.DNSResult, filePath string) error {
+	if unifiedOutput {
+		return exportUnifiedResults(models.Results(results), filePath)
+	}
+	results = exportTimes(results, func(r *models.DNSResult) *time.Time { return &r.Timestamp })
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".json":
-		return output.ExportJSON(results, filePath)
+		return exportJSON(results, filePath, schema.DNSResult)
 	case ".csv":
 		return output.ExportResultsCSV(results, filePath)
 	case ".txt":
@@ -601,11 +2479,15 @@ func exportScanResults(results []models.DNSResult, filePath string) error {
 }
 
 func exportPingResults(results []models.PingResult, filePath string) error {
+	if unifiedOutput {
+		return exportUnifiedResults(models.Results(results), filePath)
+	}
+	results = exportTimes(results, func(r *models.PingResult) *time.Time { return &r.Timestamp })
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".json":
-		return output.ExportJSON(results, filePath)
+		return exportJSON(results, filePath, schema.PingResult)
 	case ".csv":
 		return output.ExportPingResultsCSV(results, filePath)
 	default:
@@ -613,6 +2495,71 @@ func exportPingResults(results []models.PingResult, filePath string) error {
 	}
 }
 
+// addUnifiedFlag adds --unified to a command exporting results
+func addUnifiedFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&unifiedOutput, "unified", false, "Write --output files in the common result envelope (target, type, status, latency, metadata)")
+}
+
+// validateUnifiedFlag checks that --unified has JSON or CSV files to write
+func validateUnifiedFlag(paths []string) error {
+	if !unifiedOutput {
+		return nil
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("--unified requires --output")
+	}
+	for _, path := range paths {
+		if strings.ToLower(filepath.Ext(path)) == ".txt" {
+			return fmt.Errorf("--unified cannot write %s (use .json or .csv)", path)
+		}
+	}
+	return nil
+}
+
+// exportUnifiedResults exports results in the common envelope, the same
+// columns for every result type
+func exportUnifiedResults(results []models.Result, filePath string) error {
+	results = exportTimes(results, func(r *models.Result) *time.Time { return &r.Timestamp })
+	if strings.ToLower(filepath.Ext(filePath)) == ".csv" {
+		return output.ExportGenericCSV(results, filePath)
+	}
+	return exportJSON(results, filePath, schema.Result)
+}
+
+// exportTimes returns a copy of results with their timestamps in UTC when
+// --utc is set. Exports write RFC 3339 timestamps with the zone offset, so
+// they parse back to the same instant either way; --utc makes files from
+// machines in different zones compare as text.
+func exportTimes[T any](results []T, timestamp func(*T) *time.Time) []T {
+	if !utcTimes {
+		return results
+	}
+	converted := make([]T, len(results))
+	copy(converted, results)
+	for i := range converted {
+		t := timestamp(&converted[i])
+		*t = t.UTC()
+	}
+	return converted
+}
+
+// confirm asks a yes/no question on the terminal. Non-interactive sessions
+// cannot answer, so they fail with a hint to pass --yes.
+func confirm(prompt string) (bool, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("%s (stdin is not a terminal; use --yes to proceed)", prompt)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, nil
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
 func readFQDNsFromFile(filePath string) ([]string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -1,7 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
+
+	"3gpp-scanner/internal/countries"
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/monitor"
+	"3gpp-scanner/internal/schema"
+
+	"github.com/spf13/cobra"
 )
 
 // Test Scan Flag Validations
@@ -66,6 +83,16 @@ func TestValidateScanFlags(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "valid ntp mode",
+			setupFlags: func() {
+				scanMode = "ntp"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+			},
+			expectError: false,
+		},
 		{
 			name: "valid custom mode with subdomains",
 			setupFlags: func() {
@@ -76,96 +103,2802 @@ func TestValidateScanFlags(t *testing.T) {
 			},
 			expectError: false,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.setupFlags()
-			err := validateScanFlags()
-
-			if tt.expectError && err == nil {
-				t.Errorf("expected error but got none")
-			}
-			if !tt.expectError && err != nil {
-				t.Errorf("unexpected error: %v", err)
-			}
-			if tt.expectError && err != nil && tt.errorMsg != "" {
-				if !contains(err.Error(), tt.errorMsg) {
-					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
-				}
-			}
-		})
-	}
-}
-
-// Test Ping Flag Validations
-func TestValidatePingFlags(t *testing.T) {
-	tests := []struct {
-		name        string
-		setupFlags  func()
-		expectError bool
-		errorMsg    string
-	}{
 		{
-			name: "missing file",
+			name: "invalid source ip",
 			setupFlags: func() {
-				pingFile = ""
-				pingMethod = "icmp"
-				pingTimeout = 300
-				pingWorkers = 10
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanSourceIP = "not-an-ip"
 			},
 			expectError: true,
-			errorMsg:    "--file required",
+			errorMsg:    "invalid source IP",
 		},
 		{
-			name: "invalid method",
+			name: "source ip and interface together",
 			setupFlags: func() {
-				pingFile = "test.txt"
-				pingMethod = "invalid"
-				pingTimeout = 300
-				pingWorkers = 10
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanSourceIP = "192.0.2.10"
+				scanInterface = "eth0"
 			},
 			expectError: true,
-			errorMsg:    "invalid method",
+			errorMsg:    "mutually exclusive",
 		},
 		{
-			name: "zero timeout",
+			name: "redact without output",
 			setupFlags: func() {
-				pingFile = "test.txt"
-				pingMethod = "tcp"
-				pingTimeout = 0
-				pingWorkers = 10
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanRedact = []string{"ips"}
 			},
 			expectError: true,
-			errorMsg:    "--timeout must be positive",
+			errorMsg:    "--redact requires --output",
 		},
 		{
-			name: "negative workers",
+			name: "invalid redact field",
 			setupFlags: func() {
-				pingFile = "test.txt"
-				pingMethod = "icmp"
-				pingTimeout = 300
-				pingWorkers = -5
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json"}
+				scanRedact = []string{"fqdns"}
 			},
 			expectError: true,
-			errorMsg:    "--workers must be positive",
+			errorMsg:    "invalid redact field",
 		},
 		{
-			name: "valid tcp ping",
+			name: "valid redact",
 			setupFlags: func() {
-				pingFile = "test.txt"
-				pingMethod = "tcp"
-				pingTimeout = 300
-				pingWorkers = 10
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json"}
+				scanRedact = []string{"ips", "operators"}
+			},
+			expectError: false,
+		},
+		{
+			name: "include failures without output",
+			setupFlags: func() {
+				scanMode = "epdg"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanFailures = true
+			},
+			expectError: true,
+			errorMsg:    "--include-failures requires --output",
+		},
+		{
+			name: "machine without output",
+			setupFlags: func() {
+				scanMode = "epdg"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				machine = true
+			},
+			expectError: true,
+			errorMsg:    "--machine requires --output or --db",
+		},
+		{
+			name: "machine with database",
+			setupFlags: func() {
+				scanMode = "epdg"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanDB = "database.db"
+				machine = true
+			},
+			expectError: false,
+		},
+		{
+			name: "no sync without database",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanDBNoSync = true
+			},
+			expectError: true,
+			errorMsg:    "--db-no-sync requires --db",
+		},
+		{
+			name: "valid no sync",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanDB = "database.db"
+				scanDBNoSync = true
+			},
+			expectError: false,
+		},
+		{
+			name: "several outputs",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json", "results.csv", "fqdns.txt"}
+			},
+			expectError: false,
+		},
+		{
+			name: "duplicate output",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json", "./results.json"}
+			},
+			expectError: true,
+			errorMsg:    "given more than once",
+		},
+		{
+			name: "unsupported output",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json", "results.xml"}
+			},
+			expectError: true,
+			errorMsg:    "unsupported format for results.xml",
+		},
+		{
+			name: "unified fqdn list",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.json", "results.txt"}
+				unifiedOutput = true
+			},
+			expectError: true,
+			errorMsg:    "--unified cannot write results.txt",
+		},
+		{
+			name: "validate output without json",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOutputs = []string{"results.csv"}
+				validateOutput = true
+			},
+			expectError: true,
+			errorMsg:    "--validate-output requires a .json --output",
+		},
+		{
+			name: "zone cache without negative cache",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanNoNegCache = true
+				scanZoneCache = "zones.json"
+			},
+			expectError: true,
+			errorMsg:    "--zone-cache cannot be used with --no-negative-cache",
+		},
+		{
+			name: "precheck without negative cache",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanNoNegCache = true
+				scanPrecheck = true
+			},
+			expectError: true,
+			errorMsg:    "--precheck cannot be used with --no-negative-cache",
+		},
+		{
+			name: "invalid resolver",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanResolvers = []string{"1.1.1.1", "resolver.local"}
+			},
+			expectError: true,
+			errorMsg:    "invalid resolver",
+		},
+		{
+			name: "invalid order",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOrder = "by-country"
+			},
+			expectError: true,
+			errorMsg:    "invalid order",
+		},
+		{
+			name: "seed without shuffle",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOrder = "by-mcc"
+				scanSeed = 42
 			},
+			expectError: true,
+			errorMsg:    "--seed requires --order=shuffle",
+		},
+		{
+			name: "invalid shard",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanShard = "4/3"
+			},
+			expectError: true,
+			errorMsg:    "invalid shard",
+		},
+		{
+			name: "valid shard",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanShard = "3/3"
+			},
+			expectError: false,
+		},
+		{
+			name: "seeded shuffle",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanOrder = "shuffle"
+				scanSeed = 42
+			},
+			expectError: false,
+		},
+		{
+			name: "negative query budget",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanQueryBudget = -time.Second
+			},
+			expectError: true,
+			errorMsg:    "--query-budget must not be negative",
+		},
+		{
+			name: "targets and rescan",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanTargets = "candidates.txt"
+				scanRescan = true
+				scanDB = "database.db"
+			},
+			expectError: true,
+			errorMsg:    "cannot specify both --targets and --rescan",
+		},
+		{
+			name: "rescan without db",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanRescan = true
+			},
+			expectError: true,
+			errorMsg:    "--rescan requires --db",
+		},
+		{
+			name: "targets with order",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanTargets = "-"
+				scanOrder = "shuffle"
+			},
+			expectError: true,
+			errorMsg:    "--order cannot be used with --targets or --rescan",
+		},
+		{
+			name: "targets with precheck",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanTargets = "candidates.txt"
+				scanPrecheck = true
+			},
+			expectError: true,
+			errorMsg:    "--precheck cannot be used with --targets or --rescan",
+		},
+		{
+			name: "rescan with precheck",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanRescan = true
+				scanDB = "database.db"
+				scanPrecheck = true
+			},
+			expectError: true,
+			errorMsg:    "--precheck cannot be used with --targets or --rescan",
+		},
+		{
+			name: "rescan",
+			setupFlags: func() {
+				scanMode = "all"
+				scanSubdomains = ""
+				scanConcurrency = 10
+				scanDelay = 500
+				scanRescan = true
+				scanDB = "database.db"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid plmn",
+			setupFlags: func() {
+				scanMode = "all"
+				scanConcurrency = 10
+				scanDelay = 500
+				scanPLMNs = []string{"26201", "2621"}
+			},
+			expectError: true,
+			errorMsg:    "invalid PLMN: 2621",
+		},
+		{
+			name: "plmn with targets",
+			setupFlags: func() {
+				scanMode = "all"
+				scanConcurrency = 10
+				scanDelay = 500
+				scanPLMNs = []string{"26201"}
+				scanTargets = "fqdns.txt"
+			},
+			expectError: true,
+			errorMsg:    "--plmn cannot be used with --targets or --rescan",
+		},
+		{
+			name: "plmn",
+			setupFlags: func() {
+				scanMode = "all"
+				scanConcurrency = 10
+				scanDelay = 500
+				scanPLMNs = []string{"26201", "310-260"}
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanOrder = "list"
+			scanSeed = 0
+			scanShard = ""
+			scanQueryBudget = 0
+			scanTargets = ""
+			scanRescan = false
+			scanPLMNs = nil
+			scanSourceIP = ""
+			scanInterface = ""
+			scanResolvers = nil
+			scanNoNegCache = false
+			scanPrecheck = false
+			scanZoneCache = ""
+			scanZoneTTL = 7 * 24 * time.Hour
+			scanOutputs = nil
+			scanFailures = false
+			unifiedOutput = false
+			validateOutput = false
+			scanRedact = nil
+			scanDB = ""
+			scanDBNoSync = false
+			machine = false
+			redactMethod = "hash"
+			tt.setupFlags()
+			err := validateScanFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Ping Flag Validations
+func TestValidatePingFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "missing file",
+			setupFlags: func() {
+				pingFile = ""
+				pingMethod = "icmp"
+				pingTimeout = 300
+				pingWorkers = 10
+			},
+			expectError: true,
+			errorMsg:    "--file required",
+		},
+		{
+			name: "invalid method",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "invalid"
+				pingTimeout = 300
+				pingWorkers = 10
+			},
+			expectError: true,
+			errorMsg:    "invalid method",
+		},
+		{
+			name: "zero timeout",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 0
+				pingWorkers = 10
+			},
+			expectError: true,
+			errorMsg:    "--timeout must be positive",
+		},
+		{
+			name: "negative workers",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "icmp"
+				pingTimeout = 300
+				pingWorkers = -5
+			},
+			expectError: true,
+			errorMsg:    "--workers must be positive",
+		},
+		{
+			name: "valid https ping",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "https"
+				pingTimeout = 2000
+				pingWorkers = 10
+			},
+			expectError: false,
+		},
+		{
+			name: "valid tcp ping",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+			},
+			expectError: false,
+		},
+		{
+			name: "machine without output",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				machine = true
+			},
+			expectError: true,
+			errorMsg:    "--machine requires --output",
+		},
+		{
+			name: "machine with output",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingOutputs = []string{"pings.json"}
+				machine = true
+			},
+			expectError: false,
+		},
+		{
+			name: "dedupe by ip with tcp",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingDedupe = true
+			},
+			expectError: false,
+		},
+		{
+			name: "dedupe by ip with https",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "https"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingDedupe = true
+			},
+			expectError: true,
+			errorMsg:    "--dedupe-by-ip cannot be used with --method=https",
+		},
+		{
+			name: "fallback to tcp",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "icmp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingFallback = "tcp"
+			},
+			expectError: false,
+		},
+		{
+			name: "fallback without icmp",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingFallback = "https"
+			},
+			expectError: true,
+			errorMsg:    "--fallback requires --method=icmp",
+		},
+		{
+			name: "invalid fallback",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "icmp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingFallback = "udp"
+			},
+			expectError: true,
+			errorMsg:    "invalid --fallback: udp",
+		},
+		{
+			name: "encrypt without output",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				encryptOutput = true
+			},
+			expectError: true,
+			errorMsg:    "--encrypt requires --output",
+		},
+		{
+			name: "passphrase file without encrypt",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingOutputs = []string{"out.json"}
+				passphraseFile = "pass.txt"
+			},
+			expectError: true,
+			errorMsg:    "--passphrase-file requires --encrypt",
+		},
+		{
+			name: "encrypted output",
+			setupFlags: func() {
+				pingFile = "test.txt"
+				pingMethod = "tcp"
+				pingTimeout = 300
+				pingWorkers = 10
+				pingOutputs = []string{"out.json"}
+				encryptOutput = true
+				passphraseFile = "pass.txt"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pingOutputs = nil
+			machine, pingDedupe, pingFallback = false, false, ""
+			encryptOutput, passphraseFile = false, ""
+			tt.setupFlags()
+			err := validatePingFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Fastscan Flag Validations
+func TestValidateFastscanFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "defaults",
+			setupFlags:  func() {},
+			expectError: false,
+		},
+		{
+			name: "custom mode",
+			setupFlags: func() {
+				fastMode = "custom"
+			},
+			expectError: true,
+			errorMsg:    "invalid mode: custom",
+		},
+		{
+			name: "zero qps",
+			setupFlags: func() {
+				fastQPS = 0
+			},
+			expectError: true,
+			errorMsg:    "--qps must be positive",
+		},
+		{
+			name: "port out of range",
+			setupFlags: func() {
+				fastPorts = []int{443, 70000}
+			},
+			expectError: true,
+			errorMsg:    "invalid port: 70000",
+		},
+		{
+			name: "zero timeout",
+			setupFlags: func() {
+				fastTimeout = 0
+			},
+			expectError: true,
+			errorMsg:    "--timeout must be positive",
+		},
+		{
+			name: "text output",
+			setupFlags: func() {
+				fastOutput = "hits.txt"
+			},
+			expectError: true,
+			errorMsg:    "use .json or .csv",
+		},
+		{
+			name: "valid triage",
+			setupFlags: func() {
+				fastCountries = []string{"DE", "Austria"}
+				fastMode = "ims"
+				fastResolvers = []string{"9.9.9.9"}
+				fastOutput = "hits.csv"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fastCountries, fastMode, fastQPS, fastConcurrency = nil, "epdg", 200, 50
+			fastQueryBudget, fastResolvers, fastPorts = 2*time.Second, nil, []int{443, 4500}
+			fastTimeout, fastWorkers, fastOutput = 1000, 20, ""
+			tt.setupFlags()
+			err := validateFastscanFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestFilterCountries(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", CountryCode: "DE", CountryName: "Germany"},
+		{MCC: "232", MNC: "01", CountryCode: "AT", CountryName: "Austria"},
+		{MCC: "208", MNC: "01", CountryCode: "FR", CountryName: "France"},
+	}
+	kept := filterCountries(entries, []string{"de", " austria "})
+	if len(kept) != 2 || kept[0].MCC != "262" || kept[1].MCC != "232" {
+		t.Errorf("filterCountries = %+v, want Germany and Austria", kept)
+	}
+}
+
+func TestFastscanLine(t *testing.T) {
+	dnsHit := models.DNSResult{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "192.0.2.2"}, CountryCode: "DE"}
+
+	reachable := fastscanLine(fastHit{dns: dnsHit, ping: models.PingResult{Success: true, IP: "192.0.2.1", Port: 4500, Latency: 12340 * time.Microsecond}})
+	if want := "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org  DE  192.0.2.1,192.0.2.2  reachable 192.0.2.1:4500 (12.34 ms)"; reachable != want {
+		t.Errorf("reachable line = %q, want %q", reachable, want)
+	}
+	dnsHit.CountryCode = ""
+	unreachable := fastscanLine(fastHit{dns: dnsHit, ping: models.PingResult{Status: models.StatusTimeout}})
+	if !contains(unreachable, "  --  ") || !contains(unreachable, "unreachable (TIMEOUT)") {
+		t.Errorf("unreachable line = %q", unreachable)
+	}
+}
+
+func TestFormatQueryTable(t *testing.T) {
+	seen := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	details := []models.FQDNDetail{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", CountryCode: "DE", IPs: []string{"192.0.2.1", "192.0.2.2"}, LastSeen: &seen},
+		{FQDN: "ims.mnc005.mcc001.pub.3gppnetwork.org", Operator: "Test Network", Tags: []string{"lab"}},
+	}
+	want := "" +
+		"FQDN                                        Operator      Country  IPs                  Last Seen             Tags\n" +
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org  Telekom       DE       192.0.2.1,192.0.2.2  2026-03-01T12:00:00Z\n" +
+		"ims.mnc005.mcc001.pub.3gppnetwork.org       Test Network  -        -                    -                     lab\n"
+	if got := formatQueryTable(details, ""); got != want {
+		t.Errorf("formatQueryTable =\n%s\nwant\n%s", got, want)
+	}
+
+	// MCC 001 is a test network of no country
+	want = "" +
+		"FQDN                                        Operator      Country            IPs                  Last Seen             Tags\n" +
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org  Telekom       Deutschland (262)  192.0.2.1,192.0.2.2  2026-03-01T12:00:00Z\n" +
+		"ims.mnc005.mcc001.pub.3gppnetwork.org       Test Network  -                  -                    -                     lab\n"
+	if got := formatQueryTable(details, countries.NamesLocal); got != want {
+		t.Errorf("formatQueryTable with local names =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunQueryExportFile(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	db, err := database.NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	if err := db.InsertResults([]models.DNSResult{{
+		FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom",
+		CountryCode: "DE", IPs: []string{"192.0.2.1"},
+	}}); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+	db.Close()
+
+	quiet, dbJournalMode = true, database.DefaultJournalMode
+	defer func() { quiet = false }()
+	defer func() { queryDB, queryOperator, queryExport, queryFile = "database.db", "", "", "" }()
+
+	tests := []struct {
+		export string
+		file   string
+		want   string
+	}{
+		{"", "telekom.json", `"country_code": "DE"`},
+		{"csv", "telekom.txt", "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,Telekom,,DE,192.0.2.1,,,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			queryDB, queryOperator, queryExport, queryFile = dbPath, "Telekom", tt.export, filepath.Join(dir, tt.file)
+			if err := runQuery(nil, nil); err != nil {
+				t.Fatalf("runQuery: %v", err)
+			}
+			content, err := os.ReadFile(queryFile)
+			if err != nil {
+				t.Fatalf("export not written: %v", err)
+			}
+			if !contains(string(content), tt.want) {
+				t.Errorf("export does not contain %q:\n%s", tt.want, content)
+			}
+		})
+	}
+}
+
+func TestICMPMethod(t *testing.T) {
+	denied := errors.New("raw ICMP sockets need root or CAP_NET_RAW")
+	tests := []struct {
+		name     string
+		fallback string
+		mtu      bool
+		icmpErr  error
+		want     string
+		errorMsg string
+	}{
+		{name: "icmp available", fallback: "tcp", want: "icmp"},
+		{name: "fallback to tcp", fallback: "tcp", icmpErr: denied, want: "tcp"},
+		{name: "no fallback", icmpErr: denied, errorMsg: "or --fallback=tcp to switch automatically"},
+		{name: "mtu needs icmp", fallback: "tcp", mtu: true, icmpErr: denied, errorMsg: "--mtu: raw ICMP sockets need root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := icmpMethod("icmp", tt.fallback, tt.mtu, tt.icmpErr)
+			if tt.errorMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("icmpMethod = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Test Query Flag Validations
+func TestValidateQueryFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "no search criteria",
+			setupFlags: func() {
+				queryMNC = ""
+				queryMCC = ""
+				queryOperator = ""
+			},
+			expectError: true,
+			errorMsg:    "either --mnc/--mcc, --operator, --tag, --private-ips or --since required",
+		},
+		{
+			name: "mnc without mcc",
+			setupFlags: func() {
+				queryMNC = "01"
+				queryMCC = ""
+				queryOperator = ""
+			},
+			expectError: true,
+			errorMsg:    "--mnc and --mcc must be used together",
+		},
+		{
+			name: "mcc without mnc",
+			setupFlags: func() {
+				queryMNC = ""
+				queryMCC = "310"
+				queryOperator = ""
+			},
+			expectError: true,
+			errorMsg:    "--mnc and --mcc must be used together",
+		},
+		{
+			name: "valid mnc and mcc",
+			setupFlags: func() {
+				queryMNC = "01"
+				queryMCC = "310"
+				queryOperator = ""
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid mcc",
+			setupFlags: func() {
+				queryMNC = "01"
+				queryMCC = "31a"
+			},
+			expectError: true,
+			errorMsg:    "invalid --mcc: 31a",
+		},
+		{
+			name: "valid operator",
+			setupFlags: func() {
+				queryMNC = ""
+				queryMCC = ""
+				queryOperator = "Verizon"
+			},
+			expectError: false,
+		},
+		{
+			name: "negative limit",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryLimit = -1
+			},
+			expectError: true,
+			errorMsg:    "--limit cannot be negative",
+		},
+		{
+			name: "negative offset",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryOffset = -10
+			},
+			expectError: true,
+			errorMsg:    "--offset cannot be negative",
+		},
+		{
+			name: "invalid order",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryOrderBy = "ip"
+			},
+			expectError: true,
+			errorMsg:    "invalid order",
+		},
+		{
+			name: "count by mcc alone",
+			setupFlags: func() {
+				queryMNC = ""
+				queryMCC = "310"
+				queryOperator = ""
+				querySubdomain = "epdg"
+				queryCount = true
+			},
+			expectError: false,
+		},
+		{
+			name: "count of whole database",
+			setupFlags: func() {
+				queryMNC = ""
+				queryMCC = ""
+				queryOperator = ""
+				queryCount = true
+			},
+			expectError: false,
+		},
+		{
+			name: "mnc without mcc in count mode",
+			setupFlags: func() {
+				queryMNC = "01"
+				queryMCC = ""
+				queryCount = true
+			},
+			expectError: true,
+			errorMsg:    "--mnc and --mcc must be used together",
+		},
+		{
+			name: "distinct ips not stored",
+			setupFlags: func() {
+				queryMCC = "310"
+				queryMNC = ""
+				queryDistinct = "ips"
+			},
+			expectError: true,
+			errorMsg:    "does not store IPs",
+		},
+		{
+			name: "invalid distinct",
+			setupFlags: func() {
+				queryMCC = "310"
+				queryMNC = ""
+				queryDistinct = "countries"
+			},
+			expectError: true,
+			errorMsg:    "invalid --distinct",
+		},
+		{
+			name: "count and distinct together",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryCount = true
+				queryDistinct = "operators"
+			},
+			expectError: true,
+			errorMsg:    "cannot specify both --count and --distinct",
+		},
+		{
+			name: "paging with count",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryCount = true
+				queryLimit = 10
+			},
+			expectError: true,
+			errorMsg:    "cannot be used with --count or --distinct",
+		},
+		{
+			name: "valid page in reverse order",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryLimit = 100
+				queryOffset = 200
+				queryOrderBy = "-fqdn"
+			},
+			expectError: false,
+		},
+		{
+			name: "valid tag alone",
+			setupFlags: func() {
+				queryTag = "confirmed-live"
+			},
+			expectError: false,
+		},
+		{
+			name: "tag with spaces",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryTag = "customer x"
+			},
+			expectError: true,
+			errorMsg:    "--tag: invalid tag",
+		},
+		{
+			name: "valid private ips alone",
+			setupFlags: func() {
+				queryPrivate = true
+			},
+			expectError: false,
+		},
+		{
+			name: "valid since run alone",
+			setupFlags: func() {
+				querySince = "run:42"
+			},
+			expectError: false,
+		},
+		{
+			name: "valid since date with operator",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				querySince = "2024-01-01"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid since",
+			setupFlags: func() {
+				querySince = "last-week"
+			},
+			expectError: true,
+			errorMsg:    "--since: invalid reference point",
+		},
+		{
+			name: "local country names",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryNames = "local"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid country names",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryNames = "german"
+			},
+			expectError: true,
+			errorMsg:    "--country-names: invalid country names: german",
+		},
+		{
+			name: "valid csv export",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryExport = "csv"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid export format",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryExport = "xml"
+			},
+			expectError: true,
+			errorMsg:    "--export: unknown export format: xml",
+		},
+		{
+			name: "valid export file",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryFile = "verizon.json"
+			},
+			expectError: false,
+		},
+		{
+			name: "export file with format",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryExport = "csv"
+				queryFile = "verizon.txt"
+			},
+			expectError: false,
+		},
+		{
+			name: "export file of unknown format",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryFile = "verizon.xlsx"
+			},
+			expectError: true,
+			errorMsg:    "--export-file: unknown export format: xlsx",
+		},
+		{
+			name: "export file without extension",
+			setupFlags: func() {
+				queryOperator = "Verizon"
+				queryFile = "verizon"
+			},
+			expectError: true,
+			errorMsg:    "--export-file verizon has no extension",
+		},
+		{
+			name: "export with count",
+			setupFlags: func() {
+				queryMCC = "310"
+				queryCount = true
+				queryExport = "json"
+			},
+			expectError: true,
+			errorMsg:    "--export cannot be used with --count or --distinct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryMNC, queryMCC, queryOperator = "", "", ""
+			queryLimit, queryOffset, queryOrderBy = 0, 0, ""
+			queryCount, queryDistinct, querySubdomain, queryTag = false, "", "", ""
+			queryPrivate, queryExport, queryFile, querySince = false, "", "", ""
+			queryNames = ""
+			tt.setupFlags()
+			err := validateQueryFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Profile Flag Validations
+func TestValidateProfileFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "missing mnc",
+			setupFlags: func() {
+				profileMCC = "262"
+				profileMNC = ""
+			},
+			expectError: true,
+			errorMsg:    "--mcc and --mnc required",
+		},
+		{
+			name: "mnc out of range",
+			setupFlags: func() {
+				profileMCC = "262"
+				profileMNC = "1000"
+			},
+			expectError: true,
+			errorMsg:    "invalid --mnc: 1000",
+		},
+		{
+			name: "invalid format",
+			setupFlags: func() {
+				profileMCC = "262"
+				profileMNC = "01"
+				profileFormat = "pdf"
+			},
+			expectError: true,
+			errorMsg:    "invalid format",
+		},
+		{
+			name: "valid mnc zero html",
+			setupFlags: func() {
+				profileMCC = "234"
+				profileMNC = "00"
+				profileFormat = "html"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profileFormat = "text"
+			tt.setupFlags()
+			err := validateProfileFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Compare Flag Validations
+func TestValidateCompareFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "single operator",
+			setupFlags: func() {
+				compareOperators = []string{"Vodafone DE"}
+			},
+			expectError: true,
+			errorMsg:    "at least two operator names",
+		},
+		{
+			name: "duplicate operator",
+			setupFlags: func() {
+				compareOperators = []string{"O2 DE", " O2 DE"}
+			},
+			expectError: true,
+			errorMsg:    "operator listed twice",
+		},
+		{
+			name: "invalid format",
+			setupFlags: func() {
+				compareOperators = []string{"Vodafone DE", "O2 DE"}
+				compareFormat = "html"
+			},
+			expectError: true,
+			errorMsg:    "invalid format",
+		},
+		{
+			name: "valid comparison",
+			setupFlags: func() {
+				compareOperators = []string{"Vodafone DE", "O2 DE"}
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareFormat = "text"
+			tt.setupFlags()
+			err := validateCompareFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test MVNO Flag Validations
+func TestValidateMVNOFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing file",
+			setupFlags:  func() { mvnoFile = "" },
+			expectError: true,
+			errorMsg:    "--file required",
+		},
+		{
+			name: "confidence out of range",
+			setupFlags: func() {
+				mvnoFile = "results.json"
+				mvnoMinConfidence = 1.5
+			},
+			expectError: true,
+			errorMsg:    "between 0 and 1",
+		},
+		{
+			name: "valid json output",
+			setupFlags: func() {
+				mvnoFile = "results.json"
+				mvnoFormat = "json"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mvnoMinConfidence = 0.4
+			mvnoFormat = "text"
+			tt.setupFlags()
+			err := validateMVNOFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCIDRFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing input",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "either --file or --db required",
+		},
+		{
+			name: "file and db",
+			setupFlags: func() {
+				cidrFile = "results.json"
+				cidrDB = "database.db"
+			},
+			expectError: true,
+			errorMsg:    "cannot specify both --file and --db",
+		},
+		{
+			name: "ipv4 prefix out of range",
+			setupFlags: func() {
+				cidrFile = "results.json"
+				cidrIPv4Bits = 33
+			},
+			expectError: true,
+			errorMsg:    "--ipv4-prefix must be between 0 and 32",
+		},
+		{
+			name: "valid csv from database",
+			setupFlags: func() {
+				cidrDB = "database.db"
+				cidrIPv6Bits = 64
+				cidrFormat = "csv"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cidrFile, cidrDB, cidrFormat = "", "", "text"
+			cidrIPv4Bits, cidrIPv6Bits = 24, 48
+			tt.setupFlags()
+			err := validateCIDRFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateWhoisFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"valid", func() {}, ""},
+		{"missing database", func() { whoisDB = "" }, "--db required"},
+		{"empty server", func() { whoisServer = "" }, "--server must not be empty"},
+		{"no workers", func() { whoisWorkers = 0 }, "--workers must be at least 1"},
+		{"no timeout", func() { whoisTimeout = 0 }, "--timeout must be positive"},
+		{"invalid format", func() { whoisFormat = "csv" }, "invalid format: csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whoisDB, whoisServer, whoisWorkers, whoisTimeout, whoisFormat = "database.db", "whois.iana.org", 2, 15*time.Second, "text"
+			tt.setupFlags()
+			err := validateWhoisFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateBGPFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing input",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "either --file or --db required",
+		},
+		{
+			name: "as names without rib",
+			setupFlags: func() {
+				bgpFile = "results.json"
+				bgpASNames = "asns.csv"
+			},
+			expectError: true,
+			errorMsg:    "--as-names requires --rib",
+		},
+		{
+			name: "refresh without database",
+			setupFlags: func() {
+				bgpFile = "results.json"
+				bgpRefresh = true
+			},
+			expectError: true,
+			errorMsg:    "--refresh requires --db",
+		},
+		{
+			name: "no workers",
+			setupFlags: func() {
+				bgpDB = "database.db"
+				bgpWorkers = 0
+			},
+			expectError: true,
+			errorMsg:    "--workers must be at least 1",
+		},
+		{
+			name: "valid local table with database",
+			setupFlags: func() {
+				bgpDB = "database.db"
+				bgpRIB = "table.txt"
+				bgpASNames = "asns.csv"
+				bgpRefresh = true
+				bgpFormat = "json"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bgpFile, bgpDB, bgpRIB, bgpASNames, bgpFormat = "", "", "", "", "text"
+			bgpRefresh, bgpWorkers, bgpTop = false, 4, 10
+			tt.setupFlags()
+			err := validateBGPFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCTLookupFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing output",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "--output required",
+		},
+		{
+			name: "mcc out of range",
+			setupFlags: func() {
+				ctOutput = "candidates.txt"
+				ctMCC = 1000
+			},
+			expectError: true,
+			errorMsg:    "--mcc must be between 0 and 999",
+		},
+		{
+			name: "unknown source",
+			setupFlags: func() {
+				ctOutput = "candidates.txt"
+				ctSource = "google"
+			},
+			expectError: true,
+			errorMsg:    "invalid source: google",
+		},
+		{
+			name: "api key for crt.sh",
+			setupFlags: func() {
+				ctOutput = "candidates.txt"
+				ctAPIKey = "key"
+			},
+			expectError: true,
+			errorMsg:    "--api-key requires --source=certspotter",
+		},
+		{
+			name: "valid cert spotter search",
+			setupFlags: func() {
+				ctOutput = "candidates.txt"
+				ctMCC = 262
+				ctSource = "certspotter"
+				ctAPIKey = "key"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctOutput, ctDomain, ctSource, ctAPIKey = "", "pub.3gppnetwork.org", "crtsh", ""
+			ctMCC = 0
+			tt.setupFlags()
+			err := validateCTLookupFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePDNSFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing provider",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "--provider required",
+		},
+		{
+			name: "unknown provider",
+			setupFlags: func() {
+				pdnsProvider = "shodan"
+				pdnsAPIKey = "key"
+			},
+			expectError: true,
+			errorMsg:    "unknown passive DNS provider: shodan",
+		},
+		{
+			name: "missing api key",
+			setupFlags: func() {
+				pdnsProvider = "dnsdb"
+			},
+			expectError: true,
+			errorMsg:    "--api-key required",
+		},
+		{
+			name: "mcc without mnc",
+			setupFlags: func() {
+				pdnsProvider = "dnsdb"
+				pdnsAPIKey = "key"
+				pdnsMCC = "262"
+			},
+			expectError: true,
+			errorMsg:    "--mcc and --mnc must be used together",
+		},
+		{
+			name: "fqdn and operator",
+			setupFlags: func() {
+				pdnsProvider = "dnsdb"
+				pdnsAPIKey = "key"
+				pdnsFQDN = "ims.mnc001.mcc262.pub.3gppnetwork.org"
+				pdnsMCC, pdnsMNC = "262", "01"
+			},
+			expectError: true,
+			errorMsg:    "cannot specify both --fqdn and --mcc/--mnc",
+		},
+		{
+			name: "valid operator lookup",
+			setupFlags: func() {
+				pdnsProvider = "virustotal"
+				pdnsAPIKey = "key"
+				pdnsMCC, pdnsMNC = "262", "00"
+				pdnsDelay = 15000
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid mnc",
+			setupFlags: func() {
+				pdnsProvider = "dnsdb"
+				pdnsAPIKey = "key"
+				pdnsMCC, pdnsMNC = "262", "0x1"
+			},
+			expectError: true,
+			errorMsg:    "invalid --mnc: 0x1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdnsProvider, pdnsAPIKey, pdnsFQDN = "", "", ""
+			pdnsMCC, pdnsMNC, pdnsLimit, pdnsDelay = "", "", 0, 1000
+			tt.setupFlags()
+			err := validatePDNSFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMetricsFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "print influx",
+			setupFlags:  func() {},
+			expectError: false,
+		},
+		{
+			name: "invalid format",
+			setupFlags: func() {
+				metricsFormat = "graphite"
+			},
+			expectError: true,
+			errorMsg:    "invalid format: graphite",
+		},
+		{
+			name: "invalid url",
+			setupFlags: func() {
+				metricsURL = "influx:8086"
+				metricsBucket = "3gpp"
+			},
+			expectError: true,
+			errorMsg:    "invalid --url",
+		},
+		{
+			name: "influx push without bucket",
+			setupFlags: func() {
+				metricsURL = "http://influx:8086"
+			},
+			expectError: true,
+			errorMsg:    "--bucket required",
+		},
+		{
+			name: "token without url",
+			setupFlags: func() {
+				metricsToken = "secret"
+			},
+			expectError: true,
+			errorMsg:    "require --url",
+		},
+		{
+			name: "bucket with prometheus",
+			setupFlags: func() {
+				metricsFormat = "prometheus"
+				metricsURL = "http://pushgateway:9091"
+				metricsBucket = "3gpp"
+			},
+			expectError: true,
+			errorMsg:    "require --format=influx",
+		},
+		{
+			name: "instance with influx",
+			setupFlags: func() {
+				metricsInstance = "scanner-1"
+			},
+			expectError: true,
+			errorMsg:    "--instance requires --format=prometheus",
+		},
+		{
+			name: "valid influx push",
+			setupFlags: func() {
+				metricsURL = "https://influx:8086"
+				metricsOrg = "secops"
+				metricsBucket = "3gpp"
+				metricsToken = "secret"
+			},
+			expectError: false,
+		},
+		{
+			name: "valid pushgateway",
+			setupFlags: func() {
+				metricsFormat = "prometheus"
+				metricsURL = "http://pushgateway:9091"
+				metricsInstance = "scanner-1"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metricsFormat, metricsURL, metricsToken, metricsOrg = "influx", "", "", ""
+			metricsBucket, metricsJob, metricsInstance = "", "3gpp-scanner", ""
+			tt.setupFlags()
+			err := validateMetricsFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSchemaFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{name: "list", setupFlags: func() {}},
+		{name: "print", args: []string{"dns-result"}, setupFlags: func() {}},
+		{name: "unknown schema", args: []string{"stats"}, setupFlags: func() {}, expectError: true, errorMsg: "unknown schema: stats"},
+		{name: "dir with name", args: []string{"result"}, setupFlags: func() { schemaDir = "schemas" }, expectError: true, errorMsg: "cannot specify a name"},
+		{name: "validate without name", setupFlags: func() { schemaValidate = "results.json" }, expectError: true, errorMsg: "--validate requires a schema name"},
+		{name: "validate", args: []string{"ping-result"}, setupFlags: func() { schemaValidate = "ping.json" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaDir, schemaValidate = "", ""
+			tt.setupFlags()
+			err := validateSchemaFlags(tt.args)
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestExportJSONValidated(t *testing.T) {
+	defer func() { validateOutput = false }()
+	validateOutput = true
+	path := filepath.Join(t.TempDir(), "results.json")
+
+	if err := exportScanResults([]models.DNSResult{{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", MCC: "262", MNC: "01"}}, path); err != nil {
+		t.Errorf("valid export failed validation: %v", err)
+	}
+	if err := exportJSON([]map[string]string{{"fqdn": "x"}}, path, schema.DNSResult); err == nil || !contains(err.Error(), "does not match schema dns-result") {
+		t.Errorf("invalid export: %v", err)
+	}
+}
+
+func TestExportTimesUTC(t *testing.T) {
+	defer func() { utcTimes = false }()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	results := []models.ZoneInfo{{Zone: "mnc001.mcc262.pub.3gppnetwork.org", Timestamp: at}}
+	timestamp := func(z *models.ZoneInfo) *time.Time { return &z.Timestamp }
+
+	if got := exportTimes(results, timestamp); got[0].Timestamp.Location() != at.Location() {
+		t.Errorf("without --utc: %v, want local zone kept", got[0].Timestamp)
+	}
+
+	utcTimes = true
+	got := exportTimes(results, timestamp)
+	if got[0].Timestamp.Location() != time.UTC || !got[0].Timestamp.Equal(at) {
+		t.Errorf("with --utc: %v, want %v in UTC", got[0].Timestamp, at)
+	}
+	if results[0].Timestamp.Location() != at.Location() {
+		t.Error("exportTimes changed the caller's results")
+	}
+}
+
+// Test Probe Flag Validations
+func TestValidateProbeFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing file",
+			setupFlags:  func() { probeFile = "" },
+			expectError: true,
+			errorMsg:    "--file required",
+		},
+		{
+			name:        "no probes",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "at least one of --probes or --plugin required",
+		},
+		{
+			name: "empty plugin",
+			setupFlags: func() {
+				probePlugins = []string{" "}
+			},
+			expectError: true,
+			errorMsg:    "--plugin cannot be empty",
+		},
+		{
+			name: "unsupported output",
+			setupFlags: func() {
+				probePlugins = []string{"./check"}
+				probeOutputs = []string{"probes.xml"}
+			},
+			expectError: true,
+			errorMsg:    "unsupported format",
+		},
+		{
+			name: "zero telecom rate",
+			setupFlags: func() {
+				probeNames = []string{"gtp"}
+				probeTelRate = 0
+			},
+			expectError: true,
+			errorMsg:    "--telecom-rate must be positive",
+		},
+		{
+			name: "unified without output",
+			setupFlags: func() {
+				probeNames = []string{"ike"}
+				unifiedOutput = true
+			},
+			expectError: true,
+			errorMsg:    "--unified requires --output",
+		},
+		{
+			name: "valid plugin",
+			setupFlags: func() {
+				probePlugins = []string{"./check --port 8443"}
+				probeOutputs = []string{"probes.csv"}
+			},
+			expectError: false,
+		},
+		{
+			name: "valid unified output",
+			setupFlags: func() {
+				probeNames = []string{"sip"}
+				probeOutputs = []string{"probes.json", "probes.csv"}
+				unifiedOutput = true
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probeFile = "results.json"
+			probeNames = nil
+			probePlugins = nil
+			probeWorkers = 5
+			probeTimeout = 3000
+			probeTelRate = 1
+			probeOutputs = nil
+			unifiedOutput = false
+			validateOutput = false
+			tt.setupFlags()
+			err := validateProbeFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Fingerprint Flag Validations
+func TestValidateFingerprintFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing file",
+			setupFlags:  func() { fingerprintFile = "" },
+			expectError: true,
+			errorMsg:    "--file required",
+		},
+		{
+			name: "confidence out of range",
+			setupFlags: func() {
+				fingerprintFile = "results.json"
+				fingerprintMinConfidence = -0.1
+			},
+			expectError: true,
+			errorMsg:    "between 0 and 1",
+		},
+		{
+			name: "invalid format",
+			setupFlags: func() {
+				fingerprintFile = "results.json"
+				fingerprintFormat = "csv"
+			},
+			expectError: true,
+			errorMsg:    "invalid format",
+		},
+		{
+			name: "valid json output",
+			setupFlags: func() {
+				fingerprintFile = "results.json"
+				fingerprintFormat = "json"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fingerprintMinConfidence = 0.3
+			fingerprintFormat = "text"
+			tt.setupFlags()
+			err := validateFingerprintFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Score Flag Validations
+func TestValidateScoreFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "missing file",
+			setupFlags:  func() { scoreFile = "" },
+			expectError: true,
+			errorMsg:    "--file required",
+		},
+		{
+			name: "min score out of range",
+			setupFlags: func() {
+				scoreFile = "results.json"
+				scoreMinScore = 2
+			},
+			expectError: true,
+			errorMsg:    "between 0 and 1",
+		},
+		{
+			name: "valid json output",
+			setupFlags: func() {
+				scoreFile = "results.json"
+				scoreMinScore = 0.5
+				scoreFormat = "json"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoreMinScore = 0
+			scoreFormat = "text"
+			tt.setupFlags()
+			err := validateScoreFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Database Flag Validations
+func TestValidateDBFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "defaults",
+			setupFlags:  func() {},
+			expectError: false,
+		},
+		{
+			name:        "rollback journal",
+			setupFlags:  func() { dbJournalMode = "DELETE" },
+			expectError: false,
+		},
+		{
+			name:        "invalid journal mode",
+			setupFlags:  func() { dbJournalMode = "fast" },
+			expectError: true,
+			errorMsg:    "invalid journal mode",
+		},
+		{
+			name:        "negative busy timeout",
+			setupFlags:  func() { dbBusyTimeout = -time.Second },
+			expectError: true,
+			errorMsg:    "cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbJournalMode = "wal"
+			dbBusyTimeout = 5 * time.Second
+			tt.setupFlags()
+			err := validateDBFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestExportAll(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{
+		filepath.Join(dir, "results.json"),
+		filepath.Join(dir, "missing", "results.csv"),
+		filepath.Join(dir, "results.txt"),
+	}
+	quiet = true
+	defer func() { quiet = false }()
+
+	err := exportAll(paths, func(path string) error {
+		return os.WriteFile(path, []byte("ok"), 0644)
+	})
+	if err == nil || !contains(err.Error(), "export to "+paths[1]+" failed") {
+		t.Errorf("expected the failing destination to be reported, got %v", err)
+	}
+	// The other destinations are still written
+	for _, path := range []string{paths[0], paths[2]} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s not written: %v", path, err)
+		}
+	}
+}
+
+func TestEncryptExports(t *testing.T) {
+	dir := t.TempDir()
+	passphraseFile = filepath.Join(dir, "pass.txt")
+	quiet = true
+	defer func() { passphraseFile, quiet, decryptOutput = "", false, "" }()
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	passphrase, err := readPassphrase(true)
+	if err != nil || string(passphrase) != "correct horse" {
+		t.Fatalf("readPassphrase = %q, %v", passphrase, err)
+	}
+
+	path := filepath.Join(dir, "results.csv")
+	if err := os.WriteFile(path, []byte("FQDN\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryptExports(passphrase, []string{path})
+	if err != nil {
+		t.Fatalf("encryptExports: %v", err)
+	}
+	if len(encrypted) != 1 || encrypted[0] != path+".enc" {
+		t.Fatalf("encrypted paths = %v", encrypted)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("plaintext %s not removed", path)
+	}
+	if err := checkEncryptable(encrypted[0]); err == nil || !contains(err.Error(), "already encrypted") {
+		t.Errorf("checkEncryptable of encrypted file = %v", err)
+	}
+
+	decryptOutput = filepath.Join(dir, "shared.csv")
+	if err := runDecrypt(nil, encrypted); err != nil {
+		t.Fatalf("runDecrypt: %v", err)
+	}
+	if data, _ := os.ReadFile(decryptOutput); string(data) != "FQDN\n" {
+		t.Errorf("decrypted = %q", data)
+	}
+	if err := runDecrypt(nil, encrypted); err == nil || !contains(err.Error(), "already exists") {
+		t.Errorf("expected existing output to be refused, got %v", err)
+	}
+}
+
+func TestValidateDecryptFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		args     []string
+		errorMsg string
+	}{
+		{"encrypted file", "", []string{"database.db.enc"}, ""},
+		{"no suffix", "", []string{"database.db"}, "does not end in .enc (set --output)"},
+		{"no suffix with output", "database.db", []string{"backup.bin"}, ""},
+		{"output for several files", "out.db", []string{"a.enc", "b.enc"}, "--output requires a single file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decryptOutput = tt.output
+			defer func() { decryptOutput = "" }()
+			err := validateDecryptFlags(tt.args)
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateMonitorFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"defaults", func() {}, ""},
+		{"daemon", func() { monitorInterval = time.Hour; monitorWebhook = "https://hooks.example/alerts" }, ""},
+		{"interval too short", func() { monitorInterval = time.Second }, "--interval must be at least 1m"},
+		{"certificate checks off", func() { monitorCertWithin = 0 }, ""},
+		{"invalid port", func() { monitorCertPort = 0 }, "--cert-port must be between 1 and 65535"},
+		{"invalid webhook", func() { monitorWebhook = "ftp://hooks.example" }, "invalid --webhook"},
+		{"invalid format", func() { monitorFormat = "xml" }, "invalid format: xml"},
+		{"mass-change off", func() { monitorMassShare = 0 }, ""},
+		{"mass-change above 1", func() { monitorMassShare = 1.5 }, "--mass-change must be between 0 and 1"},
+		{"mass-change-min zero", func() { monitorMassMin = 0 }, "--mass-change-min must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitorInterval, monitorCertWithin, monitorCertPort = 0, dayDuration(30*24*time.Hour), 443
+			monitorTimeout, monitorWorkers, monitorWebhook, monitorFormat = 5*time.Second, 20, "", "text"
+			monitorMassShare, monitorMassMin = 0.5, 3
+			tt.setupFlags()
+			err := validateMonitorFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateAlertsFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"defaults", func() {}, ""},
+		{"daemon", func() { alertsInterval = time.Hour; alertsWebhook = "https://hooks.example/alerts" }, ""},
+		{"negative window", func() { alertsWithin = dayDuration(-time.Hour) }, "--within cannot be negative"},
+		{"interval too short", func() { alertsInterval = time.Second }, "--interval must be at least 1m"},
+		{"invalid webhook", func() { alertsWebhook = "hooks.example" }, "invalid --webhook"},
+		{"invalid format", func() { alertsFormat = "csv" }, "invalid format: csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertsWithin, alertsInterval, alertsWebhook, alertsFormat = dayDuration(30*24*time.Hour), 0, "", "text"
+			tt.setupFlags()
+			err := validateAlertsFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestCertExpiryAlerts(t *testing.T) {
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	const (
+		xcap = "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"
+		bsf  = "bsf.mnc001.mcc262.pub.3gppnetwork.org"
+		aes  = "aes.mnc010.mcc334.pub.3gppnetwork.org"
+	)
+	if err := db.InsertResults([]models.DNSResult{
+		{FQDN: xcap, MNC: "01", MCC: "262", Operator: "Telekom", IPs: []string{"192.0.2.1"}},
+		{FQDN: bsf, MNC: "01", MCC: "262", Operator: "Telekom", IPs: []string{"192.0.2.2"}},
+		{FQDN: aes, MNC: "10", MCC: "334", Operator: "Other", IPs: []string{"192.0.2.3"}},
+	}); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tlsResult := func(fqdn string, notAfter time.Time) models.Result {
+		return models.Result{Target: fqdn, Type: "tls", Success: true, Metadata: map[string]string{"not_after": notAfter.Format(time.RFC3339)}}
+	}
+	if err := db.SaveResults([]models.Result{
+		tlsResult(xcap, now.AddDate(0, 0, -1)), // Renewed below
+		tlsResult(bsf, now.AddDate(0, 0, -2)),
+		tlsResult(aes, now.AddDate(0, 0, 10)),
+		tlsResult(xcap, now.AddDate(0, 0, 20)),
+		{Target: "ims.mnc001.mcc262.pub.3gppnetwork.org", Type: "sip", Success: true},
+	}); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+
+	alerts, err := certExpiryAlerts(db, nil, 30*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("certExpiryAlerts: %v", err)
+	}
+	var got []string
+	for _, alert := range alerts {
+		got = append(got, alert.Kind+" "+alert.FQDN+" "+alert.Operator)
+	}
+	want := []string{"cert-expired " + bsf + " Telekom", "cert-expiring " + aes + " Other", "cert-expiring " + xcap + " Telekom"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("alerts = %q, want %q", got, want)
+	}
+
+	watched, err := certExpiryAlerts(db, &monitor.Watchlist{PLMNs: []dns.PLMN{{MCC: "262", MNC: "01"}}}, 15*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("certExpiryAlerts with watchlist: %v", err)
+	}
+	if len(watched) != 1 || watched[0].FQDN != bsf {
+		t.Errorf("watched alerts = %+v, want only %s", watched, bsf)
+	}
+}
+
+func TestDayDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		text    string
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, "30d", false},
+		{"72h", 72 * time.Hour, "3d", false},
+		{"90m", 90 * time.Minute, "1h30m0s", false},
+		{"0", 0, "0s", false},
+		{"-1d", 0, "", true},
+		{"soon", 0, "", true},
+	}
+	for _, tt := range tests {
+		var d dayDuration
+		err := d.Set(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !tt.wantErr && (time.Duration(d) != tt.want || d.String() != tt.text) {
+			t.Errorf("Set(%q) = %v (%s), want %v (%s)", tt.input, time.Duration(d), d.String(), tt.want, tt.text)
+		}
+	}
+}
+
+func TestValidateSyncFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		remote   string
+		version  string
+		args     []string
+		errorMsg string
+	}{
+		{"bucket and prefix", "s3://team/epdg", "", []string{"database.db"}, ""},
+		{"version of one file", "s3://team", "20260101", []string{"database.db"}, ""},
+		{"not s3", "https://team/epdg", "", []string{"database.db"}, "expected s3://bucket/prefix"},
+		{"version of several files", "s3://team", "20260101", []string{"a.db", "b.json"}, "--version requires a single NAME"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncRemote, syncVersion = tt.remote, tt.version
+			defer func() { syncRemote, syncVersion = "", "" }()
+			_, err := validateSyncFlags(tt.args)
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateOutputFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		verbose, quiet bool
+		expectError    bool
+	}{
+		{"defaults", false, false, false},
+		{"verbose", true, false, false},
+		{"quiet", false, true, false},
+		{"quiet and verbose", true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verbose, quiet = tt.verbose, tt.quiet
+			defer func() { verbose, quiet = false, false }()
+			err := validateOutputFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && !contains(err.Error(), "mutually exclusive") {
+				t.Errorf("expected error containing %q, got %q", "mutually exclusive", err.Error())
+			}
+		})
+	}
+}
+
+func TestApplyLowResource(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(-1))
+	t.Setenv("GOMEMLIMIT", "")
+	lowResource = true
+	defer func() { lowResource = false }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		workers int
+	}{
+		{"default lowered", nil, 2},
+		{"explicit kept", []string{"--workers=8"}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := pingCmd()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyLowResource(cmd); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pingWorkers != tt.workers {
+				t.Errorf("expected %d workers, got %d", tt.workers, pingWorkers)
+			}
+		})
+	}
+
+	if got := debug.SetMemoryLimit(-1); got != lowResourceMemoryLimit {
+		t.Errorf("expected memory limit %d, got %d", lowResourceMemoryLimit, got)
+	}
+	if showProgress() {
+		t.Errorf("expected no progress bar with --low-resource")
+	}
+}
+
+func TestValidateBenchFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "defaults",
+			setupFlags:  func() {},
+			expectError: false,
+		},
+		{
+			name:        "resolvers with and without port",
+			setupFlags:  func() { benchResolvers = []string{"1.1.1.1", "[2001:db8::53]:5353"} },
+			expectError: false,
+		},
+		{
+			name:        "hostname resolver",
+			setupFlags:  func() { benchResolvers = []string{"dns.google"} },
+			expectError: true,
+			errorMsg:    "invalid resolver",
+		},
+		{
+			name:        "file and db",
+			setupFlags:  func() { benchFile = "fqdns.txt"; benchDB = "database.db" },
+			expectError: true,
+			errorMsg:    "cannot specify both",
+		},
+		{
+			name:        "zero sample",
+			setupFlags:  func() { benchSample = 0 },
+			expectError: true,
+			errorMsg:    "--sample must be positive",
+		},
+		{
+			name:        "invalid format",
+			setupFlags:  func() { benchFormat = "csv" },
+			expectError: true,
+			errorMsg:    "invalid format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			benchResolvers = nil
+			benchFile = ""
+			benchDB = ""
+			benchSample = 50
+			benchWorkers = 4
+			benchSourceIP = ""
+			benchInterface = ""
+			benchFormat = "text"
+			tt.setupFlags()
+			err := validateBenchFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// Test Tag Flag Validation
+func TestValidateCrosscheckFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"database", func() {}, ""},
+		{"file", func() { crossDB, crossFile = "", "fqdns.txt" }, ""},
+		{"no source", func() { crossDB = "" }, "either --file or --db required"},
+		{"both sources", func() { crossFile = "fqdns.txt" }, "cannot specify both --file and --db"},
+		{"own resolvers", func() { crossResolvers = []string{"10.0.0.53", "9.9.9.9:53"} }, ""},
+		{"one resolver", func() { crossResolvers = []string{"10.0.0.53"} }, "at least two resolvers"},
+		{"invalid resolver", func() { crossResolvers = []string{"dns.example", "9.9.9.9"} }, "invalid resolver"},
+		{"prefix too long", func() { crossPrefix = 33 }, "--prefix must be between 8 and 32"},
+		{"no workers", func() { crossWorkers = 0 }, "--workers must be positive"},
+		{"invalid format", func() { crossFormat = "csv" }, "invalid format: csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crossResolvers, crossFile, crossDB, crossLimit = nil, "", "database.db", 0
+			crossPrefix, crossWorkers, crossDelay, crossFormat = 24, 5, 50, "text"
+			crossSourceIP, crossInterface = "", ""
+			tt.setupFlags()
+			err := validateCrosscheckFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestFormatCrosscheck(t *testing.T) {
+	servers := []string{"8.8.8.8:53", "10.0.0.53:53"}
+	results := []dns.CrossCheckResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Verdict: dns.VerdictConsistent, Answers: []dns.ResolverAnswer{
+			{Server: servers[0], Status: models.StatusOK, IPs: []string{"20.1.1.1"}},
+			{Server: servers[1], Status: models.StatusOK, IPs: []string{"20.1.1.1"}},
+		}},
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Verdict: dns.VerdictSuspicious, Reason: "NXDOMAIN from 8.8.8.8:53, addresses from 10.0.0.53:53", Answers: []dns.ResolverAnswer{
+			{Server: servers[0], Status: models.StatusNXDomain},
+			{Server: servers[1], Status: models.StatusOK, IPs: []string{"20.1.1.1", "20.1.1.2"}},
+		}},
+	}
+
+	report := newCrosscheckReport(servers, 24, results, false)
+	want := "" +
+		"SUSPICIOUS   ims.mnc001.mcc262.pub.3gppnetwork.org: NXDOMAIN from 8.8.8.8:53, addresses from 10.0.0.53:53\n" +
+		"  8.8.8.8:53             NXDOMAIN\n" +
+		"  10.0.0.53:53           20.1.1.1, 20.1.1.2\n" +
+		"\n" +
+		"Checked 2 FQDNs against 2 resolvers: 1 consistent, 0 geodns, 1 suspicious, 0 inconclusive\n"
+	if got := formatCrosscheck(report); got != want {
+		t.Errorf("formatCrosscheck =\n%s\nwant\n%s", got, want)
+	}
+	if all := newCrosscheckReport(servers, 24, results, true); len(all.Results) != 2 {
+		t.Errorf("--all report has %d results, want 2", len(all.Results))
+	}
+}
+
+func TestParseVantage(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		file     string
+		lat, lon float64
+		errorMsg string
+	}{
+		{"ping-fra.json@50.11,8.68", "ping-fra", "ping-fra.json", 50.11, 8.68, ""},
+		{"runs/iad@home.json@39.04, -77.49", "iad@home", "runs/iad@home.json", 39.04, -77.49, ""},
+		{"ping-fra.json", "", "", 0, 0, "must be FILE@LATITUDE,LONGITUDE"},
+		{"ping-fra.json@50.11", "", "", 0, 0, "bad coordinates"},
+		{"ping-fra.json@95,8", "", "", 0, 0, "bad coordinates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, file, err := parseVantage(tt.input)
+			if tt.errorMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil || v.Name != tt.name || file != tt.file || v.Latitude != tt.lat || v.Longitude != tt.lon {
+				t.Errorf("parseVantage = %+v, %q, %v", v, file, err)
+			}
+		})
+	}
+}
+
+func TestValidateGeolocateFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"two vantages", func() {}, ""},
+		{"no vantage", func() { geolocVantages = nil }, "at least one --vantage required"},
+		{"duplicate name", func() { geolocVantages = append(geolocVantages, "other/fra.json@1,2") }, "duplicate vantage point \"fra\""},
+		{"invalid format", func() { geolocFormat = "kml" }, "invalid format: kml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geolocVantages, geolocFormat = []string{"fra.json@50.11,8.68", "iad.json@39.04,-77.49"}, "text"
+			tt.setupFlags()
+			err := validateGeolocateFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateExportFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupFlags func()
+		errorMsg   string
+	}{
+		{"zgrab2", func() {}, ""},
+		{"nuclei for one operator", func() { exportFor, exportMCC, exportMNC = "nuclei", "262", "01" }, ""},
+		{"missing --for", func() { exportFor = "" }, "--for required"},
+		{"invalid --for", func() { exportFor = "masscan" }, "invalid --for: masscan"},
+		{"mnc without mcc", func() { exportMNC = "01" }, "--mnc requires --mcc"},
+		{"invalid mcc", func() { exportMCC = "26a" }, "invalid --mcc"},
+		{"invalid since", func() { exportSince = "yesterday" }, "since"},
+		{"invalid port", func() { exportPorts = []int{443, 70000} }, "invalid port in --ports: 70000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportFor, exportMCC, exportMNC, exportSince, exportPorts = "zgrab2", "", "", "", nil
+			tt.setupFlags()
+			err := validateExportFlags()
+
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateTagFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		tag         string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "no targets",
+			tag:         "confirmed-live",
+			setupFlags:  func() {},
+			expectError: true,
+			errorMsg:    "at least one of --fqdn, --operator or --file required",
+		},
+		{
+			name:        "invalid tag",
+			tag:         "a,b",
+			setupFlags:  func() { tagOperators = []string{"Verizon"} },
+			expectError: true,
+			errorMsg:    "spaces and commas are not allowed",
+		},
+		{
+			name:        "valid fqdns",
+			tag:         "honeypot?",
+			setupFlags:  func() { tagFQDNs = []string{"epdg.epc.mnc001.mcc310.pub.3gppnetwork.org"} },
+			expectError: false,
+		},
+		{
+			name:        "valid file",
+			tag:         "customer-x",
+			setupFlags:  func() { tagFile = "fqdns.txt" },
 			expectError: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			tagFQDNs = nil
+			tagOperators = nil
+			tagFile = ""
 			tt.setupFlags()
-			err := validatePingFlags()
+			err := validateTagFlags(tt.tag)
 
 			if tt.expectError && err == nil {
 				t.Errorf("expected error but got none")
@@ -182,68 +2915,65 @@ func TestValidatePingFlags(t *testing.T) {
 	}
 }
 
-// Test Query Flag Validations
-func TestValidateQueryFlags(t *testing.T) {
+// Test Ack Flag Validation
+func TestValidateAckFlags(t *testing.T) {
 	tests := []struct {
 		name        string
+		finding     string
 		setupFlags  func()
 		expectError bool
 		errorMsg    string
 	}{
 		{
-			name: "no search criteria",
-			setupFlags: func() {
-				queryMNC = 0
-				queryMCC = 0
-				queryOperator = ""
-			},
+			name:        "no targets",
+			finding:     "ip-changed",
+			setupFlags:  func() {},
 			expectError: true,
-			errorMsg:    "either --mnc/--mcc or --operator required",
+			errorMsg:    "at least one of --fqdn, --operator or --file required",
 		},
 		{
-			name: "mnc without mcc",
-			setupFlags: func() {
-				queryMNC = 1
-				queryMCC = 0
-				queryOperator = ""
-			},
+			name:        "unknown finding",
+			finding:     "open-port",
+			setupFlags:  func() { ackOperators = []string{"Verizon"} },
 			expectError: true,
-			errorMsg:    "--mnc and --mcc must be used together",
+			errorMsg:    "invalid finding: open-port",
 		},
 		{
-			name: "mcc without mnc",
-			setupFlags: func() {
-				queryMNC = 0
-				queryMCC = 310
-				queryOperator = ""
-			},
+			name:        "resolved is not acknowledged on its own",
+			finding:     "resolved",
+			setupFlags:  func() { ackOperators = []string{"Verizon"} },
 			expectError: true,
-			errorMsg:    "--mnc and --mcc must be used together",
+			errorMsg:    "invalid finding",
 		},
 		{
-			name: "valid mnc and mcc",
-			setupFlags: func() {
-				queryMNC = 1
-				queryMCC = 310
-				queryOperator = ""
-			},
+			name:        "negative expiry",
+			finding:     "cert-expiring",
+			setupFlags:  func() { ackFile = "fqdns.txt"; ackExpires = dayDuration(-time.Hour) },
+			expectError: true,
+			errorMsg:    "--expires cannot be negative",
+		},
+		{
+			name:        "valid fqdns",
+			finding:     "new-fqdn",
+			setupFlags:  func() { ackFQDNs = []string{"epdg.epc.mnc001.mcc310.pub.3gppnetwork.org"} },
 			expectError: false,
 		},
 		{
-			name: "valid operator",
-			setupFlags: func() {
-				queryMNC = 0
-				queryMCC = 0
-				queryOperator = "Verizon"
-			},
+			name:        "valid operator with expiry",
+			finding:     "all",
+			setupFlags:  func() { ackOperators = []string{"Verizon"}; ackExpires = dayDuration(30 * 24 * time.Hour) },
 			expectError: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			ackFQDNs = nil
+			ackOperators = nil
+			ackFile = ""
+			ackExpires = 0
 			tt.setupFlags()
-			err := validateQueryFlags()
+			err := validateAckFlags(tt.finding)
 
 			if tt.expectError && err == nil {
 				t.Errorf("expected error but got none")
@@ -260,6 +2990,347 @@ func TestValidateQueryFlags(t *testing.T) {
 	}
 }
 
+// Test Dropping Acknowledged Alerts
+func TestDropAcked(t *testing.T) {
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "acks.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.AddAcks("unresolved", database.TagKindFQDN, []string{"a.example"}, "", nil); err != nil {
+		t.Fatalf("AddAcks: %v", err)
+	}
+	if _, err := db.AddAcks("mass-change", database.TagKindOperator, []string{"Op"}, "", nil); err != nil {
+		t.Fatalf("AddAcks: %v", err)
+	}
+	acks, err := db.ActiveAcks(time.Now())
+	if err != nil {
+		t.Fatalf("ActiveAcks: %v", err)
+	}
+
+	alerts := []monitor.Alert{
+		{Kind: monitor.KindUnresolved, FQDN: "a.example", Operator: "Op"},
+		{Kind: monitor.KindResolved, FQDN: "a.example", Operator: "Op"},
+		{Kind: monitor.KindIPChanged, FQDN: "a.example", Operator: "Op"},
+		{Kind: monitor.KindMassChange, Operator: "Op"},
+		{Kind: monitor.KindUnresolved, FQDN: "b.example", Operator: "Op"},
+	}
+	kept, dropped := dropAcked(alerts, acks)
+	if dropped != 3 || len(kept) != 2 || kept[0].Kind != monitor.KindIPChanged || kept[1].FQDN != "b.example" {
+		t.Errorf("dropAcked kept %+v, dropped %d", kept, dropped)
+	}
+	if kept, dropped := dropAcked(alerts, nil); dropped != 0 || len(kept) != len(alerts) {
+		t.Errorf("dropAcked without acknowledgments dropped %d", dropped)
+	}
+}
+
+// Test Note Target Selection
+func TestNoteTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		fqdn       string
+		operator   string
+		required   bool
+		wantKind   string
+		wantTarget string
+		errorMsg   string
+	}{
+		{name: "fqdn is normalized", fqdn: "EPDG.example.org.", required: true, wantKind: "fqdn", wantTarget: "epdg.example.org"},
+		{name: "operator", operator: "Verizon", required: true, wantKind: "operator", wantTarget: "Verizon"},
+		{name: "both", fqdn: "epdg.example.org", operator: "Verizon", errorMsg: "cannot specify both --fqdn and --operator"},
+		{name: "none required", required: true, errorMsg: "either --fqdn or --operator required"},
+		{name: "none optional"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteFQDN, noteOperator = tt.fqdn, tt.operator
+			kind, target, err := noteTarget(tt.required)
+
+			if tt.errorMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kind != tt.wantKind || target != tt.wantTarget {
+				t.Errorf("got %s %q, want %s %q", kind, target, tt.wantKind, tt.wantTarget)
+			}
+		})
+	}
+	noteFQDN, noteOperator = "", ""
+
+	notes := []models.Note{
+		{ID: 1, Kind: "fqdn", Target: "epdg.example.org"},
+		{ID: 2, Kind: "operator", Target: "epdg.example.org"},
+		{ID: 3, Kind: "operator", Target: "Verizon"},
+	}
+	kept := relevantNotes(notes, []string{"epdg.example.org"}, []string{"Verizon"})
+	if len(kept) != 2 || kept[0].ID != 1 || kept[1].ID != 3 {
+		t.Errorf("relevantNotes = %+v", kept)
+	}
+}
+
+// Test Serve Flag Validation
+func TestValidateServeFlags(t *testing.T) {
+	tests := []struct {
+		listen      string
+		rateLimit   float64
+		rateBurst   int
+		tlsCert     string
+		tlsKey      string
+		expectError bool
+	}{
+		{listen: "127.0.0.1:8080"},
+		{listen: ":0"},
+		{listen: "[::1]:8080"},
+		{listen: "8080", expectError: true},
+		{listen: "127.0.0.1:8080", rateLimit: -1, expectError: true},
+		{listen: "127.0.0.1:8080", rateBurst: -1, expectError: true},
+		{listen: "127.0.0.1:8080", tlsCert: "serve.crt", expectError: true},
+		{listen: "127.0.0.1:8080", tlsCert: "serve.crt", tlsKey: "serve.key"},
+	}
+
+	for _, tt := range tests {
+		serveListen = tt.listen
+		serveRateLimit, serveRateBurst = 10, 20
+		serveTLSCert, serveTLSKey = tt.tlsCert, tt.tlsKey
+		if tt.rateLimit != 0 {
+			serveRateLimit = tt.rateLimit
+		}
+		if tt.rateBurst != 0 {
+			serveRateBurst = tt.rateBurst
+		}
+		err := validateServeFlags()
+		if tt.expectError && err == nil {
+			t.Errorf("%s: expected error but got none", tt.listen)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.listen, err)
+		}
+	}
+	serveListen, serveRateLimit, serveRateBurst = "127.0.0.1:8080", 10, 20
+	serveTLSCert, serveTLSKey = "", ""
+
+	for host, want := range map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true, "": false, "0.0.0.0": false, "10.0.0.1": false} {
+		if got := isLoopback(host); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestValidateStreamFlags(t *testing.T) {
+	fingerprint := strings.Repeat("AB:", 31) + "AB"
+	tests := []struct {
+		url         string
+		keyFile     string
+		ca          string
+		fingerprint string
+		expectError bool
+	}{
+		{},
+		{url: "http://127.0.0.1:8080"},
+		{url: "https://scanner-host:8443/", keyFile: "stream.key"},
+		{url: "https://scanner-host:8443", ca: "serve.crt"},
+		{url: "https://scanner-host:8443", fingerprint: fingerprint},
+		{url: "https://scanner-host:8443", fingerprint: strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))},
+		{keyFile: "stream.key", expectError: true},
+		{url: "127.0.0.1:8080", expectError: true},
+		{url: "ftp://scanner-host", expectError: true},
+		{url: "http://", expectError: true},
+		{ca: "serve.crt", expectError: true},
+		{url: "http://127.0.0.1:8080", fingerprint: fingerprint, expectError: true},
+		{url: "https://scanner-host:8443", ca: "serve.crt", fingerprint: fingerprint, expectError: true},
+		{url: "https://scanner-host:8443", fingerprint: "AB:CD", expectError: true},
+	}
+
+	for _, tt := range tests {
+		streamURL, streamKeyFile, streamCA, streamFingerprint = tt.url, tt.keyFile, tt.ca, tt.fingerprint
+		err := validateStreamFlags()
+		if tt.expectError && err == nil {
+			t.Errorf("%+v: expected error but got none", tt)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+	}
+	streamURL, streamKeyFile, streamCA, streamFingerprint = "", "", "", ""
+}
+
+func TestValidateReportFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		results     string
+		db          string
+		vars        []string
+		expectError bool
+	}{
+		{name: "results", template: "r.tmpl", results: "results.json"},
+		{name: "db with vars", template: "r.tmpl", db: "database.db", vars: []string{"customer=Example", "empty="}},
+		{name: "no template", results: "results.json", expectError: true},
+		{name: "no input", template: "r.tmpl", expectError: true},
+		{name: "var without value", template: "r.tmpl", db: "database.db", vars: []string{"customer"}, expectError: true},
+		{name: "var without key", template: "r.tmpl", db: "database.db", vars: []string{"=x"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportTemplate, reportResults, reportPings, reportDB = tt.template, tt.results, "", tt.db
+			reportVars = tt.vars
+			err := validateReportFlags()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	reportTemplate, reportResults, reportDB, reportVars = "", "", "", nil
+}
+
+func TestValidateImportCSVFlags(t *testing.T) {
+	tests := []struct {
+		mapping     string
+		delimiter   string
+		comma       rune
+		expectError bool
+	}{
+		{mapping: "fqdn=Host", delimiter: ",", comma: ','},
+		{mapping: "fqdn=1,ip=2", delimiter: ";", comma: ';'},
+		{mapping: "fqdn=1", delimiter: "tab", comma: '\t'},
+		{mapping: "", delimiter: ",", expectError: true},
+		{mapping: "host=1", delimiter: ",", expectError: true},
+		{mapping: "fqdn=1", delimiter: ";;", expectError: true},
+		{mapping: "fqdn=1", delimiter: "", expectError: true},
+		{mapping: "fqdn=1", delimiter: `"`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		importMapping, importDelimiter = tt.mapping, tt.delimiter
+		_, comma, err := validateImportCSVFlags()
+		if tt.expectError && err == nil {
+			t.Errorf("%q %q: expected error but got none", tt.mapping, tt.delimiter)
+		}
+		if !tt.expectError && (err != nil || comma != tt.comma) {
+			t.Errorf("%q %q: got %q, %v", tt.mapping, tt.delimiter, comma, err)
+		}
+	}
+	importMapping, importDelimiter = "", ","
+}
+
+// Test Machine Mode Exit Codes
+func TestFinishMachine(t *testing.T) {
+	tests := []struct {
+		name       string
+		summary    map[string]int
+		err        error
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "no findings", summary: map[string]int{"queries": 10, "findings": 0}, wantCode: exitNoFindings, wantStatus: `"status": "no_findings"`},
+		{name: "findings", summary: map[string]int{"queries": 10, "findings": 3}, wantCode: exitFindings, wantStatus: `"status": "findings"`},
+		{name: "error", err: errors.New("scan failed"), wantCode: exitError, wantStatus: `"error": "scan failed"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report = &machineReport{Summary: tt.summary}
+			var buf bytes.Buffer
+			code := finishMachine(&buf, &cobra.Command{Use: "scan"}, tt.err)
+
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !contains(buf.String(), tt.wantStatus) || !contains(buf.String(), `"command": "scan"`) {
+				t.Errorf("unexpected report: %s", buf.String())
+			}
+		})
+	}
+	report = &machineReport{}
+}
+
+// Test Engagement Scope
+func TestLoadScope(t *testing.T) {
+	oldQuiet := quiet
+	quiet = true
+	defer func() {
+		quiet = oldQuiet
+		scopeFile = ""
+		report = &machineReport{}
+	}()
+
+	noList := func() []models.MCCMNCEntry { return nil }
+
+	scopeFile = ""
+	engagement, err := loadScope(noList)
+	if err != nil || engagement != nil {
+		t.Fatalf("without --scope: got %v, %v", engagement, err)
+	}
+	if targetChecker(nil, nil) != nil {
+		t.Errorf("targetChecker without list or scope should be nil")
+	}
+
+	dir := t.TempDir()
+	scopeFile = filepath.Join(dir, "scope.yaml")
+	if err := os.WriteFile(scopeFile, []byte("name: test\noperators: [Telia]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadScope(noList); err == nil || !contains(err.Error(), "no MCC-MNC list") {
+		t.Errorf("expected missing MCC-MNC list error, got %v", err)
+	}
+
+	engagement, err = loadScope(func() []models.MCCMNCEntry {
+		return []models.MCCMNCEntry{{MCC: "240", MNC: "01", Brand: "Telia"}}
+	})
+	if err != nil {
+		t.Fatalf("loadScope: %v", err)
+	}
+	if report.Scope != "test" {
+		t.Errorf("report.Scope = %q, want test", report.Scope)
+	}
+	checker := targetChecker(nil, engagement)
+	if err := checker.CheckName("ims.mnc001.mcc240.pub.3gppnetwork.org"); err != nil {
+		t.Errorf("in-scope name refused: %v", err)
+	}
+	if err := checker.CheckName("ims.mnc002.mcc240.pub.3gppnetwork.org"); err == nil {
+		t.Errorf("out-of-scope name allowed")
+	}
+}
+
+// Test Version Output
+func TestValidateVersionFlags(t *testing.T) {
+	versionFormat = "yaml"
+	if err := validateVersionFlags(); err == nil || !contains(err.Error(), "invalid format") {
+		t.Errorf("expected invalid format error, got %v", err)
+	}
+	versionFormat = "json"
+	if err := validateVersionFlags(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	versionFormat = "text"
+}
+
+func TestFormatBuildInfo(t *testing.T) {
+	info := buildInfo{
+		Version:  "1.0.0",
+		Commit:   "0123456789abcdef0123",
+		Modified: true,
+		Database: databaseInfo{Driver: "mattn/go-sqlite3", Error: "requires cgo"},
+		Probes:   []string{"ike", "sip"},
+	}
+	text := formatBuildInfo(info)
+	for _, want := range []string{"Commit:     0123456789ab-dirty", "Built:      unknown", "unavailable (requires cgo)", "Probes:     ike, sip"} {
+		if !contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
+
 // Test Stats Flag Validations
 func TestValidateStatsFlags(t *testing.T) {
 	tests := []struct {
@@ -307,6 +3378,37 @@ func TestValidateStatsFlags(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "invalid chart",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "text"
+				statsChart = "excel"
+			},
+			expectError: true,
+			errorMsg:    "invalid chart",
+		},
+		{
+			name: "html without map",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "html"
+			},
+			expectError: true,
+			errorMsg:    "--format=html requires --map",
+		},
+		{
+			name: "html map",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "html"
+				statsMap = true
+			},
+			expectError: false,
+		},
 		{
 			name: "valid db with csv",
 			setupFlags: func() {
@@ -316,10 +3418,59 @@ func TestValidateStatsFlags(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "negative top",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "text"
+				statsTop = -1
+			},
+			expectError: true,
+			errorMsg:    "--top must not be negative",
+		},
+		{
+			name: "by operator with chart",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "text"
+				statsChart = "vega"
+				statsByOp = true
+			},
+			expectError: true,
+			errorMsg:    "--by-operator cannot be used with --map or --chart",
+		},
+		{
+			name: "english country names",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "text"
+				statsNames = "english"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid country names",
+			setupFlags: func() {
+				statsFile = "test.txt"
+				statsDB = ""
+				statsFormat = "text"
+				statsNames = "numeric"
+			},
+			expectError: true,
+			errorMsg:    "--country-names: invalid country names",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			statsChart = ""
+			statsMap = false
+			statsTop = 0
+			statsByOp = false
+			statsNames = ""
 			tt.setupFlags()
 			err := validateStatsFlags()
 
@@ -351,3 +3502,75 @@ func stringContains(s, substr string) bool {
 	}
 	return false
 }
+
+// Test Fetch Flag Validations
+func TestValidateFetchFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFlags  func()
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "plain refresh",
+			setupFlags: func() {
+				fetchOutput = ""
+				fetchFormat = "json"
+				fetchCountries = nil
+				fetchStatus = ""
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid format",
+			setupFlags: func() {
+				fetchOutput = "out.xml"
+				fetchFormat = "xml"
+				fetchCountries = nil
+				fetchStatus = ""
+			},
+			expectError: true,
+			errorMsg:    "invalid format",
+		},
+		{
+			name: "filter without output",
+			setupFlags: func() {
+				fetchOutput = ""
+				fetchFormat = "json"
+				fetchCountries = []string{"DE"}
+				fetchStatus = ""
+			},
+			expectError: true,
+			errorMsg:    "--output required",
+		},
+		{
+			name: "csv with output and filters",
+			setupFlags: func() {
+				fetchOutput = "de.csv"
+				fetchFormat = "csv"
+				fetchCountries = []string{"DE"}
+				fetchStatus = "operational"
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupFlags()
+			err := validateFetchFlags()
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectError && err != nil && tt.errorMsg != "" {
+				if !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errorMsg, err.Error())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"3gpp-scanner/internal/metrics"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Metrics command flags
+	metricsDB       string
+	metricsFormat   string
+	metricsURL      string
+	metricsToken    string
+	metricsOrg      string
+	metricsBucket   string
+	metricsJob      string
+	metricsInstance string
+)
+
+func metricsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Export run summaries and operator counts for Grafana",
+		Long: `Export the totals of the database (FQDNs, operators, IPs), the FQDN count
+of every operator and the recorded runs as metrics, so recurring scans show
+up in existing Grafana dashboards. Run it after each scan, e.g. from cron.
+
+With --url the metrics are pushed: --format=influx writes them to InfluxDB
+through the /api/v2/write API (InfluxDB 2, or 1.8+ with --bucket=db/rp and
+--token=user:password), --format=prometheus replaces the metrics of --job in
+a Prometheus Pushgateway. Without --url they are printed to stdout, e.g. for
+Telegraf or the node_exporter textfile collector.
+
+InfluxDB points of a run carry its start time, so every run is a point of
+its own and exporting again does not duplicate it. The Pushgateway does not
+accept timestamps: it gets the run count and the latest run only.`,
+		Example: `  # Push to InfluxDB 2 after a scan
+  3gpp-scanner metrics --db=database.db --format=influx \
+    --url=http://influx:8086 --org=secops --bucket=3gpp --token=$INFLUX_TOKEN
+
+  # Push to a Prometheus Pushgateway
+  3gpp-scanner metrics --db=database.db --format=prometheus --url=http://pushgateway:9091
+
+  # Write a file for the node_exporter textfile collector
+  3gpp-scanner metrics --db=database.db --format=prometheus > /var/lib/node_exporter/3gpp.prom`,
+		RunE: runMetrics,
+	}
+
+	cmd.Flags().StringVar(&metricsDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&metricsFormat, "format", metrics.FormatInflux, "Metrics format: "+strings.Join(metrics.Formats, " or "))
+	cmd.Flags().StringVar(&metricsURL, "url", "", "InfluxDB or Pushgateway URL to push to (default: print to stdout)")
+	cmd.Flags().StringVar(&metricsToken, "token", "", "InfluxDB API token (or user:password for InfluxDB 1.8)")
+	cmd.Flags().StringVar(&metricsOrg, "org", "", "InfluxDB organization")
+	cmd.Flags().StringVar(&metricsBucket, "bucket", "", "InfluxDB bucket (or database/retention-policy for InfluxDB 1.8)")
+	cmd.Flags().StringVar(&metricsJob, "job", "3gpp-scanner", "Pushgateway job name")
+	cmd.Flags().StringVar(&metricsInstance, "instance", "", "Pushgateway instance label, e.g. the scanning host")
+
+	return cmd
+}
+
+// validateMetricsFlags validates metrics command flags
+func validateMetricsFlags() error {
+	if metricsFormat != metrics.FormatInflux && metricsFormat != metrics.FormatPrometheus {
+		return fmt.Errorf("invalid format: %s (must be %s)", metricsFormat, strings.Join(metrics.Formats, " or "))
+	}
+	if metricsURL != "" {
+		u, err := url.Parse(metricsURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --url: %s (must be an http or https URL)", metricsURL)
+		}
+	}
+	influxFlags := metricsToken != "" || metricsOrg != "" || metricsBucket != ""
+	if influxFlags && metricsFormat != metrics.FormatInflux {
+		return fmt.Errorf("--token, --org and --bucket require --format=influx")
+	}
+	if influxFlags && metricsURL == "" {
+		return fmt.Errorf("--token, --org and --bucket require --url")
+	}
+	if metricsURL != "" && metricsFormat == metrics.FormatInflux && metricsBucket == "" {
+		return fmt.Errorf("--bucket required to push to InfluxDB")
+	}
+	if metricsInstance != "" && metricsFormat != metrics.FormatPrometheus {
+		return fmt.Errorf("--instance requires --format=prometheus")
+	}
+	if metricsJob == "" {
+		return fmt.Errorf("--job cannot be empty")
+	}
+	return nil
+}
+
+// Metrics command implementation
+func runMetrics(cmd *cobra.Command, args []string) error {
+	if err := validateMetricsFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(metricsDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	st, err := db.GetStats()
+	if err != nil {
+		return fmt.Errorf("failed to get statistics: %w", err)
+	}
+	runs, err := db.QueryRuns()
+	if err != nil {
+		return fmt.Errorf("failed to query runs: %w", err)
+	}
+	snapshot := metrics.NewSnapshot(st, runs)
+
+	if metricsURL == "" {
+		return metrics.Encode(os.Stdout, snapshot, metricsFormat)
+	}
+
+	pusher := metrics.NewPusher(userAgent())
+	ctx := context.Background()
+	if metricsFormat == metrics.FormatInflux {
+		err = pusher.PushInflux(ctx, metrics.InfluxTarget{URL: metricsURL, Org: metricsOrg, Bucket: metricsBucket, Token: metricsToken}, snapshot)
+	} else {
+		err = pusher.PushGateway(ctx, metricsURL, metricsJob, metricsInstance, snapshot)
+	}
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Pushed metrics of %d FQDNs, %d operators and %d runs to %s\n", snapshot.FQDNs, len(snapshot.OperatorCounts), len(runs), metricsURL)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/monitor"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Monitor command flags
+	monitorDB         string
+	monitorWatchlist  string
+	monitorInterval   time.Duration
+	monitorCertWithin = dayDuration(monitor.DefaultConfig().CertWithin)
+	monitorCertPort   int
+	monitorTimeout    time.Duration
+	monitorWorkers    int
+	monitorWebhook    string
+	monitorFormat     string
+	monitorMassShare  float64
+	monitorMassMin    int
+	monitorVolatility string
+)
+
+func monitorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Watch your own networks' FQDNs and alert on regressions",
+		Long: `Monitor the FQDNs of the networks in a watchlist file, typically your own
+PLMNs, and alert when one stops resolving, resolves to other addresses than
+before, or presents a TLS certificate close to expiry. Alerts acknowledged
+with 'ack add' are not reported.
+
+The watchlist holds one PLMN (MCC-MNC, e.g. 262-01) or operator name per
+line; # starts a comment. The FQDNs of those networks and their expected
+addresses are read from the database, so scan them first.
+
+Without --interval one check runs, e.g. from cron. With --interval the
+command runs as a daemon and checks again at that interval until stopped,
+alerting once per change: an FQDN that stays unresolved is not reported
+again until it resolves, and changed addresses become the new baseline.
+Alerts are printed and, with --webhook, posted as JSON (with a "text" field
+for Slack and Mattermost incoming webhooks).
+
+When a large share of one operator's FQDNs stop resolving or change addresses
+in the same check (--mass-change, at least --mass-change-min FQDNs), a
+mass-change alert flags a likely migration or incident. The daemon counts
+the changes of every FQDN; --volatility writes these metrics as JSON after
+each check, telling volatile FQDNs (e.g. round-robin DNS) from stable ones.`,
+		Example: `  # One check of our own networks
+  3gpp-scanner monitor --db=database.db --watchlist=our-plmns.txt
+
+  # Run as a daemon, checking hourly and posting alerts to a chat webhook
+  3gpp-scanner monitor --db=database.db --watchlist=our-plmns.txt \
+    --interval=1h --webhook=https://hooks.slack.com/services/...
+
+  # Daemon keeping per-FQDN volatility metrics, flagging a third of an operator changing at once
+  3gpp-scanner monitor --db=database.db --watchlist=our-plmns.txt --interval=15m \
+    --volatility=volatility.json --mass-change=0.3
+
+  # Warn two weeks before certificates expire, as JSON lines
+  3gpp-scanner monitor --db=database.db --watchlist=our-plmns.txt --cert-within=14d --format=json`,
+		RunE: runMonitor,
+	}
+
+	defaults := monitor.DefaultConfig()
+	cmd.Flags().StringVar(&monitorDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&monitorWatchlist, "watchlist", "", "File of monitored PLMNs (MCC-MNC) and operator names, one per line")
+	cmd.Flags().DurationVar(&monitorInterval, "interval", 0, "Check again at this interval until stopped (default: check once)")
+	cmd.Flags().Var(&monitorCertWithin, "cert-within", "Alert for certificates expiring within this time, e.g. 30d or 72h (0 disables certificate checks)")
+	cmd.Flags().IntVar(&monitorCertPort, "cert-port", defaults.CertPort, "TLS port whose certificate is checked")
+	cmd.Flags().DurationVar(&monitorTimeout, "timeout", defaults.Timeout, "Timeout per lookup and TLS handshake")
+	cmd.Flags().IntVar(&monitorWorkers, "workers", defaults.Workers, "Number of FQDNs checked in parallel")
+	cmd.Flags().StringVar(&monitorWebhook, "webhook", "", "URL alerts are posted to as JSON")
+	cmd.Flags().StringVar(&monitorFormat, "format", "text", "Alert output format: text or json (one alert per line)")
+	cmd.Flags().Float64Var(&monitorMassShare, "mass-change", defaults.MassChangeShare, "Share of an operator's FQDNs changing in one check that raises a mass-change alert (0 disables)")
+	cmd.Flags().IntVar(&monitorMassMin, "mass-change-min", defaults.MassChangeMin, "Minimum number of changed FQDNs for a mass-change alert")
+	cmd.Flags().StringVar(&monitorVolatility, "volatility", "", "JSON file the per-FQDN volatility metrics are written to after each check")
+	cmd.MarkFlagRequired("watchlist")
+
+	return cmd
+}
+
+// dayDuration is a duration flag that also accepts whole days, e.g. 30d
+type dayDuration time.Duration
+
+func (d *dayDuration) String() string {
+	duration := time.Duration(*d)
+	if duration > 0 && duration%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(duration/(24*time.Hour))) + "d"
+	}
+	return duration.String()
+}
+
+func (d *dayDuration) Set(s string) error {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid number of days: %s", s)
+		}
+		*d = dayDuration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = dayDuration(duration)
+	return nil
+}
+
+func (d *dayDuration) Type() string {
+	return "duration"
+}
+
+// validateMonitorFlags validates monitor command flags
+func validateMonitorFlags() error {
+	if monitorInterval < 0 {
+		return fmt.Errorf("--interval cannot be negative")
+	}
+	if monitorInterval > 0 && monitorInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+	if monitorCertWithin < 0 {
+		return fmt.Errorf("--cert-within cannot be negative")
+	}
+	if monitorCertPort < 1 || monitorCertPort > 65535 {
+		return fmt.Errorf("--cert-port must be between 1 and 65535")
+	}
+	if monitorTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	if monitorWorkers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	if monitorMassShare < 0 || monitorMassShare > 1 {
+		return fmt.Errorf("--mass-change must be between 0 and 1")
+	}
+	if monitorMassMin < 1 {
+		return fmt.Errorf("--mass-change-min must be at least 1")
+	}
+	return validateAlertOutput(monitorWebhook, monitorFormat)
+}
+
+// validateAlertOutput validates the --webhook and --format flags of
+// commands that report alerts
+func validateAlertOutput(webhook, format string) error {
+	if webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --webhook: %s (must be an http or https URL)", webhook)
+		}
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", format)
+	}
+	return nil
+}
+
+// watchTargets reads the FQDNs of the watched networks and their stored
+// addresses from the database
+func watchTargets(db *database.DB, list *monitor.Watchlist) ([]monitor.Target, error) {
+	seen := make(map[string]bool)
+	var fqdns []string
+	add := func(found []string, err error) error {
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		for _, fqdn := range found {
+			if !seen[fqdn] {
+				seen[fqdn] = true
+				fqdns = append(fqdns, fqdn)
+			}
+		}
+		return nil
+	}
+	for _, plmn := range list.PLMNs {
+		if err := add(db.QueryByMNCMCC(plmn.MNC, plmn.MCC)); err != nil {
+			return nil, err
+		}
+	}
+	for _, operator := range list.Operators {
+		if err := add(db.QueryByOperator(operator)); err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := db.QueryDetails(fqdns)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]monitor.Target, len(details))
+	for i, detail := range details {
+		targets[i] = monitor.Target{FQDN: detail.FQDN, Operator: detail.Operator, IPs: detail.IPs}
+	}
+	return targets, nil
+}
+
+// reportAlerts prints alerts in format (text or json) and posts them to
+// webhook, if set
+func reportAlerts(ctx context.Context, alerts []monitor.Alert, format, webhook string) error {
+	for _, alert := range alerts {
+		if format == "json" {
+			line, err := json.Marshal(alert)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		} else {
+			fmt.Println(alert)
+		}
+	}
+	if webhook == "" || len(alerts) == 0 {
+		return nil
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	if err := monitor.PostWebhook(ctx, client, webhook, userAgent(), alerts); err != nil {
+		return fmt.Errorf("failed to post alerts: %w", err)
+	}
+	return nil
+}
+
+func runMonitor(cmd *cobra.Command, args []string) error {
+	if err := validateMonitorFlags(); err != nil {
+		return err
+	}
+	list, err := monitor.LoadWatchlist(monitorWatchlist)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(monitorDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	targets, err := watchTargets(db, list)
+	db.Close()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no FQDNs of the watched networks in %s (scan them first)", monitorDB)
+	}
+
+	mon := monitor.New(targets, monitor.Config{
+		CertPort:        monitorCertPort,
+		CertWithin:      time.Duration(monitorCertWithin),
+		Timeout:         monitorTimeout,
+		Workers:         monitorWorkers,
+		MassChangeShare: monitorMassShare,
+		MassChangeMin:   monitorMassMin,
+	})
+	summaries := !quiet && monitorFormat == "text"
+	if summaries {
+		fmt.Printf("Monitoring %d FQDNs of %d PLMNs and %d operators\n", mon.Len(), len(list.PLMNs), len(list.Operators))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	for {
+		alerts := mon.Check(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		acks, err := loadAcks(monitorDB)
+		if err != nil {
+			// A daemon keeps alerting when the database is busy
+			if monitorInterval == 0 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: failed to read acknowledgments: %v\n", err)
+		}
+		alerts, acked := dropAcked(alerts, acks)
+		if err := reportAlerts(ctx, alerts, monitorFormat, monitorWebhook); err != nil {
+			// An unreachable webhook must not stop monitoring the FQDNs
+			if monitorInterval == 0 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if monitorVolatility != "" {
+			if err := output.ExportJSON(mon.Volatility(), monitorVolatility); err != nil {
+				return fmt.Errorf("failed to write volatility metrics: %w", err)
+			}
+		}
+		if summaries {
+			fmt.Printf("%s  checked %d FQDNs, %d alerts, %d acknowledged\n", time.Now().Format("2006-01-02 15:04:05"), mon.Len(), len(alerts), acked)
+		}
+
+		if monitorInterval == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(monitorInterval):
+		}
+	}
+}
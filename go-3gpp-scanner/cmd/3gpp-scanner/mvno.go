@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// MVNO command flags
+	mvnoFile          string
+	mvnoMCCMNC        string
+	mvnoMinConfidence float64
+	mvnoFormat        string
+)
+
+func mvnoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mvno",
+		Short: "Suggest which MCC-MNCs ride on which host networks",
+		Long: `Analyze a JSON scan export for shared infrastructure between MCC-MNCs and
+suggest which ones (typically MVNOs) ride on which host networks. Signals are
+CNAMEs into another operator's zone, shared CNAME targets, shared IPs and
+shared /24 prefixes, combined into a confidence score between 0 and 1.
+
+The MCC-MNC list adds operator names and marks entries noted as MVNOs.`,
+		Example: `  # Mapping table from a scan export
+  3gpp-scanner mvno --file=results.json
+
+  # Only strong suggestions, as JSON
+  3gpp-scanner mvno --file=results.json --min-confidence=0.6 --format=json`,
+		RunE: runMVNO,
+	}
+
+	cmd.Flags().StringVarP(&mvnoFile, "file", "f", "", "JSON scan export to analyze")
+	cmd.Flags().StringVar(&mvnoMCCMNC, "mccmnc-file", "", "MCC-MNC list for operator names and MVNO hints (default: cached list if present)")
+	cmd.Flags().Float64Var(&mvnoMinConfidence, "min-confidence", 0.4, "Minimum confidence (0-1) of reported mappings")
+	cmd.Flags().StringVar(&mvnoFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateMVNOFlags validates mvno command flags
+func validateMVNOFlags() error {
+	if mvnoFile == "" {
+		return fmt.Errorf("--file required")
+	}
+	if mvnoMinConfidence < 0 || mvnoMinConfidence > 1 {
+		return fmt.Errorf("--min-confidence must be between 0 and 1")
+	}
+	if mvnoFormat != "text" && mvnoFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", mvnoFormat)
+	}
+	return nil
+}
+
+// MVNO command implementation
+func runMVNO(cmd *cobra.Command, args []string) error {
+	if err := validateMVNOFlags(); err != nil {
+		return err
+	}
+
+	results, err := stats.LoadResults(mvnoFile)
+	if err != nil {
+		return fmt.Errorf("failed to read results: %w", err)
+	}
+
+	mappings := stats.MapHostNetworks(results, loadMCCMNCList(mvnoMCCMNC), mvnoMinConfidence)
+
+	if mvnoFormat == "json" {
+		if err := output.ExportJSON(mappings, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(stats.FormatHostMappings(mappings))
+	return nil
+}
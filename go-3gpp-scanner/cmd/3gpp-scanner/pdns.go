@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/pdns"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Passive DNS command flags
+	pdnsDB       string
+	pdnsProvider string
+	pdnsAPIKey   string
+	pdnsFQDN     string
+	pdnsMCC      string
+	pdnsMNC      string
+	pdnsLimit    int
+	pdnsDelay    int
+)
+
+// pdnsMaxFailures is the number of failed lookups in a row after which
+// pdns gives up, e.g. on an invalid API key or exhausted quota
+const pdnsMaxFailures = 3
+
+func pdnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pdns",
+		Short: "Collect historical resolutions of FQDNs from passive DNS",
+		Long: `Query a passive DNS provider for the addresses the FQDNs of the database
+resolved to in the past, and store them in the ip_history table with their
+first and last sighting. The history shows endpoints that moved, e.g. from an
+operator's own space into a cloud, and is part of the operator profile.
+
+Providers: dnsdb (Farsight DNSDB API v2) and virustotal (VirusTotal API v3;
+the public API allows four requests per minute, so use --delay=15000).
+Repeated runs widen the stored first/last sightings.`,
+		Example: `  # History of one operator's FQDNs from DNSDB
+  3gpp-scanner pdns --db=database.db --provider=dnsdb --api-key=KEY --mcc=262 --mnc=1
+
+  # One FQDN from VirusTotal, then show it in the profile
+  3gpp-scanner pdns --db=database.db --provider=virustotal --api-key=KEY --fqdn=epdg.epc.mnc001.mcc262.pub.3gppnetwork.org
+  3gpp-scanner profile --db=database.db --mcc=262 --mnc=1`,
+		RunE: runPDNS,
+	}
+
+	cmd.Flags().StringVar(&pdnsDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&pdnsProvider, "provider", "", "Passive DNS provider: "+strings.Join(pdns.Providers, " or "))
+	cmd.Flags().StringVar(&pdnsAPIKey, "api-key", "", "API key of the provider")
+	cmd.Flags().StringVar(&pdnsFQDN, "fqdn", "", "Only this FQDN")
+	cmd.Flags().StringVar(&pdnsMCC, "mcc", "", "Only FQDNs of this Mobile Country Code (with --mnc)")
+	cmd.Flags().StringVar(&pdnsMNC, "mnc", "", "Only FQDNs of this Mobile Network Code as listed, e.g. 01 or 001 (with --mcc)")
+	cmd.Flags().IntVar(&pdnsLimit, "limit", 0, "Query at most this many FQDNs (0 for all)")
+	cmd.Flags().IntVar(&pdnsDelay, "delay", 1000, "Delay between queries in milliseconds")
+
+	return cmd
+}
+
+// validatePDNSFlags validates pdns command flags
+func validatePDNSFlags() error {
+	if pdnsProvider == "" {
+		return fmt.Errorf("--provider required (%s)", strings.Join(pdns.Providers, " or "))
+	}
+	if _, err := pdns.New(pdnsProvider, ""); err != nil {
+		return err
+	}
+	if pdnsAPIKey == "" {
+		return fmt.Errorf("--api-key required")
+	}
+	if (pdnsMCC != "") != (pdnsMNC != "") {
+		return fmt.Errorf("--mcc and --mnc must be used together")
+	}
+	if err := validateCodeFlags(pdnsMCC, pdnsMNC); err != nil {
+		return err
+	}
+	if pdnsFQDN != "" && pdnsMCC != "" {
+		return fmt.Errorf("cannot specify both --fqdn and --mcc/--mnc")
+	}
+	if pdnsLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	if pdnsDelay < 0 {
+		return fmt.Errorf("--delay must not be negative")
+	}
+	return nil
+}
+
+// Passive DNS command implementation
+func runPDNS(cmd *cobra.Command, args []string) error {
+	if err := validatePDNSFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(pdnsDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	var fqdns []string
+	switch {
+	case pdnsFQDN != "":
+		fqdns = []string{strings.ToLower(strings.TrimSuffix(pdnsFQDN, "."))}
+	case pdnsMCC != "":
+		fqdns, err = db.QueryByMNCMCC(models.NormalizeMNC(pdnsMNC), models.NormalizeMCC(pdnsMCC))
+	default:
+		fqdns, err = db.GetAllFQDNs()
+	}
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	if pdnsLimit > 0 && len(fqdns) > pdnsLimit {
+		fqdns = fqdns[:pdnsLimit]
+	}
+
+	provider, err := pdns.New(pdnsProvider, pdnsAPIKey)
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Querying %s for %d FQDNs\n", provider.Name(), len(fqdns))
+	}
+
+	ctx := context.Background()
+	stored, failed, failedInRow := 0, 0, 0
+	for i, fqdn := range fqdns {
+		if i > 0 && pdnsDelay > 0 {
+			time.Sleep(time.Duration(pdnsDelay) * time.Millisecond)
+		}
+
+		history, err := provider.History(ctx, fqdn)
+		if err != nil {
+			failed++
+			failedInRow++
+			slog.Warn("passive DNS lookup failed", "fqdn", fqdn, "error", err)
+			if failedInRow >= pdnsMaxFailures {
+				return fmt.Errorf("giving up after %d failed lookups in a row: %w", failedInRow, err)
+			}
+			continue
+		}
+		failedInRow = 0
+
+		if err := db.SaveIPHistory(history); err != nil {
+			return fmt.Errorf("failed to store history: %w", err)
+		}
+		stored += len(history)
+		if !quiet && len(history) > 0 {
+			fmt.Printf("  %s: %d resolutions\n", fqdn, len(history))
+		}
+	}
+
+	if !quiet {
+		fmt.Printf("Stored %d resolutions of %d FQDNs (%d lookups failed)\n", stored, len(fqdns)-failed, failed)
+	}
+	return nil
+}
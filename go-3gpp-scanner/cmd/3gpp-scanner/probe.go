@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/probe"
+	"3gpp-scanner/internal/schema"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Probe command flags
+	probeFile      string
+	probeNames     []string
+	probePlugins   []string
+	probeWorkers   int
+	probeTimeout   int
+	probeOutputs   []string
+	probeSourceIP  string
+	probeInterface string
+	probeTelecom   bool
+	probeTelRate   float64
+	probeDB        string
+)
+
+func probeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "probe",
+		Short: "Run protocol probes and plugins against discovered hosts",
+		Long: `Run protocol probes against the FQDNs of a JSON scan export. Built-in probes
+are selected with --probes; custom checks attach as plugins: external programs
+speaking line-delimited JSON over stdin/stdout (see README, "Protocol Probes and Plugins").
+Each probe only runs against the subdomains it applies to.
+
+Built-in probes:
+  sip   SIP OPTIONS over UDP/TCP 5060 and TLS 5061 to ims and xcap hosts,
+        recording response codes and Server/User-Agent headers
+  gtp   GTP-C Echo Request (UDP 2123) to EPC hosts; needs
+        --enable-active-telecom-probes and is limited by --telecom-rate
+  stun  STUN Binding request (UDP 3478) to ePDG, stun and turn hosts,
+        recording the reflexive address and whether TURN is offered
+  ike   IKEv2 IKE_SA_INIT (UDP 500) to ePDG hosts, recording notify
+        payloads and Vendor IDs of the IKE stack
+  ntp   NTP mode 3 query (UDP 123) to ntp hosts, recording stratum,
+        reference ID and clock offset
+  tls   TLS handshake (TCP 443) to xcap, bsf and aes hosts, recording the
+        certificate subject, issuer, validity and SANs (see alerts cert-expiry)`,
+		Example: `  # SIP OPTIONS against IMS hosts
+  3gpp-scanner probe --file=results.json --probes=sip
+
+  # GTP-C echo against EPC hosts, at most one request every two seconds
+  3gpp-scanner probe --file=results.json --probes=gtp --enable-active-telecom-probes --telecom-rate=0.5
+
+  # Reflexive addresses seen by STUN servers next to ePDGs
+  3gpp-scanner probe --file=results.json --probes=stun
+
+  # Exposed operator NTP servers found with scan --mode=ntp
+  3gpp-scanner probe --file=ntp.json --probes=ntp
+
+  # Certificates of XCAP, BSF and AES hosts, stored for alerts cert-expiry
+  3gpp-scanner probe --file=results.json --probes=tls --db=database.db
+
+  # Run a custom plugin against every discovered FQDN
+  3gpp-scanner probe --file=results.json --plugin=./plugins/check-banner
+
+  # Plugin with arguments, results exported to CSV
+  3gpp-scanner probe --file=results.json --plugin="./check.py --port 8443" --output=probes.csv`,
+		RunE: runProbe,
+	}
+
+	cmd.Flags().StringVarP(&probeFile, "file", "f", "", "JSON scan export with the hosts to probe")
+	cmd.Flags().StringSliceVar(&probeNames, "probes", nil, "Built-in probes to run (comma-separated)")
+	cmd.Flags().StringArrayVar(&probePlugins, "plugin", nil, "Plugin program to run, with optional arguments (repeatable)")
+	cmd.Flags().IntVarP(&probeWorkers, "workers", "w", 5, "Number of concurrent probe workers")
+	cmd.Flags().IntVar(&probeTimeout, "timeout", 3000, "Timeout per probe in milliseconds")
+	cmd.Flags().StringSliceVarP(&probeOutputs, "output", "o", nil, "Output file (json or csv); repeat for several files")
+	cmd.Flags().StringVar(&probeDB, "db", "", "Database to store the results in (listed by serve at /api/results)")
+	cmd.Flags().StringVar(&probeSourceIP, "source-ip", "", "Local IP address to send probes from")
+	cmd.Flags().StringVar(&probeInterface, "interface", "", "Network interface to send probes from")
+	cmd.Flags().BoolVar(&probeTelecom, "enable-active-telecom-probes", false, "Allow probes speaking telecom control protocols (gtp)")
+	cmd.Flags().Float64Var(&probeTelRate, "telecom-rate", 1, "Maximum active telecom requests per second across all workers")
+	addUnifiedFlag(cmd)
+	addValidateOutputFlag(cmd)
+	addExcludeFlag(cmd)
+	addScopeFlag(cmd)
+	addAuditFlag(cmd)
+
+	return cmd
+}
+
+// validateProbeFlags validates probe command flags
+func validateProbeFlags() error {
+	if probeFile == "" {
+		return fmt.Errorf("--file required")
+	}
+	if len(probeNames) == 0 && len(probePlugins) == 0 {
+		return fmt.Errorf("at least one of --probes or --plugin required")
+	}
+	for _, spec := range probePlugins {
+		if strings.TrimSpace(spec) == "" {
+			return fmt.Errorf("--plugin cannot be empty")
+		}
+	}
+	if probeWorkers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if probeTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	if probeTelRate <= 0 {
+		return fmt.Errorf("--telecom-rate must be positive")
+	}
+	if err := validateOutputPaths(probeOutputs, ".json", ".csv"); err != nil {
+		return err
+	}
+	if err := validateUnifiedFlag(probeOutputs); err != nil {
+		return err
+	}
+	if err := validateValidateOutputFlag(probeOutputs...); err != nil {
+		return err
+	}
+	binding := netbind.Binding{SourceIP: probeSourceIP, Interface: probeInterface}
+	return binding.Validate()
+}
+
+// Probe command implementation
+func runProbe(cmd *cobra.Command, args []string) error {
+	if err := validateProbeFlags(); err != nil {
+		return err
+	}
+
+	targets, err := stats.LoadResults(probeFile)
+	if err != nil {
+		return fmt.Errorf("failed to read results: %w", err)
+	}
+
+	config := &models.ProbeConfig{
+		Timeout:       time.Duration(probeTimeout) * time.Millisecond,
+		Workers:       probeWorkers,
+		SourceIP:      probeSourceIP,
+		Interface:     probeInterface,
+		ActiveTelecom: probeTelecom,
+		TelecomRate:   probeTelRate,
+	}
+
+	var probes []probe.Probe
+	for _, name := range probeNames {
+		p, err := probe.New(strings.TrimSpace(name), config)
+		if errors.Is(err, probe.ErrActiveTelecom) {
+			return fmt.Errorf("%w: pass --enable-active-telecom-probes to send telecom control traffic", err)
+		}
+		if err != nil {
+			return err
+		}
+		probes = append(probes, p)
+	}
+	for _, spec := range probePlugins {
+		fields := strings.Fields(spec)
+		plugin, err := probe.StartPlugin(fields[0], fields[1:]...)
+		if err != nil {
+			return err
+		}
+		defer plugin.Close()
+		probes = append(probes, plugin)
+	}
+
+	excludeList, err := loadExcludeList()
+	if err != nil {
+		return err
+	}
+	engagement, err := loadScope(func() []models.MCCMNCEntry { return loadMCCMNCList("") })
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := openAuditLog("probe")
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	runner := probe.NewRunner(config, probes)
+	runner.SetExclude(targetChecker(excludeList, engagement))
+	runner.SetAudit(auditLog)
+	total := runner.Jobs(targets)
+	if !quiet {
+		fmt.Printf("Running %d probes against %d hosts (%d checks)\n", len(probes), len(targets), total)
+	}
+
+	var bar *progressbar.ProgressBar
+	if showProgress() && total > 0 {
+		bar = newScanProgressBar(total, "Probing")
+		runner.SetProgressCallback(func(current, total, successful int) {
+			bar.Set(current)
+		})
+	}
+
+	results := runner.Run(context.Background(), targets)
+	if bar != nil {
+		bar.Finish()
+	}
+	reportExcluded(excludeList)
+	reportOutOfScope(engagement)
+	if err := closeAuditLog(auditLog); err != nil {
+		return err
+	}
+
+	if probeDB != "" {
+		if err := saveProbeResults(results); err != nil {
+			return err
+		}
+	}
+
+	if len(probeOutputs) > 0 {
+		return exportAll(probeOutputs, func(path string) error {
+			return exportProbeResults(results, path)
+		})
+	}
+
+	if !quiet {
+		output.PrintProbeResults(results)
+	}
+
+	return nil
+}
+
+// saveProbeResults stores probe results in --db in the common envelope
+func saveProbeResults(results []models.ProbeResult) error {
+	db, err := openDB(probeDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveResults(models.Results(results)); err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Saved %d probe results to %s\n", len(results), probeDB)
+	}
+	return nil
+}
+
+func exportProbeResults(results []models.ProbeResult, filePath string) error {
+	if unifiedOutput {
+		return exportUnifiedResults(models.Results(results), filePath)
+	}
+	results = exportTimes(results, func(r *models.ProbeResult) *time.Time { return &r.Timestamp })
+	if strings.ToLower(filepath.Ext(filePath)) == ".csv" {
+		return output.ExportProbeResultsCSV(results, filePath)
+	}
+	return exportJSON(results, filePath, schema.ProbeResult)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/profile"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Profile command flags
+	profileMCC     string
+	profileMNC     string
+	profileDB      string
+	profileResults string
+	profilePings   string
+	profileFormat  string
+	profileMCCMNC  string
+)
+
+func profileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Show everything known about one operator",
+		Long: `Aggregate everything known about one MCC/MNC into a single dossier: operator
+names, FQDNs grouped by type, analyst notes and passive DNS history (see pdns)
+from the database, plus IP addresses, first/last seen times and ping history
+from optional JSON scan and ping exports. Sections without data are listed as
+not available.`,
+		Example: `  # Text dossier from the database
+  3gpp-scanner profile --mcc=262 --mnc=01 --db=database.db
+
+  # HTML dossier including IPs and ping history
+  3gpp-scanner profile --mcc=262 --mnc=01 --results=results.json --pings=ping.json --format=html > 262-01.html`,
+		RunE: runProfile,
+	}
+
+	cmd.Flags().StringVar(&profileMCC, "mcc", "", "Mobile Country Code")
+	cmd.Flags().StringVar(&profileMNC, "mnc", "", "Mobile Network Code as listed, e.g. 01 or 001 (different networks)")
+	cmd.Flags().StringVar(&profileDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&profileResults, "results", "", "JSON scan export for IPs and first/last seen")
+	cmd.Flags().StringVar(&profilePings, "pings", "", "JSON ping export for ping history")
+	cmd.Flags().StringVar(&profileFormat, "format", "text", "Output format: text, json or html")
+	cmd.Flags().StringVar(&profileMCCMNC, "mccmnc-file", "", "MCC-MNC list for the country name (default: cached list if present)")
+
+	return cmd
+}
+
+// validateProfileFlags validates profile command flags
+func validateProfileFlags() error {
+	if profileMCC == "" || profileMNC == "" {
+		return fmt.Errorf("--mcc and --mnc required")
+	}
+	if err := validateCodeFlags(profileMCC, profileMNC); err != nil {
+		return err
+	}
+	switch profileFormat {
+	case "text", "json", "html":
+	default:
+		return fmt.Errorf("invalid format: %s (must be text, json or html)", profileFormat)
+	}
+	return nil
+}
+
+// Profile command implementation
+func runProfile(cmd *cobra.Command, args []string) error {
+	if err := validateProfileFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(profileDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	mcc, mnc := models.NormalizeMCC(profileMCC), models.NormalizeMNC(profileMNC)
+	var src profile.Sources
+	src.Operators, err = db.QueryOperatorNames(mnc, mcc)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	src.FQDNs, err = db.QueryByMNCMCC(mnc, mcc)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	src.Notes, err = db.QueryNotes("", "")
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	src.History, err = db.QueryIPHistory("")
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if profileResults != "" {
+		src.Results, err = stats.LoadResults(profileResults)
+		if err != nil {
+			return fmt.Errorf("failed to read results: %w", err)
+		}
+		if src.Results == nil {
+			src.Results = []models.DNSResult{}
+		}
+	}
+	if profilePings != "" {
+		src.Pings, err = stats.LoadPingResults(profilePings)
+		if err != nil {
+			return fmt.Errorf("failed to read ping results: %w", err)
+		}
+		if src.Pings == nil {
+			src.Pings = []models.PingResult{}
+		}
+	}
+	if names := loadCountryNames(profileMCCMNC); names != nil {
+		src.Country = names[mcc]
+	}
+
+	p := profile.Build(mcc, mnc, src)
+
+	switch profileFormat {
+	case "json":
+		if err := output.ExportJSON(p, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+	case "html":
+		page, err := profile.HTML(p)
+		if err != nil {
+			return fmt.Errorf("HTML generation failed: %w", err)
+		}
+		fmt.Print(page)
+	default:
+		fmt.Print(profile.FormatText(p))
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/customreport"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Report command flags
+	reportTemplate string
+	reportResults  string
+	reportPings    string
+	reportDB       string
+	reportOutput   string
+	reportHTML     bool
+	reportVars     []string
+	reportMCCMNC   string
+)
+
+func reportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render a custom report from a template",
+		Long: `Render a Go template over scan results, statistics and run metadata to
+produce your own deliverable. Templates ending in .html or .htm (optionally
+followed by .tmpl) are HTML templates, which escape values for their
+context; others are plain text templates.
+
+The template sees:
+
+  .Meta      Generated, Version, Template, Results, Pings, Database,
+             FirstSeen and LastSeen (range of result timestamps)
+  .Vars      Values from --set, e.g. {{.Vars.customer}}
+  .Results   DNS results from --results
+  .Pings     Ping results from --pings
+  .Stats     Statistics of --results, or of --db without results
+  .Latency   Latency summary of --pings
+  .Vendors   FQDN to vendor, .Tags FQDN to tags, .Notes (from --db)
+
+Functions besides the template builtins: join, upper, lower, date LAYOUT
+TIME, byCount MAP (pairs by descending count), top N PAIRS, percent PART
+WHOLE, ms DURATION and add.`,
+		Example: `  # Branded HTML report from a scan export and the database
+  3gpp-scanner report --template=report.html.tmpl --results=results.json \
+    --db=database.db --set=customer="Example Telecom" --output=report.html
+
+  # Plain text summary with ping latency
+  3gpp-scanner report --template=summary.tmpl --results=results.json --pings=ping.json`,
+		Args: cobra.NoArgs,
+		RunE: runReport,
+	}
+
+	cmd.Flags().StringVarP(&reportTemplate, "template", "t", "", "Go template file (required)")
+	cmd.Flags().StringVar(&reportResults, "results", "", "JSON scan export")
+	cmd.Flags().StringVar(&reportPings, "pings", "", "JSON ping export")
+	cmd.Flags().StringVar(&reportDB, "db", "", "Database for statistics, vendors, tags and notes")
+	cmd.Flags().StringVarP(&reportOutput, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().BoolVar(&reportHTML, "html", false, "Treat the template as HTML regardless of its file name")
+	cmd.Flags().StringArrayVar(&reportVars, "set", nil, "Template variable as key=value, available as .Vars.key (repeatable)")
+	cmd.Flags().StringVar(&reportMCCMNC, "mccmnc-file", "", "MCC-MNC list for country counts and names (default: cached list if present)")
+
+	return cmd
+}
+
+// validateReportFlags validates report command flags
+func validateReportFlags() error {
+	if reportTemplate == "" {
+		return fmt.Errorf("--template required")
+	}
+	if reportResults == "" && reportPings == "" && reportDB == "" {
+		return fmt.Errorf("at least one of --results, --pings or --db required")
+	}
+	for _, v := range reportVars {
+		if key, _, ok := strings.Cut(v, "="); !ok || key == "" {
+			return fmt.Errorf("invalid --set: %s (must be key=value)", v)
+		}
+	}
+	return nil
+}
+
+// Report command implementation
+func runReport(cmd *cobra.Command, args []string) error {
+	if err := validateReportFlags(); err != nil {
+		return err
+	}
+
+	tmpl, err := customreport.ParseFile(reportTemplate, reportHTML || customreport.IsHTML(reportTemplate))
+	if err != nil {
+		return err
+	}
+
+	data := &customreport.Data{
+		Meta: customreport.Meta{
+			Generated: time.Now(),
+			Version:   version,
+			Template:  reportTemplate,
+			Results:   reportResults,
+			Pings:     reportPings,
+			Database:  reportDB,
+		},
+		Vars: make(map[string]string),
+	}
+	for _, v := range reportVars {
+		key, value, _ := strings.Cut(v, "=")
+		data.Vars[key] = value
+	}
+
+	if reportResults != "" {
+		data.Results, err = stats.LoadResults(reportResults)
+		if err != nil {
+			return fmt.Errorf("failed to read results: %w", err)
+		}
+		data.Stats = stats.NewAnalyzer().AnalyzeResults(data.Results)
+	}
+	if reportPings != "" {
+		data.Pings, err = stats.LoadPingResults(reportPings)
+		if err != nil {
+			return fmt.Errorf("failed to read ping results: %w", err)
+		}
+		data.Latency = stats.SummarizeLatency(data.Pings, len(data.Pings), loadCountryNames(reportMCCMNC))
+	}
+	data.SetTimes()
+
+	if reportDB != "" {
+		db, err := openDB(reportDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+
+		if data.Stats == nil {
+			if data.Stats, err = db.GetStats(); err != nil {
+				return fmt.Errorf("stats query failed: %w", err)
+			}
+		}
+		if data.Vendors, err = db.QueryVendors(); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if data.Tags, err = db.FQDNTags(); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if data.Notes, err = db.QueryNotes("", ""); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+	}
+	if data.Stats != nil && len(data.Stats.CountryCounts) == 0 {
+		if entries := loadMCCMNCList(reportMCCMNC); entries != nil {
+			data.Stats.CountryCounts = stats.CountryCounts(data.Stats.MCCDistribution, stats.MCCCountries(entries))
+		}
+	}
+
+	// Render fully before writing, so a template error leaves no partial file
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	if reportOutput == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(reportOutput, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Report written to %s\n", reportOutput)
+	}
+	return nil
+}
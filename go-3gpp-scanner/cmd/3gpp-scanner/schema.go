@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/schema"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Schema command flags
+	schemaDir      string
+	schemaValidate string
+
+	// Export validation flag (scan, ping, probe and zoneinfo)
+	validateOutput bool
+)
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [NAME]",
+		Short: "Print the JSON Schemas of exported results",
+		Long: `Print the JSON Schema (draft 2020-12) of an exported result type, or list the
+schemas without a name. The schemas document the JSON exports downstream
+integrations consume:
+
+  dns-result    scan --output=*.json
+  ping-result   ping --output=*.json
+  probe-result  probe --output=*.json
+  result        --unified exports and GET /api/results
+  zone-info     zoneinfo --output=*.json
+
+--dir writes all schemas to a directory for publishing, --validate checks an
+existing export against a schema. scan, ping, probe and zoneinfo accept
+--validate-output to check their own JSON exports as they are written.`,
+		Example: `  3gpp-scanner schema
+  3gpp-scanner schema dns-result > dns-result.schema.json
+  3gpp-scanner schema --dir=schemas/
+  3gpp-scanner schema dns-result --validate=results.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runSchema,
+	}
+
+	cmd.Flags().StringVar(&schemaDir, "dir", "", "Write all schemas to this directory as NAME.schema.json")
+	cmd.Flags().StringVar(&schemaValidate, "validate", "", "Validate this JSON file against the schema instead of printing it")
+
+	return cmd
+}
+
+// validateSchemaFlags validates schema command flags
+func validateSchemaFlags(args []string) error {
+	if len(args) > 0 {
+		if _, err := schema.Get(args[0]); err != nil {
+			return err
+		}
+	}
+	if schemaDir != "" && len(args) > 0 {
+		return fmt.Errorf("--dir writes all schemas; cannot specify a name")
+	}
+	if schemaValidate != "" && len(args) == 0 {
+		return fmt.Errorf("--validate requires a schema name")
+	}
+	if schemaValidate != "" && schemaDir != "" {
+		return fmt.Errorf("cannot specify both --validate and --dir")
+	}
+	return nil
+}
+
+// Schema command implementation
+func runSchema(cmd *cobra.Command, args []string) error {
+	if err := validateSchemaFlags(args); err != nil {
+		return err
+	}
+
+	switch {
+	case schemaValidate != "":
+		if err := schema.ValidateFile(args[0], schemaValidate); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("%s matches schema %s\n", schemaValidate, args[0])
+		}
+		return nil
+
+	case schemaDir != "":
+		if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		for _, name := range schema.Names() {
+			data, _ := schema.Get(name)
+			path := filepath.Join(schemaDir, name+".schema.json")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			if !quiet {
+				fmt.Printf("Wrote %s\n", path)
+			}
+		}
+		return nil
+
+	case len(args) == 1:
+		data, _ := schema.Get(args[0])
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	for _, name := range schema.Names() {
+		data, _ := schema.Get(name)
+		var doc struct {
+			Title string `json:"title"`
+		}
+		json.Unmarshal(data, &doc)
+		fmt.Printf("%-14s %s\n", name, doc.Title)
+	}
+	return nil
+}
+
+// addValidateOutputFlag adds --validate-output to a command with JSON exports
+func addValidateOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&validateOutput, "validate-output", false, "Check JSON --output files against their published schema (see the schema command)")
+}
+
+// validateValidateOutputFlag checks that --validate-output has a JSON file
+// to check
+func validateValidateOutputFlag(paths ...string) error {
+	if !validateOutput {
+		return nil
+	}
+	for _, path := range paths {
+		if strings.ToLower(filepath.Ext(path)) == ".json" {
+			return nil
+		}
+	}
+	return fmt.Errorf("--validate-output requires a .json --output")
+}
+
+// exportJSON writes a JSON export and, with --validate-output, checks it
+// against the schema of its result type
+func exportJSON(data interface{}, filePath, schemaName string) error {
+	if err := output.ExportJSON(data, filePath); err != nil {
+		return err
+	}
+	if !validateOutput {
+		return nil
+	}
+	return schema.ValidateFile(schemaName, filePath)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Score command flags
+	scoreFile     string
+	scorePings    string
+	scoreProbes   string
+	scoreMinScore float64
+	scoreFormat   string
+)
+
+func scoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "score",
+		Short: "Score how likely discovered endpoints are live infrastructure",
+		Long: `Score every FQDN of a JSON scan export between 0 and 1 by how likely it is
+real, live infrastructure rather than a stale DNS record:
+
+  DNS answer                              0.2
+  reachable (any successful ping)         0.3
+  valid certificate (ping --method=https) 0.2
+  IKE response (probe --probes=ike)       0.2
+  any other probe response                0.1
+
+Endpoints are listed from the highest to the lowest score.`,
+		Example: `  # Score from DNS alone, then with ping and probe evidence
+  3gpp-scanner score --file=results.json
+  3gpp-scanner score --file=results.json --pings=https.json --probes=probes.json
+
+  # Only likely live endpoints, as JSON
+  3gpp-scanner score --file=results.json --pings=https.json --probes=probes.json --min-score=0.5 --format=json`,
+		RunE: runScore,
+	}
+
+	cmd.Flags().StringVarP(&scoreFile, "file", "f", "", "JSON scan export to score")
+	cmd.Flags().StringVar(&scorePings, "pings", "", "JSON ping export (any method; https adds certificate validity)")
+	cmd.Flags().StringVar(&scoreProbes, "probes", "", "JSON probe export")
+	cmd.Flags().Float64Var(&scoreMinScore, "min-score", 0, "Only list endpoints scoring at least this (0-1)")
+	cmd.Flags().StringVar(&scoreFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateScoreFlags validates score command flags
+func validateScoreFlags() error {
+	if scoreFile == "" {
+		return fmt.Errorf("--file required")
+	}
+	if scoreMinScore < 0 || scoreMinScore > 1 {
+		return fmt.Errorf("--min-score must be between 0 and 1")
+	}
+	if scoreFormat != "text" && scoreFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", scoreFormat)
+	}
+	return nil
+}
+
+// Score command implementation
+func runScore(cmd *cobra.Command, args []string) error {
+	if err := validateScoreFlags(); err != nil {
+		return err
+	}
+
+	results, err := stats.LoadResults(scoreFile)
+	if err != nil {
+		return fmt.Errorf("failed to read results: %w", err)
+	}
+	var pings []models.PingResult
+	if scorePings != "" {
+		if pings, err = stats.LoadPingResults(scorePings); err != nil {
+			return fmt.Errorf("failed to read ping results: %w", err)
+		}
+	}
+	var probes []models.ProbeResult
+	if scoreProbes != "" {
+		if probes, err = stats.LoadProbeResults(scoreProbes); err != nil {
+			return fmt.Errorf("failed to read probe results: %w", err)
+		}
+	}
+
+	var scores []models.EndpointScore
+	for _, s := range stats.ScoreEndpoints(results, pings, probes) {
+		if s.Score >= scoreMinScore {
+			scores = append(scores, s)
+		}
+	}
+
+	if scoreFormat == "json" {
+		if err := output.ExportJSON(scores, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(stats.FormatEndpointScores(scores))
+	return nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Serve command flags
+	serveDB        string
+	serveListen    string
+	serveViewer    bool
+	serveAPIKeys   string
+	serveRateLimit float64
+	serveRateBurst int
+	serveTLSCert   string
+	serveTLSKey    string
+	serveTLSSelf   bool
+	keygenName     string
+	keygenRole     string
+)
+
+func serveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the database over HTTP",
+		Long: `Serve the database over HTTP so teammates can browse results without
+installing the CLI. The JSON API is always available:
+
+  GET    /api/fqdns       Rows with operator, vendor and tags (JSON, paged)
+  GET    /api/fqdns.csv   All matching rows as CSV
+  GET    /api/tags        Tags with their counts
+  POST   /api/tags        Add a tag: {"tag", "kind": "fqdn|operator", "targets"}
+  DELETE /api/tags        Remove a tag (same body)
+  GET    /api/notes       Notes, optionally ?kind=&target=
+  POST   /api/notes       Add a note: {"kind", "target", "text"}
+  DELETE /api/notes/{id}  Remove a note
+  GET    /api/stream      Live results of running scans (server-sent events)
+  GET    /api/results     Stored probe results, optionally ?target=&type=
+  POST   /api/results     Publish results: {"dns": [...], "ping": [...], "results": [...]}
+
+Both FQDN endpoints accept q (substring of FQDN or operator), mcc, mnc,
+operator, subdomain, tag, order (fqdn, operator or inserted; - prefix to
+reverse), limit and offset. GET /openapi.json describes the API as an
+OpenAPI 3 document for generating clients; it needs no key. --viewer adds a
+web page at / with search, filters, sorting and CSV download.
+
+Without --api-keys the server is read-only and open to anyone who can reach
+it, so it listens on localhost unless --listen names another address. With
+--api-keys every request needs "Authorization: Bearer KEY" (or X-API-Key);
+read-only keys may use the GET endpoints, read-write keys all of them.
+Requests are rate-limited per key, or per address without keys; failed
+authentication counts against the address.
+
+scan and ping --stream=URL publish each result as it arrives, and
+/api/stream relays them to every connected client as "dns" and "ping"
+events with the result as JSON data (?type= selects one); other tools may
+post results in the common envelope, relayed as "result" events. Results
+are relayed, not stored. Publishing needs a read-write key (--stream-key-file),
+so --api-keys is required.
+
+--tls-cert and --tls-key serve HTTPS. --tls-self-signed generates a
+certificate for localhost, the host name and the --listen address, written
+to --tls-cert/--tls-key when given so it survives restarts; its SHA-256
+fingerprint is printed for clients to check.`,
+		Example: `  # Browse results at http://127.0.0.1:8080/
+  3gpp-scanner serve --viewer --db=database.db
+
+  # Query the API from a script
+  curl 'http://127.0.0.1:8080/api/fqdns?mcc=310&subdomain=epdg&limit=10'
+
+  # Watch a scan live
+  curl -N -H "X-API-Key: $(cat alice.key)" http://127.0.0.1:8080/api/stream
+  3gpp-scanner scan --mode=epdg --stream=http://127.0.0.1:8080 --stream-key-file=alice.key
+
+  # Share on the lab network with keys
+  3gpp-scanner serve keygen --name=alice --role=read-write
+  3gpp-scanner serve --viewer --listen=0.0.0.0:8443 --api-keys=keys.json \
+    --tls-self-signed --tls-cert=serve.crt --tls-key=serve.key`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+
+	cmd.Flags().StringVar(&serveDB, "db", "database.db", "Database file path")
+	cmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address to listen on")
+	cmd.Flags().BoolVar(&serveViewer, "viewer", false, "Serve the web viewer at /")
+	cmd.Flags().StringVar(&serveAPIKeys, "api-keys", "", "JSON file of API keys with read-only or read-write roles (see serve keygen)")
+	cmd.Flags().Float64Var(&serveRateLimit, "rate-limit", 10, "Requests per second per client (0: unlimited)")
+	cmd.Flags().IntVar(&serveRateBurst, "rate-burst", 20, "Requests a client may send at once above --rate-limit")
+	cmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "PEM certificate file; serves HTTPS with --tls-key")
+	cmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "PEM private key file for --tls-cert")
+	cmd.Flags().BoolVar(&serveTLSSelf, "tls-self-signed", false, "Serve HTTPS with a generated self-signed certificate (saved to --tls-cert/--tls-key if given and missing)")
+
+	keygen := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate an API key and its --api-keys entry",
+		Long: `Print a new random API key and the entry to add to the "keys" array of the
+--api-keys file. The file stores only the SHA-256 of the key; hand the key
+itself to its user, it cannot be recovered later.`,
+		Args: cobra.NoArgs,
+		RunE: runServeKeygen,
+	}
+	keygen.Flags().StringVar(&keygenName, "name", "", "Name of the key holder, shown in logs (required)")
+	keygen.Flags().StringVar(&keygenRole, "role", string(server.RoleReadOnly), "Role: read-only or read-write")
+	cmd.AddCommand(keygen)
+
+	return cmd
+}
+
+// validateServeFlags validates serve command flags
+func validateServeFlags() error {
+	if _, _, err := net.SplitHostPort(serveListen); err != nil {
+		return fmt.Errorf("invalid --listen: %w", err)
+	}
+	if serveRateLimit < 0 {
+		return fmt.Errorf("--rate-limit cannot be negative")
+	}
+	if serveRateBurst < 1 {
+		return fmt.Errorf("--rate-burst must be positive")
+	}
+	if (serveTLSCert == "") != (serveTLSKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+	return nil
+}
+
+// Serve command implementation
+func runServe(cmd *cobra.Command, args []string) error {
+	if err := validateServeFlags(); err != nil {
+		return err
+	}
+
+	opts := server.Options{Viewer: serveViewer, RateLimit: serveRateLimit, RateBurst: serveRateBurst, Version: version}
+	if serveAPIKeys != "" {
+		keys, err := server.LoadKeys(serveAPIKeys)
+		if err != nil {
+			return err
+		}
+		opts.Keys = keys
+	}
+
+	db, err := openDB(serveDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	useTLS := serveTLSCert != "" || serveTLSSelf
+	var tlsConfig *tls.Config
+	if useTLS {
+		cert, created, err := server.LoadOrCreateCert(serveTLSCert, serveTLSKey, serveTLSSelf, certHosts(serveListen))
+		if err != nil {
+			return err
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		if !quiet {
+			if created {
+				fmt.Println("Generated a self-signed certificate")
+			}
+			fmt.Printf("Certificate SHA-256 fingerprint: %s\n", server.Fingerprint(cert))
+		}
+	}
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+	if host, _, _ := net.SplitHostPort(serveListen); !isLoopback(host) {
+		if opts.Keys == nil {
+			slog.Warn("serving on a non-loopback address; anyone who can reach it can read the database", "address", serveListen)
+		}
+		if !useTLS {
+			slog.Warn("serving without TLS on a non-loopback address; results and API keys cross the network in cleartext", "address", serveListen)
+		}
+	}
+	if useTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	api := server.New(db, opts)
+	srv := &http.Server{
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(api.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	if !quiet {
+		if opts.Keys != nil {
+			fmt.Printf("Accepting %d API keys from %s\n", len(opts.Keys.Keys), serveAPIKeys)
+		}
+		scheme := "http"
+		if useTLS {
+			scheme = "https"
+		}
+		if serveViewer {
+			fmt.Printf("Serving %s at %s://%s/ (Ctrl-C to stop)\n", serveDB, scheme, listener.Addr())
+		} else {
+			fmt.Printf("Serving %s API at %s://%s/api/ (Ctrl-C to stop)\n", serveDB, scheme, listener.Addr())
+		}
+	}
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// Serve keygen command implementation
+func runServeKeygen(cmd *cobra.Command, args []string) error {
+	if keygenName == "" {
+		return fmt.Errorf("--name required")
+	}
+	role := server.Role(keygenRole)
+	if role != server.RoleReadOnly && role != server.RoleReadWrite {
+		return fmt.Errorf("invalid --role: %s (must be %s or %s)", keygenRole, server.RoleReadOnly, server.RoleReadWrite)
+	}
+
+	key, err := server.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	entry, err := json.Marshal(server.Key{Name: keygenName, Role: role, SHA256: server.HashKey(key)})
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Println("API key (give this to the key holder):")
+	}
+	fmt.Println(key)
+	if !quiet {
+		fmt.Println("\nEntry for the --api-keys file:")
+	}
+	fmt.Println(string(entry))
+	return nil
+}
+
+// certHosts lists the names a generated certificate covers: localhost,
+// the host name and the listen address unless it is a wildcard
+func certHosts(listen string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		hosts = append(hosts, name)
+	}
+	if host, _, err := net.SplitHostPort(listen); err == nil && host != "" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsUnspecified() {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// isLoopback reports whether a listen host only accepts local connections
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// addStreamFlags adds --stream and its key and TLS trust flags to a
+// command that produces results
+func addStreamFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&streamURL, "stream", "", "Publish results as they arrive to a serve instance at this URL, e.g. http://127.0.0.1:8080")
+	cmd.Flags().StringVar(&streamKeyFile, "stream-key-file", "", "File holding a read-write API key for --stream")
+	cmd.Flags().StringVar(&streamCA, "stream-ca", "", "PEM CA certificates to trust for an https:// --stream, e.g. the serve --tls-cert file")
+	cmd.Flags().StringVar(&streamFingerprint, "stream-fingerprint", "", "SHA-256 fingerprint printed by serve to pin for an https:// --stream")
+}
+
+// validateStreamFlags validates the live result streaming flags
+func validateStreamFlags() error {
+	if streamURL == "" {
+		switch {
+		case streamKeyFile != "":
+			return fmt.Errorf("--stream-key-file requires --stream")
+		case streamCA != "", streamFingerprint != "":
+			return fmt.Errorf("--stream-ca and --stream-fingerprint require --stream")
+		}
+		return nil
+	}
+	u, err := url.Parse(streamURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --stream: %s (must be an http:// or https:// URL)", streamURL)
+	}
+	if streamCA != "" || streamFingerprint != "" {
+		if u.Scheme != "https" {
+			return fmt.Errorf("--stream-ca and --stream-fingerprint require an https:// --stream")
+		}
+		if streamCA != "" && streamFingerprint != "" {
+			return fmt.Errorf("--stream-ca and --stream-fingerprint are mutually exclusive")
+		}
+	}
+	if streamFingerprint != "" {
+		if _, err := server.ParseFingerprint(streamFingerprint); err != nil {
+			return fmt.Errorf("--stream-fingerprint: %w", err)
+		}
+	}
+	return nil
+}
+
+// openPublisher starts publishing to --stream; without it nothing is sent
+func openPublisher() (*server.Publisher, error) {
+	if streamURL == "" {
+		return nil, nil
+	}
+	var key string
+	if streamKeyFile != "" {
+		data, err := os.ReadFile(streamKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --stream-key-file: %w", err)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	tlsConfig, err := server.ClientTLSConfig(streamCA, streamFingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("--stream: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Streaming results to %s\n", streamURL)
+	}
+	return server.NewPublisher(streamURL, key, tlsConfig), nil
+}
+
+// closePublisher sends the last results once the run is done
+func closePublisher(p *server.Publisher) {
+	if p == nil {
+		return
+	}
+	if dropped := p.Close(); dropped > 0 {
+		slog.Warn("results not streamed", "dropped", dropped, "url", streamURL)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Snapshot command flags
+	snapshotDB    string
+	snapshotDir   string
+	snapshotLabel string
+	snapshotYes   bool
+)
+
+func snapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Create, list and restore database snapshots",
+		Long: `Keep gzip-compressed point-in-time copies of the database so risky imports
+or migrations can be rolled back. Copies are taken through SQLite (VACUUM
+INTO), so they are consistent even while a scan writes to the database.
+Snapshots are stored in "<db>.snapshots" unless --dir is given.
+
+Restoring first snapshots the current database with the label "pre-restore",
+so a restore can itself be undone.`,
+		Example: `  # Snapshot before an import, and list snapshots
+  3gpp-scanner snapshot create --db=database.db --label=pre-import
+  3gpp-scanner snapshot list --db=database.db
+
+  # Roll back (a unique name prefix is enough)
+  3gpp-scanner snapshot restore 20260101T120000Z --db=database.db`,
+	}
+
+	cmd.PersistentFlags().StringVar(&snapshotDB, "db", "database.db", "Database file path")
+	cmd.PersistentFlags().StringVar(&snapshotDir, "dir", "", "Snapshot directory (default: <db>.snapshots)")
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Snapshot the database",
+		Args:  cobra.NoArgs,
+		RunE:  runSnapshotCreate,
+	}
+	create.Flags().StringVar(&snapshotLabel, "label", "", "Label appended to the snapshot name (letters, digits, - and _)")
+	cmd.AddCommand(create)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List snapshots, oldest first",
+		Args:  cobra.NoArgs,
+		RunE:  runSnapshotList,
+	})
+
+	restore := &cobra.Command{
+		Use:   "restore NAME",
+		Short: "Replace the database with a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSnapshotRestore,
+	}
+	restore.Flags().BoolVarP(&snapshotYes, "yes", "y", false, "Skip the confirmation prompt")
+	cmd.AddCommand(restore)
+
+	return cmd
+}
+
+// newSnapshotStore opens the snapshot store selected by the flags
+func newSnapshotStore() *snapshot.Store {
+	store := snapshot.NewStore(snapshotDB, snapshotDir)
+	store.DBOptions = dbOptions()
+	return store
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	snap, err := newSnapshotStore().Create(snapshotLabel)
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Created snapshot %s (%s)\n", snap.Name, formatBytes(snap.Size))
+	}
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	store := newSnapshotStore()
+	snapshots, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range snapshots {
+		fmt.Printf("%-40s %s  %10s\n", snap.Name, snap.Created.Format("2006-01-02 15:04:05 UTC"), formatBytes(snap.Size))
+	}
+	if !quiet {
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots")
+		}
+		fmt.Printf("\nDirectory: %s\n", store.Dir)
+	}
+	return nil
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	store := newSnapshotStore()
+	snap, err := store.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !snapshotYes {
+		ok, err := confirm(fmt.Sprintf("Replace %s with snapshot %s?", snapshotDB, snap.Name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("restore aborted")
+		}
+	}
+
+	backup, err := store.Restore(snap.Name)
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		if backup != nil {
+			fmt.Printf("Saved the previous database as snapshot %s\n", backup.Name)
+		}
+		fmt.Printf("Restored %s from snapshot %s\n", snapshotDB, snap.Name)
+	}
+	return nil
+}
+
+// formatBytes renders a size with a binary unit, e.g. "1.5 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"3gpp-scanner/internal/remote"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Sync command flags
+	syncRemote   string
+	syncEndpoint string
+	syncRegion   string
+	syncVersion  string
+	syncDir      string
+	syncForce    bool
+)
+
+func syncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Share databases and exports through S3-compatible storage",
+		Long: `Push databases and exported results to Amazon S3 or an S3-compatible store
+(MinIO, Ceph, R2, ...) and pull them elsewhere, so a distributed team works
+from one canonical dataset.
+
+Every push stores a new version under <prefix>/<file name>/ and marks it
+latest; pushing unchanged content is skipped, and older versions stay
+available to pull by ID. Databases are copied through SQLite (VACUUM INTO)
+before upload, so they can be pushed while a scan writes to them.
+
+Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+AWS_SESSION_TOKEN; the region from --region, AWS_REGION or AWS_DEFAULT_REGION.
+Push encrypted copies (see encrypt) to keep results private from the store.`,
+		Example: `  # Publish the database and an export
+  3gpp-scanner sync push database.db results.json --remote=s3://team-bucket/epdg
+
+  # Fetch the latest database, replacing a local one that differs
+  3gpp-scanner sync pull database.db --remote=s3://team-bucket/epdg --force
+
+  # List versions and roll back to an older one
+  3gpp-scanner sync list database.db --remote=s3://team-bucket/epdg
+  3gpp-scanner sync pull database.db --remote=s3://team-bucket/epdg --version=20260101T120000Z --force
+
+  # Use a MinIO server
+  3gpp-scanner sync push database.db --remote=s3://scans --endpoint=http://minio.lab:9000`,
+	}
+
+	cmd.PersistentFlags().StringVar(&syncRemote, "remote", "", "Remote location, s3://bucket/prefix")
+	cmd.PersistentFlags().StringVar(&syncEndpoint, "endpoint", "", "Base URL of an S3-compatible store, addressed path-style (default: $AWS_ENDPOINT_URL_S3, $AWS_ENDPOINT_URL or AWS)")
+	cmd.PersistentFlags().StringVar(&syncRegion, "region", "", "Signing region (default: $AWS_REGION, $AWS_DEFAULT_REGION or us-east-1)")
+	cmd.MarkPersistentFlagRequired("remote")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "push FILE...",
+		Short: "Upload files as new versions",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSyncPush,
+	})
+
+	pull := &cobra.Command{
+		Use:   "pull NAME...",
+		Short: "Download the latest or a given version of files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSyncPull,
+	}
+	pull.Flags().StringVar(&syncVersion, "version", "", "Version ID, or a unique prefix of one (one NAME only; default: latest)")
+	pull.Flags().StringVar(&syncDir, "dir", ".", "Directory the files are written to")
+	pull.Flags().BoolVar(&syncForce, "force", false, "Replace local files whose content differs")
+	cmd.AddCommand(pull)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list [NAME]",
+		Short: "List pushed files, or the versions of one, oldest first",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runSyncList,
+	})
+
+	return cmd
+}
+
+// validateSyncFlags validates sync command flags and returns the remote
+func validateSyncFlags(args []string) (remote.Location, error) {
+	location, err := remote.ParseLocation(syncRemote)
+	if err != nil {
+		return remote.Location{}, err
+	}
+	if syncVersion != "" && len(args) > 1 {
+		return remote.Location{}, fmt.Errorf("--version requires a single NAME")
+	}
+	return location, nil
+}
+
+// newSyncer creates the syncer selected by the flags
+func newSyncer(args []string) (*remote.Syncer, error) {
+	location, err := validateSyncFlags(args)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := syncEndpoint
+	for _, env := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+		if endpoint == "" {
+			endpoint = os.Getenv(env)
+		}
+	}
+	client, err := remote.NewClient(endpoint, syncRegion)
+	if err != nil {
+		return nil, err
+	}
+	syncer := remote.NewSyncer(client, location)
+	syncer.DBOptions = dbOptions()
+	return syncer, nil
+}
+
+func runSyncPush(cmd *cobra.Command, args []string) error {
+	syncer, err := newSyncer(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, path := range args {
+		v, uploaded, err := syncer.Push(ctx, path)
+		if err != nil {
+			return err
+		}
+		if quiet {
+			continue
+		}
+		if uploaded {
+			fmt.Printf("Pushed %s as version %s (%s)\n", v.Name, v.ID, formatBytes(v.Size))
+		} else {
+			fmt.Printf("%s is up to date (version %s)\n", v.Name, v.ID)
+		}
+	}
+	return nil
+}
+
+func runSyncPull(cmd *cobra.Command, args []string) error {
+	syncer, err := newSyncer(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, name := range args {
+		dst := filepath.Join(syncDir, name)
+		v, downloaded, err := syncer.Pull(ctx, name, syncVersion, dst, syncForce)
+		if err != nil {
+			return err
+		}
+		if quiet {
+			continue
+		}
+		if downloaded {
+			fmt.Printf("Pulled %s version %s to %s (%s)\n", v.Name, v.ID, dst, formatBytes(v.Size))
+		} else {
+			fmt.Printf("%s is up to date (version %s)\n", dst, v.ID)
+		}
+	}
+	return nil
+}
+
+func runSyncList(cmd *cobra.Command, args []string) error {
+	syncer, err := newSyncer(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		names, err := syncer.Names(ctx)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		if !quiet && len(names) == 0 {
+			fmt.Printf("Nothing pushed to %s\n", syncer.Location)
+		}
+		return nil
+	}
+
+	versions, err := syncer.Versions(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		latest := ""
+		if v.Latest {
+			latest = "  (latest)"
+		}
+		fmt.Printf("%-33s %s  %10s%s\n", v.ID, v.Created.Format("2006-01-02 15:04:05 UTC"), formatBytes(v.Size), latest)
+	}
+	if !quiet && len(versions) == 0 {
+		fmt.Printf("No versions of %s at %s\n", args[0], syncer.Location)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Tag command flags
+	tagDB        string
+	tagFQDNs     []string
+	tagOperators []string
+	tagFile      string
+	tagFilter    string
+	tagFormat    string
+)
+
+func tagCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Label FQDNs and operators in the database",
+		Long: `Attach free-form labels such as "confirmed-live", "honeypot?" or
+"customer-x" to FQDNs or operators. An FQDN carries its own tags and the
+tags of its operator; query shows them after each FQDN and --tag filters
+by them. Tags may not contain spaces or commas.`,
+		Example: `  # Label two FQDNs and a whole operator
+  3gpp-scanner tag add confirmed-live --fqdn=epdg.epc.mnc001.mcc310.pub.3gppnetwork.org,ims.mnc001.mcc310.3gppnetwork.org
+  3gpp-scanner tag add customer-x --operator="Example Mobile"
+
+  # Label every FQDN from a file, then list tags
+  3gpp-scanner tag add honeypot? --file=suspects.txt
+  3gpp-scanner tag list
+
+  # Query by tag and drop a label
+  3gpp-scanner query --tag=customer-x
+  3gpp-scanner tag remove customer-x --operator="Example Mobile"`,
+	}
+
+	cmd.PersistentFlags().StringVar(&tagDB, "db", "database.db", "Database file path")
+
+	add := &cobra.Command{
+		Use:   "add TAG",
+		Short: "Tag FQDNs or operators",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTagAdd,
+	}
+	remove := &cobra.Command{
+		Use:   "remove TAG",
+		Short: "Remove a tag from FQDNs or operators",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTagRemove,
+	}
+	for _, c := range []*cobra.Command{add, remove} {
+		c.Flags().StringSliceVar(&tagFQDNs, "fqdn", nil, "FQDNs to label (comma-separated)")
+		c.Flags().StringSliceVar(&tagOperators, "operator", nil, "Operators to label (comma-separated)")
+		c.Flags().StringVarP(&tagFile, "file", "f", "", "File containing FQDNs to label (one per line)")
+		cmd.AddCommand(c)
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List tags with their counts, or the targets of one tag",
+		Args:  cobra.NoArgs,
+		RunE:  runTagList,
+	}
+	list.Flags().StringVar(&tagFilter, "tag", "", "List the FQDNs and operators carrying this tag")
+	list.Flags().StringVar(&tagFormat, "format", "text", "Output format: text or json")
+	cmd.AddCommand(list)
+
+	return cmd
+}
+
+// validateTagFlags validates tag add and remove flags
+func validateTagFlags(tag string) error {
+	if err := database.ValidateTag(tag); err != nil {
+		return err
+	}
+	if len(tagFQDNs) == 0 && len(tagOperators) == 0 && tagFile == "" {
+		return fmt.Errorf("at least one of --fqdn, --operator or --file required")
+	}
+	return nil
+}
+
+// validateTagListFlags validates tag list flags
+func validateTagListFlags() error {
+	if tagFilter != "" {
+		if err := database.ValidateTag(tagFilter); err != nil {
+			return fmt.Errorf("--tag: %w", err)
+		}
+	}
+	if tagFormat != "text" && tagFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", tagFormat)
+	}
+	return nil
+}
+
+func runTagAdd(cmd *cobra.Command, args []string) error {
+	return changeTags(args[0], (*database.DB).AddTag, "Added")
+}
+
+func runTagRemove(cmd *cobra.Command, args []string) error {
+	return changeTags(args[0], (*database.DB).RemoveTag, "Removed")
+}
+
+// changeTags applies an add or remove to the FQDNs and operators selected
+// by the flags
+func changeTags(tag string, change func(*database.DB, string, string, []string) (int, error), verb string) error {
+	if err := validateTagFlags(tag); err != nil {
+		return err
+	}
+
+	fqdns := tagFQDNs
+	if tagFile != "" {
+		lines, err := readFQDNsFromFile(tagFile)
+		if err != nil {
+			return fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+		fqdns = append(append([]string(nil), fqdns...), lines...)
+	}
+
+	db, err := openDB(tagDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	fqdnCount, err := change(db, database.TagKindFQDN, tag, fqdns)
+	if err != nil {
+		return err
+	}
+	operatorCount, err := change(db, database.TagKindOperator, tag, tagOperators)
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("%s tag %s: %d FQDNs, %d operators\n", verb, tag, fqdnCount, operatorCount)
+	}
+	return nil
+}
+
+func runTagList(cmd *cobra.Command, args []string) error {
+	if err := validateTagListFlags(); err != nil {
+		return err
+	}
+
+	db, err := openDB(tagDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	if tagFilter != "" {
+		tags, err := db.QueryTags("", "", tagFilter)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if tagFormat == "json" {
+			if tags == nil {
+				tags = []database.Tag{}
+			}
+			return output.ExportJSON(tags, "/dev/stdout")
+		}
+		for _, t := range tags {
+			fmt.Printf("%-8s  %s  %s\n", t.Kind, t.Created.Format("2006-01-02 15:04:05 UTC"), t.Target)
+		}
+		if !quiet {
+			fmt.Printf("\nFound %d targets tagged %s\n", len(tags), tagFilter)
+		}
+		return nil
+	}
+
+	counts, err := db.CountTags()
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	if tagFormat == "json" {
+		if counts == nil {
+			counts = []database.TagCount{}
+		}
+		return output.ExportJSON(counts, "/dev/stdout")
+	}
+	for _, c := range counts {
+		fmt.Printf("%-30s %6d FQDNs %6d operators\n", c.Tag, c.FQDNs, c.Operators)
+	}
+	if !quiet {
+		if len(counts) == 0 {
+			fmt.Println("No tags")
+		} else {
+			fmt.Printf("\nFound %d tags\n", len(counts))
+		}
+	}
+	return nil
+}
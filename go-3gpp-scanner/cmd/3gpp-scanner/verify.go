@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"3gpp-scanner/internal/signing"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Verify command flags
+	verifyPubKey string
+
+	// Keygen command flags
+	keygenOut string
+)
+
+func verifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify MANIFEST...",
+		Short: "Verify signed result manifests",
+		Long: `Check the signature of manifests written with --sign-key and confirm the
+exported files they describe are unmodified. With --pubkey, the manifest must
+also have been signed by that key (provenance).`,
+		Example: `  # Verify integrity of a shared dataset
+  3gpp-scanner verify results.json.manifest.json
+
+  # Verify it was produced by a known researcher's key
+  3gpp-scanner verify results.json.manifest.json --pubkey=alice.pub.pem`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runVerify,
+	}
+
+	cmd.Flags().StringVar(&verifyPubKey, "pubkey", "", "Trusted public key (PEM) the manifest must be signed with")
+
+	return cmd
+}
+
+func keygenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate an Ed25519 key pair for signing results",
+		Example: `  # Create signing.pem and signing.pub.pem
+  3gpp-scanner keygen --out=signing
+
+  # Sign scan output with it
+  3gpp-scanner scan --mode=epdg --output=results.json --sign-key=signing.pem`,
+		Args: cobra.NoArgs,
+		RunE: runKeygen,
+	}
+
+	cmd.Flags().StringVar(&keygenOut, "out", "signing", "Output path prefix for <out>.pem and <out>.pub.pem")
+
+	return cmd
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	var trusted ed25519.PublicKey
+	if verifyPubKey != "" {
+		var err error
+		trusted, err = signing.LoadPublicKey(verifyPubKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, manifestPath := range args {
+		m, err := signing.VerifyManifest(manifestPath, trusted)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", manifestPath, err)
+			failed++
+			continue
+		}
+
+		if !quiet {
+			pub, _ := base64.StdEncoding.DecodeString(m.PublicKey)
+			files := make([]string, 0, len(m.Files))
+			for _, f := range m.Files {
+				files = append(files, f.Path)
+			}
+			fmt.Printf("OK   %s: %s signed by %s at %s (%s)\n", manifestPath,
+				strings.Join(files, ", "), signing.Fingerprint(pub),
+				m.Created.Format("2006-01-02 15:04:05 MST"), m.Tool)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifests failed verification", failed, len(args))
+	}
+	return nil
+}
+
+func runKeygen(cmd *cobra.Command, args []string) error {
+	privPath := keygenOut + ".pem"
+	pubPath := keygenOut + ".pub.pem"
+
+	if err := signing.GenerateKey(privPath, pubPath); err != nil {
+		return err
+	}
+
+	if !quiet {
+		pub, err := signing.LoadPublicKey(pubPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Private key: %s\n", privPath)
+		fmt.Printf("Public key:  %s (fingerprint %s)\n", pubPath, signing.Fingerprint(pub))
+	}
+	return nil
+}
+
+// signExports signs each exported file with its own manifest; an empty
+// key path signs nothing
+func signExports(keyPath string, filePaths []string) error {
+	if keyPath == "" {
+		return nil
+	}
+	for _, filePath := range filePaths {
+		if err := signExport(keyPath, filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signExport writes a signed manifest next to an exported file
+func signExport(keyPath, filePath string) error {
+	priv, err := signing.LoadPrivateKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := filePath + signing.ManifestSuffix
+	if _, err := signing.SignFiles(priv, "3gpp-scanner/"+version, []string{filePath}, manifestPath); err != nil {
+		return fmt.Errorf("signing failed: %w", err)
+	}
+
+	if !quiet {
+		fmt.Printf("Signed manifest: %s\n", manifestPath)
+	}
+	return nil
+}
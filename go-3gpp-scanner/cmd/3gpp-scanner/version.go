@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/probe"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Build metadata, set with -ldflags "-X main.commit=... -X main.buildDate=..."
+	// (see the Makefile); the commit falls back to the VCS stamp of go build
+	commit    = ""
+	buildDate = ""
+
+	// Version command flags
+	versionFormat string
+)
+
+// buildInfo describes the binary for bug reports
+type buildInfo struct {
+	Version    string       `json:"version"`
+	Commit     string       `json:"commit,omitempty"`
+	Modified   bool         `json:"modified,omitempty"` // Built from a tree with uncommitted changes
+	CommitDate string       `json:"commit_date,omitempty"`
+	BuildDate  string       `json:"build_date,omitempty"`
+	GoVersion  string       `json:"go_version"`
+	Platform   string       `json:"platform"`
+	CGO        bool         `json:"cgo"`
+	Database   databaseInfo `json:"database"`
+	GeoIP      string       `json:"geoip"`
+	Probes     []string     `json:"probes"`
+}
+
+// databaseInfo reports whether the SQLite driver works in this build
+type databaseInfo struct {
+	Driver        string `json:"driver"`
+	SQLiteVersion string `json:"sqlite_version,omitempty"`
+	Available     bool   `json:"available"`
+	Error         string `json:"error,omitempty"`
+}
+
+func versionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show version, build and feature information",
+		Long: `Show the version, git commit, build date, Go version and platform, whether
+the SQLite driver works in this build (it requires cgo), the GeoIP reader
+and the registered probe modules. Include this output in bug reports.`,
+		Example: `  3gpp-scanner version
+  3gpp-scanner version --format=json`,
+		Args: cobra.NoArgs,
+		RunE: runVersion,
+	}
+
+	cmd.Flags().StringVar(&versionFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateVersionFlags validates version command flags
+func validateVersionFlags() error {
+	if versionFormat != "text" && versionFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", versionFormat)
+	}
+	return nil
+}
+
+// Version command implementation
+func runVersion(cmd *cobra.Command, args []string) error {
+	if err := validateVersionFlags(); err != nil {
+		return err
+	}
+
+	info := collectBuildInfo()
+	if versionFormat == "json" {
+		if err := output.ExportJSON(info, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(formatBuildInfo(info))
+	return nil
+}
+
+// collectBuildInfo gathers the build metadata and feature availability
+func collectBuildInfo() buildInfo {
+	info := buildMetadata()
+	sqliteVersion, err := database.SQLiteVersion()
+	if err != nil {
+		info.Database.Error = err.Error()
+	} else {
+		info.Database.SQLiteVersion = sqliteVersion
+		info.Database.Available = true
+	}
+	return info
+}
+
+// buildMetadata reads the version stamps and dependencies of the binary
+// without probing any features
+func buildMetadata() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Database:  databaseInfo{Driver: strings.TrimPrefix(database.DriverModule, "github.com/")},
+		GeoIP:     "built-in CSV reader (MaxMind GeoLite2 City Blocks format, --geoip)",
+		Probes:    probe.Names(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				info.CommitDate = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			case "CGO_ENABLED":
+				info.CGO = setting.Value == "1"
+			}
+		}
+		for _, dep := range bi.Deps {
+			if dep.Path == database.DriverModule {
+				info.Database.Driver += " " + dep.Version
+			}
+		}
+	}
+	return info
+}
+
+// versionString is the short form printed by --version
+func versionString() string {
+	info := buildMetadata()
+	if info.Commit == "" {
+		return info.Version
+	}
+	return fmt.Sprintf("%s (%s)", info.Version, shortCommit(info))
+}
+
+// shortCommit abbreviates the commit hash and marks dirty builds
+func shortCommit(info buildInfo) string {
+	c := info.Commit
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if info.Modified {
+		c += "-dirty"
+	}
+	return c
+}
+
+// formatBuildInfo renders build information as text
+func formatBuildInfo(info buildInfo) string {
+	var sb strings.Builder
+	sb.WriteString("=== 3gpp-scanner ===\n")
+
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	commitText := "unknown"
+	if info.Commit != "" {
+		commitText = shortCommit(info)
+		if info.CommitDate != "" {
+			commitText += " (" + info.CommitDate + ")"
+		}
+	}
+	cgo := "disabled"
+	if info.CGO {
+		cgo = "enabled"
+	}
+	db := fmt.Sprintf("SQLite %s via %s", info.Database.SQLiteVersion, info.Database.Driver)
+	if !info.Database.Available {
+		db = fmt.Sprintf("unavailable (%s)", info.Database.Error)
+	}
+
+	fmt.Fprintf(&sb, "Version:    %s\n", info.Version)
+	fmt.Fprintf(&sb, "Commit:     %s\n", commitText)
+	fmt.Fprintf(&sb, "Built:      %s\n", unknown(info.BuildDate))
+	fmt.Fprintf(&sb, "Go:         %s %s\n", info.GoVersion, info.Platform)
+	fmt.Fprintf(&sb, "CGO:        %s\n", cgo)
+	fmt.Fprintf(&sb, "Database:   %s\n", db)
+	fmt.Fprintf(&sb, "GeoIP:      %s\n", info.GeoIP)
+	fmt.Fprintf(&sb, "Probes:     %s\n", strings.Join(info.Probes, ", "))
+	return sb.String()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/ipclass"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/stats"
+	"3gpp-scanner/internal/whois"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Whois command flags
+	whoisDB        string
+	whoisOperator  string
+	whoisSubdomain string
+	whoisServer    string
+	whoisRefresh   bool
+	whoisWorkers   int
+	whoisTimeout   time.Duration
+	whoisFormat    string
+)
+
+func whoisCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whois",
+		Short: "Look up the registered holders and abuse contacts of discovered addresses",
+		Long: `Look up the registered network of each public address in the database over
+whois and report, per network, its holder and abuse contacts with the
+operators and FQDNs it holds, so responsible disclosure of findings can go
+to the right people.
+
+Queries start at IANA (whois.iana.org), which refers to the regional
+registry (RIPE, ARIN, APNIC, LACNIC, AFRINIC); ARIN referrals to the
+registry of legacy space are followed too. Abuse contacts are taken from
+the abuse-c and IRT objects of the network or its organisation, ARIN's
+OrgAbuseEmail and the abuse contact the RIPE database reports.
+
+Networks are stored in the database per CIDR block and reused on later
+runs: only addresses outside every stored network are looked up, one per
+/24 (IPv6: /48) first. --refresh looks all addresses up again. Registries
+rate-limit whois queries, so keep --workers low.`,
+		Example: `  # Contacts for every discovered endpoint
+  3gpp-scanner whois --db=database.db
+
+  # Whom to notify about one operator's ePDGs, as JSON
+  3gpp-scanner whois --db=database.db --operator="Telekom" --subdomain=epdg.epc --format=json`,
+		Args: cobra.NoArgs,
+		RunE: runWhois,
+	}
+
+	cmd.Flags().StringVar(&whoisDB, "db", "", "Database to analyze; networks are stored in it (required)")
+	cmd.Flags().StringVar(&whoisOperator, "operator", "", "Only FQDNs of this operator")
+	cmd.Flags().StringVar(&whoisSubdomain, "subdomain", "", "Only FQDNs of this subdomain (e.g. epdg.epc)")
+	cmd.Flags().StringVar(&whoisServer, "server", whois.DefaultServer, "Whois server asked first, host or host:port")
+	cmd.Flags().BoolVar(&whoisRefresh, "refresh", false, "Look up addresses again even if their networks are stored")
+	cmd.Flags().IntVar(&whoisWorkers, "workers", 2, "Parallel lookups")
+	cmd.Flags().DurationVar(&whoisTimeout, "timeout", 15*time.Second, "Timeout of one whois query")
+	cmd.Flags().StringVar(&whoisFormat, "format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// validateWhoisFlags validates whois command flags
+func validateWhoisFlags() error {
+	if whoisDB == "" {
+		return fmt.Errorf("--db required")
+	}
+	if whoisServer == "" {
+		return fmt.Errorf("--server must not be empty")
+	}
+	if whoisWorkers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	if whoisTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	if whoisFormat != "text" && whoisFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", whoisFormat)
+	}
+	return nil
+}
+
+// Whois command implementation
+func runWhois(cmd *cobra.Command, args []string) error {
+	if err := validateWhoisFlags(); err != nil {
+		return err
+	}
+	status := !quiet && whoisFormat == "text"
+
+	results, err := storedResults(whoisDB)
+	if err != nil {
+		return err
+	}
+	if whoisOperator != "" {
+		var selected []models.DNSResult
+		for _, result := range results {
+			if strings.EqualFold(result.Operator, whoisOperator) {
+				selected = append(selected, result)
+			}
+		}
+		results = selected
+	}
+	if whoisSubdomain != "" {
+		results = filterSubdomain(results, whoisSubdomain)
+	}
+
+	// Only public addresses are registered
+	seen := make(map[string]bool)
+	var ips []string
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			if addr, ok := ipclass.Parse(ip); ok && ipclass.Classify(addr) == ipclass.Public && !seen[ip] {
+				seen[ip] = true
+				ips = append(ips, ip)
+			}
+		}
+	}
+	sort.Strings(ips)
+
+	db, err := openDB(whoisDB)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	defer db.Close()
+
+	var networks []models.WhoisNetwork
+	if !whoisRefresh {
+		if networks, err = db.QueryWhoisNetworks(); err != nil {
+			return fmt.Errorf("failed to read whois networks: %w", err)
+		}
+	}
+	var missing []string
+	for _, ip := range ips {
+		if _, ok := whois.Find(networks, ip); !ok {
+			missing = append(missing, ip)
+		}
+	}
+
+	if len(missing) > 0 {
+		if status {
+			fmt.Printf("Looking up networks of %d addresses (%d in stored networks)...\n", len(missing), len(ips)-len(missing))
+		}
+		client := &whois.Client{Server: whoisServer, Timeout: whoisTimeout}
+		found, failed, err := whois.LookupAll(context.Background(), client, missing, whoisWorkers)
+		if failed > 0 && !quiet {
+			slog.Warn("whois lookups failed", "count", failed, "error", err)
+		}
+		if len(found) > 0 {
+			if err := db.SaveWhoisNetworks(found); err != nil {
+				return fmt.Errorf("failed to store whois networks: %w", err)
+			}
+			if status {
+				fmt.Printf("Stored %d networks in %s\n", len(found), whoisDB)
+			}
+		}
+		networks = append(networks, found...)
+	}
+
+	contacts, unknown := stats.SummarizeContacts(results, networks)
+
+	if whoisFormat == "json" {
+		report := struct {
+			Contacts []models.AbuseContact `json:"contacts"`
+			Unknown  []string              `json:"unknown_ips"`
+		}{contacts, unknown}
+		if err := output.ExportJSON(report, "/dev/stdout"); err != nil {
+			return fmt.Errorf("JSON export failed: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Print(stats.FormatContacts(contacts, len(unknown)))
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+
+	"3gpp-scanner/internal/wordlist"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Wordlist command flags
+	wordlistDir string
+)
+
+func wordlistCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wordlist",
+		Short: "Manage subdomain wordlists",
+		Long: `List, extend and import the subdomain wordlists used by custom scan mode
+(--mode=custom --wordlist=NAME) and the second-stage brute forcer (--brute).
+Wordlists are stored as text files under the user config directory; a
+built-in "default" list is always available.`,
+		Example: `  # Show available wordlists
+  3gpp-scanner wordlist list
+
+  # Add labels to a new or existing wordlist
+  3gpp-scanner wordlist add ims-core pcscf scscf icscf
+
+  # Import a wordlist file and use it for a custom scan
+  3gpp-scanner wordlist import big labels.txt
+  3gpp-scanner scan --mode=custom --wordlist=big`,
+	}
+
+	cmd.PersistentFlags().StringVar(&wordlistDir, "dir", "", "Wordlist directory (default: <user config dir>/3gpp-scanner/wordlists)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List available wordlists",
+		Args:  cobra.NoArgs,
+		RunE:  runWordlistList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show NAME",
+		Short: "Print the labels of a wordlist",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWordlistShow,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add NAME LABEL...",
+		Short: "Add labels to a wordlist (created if missing)",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  runWordlistAdd,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "import NAME FILE",
+		Short: "Create or replace a wordlist from a file",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runWordlistImport,
+	})
+
+	return cmd
+}
+
+// wordlistStore opens the wordlist store selected by --dir
+func wordlistStore() (*wordlist.Store, error) {
+	return wordlist.NewStore(wordlistDir)
+}
+
+func runWordlistList(cmd *cobra.Command, args []string) error {
+	store, err := wordlistStore()
+	if err != nil {
+		return err
+	}
+
+	lists, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range lists {
+		suffix := ""
+		if info.BuiltIn {
+			suffix = " (built-in)"
+		}
+		fmt.Printf("%-20s %5d labels%s\n", info.Name, info.Labels, suffix)
+	}
+	if !quiet {
+		fmt.Printf("\nDirectory: %s\n", store.Dir)
+	}
+	return nil
+}
+
+func runWordlistShow(cmd *cobra.Command, args []string) error {
+	store, err := wordlistStore()
+	if err != nil {
+		return err
+	}
+
+	labels, err := store.Load(args[0])
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		fmt.Println(label)
+	}
+	return nil
+}
+
+func runWordlistAdd(cmd *cobra.Command, args []string) error {
+	store, err := wordlistStore()
+	if err != nil {
+		return err
+	}
+
+	added, err := store.Add(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Added %d labels to wordlist %s\n", added, args[0])
+	}
+	return nil
+}
+
+func runWordlistImport(cmd *cobra.Command, args []string) error {
+	store, err := wordlistStore()
+	if err != nil {
+		return err
+	}
+
+	count, err := store.Import(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Imported %d labels into wordlist %s\n", count, args[0])
+	}
+	return nil
+}
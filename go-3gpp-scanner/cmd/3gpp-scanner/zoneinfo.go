@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/output"
+	"3gpp-scanner/internal/schema"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Zoneinfo command flags
+	zoneFile        string
+	zoneDB          string
+	zoneOutput      string
+	zoneConcurrency int
+	zoneDelay       int
+)
+
+func zoneinfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "zoneinfo",
+		Short: "Collect SOA, NS and MX metadata for operator zones with hits",
+		Long: `For every mncXXX.mccYYY.pub.3gppnetwork.org zone with at least one discovered
+FQDN, fetch the SOA serial, NS set and MX records. The NS set reveals which
+DNS providers host each operator's zone.`,
+		Example: `  # Zone metadata for FQDNs in a scan result file
+  3gpp-scanner zoneinfo --file=results.txt
+
+  # Zone metadata for every zone in the database, exported to CSV
+  3gpp-scanner zoneinfo --db=database.db --output=zones.csv`,
+		RunE: runZoneInfo,
+	}
+
+	cmd.Flags().StringVarP(&zoneFile, "file", "f", "", "File containing FQDNs (one per line)")
+	cmd.Flags().StringVar(&zoneDB, "db", "", "Database to read FQDNs from")
+	cmd.Flags().StringVarP(&zoneOutput, "output", "o", "", "Output file (json or csv)")
+	cmd.Flags().IntVarP(&zoneConcurrency, "concurrency", "c", 5, "Number of concurrent zones queried")
+	cmd.Flags().IntVar(&zoneDelay, "delay", 200, "Delay between zones in milliseconds")
+	addValidateOutputFlag(cmd)
+
+	return cmd
+}
+
+// validateZoneInfoFlags validates zoneinfo command flags
+func validateZoneInfoFlags() error {
+	if zoneFile == "" && zoneDB == "" {
+		return fmt.Errorf("either --file or --db required")
+	}
+	if zoneFile != "" && zoneDB != "" {
+		return fmt.Errorf("cannot specify both --file and --db")
+	}
+	if zoneConcurrency <= 0 {
+		return fmt.Errorf("--concurrency must be positive")
+	}
+	if zoneDelay < 0 {
+		return fmt.Errorf("--delay cannot be negative")
+	}
+	if err := validateValidateOutputFlag(zoneOutput); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Zoneinfo command implementation
+func runZoneInfo(cmd *cobra.Command, args []string) error {
+	if err := validateZoneInfoFlags(); err != nil {
+		return err
+	}
+
+	var fqdns []string
+	var err error
+	if zoneFile != "" {
+		fqdns, err = readFQDNsFromFile(zoneFile)
+		if err != nil {
+			return fmt.Errorf("failed to read FQDNs: %w", err)
+		}
+	} else {
+		db, err := openDB(zoneDB)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		defer db.Close()
+
+		fqdns, err = db.GetAllFQDNs()
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+	}
+
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		QueryDelay:   time.Duration(zoneDelay) * time.Millisecond,
+		Concurrency:  zoneConcurrency,
+	}
+
+	zones := dns.HitZones(fqdns, config.ParentDomain)
+	if !quiet {
+		fmt.Printf("Querying apex records for %d zones\n", len(zones))
+	}
+
+	scanner := dns.NewScanner(config)
+	results := scanner.QueryZoneInfo(context.Background(), zones)
+
+	if zoneOutput != "" {
+		if err := exportZoneInfo(results, zoneOutput); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if !quiet {
+			fmt.Printf("Exported results to: %s\n", zoneOutput)
+		}
+		return nil
+	}
+
+	if !quiet {
+		output.PrintZoneInfo(results)
+		printNSProviders(results)
+	}
+
+	return nil
+}
+
+// printNSProviders summarizes which DNS provider domains serve the zones
+func printNSProviders(zones []models.ZoneInfo) {
+	providers := make(map[string]int)
+	for _, zone := range zones {
+		seen := make(map[string]bool)
+		for _, ns := range zone.NS {
+			labels := strings.Split(ns, ".")
+			if len(labels) > 2 {
+				labels = labels[len(labels)-2:]
+			}
+			provider := strings.Join(labels, ".")
+			if !seen[provider] {
+				seen[provider] = true
+				providers[provider]++
+			}
+		}
+	}
+	if len(providers) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if providers[names[i]] != providers[names[j]] {
+			return providers[names[i]] > providers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Println("\nDNS providers (zones served):")
+	for _, name := range names {
+		fmt.Printf("  %s: %d\n", name, providers[name])
+	}
+}
+
+func exportZoneInfo(zones []models.ZoneInfo, filePath string) error {
+	zones = exportTimes(zones, func(r *models.ZoneInfo) *time.Time { return &r.Timestamp })
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	switch ext {
+	case ".json":
+		return exportJSON(zones, filePath, schema.ZoneInfo)
+	case ".csv":
+		return output.ExportZoneInfoCSV(zones, filePath)
+	default:
+		return fmt.Errorf("unsupported format (use .json or .csv)")
+	}
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is one line of the audit log: a probe sent to a target
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"` // ping or probe
+	Probe    string    `json:"probe"`   // Ping method (icmp, tcp, https, mtu) or probe name
+	Target   string    `json:"target"`
+	IP       string    `json:"ip,omitempty"`        // Destination address (with port for TCP pings), if resolved
+	SourceIP string    `json:"source_ip,omitempty"` // Local address the traffic left from
+	Result   string    `json:"result"`              // Result status; EXCLUDED means nothing was sent
+	Error    string    `json:"error,omitempty"`
+}
+
+// Log appends entries as JSON lines to a file opened in append-only mode,
+// so earlier runs are never rewritten. Each entry is written as soon as
+// its probe finishes. A nil *Log records nothing.
+type Log struct {
+	command string
+
+	mu  sync.Mutex
+	f   *os.File
+	n   int
+	err error // First write error, reported by Close
+}
+
+// Open opens (or creates) an audit log for a command
+func Open(path, command string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{command: command, f: f}, nil
+}
+
+// Record appends an entry. The time defaults to now. Write errors do not
+// stop the run; the first one is returned by Close.
+func (l *Log) Record(e Entry) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	e.Command = l.command
+
+	line, err := json.Marshal(e)
+	if err == nil {
+		line = append(line, '\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil && l.f == nil {
+		err = os.ErrClosed
+	}
+	if err == nil {
+		_, err = l.f.Write(line)
+	}
+	if err != nil {
+		if l.err == nil {
+			l.err = err
+		}
+		return
+	}
+	l.n++
+}
+
+// Len returns the number of entries written by this run
+func (l *Log) Len() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+// Close flushes the log to disk and returns the first write error. Later
+// calls do nothing.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+
+	err := l.err
+	if syncErr := l.f.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	l.f = nil
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	return nil
+}
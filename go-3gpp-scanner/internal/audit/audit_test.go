@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestLogAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	first, err := Open(path, "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Record(Entry{
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)),
+		Probe:    "tcp",
+		Target:   "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+		IP:       "192.0.2.1",
+		SourceIP: "198.51.100.2",
+		Result:   "OK",
+	})
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Open(path, "probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			second.Record(Entry{Probe: "ike", Target: "epdg.example.org", Result: "TIMEOUT"})
+		}()
+	}
+	wg.Wait()
+	if second.Len() != 20 {
+		t.Errorf("Len = %d, want 20", second.Len())
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readEntries(t, path)
+	if len(entries) != 21 {
+		t.Fatalf("got %d entries, want 21", len(entries))
+	}
+	if entries[0].Command != "ping" || entries[0].Time.Location() != time.UTC || entries[0].Time.Hour() != 2 {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	for _, e := range entries[1:] {
+		if e.Command != "probe" || e.Probe != "ike" || e.Time.IsZero() {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	l.Record(Entry{Target: "epdg.example.org"})
+	if l.Len() != 0 || l.Close() != nil {
+		t.Errorf("nil log should record nothing")
+	}
+}
@@ -0,0 +1,253 @@
+package bgp
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Source looks up the BGP origin of addresses
+type Source interface {
+	// Lookup returns the route an address is announced in; ok is false
+	// when no route covers it
+	Lookup(ctx context.Context, ip string) (origin models.IPOrigin, ok bool, err error)
+}
+
+// clouds maps the origin ASes of large cloud and hosting providers to the
+// provider, to spot endpoints run in their space
+var clouds = map[int]string{
+	16509: "AWS", 14618: "AWS", 8987: "AWS", 38895: "AWS",
+	15169: "Google Cloud", 396982: "Google Cloud", 19527: "Google Cloud", 139070: "Google Cloud",
+	8075: "Microsoft Azure", 8068: "Microsoft Azure", 8069: "Microsoft Azure", 12076: "Microsoft Azure",
+	31898: "Oracle Cloud", 7160: "Oracle Cloud",
+	45102: "Alibaba Cloud", 37963: "Alibaba Cloud",
+	132203: "Tencent Cloud", 45090: "Tencent Cloud",
+	136907: "Huawei Cloud", 55990: "Huawei Cloud",
+	36351: "IBM Cloud",
+	13335: "Cloudflare",
+	20940: "Akamai", 16625: "Akamai", 63949: "Akamai",
+	14061: "DigitalOcean",
+	16276: "OVHcloud",
+	24940: "Hetzner",
+}
+
+// CloudProvider returns the cloud provider operating an AS, or "" for
+// other networks
+func CloudProvider(asn int) string {
+	return clouds[asn]
+}
+
+// RIB is a local routing table of prefixes and their origin ASes, e.g. a
+// CAIDA prefix-to-AS file or a table dump of a route collector
+type RIB struct {
+	routes map[netip.Prefix]int
+	bits   []int // Prefix lengths present, longest first
+	names  map[int]string
+}
+
+// OpenRIB loads a routing table from a file, see LoadRIB
+func OpenRIB(path string) (*RIB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open routing table: %w", err)
+	}
+	defer file.Close()
+
+	rib, err := LoadRIB(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rib, nil
+}
+
+// LoadRIB reads a routing table with one route per line, either as
+// "PREFIX ASN" (e.g. "193.0.0.0/21 3333", as in bgp.tools table.txt) or
+// as "ADDRESS LENGTH ASN" (CAIDA pfx2as). Of multi-origin routes such as
+// "701_703" or "{701,703}" the first AS is used. Blank lines and #
+// comments are ignored.
+func LoadRIB(r io.Reader) (*RIB, error) {
+	rib := &RIB{routes: make(map[netip.Prefix]int)}
+	lengths := make(map[int]bool)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		var prefix netip.Prefix
+		var asnField string
+		var err error
+		switch {
+		case len(fields) >= 3 && !strings.Contains(fields[0], "/"):
+			prefix, err = netip.ParsePrefix(fields[0] + "/" + fields[1])
+			asnField = fields[2]
+		case len(fields) >= 2:
+			prefix, err = netip.ParsePrefix(fields[0])
+			asnField = fields[1]
+		default:
+			return nil, fmt.Errorf("line %d: expected a prefix and an origin AS", line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid prefix: %w", line, err)
+		}
+		asn, err := parseASN(asnField)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		prefix = prefix.Masked()
+		rib.routes[prefix] = asn
+		lengths[prefix.Bits()] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read routing table: %w", err)
+	}
+
+	for bits := range lengths {
+		rib.bits = append(rib.bits, bits)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(rib.bits)))
+	return rib, nil
+}
+
+// parseASN parses "3333", "AS3333" or the first AS of a multi-origin
+// route such as "701_703" or "{701,703}"
+func parseASN(s string) (int, error) {
+	s = strings.TrimPrefix(strings.ToUpper(s), "AS")
+	first := strings.FieldsFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if len(first) == 0 {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	asn, err := strconv.Atoi(first[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	return asn, nil
+}
+
+// Len returns the number of routes
+func (r *RIB) Len() int {
+	return len(r.routes)
+}
+
+// LoadNames reads AS names from a CSV file of AS number and name, such as
+// bgp.tools asns.csv ("asn,name,..." with "AS3333" numbers). Rows whose
+// first column is not an AS number, like a header, are skipped.
+func (r *RIB) LoadNames(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open AS names: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if r.names == nil {
+		r.names = make(map[int]string)
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(record) < 2 {
+			continue
+		}
+		if asn, err := parseASN(strings.TrimSpace(record[0])); err == nil {
+			r.names[asn] = strings.TrimSpace(record[1])
+		}
+	}
+}
+
+// Lookup implements Source with a longest-prefix match
+func (r *RIB) Lookup(ctx context.Context, ip string) (models.IPOrigin, bool, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return models.IPOrigin{}, false, fmt.Errorf("invalid address %q", ip)
+	}
+	addr = addr.Unmap()
+
+	for _, bits := range r.bits {
+		if bits > addr.BitLen() {
+			continue
+		}
+		prefix, _ := addr.Prefix(bits)
+		if asn, ok := r.routes[prefix]; ok {
+			return models.IPOrigin{IP: ip, Prefix: prefix.String(), ASN: asn, ASName: r.names[asn]}, true, nil
+		}
+	}
+	return models.IPOrigin{}, false, nil
+}
+
+// LookupAll looks up the origins of addresses with parallel workers and
+// marks those in cloud provider space. Addresses without a route are left
+// out. It returns the origins sorted by address, the number of failed
+// lookups and the first failure.
+func LookupAll(ctx context.Context, source Source, ips []string, workers int) ([]models.IPOrigin, int, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, ip := range ips {
+			select {
+			case jobs <- ip:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var origins []models.IPOrigin
+	var failed int
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				origin, ok, err := source.Lookup(ctx, ip)
+				mu.Lock()
+				switch {
+				case err != nil:
+					failed++
+					if firstErr == nil {
+						firstErr = err
+					}
+				case ok:
+					origin.Cloud = CloudProvider(origin.ASN)
+					origins = append(origins, origin)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(origins, func(i, j int) bool {
+		return origins[i].IP < origins[j].IP
+	})
+	return origins, failed, firstErr
+}
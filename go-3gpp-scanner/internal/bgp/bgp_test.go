@@ -0,0 +1,135 @@
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+const testRIB = `# prefix origin
+192.0.0.0/16 64500
+192.0.2.0/24 64501
+2001:db8::/32 AS64502
+198.51.100.0	24	16509_64503
+`
+
+func TestRIBLookup(t *testing.T) {
+	rib, err := LoadRIB(strings.NewReader(testRIB))
+	if err != nil {
+		t.Fatalf("LoadRIB: %v", err)
+	}
+	if rib.Len() != 4 {
+		t.Errorf("Len = %d, want 4", rib.Len())
+	}
+
+	names := filepath.Join(t.TempDir(), "asns.csv")
+	if err := os.WriteFile(names, []byte("asn,name,class,cc\nAS64501,EXAMPLE-NET,Eyeball,US\n16509,AMAZON-02\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := rib.LoadNames(names); err != nil {
+		t.Fatalf("LoadNames: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want models.IPOrigin
+		ok   bool
+	}{
+		{"192.0.2.10", models.IPOrigin{IP: "192.0.2.10", Prefix: "192.0.2.0/24", ASN: 64501, ASName: "EXAMPLE-NET"}, true},
+		{"192.0.3.1", models.IPOrigin{IP: "192.0.3.1", Prefix: "192.0.0.0/16", ASN: 64500}, true},
+		{"::ffff:192.0.2.1", models.IPOrigin{IP: "::ffff:192.0.2.1", Prefix: "192.0.2.0/24", ASN: 64501, ASName: "EXAMPLE-NET"}, true},
+		{"2001:db8::1", models.IPOrigin{IP: "2001:db8::1", Prefix: "2001:db8::/32", ASN: 64502}, true},
+		{"198.51.100.7", models.IPOrigin{IP: "198.51.100.7", Prefix: "198.51.100.0/24", ASN: 16509, ASName: "AMAZON-02"}, true},
+		{"203.0.113.1", models.IPOrigin{}, false},
+	}
+	for _, tt := range tests {
+		got, ok, err := rib.Lookup(context.Background(), tt.ip)
+		if err != nil || ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%s) = %+v, %v, %v; want %+v, %v", tt.ip, got, ok, err, tt.want, tt.ok)
+		}
+	}
+
+	if _, _, err := rib.Lookup(context.Background(), "not-an-ip"); err == nil {
+		t.Errorf("Lookup of an invalid address should fail")
+	}
+}
+
+func TestLoadRIBInvalid(t *testing.T) {
+	for _, data := range []string{"192.0.2.0/24", "192.0.2.0/33 64500", "192.0.2.0/24 ASX"} {
+		if _, err := LoadRIB(strings.NewReader(data)); err == nil {
+			t.Errorf("LoadRIB(%q) should fail", data)
+		}
+	}
+}
+
+func TestLookupAll(t *testing.T) {
+	rib, err := LoadRIB(strings.NewReader(testRIB))
+	if err != nil {
+		t.Fatalf("LoadRIB: %v", err)
+	}
+
+	origins, failed, err := LookupAll(context.Background(), rib, []string{"198.51.100.7", "203.0.113.1", "bad", "192.0.2.1"}, 3)
+	if failed != 1 || err == nil {
+		t.Errorf("LookupAll failures = %d, %v; want 1 and an error", failed, err)
+	}
+	want := []models.IPOrigin{
+		{IP: "192.0.2.1", Prefix: "192.0.2.0/24", ASN: 64501},
+		{IP: "198.51.100.7", Prefix: "198.51.100.0/24", ASN: 16509, Cloud: "AWS"},
+	}
+	if !reflect.DeepEqual(origins, want) {
+		t.Errorf("LookupAll = %+v, want %+v", origins, want)
+	}
+}
+
+func TestRIPEstatLookup(t *testing.T) {
+	holderCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource := r.URL.Query().Get("resource")
+		switch r.URL.Path {
+		case "/data/network-info/data.json":
+			if resource == "203.0.113.1" {
+				fmt.Fprint(w, `{"data":{"asns":[],"prefix":""}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"asns":["3333"],"prefix":"193.0.0.0/21"}}`)
+		case "/data/as-overview/data.json":
+			holderCalls++
+			if resource != "AS3333" {
+				t.Errorf("as-overview resource = %q", resource)
+			}
+			fmt.Fprint(w, `{"data":{"holder":"RIPE-NCC-AS"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := &RIPEstat{BaseURL: server.URL, HTTPClient: server.Client()}
+	for _, ip := range []string{"193.0.6.139", "193.0.6.140"} {
+		got, ok, err := source.Lookup(context.Background(), ip)
+		want := models.IPOrigin{IP: ip, Prefix: "193.0.0.0/21", ASN: 3333, ASName: "RIPE-NCC-AS"}
+		if err != nil || !ok || got != want {
+			t.Errorf("Lookup(%s) = %+v, %v, %v; want %+v", ip, got, ok, err, want)
+		}
+	}
+	if holderCalls != 1 {
+		t.Errorf("AS holder looked up %d times, want once", holderCalls)
+	}
+
+	if _, ok, err := source.Lookup(context.Background(), "203.0.113.1"); ok || err != nil {
+		t.Errorf("Lookup of an unannounced address = %v, %v; want not found", ok, err)
+	}
+
+	source.BaseURL = server.URL + "/missing"
+	if _, _, err := source.Lookup(context.Background(), "193.0.6.139"); err == nil {
+		t.Errorf("Lookup should fail on an HTTP error")
+	}
+}
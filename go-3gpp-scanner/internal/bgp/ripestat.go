@@ -0,0 +1,115 @@
+package bgp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// DefaultRIPEstatURL is the RIPEstat Data API
+const DefaultRIPEstatURL = "https://stat.ripe.net"
+
+// RIPEstat looks up origins with the RIPEstat Data API: network-info for
+// the announced prefix and origin AS, as-overview for the AS holder
+type RIPEstat struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	holders map[int]string
+}
+
+// NewRIPEstat creates a RIPEstat client for DefaultRIPEstatURL
+func NewRIPEstat() *RIPEstat {
+	return &RIPEstat{
+		BaseURL:    DefaultRIPEstatURL,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Lookup implements Source
+func (r *RIPEstat) Lookup(ctx context.Context, ip string) (models.IPOrigin, bool, error) {
+	var info struct {
+		Data struct {
+			ASNs   []string `json:"asns"`
+			Prefix string   `json:"prefix"`
+		} `json:"data"`
+	}
+	if err := r.get(ctx, "network-info", ip, &info); err != nil {
+		return models.IPOrigin{}, false, err
+	}
+	if len(info.Data.ASNs) == 0 || info.Data.Prefix == "" {
+		return models.IPOrigin{}, false, nil
+	}
+	asn, err := strconv.Atoi(info.Data.ASNs[0])
+	if err != nil {
+		return models.IPOrigin{}, false, fmt.Errorf("invalid AS number %q for %s", info.Data.ASNs[0], ip)
+	}
+
+	return models.IPOrigin{IP: ip, Prefix: info.Data.Prefix, ASN: asn, ASName: r.holder(ctx, asn)}, true, nil
+}
+
+// holder returns the holder of an AS, looked up once per AS; a failed
+// lookup leaves the name empty
+func (r *RIPEstat) holder(ctx context.Context, asn int) string {
+	r.mu.Lock()
+	name, ok := r.holders[asn]
+	r.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	var overview struct {
+		Data struct {
+			Holder string `json:"holder"`
+		} `json:"data"`
+	}
+	if err := r.get(ctx, "as-overview", "AS"+strconv.Itoa(asn), &overview); err != nil {
+		slog.Debug("AS holder lookup failed", "asn", asn, "error", err)
+		return ""
+	}
+
+	r.mu.Lock()
+	if r.holders == nil {
+		r.holders = make(map[int]string)
+	}
+	r.holders[asn] = overview.Data.Holder
+	r.mu.Unlock()
+	return overview.Data.Holder
+}
+
+// get queries one RIPEstat data call for a resource
+func (r *RIPEstat) get(ctx context.Context, call, resource string, v interface{}) error {
+	query := url.Values{"resource": {resource}, "sourceapp": {"3gpp-scanner"}}
+	endpoint := fmt.Sprintf("%s/data/%s/data.json?%s", r.BaseURL, call, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("RIPEstat %s request failed: %w", call, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RIPEstat %s for %s returned %s", call, resource, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse RIPEstat %s response: %w", call, err)
+	}
+	return nil
+}
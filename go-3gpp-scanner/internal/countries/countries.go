@@ -0,0 +1,60 @@
+package countries
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed countries.csv
+var countriesCSV string
+
+// Country is a country with the approximate geographic centre used to
+// place per-country aggregates on a map
+type Country struct {
+	Code      string  // ISO 3166-1 alpha-2
+	Name      string  // English short name
+	LocalName string  // Short name in the main official language and script
+	Latitude  float64 // Centroid latitude
+	Longitude float64 // Centroid longitude
+}
+
+var (
+	loadOnce sync.Once
+	byCode   map[string]Country
+	all      []Country
+)
+
+// load parses the embedded table once
+func load() {
+	loadOnce.Do(func() {
+		byCode = make(map[string]Country)
+		records, err := csv.NewReader(strings.NewReader(countriesCSV)).ReadAll()
+		if err != nil {
+			panic("countries: invalid embedded table: " + err.Error())
+		}
+		for _, record := range records[1:] {
+			lat, _ := strconv.ParseFloat(record[1], 64)
+			lon, _ := strconv.ParseFloat(record[2], 64)
+			c := Country{Code: record[0], Name: record[3], LocalName: record[4], Latitude: lat, Longitude: lon}
+			byCode[c.Code] = c
+			all = append(all, c)
+		}
+		loadMCCs()
+	})
+}
+
+// Lookup returns the country with an ISO alpha-2 code (case-insensitive)
+func Lookup(code string) (Country, bool) {
+	load()
+	c, ok := byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// All returns every known country, ordered by code
+func All() []Country {
+	load()
+	return all
+}
@@ -0,0 +1,99 @@
+package countries
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	c, ok := Lookup("de")
+	if !ok {
+		t.Fatalf("Expected DE to be known")
+	}
+	if c.Name != "Germany" || c.Latitude < 47 || c.Latitude > 55 || c.Longitude < 5 || c.Longitude > 15 {
+		t.Errorf("Unexpected country: %+v", c)
+	}
+
+	if _, ok := Lookup("ZZ"); ok {
+		t.Errorf("Expected ZZ to be unknown")
+	}
+}
+
+func TestAllValid(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range All() {
+		if len(c.Code) != 2 || c.Name == "" {
+			t.Errorf("Invalid entry: %+v", c)
+		}
+		if c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180 {
+			t.Errorf("Coordinates out of range: %+v", c)
+		}
+		if seen[c.Code] {
+			t.Errorf("Duplicate code %s", c.Code)
+		}
+		seen[c.Code] = true
+	}
+	if len(seen) < 200 {
+		t.Errorf("Expected at least 200 countries, got %d", len(seen))
+	}
+}
+
+func TestForMCC(t *testing.T) {
+	tests := []struct {
+		mcc, code string
+		ok        bool
+	}{
+		{"262", "DE", true},
+		{"311", "US", true},
+		{"405", "IN", true},
+		{"001", "", false}, // Test network
+		{"901", "", false}, // International, no country
+		{"x", "", false},
+	}
+	for _, tt := range tests {
+		c, ok := ForMCC(tt.mcc)
+		if ok != tt.ok || c.Code != tt.code {
+			t.Errorf("ForMCC(%s) = %s, %v; want %s, %v", tt.mcc, c.Code, ok, tt.code, tt.ok)
+		}
+	}
+
+	// Every MCC maps to a known country
+	load()
+	for mcc, code := range byMCC {
+		if _, ok := Lookup(code); !ok || len(mcc) != 3 {
+			t.Errorf("MCC %s maps to unknown country %s", mcc, code)
+		}
+	}
+	for _, c := range All() {
+		if c.LocalName == "" {
+			t.Errorf("%s has no local name", c.Code)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tests := []struct {
+		style     NameStyle
+		mcc, code string
+	}{
+		{"", "262", "DE"},
+		{NamesISO, "DE (262)", "DE"},
+		{NamesEnglish, "Germany (262)", "Germany"},
+		{NamesLocal, "Deutschland (262)", "Deutschland"},
+	}
+	for _, tt := range tests {
+		if got := MCCLabel("262", tt.style); got != tt.mcc {
+			t.Errorf("MCCLabel(262, %q) = %q, want %q", tt.style, got, tt.mcc)
+		}
+		if got := CodeLabel("DE", tt.style); got != tt.code {
+			t.Errorf("CodeLabel(DE, %q) = %q, want %q", tt.style, got, tt.code)
+		}
+	}
+	if got := MCCLabel("999", NamesEnglish); got != "999" {
+		t.Errorf("MCCLabel of an unassigned MCC = %q", got)
+	}
+
+	if style, err := ParseNameStyle("Local"); err != nil || style != NamesLocal {
+		t.Errorf("ParseNameStyle(Local) = %q, %v", style, err)
+	}
+	if _, err := ParseNameStyle("german"); err == nil {
+		t.Error("Expected error for an unknown style")
+	}
+}
@@ -0,0 +1,112 @@
+package countries
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mccCSV maps the MCCs of ITU-T E.212 to ISO alpha-2 codes. Countries
+// with several MCCs (US, India, Japan, ...) appear once per MCC; shared
+// MCCs (340 for the French Antilles) map to their main territory.
+//
+//go:embed mcc.csv
+var mccCSV string
+
+// byMCC maps zero-padded MCCs to country codes, filled by load
+var byMCC map[string]string
+
+// loadMCCs parses the embedded MCC table; called from load
+func loadMCCs() {
+	byMCC = make(map[string]string)
+	records, err := csv.NewReader(strings.NewReader(mccCSV)).ReadAll()
+	if err != nil {
+		panic("countries: invalid embedded MCC table: " + err.Error())
+	}
+	for _, record := range records[1:] {
+		byMCC[record[0]] = record[1]
+	}
+}
+
+// ForMCC returns the country a Mobile Country Code is assigned to. The MCC
+// may lack leading zeros.
+func ForMCC(mcc string) (Country, bool) {
+	load()
+	n, err := strconv.Atoi(strings.TrimSpace(mcc))
+	if err != nil || n < 0 || n > 999 {
+		return Country{}, false
+	}
+	code, ok := byMCC[fmt.Sprintf("%03d", n)]
+	if !ok {
+		return Country{}, false
+	}
+	return Lookup(code)
+}
+
+// NameStyle selects how countries are named in text output
+type NameStyle string
+
+// Name styles
+const (
+	NamesISO     NameStyle = "iso"     // ISO alpha-2 code, e.g. DE
+	NamesEnglish NameStyle = "english" // English short name, e.g. Germany
+	NamesLocal   NameStyle = "local"   // Name in the country's language, e.g. Deutschland
+)
+
+// NameStyles lists the accepted name styles
+func NameStyles() []NameStyle {
+	return []NameStyle{NamesISO, NamesEnglish, NamesLocal}
+}
+
+// ParseNameStyle parses a name style; the empty string is the zero style,
+// which keeps MCCs and codes as they are
+func ParseNameStyle(s string) (NameStyle, error) {
+	style := NameStyle(strings.ToLower(strings.TrimSpace(s)))
+	if style == "" {
+		return "", nil
+	}
+	for _, known := range NameStyles() {
+		if style == known {
+			return style, nil
+		}
+	}
+	return "", fmt.Errorf("invalid country names: %s (must be iso, english or local)", s)
+}
+
+// NameIn returns the name of a country in a style; the zero style gives
+// the code
+func (c Country) NameIn(style NameStyle) string {
+	switch style {
+	case NamesEnglish:
+		return c.Name
+	case NamesLocal:
+		return c.LocalName
+	}
+	return c.Code
+}
+
+// CodeLabel names the country with an ISO alpha-2 code in a style, or
+// returns the code when it is unknown or the style is zero
+func CodeLabel(code string, style NameStyle) string {
+	if style == "" {
+		return code
+	}
+	if c, ok := Lookup(code); ok {
+		return c.NameIn(style)
+	}
+	return code
+}
+
+// MCCLabel names an MCC by its country, e.g. "Germany (262)", or returns
+// the MCC when its country is unknown or the style is zero
+func MCCLabel(mcc string, style NameStyle) string {
+	if style == "" {
+		return mcc
+	}
+	if c, ok := ForMCC(mcc); ok {
+		return fmt.Sprintf("%s (%s)", c.NameIn(style), mcc)
+	}
+	return mcc
+}
@@ -0,0 +1,205 @@
+// Package crypt encrypts result files and databases at rest with a
+// passphrase. Files are sealed with AES-256-GCM in 64 KiB chunks, so large
+// databases stream through a small buffer; the key is derived from the
+// passphrase with PBKDF2-SHA256 and a random salt.
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Suffix is appended to a file's name to form the name of its encrypted copy
+const Suffix = ".enc"
+
+// ErrDecrypt is returned when a file does not decrypt: the passphrase is
+// wrong, or the file was modified or truncated
+var ErrDecrypt = errors.New("wrong passphrase or corrupted file")
+
+// File layout: magic, PBKDF2 iterations, salt and nonce prefix, then the
+// sealed chunks. Each chunk nonce is the prefix, the chunk counter and a
+// final-chunk flag, so chunks cannot be reordered, dropped or appended.
+const (
+	magic      = "3GPPENC1"
+	saltSize   = 16
+	prefixSize = 7
+	headerSize = len(magic) + 4 + saltSize + prefixSize
+	chunkSize  = 64 << 10
+	keySize    = 32
+
+	maxIterations = 10_000_000 // Refuse headers that would stall decryption
+)
+
+// iterations is the PBKDF2 work factor of new files; tests lower it
+var iterations = 600_000
+
+// Encrypt reads plaintext from r and writes it encrypted with passphrase to w
+func Encrypt(w io.Writer, r io.Reader, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return fmt.Errorf("passphrase cannot be empty")
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[len(magic):], uint32(iterations))
+	if _, err := rand.Read(header[len(magic)+4:]); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	return chunks(bufio.NewReaderSize(r, chunkSize), chunkSize, func(counter uint32, chunk []byte, last bool) error {
+		_, err := w.Write(aead.Seal(nil, nonce(header, counter, last), chunk, header))
+		return err
+	})
+}
+
+// Decrypt reads a file written by Encrypt from r and writes its plaintext
+// to w. It returns ErrDecrypt when authentication fails; plaintext already
+// written before the failing chunk must then be discarded.
+func Decrypt(w io.Writer, r io.Reader, passphrase []byte) error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte(magic)) {
+		return fmt.Errorf("not an encrypted file")
+	}
+	if iter := binary.BigEndian.Uint32(header[len(magic):]); iter == 0 || iter > maxIterations {
+		return fmt.Errorf("unsupported key derivation work factor")
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return err
+	}
+
+	return chunks(bufio.NewReaderSize(r, chunkSize+aead.Overhead()), chunkSize+aead.Overhead(), func(counter uint32, chunk []byte, last bool) error {
+		plain, err := aead.Open(nil, nonce(header, counter, last), chunk, header)
+		if err != nil {
+			return ErrDecrypt
+		}
+		_, err = w.Write(plain)
+		return err
+	})
+}
+
+// EncryptFile writes an encrypted copy of src to dst
+func EncryptFile(src, dst string, passphrase []byte) error {
+	return transform(src, dst, func(w io.Writer, r io.Reader) error {
+		return Encrypt(w, r, passphrase)
+	})
+}
+
+// DecryptFile writes the plaintext of the encrypted file src to dst. A
+// failed decryption leaves no partial dst behind.
+func DecryptFile(src, dst string, passphrase []byte) error {
+	return transform(src, dst, func(w io.Writer, r io.Reader) error {
+		return Decrypt(w, r, passphrase)
+	})
+}
+
+// IsEncrypted reports whether a file starts like one written by Encrypt
+func IsEncrypted(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	buf := make([]byte, len(magic))
+	if _, err := io.ReadFull(file, buf); err != nil {
+		return false, nil
+	}
+	return string(buf) == magic, nil
+}
+
+// newAEAD derives the key of a file from the passphrase and the salt in
+// its header
+func newAEAD(passphrase, header []byte) (cipher.AEAD, error) {
+	iter := int(binary.BigEndian.Uint32(header[len(magic):]))
+	salt := header[len(magic)+4 : len(magic)+4+saltSize]
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, iter, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce is the GCM nonce of one chunk
+func nonce(header []byte, counter uint32, last bool) []byte {
+	n := make([]byte, 0, prefixSize+5)
+	n = append(n, header[headerSize-prefixSize:]...)
+	n = binary.BigEndian.AppendUint32(n, counter)
+	if last {
+		return append(n, 1)
+	}
+	return append(n, 0)
+}
+
+// chunks hands r to fn in pieces of size bytes, flagging the last one. An
+// empty input is a single empty last chunk.
+func chunks(r *bufio.Reader, size int, fn func(counter uint32, chunk []byte, last bool) error) error {
+	buf := make([]byte, size)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := n < size
+		if !last {
+			if _, peekErr := r.Peek(1); peekErr == io.EOF {
+				last = true
+			}
+		}
+		if err := fn(counter, buf[:n], last); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return fmt.Errorf("file too large")
+		}
+	}
+}
+
+// transform writes fn's output from src to a temporary file next to dst
+// and moves it into place once complete
+func transform(src, dst string, fn func(w io.Writer, r io.Reader) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	if err := fn(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
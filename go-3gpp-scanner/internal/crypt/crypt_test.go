@@ -0,0 +1,110 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	defer func(n int) { iterations = n }(iterations)
+	iterations = 1000
+	passphrase := []byte("correct horse")
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 100},
+		{"one chunk", chunkSize},
+		{"chunk and a byte", chunkSize + 1},
+		{"several chunks", 3*chunkSize + 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := bytes.Repeat([]byte("epdg"), tt.size/4+1)[:tt.size]
+			var sealed bytes.Buffer
+			if err := Encrypt(&sealed, bytes.NewReader(plain), passphrase); err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if tt.size > 0 && bytes.Contains(sealed.Bytes(), plain[:min(tt.size, 64)]) {
+				t.Errorf("ciphertext contains plaintext")
+			}
+
+			var opened bytes.Buffer
+			if err := Decrypt(&opened, bytes.NewReader(sealed.Bytes()), passphrase); err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !bytes.Equal(opened.Bytes(), plain) {
+				t.Errorf("round trip changed %d bytes into %d", len(plain), opened.Len())
+			}
+
+			if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("wrong passphrase: expected ErrDecrypt, got %v", err)
+			}
+			truncated := sealed.Bytes()[:sealed.Len()-1]
+			if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated), passphrase); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("truncated: expected ErrDecrypt, got %v", err)
+			}
+		})
+	}
+
+	// Dropping the final chunk must not decrypt as a shorter file
+	var sealed bytes.Buffer
+	if err := Encrypt(&sealed, bytes.NewReader(make([]byte, 2*chunkSize)), passphrase); err != nil {
+		t.Fatal(err)
+	}
+	dropped := sealed.Bytes()[:headerSize+chunkSize+16]
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(dropped), passphrase); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("dropped chunk: expected ErrDecrypt, got %v", err)
+	}
+
+	if err := Encrypt(&bytes.Buffer{}, bytes.NewReader(nil), nil); err == nil {
+		t.Errorf("expected error for empty passphrase")
+	}
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader([]byte("FQDN,Operator\n")), passphrase); err == nil || err.Error() != "not an encrypted file" {
+		t.Errorf("plaintext input: got %v", err)
+	}
+}
+
+func TestEncryptFile(t *testing.T) {
+	defer func(n int) { iterations = n }(iterations)
+	iterations = 1000
+	passphrase := []byte("correct horse")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "results.csv")
+	if err := os.WriteFile(src, []byte("FQDN,Operator\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	enc := src + Suffix
+	if err := EncryptFile(src, enc, passphrase); err != nil {
+		t.Fatalf("EncryptFile: %v", err)
+	}
+	for path, want := range map[string]bool{src: false, enc: true} {
+		if got, err := IsEncrypted(path); err != nil || got != want {
+			t.Errorf("IsEncrypted(%s) = %v, %v", filepath.Base(path), got, err)
+		}
+	}
+
+	out := filepath.Join(dir, "decrypted.csv")
+	if err := DecryptFile(enc, out, []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected ErrDecrypt, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("failed decryption left %s behind", out)
+	}
+	if err := DecryptFile(enc, out, passphrase); err != nil {
+		t.Fatalf("DecryptFile: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "FQDN,Operator\n" {
+		t.Errorf("decrypted = %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("expected only the three files, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,246 @@
+package ctlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCrtShURL       = "https://crt.sh"
+	DefaultCertSpotterURL = "https://api.certspotter.com"
+	userAgent             = "3gpp-scanner (+https://github.com/asnd/sec100)"
+)
+
+// Certificate is a logged certificate and the DNS names it covers
+type Certificate struct {
+	ID     string
+	Issuer string
+	Names  []string
+}
+
+// Source searches certificate transparency logs
+type Source interface {
+	// Search returns the logged certificates with names below domain
+	Search(ctx context.Context, domain string) ([]Certificate, error)
+}
+
+// CrtSh searches the crt.sh CT log aggregator
+type CrtSh struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewCrtSh creates a crt.sh client. Queries of large domains are slow, so
+// the client waits up to two minutes.
+func NewCrtSh() *CrtSh {
+	return &CrtSh{
+		BaseURL:    DefaultCrtShURL,
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Search implements Source
+func (c *CrtSh) Search(ctx context.Context, domain string) ([]Certificate, error) {
+	query := url.Values{"q": {"%." + domain}, "output": {"json"}, "deduplicate": {"Y"}}
+	var entries []struct {
+		ID         int64  `json:"id"`
+		IssuerName string `json:"issuer_name"`
+		CommonName string `json:"common_name"`
+		NameValue  string `json:"name_value"` // Newline-separated SANs
+	}
+	if err := getJSON(ctx, c.HTTPClient, c.BaseURL+"/?"+query.Encode(), "", &entries); err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+
+	certs := make([]Certificate, 0, len(entries))
+	for _, e := range entries {
+		names := strings.Split(e.NameValue, "\n")
+		if e.CommonName != "" {
+			names = append(names, e.CommonName)
+		}
+		certs = append(certs, Certificate{ID: strconv.FormatInt(e.ID, 10), Issuer: e.IssuerName, Names: names})
+	}
+	return certs, nil
+}
+
+// CertSpotter searches the SSLMate Cert Spotter API. Unauthenticated
+// clients are rate limited; APIKey raises the limits.
+type CertSpotter struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+	MaxPages   int // Pages of issuances to fetch at most, 0 for no limit
+}
+
+// NewCertSpotter creates a Cert Spotter client
+func NewCertSpotter(apiKey string) *CertSpotter {
+	return &CertSpotter{
+		BaseURL:    DefaultCertSpotterURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxPages:   100,
+	}
+}
+
+// Search implements Source, following the pagination of the issuances
+// endpoint
+func (c *CertSpotter) Search(ctx context.Context, domain string) ([]Certificate, error) {
+	var certs []Certificate
+	after := ""
+	for page := 0; c.MaxPages == 0 || page < c.MaxPages; page++ {
+		query := url.Values{
+			"domain":             {domain},
+			"include_subdomains": {"true"},
+			"expand":             {"dns_names", "issuer"},
+		}
+		if after != "" {
+			query.Set("after", after)
+		}
+		var issuances []struct {
+			ID       string   `json:"id"`
+			DNSNames []string `json:"dns_names"`
+			Issuer   struct {
+				Name string `json:"name"`
+			} `json:"issuer"`
+		}
+		endpoint := c.BaseURL + "/v1/issuances?" + query.Encode()
+		if err := getJSON(ctx, c.HTTPClient, endpoint, c.APIKey, &issuances); err != nil {
+			return nil, fmt.Errorf("certspotter: %w", err)
+		}
+		if len(issuances) == 0 {
+			break
+		}
+		for _, i := range issuances {
+			certs = append(certs, Certificate{ID: i.ID, Issuer: i.Issuer.Name, Names: i.DNSNames})
+		}
+		after = issuances[len(issuances)-1].ID
+	}
+	return certs, nil
+}
+
+// getJSON fetches and decodes a JSON document, with a bearer token if one
+// is given
+func getJSON(ctx context.Context, client *http.Client, endpoint, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// Names returns the distinct names of certificates below domain,
+// lowercased and sorted. Wildcards contribute the name they cover
+// ("*.ims.example" yields "ims.example"); the domain itself is left out.
+func Names(certs []Certificate, domain string) []string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	seen := make(map[string]bool)
+	var names []string
+	for _, cert := range certs {
+		for _, name := range cert.Names {
+			name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+			name = strings.TrimPrefix(name, "*.")
+			if !strings.HasSuffix(name, "."+domain) || !validName(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validName reports whether a name consists of DNS labels only, so that
+// e.g. e-mail addresses in certificate names are dropped
+func validName(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MergeFile appends the names not yet in a candidate list (one FQDN per
+// line; blank lines and # comments are ignored) to it, creating the file
+// if needed, and returns the names added. Existing lines are kept as
+// they are.
+func MergeFile(path string, names []string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read candidates: %w", err)
+	}
+	known := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			known[strings.TrimSuffix(strings.ToLower(line), ".")] = true
+		}
+	}
+
+	var added []string
+	for _, name := range names {
+		if !known[name] {
+			known[name] = true
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	out, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open candidates: %w", err)
+	}
+	w := bufio.NewWriter(out)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		w.WriteString("\n")
+	}
+	for _, name := range added {
+		fmt.Fprintln(w, name)
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to write candidates: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write candidates: %w", err)
+	}
+	return added, nil
+}
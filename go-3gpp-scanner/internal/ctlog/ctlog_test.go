@@ -0,0 +1,116 @@
+package ctlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCrtShSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "%.pub.3gppnetwork.org" {
+			t.Errorf("query = %q", q)
+		}
+		fmt.Fprint(w, `[
+			{"id": 1, "issuer_name": "C=US, O=Example CA", "common_name": "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+			 "name_value": "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org\n*.ims.mnc001.mcc262.pub.3gppnetwork.org"},
+			{"id": 2, "issuer_name": "C=US, O=Example CA", "common_name": "",
+			 "name_value": "XCAP.IMS.MNC002.MCC262.PUB.3GPPNETWORK.ORG\nadmin@pub.3gppnetwork.org\nwww.example.org"}
+		]`)
+	}))
+	defer server.Close()
+
+	source := &CrtSh{BaseURL: server.URL, HTTPClient: server.Client()}
+	certs, err := source.Search(context.Background(), "pub.3gppnetwork.org")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(certs) != 2 || certs[0].ID != "1" || certs[1].Issuer != "C=US, O=Example CA" {
+		t.Fatalf("Search = %+v", certs)
+	}
+
+	want := []string{
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+		"ims.mnc001.mcc262.pub.3gppnetwork.org",
+		"xcap.ims.mnc002.mcc262.pub.3gppnetwork.org",
+	}
+	if got := Names(certs, "pub.3gppnetwork.org"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names = %v, want %v", got, want)
+	}
+}
+
+func TestCertSpotterSearch(t *testing.T) {
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `[{"id": "10", "dns_names": ["a.mnc001.mcc262.pub.3gppnetwork.org"], "issuer": {"name": "CA"}}]`)
+		case "10":
+			fmt.Fprint(w, `[{"id": "11", "dns_names": ["b.mnc001.mcc262.pub.3gppnetwork.org"]}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	source := &CertSpotter{BaseURL: server.URL, APIKey: "secret", HTTPClient: server.Client()}
+	certs, err := source.Search(context.Background(), "pub.3gppnetwork.org")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(certs) != 2 || certs[1].ID != "11" || pages != 3 {
+		t.Errorf("Search = %+v after %d pages", certs, pages)
+	}
+
+	source.MaxPages = 1
+	if certs, err := source.Search(context.Background(), "pub.3gppnetwork.org"); err != nil || len(certs) != 1 {
+		t.Errorf("Search with one page = %+v, %v", certs, err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	source.BaseURL = failing.URL
+	if _, err := source.Search(context.Background(), "pub.3gppnetwork.org"); err == nil {
+		t.Errorf("Search should fail when rate limited")
+	}
+}
+
+func TestMergeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidates.txt")
+
+	added, err := MergeFile(path, []string{"a.example", "b.example"})
+	if err != nil || !reflect.DeepEqual(added, []string{"a.example", "b.example"}) {
+		t.Fatalf("MergeFile into new file = %v, %v", added, err)
+	}
+
+	// Existing entries, comments and a missing final newline are kept
+	if err := os.WriteFile(path, []byte("# seeds\nA.example.\nc.example"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added, err = MergeFile(path, []string{"a.example", "c.example", "d.example"})
+	if err != nil || !reflect.DeepEqual(added, []string{"d.example"}) {
+		t.Fatalf("MergeFile = %v, %v", added, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# seeds\nA.example.\nc.example\nd.example\n"; string(data) != want {
+		t.Errorf("candidates = %q, want %q", data, want)
+	}
+
+	if added, err := MergeFile(path, []string{"d.example"}); err != nil || added != nil {
+		t.Errorf("MergeFile without new names = %v, %v", added, err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package customreport renders user-supplied Go templates over scan results,
+// statistics and run metadata, so teams can produce their own deliverables.
+package customreport
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/stats"
+)
+
+// Data is what a report template sees as its dot
+type Data struct {
+	Meta    Meta
+	Vars    map[string]string // --set key=value pairs, e.g. customer name or logo URL
+	Results []models.DNSResult
+	Pings   []models.PingResult
+	Stats   *models.Stats          // Nil without results or a database
+	Latency *models.LatencySummary // Nil without ping results
+	Vendors map[string]string      // FQDN to fingerprinted vendor (database only)
+	Tags    map[string][]string    // FQDN to tags (database only)
+	Notes   []models.Note          // Analyst notes (database only)
+}
+
+// Meta describes the report run and its inputs
+type Meta struct {
+	Generated time.Time
+	Version   string
+	Template  string
+	Results   string    // Scan export read, if any
+	Pings     string    // Ping export read, if any
+	Database  string    // Database read, if any
+	FirstSeen time.Time // Earliest result timestamp; zero without results
+	LastSeen  time.Time // Latest result timestamp
+}
+
+// SetTimes fills FirstSeen and LastSeen from the result timestamps
+func (d *Data) SetTimes() {
+	see := func(t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if d.Meta.FirstSeen.IsZero() || t.Before(d.Meta.FirstSeen) {
+			d.Meta.FirstSeen = t
+		}
+		if t.After(d.Meta.LastSeen) {
+			d.Meta.LastSeen = t
+		}
+	}
+	for _, r := range d.Results {
+		see(r.Timestamp)
+	}
+	for _, r := range d.Pings {
+		see(r.Timestamp)
+	}
+}
+
+// Funcs are the functions available to templates besides the builtins
+var Funcs = map[string]interface{}{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// date formats a time with a Go layout, e.g. {{date "2006-01-02" .Meta.Generated}}
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+	// byCount sorts a count map by descending count, then key:
+	// {{range byCount .Stats.MCCDistribution}}{{.Key}} {{.Value}}{{end}}
+	"byCount": byCount,
+	// top keeps the first n entries of byCount
+	"top": func(n int, pairs []stats.KeyValue) []stats.KeyValue {
+		if n < len(pairs) {
+			return pairs[:n]
+		}
+		return pairs
+	},
+	// percent formats part of a whole, e.g. "12.5%"
+	"percent": func(part, whole int) string {
+		if whole == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(whole))
+	},
+	// ms formats a duration in milliseconds, e.g. "42.3"
+	"ms": func(d time.Duration) string {
+		return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+	},
+	"add": func(a, b int) int { return a + b },
+}
+
+func byCount(m map[string]int) []stats.KeyValue {
+	pairs := make([]stats.KeyValue, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, stats.KeyValue{Key: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Value != pairs[j].Value {
+			return pairs[i].Value > pairs[j].Value
+		}
+		return pairs[i].Key < pairs[j].Key
+	})
+	return pairs
+}
+
+// IsHTML reports whether a template file name calls for HTML escaping:
+// .html or .htm, optionally followed by .tmpl
+func IsHTML(path string) bool {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(path)), ".tmpl")
+	return strings.HasSuffix(name, ".html") || strings.HasSuffix(name, ".htm")
+}
+
+// Template is a parsed report template
+type Template struct {
+	exec interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+}
+
+// Parse parses a template. HTML templates are parsed with html/template,
+// which escapes values for their context, so results cannot inject markup.
+// Missing .Vars keys render empty, so optional branding can be left unset.
+func Parse(name, text string, html bool) (*Template, error) {
+	var t Template
+	var err error
+	if html {
+		t.exec, err = htmltemplate.New(name).Option("missingkey=zero").Funcs(Funcs).Parse(text)
+	} else {
+		t.exec, err = texttemplate.New(name).Option("missingkey=zero").Funcs(Funcs).Parse(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &t, nil
+}
+
+// ParseFile parses a template file
+func ParseFile(path string, html bool) (*Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return Parse(filepath.Base(path), string(text), html)
+}
+
+// Execute renders the template for data
+func (t *Template) Execute(w io.Writer, data *Data) error {
+	if err := t.exec.Execute(w, data); err != nil {
+		return fmt.Errorf("template failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package customreport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func testData() *Data {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	d := &Data{
+		Meta: Meta{Version: "1.0.0"},
+		Vars: map[string]string{"customer": "Example <Telecom>"},
+		Results: []models.DNSResult{
+			{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Timestamp: t0.Add(time.Hour)},
+			{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Timestamp: t0},
+		},
+		Pings: []models.PingResult{{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Latency: 42300 * time.Microsecond}},
+		Stats: &models.Stats{MCCDistribution: map[string]int{"262": 2, "310": 5, "208": 2}},
+	}
+	d.SetTimes()
+	return d
+}
+
+func render(t *testing.T, text string, html bool) string {
+	t.Helper()
+	tmpl, err := Parse("test", text, html)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, testData()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestSetTimes(t *testing.T) {
+	d := testData()
+	if got := d.Meta.FirstSeen.Format("15:04"); got != "10:00" {
+		t.Errorf("FirstSeen = %s", got)
+	}
+	if got := d.Meta.LastSeen.Format("15:04"); got != "11:00" {
+		t.Errorf("LastSeen = %s", got)
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{range byCount .Stats.MCCDistribution}}{{.Key}}={{.Value}} {{end}}`, "310=5 208=2 262=2 "},
+		{`{{range top 1 (byCount .Stats.MCCDistribution)}}{{.Key}}{{end}}`, "310"},
+		{`{{percent 1 8}}`, "12.5%"},
+		{`{{percent 1 0}}`, "0%"},
+		{`{{range .Pings}}{{ms .Latency}}{{end}}`, "42.3"},
+		{`{{date "2006-01-02" .Meta.FirstSeen}}`, "2024-05-01"},
+		{`{{upper .Meta.Version}} {{len .Results}} {{add 1 2}}`, "1.0.0 2 3"},
+		{`{{.Vars.missing}}`, ""},
+	}
+	for _, tt := range tests {
+		if got := render(t, tt.text, false); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLEscapes(t *testing.T) {
+	text := `<h1>{{.Vars.customer}}</h1>`
+	if got := render(t, text, false); got != "<h1>Example <Telecom></h1>" {
+		t.Errorf("text template = %q", got)
+	}
+	if got := render(t, text, true); got != "<h1>Example &lt;Telecom&gt;</h1>" {
+		t.Errorf("HTML template = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("bad", "{{range}}", false); err == nil {
+		t.Errorf("Expected error for invalid template")
+	}
+	if _, err := Parse("bad", "{{nosuchfunc 1}}", true); err == nil {
+		t.Errorf("Expected error for unknown function")
+	}
+
+	tmpl, err := Parse("missing", "{{.Nope}}", false)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, testData()); err == nil || !strings.Contains(err.Error(), "template failed") {
+		t.Errorf("Expected execution error for unknown field, got %v", err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html.tmpl")
+	if err := os.WriteFile(path, []byte(`{{.Vars.customer}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := ParseFile(path, IsHTML(path))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, testData()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "Example &lt;Telecom&gt;" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	if _, err := ParseFile(filepath.Join(t.TempDir(), "missing.tmpl"), false); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	for path, want := range map[string]bool{
+		"report.html": true, "report.HTM": true, "dir/report.html.tmpl": true,
+		"report.tmpl": false, "summary.md.tmpl": false, "html.tmpl": false,
+	} {
+		if got := IsHTML(path); got != want {
+			t.Errorf("IsHTML(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
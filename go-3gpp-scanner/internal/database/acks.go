@@ -0,0 +1,183 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Findings acknowledged by every command, besides the alert kinds of
+// monitor and alerts
+const (
+	FindingAll     = "all"      // Every finding on the target
+	FindingNewFQDN = "new-fqdn" // The FQDN is new since a --since reference point
+)
+
+// Ack acknowledges a finding on an FQDN or operator as reviewed, so
+// recurring reports stop repeating it
+type Ack struct {
+	Finding string     `json:"finding"`
+	Kind    string     `json:"kind"`
+	Target  string     `json:"target"`
+	Note    string     `json:"note,omitempty"`
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"` // Nil for acknowledgments that never lapse
+}
+
+// Active reports whether the acknowledgment still applies at now
+func (a Ack) Active(now time.Time) bool {
+	return a.Expires == nil || now.Before(*a.Expires)
+}
+
+// ackCondition excludes FQDNs acknowledged as new, directly or through
+// their operator, while the acknowledgment is active
+const ackCondition = `fqdn NOT IN (SELECT target FROM acknowledgments WHERE kind = 'fqdn'
+		AND finding IN ('all', 'new-fqdn') AND (expires IS NULL OR expires > ?))
+	AND operator NOT IN (SELECT target FROM acknowledgments WHERE kind = 'operator'
+		AND finding IN ('all', 'new-fqdn') AND (expires IS NULL OR expires > ?))`
+
+// AddAcks acknowledges a finding on targets of a kind and returns how many
+// targets were acknowledged. Acknowledging again replaces the note and
+// expiry; a nil expires never lapses.
+func (db *DB) AddAcks(finding, kind string, targets []string, note string, expires *time.Time) (int, error) {
+	var expiry sql.NullString
+	if expires != nil {
+		expiry = sql.NullString{String: expires.UTC().Format(time.RFC3339), Valid: true}
+	}
+	created := time.Now().UTC().Format(time.RFC3339)
+	return db.changeAcks(finding, kind, targets,
+		"INSERT OR REPLACE INTO acknowledgments (finding, kind, target, note, created, expires) VALUES (?, ?, ?, ?, ?, ?)",
+		note, created, expiry)
+}
+
+// RemoveAcks withdraws the acknowledgment of a finding on targets of a kind
+// and returns how many were removed
+func (db *DB) RemoveAcks(finding, kind string, targets []string) (int, error) {
+	return db.changeAcks(finding, kind, targets,
+		"DELETE FROM acknowledgments WHERE finding = ? AND kind = ? AND target = ?")
+}
+
+// changeAcks runs an insert or delete for each target in one transaction;
+// extra arguments follow finding, kind and target
+func (db *DB) changeAcks(finding, kind string, targets []string, query string, extra ...interface{}) (int, error) {
+	if kind != TagKindFQDN && kind != TagKindOperator {
+		return 0, fmt.Errorf("invalid acknowledgment kind: %s", kind)
+	}
+	if finding == "" {
+		return 0, fmt.Errorf("finding cannot be empty")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare acknowledgment statement: %w", err)
+	}
+	defer stmt.Close()
+
+	changed := 0
+	for _, target := range targets {
+		res, err := stmt.Exec(append([]interface{}{finding, kind, target}, extra...)...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update acknowledgment: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		changed += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changed, nil
+}
+
+// QueryAcks returns the acknowledgments of a finding, or of every finding
+// when empty, by finding, kind and target; expired ones included
+func (db *DB) QueryAcks(finding string) ([]Ack, error) {
+	query := "SELECT finding, kind, target, note, created, expires FROM acknowledgments"
+	var args []interface{}
+	if finding != "" {
+		query += " WHERE finding = ?"
+		args = append(args, finding)
+	}
+	query += " ORDER BY finding, kind, target"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var acks []Ack
+	for rows.Next() {
+		var a Ack
+		var note, expires sql.NullString
+		var created string
+		if err := rows.Scan(&a.Finding, &a.Kind, &a.Target, &note, &created, &expires); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		a.Note = note.String
+		a.Created, _ = time.Parse(time.RFC3339, created)
+		if t, err := time.Parse(time.RFC3339, expires.String); expires.Valid && err == nil {
+			a.Expires = &t
+		}
+		acks = append(acks, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return acks, nil
+}
+
+// AckSet answers whether findings are acknowledged
+type AckSet struct {
+	acked map[string]bool // finding, kind and target joined by newlines
+}
+
+// ActiveAcks returns the acknowledgments active at now
+func (db *DB) ActiveAcks(now time.Time) (*AckSet, error) {
+	acks, err := db.QueryAcks("")
+	if err != nil {
+		return nil, err
+	}
+	set := &AckSet{acked: make(map[string]bool)}
+	for _, a := range acks {
+		if a.Active(now) {
+			set.acked[strings.Join([]string{a.Finding, a.Kind, a.Target}, "\n")] = true
+		}
+	}
+	return set, nil
+}
+
+// Len returns the number of active acknowledgments
+func (s *AckSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.acked)
+}
+
+// Covers reports whether a finding on an FQDN of an operator is
+// acknowledged, for the finding or all findings, on the FQDN or the
+// operator. Either may be empty, e.g. for findings on a whole operator.
+func (s *AckSet) Covers(finding, fqdn, operator string) bool {
+	if s == nil {
+		return false
+	}
+	for _, f := range []string{finding, FindingAll} {
+		if fqdn != "" && s.acked[f+"\n"+TagKindFQDN+"\n"+fqdn] {
+			return true
+		}
+		if operator != "" && s.acked[f+"\n"+TagKindOperator+"\n"+operator] {
+			return true
+		}
+	}
+	return false
+}
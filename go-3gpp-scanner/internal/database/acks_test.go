@@ -0,0 +1,124 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestAcks(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "acks.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	fqdns := []string{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "ims.mnc001.mcc262.pub.3gppnetwork.org"}
+	if n, err := db.AddAcks("ip-changed", TagKindFQDN, fqdns, "GeoDNS", nil); err != nil || n != 2 {
+		t.Fatalf("AddAcks fqdn: %d, %v", n, err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := db.AddAcks(FindingAll, TagKindOperator, []string{"Telekom"}, "", &past); err != nil {
+		t.Fatalf("AddAcks operator: %v", err)
+	}
+	if _, err := db.AddAcks("cert-expiring", TagKindOperator, []string{"Vodafone"}, "", nil); err != nil {
+		t.Fatalf("AddAcks operator: %v", err)
+	}
+	if _, err := db.AddAcks("ip-changed", "plmn", fqdns, "", nil); err == nil {
+		t.Error("Expected error for an invalid kind")
+	}
+
+	acks, err := db.QueryAcks("")
+	if err != nil || len(acks) != 4 {
+		t.Fatalf("QueryAcks = %d acknowledgments, %v, want 4", len(acks), err)
+	}
+	if acks[0].Finding != FindingAll || acks[0].Active(time.Now()) || acks[0].Expires == nil {
+		t.Errorf("Expected the expired acknowledgment first, got %+v", acks[0])
+	}
+	if acks, err := db.QueryAcks("ip-changed"); err != nil || len(acks) != 2 || acks[0].Note != "GeoDNS" {
+		t.Errorf("QueryAcks(ip-changed) = %+v, %v", acks, err)
+	}
+
+	set, err := db.ActiveAcks(time.Now())
+	if err != nil {
+		t.Fatalf("ActiveAcks: %v", err)
+	}
+	if set.Len() != 3 {
+		t.Errorf("Len = %d, want 3", set.Len())
+	}
+	tests := []struct {
+		finding, fqdn, operator string
+		want                    bool
+	}{
+		{"ip-changed", fqdns[0], "Telekom", true},
+		{"unresolved", fqdns[0], "Telekom", false},                                   // Operator acknowledgment expired
+		{"cert-expiring", "bsf.mnc002.mcc262.pub.3gppnetwork.org", "Vodafone", true}, // Through the operator
+		{"cert-expired", "bsf.mnc002.mcc262.pub.3gppnetwork.org", "Vodafone", false},
+		{"cert-expiring", "", "Vodafone", true},
+	}
+	for _, tt := range tests {
+		if got := set.Covers(tt.finding, tt.fqdn, tt.operator); got != tt.want {
+			t.Errorf("Covers(%s, %s, %s) = %v, want %v", tt.finding, tt.fqdn, tt.operator, got, tt.want)
+		}
+	}
+	if (*AckSet)(nil).Covers("ip-changed", fqdns[0], "") {
+		t.Error("A nil set covers nothing")
+	}
+
+	// Acknowledging again replaces the note
+	if _, err := db.AddAcks("ip-changed", TagKindFQDN, fqdns[:1], "Load balancer", nil); err != nil {
+		t.Fatalf("AddAcks again: %v", err)
+	}
+	if acks, _ := db.QueryAcks("ip-changed"); acks[0].Note != "Load balancer" {
+		t.Errorf("Note = %q after acknowledging again", acks[0].Note)
+	}
+	if n, err := db.RemoveAcks("ip-changed", TagKindFQDN, fqdns); err != nil || n != 2 {
+		t.Errorf("RemoveAcks: %d, %v", n, err)
+	}
+	if acks, _ := db.QueryAcks("ip-changed"); len(acks) != 0 {
+		t.Errorf("Acknowledgments left after removal: %+v", acks)
+	}
+}
+
+func TestSinceAcknowledged(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "since.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	// The second run adds the FQDNs of Operator 3 and Operator 4
+	results := testResults(5)
+	if err := db.InsertResultsBatch(results[:3], InsertOptions{Run: &models.Run{Kind: RunKindScan, Started: time.Now()}}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+	if err := db.InsertResultsBatch(results, InsertOptions{SkipExisting: true, Run: &models.Run{Kind: RunKindScan, Started: time.Now()}}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+	since, _ := ParseSince("run:1")
+
+	if _, err := db.AddAcks(FindingNewFQDN, TagKindFQDN, []string{results[3].FQDN}, "", nil); err != nil {
+		t.Fatalf("AddAcks: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if _, err := db.AddAcks(FindingAll, TagKindOperator, []string{"Operator 4"}, "", &past); err != nil {
+		t.Fatalf("AddAcks: %v", err)
+	}
+	fqdns, err := db.QuerySincePage(QueryOptions{Since: since})
+	if err != nil || len(fqdns) != 1 || fqdns[0] != results[4].FQDN {
+		t.Errorf("QuerySincePage = %v, %v, want only %s", fqdns, err, results[4].FQDN)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if _, err := db.AddAcks(FindingAll, TagKindOperator, []string{"Operator 4"}, "", &future); err != nil {
+		t.Fatalf("AddAcks: %v", err)
+	}
+	if n, err := db.CountFQDNs(FQDNFilter{Since: since}); err != nil || n != 0 {
+		t.Errorf("CountFQDNs = %d, %v, want 0 once both are acknowledged", n, err)
+	}
+	if n, err := db.CountFQDNs(FQDNFilter{}); err != nil || n != 5 {
+		t.Errorf("CountFQDNs without --since = %d, %v, want 5", n, err)
+	}
+}
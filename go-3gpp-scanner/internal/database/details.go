@@ -0,0 +1,115 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// QueryDetails joins each FQDN with its operator, the country of its zone
+// (mncXXX.mccYYY), its stored addresses and the latest time a recorded run
+// found it. Rows keep the order of fqdns; FQDNs not in the database are
+// skipped.
+func (db *DB) QueryDetails(fqdns []string) ([]models.FQDNDetail, error) {
+	details := make([]models.FQDNDetail, 0, len(fqdns))
+	for start := 0; start < len(fqdns); start += DefaultBatchSize {
+		end := start + DefaultBatchSize
+		if end > len(fqdns) {
+			end = len(fqdns)
+		}
+		batch, err := db.queryDetailsBatch(fqdns[start:end])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, batch...)
+	}
+	return details, nil
+}
+
+// queryDetailsBatch answers QueryDetails for at most DefaultBatchSize FQDNs
+func (db *DB) queryDetailsBatch(fqdns []string) ([]models.FQDNDetail, error) {
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(fqdns)), ", ")
+	args := make([]interface{}, len(fqdns))
+	for i, fqdn := range fqdns {
+		args[i] = fqdn
+	}
+
+	// Stored MNCs are padded to the three digits of the zone name
+	rows, err := db.conn.Query(`
+		SELECT f.fqdn, MAX(f.operator), MAX(o.country_name), MAX(o.country_code)
+		FROM (
+			SELECT fqdn, operator, instr(fqdn, '.mcc') AS pos FROM available_fqdns WHERE fqdn IN (`+in+`)
+		) f
+		LEFT JOIN (
+			SELECT substr('000' || mnc, -3) AS mnc, mcc, MAX(country_name) AS country_name, MAX(UPPER(country_code)) AS country_code
+			FROM operators GROUP BY 1, mcc
+		) o ON f.pos > 3 AND o.mnc = substr(f.fqdn, f.pos - 3, 3) AND o.mcc = substr(f.fqdn, f.pos + 4, 3)
+		GROUP BY f.fqdn`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	byFQDN := make(map[string]*models.FQDNDetail, len(fqdns))
+	for rows.Next() {
+		var d models.FQDNDetail
+		var operator, country, code sql.NullString
+		if err := rows.Scan(&d.FQDN, &operator, &country, &code); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		d.Operator, d.Country, d.CountryCode = operator.String, country.String, code.String
+		d.IPs = []string{}
+		byFQDN[d.FQDN] = &d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	ips, err := db.conn.Query("SELECT fqdn, ip FROM fqdn_ips WHERE fqdn IN ("+in+") ORDER BY fqdn, ip", args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer ips.Close()
+	for ips.Next() {
+		var fqdn, ip string
+		if err := ips.Scan(&fqdn, &ip); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if d := byFQDN[fqdn]; d != nil {
+			d.IPs = append(d.IPs, ip)
+		}
+	}
+	if err := ips.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	seen, err := db.conn.Query("SELECT fqdn, MAX(seen) FROM fqdn_runs WHERE fqdn IN ("+in+") GROUP BY fqdn", args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer seen.Close()
+	for seen.Next() {
+		var fqdn, last string
+		if err := seen.Scan(&fqdn, &last); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if t, err := time.Parse(time.RFC3339, last); err == nil && byFQDN[fqdn] != nil {
+			byFQDN[fqdn].LastSeen = &t
+		}
+	}
+	if err := seen.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	details := make([]models.FQDNDetail, 0, len(byFQDN))
+	for _, fqdn := range fqdns {
+		if d := byFQDN[fqdn]; d != nil {
+			details = append(details, *d)
+			delete(byFQDN, fqdn)
+		}
+	}
+	return details, nil
+}
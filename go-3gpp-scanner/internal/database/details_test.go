@@ -0,0 +1,46 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestQueryDetails(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	seen := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	scanned := []models.DNSResult{{
+		FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom",
+		Country: "Germany", CountryCode: "de", IPs: []string{"192.0.2.2", "192.0.2.1"}, Timestamp: seen,
+	}}
+	if err := db.InsertResultsBatch(scanned, InsertOptions{Run: &models.Run{Kind: RunKindScan}}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+	// No run and no operator details
+	if err := db.InsertResults([]models.DNSResult{{
+		FQDN: "ims.mnc005.mcc001.pub.3gppnetwork.org", MNC: "05", MCC: "001", Operator: "Test Network",
+	}}); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+
+	got, err := db.QueryDetails([]string{"ims.mnc005.mcc001.pub.3gppnetwork.org", "missing.example", "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"})
+	if err != nil {
+		t.Fatalf("QueryDetails: %v", err)
+	}
+	want := []models.FQDNDetail{
+		{FQDN: "ims.mnc005.mcc001.pub.3gppnetwork.org", Operator: "Test Network", IPs: []string{}},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", Country: "Germany", CountryCode: "DE",
+			IPs: []string{"192.0.2.1", "192.0.2.2"}, LastSeen: &seen},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryDetails = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build cgo && !sqlite_purego
+
+package database
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The SQLite driver of cgo builds, linking the SQLite C library
+const (
+	DriverName   = "sqlite3"                     // database/sql driver name
+	DriverModule = "github.com/mattn/go-sqlite3" // Go module providing it
+)
+
+// dsnParams renders the connection settings as go-sqlite3 parameters
+func (o *options) dsnParams() url.Values {
+	params := url.Values{}
+	params.Set("_busy_timeout", strconv.FormatInt(o.busyTimeout.Milliseconds(), 10))
+	if o.readOnly {
+		return params
+	}
+	params.Set("_journal_mode", strings.ToUpper(o.journalMode))
+	if o.synchronous != "" {
+		params.Set("_synchronous", strings.ToUpper(o.synchronous))
+	}
+	params.Set("_txlock", "immediate")
+	return params
+}
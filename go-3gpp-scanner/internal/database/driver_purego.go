@@ -0,0 +1,35 @@
+//go:build !cgo || sqlite_purego
+
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// The SQLite driver of builds without cgo, or with the sqlite_purego tag:
+// SQLite translated to Go, so static binaries cross-compile without a C
+// toolchain
+const (
+	DriverName   = "sqlite"             // database/sql driver name
+	DriverModule = "modernc.org/sqlite" // Go module providing it
+)
+
+// dsnParams renders the connection settings as modernc.org/sqlite
+// parameters, which run each _pragma on every new connection
+func (o *options) dsnParams() url.Values {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", o.busyTimeout.Milliseconds()))
+	if o.readOnly {
+		return params
+	}
+	params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", strings.ToUpper(o.journalMode)))
+	if o.synchronous != "" {
+		params.Add("_pragma", fmt.Sprintf("synchronous(%s)", strings.ToUpper(o.synchronous)))
+	}
+	params.Set("_txlock", "immediate")
+	return params
+}
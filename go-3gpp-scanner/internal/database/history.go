@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// SaveIPHistory stores passive DNS resolutions. A resolution already
+// stored for the same FQDN, address and source is widened to the earliest
+// first and latest last sighting, keeping the higher count.
+func (db *DB) SaveIPHistory(resolutions []models.IPResolution) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO ip_history (fqdn, ip, source, first_seen, last_seen, count) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (fqdn, ip, source) DO UPDATE SET
+			first_seen = MIN(first_seen, excluded.first_seen),
+			last_seen = MAX(last_seen, excluded.last_seen),
+			count = MAX(COALESCE(count, 0), COALESCE(excluded.count, 0))`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare history statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range resolutions {
+		var count interface{}
+		if r.Count > 0 {
+			count = r.Count
+		}
+		_, err := stmt.Exec(r.FQDN, r.IP, r.Source,
+			r.FirstSeen.UTC().Format(time.RFC3339), r.LastSeen.UTC().Format(time.RFC3339), count)
+		if err != nil {
+			return fmt.Errorf("failed to insert resolution: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryIPHistory returns the stored resolutions of an FQDN ordered by
+// FQDN, first sighting and address; an empty FQDN matches all
+func (db *DB) QueryIPHistory(fqdn string) ([]models.IPResolution, error) {
+	query := "SELECT fqdn, ip, source, first_seen, last_seen, count FROM ip_history"
+	var args []interface{}
+	if fqdn != "" {
+		query += " WHERE fqdn = ?"
+		args = append(args, fqdn)
+	}
+	query += " ORDER BY fqdn, first_seen, ip, source"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.IPResolution
+	for rows.Next() {
+		var r models.IPResolution
+		var first, last string
+		var count sql.NullInt64
+		if err := rows.Scan(&r.FQDN, &r.IP, &r.Source, &first, &last, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		r.FirstSeen, _ = time.Parse(time.RFC3339, first)
+		r.LastSeen, _ = time.Parse(time.RFC3339, last)
+		r.Count = int(count.Int64)
+		history = append(history, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return history, nil
+}
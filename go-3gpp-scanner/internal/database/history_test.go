@@ -0,0 +1,54 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestIPHistory(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	const fqdn = "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"
+
+	if err := db.SaveIPHistory([]models.IPResolution{
+		{FQDN: fqdn, IP: "192.0.2.1", FirstSeen: day(10), LastSeen: day(20), Count: 5, Source: "dnsdb"},
+		{FQDN: fqdn, IP: "192.0.2.1", FirstSeen: day(15), LastSeen: day(15), Source: "virustotal"},
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", IP: "192.0.2.7", FirstSeen: day(1), LastSeen: day(2), Source: "dnsdb"},
+	}); err != nil {
+		t.Fatalf("SaveIPHistory: %v", err)
+	}
+	// A later query widens the sightings of the same provider
+	if err := db.SaveIPHistory([]models.IPResolution{
+		{FQDN: fqdn, IP: "192.0.2.1", FirstSeen: day(5), LastSeen: day(12), Count: 3, Source: "dnsdb"},
+		{FQDN: fqdn, IP: "192.0.2.2", FirstSeen: day(25), LastSeen: day(28), Count: 1, Source: "dnsdb"},
+	}); err != nil {
+		t.Fatalf("SaveIPHistory again: %v", err)
+	}
+
+	history, err := db.QueryIPHistory(fqdn)
+	if err != nil {
+		t.Fatalf("QueryIPHistory: %v", err)
+	}
+	want := []models.IPResolution{
+		{FQDN: fqdn, IP: "192.0.2.1", FirstSeen: day(5), LastSeen: day(20), Count: 5, Source: "dnsdb"},
+		{FQDN: fqdn, IP: "192.0.2.1", FirstSeen: day(15), LastSeen: day(15), Source: "virustotal"},
+		{FQDN: fqdn, IP: "192.0.2.2", FirstSeen: day(25), LastSeen: day(28), Count: 1, Source: "dnsdb"},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("QueryIPHistory = %+v, want %+v", history, want)
+	}
+
+	all, err := db.QueryIPHistory("")
+	if err != nil || len(all) != 4 {
+		t.Errorf("QueryIPHistory of all FQDNs = %d resolutions, %v", len(all), err)
+	}
+}
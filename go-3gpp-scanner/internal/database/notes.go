@@ -0,0 +1,130 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ErrNoteNotFound is returned when editing or deleting a missing note
+var ErrNoteNotFound = errors.New("note not found")
+
+// validateNote checks the kind and text of a note; notes attach to the
+// same kinds of targets as tags
+func validateNote(kind, target, text string) error {
+	if kind != TagKindFQDN && kind != TagKindOperator {
+		return fmt.Errorf("invalid note kind: %s", kind)
+	}
+	if target == "" {
+		return fmt.Errorf("note target cannot be empty")
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("note text cannot be empty")
+	}
+	return nil
+}
+
+// AddNote attaches a note to an FQDN or operator
+func (db *DB) AddNote(kind, target, text string) (models.Note, error) {
+	if err := validateNote(kind, target, text); err != nil {
+		return models.Note{}, err
+	}
+
+	note := models.Note{Kind: kind, Target: target, Text: text, Created: time.Now().UTC().Truncate(time.Second)}
+	res, err := db.conn.Exec("INSERT INTO notes (kind, target, note, created) VALUES (?, ?, ?, ?)",
+		kind, target, text, note.Created.Format(time.RFC3339))
+	if err != nil {
+		return models.Note{}, fmt.Errorf("failed to add note: %w", err)
+	}
+	if note.ID, err = res.LastInsertId(); err != nil {
+		return models.Note{}, fmt.Errorf("failed to add note: %w", err)
+	}
+	return note, nil
+}
+
+// EditNote replaces the text of a note
+func (db *DB) EditNote(id int64, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("note text cannot be empty")
+	}
+	res, err := db.conn.Exec("UPDATE notes SET note = ?, updated = ? WHERE id = ?",
+		text, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("failed to edit note: %w", err)
+	}
+	return noteChanged(res, id)
+}
+
+// DeleteNote removes a note
+func (db *DB) DeleteNote(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM notes WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	return noteChanged(res, id)
+}
+
+// noteChanged reports a missing note when a statement touched no row
+func noteChanged(res sql.Result, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("note %d: %w", id, ErrNoteNotFound)
+	}
+	return nil
+}
+
+// QueryNotes returns the notes on a kind and target, oldest first; empty
+// arguments match all
+func (db *DB) QueryNotes(kind, target string) ([]models.Note, error) {
+	var conditions []string
+	var args []interface{}
+	if kind != "" {
+		conditions = append(conditions, "kind = ?")
+		args = append(args, kind)
+	}
+	if target != "" {
+		conditions = append(conditions, "target = ?")
+		args = append(args, target)
+	}
+	query := "SELECT id, kind, target, note, created, updated FROM notes"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []models.Note
+	for rows.Next() {
+		var n models.Note
+		var created string
+		var updated sql.NullString
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Target, &n.Text, &created, &updated); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		n.Created, _ = time.Parse(time.RFC3339, created)
+		if updated.Valid {
+			if t, err := time.Parse(time.RFC3339, updated.String); err == nil {
+				n.Updated = &t
+			}
+		}
+		notes = append(notes, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return notes, nil
+}
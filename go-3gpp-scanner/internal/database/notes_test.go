@@ -0,0 +1,63 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	first, err := db.AddNote(TagKindFQDN, "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org", "answers IKE_SA_INIT")
+	if err != nil {
+		t.Fatalf("AddNote: %v", err)
+	}
+	second, err := db.AddNote(TagKindOperator, "Operator 1", "contact via PSIRT")
+	if err != nil {
+		t.Fatalf("AddNote: %v", err)
+	}
+	if first.ID == 0 || second.ID <= first.ID || first.Created.IsZero() {
+		t.Errorf("unexpected notes %+v %+v", first, second)
+	}
+
+	if err := db.EditNote(first.ID, "answers IKE_SA_INIT, vendor unknown"); err != nil {
+		t.Fatalf("EditNote: %v", err)
+	}
+	notes, err := db.QueryNotes(TagKindFQDN, "")
+	if err != nil {
+		t.Fatalf("QueryNotes: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Text != "answers IKE_SA_INIT, vendor unknown" || notes[0].Updated == nil {
+		t.Errorf("QueryNotes after edit = %+v", notes)
+	}
+
+	if err := db.DeleteNote(first.ID); err != nil {
+		t.Fatalf("DeleteNote: %v", err)
+	}
+	if err := db.DeleteNote(first.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("DeleteNote twice: %v", err)
+	}
+	if err := db.EditNote(first.ID, "x"); err == nil {
+		t.Error("EditNote of a deleted note succeeded")
+	}
+
+	notes, err = db.QueryNotes("", "")
+	if err != nil {
+		t.Fatalf("QueryNotes: %v", err)
+	}
+	if len(notes) != 1 || notes[0].ID != second.ID || notes[0].Updated != nil {
+		t.Errorf("QueryNotes = %+v", notes)
+	}
+
+	if _, err := db.AddNote(TagKindFQDN, "x", "  "); err == nil {
+		t.Error("AddNote accepted empty text")
+	}
+	if _, err := db.AddNote("vendor", "x", "y"); err == nil {
+		t.Error("AddNote accepted an invalid kind")
+	}
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// SaveIPOrigins stores the BGP origins of addresses, replacing earlier
+// lookups of the same addresses
+func (db *DB) SaveIPOrigins(origins []models.IPOrigin) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO ip_origins (ip, prefix, asn, as_name, cloud, looked_up) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare origin statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, o := range origins {
+		if _, err := stmt.Exec(o.IP, o.Prefix, o.ASN, nullable(o.ASName), nullable(o.Cloud), now); err != nil {
+			return fmt.Errorf("failed to insert origin: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryIPOrigins returns the stored origin of every looked up address
+func (db *DB) QueryIPOrigins() (map[string]models.IPOrigin, error) {
+	rows, err := db.conn.Query("SELECT ip, prefix, asn, as_name, cloud FROM ip_origins")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	origins := make(map[string]models.IPOrigin)
+	for rows.Next() {
+		var o models.IPOrigin
+		var name, cloud sql.NullString
+		if err := rows.Scan(&o.IP, &o.Prefix, &o.ASN, &name, &cloud); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		o.ASName, o.Cloud = name.String, cloud.String
+		origins[o.IP] = o
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return origins, nil
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestIPOrigins(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveIPOrigins([]models.IPOrigin{
+		{IP: "192.0.2.1", Prefix: "192.0.2.0/24", ASN: 64500},
+		{IP: "198.51.100.7", Prefix: "198.51.100.0/24", ASN: 16509, ASName: "AMAZON-02", Cloud: "AWS"},
+	}); err != nil {
+		t.Fatalf("SaveIPOrigins: %v", err)
+	}
+	// A new lookup replaces the stored origin
+	if err := db.SaveIPOrigins([]models.IPOrigin{
+		{IP: "192.0.2.1", Prefix: "192.0.0.0/22", ASN: 64501, ASName: "EXAMPLE"},
+	}); err != nil {
+		t.Fatalf("SaveIPOrigins again: %v", err)
+	}
+
+	got, err := db.QueryIPOrigins()
+	if err != nil {
+		t.Fatalf("QueryIPOrigins: %v", err)
+	}
+	want := map[string]models.IPOrigin{
+		"192.0.2.1":    {IP: "192.0.2.1", Prefix: "192.0.0.0/22", ASN: 64501, ASName: "EXAMPLE"},
+		"198.51.100.7": {IP: "198.51.100.7", Prefix: "198.51.100.0/24", ASN: 16509, ASName: "AMAZON-02", Cloud: "AWS"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryIPOrigins = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// SaveResults stores results in the common envelope, e.g. of probes, with
+// their metadata as JSON. Every call adds rows, so repeated runs keep
+// their history.
+func (db *DB) SaveResults(results []models.Result) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO results (target, type, ips, success, status, latency_us, metadata, error, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare results statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		if r.Target == "" || r.Type == "" {
+			return fmt.Errorf("result without target or type")
+		}
+		var metadata interface{}
+		if len(r.Metadata) > 0 {
+			data, err := json.Marshal(r.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata: %w", err)
+			}
+			metadata = string(data)
+		}
+		var latency interface{}
+		if r.Latency > 0 {
+			latency = r.Latency.Microseconds()
+		}
+		timestamp := r.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		_, err := stmt.Exec(r.Target, r.Type, nullable(strings.Join(r.IPs, ",")), r.Success, nullable(string(r.Status)),
+			latency, metadata, nullable(r.Error), timestamp.UTC().Format(time.RFC3339))
+		if err != nil {
+			return fmt.Errorf("failed to insert result: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryResults returns stored results of a target and type, oldest first;
+// empty arguments match all
+func (db *DB) QueryResults(target, typ string) ([]models.Result, error) {
+	query := "SELECT target, type, ips, success, status, latency_us, metadata, error, timestamp FROM results"
+	var conditions []string
+	var args []interface{}
+	if target != "" {
+		conditions = append(conditions, "target = ?")
+		args = append(args, target)
+	}
+	if typ != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, typ)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.Result
+	for rows.Next() {
+		var r models.Result
+		var ips, status, metadata, errMsg sql.NullString
+		var latency sql.NullInt64
+		var timestamp string
+		if err := rows.Scan(&r.Target, &r.Type, &ips, &r.Success, &status, &latency, &metadata, &errMsg, &timestamp); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if ips.String != "" {
+			r.IPs = strings.Split(ips.String, ",")
+		}
+		if metadata.String != "" {
+			if err := json.Unmarshal([]byte(metadata.String), &r.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of %s: %w", r.Target, err)
+			}
+		}
+		r.Status = models.ResultStatus(status.String)
+		r.Latency = time.Duration(latency.Int64) * time.Microsecond
+		r.Error = errMsg.String
+		r.Timestamp, _ = time.Parse(time.RFC3339, timestamp)
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return results, nil
+}
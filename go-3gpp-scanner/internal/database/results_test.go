@@ -0,0 +1,50 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestResults(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	const fqdn = "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	saved := []models.Result{
+		{Target: fqdn, Type: "ike", IPs: []string{"192.0.2.1"}, Success: true, Status: models.StatusOK,
+			Latency: 42 * time.Millisecond, Metadata: map[string]string{"vendor_id": "strongSwan"}, Timestamp: at},
+		{Target: fqdn, Type: "sip", IPs: []string{"192.0.2.1"}, Status: models.StatusTimeout, Error: "no reply", Timestamp: at},
+		models.DNSResult{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.7", "2001:db8::7"}, MCC: "262", MNC: "01", Timestamp: at}.Result(),
+	}
+	if err := db.SaveResults(saved); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+
+	all, err := db.QueryResults("", "")
+	if err != nil {
+		t.Fatalf("QueryResults: %v", err)
+	}
+	if !reflect.DeepEqual(all, saved) {
+		t.Errorf("QueryResults = %+v, want %+v", all, saved)
+	}
+
+	ike, err := db.QueryResults(fqdn, "ike")
+	if err != nil {
+		t.Fatalf("QueryResults of a type: %v", err)
+	}
+	if len(ike) != 1 || ike[0].Metadata["vendor_id"] != "strongSwan" {
+		t.Errorf("QueryResults(%s, ike) = %+v", fqdn, ike)
+	}
+
+	if err := db.SaveResults([]models.Result{{Target: fqdn}}); err == nil {
+		t.Error("SaveResults without type succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Run kinds: how the results of a run got into the database
+const (
+	RunKindScan     = "scan"
+	RunKindImported = "imported"
+)
+
+// insertRun records a run and the FQDNs it stored, and sets run.ID
+func insertRun(tx *sql.Tx, run *models.Run, results []models.DNSResult, batchSize int) error {
+	if run.Kind != RunKindScan && run.Kind != RunKindImported {
+		return fmt.Errorf("invalid run kind: %s", run.Kind)
+	}
+	if run.Started.IsZero() {
+		run.Started = time.Now()
+	}
+	run.Results = len(results)
+
+	res, err := tx.Exec("INSERT INTO runs (kind, source, started, results) VALUES (?, ?, ?, ?)",
+		run.Kind, run.Source, run.Started.UTC().Format(time.RFC3339), run.Results)
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(results))
+	for _, result := range results {
+		seen := result.Timestamp
+		if seen.IsZero() {
+			seen = run.Started
+		}
+		rows = append(rows, []interface{}{result.FQDN, id, seen.UTC().Format(time.RFC3339)})
+	}
+	if err := insertRows(tx, "INSERT OR IGNORE INTO fqdn_runs (fqdn, run_id, seen) VALUES ", rows, batchSize); err != nil {
+		return fmt.Errorf("failed to insert run fqdns: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+// QueryRuns returns the recorded runs, oldest first
+func (db *DB) QueryRuns() ([]models.Run, error) {
+	rows, err := db.conn.Query("SELECT id, kind, source, started, results FROM runs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.Run
+	for rows.Next() {
+		var r models.Run
+		var source sql.NullString
+		var started string
+		if err := rows.Scan(&r.ID, &r.Kind, &source, &started, &r.Results); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		r.Source = source.String
+		r.Started, _ = time.Parse(time.RFC3339, started)
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+	return runs, nil
+}
+
+// Since is the reference point of an incremental query: FQDNs first stored
+// by a run after RunID, or first seen at or after Time. FQDNs stored
+// before runs were recorded have no first run and never match, nor do
+// FQDNs acknowledged as new-fqdn. The zero value matches every FQDN.
+type Since struct {
+	RunID int64
+	Time  time.Time
+}
+
+// ParseSince parses "run:ID", a date (2006-01-02, UTC) or an RFC 3339 time
+func ParseSince(s string) (Since, error) {
+	if id, ok := strings.CutPrefix(s, "run:"); ok {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || n < 1 {
+			return Since{}, fmt.Errorf("invalid run ID: %s", id)
+		}
+		return Since{RunID: n}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return Since{Time: t}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return Since{Time: t}, nil
+	}
+	return Since{}, fmt.Errorf("invalid reference point: %s (use run:ID, YYYY-MM-DD or an RFC 3339 time)", s)
+}
+
+// IsZero reports whether s matches every FQDN
+func (s Since) IsZero() bool {
+	return s.RunID == 0 && s.Time.IsZero()
+}
+
+// condition builds the SQL condition and arguments selecting the FQDNs
+// new since s and not acknowledged; seen times are stored as UTC RFC 3339,
+// which sorts as text
+func (s Since) condition() (string, []interface{}) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	if !s.Time.IsZero() {
+		return "fqdn IN (SELECT fqdn FROM fqdn_runs GROUP BY fqdn HAVING MIN(seen) >= ?) AND " + ackCondition,
+			[]interface{}{s.Time.UTC().Format(time.RFC3339), now, now}
+	}
+	return "fqdn IN (SELECT fqdn FROM fqdn_runs GROUP BY fqdn HAVING MIN(run_id) > ?) AND " + ackCondition,
+		[]interface{}{s.RunID, now, now}
+}
+
+// LatestRunID returns the ID of the newest run, 0 when none was recorded.
+// Passing it as run:ID next time exports only what is stored after now.
+func (db *DB) LatestRunID() (int64, error) {
+	var id sql.NullInt64
+	if err := db.conn.QueryRow("SELECT MAX(id) FROM runs").Scan(&id); err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+	return id.Int64, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestRuns(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	defer db.Close()
+
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	scan := &models.Run{Kind: RunKindScan, Source: "all", Started: started}
+	if err := db.InsertResultsBatch(testResults(3), InsertOptions{Run: scan}); err != nil {
+		t.Fatalf("InsertResultsBatch failed: %v", err)
+	}
+	imported := &models.Run{Kind: RunKindImported, Source: "/data/old.db"}
+	if err := db.InsertResultsBatch(testResults(5), InsertOptions{SkipExisting: true, Run: imported}); err != nil {
+		t.Fatalf("InsertResultsBatch failed: %v", err)
+	}
+	if scan.ID != 1 || imported.ID != 2 {
+		t.Errorf("run IDs = %d, %d, want 1, 2", scan.ID, imported.ID)
+	}
+
+	runs, err := db.QueryRuns()
+	if err != nil {
+		t.Fatalf("QueryRuns failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[0].Kind != RunKindScan || runs[0].Source != "all" || runs[0].Results != 3 || !runs[0].Started.Equal(started) {
+		t.Errorf("unexpected scan run: %+v", runs[0])
+	}
+	if runs[1].Kind != RunKindImported || runs[1].Results != 5 {
+		t.Errorf("unexpected import run: %+v", runs[1])
+	}
+
+	var linked int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM fqdn_runs WHERE run_id = ?", imported.ID).Scan(&linked); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if linked != 5 {
+		t.Errorf("import run linked to %d FQDNs, want 5", linked)
+	}
+
+	if err := db.InsertResultsBatch(testResults(1), InsertOptions{Run: &models.Run{Kind: "manual"}}); err == nil {
+		t.Error("expected error for invalid run kind")
+	}
+}
+
+func TestSince(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "since.db"))
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	defer db.Close()
+
+	if id, err := db.LatestRunID(); err != nil || id != 0 {
+		t.Errorf("LatestRunID of an empty database = %d, %v, want 0", id, err)
+	}
+
+	// The second run finds the first run's FQDNs again plus two new ones
+	first := &models.Run{Kind: RunKindScan, Started: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	if err := db.InsertResultsBatch(testResults(3), InsertOptions{Run: first}); err != nil {
+		t.Fatalf("InsertResultsBatch failed: %v", err)
+	}
+	second := &models.Run{Kind: RunKindScan, Started: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)}
+	if err := db.InsertResultsBatch(testResults(5), InsertOptions{SkipExisting: true, Run: second}); err != nil {
+		t.Fatalf("InsertResultsBatch failed: %v", err)
+	}
+	if id, err := db.LatestRunID(); err != nil || id != second.ID {
+		t.Errorf("LatestRunID = %d, %v, want %d", id, err, second.ID)
+	}
+
+	tests := []struct {
+		since string
+		want  int
+	}{
+		{"run:1", 2},
+		{"run:2", 0},
+		{"2024-05-01", 5},
+		{"2024-05-02", 2},
+		{"2024-06-01T10:00:01Z", 0},
+	}
+	for _, tt := range tests {
+		since, err := ParseSince(tt.since)
+		if err != nil {
+			t.Fatalf("ParseSince(%q) failed: %v", tt.since, err)
+		}
+		fqdns, err := db.QuerySincePage(QueryOptions{Since: since})
+		if err != nil {
+			t.Fatalf("QuerySincePage failed: %v", err)
+		}
+		if len(fqdns) != tt.want {
+			t.Errorf("since %s: got %d FQDNs, want %d", tt.since, len(fqdns), tt.want)
+		}
+		count, err := db.CountFQDNs(FQDNFilter{MCC: "310", Since: since})
+		if err != nil || count != tt.want {
+			t.Errorf("since %s: CountFQDNs = %d, %v, want %d", tt.since, count, err, tt.want)
+		}
+	}
+
+	since, _ := ParseSince("run:1")
+	fqdns, err := db.QueryByOperatorPage("Operator 4", QueryOptions{Since: since})
+	if err != nil || len(fqdns) != 1 {
+		t.Errorf("QueryByOperatorPage since run:1 = %v, %v, want one FQDN", fqdns, err)
+	}
+
+	for _, invalid := range []string{"run:", "run:0", "run:x", "yesterday", "2024-13-01"} {
+		if _, err := ParseSince(invalid); err == nil {
+			t.Errorf("ParseSince(%q): expected error", invalid)
+		}
+	}
+}
@@ -4,9 +4,13 @@ const (
 	// Schema SQL for creating tables (compatible with Python version)
 	schemaSQL = `
 CREATE TABLE IF NOT EXISTS operators (
-    mnc INTEGER,
-    mcc INTEGER,
-    operator TEXT
+    mnc TEXT,
+    mcc TEXT,
+    operator TEXT,
+    country_name TEXT,
+    country_code TEXT,
+    brand TEXT,
+    status TEXT
 );
 
 CREATE TABLE IF NOT EXISTS available_fqdns (
@@ -14,7 +18,120 @@ CREATE TABLE IF NOT EXISTS available_fqdns (
     fqdn TEXT
 );
 
+CREATE TABLE IF NOT EXISTS fqdn_ips (
+    fqdn TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    PRIMARY KEY (fqdn, ip)
+);
+
+CREATE TABLE IF NOT EXISTS runs (
+    id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL,
+    source TEXT,
+    started TEXT NOT NULL,
+    results INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fqdn_runs (
+    fqdn TEXT NOT NULL,
+    run_id INTEGER NOT NULL,
+    seen TEXT NOT NULL,
+    PRIMARY KEY (fqdn, run_id)
+);
+
+CREATE TABLE IF NOT EXISTS endpoint_vendors (
+    fqdn TEXT PRIMARY KEY,
+    vendor TEXT,
+    confidence REAL,
+    signals TEXT
+);
+
+CREATE TABLE IF NOT EXISTS ip_origins (
+    ip TEXT PRIMARY KEY,
+    prefix TEXT NOT NULL,
+    asn INTEGER NOT NULL,
+    as_name TEXT,
+    cloud TEXT,
+    looked_up TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS whois_networks (
+    prefix TEXT PRIMARY KEY,
+    range TEXT,
+    net_name TEXT,
+    org TEXT,
+    country TEXT,
+    abuse_emails TEXT,
+    server TEXT NOT NULL,
+    looked_up TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ip_history (
+    fqdn TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    source TEXT NOT NULL,
+    first_seen TEXT NOT NULL,
+    last_seen TEXT NOT NULL,
+    count INTEGER,
+    PRIMARY KEY (fqdn, ip, source)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created TEXT NOT NULL,
+    PRIMARY KEY (kind, target, tag)
+);
+
+CREATE TABLE IF NOT EXISTS notes (
+    id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    note TEXT NOT NULL,
+    created TEXT NOT NULL,
+    updated TEXT
+);
+
+CREATE TABLE IF NOT EXISTS acknowledgments (
+    finding TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    note TEXT,
+    created TEXT NOT NULL,
+    expires TEXT,
+    PRIMARY KEY (finding, kind, target)
+);
+
+CREATE TABLE IF NOT EXISTS results (
+    id INTEGER PRIMARY KEY,
+    target TEXT NOT NULL,
+    type TEXT NOT NULL,
+    ips TEXT,
+    success INTEGER NOT NULL,
+    status TEXT,
+    latency_us INTEGER,
+    metadata TEXT,
+    error TEXT,
+    timestamp TEXT NOT NULL
+);
+
 CREATE INDEX IF NOT EXISTS idx_operators_mnc_mcc ON operators(mnc, mcc);
 CREATE INDEX IF NOT EXISTS idx_fqdns_operator ON available_fqdns(operator);
+CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);
+CREATE INDEX IF NOT EXISTS idx_notes_target ON notes(kind, target);
+CREATE INDEX IF NOT EXISTS idx_fqdn_runs_run ON fqdn_runs(run_id);
+CREATE INDEX IF NOT EXISTS idx_results_target ON results(target, type);
 `
 )
+
+// addedColumns are columns added to existing tables since the first
+// schema; InitSchema adds them to older databases
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"operators", "country_name", "TEXT"},
+	{"operators", "country_code", "TEXT"},
+	{"operators", "brand", "TEXT"},
+	{"operators", "status", "TEXT"},
+}
@@ -1,12 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
+	"3gpp-scanner/internal/ipclass"
 	"3gpp-scanner/internal/models"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // DB wraps the SQLite database connection
@@ -15,9 +18,99 @@ type DB struct {
 	path string
 }
 
-// NewDB creates a new database connection
-func NewDB(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+// Connection defaults: WAL lets queries read while a scan writes, and the
+// busy timeout makes concurrent writers wait instead of failing with
+// "database is locked"
+const (
+	DefaultJournalMode = "wal"
+	DefaultBusyTimeout = 5 * time.Second
+)
+
+// SQLiteHeader starts every SQLite database file
+var SQLiteHeader = []byte("SQLite format 3\x00")
+
+// options holds the connection settings applied to every pooled connection
+type options struct {
+	journalMode string
+	busyTimeout time.Duration
+	synchronous string
+	readOnly    bool // Open with mode=ro, leaving the file's settings alone
+}
+
+// Option configures the connections opened by NewDB
+type Option func(*options)
+
+// WithJournalMode sets the journal mode: wal, delete, truncate, persist,
+// memory or off
+func WithJournalMode(mode string) Option {
+	return func(o *options) { o.journalMode = strings.ToLower(mode) }
+}
+
+// WithBusyTimeout sets how long a statement waits for a lock held by
+// another connection or process; zero fails immediately
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *options) { o.busyTimeout = d }
+}
+
+// WithSynchronous sets the synchronous pragma: off, normal, full or extra
+func WithSynchronous(level string) Option {
+	return func(o *options) { o.synchronous = strings.ToLower(level) }
+}
+
+var (
+	journalModes = map[string]bool{"wal": true, "delete": true, "truncate": true, "persist": true, "memory": true, "off": true}
+	syncLevels   = map[string]bool{"off": true, "normal": true, "full": true, "extra": true}
+)
+
+// ValidateJournalMode checks a WithJournalMode value
+func ValidateJournalMode(mode string) error {
+	if !journalModes[strings.ToLower(mode)] {
+		return fmt.Errorf("invalid journal mode: %s (must be wal, delete, truncate, persist, memory or off)", mode)
+	}
+	return nil
+}
+
+// dsn adds the connection settings to the database path as parameters of
+// the driver (see dsnParams), so every connection of the pool gets them
+func (o *options) dsn(dbPath string) (string, error) {
+	if err := ValidateJournalMode(o.journalMode); err != nil && !o.readOnly {
+		return "", err
+	}
+	if o.synchronous != "" && !syncLevels[o.synchronous] {
+		return "", fmt.Errorf("invalid synchronous level: %s (must be off, normal, full or extra)", o.synchronous)
+	}
+	if o.busyTimeout < 0 {
+		return "", fmt.Errorf("busy timeout cannot be negative")
+	}
+
+	// Writers take the lock when the transaction starts (_txlock), where
+	// the busy timeout applies, instead of failing on a later lock upgrade
+	params := o.dsnParams()
+	if o.readOnly {
+		// Only URI file names take the mode, in both drivers
+		params.Set("mode", "ro")
+		return "file:" + (&url.URL{Path: dbPath}).EscapedPath() + "?" + params.Encode(), nil
+	}
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+	return dbPath + separator + params.Encode(), nil
+}
+
+// NewDB creates a new database connection. Without options it uses WAL
+// mode and a busy timeout of DefaultBusyTimeout.
+func NewDB(dbPath string, opts ...Option) (*DB, error) {
+	o := &options{journalMode: DefaultJournalMode, busyTimeout: DefaultBusyTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dsn, err := o.dsn(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(DriverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -36,60 +129,222 @@ func NewDB(dbPath string) (*DB, error) {
 	return db, nil
 }
 
+// OpenReadOnly opens an existing database for reading only. Unlike NewDB
+// it neither creates the file nor runs InitSchema and its migrations, so
+// the file stays as it is, e.g. while copying it. Journal mode options are
+// ignored.
+func OpenReadOnly(dbPath string, opts ...Option) (*DB, error) {
+	o := &options{busyTimeout: DefaultBusyTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.readOnly = true
+	dsn, err := o.dsn(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{conn: conn, path: dbPath}, nil
+}
+
+// SQLiteVersion reports the version of the SQLite library of the driver
+func SQLiteVersion() (string, error) {
+	conn, err := sql.Open(DriverName, ":memory:")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var version string
+	if err := conn.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// InitSchema creates the database tables if they don't exist
+// InitSchema creates the database tables if they don't exist and adds
+// columns missing from databases of earlier versions
 func (db *DB) InitSchema() error {
 	_, err := db.conn.Exec(schemaSQL)
 	if err != nil {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
-	return nil
+
+	existing := make(map[string]bool)
+	read := make(map[string]bool)
+	for _, c := range addedColumns {
+		if !read[c.table] {
+			if err := existingKeys(db.conn, "SELECT '"+c.table+".' || name FROM pragma_table_info('"+c.table+"')", existing); err != nil {
+				return fmt.Errorf("failed to read columns of %s: %w", c.table, err)
+			}
+			read[c.table] = true
+		}
+		if existing[c.table+"."+c.column] {
+			continue
+		}
+		if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return db.migrateOperatorCodes()
 }
 
-// InsertResults inserts DNS scan results into the database
-func (db *DB) InsertResults(results []models.DNSResult) error {
+// migrateOperatorCodes converts the INTEGER mnc and mcc columns of
+// databases created by earlier versions to TEXT, so "01" and "001" stay
+// distinct. Stored MCCs get three digits and MNCs at least two, since the
+// width of an MNC stored as a number is lost.
+func (db *DB) migrateOperatorCodes() error {
+	var mncType string
+	if err := db.conn.QueryRow("SELECT type FROM pragma_table_info('operators') WHERE name = 'mnc'").Scan(&mncType); err != nil {
+		return fmt.Errorf("failed to read columns of operators: %w", err)
+	}
+	if !strings.EqualFold(mncType, "INTEGER") {
+		return nil
+	}
+
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
+	for _, stmt := range []string{
+		`CREATE TABLE operators_text (
+			mnc TEXT, mcc TEXT, operator TEXT, country_name TEXT, country_code TEXT, brand TEXT, status TEXT
+		)`,
+		`INSERT INTO operators_text
+			SELECT CASE WHEN typeof(mnc) = 'integer' THEN printf('%02d', mnc) ELSE mnc END,
+				CASE WHEN typeof(mcc) = 'integer' THEN printf('%03d', mcc) ELSE mcc END,
+				operator, country_name, country_code, brand, status
+			FROM operators`,
+		`DROP TABLE operators`,
+		`ALTER TABLE operators_text RENAME TO operators`,
+		`CREATE INDEX IF NOT EXISTS idx_operators_mnc_mcc ON operators(mnc, mcc)`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to migrate mnc and mcc to text: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DefaultBatchSize is the number of rows per multi-row INSERT, well
+// below SQLite's limit on bound parameters
+const DefaultBatchSize = 500
+
+// InsertOptions tunes InsertResultsBatch. The zero value inserts
+// DefaultBatchSize rows per statement with the connection's durability.
+type InsertOptions struct {
+	BatchSize    int  // Rows per INSERT statement; 0 selects DefaultBatchSize
+	NoSync       bool // synchronous=OFF during the load: faster, but a power loss can corrupt the database
+	SkipExisting bool // Leave out operators and FQDNs already stored, for imports that may overlap
+
+	// Run, if set, is recorded with the results as their provenance; its
+	// ID is set once the results are stored
+	Run *models.Run
+}
 
-	// Prepare statements
-	operatorStmt, err := tx.Prepare("INSERT INTO operators (mnc, mcc, operator) VALUES (?, ?, ?)")
+// InsertResults inserts DNS scan results into the database
+func (db *DB) InsertResults(results []models.DNSResult) error {
+	return db.InsertResultsBatch(results, InsertOptions{})
+}
+
+// InsertResultsBatch inserts DNS scan results in one transaction using
+// multi-row INSERT statements
+func (db *DB) InsertResultsBatch(results []models.DNSResult, opts InsertOptions) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	// Pragmas are per connection, so pin one for the whole load
+	ctx := context.Background()
+	conn, err := db.conn.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to prepare operator statement: %w", err)
+		return fmt.Errorf("failed to get connection: %w", err)
 	}
-	defer operatorStmt.Close()
+	defer conn.Close()
 
-	fqdnStmt, err := tx.Prepare("INSERT INTO available_fqdns (operator, fqdn) VALUES (?, ?)")
+	if opts.NoSync {
+		var previous int
+		if err := conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&previous); err != nil {
+			return fmt.Errorf("failed to read synchronous pragma: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, "PRAGMA synchronous = OFF"); err != nil {
+			return fmt.Errorf("failed to disable synchronous writes: %w", err)
+		}
+		defer conn.ExecContext(ctx, fmt.Sprintf("PRAGMA synchronous = %d", previous))
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to prepare fqdn statement: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer fqdnStmt.Close()
+	defer tx.Rollback()
 
 	// Track inserted operators to avoid duplicates
 	operatorSeen := make(map[string]bool)
+	fqdnSeen := make(map[string]bool)
+	if opts.SkipExisting {
+		if err := existingKeys(tx, "SELECT mnc || ':' || mcc || ':' || COALESCE(operator, '') FROM operators", operatorSeen); err != nil {
+			return fmt.Errorf("failed to read operators: %w", err)
+		}
+		if err := existingKeys(tx, "SELECT COALESCE(operator, '') || ':' || fqdn FROM available_fqdns", fqdnSeen); err != nil {
+			return fmt.Errorf("failed to read fqdns: %w", err)
+		}
+	}
 
+	var operatorRows, detailRows, fqdnRows, ipRows [][]interface{}
+	detailSeen := make(map[string]bool)
 	for _, result := range results {
-		operatorKey := fmt.Sprintf("%d:%d:%s", result.MNC, result.MCC, result.Operator)
-
-		// Insert operator if not seen before
+		operatorKey := fmt.Sprintf("%s:%s:%s", result.MNC, result.MCC, result.Operator)
+		details := []interface{}{nullable(result.Country), nullable(result.CountryCode), nullable(result.Brand), nullable(result.OperatorStatus)}
 		if !operatorSeen[operatorKey] {
-			_, err = operatorStmt.Exec(result.MNC, result.MCC, result.Operator)
-			if err != nil {
-				return fmt.Errorf("failed to insert operator: %w", err)
-			}
+			operatorRows = append(operatorRows, append([]interface{}{result.MNC, result.MCC, result.Operator}, details...))
 			operatorSeen[operatorKey] = true
 		}
+		hasDetails := result.Country != "" || result.CountryCode != "" || result.Brand != "" || result.OperatorStatus != ""
+		if hasDetails && !detailSeen[operatorKey] {
+			detailRows = append(detailRows, append(details, result.MNC, result.MCC, result.Operator))
+			detailSeen[operatorKey] = true
+		}
+		fqdnKey := result.Operator + ":" + result.FQDN
+		if !opts.SkipExisting || !fqdnSeen[fqdnKey] {
+			fqdnRows = append(fqdnRows, []interface{}{result.Operator, result.FQDN})
+			fqdnSeen[fqdnKey] = true
+		}
+		for _, ip := range result.IPs {
+			ipRows = append(ipRows, []interface{}{result.FQDN, ip})
+		}
+	}
 
-		// Insert FQDN
-		_, err = fqdnStmt.Exec(result.Operator, result.FQDN)
-		if err != nil {
-			return fmt.Errorf("failed to insert fqdn: %w", err)
+	if err := insertRows(tx, "INSERT INTO operators (mnc, mcc, operator, country_name, country_code, brand, status) VALUES ", operatorRows, batchSize); err != nil {
+		return fmt.Errorf("failed to insert operators: %w", err)
+	}
+	if err := fillOperatorDetails(tx, detailRows); err != nil {
+		return fmt.Errorf("failed to update operators: %w", err)
+	}
+	if err := insertRows(tx, "INSERT INTO available_fqdns (operator, fqdn) VALUES ", fqdnRows, batchSize); err != nil {
+		return fmt.Errorf("failed to insert fqdns: %w", err)
+	}
+	if err := insertRows(tx, "INSERT OR IGNORE INTO fqdn_ips (fqdn, ip) VALUES ", ipRows, batchSize); err != nil {
+		return fmt.Errorf("failed to insert ips: %w", err)
+	}
+	if opts.Run != nil {
+		if err := insertRun(tx, opts.Run, results, batchSize); err != nil {
+			return err
 		}
 	}
 
@@ -100,8 +355,171 @@ func (db *DB) InsertResults(results []models.DNSResult) error {
 	return nil
 }
 
+// querier is a connection pool or transaction
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// fillOperatorDetails sets the country, brand and status of operators
+// stored without them, e.g. by earlier versions. Each row holds the four
+// details followed by MNC, MCC and operator name.
+func fillOperatorDetails(tx *sql.Tx, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare(`UPDATE operators SET
+		country_name = COALESCE(country_name, ?),
+		country_code = COALESCE(country_code, ?),
+		brand = COALESCE(brand, ?),
+		status = COALESCE(status, ?)
+		WHERE mnc = ? AND mcc = ? AND operator IS ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullable stores empty strings as NULL
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// existingKeys adds the keys a single-column query returns to seen
+func existingKeys(q querier, query string, seen map[string]bool) error {
+	rows, err := q.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key sql.NullString
+		if err := rows.Scan(&key); err != nil {
+			return err
+		}
+		seen[key.String] = true
+	}
+	return rows.Err()
+}
+
+// insertRows runs prefix with up to batchSize value tuples per statement.
+// All rows must have the same number of values.
+func insertRows(tx *sql.Tx, prefix string, rows [][]interface{}, batchSize int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	width := len(rows[0])
+
+	var stmt *sql.Stmt
+	stmtRows := 0
+	defer func() {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}()
+
+	args := make([]interface{}, 0, min(batchSize, len(rows))*width)
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+
+		// Full batches share one statement; only the tail needs another
+		if end-start != stmtRows {
+			if stmt != nil {
+				stmt.Close()
+			}
+			var err error
+			stmt, err = tx.Prepare(prefix + valueTuples(width, end-start))
+			if err != nil {
+				stmt = nil
+				return fmt.Errorf("failed to prepare statement: %w", err)
+			}
+			stmtRows = end - start
+		}
+
+		args = args[:0]
+		for _, row := range rows[start:end] {
+			args = append(args, row...)
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueTuples renders n placeholder tuples of width values, e.g.
+// "(?, ?), (?, ?)"
+func valueTuples(width, n int) string {
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", width), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(tuple+", ", n), ", ")
+}
+
+// QueryOptions pages, orders and narrows FQDN queries. The zero value
+// returns every row in insertion order.
+type QueryOptions struct {
+	Limit     int    // Maximum rows; 0 means no limit
+	Offset    int    // Rows to skip
+	OrderBy   string // A key of orderColumns, "-" prefix for descending
+	Subdomain string // Only FQDNs starting with this label sequence, e.g. "epdg"
+	Tag       string // Only FQDNs carrying this tag, directly or through their operator
+	Since     Since  // Only FQDNs new since this reference point
+
+	PrivateIPs bool // Only FQDNs resolving to a private address, see privateIPCondition
+}
+
+// orderColumns maps --order-by keys to ORDER BY clauses
+var orderColumns = map[string]string{
+	"fqdn":     "fqdn",
+	"operator": "operator, fqdn",
+	"inserted": "rowid",
+}
+
+// OrderByKeys lists the accepted QueryOptions.OrderBy keys
+func OrderByKeys() []string {
+	return []string{"fqdn", "operator", "inserted"}
+}
+
+// ValidateOrderBy checks a QueryOptions.OrderBy value
+func ValidateOrderBy(orderBy string) error {
+	_, err := orderClause(orderBy)
+	return err
+}
+
+// orderClause builds the ORDER BY clause for an order key
+func orderClause(orderBy string) (string, error) {
+	if orderBy == "" {
+		return " ORDER BY rowid", nil
+	}
+	key, direction := orderBy, "ASC"
+	if strings.HasPrefix(key, "-") {
+		key, direction = key[1:], "DESC"
+	}
+	columns, ok := orderColumns[key]
+	if !ok {
+		return "", fmt.Errorf("invalid order: %s (must be one of %s, optionally prefixed with -)", orderBy, strings.Join(OrderByKeys(), ", "))
+	}
+	var parts []string
+	for _, column := range strings.Split(columns, ", ") {
+		parts = append(parts, column+" "+direction)
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
 // QueryByMNCMCC queries FQDNs for a specific MNC and MCC
-func (db *DB) QueryByMNCMCC(mnc, mcc int) ([]string, error) {
+func (db *DB) QueryByMNCMCC(mnc, mcc string) ([]string, error) {
+	return db.QueryByMNCMCCPage(mnc, mcc, QueryOptions{})
+}
+
+// QueryByMNCMCCPage queries one page of FQDNs for a specific MNC and MCC
+func (db *DB) QueryByMNCMCCPage(mnc, mcc string, opts QueryOptions) ([]string, error) {
 	query := `
 		SELECT fqdn
 		FROM available_fqdns
@@ -111,8 +529,53 @@ func (db *DB) QueryByMNCMCC(mnc, mcc int) ([]string, error) {
 			WHERE mnc = ? AND mcc = ?
 		)
 	`
+	return db.queryFQDNs(query, opts, mnc, mcc)
+}
+
+// QueryByOperator queries FQDNs for a specific operator name
+func (db *DB) QueryByOperator(operator string) ([]string, error) {
+	return db.QueryByOperatorPage(operator, QueryOptions{})
+}
+
+// QueryByOperatorPage queries one page of FQDNs for a specific operator name
+func (db *DB) QueryByOperatorPage(operator string, opts QueryOptions) ([]string, error) {
+	return db.queryFQDNs("SELECT fqdn FROM available_fqdns WHERE operator = ?", opts, operator)
+}
 
-	rows, err := db.conn.Query(query, mnc, mcc)
+// queryFQDNs runs a single-column FQDN query with ordering and paging
+func (db *DB) queryFQDNs(query string, opts QueryOptions, args ...interface{}) ([]string, error) {
+	order, err := orderClause(opts.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Subdomain != "" {
+		query += " AND " + subdomainCondition
+		args = append(args, subdomainPattern(opts.Subdomain))
+	}
+	if opts.Tag != "" {
+		query += " AND " + tagCondition
+		args = append(args, opts.Tag, opts.Tag)
+	}
+	if opts.PrivateIPs {
+		query += " AND " + privateIPCondition
+	}
+	if !opts.Since.IsZero() {
+		condition, sinceArgs := opts.Since.condition()
+		query += " AND " + condition
+		args = append(args, sinceArgs...)
+	}
+	query += order
+	if opts.Limit > 0 || opts.Offset > 0 {
+		// SQLite needs a LIMIT for OFFSET; -1 means no limit
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, opts.Offset)
+	}
+
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -134,11 +597,195 @@ func (db *DB) QueryByMNCMCC(mnc, mcc int) ([]string, error) {
 	return fqdns, nil
 }
 
-// QueryByOperator queries FQDNs for a specific operator name
-func (db *DB) QueryByOperator(operator string) ([]string, error) {
-	query := "SELECT fqdn FROM available_fqdns WHERE operator = ?"
+// QueryPrivateIPPage queries one page of FQDNs resolving to a private
+// address
+func (db *DB) QueryPrivateIPPage(opts QueryOptions) ([]string, error) {
+	opts.PrivateIPs = false
+	return db.queryFQDNs("SELECT fqdn FROM available_fqdns WHERE "+privateIPCondition, opts)
+}
+
+// QuerySincePage queries one page of FQDNs new since opts.Since
+func (db *DB) QuerySincePage(opts QueryOptions) ([]string, error) {
+	condition, args := opts.Since.condition()
+	opts.Since = Since{}
+	return db.queryFQDNs("SELECT fqdn FROM available_fqdns WHERE "+condition, opts, args...)
+}
+
+// privateIPCondition matches FQDNs with a stored address in RFC 1918,
+// RFC 6598 shared or IPv6 unique local space, as ipclass.Private does
+const privateIPCondition = `fqdn IN (SELECT fqdn FROM fqdn_ips WHERE ip LIKE '10.%' OR ip LIKE '192.168.%'
+	OR (ip LIKE '172.%' AND CAST(substr(ip, 5) AS INTEGER) BETWEEN 16 AND 31)
+	OR (ip LIKE '100.%' AND CAST(substr(ip, 5) AS INTEGER) BETWEEN 64 AND 127)
+	OR ip LIKE 'fc__:%' OR ip LIKE 'fd__:%')`
+
+// subdomainCondition matches FQDNs below a subdomain, see subdomainPattern
+const subdomainCondition = `fqdn LIKE ? ESCAPE '\'`
+
+// subdomainPattern turns a subdomain into a LIKE pattern, so "epdg"
+// matches "epdg.epc.mnc001..." but not "epdgx.mnc001..."
+func subdomainPattern(subdomain string) string {
+	return likeEscaper.Replace(subdomain) + ".%"
+}
+
+// likeEscaper escapes LIKE wildcards for use with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FQDNFilter selects FQDNs for aggregate queries. Empty fields match all;
+// MNC requires MCC. MNCs match digit for digit, so 01 is not 001.
+type FQDNFilter struct {
+	MCC       string
+	MNC       string
+	Operator  string
+	Subdomain string
+	Tag       string
+	Search    string // Substring of the FQDN or operator name
+	Since     Since  // Only FQDNs new since this reference point
+
+	PrivateIPs bool // Only FQDNs resolving to a private address
+}
 
-	rows, err := db.conn.Query(query, operator)
+// where builds the WHERE clause and arguments for a filter
+func (f FQDNFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.MCC != "" {
+		if f.MNC != "" {
+			conditions = append(conditions, "operator IN (SELECT operator FROM operators WHERE mnc = ? AND mcc = ?)")
+			args = append(args, f.MNC, f.MCC)
+		} else {
+			conditions = append(conditions, "operator IN (SELECT operator FROM operators WHERE mcc = ?)")
+			args = append(args, f.MCC)
+		}
+	}
+	if f.Operator != "" {
+		conditions = append(conditions, "operator = ?")
+		args = append(args, f.Operator)
+	}
+	if f.Subdomain != "" {
+		conditions = append(conditions, subdomainCondition)
+		args = append(args, subdomainPattern(f.Subdomain))
+	}
+	if f.Tag != "" {
+		conditions = append(conditions, tagCondition)
+		args = append(args, f.Tag, f.Tag)
+	}
+	if f.PrivateIPs {
+		conditions = append(conditions, privateIPCondition)
+	}
+	if !f.Since.IsZero() {
+		condition, sinceArgs := f.Since.condition()
+		conditions = append(conditions, condition)
+		args = append(args, sinceArgs...)
+	}
+	if f.Search != "" {
+		conditions = append(conditions, `(fqdn LIKE ? ESCAPE '\' OR operator LIKE ? ESCAPE '\')`)
+		pattern := "%" + likeEscaper.Replace(f.Search) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// CountFQDNs counts the distinct FQDNs matching a filter without reading them
+func (db *DB) CountFQDNs(f FQDNFilter) (int, error) {
+	where, args := f.where()
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(DISTINCT fqdn) FROM available_fqdns"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	return count, nil
+}
+
+// CountRows counts the FQDN rows matching a filter, duplicates included,
+// to page through QueryRows
+func (db *DB) CountRows(f FQDNFilter) (int, error) {
+	where, args := f.where()
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM available_fqdns"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	return count, nil
+}
+
+// CountByOperator counts the distinct FQDNs matching a filter per operator
+func (db *DB) CountByOperator(f FQDNFilter) (map[string]int, error) {
+	where, args := f.where()
+	rows, err := db.conn.Query("SELECT operator, COUNT(DISTINCT fqdn) FROM available_fqdns"+where+" GROUP BY operator", args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var operator string
+		var count int
+		if err := rows.Scan(&operator, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		counts[operator] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return counts, nil
+}
+
+// FQDNRow is one stored FQDN with its operator
+type FQDNRow struct {
+	FQDN     string `json:"fqdn"`
+	Operator string `json:"operator"`
+}
+
+// QueryRows queries one page of FQDN rows matching a filter. Only the
+// ordering and paging fields of opts are used.
+func (db *DB) QueryRows(f FQDNFilter, opts QueryOptions) ([]FQDNRow, error) {
+	order, err := orderClause(opts.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+	where, args := f.where()
+	query := "SELECT fqdn, operator FROM available_fqdns" + where + order
+	if opts.Limit > 0 || opts.Offset > 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, opts.Offset)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var result []FQDNRow
+	for rows.Next() {
+		var row FQDNRow
+		var operator sql.NullString
+		if err := rows.Scan(&row.FQDN, &operator); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		row.Operator = operator.String
+		result = append(result, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetAllFQDNs retrieves every distinct FQDN stored in the database
+func (db *DB) GetAllFQDNs() ([]string, error) {
+	rows, err := db.conn.Query("SELECT DISTINCT fqdn FROM available_fqdns ORDER BY fqdn")
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -160,11 +807,33 @@ func (db *DB) QueryByOperator(operator string) ([]string, error) {
 	return fqdns, nil
 }
 
-// GetAllOperators retrieves all unique operators from the database
+// GetAllOperators retrieves all unique operators from the database, with
+// the country, brand and status stored for them
 func (db *DB) GetAllOperators() ([]models.MCCMNCEntry, error) {
-	query := "SELECT DISTINCT mnc, mcc, operator FROM operators ORDER BY mcc, mnc"
+	return db.queryOperators("")
+}
+
+// QueryOperatorsByMNCMCC returns the operators stored for an MNC and MCC
+func (db *DB) QueryOperatorsByMNCMCC(mnc, mcc string) ([]models.MCCMNCEntry, error) {
+	return db.queryOperators(" WHERE mnc = ? AND mcc = ?", mnc, mcc)
+}
+
+// QueryOperatorsByName returns the operators stored under a name, one per
+// MNC and MCC
+func (db *DB) QueryOperatorsByName(operator string) ([]models.MCCMNCEntry, error) {
+	return db.queryOperators(" WHERE operator = ?", operator)
+}
+
+// queryOperators reads distinct operators matching a WHERE clause. The
+// operators table may hold several rows of an operator, some stored
+// without details by earlier versions, so details are merged.
+func (db *DB) queryOperators(where string, args ...interface{}) ([]models.MCCMNCEntry, error) {
+	query := `SELECT mnc, mcc, operator, MAX(country_name), MAX(country_code), MAX(brand), MAX(status)
+		FROM operators` + where + `
+		GROUP BY mnc, mcc, operator
+		ORDER BY mcc, mnc, operator`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -172,15 +841,18 @@ func (db *DB) GetAllOperators() ([]models.MCCMNCEntry, error) {
 
 	var operators []models.MCCMNCEntry
 	for rows.Next() {
-		var mnc, mcc int
-		var operator string
-		if err := rows.Scan(&mnc, &mcc, &operator); err != nil {
+		var mnc, mcc, operator, countryName, countryCode, brand, status sql.NullString
+		if err := rows.Scan(&mnc, &mcc, &operator, &countryName, &countryCode, &brand, &status); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		operators = append(operators, models.MCCMNCEntry{
-			MNC:      fmt.Sprintf("%d", mnc),
-			MCC:      fmt.Sprintf("%d", mcc),
-			Operator: operator,
+			MNC:         mnc.String,
+			MCC:         mcc.String,
+			Operator:    operator.String,
+			CountryName: countryName.String,
+			CountryCode: countryCode.String,
+			Brand:       brand.String,
+			Status:      status.String,
 		})
 	}
 
@@ -223,12 +895,252 @@ func (db *DB) GetStats() (*models.Stats, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var mcc, count int
+		var mcc sql.NullString
+		var count int
 		if err := rows.Scan(&mcc, &count); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
-		stats.MCCDistribution[fmt.Sprintf("%d", mcc)] = count
+		stats.MCCDistribution[mcc.String] += count
+	}
+
+	// Count distinct IPs
+	if err := db.conn.QueryRow("SELECT COUNT(DISTINCT ip) FROM fqdn_ips").Scan(&stats.TotalIPs); err != nil {
+		return nil, fmt.Errorf("failed to count IPs: %w", err)
+	}
+
+	// Service labels of the FQDNs: everything in front of the operator zone
+	// (mncXXX.mccYYY), as in the subdomain of scan results, per network
+	subdomains, err := db.conn.Query(`
+		SELECT substr(fqdn, 1, pos - 1) AS subdomain,
+			substr(fqdn, pos + 4, 3) AS mnc,
+			substr(fqdn, pos + 11, 3) AS mcc,
+			COUNT(*)
+		FROM (
+			SELECT DISTINCT lower(fqdn) AS fqdn, instr(lower(fqdn), '.mnc') AS pos FROM available_fqdns
+		)
+		WHERE pos > 1 AND substr(fqdn, pos + 7, 4) = '.mcc'
+		GROUP BY subdomain, mnc, mcc`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subdomain distribution: %w", err)
+	}
+	defer subdomains.Close()
+
+	for subdomains.Next() {
+		var subdomain, mnc, mcc string
+		var count int
+		if err := subdomains.Scan(&subdomain, &mnc, &mcc, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if stats.SubdomainMCCCounts == nil {
+			stats.SubdomainMCCCounts = make(map[string]map[string]int)
+			stats.SubdomainNetworkCounts = make(map[string]map[string]int)
+		}
+		if stats.SubdomainMCCCounts[subdomain] == nil {
+			stats.SubdomainMCCCounts[subdomain] = make(map[string]int)
+			stats.SubdomainNetworkCounts[subdomain] = make(map[string]int)
+		}
+		stats.SubdomainCounts[subdomain] += count
+		stats.SubdomainMCCCounts[subdomain][mcc] += count
+		stats.SubdomainNetworkCounts[subdomain][mcc+"-"+mnc] = count
+	}
+
+	// Versions and classes of the stored IPs
+	fqdnIPs, err := db.FQDNIPs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IPs: %w", err)
+	}
+	if versions, classes, private := ipclass.Tally(fqdnIPs); len(versions) > 0 {
+		stats.IPVersionCounts, stats.IPClassCounts, stats.PrivateIPFQDNs = versions, classes, private
+	}
+
+	// FQDNs per operator, and per operator and subdomain for FQDNs in an
+	// operator zone
+	operators, err := db.conn.Query(`
+		SELECT operator,
+			CASE WHEN pos > 1 AND substr(fqdn, pos + 7, 4) = '.mcc' THEN substr(fqdn, 1, pos - 1) END AS subdomain,
+			COUNT(*)
+		FROM (
+			SELECT DISTINCT COALESCE(operator, '') AS operator, lower(fqdn) AS fqdn, instr(lower(fqdn), '.mnc') AS pos FROM available_fqdns
+		)
+		GROUP BY operator, subdomain`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operator distribution: %w", err)
+	}
+	defer operators.Close()
+
+	for operators.Next() {
+		var operator string
+		var subdomain sql.NullString
+		var count int
+		if err := operators.Scan(&operator, &subdomain, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if stats.OperatorCounts == nil {
+			stats.OperatorCounts = make(map[string]int)
+			stats.OperatorSubdomainCounts = make(map[string]map[string]int)
+		}
+		stats.OperatorCounts[operator] += count
+		if subdomain.Valid {
+			if stats.OperatorSubdomainCounts[operator] == nil {
+				stats.OperatorSubdomainCounts[operator] = make(map[string]int)
+			}
+			stats.OperatorSubdomainCounts[operator][subdomain.String] = count
+		}
+	}
+
+	// FQDNs per country, by the MNC and MCC in the FQDN (mncXXX.mccYYY),
+	// as operator names repeat across countries. Stored MNCs are padded to
+	// the three digits of the zone name.
+	countries, err := db.conn.Query(`
+		SELECT o.country_code, COUNT(*)
+		FROM (
+			SELECT DISTINCT fqdn, instr(fqdn, '.mcc') AS pos FROM available_fqdns WHERE instr(fqdn, '.mcc') > 3
+		) f
+		JOIN (
+			SELECT substr('000' || mnc, -3) AS mnc, mcc, MAX(UPPER(country_code)) AS country_code
+			FROM operators WHERE country_code <> '' GROUP BY 1, mcc
+		) o ON o.mnc = substr(f.fqdn, f.pos - 3, 3) AND o.mcc = substr(f.fqdn, f.pos + 4, 3)
+		GROUP BY o.country_code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query country distribution: %w", err)
+	}
+	defer countries.Close()
+
+	for countries.Next() {
+		var code string
+		var count int
+		if err := countries.Scan(&code, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		stats.CountryCounts[code] = count
+	}
+
+	// Vendor distribution of fingerprinted endpoints
+	vendors, err := db.conn.Query("SELECT vendor, COUNT(*) FROM endpoint_vendors GROUP BY vendor")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vendor distribution: %w", err)
+	}
+	defer vendors.Close()
+
+	for vendors.Next() {
+		var vendor string
+		var count int
+		if err := vendors.Scan(&vendor, &count); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if stats.VendorCounts == nil {
+			stats.VendorCounts = make(map[string]int)
+		}
+		stats.VendorCounts[vendor] = count
 	}
 
 	return stats, nil
 }
+
+// QueryOperatorNames returns the distinct operator names stored for an MNC and MCC
+func (db *DB) QueryOperatorNames(mnc, mcc string) ([]string, error) {
+	rows, err := db.conn.Query("SELECT DISTINCT operator FROM operators WHERE mnc = ? AND mcc = ? ORDER BY operator", mnc, mcc)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var operators []string
+	for rows.Next() {
+		var operator string
+		if err := rows.Scan(&operator); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		operators = append(operators, operator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return operators, nil
+}
+
+// SaveVendorMatches stores endpoint vendor labels, replacing earlier
+// labels of the same FQDNs
+func (db *DB) SaveVendorMatches(matches []models.VendorMatch) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO endpoint_vendors (fqdn, vendor, confidence, signals) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare vendor statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range matches {
+		if _, err := stmt.Exec(m.FQDN, m.Vendor, m.Confidence, strings.Join(m.Signals, "; ")); err != nil {
+			return fmt.Errorf("failed to insert vendor: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryVendors returns the stored vendor label of every fingerprinted FQDN
+func (db *DB) QueryVendors() (map[string]string, error) {
+	rows, err := db.conn.Query("SELECT fqdn, vendor FROM endpoint_vendors")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	vendors := make(map[string]string)
+	for rows.Next() {
+		var fqdn, vendor string
+		if err := rows.Scan(&fqdn, &vendor); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		vendors[fqdn] = vendor
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return vendors, nil
+}
+
+// FQDNIPs returns the stored IP addresses of each FQDN, sorted
+func (db *DB) FQDNIPs() (map[string][]string, error) {
+	rows, err := db.conn.Query("SELECT fqdn, ip FROM fqdn_ips ORDER BY fqdn, ip")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	ips := make(map[string][]string)
+	for rows.Next() {
+		var fqdn, ip string
+		if err := rows.Scan(&fqdn, &ip); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ips[fqdn] = append(ips[fqdn], ip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return ips, nil
+}
+
+// BackupTo writes a consistent copy of the database to path, which must
+// not exist yet. VACUUM INTO also compacts the copy.
+func (db *DB) BackupTo(path string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,504 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/ipclass"
+	"3gpp-scanner/internal/models"
+)
+
+func TestNewDBJournalMode(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: "wal"},
+		{name: "rollback journal", opts: []Option{WithJournalMode("DELETE")}, want: "delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewDB: %v", err)
+			}
+			defer db.Close()
+
+			var mode string
+			if err := db.conn.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+				t.Fatalf("journal_mode: %v", err)
+			}
+			if mode != tt.want {
+				t.Errorf("journal_mode = %q, want %q", mode, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDBInvalidOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if _, err := NewDB(path, WithJournalMode("fast")); err == nil || !strings.Contains(err.Error(), "invalid journal mode") {
+		t.Errorf("journal mode: got %v", err)
+	}
+	if _, err := NewDB(path, WithSynchronous("sometimes")); err == nil || !strings.Contains(err.Error(), "invalid synchronous level") {
+		t.Errorf("synchronous: got %v", err)
+	}
+	if _, err := NewDB(path, WithBusyTimeout(-time.Second)); err == nil {
+		t.Error("negative busy timeout accepted")
+	}
+}
+
+// Two processes' worth of connections writing and reading the same file
+// must wait for each other instead of failing with "database is locked"
+func TestConcurrentWritersAndReaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	const writers, batches = 3, 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*batches+batches)
+	for w := 0; w < writers; w++ {
+		db, err := NewDB(path)
+		if err != nil {
+			t.Fatalf("NewDB: %v", err)
+		}
+		defer db.Close()
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for b := 0; b < batches; b++ {
+				results := make([]models.DNSResult, 50)
+				for i := range results {
+					results[i] = models.DNSResult{
+						FQDN:     fmt.Sprintf("epdg.w%d.b%d.n%d.pub.3gppnetwork.org", w, b, i),
+						MNC:      strconv.Itoa(w),
+						MCC:      strconv.Itoa(b),
+						Operator: fmt.Sprintf("Operator %d", w),
+					}
+				}
+				if err := db.InsertResults(results); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	reader, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer reader.Close()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < batches; i++ {
+			if _, err := reader.GetStats(); err != nil {
+				errs <- err
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent access failed: %v", err)
+	}
+
+	count, err := reader.CountFQDNs(FQDNFilter{})
+	if err != nil {
+		t.Fatalf("CountFQDNs: %v", err)
+	}
+	if want := writers * batches * 50; count != want {
+		t.Errorf("CountFQDNs = %d, want %d", count, want)
+	}
+}
+
+// testResults builds n results spread over ten operators
+func testResults(n int) []models.DNSResult {
+	results := make([]models.DNSResult, n)
+	for i := range results {
+		results[i] = models.DNSResult{
+			FQDN:     fmt.Sprintf("epdg.epc.mnc%03d.mcc%03d.pub.3gppnetwork.org", i%1000, 200+i/1000),
+			MNC:      strconv.Itoa(i % 10),
+			MCC:      "310",
+			Operator: fmt.Sprintf("Operator %d", i%10),
+		}
+	}
+	return results
+}
+
+func TestInsertResultsBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opts InsertOptions
+	}{
+		{name: "default batch"},
+		{name: "partial last batch", opts: InsertOptions{BatchSize: 100}},
+		{name: "one row per statement", opts: InsertOptions{BatchSize: 1}},
+		{name: "no sync", opts: InsertOptions{NoSync: true}},
+	}
+
+	results := testResults(1234)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatalf("NewDB: %v", err)
+			}
+			defer db.Close()
+			db.conn.SetMaxOpenConns(1)
+
+			var before int
+			if err := db.conn.QueryRow("PRAGMA synchronous").Scan(&before); err != nil {
+				t.Fatalf("synchronous: %v", err)
+			}
+			if err := db.InsertResultsBatch(results, tt.opts); err != nil {
+				t.Fatalf("InsertResultsBatch: %v", err)
+			}
+
+			fqdns, err := db.QueryByOperator("Operator 3")
+			if err != nil {
+				t.Fatalf("QueryByOperator: %v", err)
+			}
+			if len(fqdns) != 124 || fqdns[0] != results[3].FQDN || fqdns[123] != results[1233].FQDN {
+				t.Errorf("Operator 3: got %d FQDNs starting %v", len(fqdns), fqdns[:1])
+			}
+
+			var operators int
+			if err := db.conn.QueryRow("SELECT COUNT(*) FROM operators").Scan(&operators); err != nil {
+				t.Fatalf("count operators: %v", err)
+			}
+			if operators != 10 {
+				t.Errorf("operators = %d, want 10", operators)
+			}
+
+			var synchronous int
+			if err := db.conn.QueryRow("PRAGMA synchronous").Scan(&synchronous); err != nil {
+				t.Fatalf("synchronous: %v", err)
+			}
+			if synchronous != before {
+				t.Errorf("synchronous = %d after load, want %d", synchronous, before)
+			}
+		})
+	}
+}
+
+func TestInsertIPsAndSkipExisting(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	first := []models.DNSResult{
+		{FQDN: "epdg.a", IPs: []string{"192.0.2.2", "192.0.2.1"}, MNC: "01", MCC: "262", Operator: "Op A"},
+		{FQDN: "ims.a", MNC: "01", MCC: "262", Operator: "Op A"},
+	}
+	if err := db.InsertResults(first); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+
+	// An overlapping import adds only the new FQDN, operator and IPs
+	second := []models.DNSResult{
+		{FQDN: "epdg.a", IPs: []string{"192.0.2.1", "192.0.2.3"}, MNC: "01", MCC: "262", Operator: "Op A"},
+		{FQDN: "epdg.b", IPs: []string{"198.51.100.1"}, MNC: "02", MCC: "262", Operator: "Op B"},
+		{FQDN: "epdg.b", MNC: "02", MCC: "262", Operator: "Op B"},
+	}
+	if err := db.InsertResultsBatch(second, InsertOptions{SkipExisting: true}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+
+	var fqdns, operators int
+	db.conn.QueryRow("SELECT COUNT(*) FROM available_fqdns").Scan(&fqdns)
+	db.conn.QueryRow("SELECT COUNT(*) FROM operators").Scan(&operators)
+	if fqdns != 3 || operators != 2 {
+		t.Errorf("fqdns = %d, operators = %d, want 3 and 2", fqdns, operators)
+	}
+
+	ips, err := db.FQDNIPs()
+	if err != nil {
+		t.Fatalf("FQDNIPs: %v", err)
+	}
+	if got := strings.Join(ips["epdg.a"], ","); got != "192.0.2.1,192.0.2.2,192.0.2.3" {
+		t.Errorf("epdg.a IPs = %s", got)
+	}
+	if len(ips["ims.a"]) != 0 || len(ips["epdg.b"]) != 1 {
+		t.Errorf("IPs = %v", ips)
+	}
+}
+
+func TestOperatorDetails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// A database of an earlier version, without operator details
+	old, err := sql.Open(DriverName, path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE operators (mnc INTEGER, mcc INTEGER, operator TEXT)",
+		"CREATE TABLE available_fqdns (operator TEXT, fqdn TEXT)",
+		"INSERT INTO operators VALUES (1, 262, 'Telekom')",
+		"INSERT INTO available_fqdns VALUES ('Telekom', 'ims.mnc001.mcc262.pub.3gppnetwork.org')",
+	} {
+		if _, err := old.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	old.Close()
+
+	db, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	results := []models.DNSResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom",
+			Country: "Germany", CountryCode: "de", Brand: "Telekom", OperatorStatus: "Operational"},
+		{FQDN: "ims.mnc002.mcc262.pub.3gppnetwork.org", MNC: "02", MCC: "262", Operator: "Vodafone",
+			Country: "Germany", CountryCode: "DE"},
+		{FQDN: "ims.mnc015.mcc310.pub.3gppnetwork.org", MNC: "15", MCC: "310", Operator: "Vodafone",
+			Country: "United States of America", CountryCode: "US"},
+	}
+	if err := db.InsertResultsBatch(results, InsertOptions{SkipExisting: true}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+
+	// The existing operator row gains the details
+	telekom, err := db.QueryOperatorsByMNCMCC("01", "262")
+	if err != nil {
+		t.Fatalf("QueryOperatorsByMNCMCC: %v", err)
+	}
+	want := models.MCCMNCEntry{MNC: "01", MCC: "262", Operator: "Telekom", CountryName: "Germany", CountryCode: "de", Brand: "Telekom", Status: "Operational"}
+	if len(telekom) != 1 || telekom[0] != want {
+		t.Errorf("QueryOperatorsByMNCMCC(01, 262) = %+v", telekom)
+	}
+	vodafone, err := db.QueryOperatorsByName("Vodafone")
+	if err != nil {
+		t.Fatalf("QueryOperatorsByName: %v", err)
+	}
+	if len(vodafone) != 2 || vodafone[0].CountryCode != "DE" || vodafone[1].CountryCode != "US" {
+		t.Errorf("QueryOperatorsByName(Vodafone) = %+v", vodafone)
+	}
+
+	// Countries follow the MNC and MCC of each FQDN, not the operator name
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if len(stats.CountryCounts) != 2 || stats.CountryCounts["DE"] != 3 || stats.CountryCounts["US"] != 1 {
+		t.Errorf("CountryCounts = %v", stats.CountryCounts)
+	}
+}
+
+func TestOperatorCodesAsText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// A database of an earlier version, with integer MNC and MCC
+	old, err := sql.Open(DriverName, path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE operators (mnc INTEGER, mcc INTEGER, operator TEXT)",
+		"INSERT INTO operators VALUES (1, 262, 'Telekom'), (260, 310, 'T-Mobile'), (5, 1, 'Test Network')",
+	} {
+		if _, err := old.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	old.Close()
+
+	db, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	operators, err := db.GetAllOperators()
+	if err != nil {
+		t.Fatalf("GetAllOperators: %v", err)
+	}
+	var got []string
+	for _, op := range operators {
+		got = append(got, op.MCC+"-"+op.MNC)
+	}
+	if want := []string{"001-05", "262-01", "310-260"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("migrated operators = %v, want %v", got, want)
+	}
+
+	// Two- and three-digit MNCs stay apart
+	results := []models.DNSResult{
+		{FQDN: "ims.mnc001.mcc999.pub.3gppnetwork.org", MNC: "01", MCC: "999", Operator: "Two"},
+		{FQDN: "epdg.epc.mnc001.mcc999.pub.3gppnetwork.org", MNC: "001", MCC: "999", Operator: "Three"},
+	}
+	if err := db.InsertResults(results); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+	for mnc, want := range map[string]string{"01": "Two", "001": "Three"} {
+		names, err := db.QueryOperatorNames(mnc, "999")
+		if err != nil {
+			t.Fatalf("QueryOperatorNames: %v", err)
+		}
+		if len(names) != 1 || names[0] != want {
+			t.Errorf("QueryOperatorNames(%s, 999) = %v, want [%s]", mnc, names, want)
+		}
+	}
+
+	// Opening again leaves the migrated table alone
+	reopened, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	reopened.Close()
+}
+
+func TestGetStats(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	results := []models.DNSResult{
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom", IPs: []string{"192.0.2.1"}},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", MNC: "02", MCC: "262", Operator: "Vodafone", IPs: []string{"2001:db8::1"}},
+		{FQDN: "www.example.org", Operator: "Other"},
+	}
+	if err := db.InsertResultsBatch(results, InsertOptions{}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TotalFQDNs != 4 || stats.TotalIPs != 3 {
+		t.Errorf("TotalFQDNs = %d, TotalIPs = %d, want 4 and 3", stats.TotalFQDNs, stats.TotalIPs)
+	}
+	want := map[string]int{"ims": 1, "epdg.epc": 2}
+	if !reflect.DeepEqual(stats.SubdomainCounts, want) {
+		t.Errorf("SubdomainCounts = %v, want %v", stats.SubdomainCounts, want)
+	}
+	wantNetworks := map[string]map[string]int{
+		"ims":      {"262-001": 1},
+		"epdg.epc": {"262-001": 1, "262-002": 1},
+	}
+	if !reflect.DeepEqual(stats.SubdomainNetworkCounts, wantNetworks) || stats.SubdomainMCCCounts["epdg.epc"]["262"] != 2 {
+		t.Errorf("cross-tabs = %v and %v", stats.SubdomainNetworkCounts, stats.SubdomainMCCCounts)
+	}
+	wantOperators := map[string]int{"Telekom": 2, "Vodafone": 1, "Other": 1}
+	if !reflect.DeepEqual(stats.OperatorCounts, wantOperators) || !reflect.DeepEqual(stats.OperatorSubdomainCounts["Telekom"], map[string]int{"ims": 1, "epdg.epc": 1}) {
+		t.Errorf("OperatorCounts = %v, OperatorSubdomainCounts = %v", stats.OperatorCounts, stats.OperatorSubdomainCounts)
+	}
+}
+
+func TestPrivateIPs(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	// One FQDN per address, named after its class
+	addresses := map[string]ipclass.Class{
+		"10.1.2.3": ipclass.Private, "172.16.0.1": ipclass.Private, "172.31.255.1": ipclass.Private,
+		"192.168.0.1": ipclass.Private, "100.64.0.1": ipclass.Private, "100.127.0.1": ipclass.Private,
+		"fc00::1": ipclass.Private, "fd12:3456::1": ipclass.Private,
+		"172.15.0.1": ipclass.Public, "172.32.0.1": ipclass.Public, "100.128.0.1": ipclass.Public,
+		"8.8.8.8": ipclass.Public, "110.1.2.3": ipclass.Public, "2a00:1450::1": ipclass.Public, "fe80::1": ipclass.Bogon,
+	}
+	var results []models.DNSResult
+	var wantPrivate []string
+	for ip, class := range addresses {
+		fqdn := fmt.Sprintf("%s-%s.mnc001.mcc262.pub.3gppnetwork.org", class, strings.NewReplacer(".", "-", ":", "-").Replace(ip))
+		results = append(results, models.DNSResult{FQDN: fqdn, MNC: "01", MCC: "262", Operator: "Telekom", IPs: []string{ip}})
+		if class == ipclass.Private {
+			wantPrivate = append(wantPrivate, fqdn)
+		}
+	}
+	if err := db.InsertResultsBatch(results, InsertOptions{}); err != nil {
+		t.Fatalf("InsertResultsBatch: %v", err)
+	}
+	sort.Strings(wantPrivate)
+
+	// The SQL condition agrees with ipclass
+	got, err := db.QueryPrivateIPPage(QueryOptions{OrderBy: "fqdn"})
+	if err != nil {
+		t.Fatalf("QueryPrivateIPPage: %v", err)
+	}
+	if !reflect.DeepEqual(got, wantPrivate) {
+		t.Errorf("QueryPrivateIPPage = %v, want %v", got, wantPrivate)
+	}
+	count, err := db.CountFQDNs(FQDNFilter{PrivateIPs: true, Operator: "Telekom"})
+	if err != nil || count != len(wantPrivate) {
+		t.Errorf("CountFQDNs = %d, %v, want %d", count, err, len(wantPrivate))
+	}
+
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if !reflect.DeepEqual(stats.PrivateIPFQDNs, wantPrivate) || stats.IPClassCounts["bogon"] != 1 || stats.IPVersionCounts["ipv6"] != 4 {
+		t.Errorf("GetStats: private %v, classes %v, versions %v", stats.PrivateIPFQDNs, stats.IPClassCounts, stats.IPVersionCounts)
+	}
+}
+
+func TestValueTuples(t *testing.T) {
+	if got := valueTuples(3, 2); got != "(?, ?, ?), (?, ?, ?)" {
+		t.Errorf("valueTuples(3, 2) = %q", got)
+	}
+	if got := valueTuples(2, 1); got != "(?, ?)" {
+		t.Errorf("valueTuples(2, 1) = %q", got)
+	}
+}
+
+// BenchmarkInsertResults compares one INSERT per row with multi-row
+// batches, with and without synchronous writes:
+//
+//	go test ./internal/database -run=^$ -bench=InsertResults
+func BenchmarkInsertResults(b *testing.B) {
+	results := testResults(20000)
+	benchmarks := []struct {
+		name string
+		opts InsertOptions
+	}{
+		{name: "row-by-row", opts: InsertOptions{BatchSize: 1}},
+		{name: "batch-500", opts: InsertOptions{BatchSize: 500}},
+		{name: "batch-500-nosync", opts: InsertOptions{BatchSize: 500, NoSync: true}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				db, err := NewDB(filepath.Join(b.TempDir(), fmt.Sprintf("bench-%d.db", i)))
+				if err != nil {
+					b.Fatalf("NewDB: %v", err)
+				}
+				b.StartTimer()
+
+				if err := db.InsertResultsBatch(results, bm.opts); err != nil {
+					b.Fatalf("InsertResultsBatch: %v", err)
+				}
+
+				b.StopTimer()
+				db.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(len(results)*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Kinds of tagged targets
+const (
+	TagKindFQDN     = "fqdn"
+	TagKindOperator = "operator"
+)
+
+// Tag is an analyst label on an FQDN or operator
+type Tag struct {
+	Kind    string    `json:"kind"`
+	Target  string    `json:"target"`
+	Tag     string    `json:"tag"`
+	Created time.Time `json:"created"`
+}
+
+// TagCount is the number of targets carrying a tag
+type TagCount struct {
+	Tag       string `json:"tag"`
+	FQDNs     int    `json:"fqdns"`
+	Operators int    `json:"operators"`
+}
+
+// ValidateTag checks a tag name: up to 64 characters without spaces or
+// commas, so tags can be listed comma-separated
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+	if len(tag) > 64 {
+		return fmt.Errorf("tag %q is longer than 64 characters", tag)
+	}
+	for _, r := range tag {
+		if unicode.IsSpace(r) || r == ',' || !unicode.IsPrint(r) {
+			return fmt.Errorf("invalid tag %q: spaces and commas are not allowed", tag)
+		}
+	}
+	return nil
+}
+
+// tagCondition matches FQDNs carrying a tag, directly or through their
+// operator
+const tagCondition = `(fqdn IN (SELECT target FROM tags WHERE kind = 'fqdn' AND tag = ?)
+	OR operator IN (SELECT target FROM tags WHERE kind = 'operator' AND tag = ?))`
+
+// AddTag labels targets of a kind with a tag and returns how many labels
+// were new
+func (db *DB) AddTag(kind, tag string, targets []string) (int, error) {
+	return db.changeTags(kind, tag, targets,
+		"INSERT OR IGNORE INTO tags (kind, target, tag, created) VALUES (?, ?, ?, ?)", true)
+}
+
+// RemoveTag removes a tag from targets of a kind and returns how many
+// labels were removed
+func (db *DB) RemoveTag(kind, tag string, targets []string) (int, error) {
+	return db.changeTags(kind, tag, targets,
+		"DELETE FROM tags WHERE kind = ? AND target = ? AND tag = ?", false)
+}
+
+// changeTags runs an insert or delete for each target in one transaction
+func (db *DB) changeTags(kind, tag string, targets []string, query string, insert bool) (int, error) {
+	if kind != TagKindFQDN && kind != TagKindOperator {
+		return 0, fmt.Errorf("invalid tag kind: %s", kind)
+	}
+	if err := ValidateTag(tag); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare tag statement: %w", err)
+	}
+	defer stmt.Close()
+
+	created := time.Now().UTC().Format(time.RFC3339)
+	changed := 0
+	for _, target := range targets {
+		args := []interface{}{kind, target, tag}
+		if insert {
+			args = append(args, created)
+		}
+		res, err := stmt.Exec(args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update tag: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		changed += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changed, nil
+}
+
+// CountTags returns every tag with the number of FQDNs and operators
+// carrying it, by tag name
+func (db *DB) CountTags() ([]TagCount, error) {
+	rows, err := db.conn.Query(`
+		SELECT tag,
+			SUM(CASE WHEN kind = 'fqdn' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN kind = 'operator' THEN 1 ELSE 0 END)
+		FROM tags
+		GROUP BY tag
+		ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []TagCount
+	for rows.Next() {
+		var c TagCount
+		if err := rows.Scan(&c.Tag, &c.FQDNs, &c.Operators); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return counts, nil
+}
+
+// QueryTags returns the labels matching a kind, target and tag; empty
+// arguments match all
+func (db *DB) QueryTags(kind, target, tag string) ([]Tag, error) {
+	var conditions []string
+	var args []interface{}
+	if kind != "" {
+		conditions = append(conditions, "kind = ?")
+		args = append(args, kind)
+	}
+	if target != "" {
+		conditions = append(conditions, "target = ?")
+		args = append(args, target)
+	}
+	if tag != "" {
+		conditions = append(conditions, "tag = ?")
+		args = append(args, tag)
+	}
+	query := "SELECT kind, target, tag, created FROM tags"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY tag, kind, target"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var t Tag
+		var created string
+		if err := rows.Scan(&t.Kind, &t.Target, &t.Tag, &created); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		t.Created, _ = time.Parse(time.RFC3339, created)
+		tags = append(tags, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return tags, nil
+}
+
+// FQDNTags returns the tags of every tagged FQDN, including the tags of
+// its operator, sorted by name
+func (db *DB) FQDNTags() (map[string][]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT target, tag FROM tags WHERE kind = 'fqdn'
+		UNION
+		SELECT f.fqdn, t.tag FROM available_fqdns f
+		JOIN tags t ON t.kind = 'operator' AND t.target = f.operator
+		ORDER BY 1, 2`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var fqdn, tag string
+		if err := rows.Scan(&fqdn, &tag); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tags[fqdn] = append(tags[fqdn], tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return tags, nil
+}
+
+// QueryByTagPage queries one page of FQDNs carrying a tag, directly or
+// through their operator
+func (db *DB) QueryByTagPage(tag string, opts QueryOptions) ([]string, error) {
+	opts.Tag = ""
+	return db.queryFQDNs("SELECT fqdn FROM available_fqdns WHERE "+tagCondition, opts, tag, tag)
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	results := testResults(20)
+	if err := db.InsertResults(results); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+
+	// Operator 3 owns results 3 and 13; result 5 is tagged on its own
+	if n, err := db.AddTag(TagKindOperator, "customer-x", []string{"Operator 3"}); err != nil || n != 1 {
+		t.Fatalf("AddTag operator: %d, %v", n, err)
+	}
+	if n, err := db.AddTag(TagKindFQDN, "customer-x", []string{results[5].FQDN, results[3].FQDN}); err != nil || n != 2 {
+		t.Fatalf("AddTag fqdn: %d, %v", n, err)
+	}
+	if n, err := db.AddTag(TagKindFQDN, "customer-x", []string{results[5].FQDN}); err != nil || n != 0 {
+		t.Errorf("AddTag again: %d, %v", n, err)
+	}
+	if _, err := db.AddTag(TagKindFQDN, "confirmed-live", []string{results[5].FQDN}); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+
+	fqdns, err := db.QueryByTagPage("customer-x", QueryOptions{})
+	if err != nil {
+		t.Fatalf("QueryByTagPage: %v", err)
+	}
+	want := []string{results[3].FQDN, results[5].FQDN, results[13].FQDN}
+	if !reflect.DeepEqual(fqdns, want) {
+		t.Errorf("QueryByTagPage = %v, want %v", fqdns, want)
+	}
+
+	fqdns, err = db.QueryByOperatorPage("Operator 3", QueryOptions{Tag: "confirmed-live"})
+	if err != nil {
+		t.Fatalf("QueryByOperatorPage: %v", err)
+	}
+	if len(fqdns) != 0 {
+		t.Errorf("Operator 3 tagged confirmed-live = %v, want none", fqdns)
+	}
+
+	if n, err := db.CountFQDNs(FQDNFilter{Tag: "customer-x"}); err != nil || n != 3 {
+		t.Errorf("CountFQDNs = %d, %v, want 3", n, err)
+	}
+
+	counts, err := db.CountTags()
+	if err != nil {
+		t.Fatalf("CountTags: %v", err)
+	}
+	wantCounts := []TagCount{
+		{Tag: "confirmed-live", FQDNs: 1},
+		{Tag: "customer-x", FQDNs: 2, Operators: 1},
+	}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("CountTags = %+v, want %+v", counts, wantCounts)
+	}
+
+	tags, err := db.FQDNTags()
+	if err != nil {
+		t.Fatalf("FQDNTags: %v", err)
+	}
+	if got := tags[results[5].FQDN]; !reflect.DeepEqual(got, []string{"confirmed-live", "customer-x"}) {
+		t.Errorf("tags of %s = %v", results[5].FQDN, got)
+	}
+	if got := tags[results[13].FQDN]; !reflect.DeepEqual(got, []string{"customer-x"}) {
+		t.Errorf("tags of %s = %v, want operator tag", results[13].FQDN, got)
+	}
+	if len(tags) != 3 {
+		t.Errorf("FQDNTags has %d FQDNs, want 3", len(tags))
+	}
+
+	if n, err := db.RemoveTag(TagKindOperator, "customer-x", []string{"Operator 3"}); err != nil || n != 1 {
+		t.Fatalf("RemoveTag: %d, %v", n, err)
+	}
+	labels, err := db.QueryTags("", "", "customer-x")
+	if err != nil {
+		t.Fatalf("QueryTags: %v", err)
+	}
+	if len(labels) != 2 || labels[0].Kind != TagKindFQDN || labels[0].Created.IsZero() {
+		t.Errorf("QueryTags after remove = %+v", labels)
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr string
+	}{
+		{tag: "confirmed-live"},
+		{tag: "honeypot?"},
+		{tag: "", wantErr: "cannot be empty"},
+		{tag: "two words", wantErr: "spaces and commas"},
+		{tag: "a,b", wantErr: "spaces and commas"},
+		{tag: strings.Repeat("x", 65), wantErr: "longer than 64"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTag(tt.tag)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateTag(%q): %v", tt.tag, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateTag(%q) = %v, want %q", tt.tag, err, tt.wantErr)
+		}
+	}
+
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.AddTag("vendor", "x", []string{"y"}); err == nil {
+		t.Error("AddTag accepted an invalid kind")
+	}
+}
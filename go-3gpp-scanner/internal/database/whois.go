@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// SaveWhoisNetworks stores registered networks, replacing earlier lookups
+// of the same prefixes
+func (db *DB) SaveWhoisNetworks(networks []models.WhoisNetwork) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO whois_networks (prefix, range, net_name, org, country, abuse_emails, server, looked_up)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare whois statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, n := range networks {
+		_, err := stmt.Exec(n.Prefix, nullable(n.Range), nullable(n.NetName), nullable(n.Org), nullable(n.Country),
+			nullable(strings.Join(n.AbuseEmails, ",")), n.Server, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert whois network: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryWhoisNetworks returns the stored networks, sorted by prefix
+func (db *DB) QueryWhoisNetworks() ([]models.WhoisNetwork, error) {
+	rows, err := db.conn.Query("SELECT prefix, range, net_name, org, country, abuse_emails, server FROM whois_networks ORDER BY prefix")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var networks []models.WhoisNetwork
+	for rows.Next() {
+		var n models.WhoisNetwork
+		var rng, netName, org, country, abuse sql.NullString
+		if err := rows.Scan(&n.Prefix, &rng, &netName, &org, &country, &abuse, &n.Server); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		n.Range, n.NetName, n.Org, n.Country = rng.String, netName.String, org.String, country.String
+		if abuse.String != "" {
+			n.AbuseEmails = strings.Split(abuse.String, ",")
+		}
+		networks = append(networks, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestWhoisNetworks(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveWhoisNetworks([]models.WhoisNetwork{
+		{Prefix: "193.0.0.0/21", Range: "193.0.0.0 - 193.0.7.255", NetName: "RIPE-NCC", Org: "RIPE NCC", Country: "NL",
+			AbuseEmails: []string{"abuse@ripe.net"}, Server: "whois.ripe.net"},
+		{Prefix: "192.0.2.0/25", Org: "Example Mobile", Server: "whois.arin.net"},
+	}); err != nil {
+		t.Fatalf("SaveWhoisNetworks: %v", err)
+	}
+	// A new lookup replaces the stored network
+	if err := db.SaveWhoisNetworks([]models.WhoisNetwork{
+		{Prefix: "192.0.2.0/25", Org: "Example Mobile Inc.", AbuseEmails: []string{"abuse@mobile.example", "noc@mobile.example"}, Server: "whois.arin.net"},
+	}); err != nil {
+		t.Fatalf("SaveWhoisNetworks again: %v", err)
+	}
+
+	got, err := db.QueryWhoisNetworks()
+	if err != nil {
+		t.Fatalf("QueryWhoisNetworks: %v", err)
+	}
+	want := []models.WhoisNetwork{
+		{Prefix: "192.0.2.0/25", Org: "Example Mobile Inc.", AbuseEmails: []string{"abuse@mobile.example", "noc@mobile.example"}, Server: "whois.arin.net"},
+		{Prefix: "193.0.0.0/21", Range: "193.0.0.0 - 193.0.7.255", NetName: "RIPE-NCC", Org: "RIPE NCC", Country: "NL",
+			AbuseEmails: []string{"abuse@ripe.net"}, Server: "whois.ripe.net"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryWhoisNetworks = %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,279 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultBenchFQDNs are queried when no sample is given: ePDG names of
+// large operators that resolve, mixed with names in unassigned zones that
+// return NXDOMAIN, as most scan queries do
+var DefaultBenchFQDNs = []string{
+	"epdg.epc.mnc260.mcc310.pub.3gppnetwork.org",
+	"epdg.epc.mnc410.mcc310.pub.3gppnetwork.org",
+	"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+	"epdg.epc.mnc015.mcc234.pub.3gppnetwork.org",
+	"epdg.epc.mnc001.mcc208.pub.3gppnetwork.org",
+	"epdg.epc.mnc010.mcc440.pub.3gppnetwork.org",
+	"ims.mnc999.mcc262.pub.3gppnetwork.org",
+	"epdg.epc.mnc999.mcc310.pub.3gppnetwork.org",
+	"bsf.mnc998.mcc234.pub.3gppnetwork.org",
+	"xcap.ims.mnc997.mcc208.pub.3gppnetwork.org",
+}
+
+// ResolverBench is the measured performance of one resolver
+type ResolverBench struct {
+	Server   string `json:"server"`
+	Queries  int    `json:"queries"`
+	Answered int    `json:"answered"` // Any response, including NXDOMAIN
+	Timeouts int    `json:"timeouts"`
+	Errors   int    `json:"errors"` // Network errors other than timeouts
+	// Latencies of answered queries
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P95  time.Duration `json:"p95"`
+	// Throughput is answered queries per second at the benchmark concurrency
+	Throughput float64 `json:"throughput_qps"`
+}
+
+// TimeoutRate returns the fraction of queries that timed out
+func (b ResolverBench) TimeoutRate() float64 {
+	if b.Queries == 0 {
+		return 0
+	}
+	return float64(b.Timeouts) / float64(b.Queries)
+}
+
+// expectedCost is the expected time a scan spends on one query to this
+// resolver: the median latency, plus the query timeout for the share of
+// queries that time out before the next resolver is tried
+func (b ResolverBench) expectedCost() time.Duration {
+	return b.P50 + time.Duration(b.TimeoutRate()*float64(queryTimeout))
+}
+
+// BenchResolvers queries every FQDN against each resolver in turn, with
+// up to workers queries in flight, and measures the responses. Resolvers
+// are benchmarked one after another so they do not compete for bandwidth.
+func (s *Scanner) BenchResolvers(ctx context.Context, servers, fqdns []string, workers int) []ResolverBench {
+	if workers <= 0 {
+		workers = 1
+	}
+	results := make([]ResolverBench, 0, len(servers))
+	for i, server := range servers {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, s.benchResolver(ctx, server, fqdns, workers))
+		if s.progressFunc != nil {
+			s.progressFunc(i+1, len(servers), len(results))
+		}
+	}
+	return results
+}
+
+// benchResolver measures a single resolver
+func (s *Scanner) benchResolver(ctx context.Context, server string, fqdns []string, workers int) ResolverBench {
+	bench := ResolverBench{Server: server}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, fqdn := range fqdns {
+			select {
+			case jobs <- fqdn:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fqdn := range jobs {
+				latency, err := s.benchQuery(server, fqdn)
+
+				mu.Lock()
+				bench.Queries++
+				switch {
+				case err == nil:
+					bench.Answered++
+					latencies = append(latencies, latency)
+				case ErrorStatus(err) == models.StatusTimeout:
+					bench.Timeouts++
+				default:
+					bench.Errors++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		bench.Mean = total / time.Duration(len(latencies))
+		bench.P50 = percentile(latencies, 0.50)
+		bench.P95 = percentile(latencies, 0.95)
+	}
+	if elapsed > 0 {
+		bench.Throughput = float64(bench.Answered) / elapsed.Seconds()
+	}
+	return bench
+}
+
+// benchQuery sends one A query and returns the round-trip time
+func (s *Scanner) benchQuery(server, fqdn string) (time.Duration, error) {
+	client, err := s.clientFor(server)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeA)
+	msg.RecursionDesired = true
+
+	start := time.Now()
+	if _, _, err := client.Exchange(msg, server); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest rank)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// maxBenchTimeoutRate is the share of timeouts above which a resolver is
+// not recommended at all
+const maxBenchTimeoutRate = 0.10
+
+// maxRecommendedQPS caps the recommended rate: a short benchmark does not
+// trip the per-client limits public resolvers apply to sustained traffic
+const maxRecommendedQPS = 100
+
+// Recommendation is the scan configuration suggested by a benchmark
+type Recommendation struct {
+	Resolvers   []string      `json:"resolvers"`         // Fastest first
+	Dropped     []string      `json:"dropped,omitempty"` // Too many timeouts, or no answers
+	QPS         float64       `json:"qps"`
+	Delay       time.Duration `json:"delay"`
+	Concurrency int           `json:"concurrency"`
+}
+
+// Flags returns the scan flags applying the recommendation
+func (r Recommendation) Flags() string {
+	if len(r.Resolvers) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("--resolvers=%s --delay=%d --concurrency=%d",
+		strings.Join(r.Resolvers, ","), r.Delay.Milliseconds(), r.Concurrency)
+}
+
+// Recommend orders resolvers by the expected time per query and derives a
+// query rate. Scans send nearly all queries to the first resolver, so the
+// rate is half of its measured throughput (at most maxRecommendedQPS),
+// leaving headroom for other traffic, and the concurrency is enough to sustain that rate at its p95
+// latency.
+func Recommend(results []ResolverBench) Recommendation {
+	var rec Recommendation
+	var usable []ResolverBench
+	for _, b := range results {
+		if b.Answered == 0 || b.TimeoutRate() > maxBenchTimeoutRate {
+			rec.Dropped = append(rec.Dropped, b.Server)
+			continue
+		}
+		usable = append(usable, b)
+	}
+	if len(usable) == 0 {
+		return rec
+	}
+
+	sort.SliceStable(usable, func(i, j int) bool {
+		return usable[i].expectedCost() < usable[j].expectedCost()
+	})
+	for _, b := range usable {
+		rec.Resolvers = append(rec.Resolvers, b.Server)
+	}
+
+	best := usable[0]
+	rec.QPS = min(best.Throughput/2, maxRecommendedQPS)
+	if rec.QPS <= 0 {
+		return rec
+	}
+	rec.Delay = time.Duration(math.Ceil(1000/rec.QPS)) * time.Millisecond
+	rec.QPS = float64(time.Second) / float64(rec.Delay)
+	rec.Concurrency = max(1, int(math.Ceil(rec.QPS*best.P95.Seconds())))
+	return rec
+}
+
+// FormatBench renders benchmark results and the recommendation as text
+func FormatBench(results []ResolverBench, rec Recommendation) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Resolver Benchmark ===\n\n")
+	if len(results) == 0 {
+		sb.WriteString("No resolvers benchmarked.\n")
+		return sb.String()
+	}
+
+	width := len("Resolver")
+	for _, b := range results {
+		width = max(width, len(b.Server))
+	}
+	sb.WriteString(fmt.Sprintf("%-*s  %7s  %8s  %8s  %8s  %8s  %7s\n",
+		width, "Resolver", "Queries", "Timeouts", "Mean", "P50", "P95", "QPS"))
+	for _, b := range results {
+		sb.WriteString(fmt.Sprintf("%-*s  %7d  %7.1f%%  %8s  %8s  %8s  %7.1f\n",
+			width, b.Server, b.Queries, b.TimeoutRate()*100,
+			formatMillis(b.Mean), formatMillis(b.P50), formatMillis(b.P95), b.Throughput))
+	}
+
+	sb.WriteString("\n")
+	if len(rec.Resolvers) == 0 {
+		sb.WriteString("No resolver answered reliably; no recommendation.\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Recommended order: %s\n", strings.Join(rec.Resolvers, ", ")))
+	if len(rec.Dropped) > 0 {
+		sb.WriteString(fmt.Sprintf("Not recommended (over %.0f%% timeouts or no answers): %s\n",
+			maxBenchTimeoutRate*100, strings.Join(rec.Dropped, ", ")))
+	}
+	if rec.QPS > 0 {
+		sb.WriteString(fmt.Sprintf("Recommended rate: %.1f qps\n", rec.QPS))
+	}
+	sb.WriteString(fmt.Sprintf("\n  3gpp-scanner scan %s\n", rec.Flags()))
+	return sb.String()
+}
+
+// formatMillis renders a latency in milliseconds, or "-" when unmeasured
+func formatMillis(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+// startTestResolver serves DNS on a local UDP port with handler and
+// returns its address
+func startTestResolver(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+// nxdomainHandler answers every query with NXDOMAIN
+func nxdomainHandler(w dns.ResponseWriter, r *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetRcode(r, dns.RcodeNameError)
+	w.WriteMsg(resp)
+}
+
+func TestBenchResolvers(t *testing.T) {
+	server := startTestResolver(t, nxdomainHandler)
+	scanner := NewScanner(&models.ScanConfig{QueryDelay: time.Millisecond, Concurrency: 1})
+
+	results := scanner.BenchResolvers(context.Background(), []string{server}, DefaultBenchFQDNs, 3)
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	b := results[0]
+	if b.Server != server || b.Queries != len(DefaultBenchFQDNs) || b.Answered != b.Queries {
+		t.Errorf("Expected all %d queries answered by %s, got %+v", len(DefaultBenchFQDNs), server, b)
+	}
+	if b.P50 <= 0 || b.P95 < b.P50 || b.Throughput <= 0 {
+		t.Errorf("Unexpected latency or throughput: %+v", b)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	results := []ResolverBench{
+		{Server: "8.8.8.8:53", Queries: 100, Answered: 100, P50: 30 * time.Millisecond, P95: 80 * time.Millisecond, Throughput: 40},
+		{Server: "1.1.1.1:53", Queries: 100, Answered: 99, Timeouts: 1, P50: 10 * time.Millisecond, P95: 20 * time.Millisecond, Throughput: 100},
+		{Server: "192.0.2.1:53", Queries: 100, Answered: 60, Timeouts: 40, P50: 5 * time.Millisecond, Throughput: 20},
+		{Server: "192.0.2.2:53", Queries: 100, Errors: 100},
+	}
+
+	rec := Recommend(results)
+	if strings.Join(rec.Resolvers, ",") != "8.8.8.8:53,1.1.1.1:53" {
+		// 1% timeouts cost 50ms per query on average, more than the slower resolver
+		t.Errorf("Unexpected order: %v", rec.Resolvers)
+	}
+	if strings.Join(rec.Dropped, ",") != "192.0.2.1:53,192.0.2.2:53" {
+		t.Errorf("Unexpected dropped resolvers: %v", rec.Dropped)
+	}
+	if rec.Delay != 50*time.Millisecond || rec.QPS != 20 {
+		t.Errorf("Expected 20 qps (50ms delay), got %.1f qps (%v)", rec.QPS, rec.Delay)
+	}
+	if rec.Concurrency != 2 {
+		t.Errorf("Expected concurrency 2, got %d", rec.Concurrency)
+	}
+	if got := rec.Flags(); got != "--resolvers=8.8.8.8:53,1.1.1.1:53 --delay=50 --concurrency=2" {
+		t.Errorf("Unexpected flags: %s", got)
+	}
+
+	if rec := Recommend(results[3:]); len(rec.Resolvers) != 0 || rec.Flags() != "" {
+		t.Errorf("Expected no recommendation, got %+v", rec)
+	}
+}
+
+func TestParseResolvers(t *testing.T) {
+	got, err := ParseResolvers([]string{"1.1.1.1", " 8.8.8.8:5353", "2001:4860:4860::8888", "[::1]:53", ""})
+	if err != nil {
+		t.Fatalf("ParseResolvers: %v", err)
+	}
+	want := "1.1.1.1:53,8.8.8.8:5353,[2001:4860:4860::8888]:53,[::1]:53"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+
+	for _, bad := range []string{"dns.google", "1.1.1.1:0", "1.1.1.1:dns"} {
+		if _, err := ParseResolvers([]string{bad}); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package dns
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ErrCheckpointMismatch is returned for a checkpoint written by a scan with
+// other parameters, whose completed jobs do not carry over
+var ErrCheckpointMismatch = errors.New("checkpoint was written by a different scan")
+
+// checkpointRecord is a single line of the checkpoint file: a job, or the
+// header holding the fingerprint of the scan that wrote the file
+type checkpointRecord struct {
+	FQDN        string            `json:"fqdn,omitempty"`
+	Result      *models.DNSResult `json:"result,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+}
+
+// ScanFingerprint identifies a scan by its mode, MCC-MNC entries,
+// subdomains and servers. Order does not matter, so a shuffled list of the
+// same entries resumes the same scan. The entries are those left after
+// sharding, so a different shard of the list is a different scan.
+func ScanFingerprint(mode string, entries []models.MCCMNCEntry, subdomains, servers []string) string {
+	plmns := make([]string, len(entries))
+	for i, entry := range entries {
+		plmns[i] = entry.MCC + "-" + entry.MNC
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "mode %s\n", mode)
+	for _, part := range [][]string{plmns, subdomains, servers} {
+		sorted := slices.Clone(part)
+		slices.Sort(sorted)
+		fmt.Fprintf(h, "%s\n", strings.Join(sorted, ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Checkpoint persists completed scan jobs to an append-only JSON-lines file
+// so that a paused or interrupted scan can resume where it left off
+type Checkpoint struct {
+	path    string
+	mu      sync.Mutex
+	file    *os.File
+	done    map[string]bool
+	results []models.DNSResult
+}
+
+// OpenCheckpoint opens (or creates) a checkpoint file for the scan with
+// the given fingerprint and loads any previously completed jobs from it.
+// A file written by another scan is refused with ErrCheckpointMismatch
+// unless force is set, which resumes it under the new fingerprint.
+func OpenCheckpoint(path, fingerprint string, force bool) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		path: path,
+		done: make(map[string]bool),
+	}
+
+	stored, err := cp.load()
+	if err != nil {
+		return nil, err
+	}
+	if stored != fingerprint && (stored != "" || len(cp.done) > 0) && !force {
+		return nil, fmt.Errorf("%s: %w", path, ErrCheckpointMismatch)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	cp.file = file
+
+	// A later header supersedes the earlier one when forced
+	if stored != fingerprint {
+		if err := cp.write(checkpointRecord{Fingerprint: fingerprint}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return cp, nil
+}
+
+// load reads existing records from the checkpoint file, if present, and
+// returns the fingerprint of its last header
+func (c *Checkpoint) load() (string, error) {
+	file, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+	defer file.Close()
+
+	var fingerprint string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec checkpointRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn final line from an abrupt stop is expected; skip it
+			continue
+		}
+		if rec.Fingerprint != "" {
+			fingerprint = rec.Fingerprint
+			continue
+		}
+		if rec.FQDN == "" || c.done[rec.FQDN] {
+			continue
+		}
+		c.done[rec.FQDN] = true
+		if rec.Result != nil {
+			c.results = append(c.results, *rec.Result)
+		}
+	}
+
+	return fingerprint, scanner.Err()
+}
+
+// Done reports whether the job for an FQDN has already completed
+func (c *Checkpoint) Done(fqdn string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[fqdn]
+}
+
+// Completed returns the number of jobs recorded so far
+func (c *Checkpoint) Completed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Results returns the hits recorded by previous runs
+func (c *Checkpoint) Results() []models.DNSResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.DNSResult(nil), c.results...)
+}
+
+// Record marks an FQDN as completed, storing its result if it was a hit
+func (c *Checkpoint) Record(fqdn string, result *models.DNSResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.write(checkpointRecord{FQDN: fqdn, Result: result}); err != nil {
+		return err
+	}
+	c.done[fqdn] = true
+	return nil
+}
+
+// write appends a record to the checkpoint file
+func (c *Checkpoint) write(rec checkpointRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint record: %w", err)
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the checkpoint file, keeping it on disk for a later resume
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// Remove closes and deletes the checkpoint file once a scan has finished
+func (c *Checkpoint) Remove() error {
+	c.file.Close()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint file: %w", err)
+	}
+	return nil
+}
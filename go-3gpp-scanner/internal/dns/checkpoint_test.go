@@ -0,0 +1,112 @@
+package dns
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := t.TempDir() + "/state.jsonl"
+
+	fingerprint := ScanFingerprint("mnc", []models.MCCMNCEntry{{MCC: "310", MNC: "01"}}, []string{"ims", "bsf"}, nil)
+	cp, err := OpenCheckpoint(path, fingerprint, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint failed: %v", err)
+	}
+
+	hit := &models.DNSResult{
+		FQDN:      "ims.mnc001.mcc310.pub.3gppnetwork.org",
+		IPs:       []string{"192.0.2.1"},
+		Subdomain: "ims",
+		MNC:       "01",
+		MCC:       "310",
+		Timestamp: time.Now(),
+	}
+	if err := cp.Record(hit.FQDN, hit); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := cp.Record("bsf.mnc001.mcc310.pub.3gppnetwork.org", nil); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	cp.Close()
+
+	// Reopen and verify state was restored
+	cp, err = OpenCheckpoint(path, fingerprint, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint (resume) failed: %v", err)
+	}
+
+	if cp.Completed() != 2 {
+		t.Errorf("Expected 2 completed jobs, got %d", cp.Completed())
+	}
+	if !cp.Done("bsf.mnc001.mcc310.pub.3gppnetwork.org") {
+		t.Errorf("Expected miss to be recorded as done")
+	}
+	if results := cp.Results(); len(results) != 1 || results[0].FQDN != hit.FQDN {
+		t.Errorf("Expected 1 restored result, got %+v", results)
+	}
+
+	if err := cp.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint file to be removed")
+	}
+}
+
+func TestCheckpointFingerprint(t *testing.T) {
+	path := t.TempDir() + "/state.jsonl"
+	entries := []models.MCCMNCEntry{{MCC: "310", MNC: "01"}, {MCC: "262", MNC: "01"}}
+	fingerprint := ScanFingerprint("mnc", entries, []string{"ims"}, []string{"192.0.2.53"})
+
+	// Order does not change the scan
+	reordered := []models.MCCMNCEntry{entries[1], entries[0]}
+	if ScanFingerprint("mnc", reordered, []string{"ims"}, []string{"192.0.2.53"}) != fingerprint {
+		t.Error("Expected the fingerprint not to depend on entry order")
+	}
+
+	cp, err := OpenCheckpoint(path, fingerprint, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint failed: %v", err)
+	}
+	if err := cp.Record("ims.mnc001.mcc310.pub.3gppnetwork.org", nil); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	cp.Close()
+
+	others := map[string]string{
+		"mode":    ScanFingerprint("mcc", entries, []string{"ims"}, []string{"192.0.2.53"}),
+		"entries": ScanFingerprint("mnc", entries[:1], []string{"ims"}, []string{"192.0.2.53"}),
+		"servers": ScanFingerprint("mnc", entries, []string{"ims"}, []string{"198.51.100.53"}),
+	}
+	for name, other := range others {
+		if _, err := OpenCheckpoint(path, other, false); !errors.Is(err, ErrCheckpointMismatch) {
+			t.Errorf("Changed %s: expected ErrCheckpointMismatch, got %v", name, err)
+		}
+	}
+
+	// Forcing adopts the new fingerprint and keeps the completed jobs
+	cp, err = OpenCheckpoint(path, others["servers"], true)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint (forced) failed: %v", err)
+	}
+	if cp.Completed() != 1 {
+		t.Errorf("Expected 1 completed job after forcing, got %d", cp.Completed())
+	}
+	cp.Close()
+	if cp, err = OpenCheckpoint(path, others["servers"], false); err != nil {
+		t.Fatalf("OpenCheckpoint after forcing failed: %v", err)
+	}
+	cp.Close()
+
+	// A checkpoint without a header predates fingerprints
+	legacy := t.TempDir() + "/legacy.jsonl"
+	os.WriteFile(legacy, []byte(`{"fqdn":"ims.mnc001.mcc310.pub.3gppnetwork.org"}`+"\n"), 0644)
+	if _, err := OpenCheckpoint(legacy, fingerprint, false); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("Expected ErrCheckpointMismatch for a checkpoint without a header, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"3gpp-scanner/internal/ipclass"
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultCrossCheckServers are independent public resolvers whose answers
+// are compared: the scan resolvers plus Quad9
+var DefaultCrossCheckServers = append(slices.Clone(DefaultServers), "9.9.9.9:53")
+
+// Cross-check verdicts
+const (
+	VerdictConsistent   = "consistent"   // Every resolver returned the same answer
+	VerdictGeoDNS       = "geodns"       // Answers differ within the same networks
+	VerdictSuspicious   = "suspicious"   // Answers disagree beyond GeoDNS variance
+	VerdictInconclusive = "inconclusive" // Fewer than two resolvers answered
+)
+
+// ResolverAnswer is the answer of one resolver for an FQDN
+type ResolverAnswer struct {
+	Server string              `json:"server"`
+	Status models.ResultStatus `json:"status"`
+	IPs    []string            `json:"ips,omitempty"` // Sorted
+	Error  string              `json:"error,omitempty"`
+}
+
+// definitive reports whether the answer says something about the name,
+// rather than about the resolver or the network path to it
+func (a ResolverAnswer) definitive() bool {
+	return a.Status == models.StatusOK || a.Status == models.StatusNXDomain || a.Status == models.StatusNoAnswer
+}
+
+// CrossCheckResult compares the answers of several resolvers for an FQDN
+type CrossCheckResult struct {
+	FQDN    string           `json:"fqdn"`
+	Verdict string           `json:"verdict"`
+	Reason  string           `json:"reason,omitempty"`
+	Answers []ResolverAnswer `json:"answers"`
+}
+
+// CrossCheck resolves every FQDN through each server and judges whether
+// the answers agree. Addresses differing only within the same IPv4 prefix
+// of prefixLen bits count as GeoDNS variance. Results are in the order of
+// fqdns; up to workers FQDNs are checked at a time, each sending one query
+// per server at the scanner's rate.
+func (s *Scanner) CrossCheck(ctx context.Context, servers, fqdns []string, workers, prefixLen int) []CrossCheckResult {
+	results := make([]CrossCheckResult, len(fqdns))
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range fqdns {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var done int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range max(1, workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				answers := make([]ResolverAnswer, len(servers))
+				for j, server := range servers {
+					answers[j] = s.queryResolver(ctx, server, fqdns[i])
+				}
+				verdict, reason := JudgeAnswers(answers, prefixLen)
+				results[i] = CrossCheckResult{FQDN: fqdns[i], Verdict: verdict, Reason: reason, Answers: answers}
+
+				mu.Lock()
+				done++
+				if s.progressFunc != nil {
+					s.progressFunc(done, len(fqdns), 0)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// FQDNs not reached before cancellation
+	checked := results[:0]
+	for _, r := range results {
+		if r.FQDN != "" {
+			checked = append(checked, r)
+		}
+	}
+	return checked
+}
+
+// queryResolver sends one A query to a single resolver
+func (s *Scanner) queryResolver(ctx context.Context, server, fqdn string) ResolverAnswer {
+	answer := ResolverAnswer{Server: server}
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		answer.Status, answer.Error = models.StatusTimeout, err.Error()
+		return answer
+	}
+	client, err := s.clientFor(server)
+	if err != nil {
+		answer.Status, answer.Error = models.StatusNetworkError, err.Error()
+		return answer
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeA)
+	msg.RecursionDesired = true
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err != nil {
+		answer.Status, answer.Error = ErrorStatus(err), err.Error()
+		return answer
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		answer.Status = RcodeStatus(resp.Rcode)
+		return answer
+	}
+	for _, rr := range resp.Answer {
+		if a, ok := rr.(*dns.A); ok {
+			answer.IPs = append(answer.IPs, a.A.String())
+		}
+	}
+	sort.Strings(answer.IPs)
+	answer.Status = models.StatusOK
+	if len(answer.IPs) == 0 {
+		answer.Status = models.StatusNoAnswer
+	}
+	return answer
+}
+
+// JudgeAnswers compares the definitive answers of resolvers for one FQDN.
+// Answers are suspicious when some resolvers return addresses and others
+// deny the name, when only some return non-public addresses (a typical
+// sign of filtering resolvers and tampering), or when the address sets
+// differ and one shares no prefix of prefixLen bits with another.
+func JudgeAnswers(answers []ResolverAnswer, prefixLen int) (verdict, reason string) {
+	var found, denied []ResolverAnswer
+	for _, a := range answers {
+		switch {
+		case !a.definitive():
+		case a.Status == models.StatusOK:
+			found = append(found, a)
+		default:
+			denied = append(denied, a)
+		}
+	}
+	if len(found)+len(denied) < 2 {
+		return VerdictInconclusive, "fewer than two resolvers answered"
+	}
+	if len(found) == 0 {
+		return VerdictConsistent, ""
+	}
+	if len(denied) > 0 {
+		return VerdictSuspicious, fmt.Sprintf("%s from %s, addresses from %s",
+			denied[0].Status, answerServers(denied), answerServers(found))
+	}
+
+	var nonPublic, public []ResolverAnswer
+	for _, a := range found {
+		if hasNonPublic(a.IPs) {
+			nonPublic = append(nonPublic, a)
+		} else {
+			public = append(public, a)
+		}
+	}
+	if len(nonPublic) > 0 && len(public) > 0 {
+		return VerdictSuspicious, fmt.Sprintf("non-public addresses from %s only", answerServers(nonPublic))
+	}
+
+	same := true
+	for _, a := range found[1:] {
+		if !slices.Equal(a.IPs, found[0].IPs) {
+			same = false
+			break
+		}
+	}
+	if same {
+		return VerdictConsistent, ""
+	}
+	for i, a := range found {
+		for _, b := range found[i+1:] {
+			if !samePrefix(a.IPs, b.IPs, prefixLen) {
+				return VerdictSuspicious, fmt.Sprintf("%s and %s return addresses in different networks (%s vs %s)",
+					a.Server, b.Server, strings.Join(a.IPs, ","), strings.Join(b.IPs, ","))
+			}
+		}
+	}
+	return VerdictGeoDNS, fmt.Sprintf("addresses differ within the same /%d networks", prefixLen)
+}
+
+// samePrefix reports whether any address of a shares a prefix with any
+// address of b
+func samePrefix(a, b []string, prefixLen int) bool {
+	prefixes := make(map[string]bool, len(a))
+	for _, s := range a {
+		prefixes[prefixOf(s, prefixLen)] = true
+	}
+	for _, s := range b {
+		if prefixes[prefixOf(s, prefixLen)] {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixOf returns the IPv4 network of prefixLen bits an address is in;
+// anything else is compared as is
+func prefixOf(s string, prefixLen int) string {
+	ip, ok := ipclass.Parse(s)
+	if !ok || ip.To4() == nil {
+		return s
+	}
+	return ip.To4().Mask(net.CIDRMask(prefixLen, 32)).String()
+}
+
+// hasNonPublic reports whether any of ips is private or bogon space
+func hasNonPublic(ips []string) bool {
+	for _, s := range ips {
+		if ip, ok := ipclass.Parse(s); ok && ipclass.Classify(ip) != ipclass.Public {
+			return true
+		}
+	}
+	return false
+}
+
+// answerServers returns the servers of answers, comma-separated
+func answerServers(answers []ResolverAnswer) string {
+	names := make([]string, len(answers))
+	for i, a := range answers {
+		names[i] = a.Server
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,87 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+func TestJudgeAnswers(t *testing.T) {
+	ok := func(server string, ips ...string) ResolverAnswer {
+		return ResolverAnswer{Server: server, Status: models.StatusOK, IPs: ips}
+	}
+	status := func(server string, status models.ResultStatus) ResolverAnswer {
+		return ResolverAnswer{Server: server, Status: status}
+	}
+
+	tests := []struct {
+		name    string
+		answers []ResolverAnswer
+		verdict string
+		reason  string
+	}{
+		{"identical", []ResolverAnswer{ok("a", "20.1.1.1", "20.1.1.2"), ok("b", "20.1.1.1", "20.1.1.2")}, VerdictConsistent, ""},
+		{"all nxdomain", []ResolverAnswer{status("a", models.StatusNXDomain), status("b", models.StatusNXDomain)}, VerdictConsistent, ""},
+		{"timeouts ignored", []ResolverAnswer{ok("a", "20.1.1.1"), status("b", models.StatusTimeout), ok("c", "20.1.1.1")}, VerdictConsistent, ""},
+		{"one answer", []ResolverAnswer{ok("a", "20.1.1.1"), status("b", models.StatusServFail)}, VerdictInconclusive, "fewer than two"},
+		{"same network", []ResolverAnswer{ok("a", "20.1.1.1"), ok("b", "20.1.1.77")}, VerdictGeoDNS, "same /24"},
+		{"overlapping networks", []ResolverAnswer{ok("a", "20.1.1.1", "31.2.2.2"), ok("b", "31.2.2.9", "45.3.3.3")}, VerdictGeoDNS, ""},
+		{"different networks", []ResolverAnswer{ok("a", "20.1.1.1"), ok("b", "20.1.1.1"), ok("c", "45.3.3.3")}, VerdictSuspicious, "a and c return addresses in different networks"},
+		{"denied by some", []ResolverAnswer{ok("a", "20.1.1.1"), status("b", models.StatusNXDomain)}, VerdictSuspicious, "NXDOMAIN from b, addresses from a"},
+		{"private from one", []ResolverAnswer{ok("a", "20.1.1.1"), ok("b", "10.0.0.1")}, VerdictSuspicious, "non-public addresses from b only"},
+		{"private from all", []ResolverAnswer{ok("a", "10.0.0.1"), ok("b", "10.0.0.1")}, VerdictConsistent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, reason := JudgeAnswers(tt.answers, 24)
+			if verdict != tt.verdict || !strings.Contains(reason, tt.reason) {
+				t.Errorf("JudgeAnswers = %s (%s), want %s containing %q", verdict, reason, tt.verdict, tt.reason)
+			}
+		})
+	}
+}
+
+// addressHandler answers every A query with ip
+func addressHandler(ip string) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(r)
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP(ip),
+		})
+		w.WriteMsg(resp)
+	}
+}
+
+func TestCrossCheck(t *testing.T) {
+	honest := startTestResolver(t, addressHandler("20.1.1.1"))
+	tampered := startTestResolver(t, addressHandler("45.3.3.3"))
+	scanner := NewScanner(&models.ScanConfig{QueryDelay: time.Millisecond, Concurrency: 1})
+
+	fqdns := []string{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "ims.mnc001.mcc262.pub.3gppnetwork.org"}
+	results := scanner.CrossCheck(context.Background(), []string{honest, tampered}, fqdns, 2, 24)
+	if len(results) != len(fqdns) {
+		t.Fatalf("got %d results, want %d", len(results), len(fqdns))
+	}
+	for i, r := range results {
+		if r.FQDN != fqdns[i] || r.Verdict != VerdictSuspicious {
+			t.Errorf("result %d = %s %s, want %s suspicious", i, r.FQDN, r.Verdict, fqdns[i])
+		}
+		if len(r.Answers) != 2 || r.Answers[0].IPs[0] != "20.1.1.1" || r.Answers[1].IPs[0] != "45.3.3.3" {
+			t.Errorf("answers = %+v", r.Answers)
+		}
+	}
+
+	results = scanner.CrossCheck(context.Background(), []string{honest, honest}, fqdns[:1], 1, 24)
+	if len(results) != 1 || results[0].Verdict != VerdictConsistent {
+		t.Errorf("same resolver twice = %+v, want consistent", results)
+	}
+}
@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// assumedQueryLatency is a conservative average round-trip for a query
+// (most 3GPP FQDNs are NXDOMAIN and answer quickly)
+const assumedQueryLatency = 150 * time.Millisecond
+
+// Estimate summarizes the expected cost of a scan before it starts
+type Estimate struct {
+	TotalQueries int
+	Duration     time.Duration
+	// Bottleneck describes what bounds the duration (delay, concurrency or an MCC limit)
+	Bottleneck string
+}
+
+// EstimateScan computes the query budget and expected wall-clock time of a
+// scan. skip is the number of queries already completed (e.g. from a checkpoint).
+func EstimateScan(config *models.ScanConfig, entries []models.MCCMNCEntry, skip int) Estimate {
+	total := len(entries)*len(config.Subdomains) - skip
+	if total < 0 {
+		total = 0
+	}
+	est := Estimate{TotalQueries: total}
+
+	// Global rate limit: one query per QueryDelay
+	est.Duration = time.Duration(total) * config.QueryDelay
+	est.Bottleneck = fmt.Sprintf("--delay=%dms", config.QueryDelay.Milliseconds())
+
+	// Worker pool bound
+	if config.Concurrency > 0 {
+		byWorkers := time.Duration(total) * assumedQueryLatency / time.Duration(config.Concurrency)
+		if byWorkers > est.Duration {
+			est.Duration = byWorkers
+			est.Bottleneck = fmt.Sprintf("--concurrency=%d", config.Concurrency)
+		}
+	}
+
+	// Per-country limits: each limited MCC runs no faster than its cap
+	if len(config.MCCRateLimits) > 0 {
+		perMCC := make(map[string]int)
+		for _, entry := range entries {
+			mcc, _ := strconv.Atoi(entry.MCC)
+			perMCC[fmt.Sprintf("%03d", mcc)] += len(config.Subdomains)
+		}
+		for mcc, qps := range config.MCCRateLimits {
+			if qps <= 0 {
+				continue
+			}
+			byMCC := time.Duration(float64(perMCC[mcc]) / qps * float64(time.Second))
+			if byMCC > est.Duration {
+				est.Duration = byMCC
+				est.Bottleneck = fmt.Sprintf("MCC %s limit of %.2f qps", mcc, qps)
+			}
+		}
+	}
+
+	return est
+}
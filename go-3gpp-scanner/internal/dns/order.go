@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"fmt"
+	"iter"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Scan job orders
+const (
+	OrderList       = "list"        // MCC-MNC list order, all subdomains of an operator in a row
+	OrderShuffle    = "shuffle"     // Random, reproducible with the same seed
+	OrderByMCC      = "by-mcc"      // One query per country in turn
+	OrderByOperator = "by-operator" // One query per operator zone in turn
+)
+
+// Orders lists the valid job orders
+var Orders = []string{OrderList, OrderShuffle, OrderByMCC, OrderByOperator}
+
+// ValidateOrder checks a job order name; empty selects OrderList
+func ValidateOrder(order string) error {
+	if order == "" {
+		return nil
+	}
+	for _, o := range Orders {
+		if o == order {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid order: %s (must be one of %s)", order, strings.Join(Orders, ", "))
+}
+
+// orderJobs yields the jobs for every subdomain of every entry in the given
+// order, one at a time, so a scan never holds all of them. The grouped
+// orders alternate between groups so consecutive queries go to different
+// authoritative servers; groups of countries follow ascending MCC, operator
+// zones the list order. A shuffle only keeps a permutation of job indexes.
+func orderJobs(entries []models.MCCMNCEntry, subdomains []string, order string, seed int64) iter.Seq[job] {
+	n := len(subdomains)
+	switch order {
+	case OrderShuffle:
+		perm := make([]int32, len(entries)*n)
+		for i := range perm {
+			perm[i] = int32(i)
+		}
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(perm), func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+		return func(yield func(job) bool) {
+			for _, i := range perm {
+				if !yield(job{entry: entries[int(i)/n], subdomain: subdomains[int(i)%n]}) {
+					return
+				}
+			}
+		}
+	case OrderByMCC:
+		groups := groupEntries(entries, func(e models.MCCMNCEntry) string { return e.MCC })
+		sort.SliceStable(groups, func(a, b int) bool {
+			mccA, _ := strconv.Atoi(groups[a][0].MCC)
+			mccB, _ := strconv.Atoi(groups[b][0].MCC)
+			return mccA < mccB
+		})
+		return interleave(groups, subdomains)
+	case OrderByOperator:
+		return interleave(groupEntries(entries, func(e models.MCCMNCEntry) string { return e.MCC + "-" + e.MNC }), subdomains)
+	}
+	return func(yield func(job) bool) {
+		for _, entry := range entries {
+			for _, subdomain := range subdomains {
+				if !yield(job{entry: entry, subdomain: subdomain}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// groupEntries splits entries by key, keeping the order of first
+// appearance and the order within each group
+func groupEntries(entries []models.MCCMNCEntry, key func(models.MCCMNCEntry) string) [][]models.MCCMNCEntry {
+	index := make(map[string]int)
+	var groups [][]models.MCCMNCEntry
+	for _, e := range entries {
+		k := key(e)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+// interleave yields one job from each group in turn until all are used.
+// The jobs of a group are its entries with all subdomains each.
+func interleave(groups [][]models.MCCMNCEntry, subdomains []string) iter.Seq[job] {
+	n := len(subdomains)
+	return func(yield func(job) bool) {
+		for round, left := 0, true; left; round++ {
+			left = false
+			for _, g := range groups {
+				if round < len(g)*n {
+					left = true
+					if !yield(job{entry: g[round/n], subdomain: subdomains[round%n]}) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"iter"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+// orderTestSubdomains are the subdomains of every test entry
+var orderTestSubdomains = []string{"ims", "epdg.epc"}
+
+// orderTestEntries builds entries from "MCC-MNC" strings
+func orderTestEntries(entries ...string) []models.MCCMNCEntry {
+	var list []models.MCCMNCEntry
+	for _, e := range entries {
+		mcc, mnc, _ := strings.Cut(e, "-")
+		list = append(list, models.MCCMNCEntry{MCC: mcc, MNC: mnc})
+	}
+	return list
+}
+
+// jobKeys renders the jobs of an order as "MCC-MNC/subdomain"
+func jobKeys(jobs iter.Seq[job]) []string {
+	var keys []string
+	for j := range jobs {
+		keys = append(keys, j.entry.MCC+"-"+j.entry.MNC+"/"+j.subdomain)
+	}
+	return keys
+}
+
+func TestOrderJobs(t *testing.T) {
+	entries := []string{"310-260", "310-410", "262-01", "262-02", "440-10"}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{OrderList, []string{
+			"310-260/ims", "310-260/epdg.epc", "310-410/ims", "310-410/epdg.epc",
+			"262-01/ims", "262-01/epdg.epc", "262-02/ims", "262-02/epdg.epc",
+			"440-10/ims", "440-10/epdg.epc",
+		}},
+		{OrderByMCC, []string{
+			"262-01/ims", "310-260/ims", "440-10/ims",
+			"262-01/epdg.epc", "310-260/epdg.epc", "440-10/epdg.epc",
+			"262-02/ims", "310-410/ims",
+			"262-02/epdg.epc", "310-410/epdg.epc",
+		}},
+		{OrderByOperator, []string{
+			"310-260/ims", "310-410/ims", "262-01/ims", "262-02/ims", "440-10/ims",
+			"310-260/epdg.epc", "310-410/epdg.epc", "262-01/epdg.epc", "262-02/epdg.epc", "440-10/epdg.epc",
+		}},
+	}
+	for _, tt := range tests {
+		got := jobKeys(orderJobs(orderTestEntries(entries...), orderTestSubdomains, tt.order, 0))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestOrderJobsShuffle(t *testing.T) {
+	var entries []string
+	for mnc := 0; mnc < 20; mnc++ {
+		entries = append(entries, "310-"+strings.Repeat("1", mnc+1))
+	}
+	list := jobKeys(orderJobs(orderTestEntries(entries...), orderTestSubdomains, OrderList, 0))
+
+	first := jobKeys(orderJobs(orderTestEntries(entries...), orderTestSubdomains, OrderShuffle, 42))
+	again := jobKeys(orderJobs(orderTestEntries(entries...), orderTestSubdomains, OrderShuffle, 42))
+	other := jobKeys(orderJobs(orderTestEntries(entries...), orderTestSubdomains, OrderShuffle, 43))
+
+	if !reflect.DeepEqual(first, again) {
+		t.Error("the same seed gave different orders")
+	}
+	if reflect.DeepEqual(first, other) || reflect.DeepEqual(first, list) {
+		t.Error("shuffle did not change the order")
+	}
+	sorted := append([]string{}, first...)
+	want := append([]string{}, list...)
+	sort.Strings(sorted)
+	sort.Strings(want)
+	if !reflect.DeepEqual(sorted, want) {
+		t.Error("shuffle lost or duplicated jobs")
+	}
+}
+
+func TestOrderJobsStopEarly(t *testing.T) {
+	entries := orderTestEntries("310-260", "262-01", "262-02")
+	for _, order := range Orders {
+		var got []job
+		for j := range orderJobs(entries, orderTestSubdomains, order, 1) {
+			got = append(got, j)
+			if len(got) == 3 {
+				break
+			}
+		}
+		if len(got) != 3 {
+			t.Errorf("%s: got %d jobs before break, want 3", order, len(got))
+		}
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	for _, order := range append([]string{""}, Orders...) {
+		if err := ValidateOrder(order); err != nil {
+			t.Errorf("ValidateOrder(%q): %v", order, err)
+		}
+	}
+	if err := ValidateOrder("random"); err == nil {
+		t.Error("expected error for unknown order")
+	}
+}
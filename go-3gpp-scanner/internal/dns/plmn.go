@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// plmnPattern matches a PLMN ID: a three-digit MCC and a two- or
+// three-digit MNC, optionally separated by "-"
+var plmnPattern = regexp.MustCompile(`^(\d{3})-?(\d{2,3})$`)
+
+// PLMN identifies one operator network by MCC and MNC, keeping the MNC
+// digits as given
+type PLMN struct {
+	MCC string
+	MNC string
+}
+
+// ParsePLMN parses a PLMN ID such as 26201, 310260 or 262-01. Without a
+// separator, five digits are an MCC with a two-digit MNC and six digits
+// an MCC with a three-digit MNC.
+func ParsePLMN(s string) (PLMN, error) {
+	m := plmnPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return PLMN{}, fmt.Errorf("invalid PLMN: %s (must be MCC and MNC, e.g. 26201, 310260 or 262-01)", s)
+	}
+	return PLMN{MCC: m[1], MNC: m[2]}, nil
+}
+
+// String renders the PLMN as "MCC-MNC", e.g. "262-01"
+func (p PLMN) String() string {
+	return p.MCC + "-" + p.MNC
+}
+
+// SelectPLMNs returns one MCC-MNC entry per PLMN, taken from entries so
+// the operator and country are known. MNCs match digit for digit, so 01
+// and 001 select different entries. A PLMN missing from the list gets a
+// bare entry, so any network can be scanned without editing the list.
+func SelectPLMNs(entries []models.MCCMNCEntry, plmns []PLMN) []models.MCCMNCEntry {
+	byPLMN := make(map[PLMN]models.MCCMNCEntry, len(entries))
+	for _, entry := range entries {
+		p := PLMN{MCC: models.NormalizeMCC(entry.MCC), MNC: models.NormalizeMNC(entry.MNC)}
+		if _, ok := byPLMN[p]; !ok {
+			byPLMN[p] = entry
+		}
+	}
+
+	selected := make([]models.MCCMNCEntry, 0, len(plmns))
+	seen := make(map[PLMN]bool, len(plmns))
+	for _, p := range plmns {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		entry, ok := byPLMN[p]
+		if !ok {
+			entry = models.MCCMNCEntry{MCC: p.MCC, MNC: p.MNC}
+		}
+		selected = append(selected, entry)
+	}
+	return selected
+}
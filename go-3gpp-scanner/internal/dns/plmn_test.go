@@ -0,0 +1,47 @@
+package dns
+
+import (
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestParsePLMN(t *testing.T) {
+	tests := map[string]PLMN{
+		"26201":   {MCC: "262", MNC: "01"},
+		"310260":  {MCC: "310", MNC: "260"},
+		"262-01":  {MCC: "262", MNC: "01"},
+		" 234015": {MCC: "234", MNC: "015"},
+	}
+	for s, want := range tests {
+		if got, err := ParsePLMN(s); err != nil || got != want {
+			t.Errorf("ParsePLMN(%q) = %+v, %v, want %+v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "2620", "2620100", "262-1", "abcde", "262_01"} {
+		if _, err := ParsePLMN(s); err == nil {
+			t.Errorf("ParsePLMN(%q): expected error", s)
+		}
+	}
+}
+
+func TestSelectPLMNs(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom Deutschland", CountryName: "Germany"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone"},
+		{MCC: "310", MNC: "260", Operator: "T-Mobile US"},
+	}
+	plmns := []PLMN{{MCC: "310", MNC: "260"}, {MCC: "262", MNC: "01"}, {MCC: "262", MNC: "01"}, {MCC: "262", MNC: "001"}}
+
+	selected := SelectPLMNs(entries, plmns)
+	if len(selected) != 3 {
+		t.Fatalf("SelectPLMNs = %+v, want 3 entries", selected)
+	}
+	if selected[0].Operator != "T-Mobile US" || selected[1].Operator != "Telekom Deutschland" {
+		t.Errorf("listed PLMNs = %+v", selected[:2])
+	}
+	// 262-001 is not 262-01, so it is not in the list
+	if selected[2] != (models.MCCMNCEntry{MCC: "262", MNC: "001"}) {
+		t.Errorf("unlisted PLMN = %+v, want a bare entry", selected[2])
+	}
+}
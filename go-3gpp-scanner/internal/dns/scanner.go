@@ -2,13 +2,20 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"3gpp-scanner/internal/exclude"
 	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/schedule"
 
 	"github.com/miekg/dns"
 	"golang.org/x/time/rate"
@@ -18,8 +25,41 @@ import (
 type Scanner struct {
 	config       *models.ScanConfig
 	rateLimiter  *rate.Limiter
+	mccLimiters  map[string]*rate.Limiter
 	dnsClient    *dns.Client
+	binding      netbind.Binding
 	progressFunc func(current, total int, found int)
+	resultFunc   func(result models.DNSResult)
+	retryFunc    func(queries, concurrency int)
+	statusCounts map[models.ResultStatus]int
+	failures     []models.DNSResult
+	retried      int
+	recovered    int
+	timings      *timings
+
+	// Optional off-peak scheduling and resumable state
+	runWindow  *schedule.Window
+	pauseFunc  func(resumeAt time.Time)
+	windowMux  sync.Mutex
+	checkpoint *Checkpoint
+
+	// Optional names that must never be queried
+	exclude exclude.Checker
+
+	// Optional negative cache of nonexistent operator zones
+	zoneCache  *ZoneCache
+	cacheSkips atomic.Int64
+	zoneChecks atomic.Int64
+}
+
+// queryTimeout bounds each query to a resolver
+const queryTimeout = 5 * time.Second
+
+// DefaultServers are the recursive resolvers queried, in order
+var DefaultServers = []string{
+	"8.8.8.8:53",        // Google DNS
+	"1.1.1.1:53",        // Cloudflare DNS
+	"208.67.222.222:53", // OpenDNS
 }
 
 // job represents a DNS resolution task
@@ -28,6 +68,22 @@ type job struct {
 	subdomain string
 }
 
+// outcome is what a worker reports to the collector for one query
+type outcome struct {
+	result *models.DNSResult
+	status models.ResultStatus
+	err    error // Why the query failed, when result is nil
+	job    job
+	retry  bool // A transient failure to query again at the end
+}
+
+// errZoneMissing is the error of queries skipped by the negative cache
+var errZoneMissing = errors.New("zone does not exist (negative cache)")
+
+// retryConcurrencyDivisor lowers the concurrency of the retry pass, as
+// timeouts and SERVFAIL often mean a resolver or server is overloaded
+const retryConcurrencyDivisor = 4
+
 // NewScanner creates a new DNS scanner
 func NewScanner(config *models.ScanConfig) *Scanner {
 	// Calculate rate limit: delay between queries
@@ -35,79 +91,307 @@ func NewScanner(config *models.ScanConfig) *Scanner {
 	limiter := rate.NewLimiter(rate.Limit(qps), 1)
 
 	client := &dns.Client{
-		Timeout: 5 * time.Second,
+		Timeout: queryTimeout,
+	}
+
+	// Per-country limiters layered on top of the global rate
+	mccLimiters := make(map[string]*rate.Limiter, len(config.MCCRateLimits))
+	for mcc, maxQPS := range config.MCCRateLimits {
+		mccLimiters[mcc] = rate.NewLimiter(rate.Limit(maxQPS), 1)
 	}
 
 	return &Scanner{
 		config:      config,
 		rateLimiter: limiter,
+		mccLimiters: mccLimiters,
 		dnsClient:   client,
+		binding:     netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		timings:     newTimings(),
 	}
 }
 
-// SetProgressCallback sets a callback function for progress updates
+// SetProgressCallback sets a callback function for progress updates; total
+// is -1 while scanning a source of unknown length
 func (s *Scanner) SetProgressCallback(callback func(current, total int, found int)) {
 	s.progressFunc = callback
 }
 
-// Scan performs DNS scanning for all MCC-MNC combinations
+// SetResultCallback sets a function called with each result as it is
+// found, from a single goroutine
+func (s *Scanner) SetResultCallback(callback func(result models.DNSResult)) {
+	s.resultFunc = callback
+}
+
+// SetRetryCallback sets a function called before the retry pass with the
+// number of queries retried and the pass's concurrency. The progress
+// callback then counts the retry pass from zero.
+func (s *Scanner) SetRetryCallback(callback func(queries, concurrency int)) {
+	s.retryFunc = callback
+}
+
+// SetRunWindow restricts scanning to a daily time window. Workers pause
+// outside the window; onPause (optional) is called once per pause.
+func (s *Scanner) SetRunWindow(window *schedule.Window, onPause func(resumeAt time.Time)) {
+	s.runWindow = window
+	s.pauseFunc = onPause
+}
+
+// SetCheckpoint enables resumable scanning: completed jobs are recorded
+// to the checkpoint and skipped on subsequent runs
+func (s *Scanner) SetCheckpoint(cp *Checkpoint) {
+	s.checkpoint = cp
+}
+
+// SetExclude skips FQDNs on the exclusion list; they are counted as
+// StatusExcluded and never queried. Resolver addresses are not checked.
+func (s *Scanner) SetExclude(list exclude.Checker) {
+	s.exclude = list
+}
+
+// SetZoneCache enables the negative cache: when a subdomain query returns
+// NXDOMAIN, the zone apex is queried once, and if the zone itself does
+// not exist (NXDOMAIN at the apex means nothing exists below it, RFC 8020)
+// its remaining subdomains are counted as NXDOMAIN without being queried.
+func (s *Scanner) SetZoneCache(cache *ZoneCache) {
+	s.zoneCache = cache
+}
+
+// RetryStats returns how many queries the last scan retried after a
+// timeout or SERVFAIL, and how many of them got a definitive answer
+func (s *Scanner) RetryStats() (retried, recovered int) {
+	return s.retried, s.recovered
+}
+
+// CacheStats returns how many queries the negative cache saved and how
+// many zone apex queries it sent
+func (s *Scanner) CacheStats() (skipped, checks int) {
+	return int(s.cacheSkips.Load()), int(s.zoneChecks.Load())
+}
+
+// Scan performs DNS scanning for all MCC-MNC combinations, in the job
+// order of the config
 func (s *Scanner) Scan(ctx context.Context, entries []models.MCCMNCEntry) ([]models.DNSResult, error) {
+	return s.ScanTargets(ctx, ListSource{
+		Entries:    entries,
+		Subdomains: s.config.Subdomains,
+		Order:      s.config.Order,
+		Seed:       s.config.Seed,
+	})
+}
+
+// ScanTargets queries the targets of a source. Queries that time out or
+// fail with SERVFAIL are retried once at the end, with a quarter of the
+// concurrency, unless the config disables it. If the source fails, the
+// results so far are returned with the error.
+func (s *Scanner) ScanTargets(ctx context.Context, source TargetSource) ([]models.DNSResult, error) {
 	results := make([]models.DNSResult, 0)
-	resultsMux := &sync.Mutex{}
 
-	// Create work queue
-	totalJobs := len(entries) * len(s.config.Subdomains)
-	jobs := make(chan job, totalJobs)
+	// Resume from a previous run
+	if s.checkpoint != nil {
+		results = append(results, s.checkpoint.Results()...)
+	}
+
+	var found atomic.Int64
+	found.Store(int64(len(results)))
+	s.timings = newTimings()
+
+	// The collector of each pass owns the results, status counts and
+	// retry queue; workers only send
+	statusCounts := make(map[models.ResultStatus]int)
+	var retries []outcome
+	retrying := false
+	s.retried, s.recovered = 0, 0
+	s.failures = nil
+	count := func(o outcome) {
+		statusCounts[o.status]++
+		if o.result == nil && s.config.IncludeFailures {
+			s.failures = append(s.failures, s.failedResult(o))
+		}
+	}
+	collect := func(o outcome) {
+		if o.retry {
+			retries = append(retries, o)
+			return
+		}
+		count(o)
+		if retrying && !IsTransient(o.status) {
+			s.recovered++
+		}
+		if o.result != nil {
+			results = append(results, *o.result)
+			if s.resultFunc != nil {
+				s.resultFunc(*o.result)
+			}
+		}
+	}
+
+	sourceErr := s.runPass(ctx, source, s.config.Concurrency, !s.config.NoRetry, &found, collect)
+
+	// Retry transient failures once, more gently; if the scan was
+	// interrupted, count them as they are
+	if len(retries) > 0 && ctx.Err() == nil {
+		queue := make(targetList, len(retries))
+		for i, o := range retries {
+			queue[i] = Target{Entry: o.job.entry, Subdomain: o.job.subdomain}
+		}
+		concurrency := max(1, s.config.Concurrency/retryConcurrencyDivisor)
+		if s.retryFunc != nil {
+			s.retryFunc(len(queue), concurrency)
+		}
+		s.retried = len(queue)
+		retrying = true
+		s.runPass(ctx, queue, concurrency, false, &found, collect)
+	} else {
+		for _, o := range retries {
+			count(o)
+		}
+	}
+	s.statusCounts = statusCounts
+
+	return results, sourceErr
+}
+
+// targetList is a TargetSource of fixed targets
+type targetList []Target
 
-	// Fill job queue
-	for _, entry := range entries {
-		for _, subdomain := range s.config.Subdomains {
-			jobs <- job{entry: entry, subdomain: subdomain}
+func (l targetList) Targets(yield func(Target) bool) error {
+	for _, t := range l {
+		if !yield(t) {
+			break
 		}
 	}
-	close(jobs)
+	return nil
+}
 
-	// Progress tracking
-	var processed, found atomic.Int64
+func (l targetList) Len() int { return len(l) }
+
+// runPass runs workers over the targets of a source and hands their
+// outcomes to collect from a single goroutine. With deferTransient,
+// timeouts and SERVFAIL are handed back for a retry instead of counted.
+// It returns the error of the source, unless the scan was cancelled.
+func (s *Scanner) runPass(ctx context.Context, source TargetSource, concurrency int, deferTransient bool, found *atomic.Int64, collect func(outcome)) error {
+	// A generator feeds the workers through a small buffer, so targets
+	// are only produced as fast as they are queried
+	jobs := make(chan job, concurrency)
+	var sourceErr error
+	go func() {
+		defer close(jobs)
+		sourceErr = source.Targets(func(t Target) bool {
+			select {
+			case jobs <- job{entry: t.Entry, subdomain: t.Subdomain}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	out := make(chan outcome, concurrency)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for o := range out {
+			collect(o)
+		}
+	}()
 
-	// Start workers
+	var processed atomic.Int64
 	var wg sync.WaitGroup
-	for i := 0; i < s.config.Concurrency; i++ {
+	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			s.worker(ctx, jobs, &results, resultsMux, &processed, &found, totalJobs)
+			s.worker(ctx, jobs, out, deferTransient, &processed, found, source.Len())
 		}()
 	}
 
 	wg.Wait()
+	close(out)
+	<-collected
 
-	return results, nil
+	// Workers only stop early when cancelled; the generator may then
+	// still be blocked reading its source
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sourceErr
 }
 
 // worker processes DNS resolution jobs
-func (s *Scanner) worker(ctx context.Context, jobs <-chan job, results *[]models.DNSResult, mux *sync.Mutex, processed, found *atomic.Int64, totalJobs int) {
+func (s *Scanner) worker(ctx context.Context, jobs <-chan job, out chan<- outcome, deferTransient bool, processed, found *atomic.Int64, totalJobs int) {
 	for j := range jobs {
 		select {
 		case <-ctx.Done():
 			return
 		default:
+			fqdn := s.jobFQDN(j)
+			if s.checkpoint != nil && s.checkpoint.Done(fqdn) {
+				current := int(processed.Add(1))
+				if s.progressFunc != nil {
+					s.progressFunc(current, totalJobs, int(found.Load()))
+				}
+				continue
+			}
+
+			if s.exclude != nil {
+				if err := s.exclude.CheckName(fqdn); err != nil {
+					out <- outcome{status: models.StatusExcluded, err: err, job: j}
+					current := int(processed.Add(1))
+					if s.progressFunc != nil {
+						s.progressFunc(current, totalJobs, int(found.Load()))
+					}
+					continue
+				}
+			}
+
+			if s.zoneMissing(j) {
+				if s.checkpoint != nil {
+					if err := s.checkpoint.Record(fqdn, nil); err != nil {
+						slog.Warn("failed to record checkpoint", "fqdn", fqdn, "error", err)
+					}
+				}
+				s.cacheSkips.Add(1)
+				out <- outcome{status: models.StatusNXDomain, err: errZoneMissing, job: j}
+				current := int(processed.Add(1))
+				if s.progressFunc != nil {
+					s.progressFunc(current, totalJobs, int(found.Load()))
+				}
+				continue
+			}
+
+			if err := s.waitForWindow(ctx); err != nil {
+				return
+			}
+
 			// Rate limiting
+			if err := s.waitForMCC(ctx, j.entry.MCC); err != nil {
+				return
+			}
 			if err := s.rateLimiter.Wait(ctx); err != nil {
 				return
 			}
 
-			result := s.resolveFQDN(j.entry, j.subdomain)
+			queryStart := time.Now()
+			result, status, err := s.resolveFQDN(ctx, j.entry, j.subdomain)
+			s.timings.addZone(s.jobZone(j), time.Since(queryStart), isFailure(status))
+			if status == models.StatusNXDomain {
+				s.checkZone(ctx, j.entry.MCC, s.jobZone(j))
+			}
+			if deferTransient && IsTransient(status) {
+				// Not checkpointed, so an interrupted scan queries it again
+				out <- outcome{status: status, err: err, job: j, retry: true}
+			} else {
+				if s.checkpoint != nil {
+					if err := s.checkpoint.Record(fqdn, result); err != nil {
+						slog.Warn("failed to record checkpoint", "fqdn", fqdn, "error", err)
+					}
+				}
+				out <- outcome{result: result, status: status, err: err, job: j}
+			}
 			if result != nil {
-				mux.Lock()
-				*results = append(*results, *result)
-				mux.Unlock()
-
 				found.Add(1)
 
-				if s.config.Verbose {
-					fmt.Printf("Found A record for %s (%s IPs)\n", result.FQDN, formatIPCount(len(result.IPs)))
-				}
+				slog.Debug("found A record", "fqdn", result.FQDN, "ips", len(result.IPs))
 			}
 
 			// Update progress
@@ -119,76 +403,330 @@ func (s *Scanner) worker(ctx context.Context, jobs <-chan job, results *[]models
 	}
 }
 
-// resolveFQDN resolves a single FQDN
-func (s *Scanner) resolveFQDN(entry models.MCCMNCEntry, subdomain string) *models.DNSResult {
-	mcc, _ := strconv.Atoi(entry.MCC)
-	mnc, _ := strconv.Atoi(entry.MNC)
+// waitForWindow blocks while the current time is outside the run window
+func (s *Scanner) waitForWindow(ctx context.Context) error {
+	if s.runWindow == nil {
+		return nil
+	}
+
+	// Serialize so only one worker reports each pause
+	s.windowMux.Lock()
+	defer s.windowMux.Unlock()
+
+	now := time.Now()
+	if s.runWindow.Contains(now) {
+		return nil
+	}
+
+	resumeAt := s.runWindow.NextStart(now)
+	if s.pauseFunc != nil {
+		s.pauseFunc(resumeAt)
+	}
+
+	timer := time.NewTimer(time.Until(resumeAt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// jobFQDN returns the FQDN a job will query
+func (s *Scanner) jobFQDN(j job) string {
+	return BuildFQDN(j.subdomain, j.entry.MNC, j.entry.MCC, s.config.ParentDomain)
+}
+
+// jobZone returns the operator zone of a job
+func (s *Scanner) jobZone(j job) string {
+	return ZoneName(j.entry.MNC, j.entry.MCC, s.config.ParentDomain)
+}
+
+// zoneMissing reports whether the negative cache knows the job's zone
+// does not exist
+func (s *Scanner) zoneMissing(j job) bool {
+	if s.zoneCache == nil {
+		return false
+	}
+	exists, known := s.zoneCache.Exists(s.jobZone(j))
+	return known && !exists
+}
+
+// checkZone queries the apex of a zone, unless it is already known or
+// being checked, and records whether the zone exists
+func (s *Scanner) checkZone(ctx context.Context, mcc, zone string) {
+	if s.zoneCache == nil || !s.zoneCache.claim(zone) {
+		return
+	}
+
+	if err := s.waitForMCC(ctx, mcc); err != nil {
+		s.zoneCache.abandon(zone)
+		return
+	}
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		s.zoneCache.abandon(zone)
+		return
+	}
 
-	fqdn := fmt.Sprintf("%s.mnc%03d.mcc%03d.%s", subdomain, mnc, mcc, s.config.ParentDomain)
+	s.zoneChecks.Add(1)
+	resp, err := s.exchange(zone, dns.TypeSOA)
+	if err != nil {
+		slog.Debug("zone check failed", "zone", zone, "error", err)
+		s.zoneCache.abandon(zone)
+		return
+	}
+	exists := resp.Rcode != dns.RcodeNameError
+	s.zoneCache.set(zone, exists)
+	if !exists {
+		slog.Debug("zone does not exist", "zone", zone)
+	}
+}
 
-	ips, err := s.resolveA(fqdn)
-	if err != nil || len(ips) == 0 {
+// waitForMCC blocks until the per-country limiter for an MCC allows a query
+func (s *Scanner) waitForMCC(ctx context.Context, mccStr string) error {
+	if len(s.mccLimiters) == 0 {
+		return nil
+	}
+	mcc, _ := strconv.Atoi(mccStr)
+	limiter, ok := s.mccLimiters[fmt.Sprintf("%03d", mcc)]
+	if !ok {
 		return nil
 	}
+	return limiter.Wait(ctx)
+}
+
+// Failures returns the queries of the last scan that found no addresses,
+// with their status and error, when the config includes failures. Jobs
+// restored from a checkpoint are not included.
+func (s *Scanner) Failures() []models.DNSResult {
+	return append([]models.DNSResult(nil), s.failures...)
+}
+
+// failedResult describes the query of an outcome without a result
+func (s *Scanner) failedResult(o outcome) models.DNSResult {
+	result := s.newResult(o.job.entry, o.job.subdomain)
+	result.Status = o.status
+	if o.err != nil {
+		result.Error = o.err.Error()
+	}
+	return result
+}
+
+// StatusCounts returns how many queries of the last Scan ended in each
+// status. Results restored from a checkpoint are not included.
+func (s *Scanner) StatusCounts() map[models.ResultStatus]int {
+	counts := make(map[models.ResultStatus]int, len(s.statusCounts))
+	for status, n := range s.statusCounts {
+		counts[status] = n
+	}
+	return counts
+}
+
+// resolveFQDN resolves a single FQDN. The result is nil unless the name
+// has A records; the status classifies the outcome either way, and the
+// error says what went wrong.
+func (s *Scanner) resolveFQDN(ctx context.Context, entry models.MCCMNCEntry, subdomain string) (*models.DNSResult, models.ResultStatus, error) {
+	result := s.newResult(entry, subdomain)
 
-	return &models.DNSResult{
-		FQDN:      fqdn,
-		IPs:       ips,
+	ips, cnames, status, err := s.resolveA(ctx, result.FQDN)
+	if status != models.StatusOK {
+		return nil, status, err
+	}
+
+	result.IPs = ips
+	result.CNAMEs = cnames
+	result.Status = models.StatusOK
+	return &result, models.StatusOK, nil
+}
+
+// newResult returns the result of querying a subdomain of an entry,
+// without an outcome yet
+func (s *Scanner) newResult(entry models.MCCMNCEntry, subdomain string) models.DNSResult {
+	return models.DNSResult{
+		FQDN:      BuildFQDN(subdomain, entry.MNC, entry.MCC, s.config.ParentDomain),
 		Subdomain: subdomain,
-		MNC:       mnc,
-		MCC:       mcc,
+		MNC:       models.NormalizeMNC(entry.MNC),
+		MCC:       models.NormalizeMCC(entry.MCC),
 		Operator:  entry.Operator,
 		Timestamp: time.Now(),
+
+		Country:        entry.CountryName,
+		CountryCode:    entry.CountryCode,
+		Brand:          entry.Brand,
+		OperatorStatus: entry.Status,
 	}
 }
 
-// resolveA performs an A record DNS query
-func (s *Scanner) resolveA(fqdn string) ([]string, error) {
+// expired reports whether ctx is done or past its deadline; an exchange
+// that timed out at the deadline can return before ctx is cancelled
+func expired(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	return ctx.Err() != nil || (ok && !time.Now().Before(deadline))
+}
+
+// resolveA performs an A record DNS query and also returns the CNAME chain
+// leading to the addresses. Resolvers are tried in turn until one returns
+// addresses or the query budget is used up; when none returns addresses,
+// the most definitive failure is reported with its error.
+func (s *Scanner) resolveA(ctx context.Context, fqdn string) ([]string, []string, models.ResultStatus, error) {
 	msg := new(dns.Msg)
 	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeA)
 	msg.RecursionDesired = true
 
-	// Try multiple DNS servers
-	servers := []string{
-		"8.8.8.8:53",        // Google DNS
-		"1.1.1.1:53",        // Cloudflare DNS
-		"208.67.222.222:53", // OpenDNS
+	if s.config.QueryBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.QueryBudget)
+		defer cancel()
+	}
+
+	// The error of the most definitive failure so far
+	status := models.StatusNetworkError
+	var lastErr error
+	fail := func(next models.ResultStatus, err error) {
+		if lastErr == nil || moreDefinitive(status, next) != status {
+			status, lastErr = moreDefinitive(status, next), err
+		}
 	}
+	for _, server := range s.servers() {
+		if expired(ctx) {
+			if s.config.QueryBudget > 0 {
+				s.timings.addOverrun()
+				slog.Debug("query budget used up", "fqdn", fqdn, "budget", s.config.QueryBudget, "next_resolver", server)
+			}
+			fail(models.StatusTimeout, fmt.Errorf("query budget used up before %s", server))
+			return nil, nil, status, lastErr
+		}
+		client, err := s.clientFor(server)
+		if err != nil {
+			fail(models.StatusNetworkError, err)
+			continue
+		}
 
-	for _, server := range servers {
-		resp, _, err := s.dnsClient.Exchange(msg, server)
+		start := time.Now()
+		resp, _, err := client.ExchangeContext(ctx, msg, server)
 		if err != nil {
+			s.timings.addResolver(server, time.Since(start), true)
+			fail(ErrorStatus(err), err)
 			continue
 		}
+		s.timings.addResolver(server, time.Since(start), isFailure(RcodeStatus(resp.Rcode)))
 
 		if resp.Rcode != dns.RcodeSuccess {
+			fail(RcodeStatus(resp.Rcode), fmt.Errorf("%s from %s", dns.RcodeToString[resp.Rcode], server))
 			continue
 		}
 
-		var ips []string
+		var ips, cnames []string
 		for _, answer := range resp.Answer {
-			if a, ok := answer.(*dns.A); ok {
-				ips = append(ips, a.A.String())
+			switch rr := answer.(type) {
+			case *dns.A:
+				ips = append(ips, rr.A.String())
+			case *dns.CNAME:
+				cnames = append(cnames, strings.TrimSuffix(rr.Target, "."))
 			}
 		}
 
 		if len(ips) > 0 {
-			return ips, nil
+			return ips, cnames, models.StatusOK, nil
+		}
+		fail(models.StatusNoAnswer, fmt.Errorf("no A records from %s", server))
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no resolvers configured")
+	}
+	return nil, nil, status, lastErr
+}
+
+// exchange sends a query to each resolver in turn and returns the first
+// authoritative NOERROR or NXDOMAIN response
+func (s *Scanner) exchange(name string, qtype uint16) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = true
+
+	var lastErr error
+	for _, server := range s.servers() {
+		client, err := s.clientFor(server)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, _, err := client.Exchange(msg, server)
+		if err != nil {
+			lastErr = err
+			continue
 		}
+
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s from %s", dns.RcodeToString[resp.Rcode], server)
+			continue
+		}
+
+		return resp, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no resolvers configured")
 	}
+	return nil, lastErr
+}
 
-	return nil, fmt.Errorf("no A records found")
+// servers returns the configured resolvers, or DefaultServers
+func (s *Scanner) servers() []string {
+	if len(s.config.Resolvers) > 0 {
+		return s.config.Resolvers
+	}
+	return DefaultServers
 }
 
-// BuildFQDN constructs a 3GPP FQDN from components
-func BuildFQDN(subdomain string, mnc, mcc int, parentDomain string) string {
-	return fmt.Sprintf("%s.mnc%03d.mcc%03d.%s", subdomain, mnc, mcc, parentDomain)
+// ParseResolvers normalizes resolver addresses to host:port, adding the
+// default DNS port where it is missing
+func ParseResolvers(list []string) ([]string, error) {
+	servers := make([]string, 0, len(list))
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(item)
+		if err != nil {
+			host, port = strings.Trim(item, "[]"), "53"
+		}
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("invalid resolver %q: must be an IP address, optionally with a port", item)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid resolver %q: bad port", item)
+		}
+		servers = append(servers, net.JoinHostPort(host, port))
+	}
+	return servers, nil
 }
 
-// formatIPCount formats IP count for display
-func formatIPCount(count int) string {
-	if count == 1 {
-		return "1 IP"
+// clientFor returns a DNS client bound to the configured source address
+// for the given server, or the shared client when no binding is set
+func (s *Scanner) clientFor(server string) (*dns.Client, error) {
+	if s.binding.IsZero() {
+		return s.dnsClient, nil
 	}
-	return fmt.Sprintf("%d IPs", count)
+
+	dialer, err := s.binding.UDPDialer(s.dnsClient.Timeout, netbind.IsIPv6Address(server))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dns.Client{
+		Net:     s.dnsClient.Net,
+		Timeout: s.dnsClient.Timeout,
+		Dialer:  dialer,
+	}, nil
+}
+
+// BuildFQDN constructs a 3GPP FQDN from components; a two-digit MNC is
+// padded to three digits as in every 3GPP domain name
+func BuildFQDN(subdomain string, mnc, mcc string, parentDomain string) string {
+	return subdomain + "." + ZoneName(mnc, mcc, parentDomain)
 }
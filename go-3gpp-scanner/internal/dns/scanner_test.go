@@ -2,10 +2,16 @@ package dns
 
 import (
 	"context"
+	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"3gpp-scanner/internal/exclude"
 	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
 )
 
 func TestNewScanner(t *testing.T) {
@@ -14,7 +20,6 @@ func TestNewScanner(t *testing.T) {
 		Subdomains:   []string{"ims", "epdg.epc"},
 		QueryDelay:   500 * time.Millisecond,
 		Concurrency:  10,
-		Verbose:      false,
 	}
 
 	scanner := NewScanner(config)
@@ -39,34 +44,40 @@ func TestNewScanner(t *testing.T) {
 func TestBuildFQDN(t *testing.T) {
 	tests := []struct {
 		subdomain string
-		mnc       int
-		mcc       int
+		mnc       string
+		mcc       string
 		expected  string
 	}{
 		{
 			subdomain: "ims",
-			mnc:       1,
-			mcc:       310,
+			mnc:       "01",
+			mcc:       "310",
 			expected:  "ims.mnc001.mcc310.pub.3gppnetwork.org",
 		},
 		{
 			subdomain: "epdg.epc",
-			mnc:       5,
-			mcc:       311,
+			mnc:       "005",
+			mcc:       "311",
 			expected:  "epdg.epc.mnc005.mcc311.pub.3gppnetwork.org",
 		},
 		{
 			subdomain: "xcap.ims",
-			mnc:       0,
-			mcc:       460,
+			mnc:       "00",
+			mcc:       "460",
 			expected:  "xcap.ims.mnc000.mcc460.pub.3gppnetwork.org",
 		},
+		{
+			subdomain: "ims",
+			mnc:       "260",
+			mcc:       "1",
+			expected:  "ims.mnc260.mcc001.pub.3gppnetwork.org",
+		},
 	}
 
 	for _, tt := range tests {
 		result := BuildFQDN(tt.subdomain, tt.mnc, tt.mcc, "pub.3gppnetwork.org")
 		if result != tt.expected {
-			t.Errorf("BuildFQDN(%s, %d, %d) = %s, expected %s",
+			t.Errorf("BuildFQDN(%s, %s, %s) = %s, expected %s",
 				tt.subdomain, tt.mnc, tt.mcc, result, tt.expected)
 		}
 	}
@@ -78,7 +89,6 @@ func TestScanWithEmptyEntries(t *testing.T) {
 		Subdomains:   []string{"ims"},
 		QueryDelay:   100 * time.Millisecond,
 		Concurrency:  1,
-		Verbose:      false,
 	}
 
 	scanner := NewScanner(config)
@@ -100,7 +110,6 @@ func TestScanContextCancellation(t *testing.T) {
 		Subdomains:   []string{"ims", "epdg.epc"},
 		QueryDelay:   100 * time.Millisecond,
 		Concurrency:  2,
-		Verbose:      false,
 	}
 
 	entries := []models.MCCMNCEntry{
@@ -136,20 +145,351 @@ func TestScanContextCancellation(t *testing.T) {
 	t.Logf("Got %d results with cancelled context", len(results))
 }
 
-func TestFormatIPCount(t *testing.T) {
+func TestScanExclude(t *testing.T) {
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		Subdomains:   []string{"ims", "epdg.epc"},
+		QueryDelay:   time.Millisecond,
+		Concurrency:  2,
+	}
+	list, err := exclude.Parse(strings.NewReader("*.mcc262.pub.3gppnetwork.org"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	scanner := NewScanner(config)
+	scanner.SetExclude(list)
+	results, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone"},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+	if counts := scanner.StatusCounts(); counts[models.StatusExcluded] != 4 || len(counts) != 1 {
+		t.Errorf("Expected 4 excluded queries and nothing else, got %v", counts)
+	}
+}
+
+// flakyHandler fails the first query for each name with SERVFAIL, and
+// every query for names containing "broken". Later queries for ims names
+// get an address, others NXDOMAIN.
+func flakyHandler() dns.HandlerFunc {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		name := r.Question[0].Name
+		mu.Lock()
+		first := !seen[name]
+		seen[name] = true
+		mu.Unlock()
+
+		resp := new(dns.Msg)
+		switch {
+		case first || strings.Contains(name, "broken"):
+			resp.SetRcode(r, dns.RcodeServerFailure)
+		case strings.HasPrefix(name, "ims."):
+			resp.SetReply(r)
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		default:
+			resp.SetRcode(r, dns.RcodeNameError)
+		}
+		w.WriteMsg(resp)
+	}
+}
+
+func TestScanRetriesTransientFailures(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone"},
+	}
+
+	for _, noRetry := range []bool{false, true} {
+		config := &models.ScanConfig{
+			ParentDomain: "pub.3gppnetwork.org",
+			Subdomains:   []string{"ims", "epdg.epc", "broken"},
+			QueryDelay:   time.Millisecond,
+			Concurrency:  4,
+			Resolvers:    []string{startTestResolver(t, flakyHandler())},
+			NoRetry:      noRetry,
+		}
+		scanner := NewScanner(config)
+		var retryQueries, retryConcurrency int
+		scanner.SetRetryCallback(func(queries, concurrency int) {
+			retryQueries, retryConcurrency = queries, concurrency
+		})
+
+		results, err := scanner.Scan(context.Background(), entries)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		counts := scanner.StatusCounts()
+		retried, recovered := scanner.RetryStats()
+
+		if noRetry {
+			if len(results) != 0 || counts[models.StatusServFail] != 6 || retried != 0 || retryQueries != 0 {
+				t.Errorf("NoRetry: got %d results, outcomes %v, %d retried", len(results), counts, retried)
+			}
+			continue
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected both ims names found on retry, got %d results", len(results))
+		}
+		if retried != 6 || recovered != 4 || retryQueries != 6 || retryConcurrency != 1 {
+			t.Errorf("Expected 6 retried (concurrency 1), 4 recovered; got %d (%d), %d, callback %d",
+				retried, retryConcurrency, recovered, retryQueries)
+		}
+		if counts[models.StatusOK] != 2 || counts[models.StatusNXDomain] != 2 || counts[models.StatusServFail] != 2 {
+			t.Errorf("Unexpected outcomes after retry: %v", counts)
+		}
+	}
+}
+
+func TestScanIncludeFailures(t *testing.T) {
+	config := &models.ScanConfig{
+		ParentDomain:    "pub.3gppnetwork.org",
+		Subdomains:      []string{"ims", "epdg.epc", "broken"},
+		QueryDelay:      time.Millisecond,
+		Concurrency:     2,
+		Resolvers:       []string{startTestResolver(t, flakyHandler())},
+		IncludeFailures: true,
+	}
+	scanner := NewScanner(config)
+	results, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{{MCC: "262", MNC: "01", Operator: "Telekom"}})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	failures := scanner.Failures()
+	byFQDN := make(map[string]models.DNSResult)
+	for _, f := range failures {
+		byFQDN[f.FQDN] = f
+	}
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %+v", failures)
+	}
+	nx := byFQDN["epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"]
+	if nx.Status != models.StatusNXDomain || !strings.Contains(nx.Error, "NXDOMAIN from") || nx.Operator != "Telekom" || nx.MNC != "01" {
+		t.Errorf("Unexpected NXDOMAIN failure: %+v", nx)
+	}
+	broken := byFQDN["broken.mnc001.mcc262.pub.3gppnetwork.org"]
+	if broken.Status != models.StatusServFail || !strings.Contains(broken.Error, "SERVFAIL from") {
+		t.Errorf("Unexpected SERVFAIL failure: %+v", broken)
+	}
+
+	// Failures are only kept on request
+	config.IncludeFailures = false
+	scanner = NewScanner(config)
+	if _, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{{MCC: "262", MNC: "02"}}); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if failures := scanner.Failures(); len(failures) != 0 {
+		t.Errorf("Expected no failures without IncludeFailures, got %d", len(failures))
+	}
+}
+
+func TestScanSkipsUnbindableResolver(t *testing.T) {
+	// An IPv4 source address cannot reach the IPv6 resolver, so the
+	// IPv4 one behind it must still be asked
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		Subdomains:   []string{"ims"},
+		QueryDelay:   time.Millisecond,
+		Concurrency:  1,
+		SourceIP:     "127.0.0.1",
+		Resolvers:    []string{"[::1]:53", startTestResolver(t, flakyHandler())},
+		NoRetry:      true,
+	}
+	scanner := NewScanner(config)
+	if _, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{{MCC: "262", MNC: "01"}}); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if counts := scanner.StatusCounts(); counts[models.StatusServFail] != 1 {
+		t.Errorf("Expected the IPv4 resolver's SERVFAIL, got %v", counts)
+	}
+
+	// The first query of a name fails with SERVFAIL, which only the
+	// IPv4 resolver can report
+	_, err := scanner.exchange("mnc001.mcc262.pub.3gppnetwork.org", dns.TypeSOA)
+	if err == nil || !strings.Contains(err.Error(), "SERVFAIL from 127.0.0.1") {
+		t.Errorf("Expected SERVFAIL from the IPv4 resolver, got %v", err)
+	}
+}
+
+func TestEstimateScan(t *testing.T) {
+	config := &models.ScanConfig{
+		Subdomains:  []string{"ims", "epdg.epc"},
+		QueryDelay:  500 * time.Millisecond,
+		Concurrency: 10,
+	}
+	entries := []models.MCCMNCEntry{
+		{MCC: "310", MNC: "001"},
+		{MCC: "310", MNC: "005"},
+		{MCC: "262", MNC: "01"},
+	}
+
+	est := EstimateScan(config, entries, 0)
+	if est.TotalQueries != 6 {
+		t.Errorf("Expected 6 queries, got %d", est.TotalQueries)
+	}
+	if est.Duration != 3*time.Second {
+		t.Errorf("Expected 3s duration, got %v", est.Duration)
+	}
+
+	// A strict per-MCC limit becomes the bottleneck
+	config.MCCRateLimits = map[string]float64{"310": 0.5}
+	est = EstimateScan(config, entries, 0)
+	if est.Duration != 8*time.Second {
+		t.Errorf("Expected 8s duration with MCC limit, got %v", est.Duration)
+	}
+
+	// Already-completed queries are excluded
+	est = EstimateScan(config, entries, 4)
+	if est.TotalQueries != 2 {
+		t.Errorf("Expected 2 remaining queries, got %d", est.TotalQueries)
+	}
+}
+
+func TestZoneFromFQDN(t *testing.T) {
 	tests := []struct {
-		count    int
-		expected string
+		fqdn     string
+		zone     string
+		mnc, mcc string
+		ok       bool
 	}{
-		{1, "1 IP"},
-		{2, "2 IPs"},
-		{10, "10 IPs"},
+		{"epdg.epc.mnc001.mcc310.pub.3gppnetwork.org", "mnc001.mcc310.pub.3gppnetwork.org", "001", "310", true},
+		{"IMS.MNC005.MCC311.pub.3gppnetwork.org.", "mnc005.mcc311.pub.3gppnetwork.org", "005", "311", true},
+		{"mnc260.mcc310.pub.3gppnetwork.org", "mnc260.mcc310.pub.3gppnetwork.org", "260", "310", true},
+		{"www.example.org", "", "", "", false},
 	}
 
 	for _, tt := range tests {
-		result := formatIPCount(tt.count)
-		if result != tt.expected {
-			t.Errorf("formatIPCount(%d) = %s, expected %s", tt.count, result, tt.expected)
+		zone, mnc, mcc, ok := ZoneFromFQDN(tt.fqdn, "pub.3gppnetwork.org")
+		if ok != tt.ok || zone != tt.zone || mnc != tt.mnc || mcc != tt.mcc {
+			t.Errorf("ZoneFromFQDN(%s) = (%s, %s, %s, %v), expected (%s, %s, %s, %v)",
+				tt.fqdn, zone, mnc, mcc, ok, tt.zone, tt.mnc, tt.mcc, tt.ok)
+		}
+	}
+}
+
+func TestHitZones(t *testing.T) {
+	fqdns := []string{
+		"ims.mnc001.mcc310.pub.3gppnetwork.org",
+		"epdg.epc.mnc001.mcc310.pub.3gppnetwork.org",
+		"bsf.mnc005.mcc311.pub.3gppnetwork.org",
+		"not-a-3gpp-name.example.org",
+	}
+
+	zones := HitZones(fqdns, "pub.3gppnetwork.org")
+	if len(zones) != 2 {
+		t.Fatalf("Expected 2 zones, got %d: %v", len(zones), zones)
+	}
+	if zones[0] != "mnc001.mcc310.pub.3gppnetwork.org" {
+		t.Errorf("Unexpected first zone: %s", zones[0])
+	}
+}
+
+func TestRcodeStatus(t *testing.T) {
+	tests := []struct {
+		rcode    int
+		expected models.ResultStatus
+	}{
+		{dns.RcodeSuccess, models.StatusOK},
+		{dns.RcodeNameError, models.StatusNXDomain},
+		{dns.RcodeServerFailure, models.StatusServFail},
+		{dns.RcodeRefused, models.StatusRefused},
+	}
+
+	for _, tt := range tests {
+		if got := RcodeStatus(tt.rcode); got != tt.expected {
+			t.Errorf("RcodeStatus(%d) = %s, expected %s", tt.rcode, got, tt.expected)
 		}
 	}
 }
+
+func TestMoreDefinitive(t *testing.T) {
+	// An NXDOMAIN from one resolver outweighs a timeout from another
+	status := moreDefinitive(models.StatusTimeout, models.StatusNXDomain)
+	if status != models.StatusNXDomain {
+		t.Errorf("Expected NXDOMAIN, got %s", status)
+	}
+	status = moreDefinitive(status, models.StatusServFail)
+	if status != models.StatusNXDomain {
+		t.Errorf("Expected NXDOMAIN to be kept over SERVFAIL, got %s", status)
+	}
+	status = moreDefinitive(models.StatusNetworkError, models.StatusTimeout)
+	if status != models.StatusTimeout {
+		t.Errorf("Expected TIMEOUT over NETWORK_ERROR, got %s", status)
+	}
+}
+
+func TestScanQueryBudget(t *testing.T) {
+	silent := startTestResolver(t, func(w dns.ResponseWriter, r *dns.Msg) {})
+	answering := startTestResolver(t, flakyHandler())
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		Subdomains:   []string{"ims"},
+		QueryDelay:   time.Millisecond,
+		Concurrency:  2,
+		Resolvers:    []string{silent, answering},
+		NoRetry:      true,
+		QueryBudget:  200 * time.Millisecond,
+	}
+	scanner := NewScanner(config)
+
+	start := time.Now()
+	results, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone"},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Scan took %s, budget not applied", elapsed)
+	}
+	// The silent resolver uses up the budget, so the second is never asked
+	if len(results) != 0 || scanner.StatusCounts()[models.StatusTimeout] != 2 {
+		t.Errorf("got %d results, outcomes %v", len(results), scanner.StatusCounts())
+	}
+	if n := scanner.BudgetOverruns(); n != 2 {
+		t.Errorf("BudgetOverruns() = %d, want 2", n)
+	}
+
+	resolvers := scanner.ResolverTimings()
+	if len(resolvers) != 1 || resolvers[0].Name != silent || resolvers[0].Queries != 2 || resolvers[0].Failures != 2 {
+		t.Errorf("ResolverTimings() = %+v", resolvers)
+	}
+	zones := scanner.SlowestZones(1)
+	if len(zones) != 1 || zones[0].Queries != 1 || zones[0].Failures != 1 || zones[0].Max < 150*time.Millisecond {
+		t.Errorf("SlowestZones(1) = %+v", zones)
+	}
+}
+
+func TestQueryTimingSlowest(t *testing.T) {
+	timings := newTimings()
+	timings.addZone("fast", 10*time.Millisecond, false)
+	timings.addZone("slow", time.Second, true)
+	timings.addZone("slow", 3*time.Second, false)
+	timings.addZone("medium", 100*time.Millisecond, false)
+
+	got := timings.slowest(timings.zones, 2)
+	if len(got) != 2 || got[0].Name != "slow" || got[1].Name != "medium" {
+		t.Fatalf("slowest(2) = %+v", got)
+	}
+	if got[0].Mean() != 2*time.Second || got[0].Max != 3*time.Second || got[0].Failures != 1 {
+		t.Errorf("slow zone = %+v, mean %s", got[0], got[0].Mean())
+	}
+	if all := timings.slowest(timings.zones, 0); len(all) != 3 {
+		t.Errorf("slowest(0) returned %d timings, want 3", len(all))
+	}
+}
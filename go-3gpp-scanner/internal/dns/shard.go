@@ -0,0 +1,61 @@
+package dns
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Shard selects a fixed part of the MCC-MNC space, so a scan can be split
+// across machines or time windows: shards 1/n to n/n together cover every
+// operator zone exactly once. Zones are assigned by a hash of their name,
+// so the split does not depend on the order or length of the MCC-MNC list.
+type Shard struct {
+	Index int // 1-based
+	Count int
+}
+
+// ParseShard parses "i/n", e.g. "3/10"
+func ParseShard(s string) (Shard, error) {
+	i, n, ok := strings.Cut(s, "/")
+	index, err1 := strconv.Atoi(strings.TrimSpace(i))
+	count, err2 := strconv.Atoi(strings.TrimSpace(n))
+	if !ok || err1 != nil || err2 != nil {
+		return Shard{}, fmt.Errorf("invalid shard: %s (must be i/n, e.g. 3/10)", s)
+	}
+	if count < 1 || index < 1 || index > count {
+		return Shard{}, fmt.Errorf("invalid shard: %s (need 1 <= i <= n)", s)
+	}
+	return Shard{Index: index, Count: count}, nil
+}
+
+// String renders the shard as "i/n"
+func (sh Shard) String() string {
+	return fmt.Sprintf("%d/%d", sh.Index, sh.Count)
+}
+
+// Contains reports whether an operator zone belongs to the shard
+func (sh Shard) Contains(mnc, mcc int) bool {
+	if sh.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "mnc%03d.mcc%03d", mnc, mcc)
+	return int(h.Sum32()%uint32(sh.Count)) == sh.Index-1
+}
+
+// Filter returns the entries whose zones belong to the shard
+func (sh Shard) Filter(entries []models.MCCMNCEntry) []models.MCCMNCEntry {
+	var kept []models.MCCMNCEntry
+	for _, entry := range entries {
+		mcc, _ := strconv.Atoi(entry.MCC)
+		mnc, _ := strconv.Atoi(entry.MNC)
+		if sh.Contains(mnc, mcc) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
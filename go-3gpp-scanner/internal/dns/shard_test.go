@@ -0,0 +1,58 @@
+package dns
+
+import (
+	"fmt"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestParseShard(t *testing.T) {
+	sh, err := ParseShard("3/10")
+	if err != nil || sh.Index != 3 || sh.Count != 10 || sh.String() != "3/10" {
+		t.Errorf("ParseShard(3/10) = %+v, %v", sh, err)
+	}
+	for _, s := range []string{"", "3", "0/10", "11/10", "1/0", "a/b", "-1/4", "1/2/3"} {
+		if _, err := ParseShard(s); err == nil {
+			t.Errorf("ParseShard(%q): expected error", s)
+		}
+	}
+}
+
+func TestShardPartition(t *testing.T) {
+	var entries []models.MCCMNCEntry
+	for mcc := 200; mcc < 260; mcc++ {
+		for mnc := 0; mnc < 20; mnc++ {
+			entries = append(entries, models.MCCMNCEntry{MCC: fmt.Sprint(mcc), MNC: fmt.Sprintf("%02d", mnc)})
+		}
+	}
+
+	const n = 7
+	seen := make(map[string]int)
+	for i := 1; i <= n; i++ {
+		kept := Shard{Index: i, Count: n}.Filter(entries)
+		if len(kept) < len(entries)/n/2 || len(kept) > len(entries)/n*2 {
+			t.Errorf("shard %d/%d has %d of %d entries", i, n, len(kept), len(entries))
+		}
+		for _, e := range kept {
+			seen[e.MCC+"-"+e.MNC]++
+		}
+	}
+	if len(seen) != len(entries) {
+		t.Errorf("shards cover %d of %d entries", len(seen), len(entries))
+	}
+	for key, count := range seen {
+		if count != 1 {
+			t.Errorf("%s is in %d shards", key, count)
+		}
+	}
+
+	// Assignment depends on the zone only, not on list order or padding
+	sh := Shard{Index: 2, Count: n}
+	if sh.Contains(1, 262) != (len(sh.Filter([]models.MCCMNCEntry{{MCC: "262", MNC: "001"}})) == 1) {
+		t.Error("padded MNC assigned to a different shard")
+	}
+	if !(Shard{}).Contains(1, 262) || !(Shard{Index: 1, Count: 1}).Contains(1, 262) {
+		t.Error("a single shard must contain every zone")
+	}
+}
@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"os"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+// RcodeStatus maps a DNS response code to a result status
+func RcodeStatus(rcode int) models.ResultStatus {
+	switch rcode {
+	case dns.RcodeSuccess:
+		return models.StatusOK
+	case dns.RcodeNameError:
+		return models.StatusNXDomain
+	case dns.RcodeServerFailure:
+		return models.StatusServFail
+	case dns.RcodeRefused:
+		return models.StatusRefused
+	default:
+		return models.StatusServFail
+	}
+}
+
+// ErrorStatus classifies a transport error from a DNS exchange
+func ErrorStatus(err error) models.ResultStatus {
+	var netErr net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return models.StatusTimeout
+	}
+	return models.StatusNetworkError
+}
+
+// IsTransient reports whether a status may well change when the query is
+// repeated: a timeout or SERVFAIL
+func IsTransient(status models.ResultStatus) bool {
+	return status == models.StatusTimeout || status == models.StatusServFail
+}
+
+// statusRank orders statuses by how definitive they are about a name:
+// an answer from a resolver outranks a resolver failure, which outranks
+// not reaching a resolver at all
+func statusRank(status models.ResultStatus) int {
+	switch status {
+	case models.StatusOK:
+		return 4
+	case models.StatusNXDomain, models.StatusNoAnswer:
+		return 3
+	case models.StatusServFail, models.StatusRefused:
+		return 2
+	case models.StatusTimeout:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// moreDefinitive returns whichever of two statuses says more about the name
+func moreDefinitive(current, next models.ResultStatus) models.ResultStatus {
+	if statusRank(next) > statusRank(current) {
+		return next
+	}
+	return current
+}
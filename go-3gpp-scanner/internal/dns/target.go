@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Target is one query of a scan: a subdomain in the operator zone of an
+// MCC-MNC entry
+type Target struct {
+	Entry     models.MCCMNCEntry
+	Subdomain string
+}
+
+// TargetSource produces the targets of a scan. Targets are handed out one
+// at a time as workers become free, so a source may stream them from a
+// reader or generate them without knowing how many there are.
+type TargetSource interface {
+	// Targets calls yield for each target until it returns false, and
+	// returns an error if the targets could not be read
+	Targets(yield func(Target) bool) error
+	// Len returns the number of targets, or -1 if it is not known up front
+	Len() int
+}
+
+// ListSource is every subdomain in the zone of every MCC-MNC entry, in one
+// of the job orders (see Orders)
+type ListSource struct {
+	Entries    []models.MCCMNCEntry
+	Subdomains []string
+	Order      string
+	Seed       int64 // Seed of OrderShuffle
+}
+
+// Targets implements TargetSource
+func (l ListSource) Targets(yield func(Target) bool) error {
+	for j := range orderJobs(l.Entries, l.Subdomains, l.Order, l.Seed) {
+		if !yield(Target{Entry: j.entry, Subdomain: j.subdomain}) {
+			break
+		}
+	}
+	return nil
+}
+
+// Len implements TargetSource
+func (l ListSource) Len() int {
+	return len(l.Entries) * len(l.Subdomains)
+}
+
+// FQDNSource is a list of candidate FQDNs, e.g. stored in a database, or
+// read one per line from a file or stdin. Each FQDN must lie in the zone
+// of one of Entries, which supplies its operator; others are skipped, so
+// the MCC-MNC list filtered by policy, scope and shard bounds the scan as
+// it does for a ListSource.
+type FQDNSource struct {
+	FQDNs        []string
+	Reader       io.Reader // Read instead of FQDNs if set; blank lines and # comments are ignored
+	ParentDomain string
+	Entries      []models.MCCMNCEntry
+	Skip         func(fqdn, reason string) // Called for each FQDN left out, if set
+}
+
+// Targets implements TargetSource
+func (f FQDNSource) Targets(yield func(Target) bool) error {
+	entries := make(map[string]models.MCCMNCEntry, len(f.Entries))
+	for _, e := range f.Entries {
+		key := ZoneName(e.MNC, e.MCC, f.ParentDomain)
+		if _, ok := entries[key]; !ok {
+			entries[key] = e
+		}
+	}
+
+	// target reports whether to go on
+	target := func(fqdn string) bool {
+		fqdn = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+		zone, _, _, ok := ZoneFromFQDN(fqdn, f.ParentDomain)
+		subdomain, below := strings.CutSuffix(fqdn, "."+zone)
+		switch entry, known := entries[zone]; {
+		case !ok || !below || subdomain == "":
+			f.skip(fqdn, "not below a 3GPP operator zone of "+f.ParentDomain)
+		case !known:
+			f.skip(fqdn, "operator zone not in the MCC-MNC list")
+		default:
+			return yield(Target{Entry: entry, Subdomain: subdomain})
+		}
+		return true
+	}
+
+	if f.Reader == nil {
+		for _, fqdn := range f.FQDNs {
+			if !target(fqdn) {
+				break
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(f.Reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !target(line) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read targets: %w", err)
+	}
+	return nil
+}
+
+func (f FQDNSource) skip(fqdn, reason string) {
+	if f.Skip != nil {
+		f.Skip(fqdn, reason)
+	}
+}
+
+// Len implements TargetSource
+func (f FQDNSource) Len() int {
+	if f.Reader != nil {
+		return -1
+	}
+	return len(f.FQDNs)
+}
@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// targetKeys collects the targets of a source as "operator/subdomain"
+func targetKeys(t *testing.T, source TargetSource) []string {
+	t.Helper()
+	var keys []string
+	if err := source.Targets(func(target Target) bool {
+		keys = append(keys, target.Entry.Operator+"/"+target.Subdomain)
+		return true
+	}); err != nil {
+		t.Fatalf("Targets: %v", err)
+	}
+	return keys
+}
+
+var targetTestEntries = []models.MCCMNCEntry{
+	{MCC: "262", MNC: "01", Operator: "Telekom"},
+	{MCC: "262", MNC: "02", Operator: "Vodafone"},
+}
+
+func TestFQDNSource(t *testing.T) {
+	input := `# candidates
+ims.mnc001.mcc262.pub.3gppnetwork.org
+EPDG.EPC.MNC002.MCC262.PUB.3GPPNETWORK.ORG.
+
+sbc.ims.mnc002.mcc262.pub.3gppnetwork.org
+ims.mnc099.mcc262.pub.3gppnetwork.org
+mnc001.mcc262.pub.3gppnetwork.org
+ims.mnc001.mcc262.example.org
+www.example.org
+`
+	var skipped []string
+	source := FQDNSource{
+		Reader:       strings.NewReader(input),
+		ParentDomain: "pub.3gppnetwork.org",
+		Entries:      targetTestEntries,
+		Skip:         func(fqdn, reason string) { skipped = append(skipped, fqdn) },
+	}
+	if source.Len() != -1 {
+		t.Errorf("Len() = %d for a reader, want -1", source.Len())
+	}
+
+	got := targetKeys(t, source)
+	want := []string{"Telekom/ims", "Vodafone/epdg.epc", "Vodafone/sbc.ims"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+	wantSkipped := []string{
+		"ims.mnc099.mcc262.pub.3gppnetwork.org",
+		"mnc001.mcc262.pub.3gppnetwork.org",
+		"ims.mnc001.mcc262.example.org",
+		"www.example.org",
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", skipped, wantSkipped)
+	}
+
+	list := FQDNSource{FQDNs: []string{"ims.mnc002.mcc262.pub.3gppnetwork.org"}, ParentDomain: "pub.3gppnetwork.org", Entries: targetTestEntries}
+	if list.Len() != 1 || !reflect.DeepEqual(targetKeys(t, list), []string{"Vodafone/ims"}) {
+		t.Errorf("FQDNs source: Len() = %d, targets %v", list.Len(), targetKeys(t, list))
+	}
+}
+
+func TestListSource(t *testing.T) {
+	source := ListSource{Entries: targetTestEntries, Subdomains: []string{"ims", "bsf"}, Order: OrderByOperator}
+	got := targetKeys(t, source)
+	want := []string{"Telekom/ims", "Vodafone/ims", "Telekom/bsf", "Vodafone/bsf"}
+	if source.Len() != 4 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Len() = %d, targets %v, want %v", source.Len(), got, want)
+	}
+}
+
+// failingReader returns some FQDNs, then an error
+type failingReader struct{ r io.Reader }
+
+func (f failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestScanTargets(t *testing.T) {
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		QueryDelay:   time.Millisecond,
+		Concurrency:  2,
+		Resolvers:    []string{startTestResolver(t, flakyHandler())},
+	}
+	scanner := NewScanner(config)
+	var unknownTotal atomic.Bool
+	scanner.SetProgressCallback(func(current, total, found int) {
+		if total == -1 {
+			unknownTotal.Store(true)
+		}
+	})
+
+	input := "ims.mnc001.mcc262.pub.3gppnetwork.org\nbsf.mnc002.mcc262.pub.3gppnetwork.org\n"
+	results, err := scanner.ScanTargets(context.Background(), FQDNSource{
+		Reader:       failingReader{strings.NewReader(input)},
+		ParentDomain: config.ParentDomain,
+		Entries:      targetTestEntries,
+	})
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expected the reader error, got %v", err)
+	}
+	if len(results) != 1 || results[0].FQDN != "ims.mnc001.mcc262.pub.3gppnetwork.org" || results[0].Operator != "Telekom" {
+		t.Fatalf("results = %+v", results)
+	}
+	if counts := scanner.StatusCounts(); counts[models.StatusOK] != 1 || counts[models.StatusNXDomain] != 1 {
+		t.Errorf("outcomes = %v", counts)
+	}
+	if !unknownTotal.Load() {
+		t.Error("progress total of a reader should be -1")
+	}
+}
@@ -0,0 +1,124 @@
+package dns
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// QueryTiming aggregates the time queries took for one operator zone or
+// resolver
+type QueryTiming struct {
+	Name     string        `json:"name"`
+	Queries  int           `json:"queries"`
+	Failures int           `json:"failures"` // Queries without an answer, see isFailure
+	Total    time.Duration `json:"total"`
+	Max      time.Duration `json:"max"`
+}
+
+// Mean returns the average query time
+func (t QueryTiming) Mean() time.Duration {
+	if t.Queries == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Queries)
+}
+
+// isFailure reports whether a status means no resolver gave an answer:
+// a timeout, SERVFAIL, refusal or network error
+func isFailure(status models.ResultStatus) bool {
+	switch status {
+	case models.StatusTimeout, models.StatusServFail, models.StatusRefused, models.StatusNetworkError:
+		return true
+	}
+	return false
+}
+
+// timings collects query times per zone and per resolver from all workers
+type timings struct {
+	mu        sync.Mutex
+	zones     map[string]*QueryTiming
+	resolvers map[string]*QueryTiming
+	overrun   int // Queries that used up the per-query budget
+}
+
+func newTimings() *timings {
+	return &timings{zones: make(map[string]*QueryTiming), resolvers: make(map[string]*QueryTiming)}
+}
+
+func (t *timings) addZone(zone string, d time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	add(t.zones, zone, d, failed)
+}
+
+func (t *timings) addResolver(server string, d time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	add(t.resolvers, server, d, failed)
+}
+
+func (t *timings) addOverrun() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.overrun++
+}
+
+func add(m map[string]*QueryTiming, name string, d time.Duration, failed bool) {
+	qt, ok := m[name]
+	if !ok {
+		qt = &QueryTiming{Name: name}
+		m[name] = qt
+	}
+	qt.Queries++
+	qt.Total += d
+	if d > qt.Max {
+		qt.Max = d
+	}
+	if failed {
+		qt.Failures++
+	}
+}
+
+// slowest returns up to n timings by descending mean, all if n <= 0
+func (t *timings) slowest(m map[string]*QueryTiming, n int) []QueryTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]QueryTiming, 0, len(m))
+	for _, qt := range m {
+		list = append(list, *qt)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Mean() != list[j].Mean() {
+			return list[i].Mean() > list[j].Mean()
+		}
+		return list[i].Name < list[j].Name
+	})
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// SlowestZones returns the n operator zones whose queries took longest on
+// average in the last scan, with their failures; zones that throttle or
+// drop queries show up here
+func (s *Scanner) SlowestZones(n int) []QueryTiming {
+	return s.timings.slowest(s.timings.zones, n)
+}
+
+// ResolverTimings returns the query times of each resolver in the last
+// scan, slowest first
+func (s *Scanner) ResolverTimings() []QueryTiming {
+	return s.timings.slowest(s.timings.resolvers, 0)
+}
+
+// BudgetOverruns returns how many queries of the last scan ran out of the
+// per-query budget before every resolver was tried
+func (s *Scanner) BudgetOverruns() int {
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+	return s.timings.overrun
+}
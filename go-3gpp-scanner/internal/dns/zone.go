@@ -0,0 +1,251 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+// zonePattern matches the operator zone labels of a 3GPP FQDN
+var zonePattern = regexp.MustCompile(`(?i)(?:^|\.)mnc(\d{3})\.mcc(\d{3})\.`)
+
+// NormalizeFQDN lowercases an FQDN and strips the trailing root dot, the
+// form results and databases store
+func NormalizeFQDN(fqdn string) string {
+	return strings.ToLower(strings.TrimSuffix(fqdn, "."))
+}
+
+// ZoneFromFQDN returns the operator zone (mncXXX.mccYYY.<parent>) an FQDN
+// belongs to, along with its MNC and MCC. The MNC has the three digits of
+// the domain name, which cannot tell a two-digit MNC from a three-digit
+// one with a leading zero.
+func ZoneFromFQDN(fqdn, parentDomain string) (zone, mnc, mcc string, ok bool) {
+	fqdn = NormalizeFQDN(fqdn)
+	loc := zonePattern.FindStringSubmatchIndex(fqdn)
+	if loc == nil {
+		return "", "", "", false
+	}
+
+	mnc = fqdn[loc[2]:loc[3]]
+	mcc = fqdn[loc[4]:loc[5]]
+	return ZoneName(mnc, mcc, parentDomain), mnc, mcc, true
+}
+
+// ZoneName constructs the operator zone for an MNC/MCC pair
+func ZoneName(mnc, mcc string, parentDomain string) string {
+	return fmt.Sprintf("mnc%s.mcc%s.%s", models.PadMNC(mnc), models.NormalizeMCC(mcc), parentDomain)
+}
+
+// HitZones returns the distinct operator zones that contain at least one FQDN
+func HitZones(fqdns []string, parentDomain string) []string {
+	seen := make(map[string]bool)
+	var zones []string
+	for _, fqdn := range fqdns {
+		zone, _, _, ok := ZoneFromFQDN(fqdn, parentDomain)
+		if ok && !seen[zone] {
+			seen[zone] = true
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// QueryZoneInfo collects SOA, NS and MX metadata for each zone, honoring
+// the scanner's rate limit and concurrency settings
+func (s *Scanner) QueryZoneInfo(ctx context.Context, zones []string) []models.ZoneInfo {
+	jobs := make(chan string)
+	resultsCh := make(chan models.ZoneInfo)
+
+	go func() {
+		defer close(jobs)
+		for _, zone := range zones {
+			select {
+			case jobs <- zone:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	workers := s.config.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for zone := range jobs {
+				if err := s.rateLimiter.Wait(ctx); err != nil {
+					return
+				}
+				resultsCh <- s.zoneInfo(zone)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
+
+	var results []models.ZoneInfo
+	processed := 0
+	for info := range resultsCh {
+		results = append(results, info)
+		processed++
+		if s.progressFunc != nil {
+			s.progressFunc(processed, len(zones), len(results))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Zone < results[j].Zone })
+	return results
+}
+
+// zoneInfo queries the apex records of a single zone
+func (s *Scanner) zoneInfo(zone string) models.ZoneInfo {
+	info := models.ZoneInfo{
+		Zone:      zone,
+		Timestamp: time.Now(),
+	}
+	if _, mnc, mcc, ok := ZoneFromFQDN(zone, s.config.ParentDomain); ok {
+		info.MNC = mnc
+		info.MCC = mcc
+	}
+
+	var errs []string
+
+	if resp, err := s.exchange(zone, dns.TypeSOA); err != nil {
+		errs = append(errs, fmt.Sprintf("SOA: %v", err))
+	} else {
+		for _, rr := range resp.Answer {
+			if soa, ok := rr.(*dns.SOA); ok {
+				info.SOASerial = soa.Serial
+				info.PrimaryNS = strings.TrimSuffix(soa.Ns, ".")
+				info.Mailbox = strings.TrimSuffix(soa.Mbox, ".")
+				break
+			}
+		}
+	}
+
+	if resp, err := s.exchange(zone, dns.TypeNS); err != nil {
+		errs = append(errs, fmt.Sprintf("NS: %v", err))
+	} else {
+		for _, rr := range resp.Answer {
+			if ns, ok := rr.(*dns.NS); ok {
+				info.NS = append(info.NS, strings.TrimSuffix(ns.Ns, "."))
+			}
+		}
+		sort.Strings(info.NS)
+	}
+
+	if resp, err := s.exchange(zone, dns.TypeMX); err != nil {
+		errs = append(errs, fmt.Sprintf("MX: %v", err))
+	} else {
+		for _, rr := range resp.Answer {
+			if mx, ok := rr.(*dns.MX); ok {
+				info.MX = append(info.MX, fmt.Sprintf("%d %s", mx.Preference, strings.TrimSuffix(mx.Mx, ".")))
+			}
+		}
+		sort.Strings(info.MX)
+	}
+
+	info.Error = strings.Join(errs, "; ")
+	return info
+}
+
+// PrecheckZones queries the apex SOA of each entry's operator zone and
+// returns the entries whose zone exists, so a scan enumerates subdomains
+// only where there can be any. Zones already in the zone cache are not
+// queried again; entries whose zone could not be checked, or whose apex is
+// excluded, are kept. Outcomes are recorded in the zone cache, which is
+// created if none is set. Progress is reported per entry.
+func (s *Scanner) PrecheckZones(ctx context.Context, entries []models.MCCMNCEntry) []models.MCCMNCEntry {
+	if s.zoneCache == nil {
+		s.zoneCache = NewZoneCache()
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range entries {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	keep := make([]bool, len(entries))
+	var processed atomic.Int64
+	var wg sync.WaitGroup
+	workers := max(s.config.Concurrency, 1)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				j := job{entry: entries[i]}
+				zone := s.jobZone(j)
+				if s.exclude == nil || s.exclude.CheckName(zone) == nil {
+					if err := s.waitForWindow(ctx); err != nil {
+						return
+					}
+					s.checkZone(ctx, j.entry.MCC, zone)
+				}
+				exists, known := s.zoneCache.Exists(zone)
+				keep[i] = exists || !known
+
+				current := int(processed.Add(1))
+				if s.progressFunc != nil {
+					s.progressFunc(current, len(entries), 0)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var existing []models.MCCMNCEntry
+	for i, entry := range entries {
+		if keep[i] {
+			existing = append(existing, entry)
+		}
+	}
+	return existing
+}
+
+// HitEntries returns one MCC-MNC entry per operator zone that produced a
+// hit, for use as targets of a second-stage scan
+func HitEntries(results []models.DNSResult) []models.MCCMNCEntry {
+	seen := make(map[string]bool)
+	var entries []models.MCCMNCEntry
+	for _, r := range results {
+		key := ZoneName(r.MNC, r.MCC, "")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		entries = append(entries, models.MCCMNCEntry{
+			MCC:         r.MCC,
+			MNC:         r.MNC,
+			Operator:    r.Operator,
+			CountryName: r.Country,
+			CountryCode: r.CountryCode,
+			Brand:       r.Brand,
+			Status:      r.OperatorStatus,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,187 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// zoneCacheEntry records whether an operator zone exists
+type zoneCacheEntry struct {
+	Zone    string    `json:"zone"`
+	Exists  bool      `json:"exists"`
+	Checked time.Time `json:"checked"`
+}
+
+// zoneCheck is a pending or finished existence check of one zone
+type zoneCheck struct {
+	done   chan struct{}
+	exists bool
+}
+
+// ZoneCache remembers which operator zones exist, so subdomain queries
+// for zones proven nonexistent are skipped. Entries come from the current
+// run or, when loaded from a file, from earlier runs.
+type ZoneCache struct {
+	mu     sync.Mutex
+	zones  map[string]*zoneCheck
+	loaded map[string]time.Time // When entries read from the file were checked
+}
+
+// NewZoneCache creates an empty cache for a single run
+func NewZoneCache() *ZoneCache {
+	return &ZoneCache{
+		zones:  make(map[string]*zoneCheck),
+		loaded: make(map[string]time.Time),
+	}
+}
+
+// LoadZoneCache reads a cache file written by Save, dropping entries
+// checked more than ttl ago (zero keeps all). A missing file yields an
+// empty cache.
+func LoadZoneCache(path string, ttl time.Duration) (*ZoneCache, error) {
+	c := NewZoneCache()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone cache: %w", err)
+	}
+
+	var entries []zoneCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid zone cache %s: %w", path, err)
+	}
+	for _, e := range entries {
+		if ttl > 0 && time.Since(e.Checked) > ttl {
+			continue
+		}
+		c.set(e.Zone, e.Exists)
+		c.loaded[e.Zone] = e.Checked
+	}
+	return c, nil
+}
+
+// Save writes the cache to a file, replacing it atomically
+func (c *ZoneCache) Save(path string) error {
+	c.mu.Lock()
+	entries := make([]zoneCacheEntry, 0, len(c.zones))
+	now := time.Now().UTC()
+	for zone, check := range c.zones {
+		select {
+		case <-check.done:
+		default:
+			continue // Still being checked
+		}
+		checked, ok := c.loaded[zone]
+		if !ok {
+			checked = now
+		}
+		entries = append(entries, zoneCacheEntry{Zone: zone, Exists: check.exists, Checked: checked})
+	}
+	c.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Zone < entries[j].Zone })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".zone-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to write zone cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write zone cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write zone cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write zone cache: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of zones known to exist and not to exist
+func (c *ZoneCache) Len() (existing, nonexistent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, check := range c.zones {
+		select {
+		case <-check.done:
+			if check.exists {
+				existing++
+			} else {
+				nonexistent++
+			}
+		default:
+		}
+	}
+	return existing, nonexistent
+}
+
+// Exists reports whether a zone exists, waiting for a check in progress.
+// known is false for zones not checked yet.
+func (c *ZoneCache) Exists(zone string) (exists, known bool) {
+	c.mu.Lock()
+	check, ok := c.zones[zone]
+	c.mu.Unlock()
+	if !ok {
+		return false, false
+	}
+	<-check.done
+	return check.exists, true
+}
+
+// claim registers a check of zone by the caller. It returns false when the
+// zone is already known or being checked by someone else; otherwise the
+// caller must finish the check with set.
+func (c *ZoneCache) claim(zone string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.zones[zone]; ok {
+		return false
+	}
+	c.zones[zone] = &zoneCheck{done: make(chan struct{})}
+	return true
+}
+
+// abandon gives up a claimed check that was inconclusive. Callers waiting
+// on it treat the zone as existing; a later claim checks it again.
+func (c *ZoneCache) abandon(zone string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	check, ok := c.zones[zone]
+	if !ok {
+		return
+	}
+	delete(c.zones, zone)
+	check.exists = true
+	close(check.done)
+}
+
+// set records whether a zone exists, completing a claimed check
+func (c *ZoneCache) set(zone string, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	check, ok := c.zones[zone]
+	if !ok {
+		check = &zoneCheck{done: make(chan struct{})}
+		c.zones[zone] = check
+	}
+	select {
+	case <-check.done:
+		return // Already known
+	default:
+	}
+	check.exists = exists
+	close(check.done)
+}
@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"github.com/miekg/dns"
+)
+
+func TestZoneCacheSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zones.json")
+
+	cache := NewZoneCache()
+	cache.set("mnc001.mcc262.pub.3gppnetwork.org", true)
+	cache.set("mnc099.mcc262.pub.3gppnetwork.org", false)
+	cache.claim("mnc002.mcc262.pub.3gppnetwork.org") // Pending checks are not saved
+	if err := cache.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadZoneCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadZoneCache: %v", err)
+	}
+	if existing, nonexistent := loaded.Len(); existing != 1 || nonexistent != 1 {
+		t.Errorf("Expected 1 existing and 1 nonexistent zone, got %d and %d", existing, nonexistent)
+	}
+	if exists, known := loaded.Exists("mnc099.mcc262.pub.3gppnetwork.org"); !known || exists {
+		t.Errorf("Expected mnc099 known not to exist, got exists=%v known=%v", exists, known)
+	}
+	if _, known := loaded.Exists("mnc002.mcc262.pub.3gppnetwork.org"); known {
+		t.Errorf("Pending check was saved")
+	}
+
+	// Stale entries are dropped
+	data, _ := os.ReadFile(path)
+	stale := strings.ReplaceAll(string(data), time.Now().UTC().Format("2006-01-02"), "2020-01-01")
+	if err := os.WriteFile(path, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = LoadZoneCache(path, 24*time.Hour); err != nil {
+		t.Fatalf("LoadZoneCache: %v", err)
+	}
+	if existing, nonexistent := loaded.Len(); existing+nonexistent != 0 {
+		t.Errorf("Expected stale entries to be dropped, got %d", existing+nonexistent)
+	}
+
+	// A missing file is an empty cache
+	if _, err := LoadZoneCache(filepath.Join(t.TempDir(), "missing.json"), 0); err != nil {
+		t.Errorf("LoadZoneCache on a missing file: %v", err)
+	}
+}
+
+func TestZoneCacheAbandon(t *testing.T) {
+	cache := NewZoneCache()
+	zone := "mnc001.mcc262.pub.3gppnetwork.org"
+	if !cache.claim(zone) || cache.claim(zone) {
+		t.Fatalf("Expected only the first claim to succeed")
+	}
+	cache.abandon(zone)
+	if _, known := cache.Exists(zone); known {
+		t.Errorf("Abandoned zone is still known")
+	}
+	if !cache.claim(zone) {
+		t.Errorf("Expected an abandoned zone to be claimable again")
+	}
+}
+
+func TestScanNegativeCache(t *testing.T) {
+	// mnc001 does not exist; mnc002 exists but has none of the subdomains
+	var queries atomic.Int64
+	server := startTestResolver(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		queries.Add(1)
+		resp := new(dns.Msg)
+		resp.SetRcode(r, dns.RcodeNameError)
+		if r.Question[0].Name == "mnc002.mcc262.pub.3gppnetwork.org." {
+			resp.SetRcode(r, dns.RcodeSuccess)
+		}
+		w.WriteMsg(resp)
+	})
+
+	config := &models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		Subdomains:   []string{"ims", "epdg.epc", "bsf", "gan", "xcap.ims"},
+		QueryDelay:   time.Millisecond,
+		Concurrency:  1,
+		Resolvers:    []string{server},
+	}
+	scanner := NewScanner(config)
+	cache := NewZoneCache()
+	scanner.SetZoneCache(cache)
+
+	_, err := scanner.Scan(context.Background(), []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01"},
+		{MCC: "262", MNC: "02"},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	skipped, checks := scanner.CacheStats()
+	if skipped != 4 || checks != 2 {
+		t.Errorf("Expected 4 skipped queries and 2 zone checks, got %d and %d", skipped, checks)
+	}
+	if n := queries.Load(); n != 8 {
+		t.Errorf("Expected 8 queries sent (2 for mnc001, 6 for mnc002), got %d", n)
+	}
+	if counts := scanner.StatusCounts(); counts[models.StatusNXDomain] != 10 {
+		t.Errorf("Expected 10 NXDOMAIN outcomes, got %v", counts)
+	}
+	if existing, nonexistent := cache.Len(); existing != 1 || nonexistent != 1 {
+		t.Errorf("Expected 1 existing and 1 nonexistent zone, got %d and %d", existing, nonexistent)
+	}
+}
+
+func TestPrecheckZones(t *testing.T) {
+	var queries atomic.Int64
+	server := startTestResolver(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		queries.Add(1)
+		resp := new(dns.Msg)
+		switch r.Question[0].Name {
+		case "mnc002.mcc262.pub.3gppnetwork.org.":
+			resp.SetRcode(r, dns.RcodeSuccess)
+		case "mnc003.mcc262.pub.3gppnetwork.org.":
+			resp.SetRcode(r, dns.RcodeServerFailure)
+		default:
+			resp.SetRcode(r, dns.RcodeNameError)
+		}
+		w.WriteMsg(resp)
+	})
+
+	scanner := NewScanner(&models.ScanConfig{
+		ParentDomain: "pub.3gppnetwork.org",
+		QueryDelay:   time.Millisecond,
+		Concurrency:  2,
+		Resolvers:    []string{server},
+	})
+	cache := NewZoneCache()
+	cache.set("mnc004.mcc262.pub.3gppnetwork.org", false) // Known from an earlier run
+	scanner.SetZoneCache(cache)
+
+	var progress atomic.Int64
+	scanner.SetProgressCallback(func(current, total, found int) { progress.Add(1) })
+
+	entries := scanner.PrecheckZones(context.Background(), []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01"},
+		{MCC: "262", MNC: "02"},
+		{MCC: "262", MNC: "03"},
+		{MCC: "262", MNC: "04"},
+	})
+
+	var kept []string
+	for _, e := range entries {
+		kept = append(kept, e.MNC)
+	}
+	// mnc003 could not be checked and is kept
+	if strings.Join(kept, ",") != "02,03" {
+		t.Errorf("Expected entries 02,03 to be kept, got %v", kept)
+	}
+	if n := queries.Load(); n != 3 {
+		t.Errorf("Expected 3 apex queries, got %d", n)
+	}
+	if progress.Load() != 4 {
+		t.Errorf("Expected 4 progress updates, got %d", progress.Load())
+	}
+}
@@ -0,0 +1,299 @@
+package exclude
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrExcluded marks targets refused by an exclusion list or scope
+var ErrExcluded = errors.New("target excluded")
+
+// Error reports which rule excluded a target; it matches ErrExcluded
+type Error struct {
+	Target string
+	Rule   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s is excluded by rule %s", e.Target, e.Rule)
+}
+
+// Is makes errors.Is(err, ErrExcluded) hold
+func (e *Error) Is(target error) bool {
+	return target == ErrExcluded
+}
+
+// Skip records one target left out because of the list
+type Skip struct {
+	Target string `json:"target"`
+	Rule   string `json:"rule"`
+}
+
+// Checker vets targets before they are contacted. List and scope.Scope
+// implement it; Chain combines several.
+type Checker interface {
+	// CheckName returns an error matching ErrExcluded for refused FQDNs
+	CheckName(fqdn string) error
+	// CheckIP returns an error matching ErrExcluded for refused addresses
+	CheckIP(ip net.IP) error
+	// CheckTarget vets an FQDN and the addresses it resolved to
+	CheckTarget(fqdn string, ips []string) error
+	// HasNetworks reports whether addresses are vetted, i.e. whether
+	// targets must be resolved before they can be checked
+	HasNetworks() bool
+	// Skipped lists the targets refused so far
+	Skipped() []Skip
+}
+
+// Chain applies several checkers; the first refusal wins
+type Chain []Checker
+
+// CheckName implements Checker
+func (c Chain) CheckName(fqdn string) error {
+	for _, checker := range c {
+		if err := checker.CheckName(fqdn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckIP implements Checker
+func (c Chain) CheckIP(ip net.IP) error {
+	for _, checker := range c {
+		if err := checker.CheckIP(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasNetworks implements Checker
+func (c Chain) HasNetworks() bool {
+	for _, checker := range c {
+		if checker.HasNetworks() {
+			return true
+		}
+	}
+	return false
+}
+
+// Skipped implements Checker, merging the skips of all checkers
+func (c Chain) Skipped() []Skip {
+	var skips []Skip
+	for _, checker := range c {
+		skips = append(skips, checker.Skipped()...)
+	}
+	sort.SliceStable(skips, func(i, j int) bool { return skips[i].Target < skips[j].Target })
+	return skips
+}
+
+// CheckTarget implements Checker
+func (c Chain) CheckTarget(fqdn string, ips []string) error {
+	for _, checker := range c {
+		if err := checker.CheckTarget(fqdn, ips); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List holds FQDNs, IP addresses and CIDR networks that must never be
+// probed. A nil *List excludes nothing, so callers can pass it around
+// unconditionally.
+type List struct {
+	names    map[string]bool // Exact FQDNs
+	suffixes []string        // ".example.org" for "*.example.org"
+	networks []*net.IPNet    // Single addresses as /32 or /128
+
+	mu      sync.Mutex
+	skipped map[string]string // Target -> rule
+}
+
+// Load reads an exclusion file; see Parse for the format
+func Load(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exclude file: %w", err)
+	}
+	defer f.Close()
+
+	list, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Parse reads one entry per line: an FQDN (epdg.epc.mnc001.mcc001.pub.3gppnetwork.org),
+// a wildcard covering every name below a domain (*.mcc262.pub.3gppnetwork.org),
+// an IP address or a CIDR network. Blank lines and # comments are ignored.
+func Parse(r io.Reader) (*List, error) {
+	list := &List{names: make(map[string]bool), skipped: make(map[string]string)}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		entry := strings.TrimSpace(line)
+		if entry == "" {
+			continue
+		}
+
+		switch {
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid CIDR %q", lineNo, entry)
+			}
+			list.networks = append(list.networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list.networks = append(list.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(entry, "*."):
+			list.suffixes = append(list.suffixes, normalizeName(entry[1:]))
+		default:
+			if strings.ContainsAny(entry, " \t*") {
+				return nil, fmt.Errorf("line %d: invalid entry %q", lineNo, entry)
+			}
+			list.names[normalizeName(entry)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// normalizeName lowercases a name and drops the root dot
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// Len returns the number of entries
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.names) + len(l.suffixes) + len(l.networks)
+}
+
+// HasNetworks implements Checker
+func (l *List) HasNetworks() bool {
+	return l != nil && len(l.networks) > 0
+}
+
+// MatchName returns the rule excluding an FQDN
+func (l *List) MatchName(fqdn string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	name := normalizeName(fqdn)
+	if l.names[name] {
+		return name, true
+	}
+	for _, suffix := range l.suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return "*" + suffix, true
+		}
+	}
+	return "", false
+}
+
+// MatchIP returns the rule excluding an address
+func (l *List) MatchIP(ip net.IP) (string, bool) {
+	if l == nil || ip == nil {
+		return "", false
+	}
+	for _, network := range l.networks {
+		if network.Contains(ip) {
+			return network.String(), true
+		}
+	}
+	return "", false
+}
+
+// CheckName returns an *Error when the FQDN is excluded and records the
+// skip
+func (l *List) CheckName(fqdn string) error {
+	if rule, ok := l.MatchName(fqdn); ok {
+		return l.record(fqdn, rule)
+	}
+	return nil
+}
+
+// CheckIP returns an *Error when the address is excluded and records the
+// skip
+func (l *List) CheckIP(ip net.IP) error {
+	if rule, ok := l.MatchIP(ip); ok {
+		return l.record(ip.String(), rule)
+	}
+	return nil
+}
+
+// CheckTarget implements Checker; a skip caused by an address is
+// recorded under the FQDN
+func (l *List) CheckTarget(fqdn string, ips []string) error {
+	if err := l.CheckName(fqdn); err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if rule, ok := l.MatchIP(net.ParseIP(ip)); ok {
+			return l.record(fqdn, rule)
+		}
+	}
+	return nil
+}
+
+// FilterNames drops excluded FQDNs, recording each skip
+func (l *List) FilterNames(fqdns []string) []string {
+	if l.Len() == 0 {
+		return fqdns
+	}
+	kept := make([]string, 0, len(fqdns))
+	for _, fqdn := range fqdns {
+		if l.CheckName(fqdn) == nil {
+			kept = append(kept, fqdn)
+		}
+	}
+	return kept
+}
+
+// record notes a skipped target and returns its error
+func (l *List) record(target, rule string) error {
+	l.mu.Lock()
+	l.skipped[target] = rule
+	l.mu.Unlock()
+	return &Error{Target: target, Rule: rule}
+}
+
+// Skipped implements Checker, sorted by target
+func (l *List) Skipped() []Skip {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	skips := make([]Skip, 0, len(l.skipped))
+	for target, rule := range l.skipped {
+		skips = append(skips, Skip{Target: target, Rule: rule})
+	}
+	sort.Slice(skips, func(i, j int) bool { return skips[i].Target < skips[j].Target })
+	return skips
+}
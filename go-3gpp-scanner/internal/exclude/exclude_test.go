@@ -0,0 +1,119 @@
+package exclude
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+const testList = `
+# Legal hold
+epdg.epc.mnc001.mcc262.pub.3gppnetwork.org
+*.mcc234.pub.3gppnetwork.org   # whole country
+192.0.2.7
+198.51.100.0/24
+2001:db8::/32
+`
+
+func TestMatch(t *testing.T) {
+	list, err := Parse(strings.NewReader(testList))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if list.Len() != 5 {
+		t.Errorf("Len = %d, want 5", list.Len())
+	}
+
+	names := []struct {
+		fqdn string
+		rule string
+	}{
+		{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"},
+		{"EPDG.epc.mnc001.mcc262.pub.3gppnetwork.org.", "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"},
+		{"ims.mnc010.mcc234.pub.3gppnetwork.org", "*.mcc234.pub.3gppnetwork.org"},
+		{"ims.mnc001.mcc262.pub.3gppnetwork.org", ""},
+		{"mcc234.pub.3gppnetwork.org", ""}, // The wildcard covers names below only
+	}
+	for _, tt := range names {
+		rule, ok := list.MatchName(tt.fqdn)
+		if ok != (tt.rule != "") || rule != tt.rule {
+			t.Errorf("MatchName(%s) = %q, %v; want %q", tt.fqdn, rule, ok, tt.rule)
+		}
+	}
+
+	ips := []struct {
+		ip   string
+		rule string
+	}{
+		{"192.0.2.7", "192.0.2.7/32"},
+		{"192.0.2.8", ""},
+		{"198.51.100.200", "198.51.100.0/24"},
+		{"2001:db8::1", "2001:db8::/32"},
+		{"::ffff:192.0.2.7", "192.0.2.7/32"},
+	}
+	for _, tt := range ips {
+		rule, ok := list.MatchIP(net.ParseIP(tt.ip))
+		if ok != (tt.rule != "") || rule != tt.rule {
+			t.Errorf("MatchIP(%s) = %q, %v; want %q", tt.ip, rule, ok, tt.rule)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "bad name", "epdg.*.example.org"} {
+		if _, err := Parse(strings.NewReader(entry)); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("Parse(%q): expected line error, got %v", entry, err)
+		}
+	}
+}
+
+func TestCheckRecordsSkips(t *testing.T) {
+	list, err := Parse(strings.NewReader(testList))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	kept := list.FilterNames([]string{
+		"ims.mnc010.mcc234.pub.3gppnetwork.org",
+		"ims.mnc001.mcc262.pub.3gppnetwork.org",
+	})
+	if len(kept) != 1 || kept[0] != "ims.mnc001.mcc262.pub.3gppnetwork.org" {
+		t.Errorf("FilterNames kept %v", kept)
+	}
+
+	err = list.CheckTarget("xcap.ims.mnc001.mcc262.pub.3gppnetwork.org", []string{"203.0.113.1", "198.51.100.9"})
+	if !errors.Is(err, ErrExcluded) {
+		t.Fatalf("CheckTarget: expected ErrExcluded, got %v", err)
+	}
+	if list.CheckIP(net.ParseIP("203.0.113.1")) != nil {
+		t.Errorf("CheckIP: unexpected exclusion")
+	}
+
+	skipped := list.Skipped()
+	want := []Skip{
+		{Target: "ims.mnc010.mcc234.pub.3gppnetwork.org", Rule: "*.mcc234.pub.3gppnetwork.org"},
+		{Target: "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org", Rule: "198.51.100.0/24"},
+	}
+	if len(skipped) != len(want) {
+		t.Fatalf("Skipped = %v, want %v", skipped, want)
+	}
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Errorf("Skipped[%d] = %v, want %v", i, skipped[i], want[i])
+		}
+	}
+}
+
+func TestNilList(t *testing.T) {
+	var list *List
+	if list.Len() != 0 || list.HasNetworks() || list.Skipped() != nil {
+		t.Errorf("nil list should be empty")
+	}
+	if err := list.CheckTarget("epdg.example.org", []string{"192.0.2.1"}); err != nil {
+		t.Errorf("nil list excluded a target: %v", err)
+	}
+	if got := list.FilterNames([]string{"a", "b"}); len(got) != 2 {
+		t.Errorf("nil list filtered names: %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// EntryChange pairs the old and new version of an operator entry
+type EntryChange struct {
+	Old models.MCCMNCEntry
+	New models.MCCMNCEntry
+}
+
+// ListDiff describes how an MCC-MNC list changed between two fetches
+type ListDiff struct {
+	Added   []models.MCCMNCEntry
+	Removed []models.MCCMNCEntry
+	Renamed []EntryChange // Same MCC-MNC, different operator or brand
+}
+
+// IsEmpty reports whether the lists are equivalent
+func (d ListDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renamed) == 0
+}
+
+// DiffEntries compares two MCC-MNC lists keyed by MCC and MNC
+func DiffEntries(oldEntries, newEntries []models.MCCMNCEntry) ListDiff {
+	oldByKey := indexEntries(oldEntries)
+	newByKey := indexEntries(newEntries)
+
+	var diff ListDiff
+	for key, entry := range newByKey {
+		old, ok := oldByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+		if old.Operator != entry.Operator || old.Brand != entry.Brand {
+			diff.Renamed = append(diff.Renamed, EntryChange{Old: old, New: entry})
+		}
+	}
+	for key, entry := range oldByKey {
+		if _, ok := newByKey[key]; !ok {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+
+	sortEntries(diff.Added)
+	sortEntries(diff.Removed)
+	sort.Slice(diff.Renamed, func(i, j int) bool {
+		return entryKey(diff.Renamed[i].New) < entryKey(diff.Renamed[j].New)
+	})
+	return diff
+}
+
+// Validate reports malformed or duplicate entries in an MCC-MNC list
+func Validate(entries []models.MCCMNCEntry) []string {
+	var problems []string
+	seen := make(map[string]bool)
+
+	for i, entry := range entries {
+		if !isDigits(entry.MCC) || len(entry.MCC) != 3 {
+			problems = append(problems, fmt.Sprintf("entry %d: invalid MCC %q", i, entry.MCC))
+			continue
+		}
+		if !isDigits(entry.MNC) || len(entry.MNC) < 2 || len(entry.MNC) > 3 {
+			problems = append(problems, fmt.Sprintf("entry %d: invalid MNC %q for MCC %s", i, entry.MNC, entry.MCC))
+			continue
+		}
+		key := entryKey(entry)
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("entry %d: duplicate MCC-MNC %s-%s", i, entry.MCC, entry.MNC))
+		}
+		seen[key] = true
+	}
+
+	return problems
+}
+
+// FormatDiff renders a diff as a human-readable report
+func FormatDiff(d ListDiff) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Added: %d, Removed: %d, Renamed: %d\n",
+		len(d.Added), len(d.Removed), len(d.Renamed)))
+
+	if len(d.Added) > 0 {
+		sb.WriteString("\nAdded operators:\n")
+		for _, e := range d.Added {
+			sb.WriteString(fmt.Sprintf("  + %s-%s %s (%s)\n", e.MCC, e.MNC, displayName(e), e.CountryName))
+		}
+	}
+	if len(d.Removed) > 0 {
+		sb.WriteString("\nRemoved operators:\n")
+		for _, e := range d.Removed {
+			sb.WriteString(fmt.Sprintf("  - %s-%s %s (%s)\n", e.MCC, e.MNC, displayName(e), e.CountryName))
+		}
+	}
+	if len(d.Renamed) > 0 {
+		sb.WriteString("\nRenamed operators:\n")
+		for _, c := range d.Renamed {
+			sb.WriteString(fmt.Sprintf("  ~ %s-%s %s -> %s\n", c.New.MCC, c.New.MNC, displayName(c.Old), displayName(c.New)))
+		}
+	}
+
+	return sb.String()
+}
+
+func indexEntries(entries []models.MCCMNCEntry) map[string]models.MCCMNCEntry {
+	index := make(map[string]models.MCCMNCEntry, len(entries))
+	for _, entry := range entries {
+		index[entryKey(entry)] = entry
+	}
+	return index
+}
+
+// entryKey identifies an entry; MNC length is significant ("01" != "001")
+func entryKey(entry models.MCCMNCEntry) string {
+	return strings.TrimSpace(entry.MCC) + "-" + strings.TrimSpace(entry.MNC)
+}
+
+func sortEntries(entries []models.MCCMNCEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entryKey(entries[i]) < entryKey(entries[j]) })
+}
+
+func displayName(e models.MCCMNCEntry) string {
+	switch {
+	case e.Brand != "" && e.Operator != "" && e.Brand != e.Operator:
+		return fmt.Sprintf("%s / %s", e.Brand, e.Operator)
+	case e.Operator != "":
+		return e.Operator
+	case e.Brand != "":
+		return e.Brand
+	default:
+		return "(unnamed)"
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"3gpp-scanner/internal/models"
@@ -15,18 +17,38 @@ import (
 const (
 	DefaultMCCMNCURL = "https://raw.githubusercontent.com/pbakondy/mcc-mnc-list/master/mcc-mnc-list.json"
 	CacheFileName    = "mcc-mnc-list.json"
+	DefaultUserAgent = "3gpp-scanner (+https://github.com/asnd/sec100)"
+
+	// metaSuffix names the sidecar file holding HTTP validators for the cache
+	metaSuffix = ".meta"
 )
 
 // Fetcher handles fetching and caching of MCC-MNC data
 type Fetcher struct {
-	URL      string
-	CacheDir string
-	CacheTTL time.Duration
-	Verbose  bool
+	URL       string
+	CacheDir  string
+	CacheTTL  time.Duration
+	UserAgent string
+
+	// Retries is the number of extra attempts on transient HTTP errors
+	Retries int
+	// RetryBackoff is the initial delay between attempts (doubled each retry)
+	RetryBackoff time.Duration
+	// HTTPClient is used for requests; a 30s-timeout client is used if nil
+	HTTPClient *http.Client
+}
+
+// cacheMeta stores HTTP validators for conditional requests
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
+// errNotModified signals a 304 response to a conditional request
+var errNotModified = fmt.Errorf("not modified")
+
 // NewFetcher creates a new MCC-MNC fetcher
-func NewFetcher(url, cacheDir string, cacheTTL time.Duration, verbose bool) *Fetcher {
+func NewFetcher(url, cacheDir string, cacheTTL time.Duration) *Fetcher {
 	if url == "" {
 		url = DefaultMCCMNCURL
 	}
@@ -34,10 +56,12 @@ func NewFetcher(url, cacheDir string, cacheTTL time.Duration, verbose bool) *Fet
 		cacheDir = "."
 	}
 	return &Fetcher{
-		URL:      url,
-		CacheDir: cacheDir,
-		CacheTTL: cacheTTL,
-		Verbose:  verbose,
+		URL:          url,
+		CacheDir:     cacheDir,
+		CacheTTL:     cacheTTL,
+		UserAgent:    DefaultUserAgent,
+		Retries:      3,
+		RetryBackoff: time.Second,
 	}
 }
 
@@ -47,24 +71,26 @@ func (f *Fetcher) Fetch() ([]models.MCCMNCEntry, error) {
 
 	// Check if cache exists and is fresh
 	if f.isCacheFresh(cachePath) {
-		if f.Verbose {
-			fmt.Printf("Using cached MCC-MNC list from %s\n", cachePath)
-		}
+		slog.Debug("using cached MCC-MNC list", "path", cachePath)
 		return f.readFromFile(cachePath)
 	}
 
 	// Fetch from URL
-	if f.Verbose {
-		fmt.Printf("Fetching MCC-MNC list from %s\n", f.URL)
-	}
+	slog.Debug("fetching MCC-MNC list", "url", f.URL)
 
-	entries, err := f.fetchFromURL()
+	meta := f.readMeta(cachePath)
+	entries, newMeta, err := f.fetchWithRetry(meta)
+	if err == errNotModified {
+		slog.Debug("MCC-MNC list not modified, reusing cache", "path", cachePath)
+		// Reset the TTL clock on the unchanged cache
+		now := time.Now()
+		os.Chtimes(cachePath, now, now)
+		return f.readFromFile(cachePath)
+	}
 	if err != nil {
 		// If fetch fails, try to use stale cache
 		if _, statErr := os.Stat(cachePath); statErr == nil {
-			if f.Verbose {
-				fmt.Printf("Warning: fetch failed, using stale cache: %v\n", err)
-			}
+			slog.Warn("MCC-MNC fetch failed, using stale cache", "path", cachePath, "error", err)
 			return f.readFromFile(cachePath)
 		}
 		return nil, fmt.Errorf("failed to fetch MCC-MNC list: %w", err)
@@ -72,9 +98,9 @@ func (f *Fetcher) Fetch() ([]models.MCCMNCEntry, error) {
 
 	// Save to cache
 	if err := f.saveToCache(cachePath, entries); err != nil {
-		if f.Verbose {
-			fmt.Printf("Warning: failed to save cache: %v\n", err)
-		}
+		slog.Warn("failed to save MCC-MNC cache", "path", cachePath, "error", err)
+	} else {
+		f.writeMeta(cachePath, newMeta)
 	}
 
 	return entries, nil
@@ -82,39 +108,111 @@ func (f *Fetcher) Fetch() ([]models.MCCMNCEntry, error) {
 
 // FetchFromFile reads MCC-MNC list from a local file
 func (f *Fetcher) FetchFromFile(filePath string) ([]models.MCCMNCEntry, error) {
-	if f.Verbose {
-		fmt.Printf("Reading MCC-MNC list from %s\n", filePath)
-	}
+	slog.Debug("reading MCC-MNC list", "path", filePath)
 	return f.readFromFile(filePath)
 }
 
-// fetchFromURL downloads the MCC-MNC list from the remote URL
-func (f *Fetcher) fetchFromURL() ([]models.MCCMNCEntry, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// fetchWithRetry downloads the list, retrying transient failures with
+// exponential backoff
+func (f *Fetcher) fetchWithRetry(meta cacheMeta) ([]models.MCCMNCEntry, cacheMeta, error) {
+	backoff := f.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		entries, newMeta, transient, err := f.fetchFromURL(meta)
+		if err == nil || !transient || attempt >= f.Retries {
+			return entries, newMeta, err
+		}
+
+		slog.Info("MCC-MNC fetch failed, retrying", "attempt", attempt+1, "error", err, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
+}
 
-	resp, err := client.Get(f.URL)
+// fetchFromURL downloads the MCC-MNC list from the remote URL, sending
+// conditional headers when validators from a previous fetch are known.
+// transient reports whether the failure is worth retrying.
+func (f *Fetcher) fetchFromURL(meta cacheMeta) (entries []models.MCCMNCEntry, newMeta cacheMeta, transient bool, err error) {
+	client := f.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, f.URL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, newMeta, false, fmt.Errorf("failed to build request: %w", err)
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, newMeta, true, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, meta, false, errNotModified
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		transient = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, newMeta, transient, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, newMeta, true, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var entries []models.MCCMNCEntry
 	if err := json.Unmarshal(body, &entries); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		return nil, newMeta, false, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	return entries, nil
+	newMeta = cacheMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	return entries, newMeta, false, nil
+}
+
+// readMeta loads HTTP validators for the cache; they are only trusted
+// while the cache file itself exists
+func (f *Fetcher) readMeta(cachePath string) cacheMeta {
+	var meta cacheMeta
+	if _, err := os.Stat(cachePath); err != nil {
+		return meta
+	}
+	data, err := os.ReadFile(cachePath + metaSuffix)
+	if err != nil {
+		return meta
+	}
+	json.Unmarshal(data, &meta)
+	return meta
+}
+
+// writeMeta stores HTTP validators next to the cache file
+func (f *Fetcher) writeMeta(cachePath string, meta cacheMeta) {
+	metaPath := cachePath + metaSuffix
+	if meta.ETag == "" && meta.LastModified == "" {
+		os.Remove(metaPath)
+		return
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		slog.Warn("failed to save cache metadata", "path", metaPath, "error", err)
+	}
 }
 
 // readFromFile reads and parses the MCC-MNC list from a file
@@ -160,3 +258,41 @@ func (f *Fetcher) isCacheFresh(filePath string) bool {
 	age := time.Since(info.ModTime())
 	return age < f.CacheTTL
 }
+
+// Filter selects a subset of MCC-MNC entries. Empty fields match everything.
+type Filter struct {
+	Countries []string // ISO country codes or country names (case-insensitive)
+	Status    string   // e.g. "operational" (case-insensitive)
+}
+
+// IsEmpty reports whether the filter matches every entry
+func (flt Filter) IsEmpty() bool {
+	return len(flt.Countries) == 0 && flt.Status == ""
+}
+
+// Apply returns the entries matching the filter
+func (flt Filter) Apply(entries []models.MCCMNCEntry) []models.MCCMNCEntry {
+	if flt.IsEmpty() {
+		return entries
+	}
+
+	countries := make(map[string]bool, len(flt.Countries))
+	for _, c := range flt.Countries {
+		countries[strings.ToLower(strings.TrimSpace(c))] = true
+	}
+	status := strings.ToLower(strings.TrimSpace(flt.Status))
+
+	var filtered []models.MCCMNCEntry
+	for _, entry := range entries {
+		if len(countries) > 0 &&
+			!countries[strings.ToLower(entry.CountryCode)] &&
+			!countries[strings.ToLower(entry.CountryName)] {
+			continue
+		}
+		if status != "" && strings.ToLower(entry.Status) != status {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
@@ -0,0 +1,157 @@
+package fetcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestFilterApply(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", CountryCode: "DE", CountryName: "Germany", Status: "Operational"},
+		{MCC: "262", MNC: "99", CountryCode: "DE", CountryName: "Germany", Status: "Not operational"},
+		{MCC: "232", MNC: "01", CountryCode: "AT", CountryName: "Austria", Status: "Operational"},
+		{MCC: "310", MNC: "410", CountryCode: "US", CountryName: "United States", Status: "Operational"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{"empty filter", Filter{}, 4},
+		{"by country code", Filter{Countries: []string{"de"}}, 2},
+		{"by country name", Filter{Countries: []string{"Austria", "germany"}}, 3},
+		{"by status", Filter{Status: "operational"}, 3},
+		{"country and status", Filter{Countries: []string{"DE"}, Status: "OPERATIONAL"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Apply(entries)
+			if len(got) != tt.expected {
+				t.Errorf("Expected %d entries, got %d", tt.expected, len(got))
+			}
+		})
+	}
+}
+
+func TestDiffEntries(t *testing.T) {
+	oldEntries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom Deutschland"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone D2"},
+		{MCC: "262", MNC: "99", Operator: "Old Network"},
+	}
+	newEntries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom Deutschland"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone GmbH"},
+		{MCC: "262", MNC: "23", Operator: "1&1"},
+	}
+
+	diff := DiffEntries(oldEntries, newEntries)
+	if len(diff.Added) != 1 || diff.Added[0].MNC != "23" {
+		t.Errorf("Expected MNC 23 added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].MNC != "99" {
+		t.Errorf("Expected MNC 99 removed, got %+v", diff.Removed)
+	}
+	if len(diff.Renamed) != 1 || diff.Renamed[0].New.Operator != "Vodafone GmbH" {
+		t.Errorf("Expected MNC 02 renamed, got %+v", diff.Renamed)
+	}
+	if DiffEntries(oldEntries, oldEntries).IsEmpty() != true {
+		t.Errorf("Expected identical lists to produce an empty diff")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01"},
+		{MCC: "262", MNC: "01"},
+		{MCC: "26", MNC: "01"},
+		{MCC: "310", MNC: "4a0"},
+		{MCC: "310", MNC: "410"},
+	}
+
+	problems := Validate(entries)
+	if len(problems) != 3 {
+		t.Errorf("Expected 3 problems, got %d: %v", len(problems), problems)
+	}
+}
+
+func TestFetchConditionalAndRetry(t *testing.T) {
+	var requests atomic.Int32
+	var sawUserAgent, sawConditional atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if strings.HasPrefix(r.Header.Get("User-Agent"), "3gpp-scanner") {
+			sawUserAgent.Store(true)
+		}
+		// First request fails transiently
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			sawConditional.Store(true)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"mcc":"262","mnc":"01","operator":"Telekom"}]`))
+	}))
+	defer server.Close()
+
+	f := NewFetcher(server.URL, t.TempDir(), 0)
+	f.RetryBackoff = time.Millisecond
+
+	entries, err := f.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 requests (1 retry), got %d", requests.Load())
+	}
+
+	// Second fetch sends If-None-Match and reuses the cache on 304
+	entries, err = f.Fetch()
+	if err != nil {
+		t.Fatalf("Conditional fetch failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Operator != "Telekom" {
+		t.Errorf("Expected cached entry after 304, got %+v", entries)
+	}
+	if !sawConditional.Load() {
+		t.Errorf("Expected conditional request with If-None-Match")
+	}
+	if !sawUserAgent.Load() {
+		t.Errorf("Expected 3gpp-scanner User-Agent header")
+	}
+}
+
+func TestFetchNoRetryOnClientError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := NewFetcher(server.URL, t.TempDir(), 0)
+	f.RetryBackoff = time.Millisecond
+
+	if _, err := f.Fetch(); err == nil {
+		t.Errorf("Expected error for 404")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected no retries on 404, got %d requests", requests.Load())
+	}
+}
@@ -0,0 +1,368 @@
+package fingerprint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Source is the kind of evidence a rule inspects
+type Source string
+
+// Evidence sources
+const (
+	SourceCert   Source = "cert"   // Certificate issuer, subject and SANs
+	SourceRDNS   Source = "rdns"   // PTR names of the endpoint IP
+	SourceIKE    Source = "ike"    // IKE Vendor IDs, as hex and readable text
+	SourceBanner Source = "banner" // HTTP Server, SIP Server/User-Agent, STUN SOFTWARE
+	SourceCNAME  Source = "cname"  // CNAME targets, e.g. cloud load balancers
+)
+
+// defaultWeights apply to rules without a weight. Banners and IKE Vendor
+// IDs are set by the vendor's own software; certificates and names are
+// often chosen by the operator or its integrator.
+var defaultWeights = map[Source]float64{
+	SourceCert:   0.4,
+	SourceRDNS:   0.3,
+	SourceIKE:    0.5,
+	SourceBanner: 0.5,
+	SourceCNAME:  0.4,
+}
+
+// Rule labels endpoints whose evidence of one source matches a pattern
+type Rule struct {
+	Vendor  string  `json:"vendor"`
+	Source  Source  `json:"source"`
+	Pattern string  `json:"pattern"`          // Case-insensitive regular expression
+	Weight  float64 `json:"weight,omitempty"` // Default depends on the source
+}
+
+// DefaultRules cover the common EPC/IMS vendors and cloud platforms
+var DefaultRules = []Rule{
+	{Vendor: "Ericsson", Source: SourceCert, Pattern: `ericsson`},
+	{Vendor: "Ericsson", Source: SourceRDNS, Pattern: `ericsson`},
+	{Vendor: "Ericsson", Source: SourceBanner, Pattern: `ericsson`},
+	{Vendor: "Nokia", Source: SourceCert, Pattern: `nokia|alcatel-lucent|\bnsn\b`},
+	{Vendor: "Nokia", Source: SourceRDNS, Pattern: `nokia|\bnsn\b`},
+	{Vendor: "Nokia", Source: SourceBanner, Pattern: `nokia|alcatel-lucent|\btas\b`},
+	{Vendor: "Cisco", Source: SourceCert, Pattern: `cisco`},
+	{Vendor: "Cisco", Source: SourceRDNS, Pattern: `cisco`},
+	{Vendor: "Cisco", Source: SourceBanner, Pattern: `cisco|staros`},
+	{Vendor: "Cisco", Source: SourceIKE, Pattern: `cisco|^12f5f28c457168a9702d9fe274cc0100`}, // "Cisco Unity"
+	{Vendor: "Huawei", Source: SourceCert, Pattern: `huawei`},
+	{Vendor: "Huawei", Source: SourceRDNS, Pattern: `huawei`},
+	{Vendor: "Huawei", Source: SourceBanner, Pattern: `huawei`},
+	{Vendor: "ZTE", Source: SourceCert, Pattern: `\bzte\b`},
+	{Vendor: "ZTE", Source: SourceBanner, Pattern: `\bzte\b`},
+	{Vendor: "Mavenir", Source: SourceCert, Pattern: `mavenir`},
+	{Vendor: "Mavenir", Source: SourceRDNS, Pattern: `mavenir`},
+	{Vendor: "Mavenir", Source: SourceBanner, Pattern: `mavenir`},
+	{Vendor: "Samsung", Source: SourceCert, Pattern: `samsung`},
+	{Vendor: "Samsung", Source: SourceBanner, Pattern: `samsung`},
+	{Vendor: "Oracle", Source: SourceBanner, Pattern: `acme ?packet|oracle`},
+	{Vendor: "Oracle", Source: SourceCert, Pattern: `acme ?packet|oracle`},
+	{Vendor: "Metaswitch", Source: SourceBanner, Pattern: `metaswitch|perimeta`},
+	{Vendor: "Ribbon", Source: SourceBanner, Pattern: `ribbon|sonus`},
+	{Vendor: "strongSwan", Source: SourceIKE, Pattern: `strongswan|^882fe56d6fd20dbc2251613b2ebe5beb`},
+	{Vendor: "AWS-hosted", Source: SourceCNAME, Pattern: `\.amazonaws\.com\.?$|\.cloudfront\.net\.?$|\.awsglobalaccelerator\.com\.?$`},
+	{Vendor: "AWS-hosted", Source: SourceRDNS, Pattern: `\.amazonaws\.com\.?$`},
+	{Vendor: "AWS-hosted", Source: SourceCert, Pattern: `\bamazon\b`},
+	{Vendor: "Azure-hosted", Source: SourceCNAME, Pattern: `\.cloudapp\.azure\.com\.?$|\.azurewebsites\.net\.?$|\.trafficmanager\.net\.?$`},
+	{Vendor: "Azure-hosted", Source: SourceCert, Pattern: `microsoft azure`},
+	{Vendor: "GCP-hosted", Source: SourceRDNS, Pattern: `\.googleusercontent\.com\.?$`},
+	{Vendor: "GCP-hosted", Source: SourceCNAME, Pattern: `\.googleusercontent\.com\.?$`},
+}
+
+// Evidence is what is known about one endpoint
+type Evidence struct {
+	FQDN    string
+	IP      string
+	Cert    []string
+	RDNS    []string
+	IKE     []string
+	Banners []string
+	CNAMEs  []string
+}
+
+func (e *Evidence) values(source Source) []string {
+	switch source {
+	case SourceCert:
+		return e.Cert
+	case SourceRDNS:
+		return e.RDNS
+	case SourceIKE:
+		return e.IKE
+	case SourceBanner:
+		return e.Banners
+	case SourceCNAME:
+		return e.CNAMEs
+	}
+	return nil
+}
+
+// compiledRule is a rule with its pattern compiled
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Engine classifies endpoints with a rule set
+type Engine struct {
+	rules []compiledRule
+}
+
+// NewEngine compiles the rules
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{}
+	for _, r := range rules {
+		if r.Vendor == "" {
+			return nil, fmt.Errorf("rule %q: vendor required", r.Pattern)
+		}
+		if _, ok := defaultWeights[r.Source]; !ok {
+			return nil, fmt.Errorf("rule for %s: unknown source %q", r.Vendor, r.Source)
+		}
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule for %s: %w", r.Vendor, err)
+		}
+		if r.Weight <= 0 {
+			r.Weight = defaultWeights[r.Source]
+		}
+		e.rules = append(e.rules, compiledRule{Rule: r, re: re})
+	}
+	return e, nil
+}
+
+// LoadRules reads additional rules from a JSON array
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules JSON: %w", err)
+	}
+	return rules, nil
+}
+
+// Classify returns the most likely vendor of an endpoint, or nil when no
+// rule matches. Each source counts once per vendor, with the weight of its
+// strongest matching rule; the sum is capped at 1.
+func (e *Engine) Classify(ev Evidence) *models.VendorMatch {
+	type hit struct {
+		weight float64
+		signal string
+	}
+	hits := make(map[string]map[Source]hit)
+
+	for _, r := range e.rules {
+		for _, v := range ev.values(r.Source) {
+			if !r.re.MatchString(v) {
+				continue
+			}
+			if hits[r.Vendor] == nil {
+				hits[r.Vendor] = make(map[Source]hit)
+			}
+			if r.Weight > hits[r.Vendor][r.Source].weight {
+				hits[r.Vendor][r.Source] = hit{weight: r.Weight, signal: fmt.Sprintf("%s: %s", r.Source, v)}
+			}
+			break
+		}
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+
+	var best *models.VendorMatch
+	for vendor, sources := range hits {
+		m := &models.VendorMatch{FQDN: ev.FQDN, IP: ev.IP, Vendor: vendor}
+		score := 0.0
+		for _, h := range sources {
+			score += h.weight
+			m.Signals = append(m.Signals, h.signal)
+		}
+		sort.Strings(m.Signals)
+		m.Confidence = math.Round(math.Min(score, 1)*100) / 100
+		if best == nil || m.Confidence > best.Confidence || (m.Confidence == best.Confidence && m.Vendor < best.Vendor) {
+			best = m
+		}
+	}
+	return best
+}
+
+// ClassifyAll classifies every endpoint and keeps matches of at least
+// minConfidence, ordered by FQDN
+func (e *Engine) ClassifyAll(evidence []Evidence, minConfidence float64) []models.VendorMatch {
+	var matches []models.VendorMatch
+	for _, ev := range evidence {
+		if m := e.Classify(ev); m != nil && m.Confidence >= minConfidence {
+			matches = append(matches, *m)
+		}
+	}
+	return matches
+}
+
+// Collect gathers the evidence per FQDN from scan results and, when
+// available, HTTPS ping results and probe results
+func Collect(results []models.DNSResult, pings []models.PingResult, probes []models.ProbeResult) []Evidence {
+	byFQDN := make(map[string]*Evidence)
+	var order []string
+	get := func(fqdn string) *Evidence {
+		ev, ok := byFQDN[fqdn]
+		if !ok {
+			ev = &Evidence{FQDN: fqdn}
+			byFQDN[fqdn] = ev
+			order = append(order, fqdn)
+		}
+		return ev
+	}
+
+	for _, r := range results {
+		ev := get(r.FQDN)
+		if ev.IP == "" && len(r.IPs) > 0 {
+			ev.IP = r.IPs[0]
+		}
+		ev.CNAMEs = appendUnique(ev.CNAMEs, r.CNAMEs...)
+	}
+
+	for _, p := range pings {
+		ev := get(p.FQDN)
+		if ev.IP == "" {
+			ev.IP = p.IP
+		}
+		ev.Cert = appendUnique(ev.Cert, p.CertIssuer, p.CertSubject)
+		ev.Cert = appendUnique(ev.Cert, p.CertSANs...)
+		ev.Banners = appendUnique(ev.Banners, p.HTTPServer)
+	}
+
+	for _, p := range probes {
+		ev := get(p.FQDN)
+		if ev.IP == "" {
+			ev.IP = p.IP
+		}
+		for _, key := range []string{"server", "user_agent", "software", "http_server"} {
+			ev.Banners = appendUnique(ev.Banners, p.Details[key])
+		}
+		if ids := p.Details["vendor_ids"]; ids != "" {
+			ev.IKE = appendUnique(ev.IKE, strings.Split(ids, ",")...)
+		}
+		ev.IKE = appendUnique(ev.IKE, p.Details["vendor_id_text"])
+	}
+
+	sort.Strings(order)
+	evidence := make([]Evidence, 0, len(order))
+	for _, fqdn := range order {
+		evidence = append(evidence, *byFQDN[fqdn])
+	}
+	return evidence
+}
+
+// LookupFunc resolves the PTR names of an address
+type LookupFunc func(ctx context.Context, addr string) ([]string, error)
+
+// AddRDNS fills in the PTR names of every endpoint IP, looking up each
+// distinct IP once with the given number of workers. Failed lookups leave
+// the names empty.
+func AddRDNS(ctx context.Context, evidence []Evidence, workers int, lookup LookupFunc) {
+	if workers <= 0 {
+		workers = 1
+	}
+	ips := make(map[string]bool)
+	for _, ev := range evidence {
+		if ev.IP != "" {
+			ips[ev.IP] = true
+		}
+	}
+
+	jobs := make(chan string, len(ips))
+	for ip := range ips {
+		jobs <- ip
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	names := make(map[string][]string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				ptrs, err := lookup(ctx, ip)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				names[ip] = ptrs
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range evidence {
+		evidence[i].RDNS = names[evidence[i].IP]
+	}
+}
+
+// VendorCounts counts matches per vendor
+func VendorCounts(matches []models.VendorMatch) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.Vendor]++
+	}
+	return counts
+}
+
+// FormatMatches renders vendor matches as a table
+func FormatMatches(matches []models.VendorMatch) string {
+	var sb strings.Builder
+	sb.WriteString("=== Endpoint Vendors ===\n\n")
+	if len(matches) == 0 {
+		sb.WriteString("No endpoint matched a fingerprint rule.\n")
+		return sb.String()
+	}
+
+	fqdnWidth, vendorWidth := len("FQDN"), len("Vendor")
+	for _, m := range matches {
+		fqdnWidth = max(fqdnWidth, len(m.FQDN))
+		vendorWidth = max(vendorWidth, len(m.Vendor))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s  %-*s  %s  %s\n", fqdnWidth, "FQDN", vendorWidth, "Vendor", "Confidence", "Signals"))
+	for _, m := range matches {
+		sb.WriteString(fmt.Sprintf("%-*s  %-*s  %10.2f  %s\n",
+			fqdnWidth, m.FQDN, vendorWidth, m.Vendor, m.Confidence, strings.Join(m.Signals, "; ")))
+	}
+	return sb.String()
+}
+
+// appendUnique appends the non-empty values not yet in list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		seen := false
+		for _, existing := range list {
+			if existing == v {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,98 @@
+package fingerprint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestClassify(t *testing.T) {
+	engine, err := NewEngine(DefaultRules)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	// Banner and certificate agree: two sources add up
+	m := engine.Classify(Evidence{
+		FQDN:    "ims.mnc001.mcc262.pub.3gppnetwork.org",
+		Cert:    []string{"CN=ims-ca,O=Ericsson AB"},
+		Banners: []string{"Ericsson SBG 1.0"},
+	})
+	if m == nil || m.Vendor != "Ericsson" || m.Confidence != 0.9 || len(m.Signals) != 2 {
+		t.Fatalf("Expected Ericsson at 0.9 from two signals, got %+v", m)
+	}
+
+	// The strongest vendor wins over a weaker one
+	m = engine.Classify(Evidence{
+		CNAMEs: []string{"epdg-lb.eu-central-1.elb.amazonaws.com."},
+		IKE:    []string{"882fe56d6fd20dbc2251613b2ebe5beb"},
+		RDNS:   []string{"ec2-3-120-1-1.eu-central-1.compute.amazonaws.com."},
+	})
+	if m == nil || m.Vendor != "AWS-hosted" || m.Confidence != 0.7 {
+		t.Errorf("Expected AWS-hosted at 0.7, got %+v", m)
+	}
+
+	if m := engine.Classify(Evidence{Banners: []string{"nginx"}}); m != nil {
+		t.Errorf("Expected no match for a generic banner, got %+v", m)
+	}
+}
+
+func TestNewEngineRejectsBadRules(t *testing.T) {
+	for _, rules := range [][]Rule{
+		{{Vendor: "Acme", Source: "smtp", Pattern: "acme"}},
+		{{Vendor: "Acme", Source: SourceBanner, Pattern: "("}},
+		{{Source: SourceBanner, Pattern: "acme"}},
+	} {
+		if _, err := NewEngine(rules); err == nil {
+			t.Errorf("Expected error for %+v", rules)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	results := []models.DNSResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1"}, CNAMEs: []string{"lb.example.net."}},
+	}
+	pings := []models.PingResult{
+		{FQDN: "bsf.mnc001.mcc262.pub.3gppnetwork.org", IP: "192.0.2.2", Port: 443, CertIssuer: "CN=Nokia CA", CertSANs: []string{"bsf.example"}, HTTPServer: "Apache"},
+	}
+	probes := []models.ProbeResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Probe: "ike", Details: map[string]string{"vendor_ids": "aa,bb", "vendor_id_text": "Acme"}},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Probe: "stun", Details: map[string]string{"software": "Acme STUN"}},
+	}
+
+	evidence := Collect(results, pings, probes)
+	if len(evidence) != 2 {
+		t.Fatalf("Expected evidence for 2 FQDNs, got %d", len(evidence))
+	}
+	bsf, epdg := evidence[0], evidence[1]
+	if bsf.IP != "192.0.2.2" || len(bsf.Cert) != 2 || bsf.Banners[0] != "Apache" {
+		t.Errorf("Unexpected BSF evidence: %+v", bsf)
+	}
+	if epdg.IP != "192.0.2.1" || strings.Join(epdg.IKE, ",") != "aa,bb,Acme" || epdg.Banners[0] != "Acme STUN" || len(epdg.CNAMEs) != 1 {
+		t.Errorf("Unexpected ePDG evidence: %+v", epdg)
+	}
+}
+
+func TestAddRDNS(t *testing.T) {
+	evidence := []Evidence{{FQDN: "a", IP: "192.0.2.1"}, {FQDN: "b", IP: "192.0.2.1"}, {FQDN: "c", IP: "192.0.2.9"}}
+	lookups := 0
+	lookup := func(ctx context.Context, addr string) ([]string, error) {
+		lookups++
+		if addr == "192.0.2.9" {
+			return nil, fmt.Errorf("no PTR")
+		}
+		return []string{"host.ericsson.example."}, nil
+	}
+
+	AddRDNS(context.Background(), evidence, 1, lookup)
+	if lookups != 2 {
+		t.Errorf("Expected one lookup per distinct IP, got %d", lookups)
+	}
+	if len(evidence[0].RDNS) != 1 || len(evidence[1].RDNS) != 1 || evidence[2].RDNS != nil {
+		t.Errorf("Unexpected PTR names: %+v", evidence)
+	}
+}
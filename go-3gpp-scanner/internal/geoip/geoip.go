@@ -0,0 +1,229 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Location is the approximate position of an IP address
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Country   string  `json:"country,omitempty"`
+	City      string  `json:"city,omitempty"`
+}
+
+// block is one network range of the database
+type block struct {
+	start, end net.IP // 16-byte form
+	loc        Location
+}
+
+// DB is an in-memory GeoIP database loaded from CSV
+type DB struct {
+	blocks []block
+}
+
+// Open loads a GeoIP CSV file. The header must name a "network" column
+// (CIDR) and "latitude" and "longitude" columns, as in the MaxMind
+// GeoLite2-City-Blocks files; optional "country" (or "country_iso_code")
+// and "city" (or "city_name") columns are used when present. Rows without
+// coordinates are skipped.
+func Open(path string) (*DB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer file.Close()
+
+	db, err := Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Load reads a GeoIP CSV from r; see Open for the format
+func Load(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	networkCol, ok := cols["network"]
+	latCol, okLat := cols["latitude"]
+	lonCol, okLon := cols["longitude"]
+	if !ok || !okLat || !okLon {
+		return nil, fmt.Errorf("header must contain network, latitude and longitude columns")
+	}
+	countryCol := column(cols, "country", "country_iso_code")
+	cityCol := column(cols, "city", "city_name")
+
+	db := &DB{}
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		lat, errLat := strconv.ParseFloat(field(record, latCol), 64)
+		lon, errLon := strconv.ParseFloat(field(record, lonCol), 64)
+		if errLat != nil || errLon != nil {
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(field(record, networkCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid network: %w", line, err)
+		}
+
+		db.blocks = append(db.blocks, block{
+			start: network.IP.To16(),
+			end:   lastIP(network),
+			loc: Location{
+				Latitude:  lat,
+				Longitude: lon,
+				Country:   field(record, countryCol),
+				City:      field(record, cityCol),
+			},
+		})
+	}
+
+	sort.Slice(db.blocks, func(i, j int) bool {
+		return bytes.Compare(db.blocks[i].start, db.blocks[j].start) < 0
+	})
+	return db, nil
+}
+
+// Len returns the number of networks in the database
+func (db *DB) Len() int {
+	return len(db.blocks)
+}
+
+// Lookup returns the location of an IP address
+func (db *DB) Lookup(ipStr string) (Location, bool) {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return Location{}, false
+	}
+	ip = ip.To16()
+
+	// Last block starting at or before ip
+	i := sort.Search(len(db.blocks), func(i int) bool {
+		return bytes.Compare(db.blocks[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, db.blocks[i].end) > 0 {
+		return Location{}, false
+	}
+	return db.blocks[i].loc, true
+}
+
+// column returns the index of the first present column name, or -1
+func column(cols map[string]int, names ...string) int {
+	for _, name := range names {
+		if i, ok := cols[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// field returns a trimmed record field, or "" when the column is absent
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// lastIP returns the highest address of a network in 16-byte form
+func lastIP(network *net.IPNet) net.IP {
+	ip := network.IP.To16()
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		// Align an IPv4 mask with the 16-byte representation
+		mask = append(net.CIDRMask(96, 128)[:12], mask...)
+	}
+
+	last := make(net.IP, net.IPv6len)
+	for i := range ip {
+		last[i] = ip[i] | ^mask[i]
+	}
+	return last
+}
+
+// Locate groups the IPs of DNS results into located endpoints, one per
+// address, and reports how many addresses could not be located
+func (db *DB) Locate(results []models.DNSResult) ([]models.GeoEndpoint, int) {
+	byIP := make(map[string]*models.GeoEndpoint)
+	var order []string
+	unlocated := make(map[string]bool)
+
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			endpoint, ok := byIP[ip]
+			if !ok {
+				if unlocated[ip] {
+					continue
+				}
+				loc, found := db.Lookup(ip)
+				if !found {
+					unlocated[ip] = true
+					continue
+				}
+				endpoint = &models.GeoEndpoint{
+					IP:        ip,
+					Latitude:  loc.Latitude,
+					Longitude: loc.Longitude,
+					Country:   loc.Country,
+					City:      loc.City,
+				}
+				byIP[ip] = endpoint
+				order = append(order, ip)
+			}
+
+			endpoint.FQDNs = appendUnique(endpoint.FQDNs, result.FQDN)
+			if result.Operator != "" {
+				endpoint.Operators = appendUnique(endpoint.Operators, result.Operator)
+			}
+		}
+	}
+
+	endpoints := make([]models.GeoEndpoint, 0, len(order))
+	for _, ip := range order {
+		endpoints = append(endpoints, *byIP[ip])
+	}
+	return endpoints, len(unlocated)
+}
+
+// appendUnique appends value unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,84 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+const testCSV = `network,geoname_id,latitude,longitude,country_iso_code,city_name
+192.0.2.0/24,1,52.52,13.40,DE,Berlin
+198.51.100.0/25,2,40.71,-74.00,US,New York
+203.0.113.0/24,3,,,,
+2001:db8::/32,4,48.85,2.35,FR,Paris
+`
+
+func TestLookup(t *testing.T) {
+	db, err := Load(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if db.Len() != 3 {
+		t.Errorf("Expected 3 networks (row without coordinates skipped), got %d", db.Len())
+	}
+
+	tests := []struct {
+		ip      string
+		found   bool
+		country string
+	}{
+		{"192.0.2.1", true, "DE"},
+		{"192.0.2.255", true, "DE"},
+		{"198.51.100.127", true, "US"},
+		{"198.51.100.128", false, ""},
+		{"203.0.113.5", false, ""},
+		{"2001:db8::1", true, "FR"},
+		{"10.0.0.1", false, ""},
+		{"not-an-ip", false, ""},
+	}
+
+	for _, tt := range tests {
+		loc, ok := db.Lookup(tt.ip)
+		if ok != tt.found {
+			t.Errorf("Lookup(%s) found=%t, expected %t", tt.ip, ok, tt.found)
+			continue
+		}
+		if loc.Country != tt.country {
+			t.Errorf("Lookup(%s) country=%q, expected %q", tt.ip, loc.Country, tt.country)
+		}
+	}
+
+	loc, _ := db.Lookup("192.0.2.1")
+	if loc.City != "Berlin" || loc.Latitude != 52.52 || loc.Longitude != 13.40 {
+		t.Errorf("Unexpected location: %+v", loc)
+	}
+}
+
+func TestLoadRequiresColumns(t *testing.T) {
+	if _, err := Load(strings.NewReader("ip,lat,lon\n")); err == nil {
+		t.Errorf("Expected error for missing columns")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	db, err := Load(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	endpoints, unlocated := db.Locate([]models.DNSResult{
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "10.0.0.1"}, Operator: "Telekom"},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1"}, Operator: "Telekom"},
+	})
+
+	if unlocated != 1 {
+		t.Errorf("Expected 1 unlocated IP, got %d", unlocated)
+	}
+	if len(endpoints) != 1 {
+		t.Fatalf("Expected 1 endpoint, got %d", len(endpoints))
+	}
+	if len(endpoints[0].FQDNs) != 2 || len(endpoints[0].Operators) != 1 {
+		t.Errorf("Expected 2 FQDNs and 1 operator, got %+v", endpoints[0])
+	}
+}
@@ -0,0 +1,204 @@
+package geoip
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/countries"
+	"3gpp-scanner/internal/models"
+)
+
+// KmPerRTTMillisecond bounds the distance to an endpoint by its round-trip
+// time: light in fiber covers about 200 km per millisecond, there and back.
+// Routing detours and processing only make the real distance shorter.
+const KmPerRTTMillisecond = 100
+
+// countrySlackKm allows for the extent of a country around its centroid
+// when checking whether it lies within reach of a vantage point
+const countrySlackKm = 500
+
+// earthRadiusKm is the mean radius of the earth
+const earthRadiusKm = 6371
+
+// Vantage is a point latencies were measured from
+type Vantage struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// LatencySample is the lowest round-trip time to an endpoint seen from one
+// vantage point, and the distance it allows at most
+type LatencySample struct {
+	Vantage string        `json:"vantage"`
+	RTT     time.Duration `json:"rtt"`
+	MaxKm   float64       `json:"max_km"`
+}
+
+// LatencyEstimate is the rough location of an endpoint from the latencies
+// measured to it from several vantage points
+type LatencyEstimate struct {
+	IP      string          `json:"ip"`
+	FQDNs   []string        `json:"fqdns"`
+	Samples []LatencySample `json:"samples"` // Nearest vantage first
+	// Anycast is set when no single location is within reach of every
+	// vantage point: several instances answer, each near some vantage
+	Anycast bool `json:"anycast"`
+	// Countries whose centroid is within reach of every vantage point;
+	// for anycast endpoints, of the nearest one only
+	Countries []string `json:"countries,omitempty"`
+	// GeoIP location and whether the latencies rule it out
+	GeoIP         *Location `json:"geoip,omitempty"`
+	Contradiction string    `json:"contradiction,omitempty"`
+}
+
+// EstimateLocations estimates the location of every successfully pinged
+// IP from ping results per vantage point. A non-nil db supplies GeoIP
+// locations to check against the latencies. Estimates are ordered by IP.
+func EstimateLocations(vantages []Vantage, pings map[string][]models.PingResult, db *DB) []LatencyEstimate {
+	type endpoint struct {
+		fqdns []string
+		rtts  map[string]time.Duration // Lowest per vantage
+	}
+	byIP := make(map[string]*endpoint)
+	for _, v := range vantages {
+		for _, p := range pings[v.Name] {
+			if !p.Success || p.IP == "" || p.Latency <= 0 {
+				continue
+			}
+			e := byIP[p.IP]
+			if e == nil {
+				e = &endpoint{rtts: make(map[string]time.Duration)}
+				byIP[p.IP] = e
+			}
+			e.fqdns = appendUnique(e.fqdns, p.FQDN)
+			if rtt, ok := e.rtts[v.Name]; !ok || p.Latency < rtt {
+				e.rtts[v.Name] = p.Latency
+			}
+		}
+	}
+
+	estimates := make([]LatencyEstimate, 0, len(byIP))
+	for ip, e := range byIP {
+		var samples []LatencySample
+		located := make([]Vantage, 0, len(e.rtts))
+		for _, v := range vantages {
+			if rtt, ok := e.rtts[v.Name]; ok {
+				samples = append(samples, LatencySample{Vantage: v.Name, RTT: rtt, MaxKm: maxDistance(rtt)})
+				located = append(located, v)
+			}
+		}
+		var geo *Location
+		if db != nil {
+			if loc, ok := db.Lookup(ip); ok {
+				geo = &loc
+			}
+		}
+		estimate := Estimate(located, samples, geo)
+		estimate.IP, estimate.FQDNs = ip, e.fqdns
+		estimates = append(estimates, estimate)
+	}
+	sort.Slice(estimates, func(i, j int) bool { return estimates[i].IP < estimates[j].IP })
+	return estimates
+}
+
+// Estimate judges the samples of one endpoint, taken from vantages in the
+// same order, against each other and against a GeoIP location (optional)
+func Estimate(vantages []Vantage, samples []LatencySample, geo *Location) LatencyEstimate {
+	estimate := LatencyEstimate{GeoIP: geo}
+	byName := make(map[string]Vantage, len(vantages))
+	for _, v := range vantages {
+		byName[v.Name] = v
+	}
+	sorted := append([]LatencySample(nil), samples...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RTT < sorted[j].RTT })
+	estimate.Samples = sorted
+	if len(sorted) == 0 {
+		return estimate
+	}
+
+	// Two vantages whose reach does not overlap see different instances
+	for i, a := range sorted {
+		for _, b := range sorted[i+1:] {
+			if Distance(byName[a.Vantage], byName[b.Vantage]) > a.MaxKm+b.MaxKm {
+				estimate.Anycast = true
+			}
+		}
+	}
+
+	within := sorted
+	if estimate.Anycast {
+		within = sorted[:1]
+	}
+	for _, c := range countries.All() {
+		point := Vantage{Latitude: c.Latitude, Longitude: c.Longitude}
+		reachable := true
+		for _, s := range within {
+			if Distance(byName[s.Vantage], point) > s.MaxKm+countrySlackKm {
+				reachable = false
+				break
+			}
+		}
+		if reachable {
+			estimate.Countries = append(estimate.Countries, c.Code)
+		}
+	}
+
+	// An anycast endpoint is where GeoIP says for some vantage point at
+	// best, so only the nearest instance is checked
+	if geo != nil {
+		point := Vantage{Latitude: geo.Latitude, Longitude: geo.Longitude}
+		for _, s := range within {
+			if d := Distance(byName[s.Vantage], point); d > s.MaxKm {
+				where := "location"
+				if geo.Country != "" {
+					where = "country " + geo.Country
+				}
+				estimate.Contradiction = fmt.Sprintf("GeoIP %s is %.0f km from %s, but %s RTT allows at most %.0f km",
+					where, d, s.Vantage, formatRTT(s.RTT), s.MaxKm)
+				break
+			}
+		}
+	}
+	return estimate
+}
+
+// Summary describes the estimate in one line
+func (e LatencyEstimate) Summary() string {
+	if len(e.Samples) == 0 {
+		return "no latencies"
+	}
+	nearest := e.Samples[0]
+	var b strings.Builder
+	if e.Anycast {
+		b.WriteString("anycast; nearest instance ")
+	}
+	fmt.Fprintf(&b, "within %.0f km of %s (%s)", nearest.MaxKm, nearest.Vantage, formatRTT(nearest.RTT))
+	if len(e.Countries) > 0 && len(e.Countries) <= 10 {
+		fmt.Fprintf(&b, ", %s", strings.Join(e.Countries, " "))
+	} else if len(e.Countries) > 10 {
+		fmt.Fprintf(&b, ", %d countries", len(e.Countries))
+	}
+	return b.String()
+}
+
+// maxDistance is the farthest an endpoint can be at a round-trip time
+func maxDistance(rtt time.Duration) float64 {
+	return float64(rtt) / float64(time.Millisecond) * KmPerRTTMillisecond
+}
+
+// Distance returns the great-circle distance between two points in km
+func Distance(a, b Vantage) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func formatRTT(rtt time.Duration) string {
+	return fmt.Sprintf("%.1f ms", float64(rtt)/float64(time.Millisecond))
+}
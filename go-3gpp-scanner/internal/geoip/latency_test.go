@@ -0,0 +1,73 @@
+package geoip
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+var (
+	frankfurt = Vantage{Name: "fra", Latitude: 50.11, Longitude: 8.68}
+	ashburn   = Vantage{Name: "iad", Latitude: 39.04, Longitude: -77.49}
+	singapore = Vantage{Name: "sin", Latitude: 1.35, Longitude: 103.82}
+)
+
+func TestDistance(t *testing.T) {
+	if d := Distance(frankfurt, ashburn); math.Abs(d-6600) > 150 {
+		t.Errorf("Distance(Frankfurt, Ashburn) = %.0f km, want about 6600", d)
+	}
+	if d := Distance(frankfurt, frankfurt); d != 0 {
+		t.Errorf("Distance to itself = %f", d)
+	}
+}
+
+func TestEstimateLocations(t *testing.T) {
+	ms := func(n float64) time.Duration { return time.Duration(n * float64(time.Millisecond)) }
+	ping := func(fqdn, ip string, rtt time.Duration) models.PingResult {
+		return models.PingResult{FQDN: fqdn, IP: ip, Success: true, Latency: rtt}
+	}
+	const unicast, anycast = "20.1.1.1", "45.3.3.3"
+	pings := map[string][]models.PingResult{
+		"fra": {ping("epdg.example", unicast, ms(3)), ping("epdg2.example", unicast, ms(2.5)), ping("ims.example", anycast, ms(1.5))},
+		"iad": {ping("epdg.example", unicast, ms(90)), ping("ims.example", anycast, ms(2))},
+		"sin": {ping("epdg.example", unicast, ms(160)), {FQDN: "ims.example", IP: anycast, Status: models.StatusTimeout}},
+	}
+	db, err := Load(strings.NewReader("network,latitude,longitude,country\n20.1.1.0/24,-14.2,-51.9,BR\n45.3.3.0/24,50.1,8.7,DE\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	estimates := EstimateLocations([]Vantage{frankfurt, ashburn, singapore}, pings, db)
+	if len(estimates) != 2 || estimates[0].IP != unicast || estimates[1].IP != anycast {
+		t.Fatalf("Unexpected estimates: %+v", estimates)
+	}
+
+	u := estimates[0]
+	if u.Anycast || len(u.Samples) != 3 || u.Samples[0].Vantage != "fra" || u.Samples[0].RTT != ms(2.5) || u.Samples[0].MaxKm != 250 {
+		t.Errorf("Unexpected unicast samples: %+v", u)
+	}
+	if len(u.FQDNs) != 2 || !slices.Contains(u.Countries, "DE") || slices.Contains(u.Countries, "US") {
+		t.Errorf("Unexpected unicast FQDNs or countries: %v %v", u.FQDNs, u.Countries)
+	}
+	if !strings.Contains(u.Contradiction, "GeoIP country BR is") || !strings.Contains(u.Contradiction, "from fra, but 2.5 ms RTT allows at most 250 km") {
+		t.Errorf("Expected the GeoIP country to be contradicted, got %q", u.Contradiction)
+	}
+	if want := "within 250 km of fra (2.5 ms)"; !strings.HasPrefix(u.Summary(), want) {
+		t.Errorf("Summary = %q, want prefix %q", u.Summary(), want)
+	}
+
+	a := estimates[1]
+	if !a.Anycast || len(a.Samples) != 2 || a.Contradiction != "" {
+		t.Errorf("Expected an anycast endpoint consistent with GeoIP, got %+v", a)
+	}
+	if !slices.Contains(a.Countries, "DE") || slices.Contains(a.Countries, "US") {
+		t.Errorf("Expected countries near the nearest instance, got %v", a.Countries)
+	}
+	if !strings.HasPrefix(a.Summary(), "anycast; nearest instance within 150 km of fra") {
+		t.Errorf("Unexpected summary: %q", a.Summary())
+	}
+}
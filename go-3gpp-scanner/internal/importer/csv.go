@@ -0,0 +1,329 @@
+// Package importer reads FQDN datasets from other tools and spreadsheets
+// into scan results that can be stored in the database.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+)
+
+// Fields a CSV column can be mapped to
+const (
+	FieldFQDN        = "fqdn"
+	FieldOperator    = "operator"
+	FieldIP          = "ip"
+	FieldMCC         = "mcc"
+	FieldMNC         = "mnc"
+	FieldSubdomain   = "subdomain"
+	FieldTimestamp   = "timestamp"
+	FieldCountry     = "country"
+	FieldCountryCode = "country_code"
+)
+
+var fields = []string{FieldFQDN, FieldOperator, FieldIP, FieldMCC, FieldMNC, FieldSubdomain, FieldTimestamp, FieldCountry, FieldCountryCode}
+
+// timestampLayouts are the timestamp formats accepted in CSV files
+var timestampLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// Mapping assigns CSV columns to result fields. A column is a header name
+// (case-insensitive) or a 1-based position. Only ip may take several
+// columns, e.g. one for IPv4 and one for IPv6.
+type Mapping map[string][]string
+
+// ParseMapping parses "field=column,field=column", e.g.
+// "fqdn=Host,operator=Carrier,ip=IPv4,ip=IPv6". fqdn is required.
+func ParseMapping(spec string) (Mapping, error) {
+	m := make(Mapping)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q: must be field=column", pair)
+		}
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q (must be one of %s)", field, strings.Join(fields, ", "))
+		}
+		if len(m[field]) > 0 && field != FieldIP {
+			return nil, fmt.Errorf("field %s mapped twice", field)
+		}
+		m[field] = append(m[field], column)
+	}
+	if len(m[FieldFQDN]) == 0 {
+		return nil, fmt.Errorf("mapping must include fqdn")
+	}
+	return m, nil
+}
+
+func isField(name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CSVOptions configures ReadCSV
+type CSVOptions struct {
+	Comma    rune      // Field delimiter; 0 selects ','
+	NoHeader bool      // The first row is data; columns must be positions
+	Now      time.Time // Timestamp of rows without one
+}
+
+// Skipped is a row left out of an import
+type Skipped struct {
+	Line   int // Line of a CSV file, or rowid of a database row
+	Reason string
+}
+
+// Summary reports what an import read
+type Summary struct {
+	Rows    int       // Data rows read
+	Results int       // Distinct FQDNs
+	Merged  int       // Rows merged into an earlier row of the same FQDN
+	BadIPs  int       // IP cells that were not IP addresses, ignored
+	Skipped []Skipped // Rows without a usable FQDN, MCC/MNC or timestamp
+}
+
+// ReadCSV reads results from CSV data according to a mapping. Rows of the
+// same FQDN are merged, collecting their IPs. Unmapped MCC, MNC and
+// subdomain are derived from 3GPP FQDNs (mncXXX.mccYYY).
+func ReadCSV(r io.Reader, m Mapping, opts CSVOptions) ([]models.DNSResult, *Summary, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	var header []string
+	if !opts.NoHeader {
+		var err error
+		header, err = reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil, fmt.Errorf("empty CSV file")
+			}
+			return nil, nil, fmt.Errorf("failed to read header: %w", err)
+		}
+	}
+	columns, err := resolveColumns(m, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := newCollector(columns, opts.Now)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		c.add(line, record)
+	}
+	results, summary := c.finish()
+	return results, summary, nil
+}
+
+// collector turns records into results, merging rows of the same FQDN
+type collector struct {
+	columns map[string][]int
+	now     time.Time
+	summary Summary
+	results []models.DNSResult
+	index   map[string]int // FQDN to position in results
+}
+
+func newCollector(columns map[string][]int, now time.Time) *collector {
+	return &collector{columns: columns, now: now, index: make(map[string]int)}
+}
+
+// add parses one record; line locates it in skip reports
+func (c *collector) add(line int, record []string) {
+	c.summary.Rows++
+	result, badIPs, reason := parseRow(record, c.columns, c.now)
+	c.summary.BadIPs += badIPs
+	if reason != "" {
+		c.summary.Skipped = append(c.summary.Skipped, Skipped{Line: line, Reason: reason})
+		return
+	}
+
+	if i, ok := c.index[result.FQDN]; ok {
+		merged := &c.results[i]
+		merged.IPs = mergeIPs(merged.IPs, result.IPs)
+		if merged.Operator == "" {
+			merged.Operator = result.Operator
+		}
+		if merged.Country == "" {
+			merged.Country = result.Country
+		}
+		if merged.CountryCode == "" {
+			merged.CountryCode = result.CountryCode
+		}
+		if result.Timestamp.After(merged.Timestamp) {
+			merged.Timestamp = result.Timestamp
+		}
+		c.summary.Merged++
+		return
+	}
+	c.index[result.FQDN] = len(c.results)
+	c.results = append(c.results, result)
+}
+
+func (c *collector) finish() ([]models.DNSResult, *Summary) {
+	c.summary.Results = len(c.results)
+	return c.results, &c.summary
+}
+
+// resolveColumns turns the mapped columns into record positions
+func resolveColumns(m Mapping, header []string) (map[string][]int, error) {
+	byName := make(map[string]int)
+	for i, name := range header {
+		byName[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	columns := make(map[string][]int)
+	for field, names := range m {
+		for _, name := range names {
+			if pos, err := strconv.Atoi(name); err == nil {
+				if pos < 1 {
+					return nil, fmt.Errorf("invalid column %d for %s: positions start at 1", pos, field)
+				}
+				columns[field] = append(columns[field], pos-1)
+				continue
+			}
+			if header == nil {
+				return nil, fmt.Errorf("column %q for %s: without a header, columns must be positions", name, field)
+			}
+			i, ok := byName[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("column %q for %s not in header (%s)", name, field, strings.Join(header, ", "))
+			}
+			columns[field] = append(columns[field], i)
+		}
+	}
+	return columns, nil
+}
+
+// parseRow builds a result from one record, or returns why it is skipped
+func parseRow(record []string, columns map[string][]int, now time.Time) (result models.DNSResult, badIPs int, reason string) {
+	cell := func(field string) string {
+		if cols := columns[field]; len(cols) > 0 && cols[0] < len(record) {
+			return strings.TrimSpace(record[cols[0]])
+		}
+		return ""
+	}
+
+	result.FQDN = strings.TrimSuffix(strings.ToLower(cell(FieldFQDN)), ".")
+	if result.FQDN == "" || !strings.Contains(result.FQDN, ".") || strings.ContainsAny(result.FQDN, " \t/") {
+		return result, 0, fmt.Sprintf("invalid FQDN %q", result.FQDN)
+	}
+	result.Operator = cell(FieldOperator)
+	result.Country = cell(FieldCountry)
+	result.CountryCode = strings.ToUpper(cell(FieldCountryCode))
+	result.Status = models.StatusOK
+
+	// MNC and MCC from their columns, keeping their digits, else from the
+	// FQDN
+	_, mnc, mcc, inZone := dns.ZoneFromFQDN(result.FQDN, "")
+	for _, c := range []struct {
+		field     string
+		value     *string
+		found     string
+		normalize func(string) string
+	}{{FieldMCC, &result.MCC, mcc, models.NormalizeMCC}, {FieldMNC, &result.MNC, mnc, models.NormalizeMNC}} {
+		s := cell(c.field)
+		if s == "" {
+			if !inZone {
+				return result, 0, fmt.Sprintf("no %s for %s", c.field, result.FQDN)
+			}
+			*c.value = c.found
+			continue
+		}
+		if !models.IsCode(s) {
+			return result, 0, fmt.Sprintf("invalid %s %q", c.field, s)
+		}
+		*c.value = c.normalize(s)
+	}
+
+	result.Subdomain = cell(FieldSubdomain)
+	if result.Subdomain == "" && inZone {
+		if i := strings.Index(result.FQDN, ".mnc"); i > 0 {
+			result.Subdomain = result.FQDN[:i]
+		}
+	}
+
+	result.Timestamp = now
+	if s := cell(FieldTimestamp); s != "" {
+		t, err := parseTimestamp(s)
+		if err != nil {
+			return result, 0, fmt.Sprintf("invalid timestamp %q", s)
+		}
+		result.Timestamp = t
+	}
+
+	for _, col := range columns[FieldIP] {
+		if col >= len(record) {
+			continue
+		}
+		for _, token := range strings.FieldsFunc(record[col], func(r rune) bool {
+			return r == ';' || r == ',' || r == '|' || r == ' ' || r == '\t' || r == '\n'
+		}) {
+			if ip := net.ParseIP(token); ip != nil {
+				result.IPs = append(result.IPs, ip.String())
+			} else {
+				badIPs++
+			}
+		}
+	}
+	result.IPs = mergeIPs(nil, result.IPs)
+	return result, badIPs, ""
+}
+
+// parseTimestamp parses one of timestampLayouts or Unix seconds
+func parseTimestamp(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format")
+}
+
+// mergeIPs returns the sorted union of two IP lists
+func mergeIPs(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, ip := range append(append([]string{}, a...), b...) {
+		if !seen[ip] {
+			seen[ip] = true
+			merged = append(merged, ip)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
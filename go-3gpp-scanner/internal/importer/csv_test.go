@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMapping(t *testing.T) {
+	m, err := ParseMapping("FQDN=Host, operator=Carrier,ip=IPv4,ip=IPv6")
+	if err != nil {
+		t.Fatalf("ParseMapping failed: %v", err)
+	}
+	if m[FieldFQDN][0] != "Host" || m[FieldOperator][0] != "Carrier" || len(m[FieldIP]) != 2 {
+		t.Errorf("unexpected mapping: %v", m)
+	}
+
+	for _, spec := range []string{"", "operator=Carrier", "fqdn", "fqdn=", "fqdn=A,host=B", "fqdn=A,fqdn=B"} {
+		if _, err := ParseMapping(spec); err == nil {
+			t.Errorf("ParseMapping(%q): expected error", spec)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "\ufeffHostname,Carrier,Address,Seen\n" +
+		"EPDG.EPC.MNC001.MCC262.PUB.3GPPNETWORK.ORG.,Telekom,192.0.2.1; 192.0.2.2,2024-05-01\n" +
+		"ims.mnc001.mcc262.pub.3gppnetwork.org,Telekom,n/a,2024-05-01T10:00:00Z\n" +
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,,2001:db8::1,2024-06-01 12:00:00\n" +
+		"vpn.example.com,Other,198.51.100.1,\n" +
+		",Nobody,,\n" +
+		"bsf.mnc002.mcc262.pub.3gppnetwork.org,Vodafone,,yesterday\n"
+	m, _ := ParseMapping("fqdn=hostname,operator=Carrier,ip=Address,timestamp=Seen")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	results, summary, err := ReadCSV(strings.NewReader(data), m, CSVOptions{Now: now})
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if summary.Rows != 6 || summary.Results != 2 || summary.Merged != 1 || summary.BadIPs != 1 || len(summary.Skipped) != 3 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	epdg := results[0]
+	if epdg.FQDN != "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org" || epdg.Operator != "Telekom" {
+		t.Errorf("unexpected first result: %+v", epdg)
+	}
+	if epdg.MCC != "262" || epdg.MNC != "001" || epdg.Subdomain != "epdg.epc" {
+		t.Errorf("MCC/MNC/subdomain not derived: %+v", epdg)
+	}
+	if got := strings.Join(epdg.IPs, ","); got != "192.0.2.1,192.0.2.2,2001:db8::1" {
+		t.Errorf("merged IPs = %s", got)
+	}
+	if !epdg.Timestamp.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("merged timestamp = %v, want the latest", epdg.Timestamp)
+	}
+	if len(results[1].IPs) != 0 {
+		t.Errorf("non-IP value kept: %v", results[1].IPs)
+	}
+
+	reasons := make([]string, len(summary.Skipped))
+	for i, s := range summary.Skipped {
+		reasons[i] = s.Reason
+	}
+	want := []string{"no mcc for vpn.example.com", `invalid FQDN ""`, `invalid timestamp "yesterday"`}
+	if strings.Join(reasons, "|") != strings.Join(want, "|") {
+		t.Errorf("skip reasons = %q, want %q", reasons, want)
+	}
+	if summary.Skipped[0].Line != 5 {
+		t.Errorf("skipped line = %d, want 5", summary.Skipped[0].Line)
+	}
+}
+
+func TestReadCSVCountry(t *testing.T) {
+	data := "fqdn,country,iso\n" +
+		"ims.mnc001.mcc262.pub.3gppnetwork.org,,\n" +
+		"ims.mnc001.mcc262.pub.3gppnetwork.org,Germany,de\n"
+	m, _ := ParseMapping("fqdn=fqdn,country=country,country_code=iso")
+
+	results, _, err := ReadCSV(strings.NewReader(data), m, CSVOptions{})
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Country != "Germany" || results[0].CountryCode != "DE" {
+		t.Errorf("country not merged: %+v", results)
+	}
+}
+
+func TestReadCSVPositions(t *testing.T) {
+	data := "vpn.example.com;Example;310;260;10.0.0.1|10.0.0.2\n" +
+		"vpn2.example.com;Example;310;abc;\n"
+	m, _ := ParseMapping("fqdn=1,operator=2,mcc=3,mnc=4,ip=5")
+
+	results, summary, err := ReadCSV(strings.NewReader(data), m, CSVOptions{Comma: ';', NoHeader: true})
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if len(results) != 1 || results[0].MCC != "310" || results[0].MNC != "260" || len(results[0].IPs) != 2 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if results[0].Timestamp.IsZero() || results[0].Subdomain != "" {
+		t.Errorf("expected default timestamp and no subdomain: %+v", results[0])
+	}
+	if len(summary.Skipped) != 1 || summary.Skipped[0].Reason != `invalid mnc "abc"` {
+		t.Errorf("unexpected skips: %+v", summary.Skipped)
+	}
+}
+
+func TestReadCSVColumnErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		mapping string
+		opts    CSVOptions
+	}{
+		{"missing column", "Host,IP\n", "fqdn=Hostname", CSVOptions{}},
+		{"name without header", "a.b\n", "fqdn=Host", CSVOptions{NoHeader: true}},
+		{"position zero", "Host\n", "fqdn=0", CSVOptions{}},
+		{"empty file", "", "fqdn=Host", CSVOptions{}},
+	}
+	for _, tt := range tests {
+		m, err := ParseMapping(tt.mapping)
+		if err != nil {
+			t.Fatalf("%s: ParseMapping failed: %v", tt.name, err)
+		}
+		if _, _, err := ReadCSV(strings.NewReader(tt.data), m, tt.opts); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package importer
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// legacyTables are the tables of the Python tool holding FQDNs: its scan
+// results and those of its 5G discovery script
+var legacyTables = []string{"available_fqdns", "fiveg_fqdns"}
+
+// legacyColumns lists, per field, the columns that may hold it in the
+// Python tool's tables, in order of preference. Early versions only had
+// operator and fqdn; later ones added MCC/MNC, comma-separated IPs and
+// first/last seen times.
+var legacyColumns = []struct {
+	field string
+	names []string
+}{
+	{FieldFQDN, []string{"fqdn"}},
+	{FieldOperator, []string{"operator"}},
+	{FieldMCC, []string{"mcc"}},
+	{FieldMNC, []string{"mnc"}},
+	{FieldIP, []string{"resolved_ips"}},
+	{FieldTimestamp, []string{"last_seen", "first_seen"}},
+	{FieldCountry, []string{"country_name"}},
+	{FieldCountryCode, []string{"country_code"}},
+}
+
+// ReadLegacyDB reads the FQDNs of a database written by the Python
+// version, or by this one. Values are read whatever their stored type (MCCs as text,
+// timestamps as text or numbers), and rows of the same FQDN, e.g. its A and
+// AAAA records, are merged. The database is opened read-only.
+func ReadLegacyDB(path string) ([]models.DNSResult, *Summary, error) {
+	if _, err := os.Stat(path); err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn, err := sql.Open(database.DriverName, "file:"+url.PathEscape(path)+"?mode=ro")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	c := newCollector(nil, time.Now())
+	found := false
+	for _, table := range legacyTables {
+		columns, err := tableColumns(conn, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !columns["fqdn"] {
+			continue
+		}
+		found = true
+		if err := readLegacyTable(conn, table, columns, c); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("no %s table with an fqdn column: not a database of the Python version", strings.Join(legacyTables, " or "))
+	}
+	results, summary := c.finish()
+	if err := addStoredIPs(conn, results); err != nil {
+		return nil, nil, err
+	}
+	if err := addOperatorDetails(conn, results); err != nil {
+		return nil, nil, err
+	}
+	return results, summary, nil
+}
+
+// addStoredIPs adds the IPs of the fqdn_ips table, present in databases
+// of this version, e.g. those of scan shards being merged
+func addStoredIPs(conn *sql.DB, results []models.DNSResult) error {
+	columns, err := tableColumns(conn, "fqdn_ips")
+	if err != nil || !columns["ip"] {
+		return err
+	}
+	rows, err := conn.Query("SELECT fqdn, ip FROM fqdn_ips")
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	ips := make(map[string][]string)
+	for rows.Next() {
+		var fqdn, ip string
+		if err := rows.Scan(&fqdn, &ip); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		ips[fqdn] = append(ips[fqdn], ip)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration failed: %w", err)
+	}
+	for i := range results {
+		if stored := ips[results[i].FQDN]; len(stored) > 0 {
+			results[i].IPs = mergeIPs(results[i].IPs, stored)
+		}
+	}
+	return nil
+}
+
+// operatorDetailColumns are the operators columns with details of an
+// operator, as stored by the Python version (country) and this one
+var operatorDetailColumns = []string{"country_name", "country_code", "brand", "status"}
+
+// addOperatorDetails fills in the country, brand and status of each
+// result from the operators table, by MNC and MCC, where rows lack them
+func addOperatorDetails(conn *sql.DB, results []models.DNSResult) error {
+	columns, err := tableColumns(conn, "operators")
+	if err != nil || !columns["mnc"] || !columns["mcc"] {
+		return err
+	}
+	selected := make([]string, len(operatorDetailColumns))
+	for i, name := range operatorDetailColumns {
+		selected[i] = "NULL"
+		if columns[name] {
+			selected[i] = name
+		}
+	}
+	rows, err := conn.Query("SELECT mnc, mcc, " + strings.Join(selected, ", ") + " FROM operators")
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	details := make(map[string]models.DNSResult)
+	for rows.Next() {
+		var mnc, mcc, country, code, brand, status sql.NullString
+		if err := rows.Scan(&mnc, &mcc, &country, &code, &brand, &status); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(mnc.String)); err != nil {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(mcc.String)); err != nil {
+			continue
+		}
+		key := operatorKey(mnc.String, mcc.String)
+		d := details[key]
+		d.Country = cmp.Or(d.Country, country.String)
+		d.CountryCode = cmp.Or(d.CountryCode, strings.ToUpper(code.String))
+		d.Brand = cmp.Or(d.Brand, brand.String)
+		d.OperatorStatus = cmp.Or(d.OperatorStatus, status.String)
+		details[key] = d
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration failed: %w", err)
+	}
+
+	for i := range results {
+		r := &results[i]
+		d, ok := details[operatorKey(r.MNC, r.MCC)]
+		if !ok {
+			continue
+		}
+		r.Country = cmp.Or(r.Country, d.Country)
+		r.CountryCode = cmp.Or(r.CountryCode, d.CountryCode)
+		r.Brand = cmp.Or(r.Brand, d.Brand)
+		r.OperatorStatus = cmp.Or(r.OperatorStatus, d.OperatorStatus)
+	}
+	return nil
+}
+
+// operatorKey keys operator details by zone, as the Python version stored
+// MNCs as numbers without their width
+func operatorKey(mnc, mcc string) string {
+	return models.PadMNC(mnc) + ":" + models.NormalizeMCC(mcc)
+}
+
+// tableColumns returns the lower-case column names of a table, none if it
+// does not exist
+func tableColumns(conn *sql.DB, table string) (map[string]bool, error) {
+	rows, err := conn.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		columns[strings.ToLower(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration failed: %w", err)
+	}
+	return columns, nil
+}
+
+// readLegacyTable adds the rows of one table to c, selecting NULL for
+// fields the table has no column for
+func readLegacyTable(conn *sql.DB, table string, columns map[string]bool, c *collector) error {
+	selected := make([]string, len(legacyColumns))
+	c.columns = make(map[string][]int)
+	for i, lc := range legacyColumns {
+		selected[i] = "NULL"
+		for _, name := range lc.names {
+			if columns[name] {
+				selected[i] = name
+				c.columns[lc.field] = []int{i}
+				break
+			}
+		}
+	}
+
+	rows, err := conn.Query(fmt.Sprintf("SELECT rowid, %s FROM %s ORDER BY rowid", strings.Join(selected, ", "), table))
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(legacyColumns))
+	dest := make([]interface{}, len(values)+1)
+	var rowid int
+	dest[0] = &rowid
+	for i := range values {
+		dest[i+1] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = v.String
+		}
+		c.add(rowid, record)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration failed: %w", err)
+	}
+	return nil
+}
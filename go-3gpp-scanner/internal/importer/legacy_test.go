@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/database"
+)
+
+// legacyDB creates a database from SQL statements
+func legacyDB(t *testing.T, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	conn, err := sql.Open(database.DriverName, path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+	for _, stmt := range statements {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return path
+}
+
+func TestReadLegacyDB(t *testing.T) {
+	path := legacyDB(t,
+		`CREATE TABLE available_fqdns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, mnc INTEGER NOT NULL, mcc INTEGER NOT NULL,
+			operator TEXT NOT NULL, country_name TEXT, fqdn TEXT NOT NULL,
+			record_type TEXT NOT NULL DEFAULT 'A', service TEXT, resolved_ips TEXT,
+			first_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP, last_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`,
+		`INSERT INTO available_fqdns (mnc, mcc, operator, fqdn, record_type, resolved_ips, last_seen) VALUES
+			(1, 262, 'Telekom', 'epdg.epc.mnc001.mcc262.pub.3gppnetwork.org', 'A', '192.0.2.1,192.0.2.2', '2024-05-01T10:00:00.123456+00:00'),
+			(1, 262, 'Telekom', 'epdg.epc.mnc001.mcc262.pub.3gppnetwork.org', 'AAAA', '2001:db8::1', '2024-06-01 12:00:00'),
+			('002', '262', 'Vodafone', 'ims.mnc002.mcc262.pub.3gppnetwork.org', 'A', NULL, 1714557600),
+			('x', 262, 'Broken', 'bsf.mnc003.mcc262.pub.3gppnetwork.org', 'A', '', NULL)`,
+		`CREATE TABLE fiveg_fqdns (mnc INTEGER, mcc INTEGER, operator TEXT, nf_type TEXT, fqdn TEXT, resolved_ips TEXT)`,
+		`INSERT INTO fiveg_fqdns VALUES (1, 262, 'Telekom', 'nrf', 'nrf.5gc.mnc001.mcc262.3gppnetwork.org', '192.0.2.3')`,
+	)
+
+	results, summary, err := ReadLegacyDB(path)
+	if err != nil {
+		t.Fatalf("ReadLegacyDB failed: %v", err)
+	}
+	if summary.Rows != 5 || summary.Results != 3 || summary.Merged != 1 || len(summary.Skipped) != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.Skipped[0].Line != 4 || summary.Skipped[0].Reason != `invalid mnc "x"` {
+		t.Errorf("unexpected skip: %+v", summary.Skipped[0])
+	}
+
+	epdg := results[0]
+	if got := strings.Join(epdg.IPs, ","); got != "192.0.2.1,192.0.2.2,2001:db8::1" {
+		t.Errorf("merged IPs = %s", got)
+	}
+	if !epdg.Timestamp.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("timestamp = %v, want the latest last_seen", epdg.Timestamp)
+	}
+	ims := results[1]
+	if ims.MNC != "02" || ims.MCC != "262" || ims.Operator != "Vodafone" || len(ims.IPs) != 0 {
+		t.Errorf("text-typed row not converted: %+v", ims)
+	}
+	if !ims.Timestamp.Equal(time.Unix(1714557600, 0)) {
+		t.Errorf("numeric timestamp = %v", ims.Timestamp)
+	}
+	if results[2].FQDN != "nrf.5gc.mnc001.mcc262.3gppnetwork.org" || results[2].MCC != "262" {
+		t.Errorf("fiveg_fqdns row not read: %+v", results[2])
+	}
+}
+
+func TestReadLegacyDBEarlyLayout(t *testing.T) {
+	path := legacyDB(t,
+		`CREATE TABLE operators (mnc INTEGER, mcc INTEGER, operator TEXT)`,
+		`CREATE TABLE available_fqdns (operator TEXT, fqdn TEXT)`,
+		`INSERT INTO available_fqdns VALUES ('Op Z', 'BSF.mnc005.mcc310.pub.3gppnetwork.org'), (NULL, 'vpn.example.com')`,
+	)
+
+	results, summary, err := ReadLegacyDB(path)
+	if err != nil {
+		t.Fatalf("ReadLegacyDB failed: %v", err)
+	}
+	if len(results) != 1 || len(summary.Skipped) != 1 {
+		t.Fatalf("unexpected results %+v, summary %+v", results, summary)
+	}
+	r := results[0]
+	if r.FQDN != "bsf.mnc005.mcc310.pub.3gppnetwork.org" || r.MNC != "005" || r.MCC != "310" || r.Subdomain != "bsf" || r.Operator != "Op Z" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
+
+func TestReadLegacyDBErrors(t *testing.T) {
+	if _, _, err := ReadLegacyDB(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	path := legacyDB(t, `CREATE TABLE hosts (name TEXT)`)
+	if _, _, err := ReadLegacyDB(path); err == nil || !strings.Contains(err.Error(), "not a database of the Python version") {
+		t.Errorf("expected layout error, got %v", err)
+	}
+}
+
+func TestReadLegacyDBStoredIPs(t *testing.T) {
+	path := legacyDB(t,
+		`CREATE TABLE available_fqdns (operator TEXT, fqdn TEXT)`,
+		`CREATE TABLE fqdn_ips (fqdn TEXT NOT NULL, ip TEXT NOT NULL, PRIMARY KEY (fqdn, ip))`,
+		`INSERT INTO available_fqdns VALUES ('Op A', 'epdg.epc.mnc001.mcc310.pub.3gppnetwork.org')`,
+		`INSERT INTO fqdn_ips VALUES ('epdg.epc.mnc001.mcc310.pub.3gppnetwork.org', '192.0.2.7'),
+			('epdg.epc.mnc001.mcc310.pub.3gppnetwork.org', '192.0.2.3'), ('gone.example.com', '192.0.2.9')`,
+	)
+
+	results, _, err := ReadLegacyDB(path)
+	if err != nil {
+		t.Fatalf("ReadLegacyDB failed: %v", err)
+	}
+	if len(results) != 1 || strings.Join(results[0].IPs, ",") != "192.0.2.3,192.0.2.7" {
+		t.Errorf("stored IPs not read: %+v", results)
+	}
+}
+
+func TestReadLegacyDBOperatorDetails(t *testing.T) {
+	path := legacyDB(t,
+		`CREATE TABLE operators (mnc INTEGER, mcc INTEGER, operator TEXT, country_name TEXT, country_code TEXT)`,
+		`CREATE TABLE available_fqdns (mnc INTEGER, mcc INTEGER, operator TEXT, country_name TEXT, fqdn TEXT)`,
+		`INSERT INTO operators VALUES (1, 262, 'Telekom', 'Germany', 'de'), ('002', '262', 'Vodafone', NULL, 'DE')`,
+		`INSERT INTO available_fqdns VALUES
+			(1, 262, 'Telekom', NULL, 'ims.mnc001.mcc262.pub.3gppnetwork.org'),
+			(2, 262, 'Vodafone', 'Deutschland', 'ims.mnc002.mcc262.pub.3gppnetwork.org')`,
+	)
+
+	results, _, err := ReadLegacyDB(path)
+	if err != nil {
+		t.Fatalf("ReadLegacyDB failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := results[0]; r.Country != "Germany" || r.CountryCode != "DE" {
+		t.Errorf("operator country not read: %+v", r)
+	}
+	if r := results[1]; r.Country != "Deutschland" || r.CountryCode != "DE" {
+		t.Errorf("row country not preferred: %+v", r)
+	}
+}
@@ -0,0 +1,411 @@
+package importer
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/probe"
+)
+
+// ZGrab2 module protocols that are imported, and the probe each result is
+// stored as. tls results take the place of the tls probe, so alerts
+// cert-expiry and fingerprinting read them too.
+var zgrab2Probes = map[string]string{
+	"tls":  "tls",
+	"http": "http",
+	"ike":  "ike",
+}
+
+// zgrab2MaxLine bounds a ZGrab2 output line; HTTP results carry bodies
+const zgrab2MaxLine = 64 << 20
+
+// zgrab2Line is one host of ZGrab2 output: its results by module name
+type zgrab2Line struct {
+	IP     string                  `json:"ip"`
+	Domain string                  `json:"domain"`
+	Data   map[string]zgrab2Module `json:"data"`
+}
+
+type zgrab2Module struct {
+	Status    string          `json:"status"`
+	Protocol  string          `json:"protocol"`
+	Result    json.RawMessage `json:"result"`
+	Timestamp string          `json:"timestamp"`
+	Error     string          `json:"error"`
+}
+
+// zgrab2Handshake is the part of a zcrypto handshake log that is imported
+type zgrab2Handshake struct {
+	ServerHello *struct {
+		Version struct {
+			Name  string `json:"name"`
+			Value int    `json:"value"`
+		} `json:"version"`
+		CipherSuite struct {
+			Name string `json:"name"`
+		} `json:"cipher_suite"`
+	} `json:"server_hello"`
+	ServerCertificates *struct {
+		Certificate struct {
+			Raw    []byte `json:"raw"`
+			Parsed *struct {
+				SubjectDN string `json:"subject_dn"`
+				IssuerDN  string `json:"issuer_dn"`
+				Validity  struct {
+					Start string `json:"start"`
+					End   string `json:"end"`
+				} `json:"validity"`
+				Extensions struct {
+					SubjectAltName struct {
+						DNSNames []string `json:"dns_names"`
+					} `json:"subject_alt_name"`
+				} `json:"extensions"`
+			} `json:"parsed"`
+		} `json:"certificate"`
+	} `json:"server_certificates"`
+}
+
+type zgrab2TLSResult struct {
+	HandshakeLog *zgrab2Handshake `json:"handshake_log"`
+}
+
+type zgrab2HTTPResult struct {
+	Response *struct {
+		StatusCode int                 `json:"status_code"`
+		Headers    map[string][]string `json:"headers"`
+		Request    *struct {
+			TLSLog *zgrab2TLSResult `json:"tls_log"`
+		} `json:"request"`
+	} `json:"response"`
+}
+
+// ZGrab2Summary reports what a ZGrab2 import read
+type ZGrab2Summary struct {
+	Lines       int            // Hosts read
+	Results     int            // Probe results read
+	Unsupported map[string]int // Results of modules that are not imported, by protocol
+	Skipped     []Skipped      // Lines that are not ZGrab2 results
+}
+
+// ReadZGrab2 reads ZGrab2 JSON output, one host per line, into probe
+// results of the tls, http and ike modules, named by protocol whatever
+// the module was called in a multiple-module run. Results keep the domain
+// of their line, which may be empty; see AttachZGrab2. An HTTPS result
+// also yields a tls result from its handshake.
+func ReadZGrab2(r io.Reader) ([]models.ProbeResult, *ZGrab2Summary, error) {
+	summary := &ZGrab2Summary{Unsupported: make(map[string]int)}
+	var results []models.ProbeResult
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), zgrab2MaxLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var host zgrab2Line
+		if err := json.Unmarshal([]byte(text), &host); err != nil {
+			summary.Skipped = append(summary.Skipped, Skipped{line, fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		if host.IP == "" && host.Domain == "" {
+			summary.Skipped = append(summary.Skipped, Skipped{line, "neither ip nor domain"})
+			continue
+		}
+		summary.Lines++
+
+		names := make([]string, 0, len(host.Data))
+		for name := range host.Data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			module := host.Data[name]
+			protocol := module.Protocol
+			if protocol == "" {
+				protocol = name
+			}
+			if _, ok := zgrab2Probes[protocol]; !ok {
+				summary.Unsupported[protocol]++
+				continue
+			}
+			results = append(results, zgrab2Results(host, name, protocol, module)...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read ZGrab2 output: %w", err)
+	}
+
+	summary.Results = len(results)
+	return results, summary, nil
+}
+
+// zgrab2Results converts the result of one module
+func zgrab2Results(host zgrab2Line, name, protocol string, module zgrab2Module) []models.ProbeResult {
+	fqdn := normalizeZGrab2Domain(host.Domain)
+	result := models.ProbeResult{
+		FQDN:    fqdn,
+		IP:      host.IP,
+		Probe:   zgrab2Probes[protocol],
+		Success: module.Status == "success",
+		Status:  zgrab2Status(module.Status),
+		Error:   module.Error,
+		Details: map[string]string{"source": "zgrab2", "module": name},
+	}
+	if t, err := time.Parse(time.RFC3339, module.Timestamp); err == nil {
+		result.Timestamp = t
+	}
+	if !result.Success && result.Error == "" {
+		result.Error = module.Status
+	}
+	if len(module.Result) == 0 {
+		return []models.ProbeResult{result}
+	}
+
+	results := []models.ProbeResult{result}
+	switch protocol {
+	case "tls":
+		var tlsResult zgrab2TLSResult
+		if json.Unmarshal(module.Result, &tlsResult) == nil {
+			addDetails(result.Details, handshakeDetails(tlsResult.HandshakeLog, fqdn))
+		}
+	case "http":
+		var httpResult zgrab2HTTPResult
+		if json.Unmarshal(module.Result, &httpResult) != nil || httpResult.Response == nil {
+			break
+		}
+		response := httpResult.Response
+		if response.StatusCode != 0 {
+			result.Details["status_code"] = strconv.Itoa(response.StatusCode)
+		}
+		if server := response.Headers["server"]; len(server) > 0 {
+			result.Details["http_server"] = strings.Join(server, ", ")
+		}
+		if response.Request != nil && response.Request.TLSLog != nil && response.Request.TLSLog.HandshakeLog != nil {
+			tlsResult := result
+			tlsResult.Probe = zgrab2Probes["tls"]
+			tlsResult.Details = map[string]string{"source": "zgrab2", "module": name}
+			addDetails(tlsResult.Details, handshakeDetails(response.Request.TLSLog.HandshakeLog, fqdn))
+			results = append(results, tlsResult)
+		}
+	case "ike":
+		addDetails(result.Details, ikeDetails(module.Result))
+	}
+	return results
+}
+
+// zgrab2Status maps a ZGrab2 status to a result status
+func zgrab2Status(status string) models.ResultStatus {
+	switch status {
+	case "success":
+		return models.StatusOK
+	case "connection-refused":
+		return models.StatusRefused
+	case "connection-timeout", "io-timeout":
+		return models.StatusTimeout
+	default:
+		return models.StatusNetworkError
+	}
+}
+
+// handshakeDetails describes a handshake with the keys of the tls probe.
+// The raw certificate is parsed where ZGrab2 recorded it; otherwise its
+// parsed fields are used, without hostname_match.
+func handshakeDetails(hs *zgrab2Handshake, fqdn string) map[string]string {
+	details := make(map[string]string)
+	if hs == nil {
+		return details
+	}
+	if hello := hs.ServerHello; hello != nil {
+		if hello.Version.Value > 0 {
+			details["tls_version"] = tls.VersionName(uint16(hello.Version.Value))
+		} else if hello.Version.Name != "" {
+			details["tls_version"] = hello.Version.Name
+		}
+		if hello.CipherSuite.Name != "" {
+			details["cipher_suite"] = hello.CipherSuite.Name
+		}
+	}
+	if hs.ServerCertificates == nil {
+		return details
+	}
+	leaf := hs.ServerCertificates.Certificate
+	if cert, err := x509.ParseCertificate(leaf.Raw); err == nil {
+		certDetails := probe.CertDetails(cert, fqdn)
+		if fqdn == "" {
+			delete(certDetails, "hostname_match")
+		}
+		addDetails(details, certDetails)
+		return details
+	}
+	if parsed := leaf.Parsed; parsed != nil {
+		details["subject"] = parsed.SubjectDN
+		details["issuer"] = parsed.IssuerDN
+		details["not_before"] = zgrab2Time(parsed.Validity.Start)
+		details["not_after"] = zgrab2Time(parsed.Validity.End)
+		details["sans"] = strings.Join(parsed.Extensions.SubjectAltName.DNSNames, ";")
+	}
+	return details
+}
+
+// zgrab2Time converts a certificate time to RFC 3339 UTC, as alerts
+// cert-expiry reads it
+func zgrab2Time(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ikeDetails describes an IKE module result with the keys of the ike
+// probe. The IKE modules of ZGrab2 forks lay out their results
+// differently, so the version, notify types and Vendor IDs are collected
+// by field name wherever they appear.
+func ikeDetails(result json.RawMessage) map[string]string {
+	var tree interface{}
+	if err := json.Unmarshal(result, &tree); err != nil {
+		return nil
+	}
+	var version string
+	var notifies, vendorIDs []string
+	walkJSON(tree, "", func(key string, value interface{}) {
+		key = strings.ToLower(key)
+		switch {
+		case key == "version" && version == "":
+			version = jsonScalar(value)
+		case strings.Contains(key, "vendor_id"):
+			if s, ok := value.(string); ok {
+				if id := vendorIDHex(s); id != "" {
+					vendorIDs = append(vendorIDs, id)
+				}
+			}
+		case strings.HasPrefix(key, "notify") && strings.HasSuffix(key, "type"):
+			if n, ok := value.(float64); ok {
+				notifies = append(notifies, probe.IKENotifyName(uint16(n)))
+			} else if s := jsonScalar(value); s != "" {
+				notifies = append(notifies, s)
+			}
+		}
+	})
+
+	return map[string]string{
+		"version":    version,
+		"notify":     strings.Join(notifies, ","),
+		"vendor_ids": strings.Join(vendorIDs, ","),
+	}
+}
+
+// walkJSON calls visit with every scalar of a decoded JSON tree and the
+// key it is stored under; array elements take the key of their array
+func walkJSON(v interface{}, key string, visit func(key string, value interface{})) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkJSON(v[k], k, visit)
+		}
+	case []interface{}:
+		for _, e := range v {
+			walkJSON(e, key, visit)
+		}
+	default:
+		visit(key, v)
+	}
+}
+
+// jsonScalar formats a JSON string or number
+func jsonScalar(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// vendorIDHex returns a Vendor ID as lowercase hex, the way the ike probe
+// reports it. ZGrab2 writes bytes as base64; hex is accepted as well.
+func vendorIDHex(s string) string {
+	if b, err := hex.DecodeString(s); err == nil && len(b) > 0 {
+		return hex.EncodeToString(b)
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) > 0 {
+		return hex.EncodeToString(b)
+	}
+	return ""
+}
+
+// addDetails copies the non-empty values of src into dst
+func addDetails(dst, src map[string]string) {
+	for key, value := range src {
+		if value != "" {
+			dst[key] = value
+		}
+	}
+}
+
+// normalizeZGrab2Domain lowercases a domain and drops its root dot
+func normalizeZGrab2Domain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// AttachZGrab2 assigns results to the FQDNs of the database: a result
+// with a domain to that FQDN if it is stored, one without to every FQDN
+// resolving to its address. ips holds the stored addresses of each FQDN.
+// Results of hosts not in the database are counted as unmatched.
+func AttachZGrab2(results []models.ProbeResult, fqdns []string, ips map[string][]string) ([]models.ProbeResult, int) {
+	known := make(map[string]string, len(fqdns))
+	for _, fqdn := range fqdns {
+		known[normalizeZGrab2Domain(fqdn)] = fqdn
+	}
+	byIP := make(map[string][]string)
+	for fqdn, addrs := range ips {
+		for _, ip := range addrs {
+			byIP[ip] = append(byIP[ip], fqdn)
+		}
+	}
+	for _, names := range byIP {
+		sort.Strings(names)
+	}
+
+	var attached []models.ProbeResult
+	unmatched := 0
+	for _, r := range results {
+		if r.FQDN != "" {
+			if fqdn, ok := known[r.FQDN]; ok {
+				r.FQDN = fqdn
+				attached = append(attached, r)
+			} else {
+				unmatched++
+			}
+			continue
+		}
+		names := byIP[r.IP]
+		if len(names) == 0 {
+			unmatched++
+			continue
+		}
+		for _, fqdn := range names {
+			r.FQDN = fqdn
+			attached = append(attached, r)
+		}
+	}
+	return attached, unmatched
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestReadZGrab2(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	notAfter := time.Date(2027, 3, 1, 12, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"},
+		DNSNames:     []string{"xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	raw := base64.StdEncoding.EncodeToString(der)
+	vendorID := base64.StdEncoding.EncodeToString([]byte{0x4a, 0x13, 0x1c, 0x81})
+
+	data := `{"ip":"192.0.2.1","domain":"XCAP.IMS.MNC001.MCC262.PUB.3GPPNETWORK.ORG.","data":{"tls":{"status":"success","protocol":"tls","timestamp":"2026-10-01T10:00:00Z","result":{"handshake_log":{"server_hello":{"version":{"name":"TLSv1.2","value":771},"cipher_suite":{"name":"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}},"server_certificates":{"certificate":{"raw":"` + raw + `"}}}}}}}
+{"ip":"192.0.2.2","data":{"https":{"status":"success","protocol":"http","result":{"response":{"status_code":403,"headers":{"server":["nginx"]},"request":{"tls_log":{"handshake_log":{"server_hello":{"version":{"name":"TLSv1.3","value":772}},"server_certificates":{"certificate":{"parsed":{"subject_dn":"CN=bsf","issuer_dn":"CN=CA","validity":{"start":"2026-01-01T00:00:00Z","end":"2027-01-01T00:00:00+01:00"}}}}}}}}}},"ssh":{"status":"success","protocol":"ssh"}}}
+{"ip":"192.0.2.3","domain":"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org","data":{"ike":{"status":"success","protocol":"ike","result":{"response":{"version":"2.0","payloads":[{"notify_message_type":16430},{"vendor_id":"` + vendorID + `"}]}}},"tls":{"status":"connection-timeout","protocol":"tls","error":"dial tcp: i/o timeout"}}}
+not json
+{"data":{}}
+`
+	results, summary, err := ReadZGrab2(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadZGrab2 failed: %v", err)
+	}
+	if summary.Lines != 3 || summary.Results != 5 || summary.Unsupported["ssh"] != 1 || len(summary.Skipped) != 2 {
+		t.Fatalf("Unexpected summary: %+v", summary)
+	}
+
+	tlsResult := results[0]
+	if tlsResult.FQDN != "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org" || tlsResult.Probe != "tls" || !tlsResult.Success {
+		t.Errorf("Unexpected tls result: %+v", tlsResult)
+	}
+	for key, want := range map[string]string{
+		"tls_version":    "TLS 1.2",
+		"not_after":      "2027-03-01T12:00:00Z",
+		"hostname_match": "true",
+		"source":         "zgrab2",
+	} {
+		if got := tlsResult.Details[key]; got != want {
+			t.Errorf("tls %s = %q, want %q", key, got, want)
+		}
+	}
+
+	http, handshake := results[1], results[2]
+	if http.FQDN != "" || http.Probe != "http" || http.Details["status_code"] != "403" || http.Details["http_server"] != "nginx" || http.Details["module"] != "https" {
+		t.Errorf("Unexpected http result: %+v", http)
+	}
+	if handshake.Probe != "tls" || handshake.Details["not_after"] != "2026-12-31T23:00:00Z" || handshake.Details["subject"] != "CN=bsf" {
+		t.Errorf("Expected a tls result from the HTTPS handshake, got %+v", handshake)
+	}
+	if _, ok := handshake.Details["hostname_match"]; ok {
+		t.Error("Expected no hostname_match without a raw certificate")
+	}
+
+	ike, timeout := results[3], results[4]
+	if ike.Details["version"] != "2.0" || ike.Details["notify"] != "IKEV2_FRAGMENTATION_SUPPORTED" || ike.Details["vendor_ids"] != "4a131c81" {
+		t.Errorf("Unexpected ike details: %v", ike.Details)
+	}
+	if timeout.Success || timeout.Status != models.StatusTimeout || timeout.Error != "dial tcp: i/o timeout" {
+		t.Errorf("Unexpected failed result: %+v", timeout)
+	}
+}
+
+func TestAttachZGrab2(t *testing.T) {
+	results := []models.ProbeResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IP: "192.0.2.1", Probe: "tls"},
+		{IP: "192.0.2.2", Probe: "http"},
+		{FQDN: "vpn.example.com", IP: "192.0.2.9", Probe: "tls"},
+		{IP: "198.51.100.1", Probe: "http"},
+	}
+	fqdns := []string{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "bsf.mnc001.mcc262.pub.3gppnetwork.org", "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"}
+	ips := map[string][]string{
+		"bsf.mnc001.mcc262.pub.3gppnetwork.org":      {"192.0.2.2"},
+		"xcap.ims.mnc001.mcc262.pub.3gppnetwork.org": {"192.0.2.2"},
+	}
+
+	attached, unmatched := AttachZGrab2(results, fqdns, ips)
+	if unmatched != 2 || len(attached) != 3 {
+		t.Fatalf("Expected 3 attached and 2 unmatched results, got %d and %d", len(attached), unmatched)
+	}
+	var got []string
+	for _, r := range attached {
+		got = append(got, fmt.Sprintf("%s %s", r.Probe, r.FQDN))
+	}
+	want := "tls epdg.epc.mnc001.mcc262.pub.3gppnetwork.org, http bsf.mnc001.mcc262.pub.3gppnetwork.org, http xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Attached %s, want %s", strings.Join(got, ", "), want)
+	}
+}
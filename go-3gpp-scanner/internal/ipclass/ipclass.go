@@ -0,0 +1,117 @@
+// Package ipclass classifies resolved addresses by IP version and by
+// whether they are publicly routable.
+package ipclass
+
+import (
+	"net"
+	"sort"
+)
+
+// Class is the routability of an address
+type Class string
+
+const (
+	// Public addresses are routable on the internet
+	Public Class = "public"
+	// Private addresses are RFC 1918, RFC 6598 shared (CGNAT) or IPv6
+	// unique local space: reachable inside an operator network only, so
+	// publishing them in public DNS is a finding of its own
+	Private Class = "private"
+	// Bogon addresses must never appear on the internet: unspecified,
+	// loopback, link-local, documentation, benchmarking, multicast and
+	// reserved space
+	Bogon Class = "bogon"
+)
+
+// Classes lists the classes in report order
+var Classes = []Class{Public, Private, Bogon}
+
+// Versions of an address, see Version
+const (
+	IPv4 = "ipv4"
+	IPv6 = "ipv6"
+)
+
+// sharedSpace is the RFC 6598 carrier-grade NAT range
+var sharedSpace = mustParseCIDR("100.64.0.0/10")
+
+// bogons are the non-routable ranges other than private space
+var bogons = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("192.0.2.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("198.51.100.0/24"),
+	mustParseCIDR("203.0.113.0/24"),
+	mustParseCIDR("240.0.0.0/4"),
+	mustParseCIDR("100::/64"),
+	mustParseCIDR("2001:db8::/32"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// Classify returns the class of an address
+func Classify(ip net.IP) Class {
+	switch {
+	case ip.IsPrivate() || sharedSpace.Contains(ip):
+		return Private
+	case ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast():
+		return Bogon
+	}
+	for _, network := range bogons {
+		if network.Contains(ip) {
+			return Bogon
+		}
+	}
+	return Public
+}
+
+// Version returns IPv4 or IPv6
+func Version(ip net.IP) string {
+	if ip.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
+
+// Parse parses an address as stored with results, reporting whether it
+// is valid
+func Parse(s string) (net.IP, bool) {
+	ip := net.ParseIP(s)
+	return ip, ip != nil
+}
+
+// Tally counts the distinct valid addresses of FQDNs per version and per
+// class, and returns the FQDNs resolving to a private address, sorted
+func Tally(fqdnIPs map[string][]string) (versions, classes map[string]int, private []string) {
+	versions = make(map[string]int)
+	classes = make(map[string]int)
+	seen := make(map[string]bool)
+	for fqdn, ips := range fqdnIPs {
+		hasPrivate := false
+		for _, s := range ips {
+			ip, ok := Parse(s)
+			if !ok {
+				continue
+			}
+			class := Classify(ip)
+			hasPrivate = hasPrivate || class == Private
+			if key := ip.String(); !seen[key] {
+				seen[key] = true
+				versions[Version(ip)]++
+				classes[string(class)]++
+			}
+		}
+		if hasPrivate {
+			private = append(private, fqdn)
+		}
+	}
+	sort.Strings(private)
+	return versions, classes, private
+}
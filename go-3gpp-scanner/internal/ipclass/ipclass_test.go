@@ -0,0 +1,69 @@
+package ipclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		ip      string
+		class   Class
+		version string
+	}{
+		{"8.8.8.8", Public, IPv4},
+		{"10.1.2.3", Private, IPv4},
+		{"172.16.0.1", Private, IPv4},
+		{"172.32.0.1", Public, IPv4},
+		{"192.168.1.1", Private, IPv4},
+		{"100.64.0.1", Private, IPv4},
+		{"100.128.0.1", Public, IPv4},
+		{"127.0.0.1", Bogon, IPv4},
+		{"169.254.1.1", Bogon, IPv4},
+		{"192.0.2.10", Bogon, IPv4},
+		{"0.0.0.0", Bogon, IPv4},
+		{"224.0.0.1", Bogon, IPv4},
+		{"255.255.255.255", Bogon, IPv4},
+		{"2a00:1450::1", Public, IPv6},
+		{"fd12:3456::1", Private, IPv6},
+		{"fe80::1", Bogon, IPv6},
+		{"2001:db8::1", Bogon, IPv6},
+		{"::1", Bogon, IPv6},
+		{"::ffff:10.0.0.1", Private, IPv4},
+	}
+
+	for _, tt := range tests {
+		ip, ok := Parse(tt.ip)
+		if !ok {
+			t.Fatalf("Parse(%q) failed", tt.ip)
+		}
+		if got := Classify(ip); got != tt.class {
+			t.Errorf("Classify(%s) = %s, want %s", tt.ip, got, tt.class)
+		}
+		if got := Version(ip); got != tt.version {
+			t.Errorf("Version(%s) = %s, want %s", tt.ip, got, tt.version)
+		}
+	}
+
+	if _, ok := Parse("not-an-ip"); ok {
+		t.Error("Parse accepted an invalid address")
+	}
+}
+
+func TestTally(t *testing.T) {
+	versions, classes, private := Tally(map[string][]string{
+		"ims.a":  {"10.0.0.1", "192.0.2.1", "not-an-ip"},
+		"epdg.a": {"8.8.8.8", "10.0.0.1"},
+		"epdg.b": {"2a00:1450::1"},
+		"bsf.b":  {"fd00::1"},
+	})
+	if want := map[string]int{IPv4: 3, IPv6: 2}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("versions = %v, want %v", versions, want)
+	}
+	if want := map[string]int{"public": 2, "private": 2, "bogon": 1}; !reflect.DeepEqual(classes, want) {
+		t.Errorf("classes = %v, want %v", classes, want)
+	}
+	if want := []string{"bsf.b", "epdg.a", "ims.a"}; !reflect.DeepEqual(private, want) {
+		t.Errorf("private = %v, want %v", private, want)
+	}
+}
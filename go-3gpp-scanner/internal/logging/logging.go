@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Level maps the global output flags to a log level: --verbose shows
+// debug and info records, --quiet only errors, and the default keeps
+// warnings and errors
+func Level(verbose, quiet bool) slog.Level {
+	switch {
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// New creates a logger writing to w. JSON records carry a timestamp for
+// log collectors; text records drop it, since they are read interactively
+// next to the command output.
+func New(w io.Writer, level slog.Level, json bool) *slog.Logger {
+	if json {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// Setup installs a logger as the slog default, which the scanner
+// packages log through
+func Setup(w io.Writer, level slog.Level, json bool) {
+	slog.SetDefault(New(w, level, json))
+}
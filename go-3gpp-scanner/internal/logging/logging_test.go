@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		verbose, quiet bool
+		want           slog.Level
+	}{
+		{false, false, slog.LevelWarn},
+		{true, false, slog.LevelDebug},
+		{false, true, slog.LevelError},
+	}
+	for _, tt := range tests {
+		if got := Level(tt.verbose, tt.quiet); got != tt.want {
+			t.Errorf("Level(%v, %v) = %v, want %v", tt.verbose, tt.quiet, got, tt.want)
+		}
+	}
+}
+
+func TestNewText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelWarn, false)
+	logger.Info("hidden")
+	logger.Warn("cache unavailable", "path", "mcc-mnc-list.json")
+
+	got := buf.String()
+	if strings.Contains(got, "hidden") {
+		t.Errorf("info record below the level was written: %q", got)
+	}
+	if strings.Contains(got, "time=") {
+		t.Errorf("text record carries a timestamp: %q", got)
+	}
+	if !strings.Contains(got, `level=WARN msg="cache unavailable" path=mcc-mnc-list.json`) {
+		t.Errorf("unexpected record: %q", got)
+	}
+}
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, slog.LevelDebug, true).Debug("found A record", "fqdn", "ims.mnc001.mcc262.pub.3gppnetwork.org", "ips", 2)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON record %q: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "found A record" || record["ips"] != float64(2) || record["time"] == nil {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
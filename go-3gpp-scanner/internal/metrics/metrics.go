@@ -0,0 +1,255 @@
+// Package metrics exports database totals, FQDN counts per operator and
+// run summaries to InfluxDB or a Prometheus Pushgateway for dashboards.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Export formats
+const (
+	FormatInflux     = "influx"
+	FormatPrometheus = "prometheus"
+)
+
+// Formats lists the formats Encode accepts
+var Formats = []string{FormatInflux, FormatPrometheus}
+
+// Snapshot is the state of a database exported as metrics: totals,
+// FQDNs per operator and the recorded runs
+type Snapshot struct {
+	Time           time.Time
+	FQDNs          int
+	Operators      int
+	IPs            int
+	OperatorCounts map[string]int
+	Runs           []models.Run
+}
+
+// NewSnapshot builds a snapshot taken now from database statistics and runs
+func NewSnapshot(stats *models.Stats, runs []models.Run) *Snapshot {
+	return &Snapshot{
+		Time:           time.Now(),
+		FQDNs:          stats.TotalFQDNs,
+		Operators:      stats.UniqueOperators,
+		IPs:            stats.TotalIPs,
+		OperatorCounts: stats.OperatorCounts,
+		Runs:           runs,
+	}
+}
+
+// Encode writes a snapshot in one of Formats
+func Encode(w io.Writer, snapshot *Snapshot, format string) error {
+	switch format {
+	case FormatInflux:
+		return EncodeInflux(w, snapshot)
+	case FormatPrometheus:
+		return EncodePrometheus(w, snapshot)
+	default:
+		return fmt.Errorf("unknown metrics format: %s (must be one of %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// EncodeInflux writes a snapshot in InfluxDB line protocol with second
+// precision. Totals and operator counts carry the snapshot time, every run
+// its start time, so exporting the same runs again overwrites their points
+// instead of adding new ones.
+func EncodeInflux(w io.Writer, snapshot *Snapshot) error {
+	var sb strings.Builder
+	ts := snapshot.Time.Unix()
+	fmt.Fprintf(&sb, "scanner_database fqdns=%di,operators=%di,ips=%di %d\n", snapshot.FQDNs, snapshot.Operators, snapshot.IPs, ts)
+	for _, operator := range sortedKeys(snapshot.OperatorCounts) {
+		fmt.Fprintf(&sb, "scanner_operator,operator=%s fqdns=%di %d\n", influxTag(operatorLabel(operator)), snapshot.OperatorCounts[operator], ts)
+	}
+	for _, run := range snapshot.Runs {
+		sb.WriteString("scanner_run,kind=" + influxTag(run.Kind))
+		if run.Source != "" {
+			sb.WriteString(",source=" + influxTag(run.Source))
+		}
+		fmt.Fprintf(&sb, " id=%di,results=%di %d\n", run.ID, run.Results, run.Started.Unix())
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// EncodePrometheus writes a snapshot in the Prometheus text exposition
+// format. Samples carry no timestamps, as the Pushgateway rejects them, so
+// of the runs only the count and the latest run are exported.
+func EncodePrometheus(w io.Writer, snapshot *Snapshot) error {
+	var sb strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	gauge("scanner_fqdns", "Distinct FQDNs in the database.")
+	fmt.Fprintf(&sb, "scanner_fqdns %d\n", snapshot.FQDNs)
+	gauge("scanner_operators", "Operators with at least one FQDN.")
+	fmt.Fprintf(&sb, "scanner_operators %d\n", snapshot.Operators)
+	gauge("scanner_ips", "Distinct IP addresses the FQDNs resolved to.")
+	fmt.Fprintf(&sb, "scanner_ips %d\n", snapshot.IPs)
+
+	if len(snapshot.OperatorCounts) > 0 {
+		gauge("scanner_operator_fqdns", "FQDNs per operator.")
+		for _, operator := range sortedKeys(snapshot.OperatorCounts) {
+			fmt.Fprintf(&sb, "scanner_operator_fqdns{operator=%s} %d\n", promLabel(operatorLabel(operator)), snapshot.OperatorCounts[operator])
+		}
+	}
+
+	gauge("scanner_runs", "Scans and imports recorded in the database.")
+	fmt.Fprintf(&sb, "scanner_runs %d\n", len(snapshot.Runs))
+	if len(snapshot.Runs) > 0 {
+		last := snapshot.Runs[len(snapshot.Runs)-1]
+		labels := fmt.Sprintf("{kind=%s,source=%s}", promLabel(last.Kind), promLabel(last.Source))
+		gauge("scanner_last_run_results", "Results stored by the latest run.")
+		fmt.Fprintf(&sb, "scanner_last_run_results%s %d\n", labels, last.Results)
+		gauge("scanner_last_run_timestamp_seconds", "Start of the latest run as a Unix time.")
+		fmt.Fprintf(&sb, "scanner_last_run_timestamp_seconds%s %d\n", labels, last.Started.Unix())
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// operatorLabel names the operator of FQDNs without a known operator
+func operatorLabel(operator string) string {
+	if operator == "" {
+		return "unknown"
+	}
+	return operator
+}
+
+// influxEscaper escapes tag values in line protocol
+var influxEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", " ")
+
+// influxTag escapes a tag value; line protocol does not allow empty ones
+func influxTag(value string) string {
+	if value == "" {
+		value = "none"
+	}
+	return influxEscaper.Replace(value)
+}
+
+// promEscaper escapes label values in the text exposition format
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes and escapes a label value
+func promLabel(value string) string {
+	return `"` + promEscaper.Replace(value) + `"`
+}
+
+// sortedKeys returns the keys of a count map in order, for stable output
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Pusher sends encoded snapshots to a metrics backend
+type Pusher struct {
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// NewPusher creates a pusher with a request timeout
+func NewPusher(userAgent string) *Pusher {
+	return &Pusher{HTTPClient: &http.Client{Timeout: 30 * time.Second}, UserAgent: userAgent}
+}
+
+// InfluxTarget is an InfluxDB write endpoint. InfluxDB 2 takes an
+// organization, bucket and API token; InfluxDB 1.8+ accepts the same API
+// with the bucket as database/retention-policy and the token as
+// user:password.
+type InfluxTarget struct {
+	URL    string
+	Org    string
+	Bucket string
+	Token  string
+}
+
+// PushInflux writes a snapshot to InfluxDB through the /api/v2/write API
+func (p *Pusher) PushInflux(ctx context.Context, target InfluxTarget, snapshot *Snapshot) error {
+	if target.Bucket == "" {
+		return fmt.Errorf("influx: bucket required")
+	}
+	var body bytes.Buffer
+	if err := EncodeInflux(&body, snapshot); err != nil {
+		return err
+	}
+
+	query := url.Values{"bucket": {target.Bucket}, "precision": {"s"}}
+	if target.Org != "" {
+		query.Set("org", target.Org)
+	}
+	endpoint := strings.TrimSuffix(target.URL, "/") + "/api/v2/write?" + query.Encode()
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	if target.Token != "" {
+		header.Set("Authorization", "Token "+target.Token)
+	}
+	if err := p.send(ctx, http.MethodPost, endpoint, header, &body); err != nil {
+		return fmt.Errorf("influx: %w", err)
+	}
+	return nil
+}
+
+// PushGateway replaces the metrics of a job (and instance, if not empty)
+// in a Prometheus Pushgateway
+func (p *Pusher) PushGateway(ctx context.Context, gatewayURL, job, instance string, snapshot *Snapshot) error {
+	if job == "" {
+		return fmt.Errorf("pushgateway: job required")
+	}
+	var body bytes.Buffer
+	if err := EncodePrometheus(&body, snapshot); err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	if instance != "" {
+		endpoint += "/instance/" + url.PathEscape(instance)
+	}
+	header := http.Header{"Content-Type": {"text/plain; version=0.0.4"}}
+	if err := p.send(ctx, http.MethodPut, endpoint, header, &body); err != nil {
+		return fmt.Errorf("pushgateway: %w", err)
+	}
+	return nil
+}
+
+// send makes a request and fails on any status but 2xx
+func (p *Pusher) send(ctx context.Context, method, endpoint string, header http.Header, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Time:      time.Unix(1700000000, 0),
+		FQDNs:     12,
+		Operators: 2,
+		IPs:       9,
+		OperatorCounts: map[string]int{
+			"Telekom Deutschland": 8,
+			"":                    4,
+		},
+		Runs: []models.Run{
+			{ID: 1, Kind: "imported", Source: "/data/old.db", Started: time.Unix(1690000000, 0), Results: 10},
+			{ID: 2, Kind: "scan", Source: "epdg, shard 1/2", Started: time.Unix(1699990000, 0), Results: 2},
+		},
+	}
+}
+
+func TestEncodeInflux(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeInflux(&buf, testSnapshot()); err != nil {
+		t.Fatalf("EncodeInflux: %v", err)
+	}
+	want := `scanner_database fqdns=12i,operators=2i,ips=9i 1700000000
+scanner_operator,operator=unknown fqdns=4i 1700000000
+scanner_operator,operator=Telekom\ Deutschland fqdns=8i 1700000000
+scanner_run,kind=imported,source=/data/old.db id=1i,results=10i 1690000000
+scanner_run,kind=scan,source=epdg\,\ shard\ 1/2 id=2i,results=2i 1699990000
+`
+	if buf.String() != want {
+		t.Errorf("EncodeInflux =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestEncodePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodePrometheus(&buf, testSnapshot()); err != nil {
+		t.Fatalf("EncodePrometheus: %v", err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE scanner_fqdns gauge\nscanner_fqdns 12\n",
+		`scanner_operator_fqdns{operator="Telekom Deutschland"} 8`,
+		`scanner_operator_fqdns{operator="unknown"} 4`,
+		"scanner_runs 2\n",
+		`scanner_last_run_results{kind="scan",source="epdg, shard 1/2"} 2`,
+		`scanner_last_run_timestamp_seconds{kind="scan",source="epdg, shard 1/2"} 1699990000`,
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("EncodePrometheus output lacks %q:\n%s", line, out)
+		}
+	}
+
+	buf.Reset()
+	if err := EncodePrometheus(&buf, &Snapshot{}); err != nil {
+		t.Fatalf("EncodePrometheus: %v", err)
+	}
+	if strings.Contains(buf.String(), "scanner_last_run") || strings.Contains(buf.String(), "scanner_operator_fqdns") {
+		t.Errorf("empty snapshot exports run or operator metrics:\n%s", buf.String())
+	}
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	if err := Encode(io.Discard, testSnapshot(), "graphite"); err == nil {
+		t.Error("Encode with an unknown format succeeded")
+	}
+}
+
+func TestPushInflux(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/write" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("org") != "secops" || q.Get("bucket") != "3gpp" || q.Get("precision") != "s" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.HasPrefix(string(body), "scanner_database fqdns=12i") {
+			t.Errorf("body = %q", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	pusher := &Pusher{HTTPClient: server.Client()}
+	target := InfluxTarget{URL: server.URL + "/", Org: "secops", Bucket: "3gpp", Token: "secret"}
+	if err := pusher.PushInflux(context.Background(), target, testSnapshot()); err != nil {
+		t.Errorf("PushInflux: %v", err)
+	}
+	if err := pusher.PushInflux(context.Background(), InfluxTarget{URL: server.URL}, testSnapshot()); err == nil {
+		t.Error("PushInflux without bucket succeeded")
+	}
+}
+
+func TestPushGateway(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/3gpp-scanner/instance/scanner-1" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "scanner_fqdns 12") {
+			t.Errorf("body = %q", body)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "text format parsing error")
+	}))
+	defer server.Close()
+
+	pusher := &Pusher{HTTPClient: server.Client()}
+	if err := pusher.PushGateway(context.Background(), server.URL, "3gpp-scanner", "scanner-1", testSnapshot()); err != nil {
+		t.Errorf("PushGateway: %v", err)
+	}
+
+	status = http.StatusBadRequest
+	err := pusher.PushGateway(context.Background(), server.URL, "3gpp-scanner", "scanner-1", testSnapshot())
+	if err == nil || !strings.Contains(err.Error(), "400 text format parsing error") {
+		t.Errorf("PushGateway on a rejected push = %v", err)
+	}
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // MCCMNCEntry represents a single entry from the MCC-MNC list
 type MCCMNCEntry struct {
@@ -16,15 +22,134 @@ type MCCMNCEntry struct {
 	Notes       string `json:"notes"`
 }
 
+// ResultStatus classifies the outcome of a DNS query or probe so that
+// failures can be aggregated; free-form detail stays in Error fields
+type ResultStatus string
+
+// Result statuses
+const (
+	StatusOK           ResultStatus = "OK"
+	StatusNXDomain     ResultStatus = "NXDOMAIN"      // Name does not exist
+	StatusTimeout      ResultStatus = "TIMEOUT"       // No reply before the deadline
+	StatusServFail     ResultStatus = "SERVFAIL"      // Resolver could not answer
+	StatusRefused      ResultStatus = "REFUSED"       // Query or connection refused
+	StatusNoAnswer     ResultStatus = "NO_ANSWER"     // Name exists but has no usable records
+	StatusNetworkError ResultStatus = "NETWORK_ERROR" // Any other transport failure
+	StatusExcluded     ResultStatus = "EXCLUDED"      // Skipped: target is excluded or out of scope
+)
+
+// ResultStatuses lists all statuses in display order
+var ResultStatuses = []ResultStatus{
+	StatusOK, StatusNXDomain, StatusTimeout, StatusServFail,
+	StatusRefused, StatusNoAnswer, StatusNetworkError, StatusExcluded,
+}
+
+// NormalizeMCC trims an MCC and zero-pads it to three digits. Values that
+// are not digits are only trimmed.
+func NormalizeMCC(mcc string) string {
+	mcc = strings.TrimSpace(mcc)
+	if !isDigits(mcc) || len(mcc) >= 3 {
+		return mcc
+	}
+	return strings.Repeat("0", 3-len(mcc)) + mcc
+}
+
+// NormalizeMNC trims an MNC and pads a single digit to two. Two- and
+// three-digit MNCs keep their digits: 01 and 001 are different networks
+// and must not be conflated.
+func NormalizeMNC(mnc string) string {
+	mnc = strings.TrimSpace(mnc)
+	if isDigits(mnc) && len(mnc) == 1 {
+		return "0" + mnc
+	}
+	return mnc
+}
+
+// PadMNC returns the three-digit form of an MNC used in 3GPP domain names
+// (TS 23.003), where a two-digit MNC gets a leading zero: mnc001 for 01
+func PadMNC(mnc string) string {
+	mnc = strings.TrimSpace(mnc)
+	if !isDigits(mnc) || len(mnc) >= 3 {
+		return mnc
+	}
+	return strings.Repeat("0", 3-len(mnc)) + mnc
+}
+
+// IsCode reports whether s is a valid MCC or MNC of one to three digits
+func IsCode(s string) bool {
+	return isDigits(s) && len(s) <= 3
+}
+
+// code is an MCC or MNC in JSON, read from a string or from the number
+// earlier versions wrote
+type code string
+
+func (c *code) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		err := json.Unmarshal(data, &s)
+		*c = code(s)
+		return err
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*c = code(strconv.Itoa(n))
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // DNSResult represents the result of a DNS query
 type DNSResult struct {
-	FQDN      string    `json:"fqdn"`
-	IPs       []string  `json:"ips"`
-	Subdomain string    `json:"subdomain"`
-	MNC       int       `json:"mnc"`
-	MCC       int       `json:"mcc"`
-	Operator  string    `json:"operator"`
-	Timestamp time.Time `json:"timestamp"`
+	FQDN      string       `json:"fqdn"`
+	IPs       []string     `json:"ips"`
+	CNAMEs    []string     `json:"cnames,omitempty"` // CNAME targets followed to the addresses
+	Subdomain string       `json:"subdomain"`
+	MNC       string       `json:"mnc"` // As listed, e.g. "01" or "001" (see NormalizeMNC)
+	MCC       string       `json:"mcc"` // Three digits
+	Operator  string       `json:"operator"`
+	Status    ResultStatus `json:"status,omitempty"`
+	Error     string       `json:"error,omitempty"` // Why a failed query found no addresses
+	Timestamp time.Time    `json:"timestamp"`
+
+	// Operator details from the MCC-MNC list, if known
+	Country        string `json:"country,omitempty"`
+	CountryCode    string `json:"country_code,omitempty"` // ISO 3166-1 alpha-2
+	Brand          string `json:"brand,omitempty"`
+	OperatorStatus string `json:"operator_status,omitempty"` // e.g. Operational
+}
+
+// UnmarshalJSON reads a DNS result, including exports and checkpoints of
+// earlier versions with numeric MNC and MCC. A numeric MNC below 10 gets
+// two digits, since its original width is lost.
+func (d *DNSResult) UnmarshalJSON(data []byte) error {
+	type plain DNSResult
+	aux := struct {
+		*plain
+		MNC code `json:"mnc"`
+		MCC code `json:"mcc"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	d.MNC = NormalizeMNC(string(aux.MNC))
+	d.MCC = NormalizeMCC(string(aux.MCC))
+	return nil
 }
 
 // ScanConfig holds configuration for DNS scanning
@@ -35,27 +160,112 @@ type ScanConfig struct {
 	Concurrency  int
 	DatabasePath string
 	MCCMNCSource string
-	Verbose      bool
+	SourceIP     string // Local address to send queries from (optional)
+	Interface    string // Network interface to send queries from (optional)
+	// MCCRateLimits caps queries per second for individual MCCs (zero-padded keys)
+	MCCRateLimits map[string]float64
+	// Resolvers are the recursive resolvers (host:port) tried in order (default: Google, Cloudflare, OpenDNS)
+	Resolvers []string
+	// Order is the query order, one of the dns.Order* names (default: list order)
+	Order string
+	Seed  int64 // Seed of the shuffle order
+	// NoRetry disables retrying timeouts and SERVFAIL at the end of a scan
+	NoRetry bool
+	// QueryBudget caps the wall-clock time of one query across all
+	// resolvers; zero leaves each resolver its full timeout
+	QueryBudget time.Duration
+	// IncludeFailures keeps the queries that found no addresses, see
+	// Scanner.Failures
+	IncludeFailures bool
 }
 
 // PingConfig holds configuration for ping operations
 type PingConfig struct {
-	Method   string // "icmp" or "tcp"
-	Timeout  time.Duration
-	Workers  int
-	TCPPorts []int // Ports to check for TCP mode (default: 443, 4500)
-	Verbose  bool
+	Method          string // "icmp", "tcp" or "https"
+	Timeout         time.Duration
+	Workers         int
+	TCPPorts        []int  // Ports to check for TCP mode (default: 443, 4500)
+	HTTPSPort       int    // Port for HTTPS mode (default: 443)
+	SourceIP        string // Local address to send probes from (optional)
+	Interface       string // Network interface to send probes from (optional)
+	IncludeFailures bool   // Keep unreachable hosts in the results
+	ProbeMTU        bool   // Probe the path MTU of reachable hosts (ICMP, needs root)
+	DualStack       bool   // Probe A and AAAA targets separately and compare
+	DedupeByIP      bool   // Resolve all FQDNs first and probe each address once (icmp and tcp)
+	UserAgent       string // User-Agent of the https method's HEAD request
 }
 
-// PingResult represents the result of a ping operation
+// PingResult represents the result of a ping operation. IP is the address
+// that answered, or the last one tried on failure; Port is the TCP port of
+// the tcp and https methods.
 type PingResult struct {
-	FQDN      string        `json:"fqdn"`
-	Success   bool          `json:"success"`
-	Latency   time.Duration `json:"latency,omitempty"`
-	IP        string        `json:"ip,omitempty"`
-	Method    string        `json:"method"`
-	Error     string        `json:"error,omitempty"`
-	Timestamp time.Time     `json:"timestamp"`
+	FQDN        string        `json:"fqdn"`
+	Success     bool          `json:"success"`
+	Latency     time.Duration `json:"latency,omitempty"`
+	IP          string        `json:"ip,omitempty"`
+	Port        int           `json:"port,omitempty"`
+	ResolvedIPs []string      `json:"resolved_ips,omitempty"` // All addresses the FQDN resolved to
+	Method      string        `json:"method"`
+	Status      ResultStatus  `json:"status,omitempty"`
+	PathMTU     int           `json:"path_mtu,omitempty"`
+	// HTTPS method: TLS handshake time and HTTP status of a HEAD request
+	TLSHandshake time.Duration `json:"tls_handshake,omitempty"`
+	HTTPStatus   int           `json:"http_status,omitempty"`
+	HTTPServer   string        `json:"http_server,omitempty"` // Server header of the HEAD response
+	CertIssuer   string        `json:"cert_issuer,omitempty"` // Leaf certificate issuer
+	CertSubject  string        `json:"cert_subject,omitempty"`
+	CertSANs     []string      `json:"cert_sans,omitempty"`
+	CertValid    bool          `json:"cert_valid,omitempty"` // Names the FQDN and is within its validity period; the chain is not checked
+	DualStack    *DualStack    `json:"dual_stack,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Timestamp    time.Time     `json:"timestamp"`
+}
+
+// UnmarshalJSON reads ping results, splitting the "ip:port" addresses of
+// exports written before Port existed
+func (p *PingResult) UnmarshalJSON(data []byte) error {
+	type plain PingResult
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	if p.Port == 0 {
+		if host, port, err := net.SplitHostPort(p.IP); err == nil {
+			p.IP = host
+			p.Port, _ = strconv.Atoi(port)
+		}
+	}
+	return nil
+}
+
+// ZoneInfo holds apex metadata (SOA, NS, MX) of an operator zone
+type ZoneInfo struct {
+	Zone      string    `json:"zone"`
+	MNC       string    `json:"mnc"` // Three digits, as in the zone name
+	MCC       string    `json:"mcc"`
+	SOASerial uint32    `json:"soa_serial,omitempty"`
+	PrimaryNS string    `json:"primary_ns,omitempty"`
+	Mailbox   string    `json:"mailbox,omitempty"`
+	NS        []string  `json:"ns,omitempty"`
+	MX        []string  `json:"mx,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UnmarshalJSON reads zone information, including exports of earlier
+// versions with numeric MNC and MCC
+func (z *ZoneInfo) UnmarshalJSON(data []byte) error {
+	type plain ZoneInfo
+	aux := struct {
+		*plain
+		MNC code `json:"mnc"`
+		MCC code `json:"mcc"`
+	}{plain: (*plain)(z)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	z.MNC = PadMNC(string(aux.MNC))
+	z.MCC = NormalizeMCC(string(aux.MCC))
+	return nil
 }
 
 // Stats represents statistics about discovered FQDNs
@@ -66,4 +276,412 @@ type Stats struct {
 	CountryCounts   map[string]int `json:"country_counts"`
 	UniqueOperators int            `json:"unique_operators"`
 	TotalIPs        int            `json:"total_ips"`
+	StatusCounts    map[string]int `json:"status_counts,omitempty"`
+	VendorCounts    map[string]int `json:"vendor_counts,omitempty"` // Fingerprinted endpoints per vendor
+
+	// FQDNs per subdomain and MCC, and per subdomain and network ("MCC-MNC",
+	// zero-padded as in FQDNs, e.g. "310-001")
+	SubdomainMCCCounts     map[string]map[string]int `json:"subdomain_mcc_counts,omitempty"`
+	SubdomainNetworkCounts map[string]map[string]int `json:"subdomain_network_counts,omitempty"`
+
+	// FQDNs per operator, and per operator and subdomain
+	OperatorCounts          map[string]int            `json:"operator_counts,omitempty"`
+	OperatorSubdomainCounts map[string]map[string]int `json:"operator_subdomain_counts,omitempty"`
+
+	// Distinct IPs per version (ipv4, ipv6) and class (public, private,
+	// bogon), and the FQDNs resolving to a private address
+	IPVersionCounts map[string]int `json:"ip_version_counts,omitempty"`
+	IPClassCounts   map[string]int `json:"ip_class_counts,omitempty"`
+	PrivateIPFQDNs  []string       `json:"private_ip_fqdns,omitempty"`
+}
+
+// DualStack compares IPv4 and IPv6 reachability of one FQDN
+type DualStack struct {
+	IPv4   *FamilyProbe `json:"ipv4,omitempty"`   // Nil when the name has no A record
+	IPv6   *FamilyProbe `json:"ipv6,omitempty"`   // Nil when the name has no AAAA record
+	Faster string       `json:"faster,omitempty"` // "ipv4" or "ipv6" when both answered
+}
+
+// FamilyProbe is the probe result for one address family
+type FamilyProbe struct {
+	IP      string        `json:"ip"`
+	Success bool          `json:"success"`
+	Latency time.Duration `json:"latency,omitempty"`
+	Status  ResultStatus  `json:"status"`
+}
+
+// LatencySummary aggregates ping latencies so runs can be compared
+type LatencySummary struct {
+	Total      int               `json:"total"`
+	Successful int               `json:"successful"`
+	Min        time.Duration     `json:"min"`
+	Max        time.Duration     `json:"max"`
+	Mean       time.Duration     `json:"mean"`
+	P50        time.Duration     `json:"p50"`
+	P90        time.Duration     `json:"p90"`
+	P99        time.Duration     `json:"p99"`
+	Histogram  []LatencyBucket   `json:"histogram"`
+	ByCountry  []CountryLatency  `json:"by_country,omitempty"`
+	DualStack  *DualStackSummary `json:"dual_stack,omitempty"`
+}
+
+// DualStackSummary compares address families across all dual-stack probes
+type DualStackSummary struct {
+	Hosts         int           `json:"hosts"`          // Hosts with an A or AAAA record
+	IPv4Only      int           `json:"ipv4_only"`      // Only an A record
+	IPv6Only      int           `json:"ipv6_only"`      // Only an AAAA record
+	DualStacked   int           `json:"dual_stacked"`   // Both records
+	BothReachable int           `json:"both_reachable"` // Both families answered
+	IPv4Faster    int           `json:"ipv4_faster"`
+	IPv6Faster    int           `json:"ipv6_faster"`
+	MeanIPv4      time.Duration `json:"mean_ipv4,omitempty"` // Over hosts where both answered
+	MeanIPv6      time.Duration `json:"mean_ipv6,omitempty"`
+}
+
+// LatencyBucket counts replies at or below an upper bound; the last
+// bucket has no bound (zero) and collects everything slower
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"upper_bound,omitempty"`
+	Count      int           `json:"count"`
+}
+
+// CountryLatency is the mean latency of successful probes for one MCC
+type CountryLatency struct {
+	MCC     string        `json:"mcc"`
+	Country string        `json:"country,omitempty"`
+	Count   int           `json:"count"`
+	Mean    time.Duration `json:"mean"`
+}
+
+// GeoEndpoint is a located IP address with the FQDNs that resolve to it
+type GeoEndpoint struct {
+	IP        string   `json:"ip"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Country   string   `json:"country,omitempty"`
+	City      string   `json:"city,omitempty"`
+	FQDNs     []string `json:"fqdns"`
+	Operators []string `json:"operators,omitempty"`
+}
+
+// OperatorProfile is a dossier of everything known about one MCC/MNC
+type OperatorProfile struct {
+	MCC       string   `json:"mcc"`
+	MNC       string   `json:"mnc"`
+	Country   string   `json:"country,omitempty"`
+	Operators []string `json:"operators"`
+	// FQDNs grouped by their first label (epdg, ims, bsf, ...)
+	FQDNsByType map[string][]string `json:"fqdns_by_type"`
+	TotalFQDNs  int                 `json:"total_fqdns"`
+	IPs         []string            `json:"ips"`
+	Pings       []PingResult        `json:"pings"`
+	FirstSeen   *time.Time          `json:"first_seen,omitempty"`
+	LastSeen    *time.Time          `json:"last_seen,omitempty"`
+	// Analyst notes on the operators and FQDNs, oldest first
+	Notes []Note `json:"notes,omitempty"`
+	// Sections that could not be filled from the available data
+	Unavailable []string `json:"unavailable,omitempty"`
+	// Past resolutions from passive DNS, by FQDN and first seen
+	IPHistory []IPResolution `json:"ip_history,omitempty"`
+}
+
+// Note is a free-text analyst note on an FQDN or operator
+type Note struct {
+	ID      int64      `json:"id"`
+	Kind    string     `json:"kind"` // fqdn or operator
+	Target  string     `json:"target"`
+	Text    string     `json:"text"`
+	Created time.Time  `json:"created"`
+	Updated *time.Time `json:"updated,omitempty"`
+}
+
+// IPResolution is an address an FQDN resolved to in the past, as seen by a
+// passive DNS provider
+type IPResolution struct {
+	FQDN      string    `json:"fqdn"`
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int       `json:"count,omitempty"` // Observations, if the provider counts them
+	Source    string    `json:"source"`          // Passive DNS provider
+}
+
+// Run is one scan or import that stored results in the database
+type Run struct {
+	ID      int64     `json:"id"`
+	Kind    string    `json:"kind"`             // scan or imported
+	Source  string    `json:"source,omitempty"` // Scan mode and shard, or imported file
+	Started time.Time `json:"started"`
+	Results int       `json:"results"`
+}
+
+// OperatorComparison is one operator's column in a side-by-side comparison
+type OperatorComparison struct {
+	Operator   string `json:"operator"`
+	TotalFQDNs int    `json:"total_fqdns"`
+	// FQDN counts per service type (epdg, ims, bsf, ...)
+	Services  map[string]int `json:"services"`
+	IPs       int            `json:"ips"`
+	Probed    int            `json:"probed"`    // FQDNs with at least one ping
+	Reachable int            `json:"reachable"` // FQDNs with at least one successful ping
+}
+
+// HostMapping suggests that a guest MCC-MNC (typically an MVNO) rides on
+// the infrastructure of a host network
+type HostMapping struct {
+	Guest         string   `json:"guest"` // MCC-MNC, e.g. "262-023"
+	GuestOperator string   `json:"guest_operator,omitempty"`
+	Host          string   `json:"host"`
+	HostOperator  string   `json:"host_operator,omitempty"`
+	SharedIPs     int      `json:"shared_ips"`
+	SharedCNAMEs  int      `json:"shared_cnames"`
+	Confidence    float64  `json:"confidence"` // 0..1
+	Signals       []string `json:"signals"`
+}
+
+// OperatorPrefix is one prefix of the minimal set covering the addresses
+// an operator's FQDNs resolved to
+type OperatorPrefix struct {
+	Operator string `json:"operator"`
+	Prefix   string `json:"prefix"` // CIDR, e.g. "192.0.2.0/24"
+	IPs      int    `json:"ips"`    // Distinct addresses in the prefix
+	FQDNs    int    `json:"fqdns"`  // FQDNs resolving into the prefix
+}
+
+// FQDNDetail is a stored FQDN joined with its operator, the country of its
+// zone, its addresses and when a scan last found it
+type FQDNDetail struct {
+	FQDN        string     `json:"fqdn"`
+	Operator    string     `json:"operator"`
+	Country     string     `json:"country,omitempty"`
+	CountryCode string     `json:"country_code,omitempty"`
+	IPs         []string   `json:"ips"`
+	LastSeen    *time.Time `json:"last_seen,omitempty"` // Nil if no recorded run found it
+	Vendor      string     `json:"vendor,omitempty"`    // Label stored by fingerprint
+	Tags        []string   `json:"tags,omitempty"`
+}
+
+// IPOrigin is the BGP route an address is announced in
+type IPOrigin struct {
+	IP     string `json:"ip"`
+	Prefix string `json:"prefix"`            // Announced prefix, e.g. "193.0.0.0/21"
+	ASN    int    `json:"asn"`               // Origin AS
+	ASName string `json:"as_name,omitempty"` // Holder of the origin AS, if known
+	Cloud  string `json:"cloud,omitempty"`   // Cloud provider operating the origin AS
+}
+
+// OriginSummary counts the endpoints announced by one origin AS
+type OriginSummary struct {
+	ASN      int      `json:"asn"`
+	ASName   string   `json:"as_name,omitempty"`
+	Cloud    string   `json:"cloud,omitempty"`
+	Prefixes []string `json:"prefixes"`
+	IPs      int      `json:"ips"`
+	FQDNs    int      `json:"fqdns"`
+}
+
+// CloudEndpoint is an FQDN resolving into a cloud provider's address space
+type CloudEndpoint struct {
+	FQDN   string `json:"fqdn"`
+	IP     string `json:"ip"`
+	Prefix string `json:"prefix"`
+	ASN    int    `json:"asn"`
+	Cloud  string `json:"cloud"`
+}
+
+// WhoisNetwork is a registered address range from whois with its holder
+// and abuse contacts, for responsible disclosure. A range that is not a
+// single CIDR block is stored as one network per block.
+type WhoisNetwork struct {
+	Prefix      string   `json:"prefix"`          // CIDR block, e.g. "193.0.0.0/21"
+	Range       string   `json:"range,omitempty"` // Registered range, e.g. "193.0.0.0 - 193.0.7.255"
+	NetName     string   `json:"net_name,omitempty"`
+	Org         string   `json:"org,omitempty"` // Holder of the range
+	Country     string   `json:"country,omitempty"`
+	AbuseEmails []string `json:"abuse_emails,omitempty"`
+	Server      string   `json:"server"` // Whois server that answered
+}
+
+// AbuseContact is a registered network holding discovered endpoints, with
+// whom to notify about findings on them
+type AbuseContact struct {
+	Prefix      string   `json:"prefix"`
+	NetName     string   `json:"net_name,omitempty"`
+	Org         string   `json:"org,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	AbuseEmails []string `json:"abuse_emails"`
+	Operators   []string `json:"operators"`
+	FQDNs       []string `json:"fqdns"`
+	IPs         int      `json:"ips"`
+}
+
+// ProbeConfig holds configuration for protocol probes of discovered hosts
+type ProbeConfig struct {
+	Timeout   time.Duration
+	Workers   int
+	SourceIP  string // Local address to send probes from (optional)
+	Interface string // Network interface to send probes from (optional)
+	// ActiveTelecom enables probes speaking telecom control protocols
+	// (GTP-C); they must be opted into explicitly
+	ActiveTelecom bool
+	// TelecomRate caps active telecom probes per second across all workers
+	TelecomRate float64
+}
+
+// ProbeResult is the outcome of one probe against one discovered FQDN
+type ProbeResult struct {
+	FQDN    string            `json:"fqdn"`
+	IP      string            `json:"ip,omitempty"`
+	Probe   string            `json:"probe"`
+	Success bool              `json:"success"`
+	Status  ResultStatus      `json:"status,omitempty"`
+	Latency time.Duration     `json:"latency,omitempty"`
+	Details map[string]string `json:"details,omitempty"` // Probe-specific findings
+	Error   string            `json:"error,omitempty"`
+	// Timestamp is set by the runner when the probe finishes
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// VendorMatch labels an endpoint with its probable vendor or hosting
+// platform, from certificate, reverse DNS, IKE and banner evidence
+type VendorMatch struct {
+	FQDN       string   `json:"fqdn"`
+	IP         string   `json:"ip,omitempty"`
+	Vendor     string   `json:"vendor"`
+	Confidence float64  `json:"confidence"` // 0..1
+	Signals    []string `json:"signals"`
+}
+
+// EndpointScore rates how likely an FQDN is real, live infrastructure
+// rather than a stale DNS record
+type EndpointScore struct {
+	FQDN     string   `json:"fqdn"`
+	Operator string   `json:"operator,omitempty"`
+	Score    float64  `json:"score"` // 0..1
+	Signals  []string `json:"signals"`
+}
+
+// Result types of the Result envelope besides probe names
+const (
+	ResultTypeDNS  = "dns"
+	ResultTypePing = "ping"
+)
+
+// Result is the common envelope of DNS, ping and probe results. Fields
+// specific to a result type go into Metadata, so storage, exports and the
+// API handle new probe types without changes.
+type Result struct {
+	Target    string            `json:"target"` // FQDN
+	Type      string            `json:"type"`   // dns, ping or the probe name
+	IPs       []string          `json:"ips,omitempty"`
+	Success   bool              `json:"success"`
+	Status    ResultStatus      `json:"status,omitempty"`
+	Latency   time.Duration     `json:"latency,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// setMetadata adds a metadata field unless its value is empty
+func (r *Result) setMetadata(key, value string) {
+	if value == "" {
+		return
+	}
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]string)
+	}
+	r.Metadata[key] = value
+}
+
+// Result converts a DNS result to the common envelope
+func (d DNSResult) Result() Result {
+	r := Result{
+		Target:    d.FQDN,
+		Type:      ResultTypeDNS,
+		IPs:       d.IPs,
+		Success:   len(d.IPs) > 0,
+		Status:    d.Status,
+		Error:     d.Error,
+		Timestamp: d.Timestamp,
+	}
+	r.setMetadata("subdomain", d.Subdomain)
+	r.setMetadata("mcc", d.MCC)
+	r.setMetadata("mnc", d.MNC)
+	r.setMetadata("operator", d.Operator)
+	r.setMetadata("cnames", strings.Join(d.CNAMEs, ";"))
+	r.setMetadata("country", d.Country)
+	r.setMetadata("country_code", d.CountryCode)
+	r.setMetadata("brand", d.Brand)
+	r.setMetadata("operator_status", d.OperatorStatus)
+	return r
+}
+
+// Result converts a ping result to the common envelope
+func (p PingResult) Result() Result {
+	r := Result{
+		Target:    p.FQDN,
+		Type:      ResultTypePing,
+		Success:   p.Success,
+		Status:    p.Status,
+		Latency:   p.Latency,
+		Error:     p.Error,
+		Timestamp: p.Timestamp,
+	}
+	if p.IP != "" {
+		r.IPs = []string{p.IP}
+	}
+	r.setMetadata("method", p.Method)
+	if p.Port > 0 {
+		r.setMetadata("port", strconv.Itoa(p.Port))
+	}
+	if p.PathMTU > 0 {
+		r.setMetadata("path_mtu", strconv.Itoa(p.PathMTU))
+	}
+	if p.TLSHandshake > 0 {
+		r.setMetadata("tls_handshake", p.TLSHandshake.String())
+	}
+	if p.HTTPStatus > 0 {
+		r.setMetadata("http_status", strconv.Itoa(p.HTTPStatus))
+	}
+	r.setMetadata("http_server", p.HTTPServer)
+	r.setMetadata("cert_issuer", p.CertIssuer)
+	r.setMetadata("cert_subject", p.CertSubject)
+	r.setMetadata("cert_sans", strings.Join(p.CertSANs, ";"))
+	if p.CertIssuer != "" {
+		r.setMetadata("cert_valid", strconv.FormatBool(p.CertValid))
+	}
+	if ds := p.DualStack; ds != nil {
+		r.setMetadata("faster_family", ds.Faster)
+	}
+	return r
+}
+
+// Result converts a probe result to the common envelope; the probe
+// details become its metadata
+func (p ProbeResult) Result() Result {
+	r := Result{
+		Target:    p.FQDN,
+		Type:      p.Probe,
+		Success:   p.Success,
+		Status:    p.Status,
+		Latency:   p.Latency,
+		Error:     p.Error,
+		Timestamp: p.Timestamp,
+	}
+	if p.IP != "" {
+		r.IPs = []string{p.IP}
+	}
+	for key, value := range p.Details {
+		r.setMetadata(key, value)
+	}
+	return r
+}
+
+// Results converts DNS, ping or probe results to the common envelope
+func Results[T interface{ Result() Result }](items []T) []Result {
+	results := make([]Result, len(items))
+	for i, item := range items {
+		results[i] = item.Result()
+	}
+	return results
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -10,8 +11,8 @@ func TestDNSResult(t *testing.T) {
 		FQDN:      "ims.mnc001.mcc310.pub.3gppnetwork.org",
 		IPs:       []string{"192.0.2.1", "192.0.2.2"},
 		Subdomain: "ims",
-		MNC:       1,
-		MCC:       310,
+		MNC:       "01",
+		MCC:       "310",
 		Operator:  "Verizon",
 		Timestamp: time.Now(),
 	}
@@ -24,12 +25,63 @@ func TestDNSResult(t *testing.T) {
 		t.Errorf("Expected 2 IPs, got %d", len(result.IPs))
 	}
 
-	if result.MNC != 1 {
-		t.Errorf("Expected MNC 1, got %d", result.MNC)
+	if result.MNC != "01" {
+		t.Errorf("Expected MNC 01, got %s", result.MNC)
 	}
 
-	if result.MCC != 310 {
-		t.Errorf("Expected MCC 310, got %d", result.MCC)
+	if result.MCC != "310" {
+		t.Errorf("Expected MCC 310, got %s", result.MCC)
+	}
+}
+
+func TestNormalizeCodes(t *testing.T) {
+	tests := []struct {
+		in             string
+		mcc, mnc, zone string
+	}{
+		{"1", "001", "01", "001"},
+		{"01", "001", "01", "001"},
+		{"001", "001", "001", "001"},
+		{" 260 ", "260", "260", "260"},
+		{"", "", "", ""},
+		{"x1", "x1", "x1", "x1"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMCC(tt.in); got != tt.mcc {
+			t.Errorf("NormalizeMCC(%q) = %q, want %q", tt.in, got, tt.mcc)
+		}
+		if got := NormalizeMNC(tt.in); got != tt.mnc {
+			t.Errorf("NormalizeMNC(%q) = %q, want %q", tt.in, got, tt.mnc)
+		}
+		if got := PadMNC(tt.in); got != tt.zone {
+			t.Errorf("PadMNC(%q) = %q, want %q", tt.in, got, tt.zone)
+		}
+	}
+}
+
+func TestCodesFromJSON(t *testing.T) {
+	var results []DNSResult
+	data := `[{"fqdn": "a", "mnc": "001", "mcc": "310"}, {"fqdn": "b", "mnc": 1, "mcc": 262}, {"fqdn": "c", "mnc": 260, "mcc": 310}]`
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{"001", "310"}, {"01", "262"}, {"260", "310"}}
+	for i, r := range results {
+		if r.MNC != want[i][0] || r.MCC != want[i][1] || r.FQDN == "" {
+			t.Errorf("result %d = %+v, want MNC %s MCC %s", i, r, want[i][0], want[i][1])
+		}
+	}
+
+	var zone ZoneInfo
+	if err := json.Unmarshal([]byte(`{"zone": "mnc001.mcc262.pub.3gppnetwork.org", "mnc": 1, "mcc": 262}`), &zone); err != nil {
+		t.Fatal(err)
+	}
+	if zone.MNC != "001" || zone.MCC != "262" || zone.Zone == "" {
+		t.Errorf("zone = %+v", zone)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"mnc": true}]`), &results); err == nil {
+		t.Error("expected an error for a boolean MNC")
 	}
 }
 
@@ -56,6 +108,23 @@ func TestPingResult(t *testing.T) {
 	}
 }
 
+func TestPingResultFromJSON(t *testing.T) {
+	var results []PingResult
+	data := `[{"fqdn": "a", "ip": "192.0.2.1:4500"}, {"fqdn": "b", "ip": "[2001:db8::1]:443"}, {"fqdn": "c", "ip": "192.0.2.3", "port": 443}, {"fqdn": "d", "ip": "192.0.2.4"}]`
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		ip   string
+		port int
+	}{{"192.0.2.1", 4500}, {"2001:db8::1", 443}, {"192.0.2.3", 443}, {"192.0.2.4", 0}}
+	for i, r := range results {
+		if r.IP != want[i].ip || r.Port != want[i].port {
+			t.Errorf("result %d = %s port %d, want %s port %d", i, r.IP, r.Port, want[i].ip, want[i].port)
+		}
+	}
+}
+
 func TestMCCMNCEntry(t *testing.T) {
 	entry := MCCMNCEntry{
 		Type:        "mobile",
@@ -85,7 +154,6 @@ func TestScanConfig(t *testing.T) {
 		Subdomains:   []string{"ims", "epdg.epc"},
 		QueryDelay:   500 * time.Millisecond,
 		Concurrency:  10,
-		Verbose:      false,
 	}
 
 	if config.ParentDomain != "pub.3gppnetwork.org" {
@@ -107,7 +175,6 @@ func TestPingConfig(t *testing.T) {
 		Timeout:  300 * time.Millisecond,
 		Workers:  20,
 		TCPPorts: []int{443, 4500},
-		Verbose:  true,
 	}
 
 	if config.Method != "tcp" {
@@ -154,3 +221,39 @@ func TestStats(t *testing.T) {
 		t.Errorf("Expected MCC 310 count 45, got %d", stats.MCCDistribution["310"])
 	}
 }
+
+func TestResultEnvelope(t *testing.T) {
+	now := time.Now()
+	dns := DNSResult{
+		FQDN:      "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+		IPs:       []string{"192.0.2.1"},
+		Subdomain: "epdg.epc",
+		MNC:       "01",
+		MCC:       "262",
+		Operator:  "Telekom",
+		Status:    StatusOK,
+		Timestamp: now,
+	}.Result()
+	if dns.Type != ResultTypeDNS || !dns.Success || dns.Target != "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org" {
+		t.Errorf("DNS envelope = %+v", dns)
+	}
+	if dns.Metadata["mnc"] != "01" || dns.Metadata["operator"] != "Telekom" {
+		t.Errorf("DNS metadata = %v", dns.Metadata)
+	}
+	if _, ok := dns.Metadata["cnames"]; ok {
+		t.Errorf("empty CNAMEs in metadata: %v", dns.Metadata)
+	}
+
+	ping := PingResult{FQDN: "epdg.example", Success: true, Latency: 20 * time.Millisecond, IP: "192.0.2.1", Port: 443, Method: "https", HTTPStatus: 200}.Result()
+	if ping.Type != ResultTypePing || ping.Latency != 20*time.Millisecond || len(ping.IPs) != 1 {
+		t.Errorf("ping envelope = %+v", ping)
+	}
+	if ping.Metadata["method"] != "https" || ping.Metadata["port"] != "443" || ping.Metadata["http_status"] != "200" {
+		t.Errorf("ping metadata = %v", ping.Metadata)
+	}
+
+	probe := ProbeResult{FQDN: "epdg.example", Probe: "ike", Status: StatusTimeout, Details: map[string]string{"vendor_id": "strongSwan"}}.Result()
+	if probe.Type != "ike" || probe.Success || probe.IPs != nil || probe.Metadata["vendor_id"] != "strongSwan" {
+		t.Errorf("probe envelope = %+v", probe)
+	}
+}
@@ -0,0 +1,305 @@
+// Package monitor watches an operator's own FQDNs for regressions: names
+// that stop resolving, addresses that change and TLS certificates close to
+// expiry. Every check is compared with the previous one, so a daemon
+// alerts once per change instead of repeating known problems, and counts
+// the changes of every FQDN to tell volatile names from stable ones.
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert kinds
+const (
+	KindUnresolved   = "unresolved"
+	KindResolved     = "resolved" // Resolves again after an unresolved alert
+	KindIPChanged    = "ip-changed"
+	KindCertExpiring = "cert-expiring"
+	KindCertExpired  = "cert-expired"
+	KindMassChange   = "mass-change" // Many FQDNs of one operator changed at once
+)
+
+// Alert is one change noticed on a watched FQDN
+type Alert struct {
+	Kind       string     `json:"kind"`
+	FQDN       string     `json:"fqdn,omitempty"` // Empty for mass-change alerts
+	Operator   string     `json:"operator,omitempty"`
+	Detail     string     `json:"detail"`
+	OldIPs     []string   `json:"old_ips,omitempty"`
+	NewIPs     []string   `json:"new_ips,omitempty"`
+	CertExpiry *time.Time `json:"cert_expiry,omitempty"`
+	Time       time.Time  `json:"time"`
+}
+
+func (a Alert) String() string {
+	subject := a.FQDN
+	switch {
+	case a.FQDN == "":
+		subject = a.Operator
+	case a.Operator != "":
+		subject += " (" + a.Operator + ")"
+	}
+	return fmt.Sprintf("%s %-13s %s: %s", a.Time.UTC().Format(time.RFC3339), a.Kind, subject, a.Detail)
+}
+
+// Target is a watched FQDN with the addresses it is expected to resolve to
+type Target struct {
+	FQDN     string
+	Operator string
+	IPs      []string // Baseline; empty adopts the first answer
+}
+
+// Config tunes the checks
+type Config struct {
+	CertPort   int           // TLS port whose certificate is checked
+	CertWithin time.Duration // Alert for certificates expiring this soon; 0 skips the check
+	Timeout    time.Duration // Per lookup and TLS handshake
+	Workers    int
+	// An operator whose FQDNs change in one check at least this share and
+	// number gets a mass-change alert; a share of 0 disables the alert
+	MassChangeShare float64
+	MassChangeMin   int
+}
+
+// DefaultConfig returns the default check settings
+func DefaultConfig() Config {
+	return Config{
+		CertPort:        443,
+		CertWithin:      30 * 24 * time.Hour,
+		Timeout:         5 * time.Second,
+		Workers:         20,
+		MassChangeShare: 0.5,
+		MassChangeMin:   3,
+	}
+}
+
+// Monitor checks a fixed set of targets and remembers what it reported
+type Monitor struct {
+	config    Config
+	targets   []*state
+	operators map[string]int // Number of targets per operator
+
+	// Replaced in tests
+	lookup     func(ctx context.Context, host string) ([]string, error)
+	certExpiry func(ctx context.Context, host string, port int) (time.Time, error)
+	now        func() time.Time
+}
+
+// state is a target and what was last reported about it
+type state struct {
+	Target
+	unresolved bool
+	certAlert  string    // Kind of the last certificate alert
+	certExpiry time.Time // Expiry of the certificate last alerted
+
+	// Volatility counters, see Volatility
+	checks     int
+	changes    int
+	outages    int
+	lastChange time.Time
+}
+
+// New creates a monitor for targets
+func New(targets []Target, config Config) *Monitor {
+	m := &Monitor{
+		config:     config,
+		lookup:     net.DefaultResolver.LookupHost,
+		certExpiry: leafExpiry,
+		now:        time.Now,
+		operators:  make(map[string]int),
+	}
+	for _, t := range targets {
+		t.IPs = sortedCopy(t.IPs)
+		m.targets = append(m.targets, &state{Target: t})
+		if t.Operator != "" {
+			m.operators[t.Operator]++
+		}
+	}
+	return m
+}
+
+// Len returns the number of watched targets
+func (m *Monitor) Len() int {
+	return len(m.targets)
+}
+
+// Check resolves every target, checks its certificate and returns the
+// changes since the previous check, ordered by FQDN after any mass-change
+// alerts
+func (m *Monitor) Check(ctx context.Context) []Alert {
+	var mu sync.Mutex
+	var alerts []Alert
+	sem := make(chan struct{}, max(1, m.config.Workers))
+	var wg sync.WaitGroup
+	for _, s := range m.targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(s *state) {
+			defer func() { <-sem; wg.Done() }()
+			found := m.check(ctx, s)
+			mu.Lock()
+			alerts = append(alerts, found...)
+			mu.Unlock()
+		}(s)
+	}
+	wg.Wait()
+
+	alerts = append(alerts, m.massChanges(alerts)...)
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].FQDN < alerts[j].FQDN })
+	return alerts
+}
+
+// check checks one target; each target is only touched by one goroutine
+func (m *Monitor) check(ctx context.Context, s *state) []Alert {
+	var alerts []Alert
+	alert := func(kind, detail string) *Alert {
+		alerts = append(alerts, Alert{Kind: kind, FQDN: s.FQDN, Operator: s.Operator, Detail: detail, Time: m.now()})
+		return &alerts[len(alerts)-1]
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	ips, err := m.lookup(lookupCtx, s.FQDN)
+	cancel()
+	if ctx.Err() != nil {
+		return nil
+	}
+	s.checks++
+	if err != nil || len(ips) == 0 {
+		if !s.unresolved {
+			s.outages++
+			detail := "no addresses"
+			if err != nil {
+				detail = err.Error()
+			}
+			alert(KindUnresolved, detail).OldIPs = s.IPs
+			s.unresolved = true
+		}
+		return alerts
+	}
+
+	ips = sortedCopy(ips)
+	if s.unresolved {
+		alert(KindResolved, "resolves to "+strings.Join(ips, ", "))
+		s.unresolved = false
+	}
+	if len(s.IPs) > 0 && !slices.Equal(s.IPs, ips) {
+		a := alert(KindIPChanged, strings.Join(s.IPs, ", ")+" -> "+strings.Join(ips, ", "))
+		a.OldIPs, a.NewIPs = s.IPs, ips
+		s.changes++
+		s.lastChange = a.Time
+	}
+	s.IPs = ips
+
+	if m.config.CertWithin > 0 {
+		alerts = append(alerts, m.checkCert(ctx, s)...)
+	}
+	return alerts
+}
+
+// massChanges returns a mass-change alert for every operator of which
+// enough FQDNs stopped resolving or changed addresses in one check: a
+// migration or an incident rather than routine changes
+func (m *Monitor) massChanges(alerts []Alert) []Alert {
+	if m.config.MassChangeShare <= 0 {
+		return nil
+	}
+	changed := make(map[string]map[string]bool)
+	for _, alert := range alerts {
+		if alert.Operator == "" || (alert.Kind != KindIPChanged && alert.Kind != KindUnresolved) {
+			continue
+		}
+		if changed[alert.Operator] == nil {
+			changed[alert.Operator] = make(map[string]bool)
+		}
+		changed[alert.Operator][alert.FQDN] = true
+	}
+
+	var mass []Alert
+	for operator, fqdns := range changed {
+		total := m.operators[operator]
+		if len(fqdns) < max(1, m.config.MassChangeMin) || float64(len(fqdns)) < m.config.MassChangeShare*float64(total) {
+			continue
+		}
+		mass = append(mass, Alert{
+			Kind:     KindMassChange,
+			Operator: operator,
+			Detail:   fmt.Sprintf("%d of %d FQDNs changed addresses or stopped resolving (migration or incident?)", len(fqdns), total),
+			Time:     m.now(),
+		})
+	}
+	sort.Slice(mass, func(i, j int) bool { return mass[i].Operator < mass[j].Operator })
+	return mass
+}
+
+// checkCert alerts when the certificate of a target enters the warning
+// window or expires. Hosts without TLS on the port are skipped.
+func (m *Monitor) checkCert(ctx context.Context, s *state) []Alert {
+	certCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	expiry, err := m.certExpiry(certCtx, s.FQDN, m.config.CertPort)
+	cancel()
+	if err != nil {
+		return nil
+	}
+
+	alert, ok := CertAlert(s.FQDN, s.Operator, expiry, m.now(), m.config.CertWithin)
+	if !ok {
+		// Renewed or never close to expiry: alert again next time
+		s.certAlert, s.certExpiry = "", time.Time{}
+		return nil
+	}
+	if alert.Kind == s.certAlert && expiry.Equal(s.certExpiry) {
+		return nil
+	}
+	s.certAlert, s.certExpiry = alert.Kind, expiry
+	return []Alert{alert}
+}
+
+// CertAlert returns the alert for a certificate expiring at expiry, if it
+// has expired or expires within the given time of now
+func CertAlert(fqdn, operator string, expiry, now time.Time, within time.Duration) (Alert, bool) {
+	alert := Alert{FQDN: fqdn, Operator: operator, CertExpiry: &expiry, Time: now}
+	switch {
+	case !expiry.After(now):
+		alert.Kind, alert.Detail = KindCertExpired, "certificate expired "+expiry.UTC().Format(time.DateOnly)
+	case expiry.Before(now.Add(within)):
+		days := int(expiry.Sub(now).Hours() / 24)
+		alert.Kind, alert.Detail = KindCertExpiring, fmt.Sprintf("certificate expires %s (in %d days)", expiry.UTC().Format(time.DateOnly), days)
+	default:
+		return Alert{}, false
+	}
+	return alert, true
+}
+
+// leafExpiry completes a TLS handshake and returns the expiry of the leaf
+// certificate. Any certificate is accepted: expiry is what is checked.
+func leafExpiry(ctx context.Context, host string, port int) (time.Time, error) {
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, fmt.Errorf("no certificate")
+	}
+	return certs[0].NotAfter, nil
+}
+
+func sortedCopy(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return sorted
+}
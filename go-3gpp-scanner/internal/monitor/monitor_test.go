@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeNetwork answers lookups and handshakes from maps tests change
+// between checks
+type fakeNetwork struct {
+	ips    map[string][]string
+	expiry map[string]time.Time
+}
+
+func newTestMonitor(targets []Target, network *fakeNetwork, now time.Time) *Monitor {
+	m := New(targets, DefaultConfig())
+	m.lookup = func(ctx context.Context, host string) ([]string, error) {
+		if ips, ok := network.ips[host]; ok {
+			return ips, nil
+		}
+		return nil, fmt.Errorf("lookup %s: no such host", host)
+	}
+	m.certExpiry = func(ctx context.Context, host string, port int) (time.Time, error) {
+		if expiry, ok := network.expiry[host]; ok {
+			return expiry, nil
+		}
+		return time.Time{}, fmt.Errorf("connection refused")
+	}
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func kinds(alerts []Alert) []string {
+	var result []string
+	for _, alert := range alerts {
+		result = append(result, alert.FQDN+" "+alert.Kind)
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	const epdg, bsf = "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "bsf.mnc001.mcc262.pub.3gppnetwork.org"
+	network := &fakeNetwork{
+		ips:    map[string][]string{epdg: {"192.0.2.2", "192.0.2.1"}, bsf: {"198.51.100.1"}},
+		expiry: map[string]time.Time{bsf: now.Add(90 * 24 * time.Hour)},
+	}
+	m := newTestMonitor([]Target{
+		{FQDN: epdg, Operator: "Telekom", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{FQDN: bsf, Operator: "Telekom"},
+	}, network, now)
+	ctx := context.Background()
+
+	if alerts := m.Check(ctx); len(alerts) != 0 {
+		t.Fatalf("Expected no alerts for an unchanged network, got %v", kinds(alerts))
+	}
+
+	// Each change is reported once
+	delete(network.ips, epdg)
+	network.ips[bsf] = []string{"198.51.100.2"}
+	network.expiry[bsf] = now.Add(10 * 24 * time.Hour)
+	alerts := m.Check(ctx)
+	want := []string{bsf + " ip-changed", bsf + " cert-expiring", epdg + " unresolved"}
+	if fmt.Sprint(kinds(alerts)) != fmt.Sprint(want) {
+		t.Fatalf("Expected %v, got %v", want, kinds(alerts))
+	}
+	if alerts[0].OldIPs[0] != "198.51.100.1" || alerts[0].NewIPs[0] != "198.51.100.2" {
+		t.Errorf("Unexpected addresses: %+v", alerts[0])
+	}
+	if alerts[1].CertExpiry == nil || alerts[1].Detail != "certificate expires 2026-03-11 (in 10 days)" {
+		t.Errorf("Unexpected certificate alert: %+v", alerts[1])
+	}
+	if alerts := m.Check(ctx); len(alerts) != 0 {
+		t.Fatalf("Expected known problems not to be repeated, got %v", kinds(alerts))
+	}
+
+	// Recovery, expiry and renewal
+	network.ips[epdg] = []string{"192.0.2.1", "192.0.2.2"}
+	network.expiry[bsf] = now.Add(-time.Hour)
+	want = []string{bsf + " cert-expired", epdg + " resolved"}
+	if alerts := m.Check(ctx); fmt.Sprint(kinds(alerts)) != fmt.Sprint(want) {
+		t.Fatalf("Expected %v, got %v", want, kinds(alerts))
+	}
+	network.expiry[bsf] = now.Add(365 * 24 * time.Hour)
+	if alerts := m.Check(ctx); len(alerts) != 0 {
+		t.Fatalf("Expected no alerts after renewal, got %v", kinds(alerts))
+	}
+	network.expiry[bsf] = now.Add(24 * time.Hour)
+	if alerts := m.Check(ctx); fmt.Sprint(kinds(alerts)) != fmt.Sprint([]string{bsf + " cert-expiring"}) {
+		t.Fatalf("Expected a new certificate alert, got %v", kinds(alerts))
+	}
+}
+
+func TestCheckWithoutCertificates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	network := &fakeNetwork{
+		ips:    map[string][]string{"a.example": {"192.0.2.1"}},
+		expiry: map[string]time.Time{"a.example": now},
+	}
+	m := newTestMonitor([]Target{{FQDN: "a.example"}}, network, now)
+	m.config.CertWithin = 0
+	if alerts := m.Check(context.Background()); len(alerts) != 0 {
+		t.Errorf("Expected certificate checks to be off, got %v", kinds(alerts))
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	alerts := []Alert{{Kind: KindUnresolved, FQDN: "a.example", Detail: "no addresses", Time: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}}
+	if err := PostWebhook(context.Background(), server.Client(), server.URL, "test", alerts); err != nil {
+		t.Fatalf("PostWebhook: %v", err)
+	}
+	if len(payload.Alerts) != 1 || payload.Text != "2026-03-01T12:00:00Z unresolved    a.example: no addresses" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := PostWebhook(context.Background(), failing.Client(), failing.URL, "test", alerts); err == nil {
+		t.Error("Expected error for a failing webhook")
+	}
+}
+
+func TestMassChange(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	network := &fakeNetwork{ips: map[string][]string{}}
+	var targets []Target
+	for i := range 4 {
+		fqdn := fmt.Sprintf("host%d.telekom.example", i)
+		network.ips[fqdn] = []string{"192.0.2.1"}
+		targets = append(targets, Target{FQDN: fqdn, Operator: "Telekom", IPs: []string{"192.0.2.1"}})
+	}
+	targets = append(targets, Target{FQDN: "other.example", Operator: "Other", IPs: []string{"198.51.100.1"}})
+	network.ips["other.example"] = []string{"198.51.100.1"}
+	m := newTestMonitor(targets, network, now)
+	m.config.CertWithin = 0
+	ctx := context.Background()
+
+	// Two of four FQDNs is below --mass-change-min
+	network.ips["host0.telekom.example"] = []string{"203.0.113.1"}
+	network.ips["host1.telekom.example"] = []string{"203.0.113.1"}
+	if alerts := m.Check(ctx); len(alerts) != 2 {
+		t.Fatalf("Expected two ip-changed alerts, got %v", kinds(alerts))
+	}
+
+	network.ips["host0.telekom.example"] = []string{"203.0.113.2"}
+	network.ips["host2.telekom.example"] = []string{"203.0.113.2"}
+	delete(network.ips, "host3.telekom.example")
+	network.ips["other.example"] = []string{"198.51.100.2"}
+	alerts := m.Check(ctx)
+	if len(alerts) != 5 || alerts[0].Kind != KindMassChange || alerts[0].Operator != "Telekom" {
+		t.Fatalf("Expected a mass-change alert for Telekom first, got %v", kinds(alerts))
+	}
+	if want := "2026-03-01T12:00:00Z mass-change   Telekom: 3 of 4 FQDNs changed addresses or stopped resolving (migration or incident?)"; alerts[0].String() != want {
+		t.Errorf("Unexpected alert text: %q", alerts[0].String())
+	}
+
+	m.config.MassChangeShare = 0
+	network.ips["host0.telekom.example"] = []string{"203.0.113.3"}
+	network.ips["host1.telekom.example"] = []string{"203.0.113.3"}
+	network.ips["host2.telekom.example"] = []string{"203.0.113.3"}
+	for _, alert := range m.Check(ctx) {
+		if alert.Kind == KindMassChange {
+			t.Errorf("Expected mass-change alerts to be off, got %v", alert)
+		}
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	network := &fakeNetwork{ips: map[string][]string{"stable.example": {"192.0.2.1"}, "rotating.example": {"192.0.2.10"}}}
+	m := newTestMonitor([]Target{{FQDN: "stable.example"}, {FQDN: "rotating.example", Operator: "Telekom"}}, network, now)
+	m.config.CertWithin = 0
+	ctx := context.Background()
+
+	for i := range 4 {
+		network.ips["rotating.example"] = []string{fmt.Sprintf("192.0.2.%d", 10+i)}
+		m.Check(ctx)
+	}
+	delete(network.ips, "stable.example")
+	m.Check(ctx)
+
+	got := m.Volatility()
+	if len(got) != 2 || got[0].FQDN != "rotating.example" || got[1].FQDN != "stable.example" {
+		t.Fatalf("Expected the rotating FQDN first, got %+v", got)
+	}
+	if got[0].Checks != 5 || got[0].Changes != 3 || got[0].Rate != 0.6 || got[0].LastChange == nil || got[0].Operator != "Telekom" {
+		t.Errorf("Unexpected volatility of the rotating FQDN: %+v", got[0])
+	}
+	if got[1].Changes != 0 || got[1].Outages != 1 || got[1].Rate != 0 || got[1].LastChange != nil {
+		t.Errorf("Unexpected volatility of the stable FQDN: %+v", got[1])
+	}
+}
@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// Volatility is how often the address set of a watched FQDN changed
+// across the checks of a monitor
+type Volatility struct {
+	FQDN       string     `json:"fqdn"`
+	Operator   string     `json:"operator,omitempty"`
+	Checks     int        `json:"checks"`
+	Changes    int        `json:"changes"`     // ip-changed alerts
+	Outages    int        `json:"outages"`     // unresolved alerts
+	Rate       float64    `json:"change_rate"` // Changes per check
+	LastChange *time.Time `json:"last_change,omitempty"`
+}
+
+// Volatility returns the volatility of every target, most changes first.
+// A target stable over many checks has a rate near 0; one whose answer
+// rotates at every check, e.g. round-robin DNS, a rate near 1.
+func (m *Monitor) Volatility() []Volatility {
+	result := make([]Volatility, len(m.targets))
+	for i, s := range m.targets {
+		v := Volatility{FQDN: s.FQDN, Operator: s.Operator, Checks: s.checks, Changes: s.changes, Outages: s.outages}
+		if s.checks > 0 {
+			v.Rate = float64(s.changes) / float64(s.checks)
+		}
+		if !s.lastChange.IsZero() {
+			last := s.lastChange
+			v.LastChange = &last
+		}
+		result[i] = v
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Changes != result[j].Changes {
+			return result[i].Changes > result[j].Changes
+		}
+		return result[i].FQDN < result[j].FQDN
+	})
+	return result
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"3gpp-scanner/internal/dns"
+)
+
+// plmnLike matches entries meant as PLMNs, to refuse malformed ones
+// instead of taking them for operator names
+var plmnLike = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// Watchlist names the networks whose FQDNs are monitored
+type Watchlist struct {
+	PLMNs     []dns.PLMN
+	Operators []string // Operator names as stored in the database
+}
+
+// LoadWatchlist reads a watchlist file; see ParseWatchlist for the format
+func LoadWatchlist(path string) (*Watchlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watchlist: %w", err)
+	}
+	defer f.Close()
+
+	list, err := ParseWatchlist(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// ParseWatchlist reads one entry per line: a PLMN as dns.ParsePLMN takes
+// it (262-01 or 26201) or an operator name (Telekom Deutschland). Blank lines and # comments are
+// ignored.
+func ParseWatchlist(r io.Reader) (*Watchlist, error) {
+	list := &Watchlist{}
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		entry := strings.TrimSpace(line)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		if plmn, err := dns.ParsePLMN(entry); err == nil {
+			list.PLMNs = append(list.PLMNs, plmn)
+		} else if plmnLike.MatchString(entry) {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		} else {
+			list.Operators = append(list.Operators, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(list.PLMNs) == 0 && len(list.Operators) == 0 {
+		return nil, fmt.Errorf("watchlist is empty")
+	}
+	return list, nil
+}
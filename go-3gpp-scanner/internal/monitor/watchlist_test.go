@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/dns"
+)
+
+func TestParseWatchlist(t *testing.T) {
+	list, err := ParseWatchlist(strings.NewReader(`# Our networks
+262-01
+262-001   # a different network than 262-01
+Telekom Deutschland
+262-01
+`))
+	if err != nil {
+		t.Fatalf("ParseWatchlist: %v", err)
+	}
+	if len(list.PLMNs) != 2 || list.PLMNs[0] != (dns.PLMN{MCC: "262", MNC: "01"}) || list.PLMNs[1] != (dns.PLMN{MCC: "262", MNC: "001"}) {
+		t.Errorf("Unexpected PLMNs: %+v", list.PLMNs)
+	}
+	if len(list.Operators) != 1 || list.Operators[0] != "Telekom Deutschland" {
+		t.Errorf("Unexpected operators: %q", list.Operators)
+	}
+
+	if _, err := ParseWatchlist(strings.NewReader("# nothing\n\n")); err == nil {
+		t.Error("Expected error for an empty watchlist")
+	}
+	if list, err := ParseWatchlist(strings.NewReader("310260\n")); err != nil || list.PLMNs[0] != (dns.PLMN{MCC: "310", MNC: "260"}) {
+		t.Errorf("Expected a PLMN without separator, got %+v, %v", list, err)
+	}
+	if _, err := ParseWatchlist(strings.NewReader("262-1\n")); err == nil || !strings.Contains(err.Error(), "line 1: invalid PLMN") {
+		t.Errorf("Expected error for a malformed PLMN, got %v", err)
+	}
+}
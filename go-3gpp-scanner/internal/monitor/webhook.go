@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// webhookPayload is posted to webhooks. Slack and Mattermost incoming
+// webhooks show text; other receivers can read the alerts.
+type webhookPayload struct {
+	Text   string  `json:"text"`
+	Alerts []Alert `json:"alerts"`
+}
+
+// PostWebhook sends alerts as one JSON document to a webhook URL
+func PostWebhook(ctx context.Context, client *http.Client, url, userAgent string, alerts []Alert) error {
+	lines := make([]string, len(alerts))
+	for i, alert := range alerts {
+		lines[i] = alert.String()
+	}
+	body, err := json.Marshal(webhookPayload{Text: strings.Join(lines, "\n"), Alerts: alerts})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
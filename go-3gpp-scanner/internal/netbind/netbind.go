@@ -0,0 +1,200 @@
+package netbind
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"3gpp-scanner/internal/exclude"
+)
+
+// Binding selects the local egress address used by outgoing probes.
+// At most one of SourceIP or Interface should be set; the zero value
+// lets the operating system pick the route.
+type Binding struct {
+	SourceIP  string
+	Interface string
+
+	// Exclude refuses connections to listed addresses. Dialers check each
+	// resolved address before connecting, so hostnames are covered too.
+	Exclude exclude.Checker
+}
+
+// IsZero reports whether no binding was requested
+func (b Binding) IsZero() bool {
+	return b.SourceIP == "" && b.Interface == ""
+}
+
+// Validate checks that the binding is well-formed and usable on this host
+func (b Binding) Validate() error {
+	if b.SourceIP != "" && b.Interface != "" {
+		return fmt.Errorf("--source-ip and --interface are mutually exclusive")
+	}
+	if b.SourceIP != "" && net.ParseIP(b.SourceIP) == nil {
+		return fmt.Errorf("invalid source IP: %s", b.SourceIP)
+	}
+	if b.Interface != "" {
+		if _, err := interfaceAddrs(b.Interface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LocalIP returns the local address to bind for a destination of the
+// given address family. It returns nil when no binding is configured.
+func (b Binding) LocalIP(ipv6 bool) (net.IP, error) {
+	if b.SourceIP != "" {
+		ip := net.ParseIP(b.SourceIP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source IP: %s", b.SourceIP)
+		}
+		if (ip.To4() == nil) != ipv6 {
+			return nil, fmt.Errorf("source IP %s does not match destination address family", b.SourceIP)
+		}
+		return ip, nil
+	}
+
+	if b.Interface == "" {
+		return nil, nil
+	}
+
+	addrs, err := interfaceAddrs(b.Interface)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range addrs {
+		if (ip.To4() == nil) == ipv6 {
+			return ip, nil
+		}
+	}
+
+	family := "IPv4"
+	if ipv6 {
+		family = "IPv6"
+	}
+	return nil, fmt.Errorf("interface %s has no usable %s address", b.Interface, family)
+}
+
+// PreferredIP returns the bound local address, preferring IPv4 when the
+// destination family is not yet known (e.g. dialing a hostname).
+func (b Binding) PreferredIP() (net.IP, error) {
+	if b.IsZero() {
+		return nil, nil
+	}
+	if ip, err := b.LocalIP(false); err == nil {
+		return ip, nil
+	}
+	return b.LocalIP(true)
+}
+
+// TCPDialer returns a dialer bound to the preferred local address. Go
+// filters resolved destinations to the family of LocalAddr, so hostnames
+// dial correctly as long as they have a record of that family.
+func (b Binding) TCPDialer(timeout time.Duration) (*net.Dialer, error) {
+	dialer := &net.Dialer{Timeout: timeout, Control: b.control}
+	ip, err := b.PreferredIP()
+	if err != nil {
+		return nil, err
+	}
+	if ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return dialer, nil
+}
+
+// UDPDialer returns a dialer bound to the local address matching the
+// family of the destination address.
+func (b Binding) UDPDialer(timeout time.Duration, ipv6 bool) (*net.Dialer, error) {
+	dialer := &net.Dialer{Timeout: timeout, Control: b.control}
+	ip, err := b.LocalIP(ipv6)
+	if err != nil {
+		return nil, err
+	}
+	if ip != nil {
+		dialer.LocalAddr = &net.UDPAddr{IP: ip}
+	}
+	return dialer, nil
+}
+
+// SourceFor returns the local address traffic to dst leaves from: the
+// bound address when a binding is configured, otherwise the address the
+// kernel routes from. Connecting a UDP socket sends no packets.
+func (b Binding) SourceFor(dst net.IP) (net.IP, error) {
+	ip, err := b.LocalIP(dst.To4() == nil)
+	if err != nil || ip != nil {
+		return ip, err
+	}
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: dst, Port: 9})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// CheckName vets a destination name against Exclude
+func (b Binding) CheckName(fqdn string) error {
+	if b.Exclude == nil {
+		return nil
+	}
+	return b.Exclude.CheckName(fqdn)
+}
+
+// CheckIP vets a destination address against Exclude
+func (b Binding) CheckIP(ip net.IP) error {
+	if b.Exclude == nil {
+		return nil
+	}
+	return b.Exclude.CheckIP(ip)
+}
+
+// control runs before each connect and refuses excluded destinations
+func (b Binding) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	return b.CheckIP(net.ParseIP(host))
+}
+
+// interfaceAddrs lists the unicast, non link-local addresses of an interface
+func interfaceAddrs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown interface %s: %w", name, err)
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("interface %s is down", name)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses for %s: %w", name, err)
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP)
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("interface %s has no usable addresses", name)
+	}
+	return ips, nil
+}
+
+// IsIPv6Address reports whether a host:port or bare address is IPv6
+func IsIPv6Address(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,132 @@
+package netbind
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/exclude"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		binding     Binding
+		expectError bool
+	}{
+		{"zero binding", Binding{}, false},
+		{"valid IPv4 source", Binding{SourceIP: "192.0.2.10"}, false},
+		{"valid IPv6 source", Binding{SourceIP: "2001:db8::10"}, false},
+		{"invalid source", Binding{SourceIP: "not-an-ip"}, true},
+		{"both set", Binding{SourceIP: "192.0.2.10", Interface: "eth0"}, true},
+		{"unknown interface", Binding{Interface: "does-not-exist0"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.binding.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalIPFamilyMismatch(t *testing.T) {
+	b := Binding{SourceIP: "192.0.2.10"}
+
+	ip, err := b.LocalIP(false)
+	if err != nil {
+		t.Fatalf("LocalIP(false) failed: %v", err)
+	}
+	if !ip.Equal(net.ParseIP("192.0.2.10")) {
+		t.Errorf("Expected 192.0.2.10, got %s", ip)
+	}
+
+	if _, err := b.LocalIP(true); err == nil {
+		t.Errorf("Expected error for IPv6 destination with IPv4 source")
+	}
+}
+
+func TestSourceFor(t *testing.T) {
+	loopback := net.ParseIP("127.0.0.1")
+
+	ip, err := Binding{}.SourceFor(loopback)
+	if err != nil {
+		t.Fatalf("SourceFor failed: %v", err)
+	}
+	if !ip.IsLoopback() {
+		t.Errorf("Expected a loopback source for 127.0.0.1, got %s", ip)
+	}
+
+	ip, err = Binding{SourceIP: "192.0.2.10"}.SourceFor(loopback)
+	if err != nil || !ip.Equal(net.ParseIP("192.0.2.10")) {
+		t.Errorf("Expected the bound source 192.0.2.10, got %s (%v)", ip, err)
+	}
+}
+
+func TestTCPDialerZeroBinding(t *testing.T) {
+	dialer, err := Binding{}.TCPDialer(time.Second)
+	if err != nil {
+		t.Fatalf("TCPDialer failed: %v", err)
+	}
+	if dialer.LocalAddr != nil {
+		t.Errorf("Expected no local address, got %v", dialer.LocalAddr)
+	}
+	if dialer.Timeout != time.Second {
+		t.Errorf("Expected timeout 1s, got %v", dialer.Timeout)
+	}
+}
+
+func TestDialerExclude(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	list, err := exclude.Parse(strings.NewReader("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	dialer, err := Binding{Exclude: list}.TCPDialer(time.Second)
+	if err != nil {
+		t.Fatalf("TCPDialer: %v", err)
+	}
+	if _, err := dialer.Dial("tcp", ln.Addr().String()); !errors.Is(err, exclude.ErrExcluded) {
+		t.Errorf("Expected ErrExcluded, got %v", err)
+	}
+
+	dialer, err = Binding{}.TCPDialer(time.Second)
+	if err != nil {
+		t.Fatalf("TCPDialer: %v", err)
+	}
+	conn, err := dialer.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial without exclusions: %v", err)
+	}
+	conn.Close()
+}
+
+func TestIsIPv6Address(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected bool
+	}{
+		{"8.8.8.8:53", false},
+		{"[2001:4860:4860::8888]:53", true},
+		{"2001:db8::1", true},
+		{"example.org:53", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsIPv6Address(tt.address); got != tt.expected {
+			t.Errorf("IsIPv6Address(%s) = %v, expected %v", tt.address, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Follow-up scanner formats, see ExportFollowUp
+const (
+	FollowUpZGrab2 = "zgrab2"
+	FollowUpNuclei = "nuclei"
+)
+
+// FollowUpFormats lists the follow-up scanner formats
+var FollowUpFormats = []string{FollowUpZGrab2, FollowUpNuclei}
+
+// Service is a TCP service to scan on an endpoint
+type Service struct {
+	Name string // Tag of the service, e.g. https
+	Port int
+}
+
+// Services of each kind of 3GPP endpoint. ePDGs take IKE and ESP over TCP
+// (RFC 8229) on 4500 next to HTTPS, as ping checks by default; IMS hosts
+// SIP; everything else (xcap, ut, bsf, aes, rcs, ...) serves HTTPS.
+var (
+	epdgServices    = []Service{{"https", 443}, {"ike-tcp", 4500}}
+	sipServices     = []Service{{"sip", 5060}, {"sips", 5061}}
+	defaultServices = []Service{{"https", 443}}
+)
+
+// ServicesFor returns the services to scan on an FQDN, by the labels of
+// its subdomain: sos.epdg.epc is an ePDG, pcscf.ims and sos.ims are IMS
+// hosts, while xcap.ims serves HTTPS
+func ServicesFor(fqdn string) []Service {
+	labels := strings.Split(fqdn, ".")
+	for _, label := range labels {
+		if label == "epdg" || label == "n3iwf" || label == "vowifi" {
+			return epdgServices
+		}
+	}
+	switch labels[0] {
+	case "ims", "pcscf", "sos":
+		return sipServices
+	}
+	return defaultServices
+}
+
+// FollowUpTarget is one endpoint and port for a follow-up scanner
+type FollowUpTarget struct {
+	Host    string
+	IP      string
+	Port    int
+	Service string
+}
+
+// FollowUpTargets expands FQDN details into a target per address and
+// service. Non-empty ports replace the services of every FQDN.
+func FollowUpTargets(details []models.FQDNDetail, ports []int) []FollowUpTarget {
+	var targets []FollowUpTarget
+	seen := make(map[FollowUpTarget]bool)
+	for _, d := range details {
+		services := ServicesFor(d.FQDN)
+		if len(ports) > 0 {
+			services = make([]Service, len(ports))
+			for i, port := range ports {
+				services[i] = Service{Name: "tcp" + strconv.Itoa(port), Port: port}
+			}
+		}
+		for _, ip := range d.IPs {
+			for _, service := range services {
+				target := FollowUpTarget{Host: d.FQDN, IP: ip, Port: service.Port, Service: service.Name}
+				if !seen[target] {
+					seen[target] = true
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// ExportFollowUp writes targets as input of a follow-up scanner:
+//
+//   - zgrab2: CSV lines "ip,domain,tag,port", the input format of ZGrab2
+//     (the tag names the service, for --trigger in multiple-module runs)
+//   - nuclei: one target per line for -list: https://host for HTTPS on
+//     443, host:port otherwise, each host and port once
+func ExportFollowUp(targets []FollowUpTarget, format, filePath string) error {
+	if format != FollowUpZGrab2 && format != FollowUpNuclei {
+		return fmt.Errorf("unknown follow-up format: %s (must be %s)", format, strings.Join(FollowUpFormats, " or "))
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	written := make(map[string]bool)
+	for _, t := range targets {
+		var line string
+		switch format {
+		case FollowUpZGrab2:
+			line = fmt.Sprintf("%s,%s,%s,%d", t.IP, t.Host, t.Service, t.Port)
+		case FollowUpNuclei:
+			line = t.Host + ":" + strconv.Itoa(t.Port)
+			if t.Service == "https" && t.Port == 443 {
+				line = "https://" + t.Host
+			}
+		}
+		if written[line] {
+			continue
+		}
+		written[line] = true
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("failed to write target: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	return nil
+}
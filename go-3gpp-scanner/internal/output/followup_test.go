@@ -0,0 +1,83 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestServicesFor(t *testing.T) {
+	tests := []struct {
+		fqdn string
+		want int // First port
+	}{
+		{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", 443},
+		{"sos.epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", 443},
+		{"ims.mnc001.mcc262.pub.3gppnetwork.org", 5060},
+		{"pcscf.ims.mnc001.mcc262.pub.3gppnetwork.org", 5060},
+		{"xcap.ims.mnc001.mcc262.pub.3gppnetwork.org", 443},
+		{"bsf.mnc001.mcc262.pub.3gppnetwork.org", 443},
+	}
+	for _, tt := range tests {
+		if got := ServicesFor(tt.fqdn); got[0].Port != tt.want {
+			t.Errorf("ServicesFor(%s) = %v, want first port %d", tt.fqdn, got, tt.want)
+		}
+	}
+	if got := ServicesFor("sos.epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"); len(got) != 2 || got[1] != (Service{"ike-tcp", 4500}) {
+		t.Errorf("Expected HTTPS and IKE over TCP for an emergency ePDG, got %v", got)
+	}
+}
+
+func TestExportFollowUp(t *testing.T) {
+	details := []models.FQDNDetail{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.3"}},
+		{FQDN: "bsf.mnc001.mcc262.pub.3gppnetwork.org"}, // Unresolved: no targets
+	}
+	targets := FollowUpTargets(details, nil)
+	if len(targets) != 6 {
+		t.Fatalf("Expected 6 targets, got %d: %+v", len(targets), targets)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FollowUpZGrab2, "" +
+			"192.0.2.1,epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,https,443\n" +
+			"192.0.2.1,epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,ike-tcp,4500\n" +
+			"192.0.2.2,epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,https,443\n" +
+			"192.0.2.2,epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,ike-tcp,4500\n" +
+			"192.0.2.3,ims.mnc001.mcc262.pub.3gppnetwork.org,sip,5060\n" +
+			"192.0.2.3,ims.mnc001.mcc262.pub.3gppnetwork.org,sips,5061\n"},
+		{FollowUpNuclei, "" +
+			"https://epdg.epc.mnc001.mcc262.pub.3gppnetwork.org\n" +
+			"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org:4500\n" +
+			"ims.mnc001.mcc262.pub.3gppnetwork.org:5060\n" +
+			"ims.mnc001.mcc262.pub.3gppnetwork.org:5061\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			path := t.TempDir() + "/targets.txt"
+			if err := ExportFollowUp(targets, tt.format, path); err != nil {
+				t.Fatalf("ExportFollowUp: %v", err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read targets: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("Unexpected targets:\n%s\nwant\n%s", content, tt.want)
+			}
+		})
+	}
+
+	ports := FollowUpTargets(details[1:2], []int{8443})
+	if len(ports) != 1 || ports[0].Port != 8443 || ports[0].Service != "tcp8443" {
+		t.Errorf("Expected --ports to replace the services, got %+v", ports)
+	}
+	if err := ExportFollowUp(targets, "masscan", t.TempDir()+"/targets.txt"); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"3gpp-scanner/internal/models"
 )
@@ -39,7 +43,7 @@ func ExportResultsCSV(results []models.DNSResult, filePath string) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"FQDN", "IPs", "Subdomain", "MNC", "MCC", "Operator", "Timestamp"}
+	header := []string{"FQDN", "IPs", "Subdomain", "MNC", "MCC", "Operator", "Status", "Error", "Timestamp"}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
@@ -58,10 +62,12 @@ func ExportResultsCSV(results []models.DNSResult, filePath string) error {
 			result.FQDN,
 			ips,
 			result.Subdomain,
-			fmt.Sprintf("%d", result.MNC),
-			fmt.Sprintf("%d", result.MCC),
+			result.MNC,
+			result.MCC,
 			result.Operator,
-			result.Timestamp.Format("2006-01-02 15:04:05"),
+			string(result.Status),
+			result.Error,
+			result.Timestamp.Format(time.RFC3339),
 		}
 
 		if err := writer.Write(row); err != nil {
@@ -84,7 +90,8 @@ func ExportPingResultsCSV(results []models.PingResult, filePath string) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"FQDN", "Success", "Latency_ms", "IP", "Method", "Error", "Timestamp"}
+	header := []string{"FQDN", "Success", "Latency_ms", "IP", "Port", "Resolved_IPs", "Method", "Status", "TLS_Handshake_ms", "HTTP_Status", "Path_MTU",
+		"IPv4_Latency_ms", "IPv6_Latency_ms", "Faster_Family", "Error", "Timestamp"}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
@@ -96,16 +103,210 @@ func ExportPingResultsCSV(results []models.PingResult, filePath string) error {
 			latencyMs = fmt.Sprintf("%.2f", float64(result.Latency.Microseconds())/1000.0)
 		}
 
+		handshakeMs, httpStatus := "", ""
+		if result.TLSHandshake > 0 {
+			handshakeMs = fmt.Sprintf("%.2f", float64(result.TLSHandshake.Microseconds())/1000.0)
+		}
+		if result.HTTPStatus > 0 {
+			httpStatus = fmt.Sprintf("%d", result.HTTPStatus)
+		}
+
+		port, pathMTU := "", ""
+		if result.Port > 0 {
+			port = strconv.Itoa(result.Port)
+		}
+		if result.PathMTU > 0 {
+			pathMTU = fmt.Sprintf("%d", result.PathMTU)
+		}
+
+		var v4, v6, faster string
+		if ds := result.DualStack; ds != nil {
+			v4 = familyLatency(ds.IPv4)
+			v6 = familyLatency(ds.IPv6)
+			faster = ds.Faster
+		}
+
 		row := []string{
 			result.FQDN,
 			fmt.Sprintf("%t", result.Success),
 			latencyMs,
 			result.IP,
+			port,
+			strings.Join(result.ResolvedIPs, ";"),
 			result.Method,
+			string(result.Status),
+			handshakeMs,
+			httpStatus,
+			pathMTU,
+			v4,
+			v6,
+			faster,
 			result.Error,
-			result.Timestamp.Format("2006-01-02 15:04:05"),
+			result.Timestamp.Format(time.RFC3339),
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// familyLatency formats a dual-stack family probe for CSV: the latency in
+// milliseconds, the failure status, or empty when the family has no record
+func familyLatency(probe *models.FamilyProbe) string {
+	switch {
+	case probe == nil:
+		return ""
+	case probe.Success:
+		return fmt.Sprintf("%.2f", float64(probe.Latency.Microseconds())/1000.0)
+	default:
+		return string(probe.Status)
+	}
+}
+
+// ExportZoneInfoCSV exports zone apex metadata to CSV format
+func ExportZoneInfoCSV(zones []models.ZoneInfo, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Write header
+	header := []string{"Zone", "MNC", "MCC", "SOA_Serial", "Primary_NS", "Mailbox", "NS", "MX", "Error", "Timestamp"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	// Write data
+	for _, zone := range zones {
+		serial := ""
+		if zone.SOASerial > 0 {
+			serial = fmt.Sprintf("%d", zone.SOASerial)
+		}
+
+		row := []string{
+			zone.Zone,
+			zone.MNC,
+			zone.MCC,
+			serial,
+			zone.PrimaryNS,
+			zone.Mailbox,
+			strings.Join(zone.NS, ";"),
+			strings.Join(zone.MX, ";"),
+			zone.Error,
+			zone.Timestamp.Format(time.RFC3339),
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExportMCCMNCCSV exports MCC-MNC operator entries to CSV format
+func ExportMCCMNCCSV(entries []models.MCCMNCEntry, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Write header
+	header := []string{"Type", "CountryName", "CountryCode", "MCC", "MNC", "Brand", "Operator", "Status", "Bands", "Notes"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	// Write data
+	for _, entry := range entries {
+		row := []string{
+			entry.Type,
+			entry.CountryName,
+			entry.CountryCode,
+			entry.MCC,
+			entry.MNC,
+			entry.Brand,
+			entry.Operator,
+			entry.Status,
+			entry.Bands,
+			entry.Notes,
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
 		}
+	}
+
+	return nil
+}
+
+// ExportPrefixesCSV exports aggregated operator prefixes to CSV format
+func ExportPrefixesCSV(prefixes []models.OperatorPrefix, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Operator", "Prefix", "IPs", "FQDNs"}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, p := range prefixes {
+		row := []string{p.Operator, p.Prefix, strconv.Itoa(p.IPs), strconv.Itoa(p.FQDNs)}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	return nil
+}
 
+// ExportFQDNDetailsCSV exports query rows to CSV format. Addresses and
+// tags are joined with ";", and FQDNs no recorded run found have an empty
+// LastSeen.
+func ExportFQDNDetailsCSV(details []models.FQDNDetail, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"FQDN", "Operator", "Country", "CountryCode", "IPs", "LastSeen", "Vendor", "Tags"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, d := range details {
+		lastSeen := ""
+		if d.LastSeen != nil {
+			lastSeen = d.LastSeen.Format(time.RFC3339)
+		}
+		row := []string{
+			d.FQDN,
+			d.Operator,
+			d.Country,
+			d.CountryCode,
+			strings.Join(d.IPs, ";"),
+			lastSeen,
+			d.Vendor,
+			strings.Join(d.Tags, ";"),
+		}
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
 		}
@@ -148,9 +349,61 @@ func PrintPingResults(results []models.PingResult) {
 	for _, result := range results {
 		if result.Success {
 			latencyMs := float64(result.Latency.Microseconds()) / 1000.0
-			fmt.Printf("Pinging %s ... %s (%.2f ms)\n", result.FQDN, result.IP, latencyMs)
+			details := fmt.Sprintf("%.2f ms", latencyMs)
+			if result.TLSHandshake > 0 {
+				details += fmt.Sprintf(", TLS %.2f ms", float64(result.TLSHandshake.Microseconds())/1000.0)
+			}
+			if result.HTTPStatus > 0 {
+				details += fmt.Sprintf(", HTTP %d", result.HTTPStatus)
+			}
+			if result.PathMTU > 0 {
+				details += fmt.Sprintf(", path MTU %d", result.PathMTU)
+			}
+			addr := result.IP
+			if result.Port > 0 {
+				addr = net.JoinHostPort(result.IP, strconv.Itoa(result.Port))
+			}
+			fmt.Printf("Pinging %s ... %s (%s)\n", result.FQDN, addr, details)
 		} else if result.Error != "" {
-			fmt.Printf("Pinging %s ... FAILED: %s\n", result.FQDN, result.Error)
+			fmt.Printf("Pinging %s ... FAILED (%s): %s\n", result.FQDN, result.Status, result.Error)
+		}
+		if ds := result.DualStack; ds != nil {
+			fmt.Printf("  IPv4: %s, IPv6: %s", familySummary(ds.IPv4), familySummary(ds.IPv6))
+			if ds.Faster != "" {
+				fmt.Printf(" (faster: %s)", ds.Faster)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// familySummary describes a dual-stack family probe for display
+func familySummary(probe *models.FamilyProbe) string {
+	switch {
+	case probe == nil:
+		return "no record"
+	case probe.Success:
+		return fmt.Sprintf("%s %.2f ms", probe.IP, float64(probe.Latency.Microseconds())/1000.0)
+	default:
+		return fmt.Sprintf("%s %s", probe.IP, probe.Status)
+	}
+}
+
+// PrintZoneInfo prints zone apex metadata to stdout
+func PrintZoneInfo(zones []models.ZoneInfo) {
+	for _, zone := range zones {
+		fmt.Printf("%s\n", zone.Zone)
+		if zone.SOASerial > 0 {
+			fmt.Printf("  SOA: serial=%d primary=%s mailbox=%s\n", zone.SOASerial, zone.PrimaryNS, zone.Mailbox)
+		}
+		for _, ns := range zone.NS {
+			fmt.Printf("  NS: %s\n", ns)
+		}
+		for _, mx := range zone.MX {
+			fmt.Printf("  MX: %s\n", mx)
+		}
+		if zone.Error != "" {
+			fmt.Printf("  Error: %s\n", zone.Error)
 		}
 	}
 }
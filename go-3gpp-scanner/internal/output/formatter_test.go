@@ -17,8 +17,8 @@ func TestExportJSON(t *testing.T) {
 			FQDN:      "ims.mnc001.mcc310.pub.3gppnetwork.org",
 			IPs:       []string{"192.0.2.1"},
 			Subdomain: "ims",
-			MNC:       1,
-			MCC:       310,
+			MNC:       "01",
+			MCC:       "310",
 			Operator:  "Verizon",
 			Timestamp: time.Now(),
 		},
@@ -58,8 +58,8 @@ func TestExportResultsCSV(t *testing.T) {
 			FQDN:      "ims.mnc001.mcc310.pub.3gppnetwork.org",
 			IPs:       []string{"192.0.2.1", "192.0.2.2"},
 			Subdomain: "ims",
-			MNC:       1,
-			MCC:       310,
+			MNC:       "01",
+			MCC:       "310",
 			Operator:  "Verizon",
 			Timestamp: time.Now(),
 		},
@@ -129,6 +129,63 @@ func TestExportPingResultsCSV(t *testing.T) {
 	}
 }
 
+func TestExportGenericCSV(t *testing.T) {
+	tmpFile := t.TempDir() + "/results.csv"
+
+	results := []models.Result{
+		models.DNSResult{FQDN: "ims.mnc001.mcc310.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "192.0.2.2"}, MNC: "01", MCC: "310"}.Result(),
+		models.ProbeResult{FQDN: "ims.mnc001.mcc310.pub.3gppnetwork.org", Probe: "sip", IP: "192.0.2.1", Success: true,
+			Latency: 15 * time.Millisecond, Details: map[string]string{"server": "Kamailio"}}.Result(),
+	}
+
+	if err := ExportGenericCSV(results, tmpFile); err != nil {
+		t.Fatalf("ExportGenericCSV failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read CSV file: %v", err)
+	}
+
+	contentStr := string(content)
+	for _, want := range []string{
+		"Target,Type,IPs,Success,Status,Latency_ms,Metadata,Error,Timestamp",
+		"dns,192.0.2.1;192.0.2.2,true",
+		"mcc=310; mnc=01",
+		"sip,192.0.2.1,true,,15.00,server=Kamailio",
+	} {
+		if !contains(contentStr, want) {
+			t.Errorf("CSV does not contain %q:\n%s", want, contentStr)
+		}
+	}
+}
+
+func TestExportCSVTimestamps(t *testing.T) {
+	tmpFile := t.TempDir() + "/zones.csv"
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	zones := []models.ZoneInfo{
+		{Zone: "mnc001.mcc262.pub.3gppnetwork.org", MNC: "001", MCC: "262", Timestamp: at},
+		{Zone: "mnc002.mcc262.pub.3gppnetwork.org", MNC: "002", MCC: "262", Timestamp: at.UTC()},
+	}
+	if err := ExportZoneInfoCSV(zones, tmpFile); err != nil {
+		t.Fatalf("ExportZoneInfoCSV failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read CSV file: %v", err)
+	}
+
+	// RFC 3339 keeps the zone offset, so both rows parse to the same
+	// instant; MNC and MCC keep their leading zeros
+	for _, want := range []string{"2026-03-01T12:00:00+01:00", "2026-03-01T11:00:00Z", "pub.3gppnetwork.org,001,262,"} {
+		if !contains(string(content), want) {
+			t.Errorf("CSV does not contain %q:\n%s", want, content)
+		}
+	}
+}
+
 func TestExportFQDNList(t *testing.T) {
 	tmpFile := t.TempDir() + "/fqdns.txt"
 
@@ -156,6 +213,50 @@ func TestExportFQDNList(t *testing.T) {
 	}
 }
 
+func TestExportPrefixesCSV(t *testing.T) {
+	tmpFile := t.TempDir() + "/prefixes.csv"
+
+	prefixes := []models.OperatorPrefix{
+		{Operator: "Telekom, DE", Prefix: "192.0.2.0/23", IPs: 3, FQDNs: 2},
+	}
+	if err := ExportPrefixesCSV(prefixes, tmpFile); err != nil {
+		t.Fatalf("ExportPrefixesCSV failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if want := "Operator,Prefix,IPs,FQDNs\n\"Telekom, DE\",192.0.2.0/23,3,2\n"; string(content) != want {
+		t.Errorf("CSV = %q, want %q", content, want)
+	}
+}
+
+func TestExportFQDNDetailsCSV(t *testing.T) {
+	tmpFile := t.TempDir() + "/query.csv"
+
+	seen := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	details := []models.FQDNDetail{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", Country: "Germany", CountryCode: "DE",
+			IPs: []string{"192.0.2.1", "192.0.2.2"}, LastSeen: &seen, Tags: []string{"confirmed-live", "vip"}},
+		{FQDN: "ims.mnc005.mcc001.pub.3gppnetwork.org", Operator: "Test Network"},
+	}
+	if err := ExportFQDNDetailsCSV(details, tmpFile); err != nil {
+		t.Fatalf("ExportFQDNDetailsCSV failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := "FQDN,Operator,Country,CountryCode,IPs,LastSeen,Vendor,Tags\n" +
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org,Telekom,Germany,DE,192.0.2.1;192.0.2.2,2026-03-01T12:00:00Z,,confirmed-live;vip\n" +
+		"ims.mnc005.mcc001.pub.3gppnetwork.org,Test Network,,,,,,\n"
+	if string(content) != want {
+		t.Errorf("CSV = %q, want %q", content, want)
+	}
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	for i := 0; i < len(s)-len(substr)+1; i++ {
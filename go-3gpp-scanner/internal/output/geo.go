@@ -0,0 +1,98 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ExportGeoJSON exports located endpoints as a GeoJSON FeatureCollection
+func ExportGeoJSON(endpoints []models.GeoEndpoint, filePath string) error {
+	type geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string                 `json:"type"`
+		Geometry   geometry               `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+
+	features := make([]feature, 0, len(endpoints))
+	for _, e := range endpoints {
+		features = append(features, feature{
+			Type: "Feature",
+			// GeoJSON positions are longitude first
+			Geometry: geometry{Type: "Point", Coordinates: [2]float64{e.Longitude, e.Latitude}},
+			Properties: map[string]interface{}{
+				"ip":        e.IP,
+				"country":   e.Country,
+				"city":      e.City,
+				"fqdns":     e.FQDNs,
+				"operators": e.Operators,
+			},
+		})
+	}
+
+	return ExportJSON(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	}, filePath)
+}
+
+// ExportKML exports located endpoints as KML placemarks for Google Earth
+func ExportKML(endpoints []models.GeoEndpoint, filePath string) error {
+	type point struct {
+		Coordinates string `xml:"coordinates"`
+	}
+	type placemark struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+		Point       point  `xml:"Point"`
+	}
+	type document struct {
+		Name       string      `xml:"name"`
+		Placemarks []placemark `xml:"Placemark"`
+	}
+	type kml struct {
+		XMLName  xml.Name `xml:"http://www.opengis.net/kml/2.2 kml"`
+		Document document `xml:"Document"`
+	}
+
+	doc := kml{Document: document{Name: "3GPP Scanner endpoints"}}
+	for _, e := range endpoints {
+		var desc strings.Builder
+		if e.City != "" || e.Country != "" {
+			desc.WriteString(strings.Trim(e.City+", "+e.Country, ", ") + "\n")
+		}
+		if len(e.Operators) > 0 {
+			desc.WriteString("Operators: " + strings.Join(e.Operators, ", ") + "\n")
+		}
+		desc.WriteString(strings.Join(e.FQDNs, "\n"))
+
+		doc.Document.Placemarks = append(doc.Document.Placemarks, placemark{
+			Name:        e.IP,
+			Description: desc.String(),
+			Point:       point{Coordinates: fmt.Sprintf("%f,%f", e.Longitude, e.Latitude)},
+		})
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("failed to write KML: %w", err)
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode KML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+var testEndpoints = []models.GeoEndpoint{
+	{
+		IP:        "192.0.2.1",
+		Latitude:  52.52,
+		Longitude: 13.40,
+		Country:   "DE",
+		City:      "Berlin",
+		FQDNs:     []string{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"},
+		Operators: []string{"Telekom"},
+	},
+}
+
+func TestExportGeoJSON(t *testing.T) {
+	tmpFile := t.TempDir() + "/endpoints.geojson"
+
+	if err := ExportGeoJSON(testEndpoints, tmpFile); err != nil {
+		t.Fatalf("ExportGeoJSON failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read exported file: %v", err)
+	}
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(content, &collection); err != nil {
+		t.Fatalf("Failed to unmarshal GeoJSON: %v", err)
+	}
+
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 1 {
+		t.Fatalf("Unexpected collection: %s with %d features", collection.Type, len(collection.Features))
+	}
+	coords := collection.Features[0].Geometry.Coordinates
+	if len(coords) != 2 || coords[0] != 13.40 || coords[1] != 52.52 {
+		t.Errorf("Expected [lon, lat] = [13.40, 52.52], got %v", coords)
+	}
+	if collection.Features[0].Properties["ip"] != "192.0.2.1" {
+		t.Errorf("Expected ip property, got %v", collection.Features[0].Properties)
+	}
+}
+
+func TestExportKML(t *testing.T) {
+	tmpFile := t.TempDir() + "/endpoints.kml"
+
+	if err := ExportKML(testEndpoints, tmpFile); err != nil {
+		t.Fatalf("ExportKML failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read exported file: %v", err)
+	}
+
+	var doc struct {
+		Placemarks []struct {
+			Name        string `xml:"name"`
+			Coordinates string `xml:"Point>coordinates"`
+		} `xml:"Document>Placemark"`
+	}
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	if len(doc.Placemarks) != 1 || doc.Placemarks[0].Name != "192.0.2.1" {
+		t.Fatalf("Unexpected placemarks: %+v", doc.Placemarks)
+	}
+	if !strings.HasPrefix(doc.Placemarks[0].Coordinates, "13.4") {
+		t.Errorf("Expected longitude first in coordinates, got %s", doc.Placemarks[0].Coordinates)
+	}
+}
@@ -0,0 +1,130 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ExportProbeResultsCSV exports probe results to CSV format. Probe
+// details are flattened into one "key=value; key=value" column.
+func ExportProbeResultsCSV(results []models.ProbeResult, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"FQDN", "Probe", "IP", "Success", "Status", "Latency_ms", "Details", "Error", "Timestamp"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, result := range results {
+		latencyMs := ""
+		if result.Latency > 0 {
+			latencyMs = fmt.Sprintf("%.2f", float64(result.Latency.Microseconds())/1000.0)
+		}
+
+		row := []string{
+			result.FQDN,
+			result.Probe,
+			result.IP,
+			fmt.Sprintf("%t", result.Success),
+			string(result.Status),
+			latencyMs,
+			formatDetails(result.Details),
+			result.Error,
+			result.Timestamp.Format(time.RFC3339),
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PrintProbeResults prints probe results to stdout
+func PrintProbeResults(results []models.ProbeResult) {
+	for _, result := range results {
+		if result.Success {
+			details := formatDetails(result.Details)
+			if details != "" {
+				details = " " + details
+			}
+			fmt.Printf("[%s] %s ... %s (%.2f ms)%s\n", result.Probe, result.FQDN, result.IP,
+				float64(result.Latency.Microseconds())/1000.0, details)
+		} else {
+			fmt.Printf("[%s] %s ... FAILED (%s): %s\n", result.Probe, result.FQDN, result.Status, result.Error)
+		}
+	}
+}
+
+// formatDetails renders probe details sorted by key
+func formatDetails(details map[string]string) string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+details[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ExportGenericCSV exports results in the common envelope to CSV format.
+// Metadata is flattened into one "key=value; key=value" column, so every
+// result type shares the same columns.
+func ExportGenericCSV(results []models.Result, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"Target", "Type", "IPs", "Success", "Status", "Latency_ms", "Metadata", "Error", "Timestamp"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, result := range results {
+		latencyMs := ""
+		if result.Latency > 0 {
+			latencyMs = fmt.Sprintf("%.2f", float64(result.Latency.Microseconds())/1000.0)
+		}
+
+		row := []string{
+			result.Target,
+			result.Type,
+			strings.Join(result.IPs, ";"),
+			fmt.Sprintf("%t", result.Success),
+			string(result.Status),
+			latencyMs,
+			formatDetails(result.Metadata),
+			result.Error,
+			result.Timestamp.Format(time.RFC3339),
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	return nil
+}
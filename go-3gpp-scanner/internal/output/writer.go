@@ -0,0 +1,80 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Writer exports data in one format to a file
+type Writer func(data interface{}, filePath string) error
+
+var (
+	writersMu sync.Mutex
+	writers   = make(map[string]Writer)
+)
+
+func init() {
+	RegisterWriter("json", ExportJSON)
+	RegisterWriter("csv", exportCSV)
+}
+
+// RegisterWriter makes an export format available by name. It panics when
+// the name is taken, as registration happens in init functions.
+func RegisterWriter(format string, writer Writer) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if _, ok := writers[format]; ok {
+		panic(fmt.Sprintf("writer %s registered twice", format))
+	}
+	writers[format] = writer
+}
+
+// WriterFor returns the registered writer of an export format
+func WriterFor(format string) (Writer, error) {
+	writersMu.Lock()
+	writer, ok := writers[format]
+	writersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown export format: %s (available: %v)", format, Formats())
+	}
+	return writer, nil
+}
+
+// Formats lists the registered export formats in alphabetical order
+func Formats() []string {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	formats := make([]string, 0, len(writers))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// exportCSV writes data with the CSV exporter of its type
+func exportCSV(data interface{}, filePath string) error {
+	switch rows := data.(type) {
+	case []models.DNSResult:
+		return ExportResultsCSV(rows, filePath)
+	case []models.PingResult:
+		return ExportPingResultsCSV(rows, filePath)
+	case []models.ProbeResult:
+		return ExportProbeResultsCSV(rows, filePath)
+	case []models.Result:
+		return ExportGenericCSV(rows, filePath)
+	case []models.ZoneInfo:
+		return ExportZoneInfoCSV(rows, filePath)
+	case []models.MCCMNCEntry:
+		return ExportMCCMNCCSV(rows, filePath)
+	case []models.OperatorPrefix:
+		return ExportPrefixesCSV(rows, filePath)
+	case []models.FQDNDetail:
+		return ExportFQDNDetailsCSV(rows, filePath)
+	default:
+		return fmt.Errorf("no CSV export for %T", data)
+	}
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestWriters(t *testing.T) {
+	if got := Formats(); !reflect.DeepEqual(got, []string{"csv", "json"}) {
+		t.Errorf("Formats() = %v", got)
+	}
+
+	seen := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	details := []models.FQDNDetail{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", CountryCode: "DE", IPs: []string{"192.0.2.1"}, LastSeen: &seen},
+	}
+	dir := t.TempDir()
+
+	writeCSV, err := WriterFor("csv")
+	if err != nil {
+		t.Fatalf("WriterFor(csv): %v", err)
+	}
+	csvPath := filepath.Join(dir, "query.csv")
+	if err := writeCSV(details, csvPath); err != nil {
+		t.Fatalf("csv writer: %v", err)
+	}
+	content, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "FQDN,Operator,") || !contains(string(content), ",Telekom,,DE,192.0.2.1,2026-03-01T12:00:00Z,") {
+		t.Errorf("CSV = %q", content)
+	}
+
+	writeJSON, err := WriterFor("json")
+	if err != nil {
+		t.Fatalf("WriterFor(json): %v", err)
+	}
+	jsonPath := filepath.Join(dir, "query.json")
+	if err := writeJSON(details, jsonPath); err != nil {
+		t.Fatalf("json writer: %v", err)
+	}
+	content, err = os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []models.FQDNDetail
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("JSON export does not decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, details) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, details)
+	}
+
+	if err := writeCSV(map[string]int{"a": 1}, filepath.Join(dir, "map.csv")); err == nil || !contains(err.Error(), "no CSV export for map[string]int") {
+		t.Errorf("csv writer of unsupported type: %v", err)
+	}
+	if _, err := WriterFor("xml"); err == nil || !contains(err.Error(), "unknown export format: xml (available: [csv json])") {
+		t.Errorf("WriterFor(xml) = %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package pdns
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+)
+
+// DefaultDNSDBURL is the Farsight (DomainTools) DNSDB API
+const DefaultDNSDBURL = "https://api.dnsdb.info"
+
+// DNSDB queries the Farsight DNSDB API v2 for the A and AAAA RRsets of a
+// name
+type DNSDB struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+	Limit      int // Maximum RRsets per query, 0 for the server default
+}
+
+// NewDNSDB creates a DNSDB client
+func NewDNSDB(apiKey string) *DNSDB {
+	return &DNSDB{BaseURL: DefaultDNSDBURL, APIKey: apiKey, HTTPClient: newClient(), Limit: 1000}
+}
+
+// Name implements Provider
+func (d *DNSDB) Name() string {
+	return "dnsdb"
+}
+
+// History implements Provider. DNSDB answers in the streaming SAF format:
+// one JSON object per line, RRsets in "obj" and the end of the stream in
+// "cond".
+func (d *DNSDB) History(ctx context.Context, fqdn string) ([]models.IPResolution, error) {
+	endpoint := fmt.Sprintf("%s/dnsdb/v2/lookup/rrset/name/%s/ANY", d.BaseURL, url.PathEscape(dns.NormalizeFQDN(fqdn)))
+	if d.Limit > 0 {
+		endpoint += fmt.Sprintf("?limit=%d", d.Limit)
+	}
+	resp, err := get(ctx, d.HTTPClient, endpoint, http.Header{
+		"X-Api-Key": {d.APIKey},
+		"Accept":    {"application/x-ndjson"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dnsdb: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dnsdb: unexpected status code: %d", resp.StatusCode)
+	}
+
+	var resolutions []models.IPResolution
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Cond string `json:"cond"`
+			Msg  string `json:"msg"`
+			Obj  *struct {
+				RRName        string   `json:"rrname"`
+				RRType        string   `json:"rrtype"`
+				RData         []string `json:"rdata"`
+				Count         int      `json:"count"`
+				TimeFirst     int64    `json:"time_first"`
+				TimeLast      int64    `json:"time_last"`
+				ZoneTimeFirst int64    `json:"zone_time_first"`
+				ZoneTimeLast  int64    `json:"zone_time_last"`
+			} `json:"obj"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("dnsdb: failed to parse response: %w", err)
+		}
+		if line.Cond == "failed" {
+			return nil, fmt.Errorf("dnsdb: query failed: %s", line.Msg)
+		}
+		obj := line.Obj
+		if obj == nil || (obj.RRType != "A" && obj.RRType != "AAAA") {
+			continue
+		}
+		// Observations from zone files carry zone_time_* instead
+		first, last := obj.TimeFirst, obj.TimeLast
+		if first == 0 {
+			first, last = obj.ZoneTimeFirst, obj.ZoneTimeLast
+		}
+		for _, ip := range obj.RData {
+			resolutions = append(resolutions, models.IPResolution{
+				FQDN:      dns.NormalizeFQDN(obj.RRName),
+				IP:        ip,
+				FirstSeen: time.Unix(first, 0).UTC(),
+				LastSeen:  time.Unix(last, 0).UTC(),
+				Count:     obj.Count,
+				Source:    d.Name(),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("dnsdb: failed to read response: %w", err)
+	}
+	return resolutions, nil
+}
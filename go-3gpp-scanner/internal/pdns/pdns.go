@@ -0,0 +1,79 @@
+package pdns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+const userAgent = "3gpp-scanner (+https://github.com/asnd/sec100)"
+
+// Provider is a passive DNS service
+type Provider interface {
+	// Name identifies the provider in stored resolutions
+	Name() string
+	// History returns the addresses an FQDN resolved to in the past
+	History(ctx context.Context, fqdn string) ([]models.IPResolution, error)
+}
+
+// constructors create the providers by name; a new provider only needs
+// an entry here
+var constructors = map[string]func(apiKey string) Provider{
+	"dnsdb":      func(apiKey string) Provider { return NewDNSDB(apiKey) },
+	"virustotal": func(apiKey string) Provider { return NewVirusTotal(apiKey) },
+}
+
+// Providers lists the provider names New accepts
+var Providers = []string{"dnsdb", "virustotal"}
+
+// New creates the named provider with an API key
+func New(name, apiKey string) (Provider, error) {
+	constructor, ok := constructors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown passive DNS provider: %s (must be one of %s)", name, strings.Join(Providers, ", "))
+	}
+	return constructor(apiKey), nil
+}
+
+// newClient is the HTTP client of providers
+func newClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// get sends an authenticated GET request and returns the response, which
+// the caller must close
+func get(ctx context.Context, client *http.Client, endpoint string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// decodeJSON decodes a successful response
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
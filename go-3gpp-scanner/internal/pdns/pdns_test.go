@@ -0,0 +1,113 @@
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+const testFQDN = "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"
+
+func TestNew(t *testing.T) {
+	for _, name := range Providers {
+		provider, err := New(name, "key")
+		if err != nil || provider.Name() != name {
+			t.Errorf("New(%s) = %v, %v", name, provider, err)
+		}
+	}
+	if _, err := New("shodan", "key"); err == nil || !strings.Contains(err.Error(), "dnsdb, virustotal") {
+		t.Errorf("New of an unknown provider = %v", err)
+	}
+}
+
+func TestDNSDBHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "key" {
+			t.Errorf("X-API-Key = %q", r.Header.Get("X-API-Key"))
+		}
+		if r.URL.Path != "/dnsdb/v2/lookup/rrset/name/"+testFQDN+"/ANY" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"cond":"begin"}`)
+		fmt.Fprintln(w, `{"obj":{"count":12,"time_first":1600000000,"time_last":1700000000,"rrname":"`+testFQDN+`.","rrtype":"A","rdata":["192.0.2.1","192.0.2.2"]}}`)
+		fmt.Fprintln(w, `{"obj":{"count":3,"zone_time_first":1650000000,"zone_time_last":1650000100,"rrname":"`+testFQDN+`.","rrtype":"AAAA","rdata":["2001:db8::1"]}}`)
+		fmt.Fprintln(w, `{"obj":{"count":1,"time_first":1600000000,"time_last":1600000000,"rrname":"`+testFQDN+`.","rrtype":"CNAME","rdata":["x.example."]}}`)
+		fmt.Fprintln(w, `{"cond":"succeeded"}`)
+	}))
+	defer server.Close()
+
+	source := &DNSDB{BaseURL: server.URL, APIKey: "key", HTTPClient: server.Client()}
+	history, err := source.History(context.Background(), strings.ToUpper(testFQDN))
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	want := []models.IPResolution{
+		{FQDN: testFQDN, IP: "192.0.2.1", FirstSeen: time.Unix(1600000000, 0).UTC(), LastSeen: time.Unix(1700000000, 0).UTC(), Count: 12, Source: "dnsdb"},
+		{FQDN: testFQDN, IP: "192.0.2.2", FirstSeen: time.Unix(1600000000, 0).UTC(), LastSeen: time.Unix(1700000000, 0).UTC(), Count: 12, Source: "dnsdb"},
+		{FQDN: testFQDN, IP: "2001:db8::1", FirstSeen: time.Unix(1650000000, 0).UTC(), LastSeen: time.Unix(1650000100, 0).UTC(), Count: 3, Source: "dnsdb"},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("History = %+v, want %+v", history, want)
+	}
+}
+
+func TestDNSDBFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "denied") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintln(w, `{"cond":"begin"}`)
+		fmt.Fprintln(w, `{"cond":"failed","msg":"quota exceeded"}`)
+	}))
+	defer server.Close()
+
+	source := &DNSDB{BaseURL: server.URL, HTTPClient: server.Client()}
+	if _, err := source.History(context.Background(), testFQDN); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("History with a failed stream = %v", err)
+	}
+	if _, err := source.History(context.Background(), "denied.example"); err == nil {
+		t.Errorf("History should fail on HTTP 403")
+	}
+}
+
+func TestVirusTotalHistory(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-apikey") != "key" {
+			t.Errorf("x-apikey = %q", r.Header.Get("x-apikey"))
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprintf(w, `{"data":[{"attributes":{"date":1700000000,"host_name":"%s","ip_address":"192.0.2.1"}}],
+				"links":{"next":"%s/domains/%s/resolutions?limit=40&cursor=abc"}}`, testFQDN, server.URL, testFQDN)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"attributes":{"date":1600000000,"ip_address":"192.0.2.9"}}],"links":{}}`)
+	}))
+	defer server.Close()
+
+	source := &VirusTotal{BaseURL: server.URL, APIKey: "key", HTTPClient: server.Client()}
+	history, err := source.History(context.Background(), testFQDN)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	want := []models.IPResolution{
+		{FQDN: testFQDN, IP: "192.0.2.1", FirstSeen: time.Unix(1700000000, 0).UTC(), LastSeen: time.Unix(1700000000, 0).UTC(), Source: "virustotal"},
+		{FQDN: testFQDN, IP: "192.0.2.9", FirstSeen: time.Unix(1600000000, 0).UTC(), LastSeen: time.Unix(1600000000, 0).UTC(), Source: "virustotal"},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("History = %+v, want %+v", history, want)
+	}
+
+	source.MaxPages = 1
+	if history, err := source.History(context.Background(), testFQDN); err != nil || len(history) != 1 {
+		t.Errorf("History with one page = %+v, %v", history, err)
+	}
+}
@@ -0,0 +1,83 @@
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+)
+
+// DefaultVirusTotalURL is the VirusTotal API v3
+const DefaultVirusTotalURL = "https://www.virustotal.com/api/v3"
+
+// VirusTotal queries the resolutions relationship of a domain in the
+// VirusTotal API. The public API allows four requests per minute.
+type VirusTotal struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+	MaxPages   int // Pages of 40 resolutions to fetch at most, 0 for no limit
+}
+
+// NewVirusTotal creates a VirusTotal client
+func NewVirusTotal(apiKey string) *VirusTotal {
+	return &VirusTotal{BaseURL: DefaultVirusTotalURL, APIKey: apiKey, HTTPClient: newClient(), MaxPages: 5}
+}
+
+// Name implements Provider
+func (v *VirusTotal) Name() string {
+	return "virustotal"
+}
+
+// History implements Provider. VirusTotal records the last date it saw
+// each address, which becomes both first and last seen.
+func (v *VirusTotal) History(ctx context.Context, fqdn string) ([]models.IPResolution, error) {
+	fqdn = dns.NormalizeFQDN(fqdn)
+	endpoint := fmt.Sprintf("%s/domains/%s/resolutions?limit=40", v.BaseURL, url.PathEscape(fqdn))
+
+	var resolutions []models.IPResolution
+	for page := 0; endpoint != "" && (v.MaxPages == 0 || page < v.MaxPages); page++ {
+		resp, err := get(ctx, v.HTTPClient, endpoint, http.Header{"X-Apikey": {v.APIKey}})
+		if err != nil {
+			return nil, fmt.Errorf("virustotal: %w", err)
+		}
+		var body struct {
+			Data []struct {
+				Attributes struct {
+					Date      int64  `json:"date"`
+					HostName  string `json:"host_name"`
+					IPAddress string `json:"ip_address"`
+				} `json:"attributes"`
+			} `json:"data"`
+			Links struct {
+				Next string `json:"next"`
+			} `json:"links"`
+		}
+		err = decodeJSON(resp, &body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("virustotal: %w", err)
+		}
+
+		for _, r := range body.Data {
+			seen := time.Unix(r.Attributes.Date, 0).UTC()
+			name := dns.NormalizeFQDN(r.Attributes.HostName)
+			if name == "" {
+				name = fqdn
+			}
+			resolutions = append(resolutions, models.IPResolution{
+				FQDN:      name,
+				IP:        r.Attributes.IPAddress,
+				FirstSeen: seen,
+				LastSeen:  seen,
+				Source:    v.Name(),
+			})
+		}
+		endpoint = body.Links.Next
+	}
+	return resolutions, nil
+}
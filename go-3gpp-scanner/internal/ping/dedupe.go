@@ -0,0 +1,130 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// addrProbe is the shared outcome of probing one address
+type addrProbe struct {
+	once   sync.Once
+	result models.PingResult
+}
+
+// dedupe holds the addresses of every FQDN, resolved before probing, so
+// FQDNs sharing an address are answered by a single probe of it
+type dedupe struct {
+	resolved map[string]models.PingResult // ResolvedIPs set, or the lookup failure
+	probes   map[string]*addrProbe        // One per distinct address, read-only once built
+}
+
+// resolveAll looks up every FQDN with the configured number of workers.
+// Excluded names are not resolved; the worker reports them.
+func (p *Pinger) resolveAll(ctx context.Context, fqdns []string) *dedupe {
+	d := &dedupe{
+		resolved: make(map[string]models.PingResult, len(fqdns)),
+		probes:   make(map[string]*addrProbe),
+	}
+
+	jobs := make(chan string, len(fqdns))
+	for _, fqdn := range fqdns {
+		jobs <- fqdn
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fqdn := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				if p.binding.CheckName(fqdn) != nil {
+					continue
+				}
+				result := models.PingResult{FQDN: fqdn, Method: p.config.Method}
+				resolve(&result)
+
+				mu.Lock()
+				d.resolved[fqdn] = result
+				for _, ip := range result.ResolvedIPs {
+					if d.probes[ip] == nil {
+						d.probes[ip] = &addrProbe{}
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return d
+}
+
+// pingDeduped answers an FQDN from the probes of its addresses, probing
+// each address at most once per run. ICMP uses the first address, as
+// PingOne does; TCP moves on to the next address until one accepts.
+func (p *Pinger) pingDeduped(d *dedupe, fqdn string) models.PingResult {
+	result, ok := d.resolved[fqdn]
+	if !ok {
+		// Not resolved in the pre-pass, e.g. cancelled
+		result = p.PingOne(fqdn)
+		p.record(fqdn, p.config.Method, result.IP, result.Status, result.Error)
+		return result
+	}
+	result.Timestamp = time.Now()
+	if result.Error != "" {
+		p.record(fqdn, p.config.Method, "", result.Status, result.Error)
+		return result
+	}
+
+	candidates := result.ResolvedIPs
+	if p.config.Method == "icmp" {
+		candidates = candidates[:1]
+	}
+	var answer models.PingResult
+	for i, ip := range candidates {
+		probe := d.probes[ip]
+		probe.once.Do(func() {
+			probe.result = p.probeAddr(fqdn, net.ParseIP(ip))
+			p.addrProbes.Add(1)
+		})
+		if i == 0 || probe.result.Success {
+			answer = probe.result
+		}
+		if probe.result.Success {
+			break
+		}
+	}
+
+	result.Success = answer.Success
+	result.Latency = answer.Latency
+	result.IP = answer.IP
+	result.Port = answer.Port
+	result.Status = answer.Status
+	result.Error = answer.Error
+	return result
+}
+
+// probeAddr probes one address with the configured method; fqdn only
+// labels the audit record
+func (p *Pinger) probeAddr(fqdn string, ip net.IP) models.PingResult {
+	result := models.PingResult{FQDN: fqdn, Method: p.config.Method}
+	if p.config.Method == "icmp" {
+		p.echoTo(&result, ip)
+	} else if dialer, err := p.binding.TCPDialer(p.config.Timeout); err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+	} else {
+		p.connectPorts(&result, dialer, []net.IP{ip})
+	}
+	p.record(fqdn, p.config.Method, result.IP, result.Status, result.Error)
+	return result
+}
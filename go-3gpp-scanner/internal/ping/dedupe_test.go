@@ -0,0 +1,76 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/models"
+)
+
+func TestPingDedupeByIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path, "ping")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pinger := NewPinger(&models.PingConfig{
+		Method:     "tcp",
+		Timeout:    time.Second,
+		Workers:    4,
+		TCPPorts:   []int{port},
+		DedupeByIP: true,
+	})
+	pinger.SetAudit(log)
+
+	fqdns := []string{"127.0.0.1", "127.0.0.1", "127.0.0.1", "127.0.0.1"}
+	results, err := pinger.Ping(context.Background(), fqdns)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(results) != len(fqdns) {
+		t.Fatalf("Expected a result for each of %d FQDNs, got %d", len(fqdns), len(results))
+	}
+	for _, r := range results {
+		if !r.Success || r.IP != "127.0.0.1" || r.Port != port || len(r.ResolvedIPs) != 1 || r.Timestamp.IsZero() {
+			t.Errorf("Expected shared success on 127.0.0.1 port %d, got %+v", port, r)
+		}
+	}
+	if got := pinger.AddressesProbed(); got != 1 {
+		t.Errorf("Expected one address probed, got %d", got)
+	}
+
+	// Only the probe actually sent is audited
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+		t.Errorf("Expected one audit entry, got %d:\n%s", lines, data)
+	}
+}
@@ -0,0 +1,92 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// probeDualStack resolves the A and AAAA records of fqdn and probes one
+// address of each family, reporting which answers faster
+func (p *Pinger) probeDualStack(ctx context.Context, fqdn string) *models.DualStack {
+	ds := &models.DualStack{
+		IPv4: p.probeFamily(ctx, fqdn, "ip4"),
+		IPv6: p.probeFamily(ctx, fqdn, "ip6"),
+	}
+	if ds.IPv4 == nil && ds.IPv6 == nil {
+		return nil
+	}
+
+	if ds.IPv4 != nil && ds.IPv6 != nil && ds.IPv4.Success && ds.IPv6.Success {
+		if ds.IPv6.Latency < ds.IPv4.Latency {
+			ds.Faster = "ipv6"
+		} else {
+			ds.Faster = "ipv4"
+		}
+	}
+	return ds
+}
+
+// probeFamily probes the first address of one family ("ip4" or "ip6"),
+// returning nil when the name has no record of that family
+func (p *Pinger) probeFamily(ctx context.Context, fqdn, network string) *models.FamilyProbe {
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	ips, err := net.DefaultResolver.LookupIP(lookupCtx, network, fqdn)
+	if err != nil || len(ips) == 0 {
+		return nil
+	}
+
+	ip := ips[0]
+	probe := &models.FamilyProbe{IP: ip.String()}
+
+	var latency time.Duration
+	var status models.ResultStatus
+	if p.config.Method == "icmp" {
+		latency, status, _ = p.echoICMP(ip)
+	} else {
+		latency, status = p.connectTCP(ip)
+	}
+
+	probe.Status = status
+	if status == models.StatusOK {
+		probe.Success = true
+		probe.Latency = latency
+	}
+	return probe
+}
+
+// connectTCP tries each configured port on ip and returns the latency of
+// the first successful connect
+func (p *Pinger) connectTCP(ip net.IP) (time.Duration, models.ResultStatus) {
+	if p.binding.CheckIP(ip) != nil {
+		return 0, models.StatusExcluded
+	}
+	dialer := &net.Dialer{Timeout: p.config.Timeout}
+	localIP, err := p.binding.LocalIP(ip.To4() == nil)
+	if err != nil {
+		return 0, models.StatusNetworkError
+	}
+	if localIP != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: localIP}
+	}
+
+	status := models.StatusNetworkError
+	for _, port := range p.config.TCPPorts {
+		start := time.Now()
+		conn, err := dialer.Dial("tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err == nil {
+			latency := time.Since(start)
+			conn.Close()
+			return latency, models.StatusOK
+		}
+		if s := ConnStatus(err); s != models.StatusNetworkError {
+			status = s
+		}
+	}
+	return 0, status
+}
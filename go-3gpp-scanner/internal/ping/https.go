@@ -0,0 +1,104 @@
+package ping
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ClientTLS starts a TLS client on conn for serverName. Probes map
+// infrastructure rather than trust it, so any certificate is accepted;
+// callers record it or check its validity themselves.
+func ClientTLS(conn net.Conn, serverName string) *tls.Conn {
+	return tls.Client(conn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+}
+
+// pingHTTPS connects to the HTTPS port, completes a TLS handshake and sends
+// a HEAD request. A TCP connect alone often misrepresents availability:
+// load balancers accept connections for services that never answer.
+// Latency is the TCP connect time; handshake time and HTTP status are
+// recorded separately. Success means the TLS handshake completed.
+func (p *Pinger) pingHTTPS(fqdn string) models.PingResult {
+	result := models.PingResult{
+		FQDN:      fqdn,
+		Method:    "https",
+		Timestamp: time.Now(),
+	}
+
+	dialer, err := p.binding.TCPDialer(p.config.Timeout)
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+
+	ips, ok := resolve(&result)
+	if !ok {
+		return result
+	}
+
+	start := time.Now()
+	conn, err := p.dialFirst(dialer, ips, p.config.HTTPSPort)
+	if err != nil {
+		result.Status = ConnStatus(err)
+		result.Port = p.config.HTTPSPort
+		setDialed(&result, err)
+		result.Error = fmt.Sprintf("TCP connect failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+	result.Latency = time.Since(start)
+	setPeer(&result, conn.RemoteAddr())
+
+	tlsConn := ClientTLS(conn, fqdn)
+	tlsConn.SetDeadline(time.Now().Add(p.config.Timeout))
+
+	handshakeStart := time.Now()
+	if err := tlsConn.Handshake(); err != nil {
+		result.Status = ConnStatus(err)
+		result.Error = fmt.Sprintf("TLS handshake failed: %v", err)
+		return result
+	}
+	result.TLSHandshake = time.Since(handshakeStart)
+	result.Success = true
+	result.Status = models.StatusOK
+
+	// The leaf certificate hints at the vendor or hosting platform
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		result.CertIssuer = certs[0].Issuer.String()
+		result.CertSubject = certs[0].Subject.String()
+		result.CertSANs = certs[0].DNSNames
+		now := time.Now()
+		result.CertValid = certs[0].VerifyHostname(fqdn) == nil &&
+			now.After(certs[0].NotBefore) && now.Before(certs[0].NotAfter)
+	}
+
+	// The HTTP status is informative; many ePDG and IMS hosts do not speak HTTP
+	tlsConn.SetDeadline(time.Now().Add(p.config.Timeout))
+	req, err := http.NewRequest(http.MethodHead, "https://"+fqdn+"/", nil)
+	if err != nil {
+		return result
+	}
+	req.Header.Set("User-Agent", p.config.UserAgent)
+	req.Close = true
+	if err := req.Write(tlsConn); err != nil {
+		result.Error = fmt.Sprintf("HTTP request failed: %v", err)
+		return result
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(tlsConn), req)
+	if err != nil {
+		result.Error = fmt.Sprintf("HTTP response failed: %v", err)
+		return result
+	}
+	resp.Body.Close()
+	result.HTTPStatus = resp.StatusCode
+	result.HTTPServer = resp.Header.Get("Server")
+
+	return result
+}
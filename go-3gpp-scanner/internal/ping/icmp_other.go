@@ -0,0 +1,113 @@
+//go:build !windows
+
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ICMPAvailable reports whether raw ICMP sockets can be opened, as the
+// icmp method and MTU probing need. Without root or CAP_NET_RAW every
+// probe would fail the same way, so callers check once up front.
+func ICMPAvailable() error {
+	conn, err := icmp.ListenPacket("ip4:icmp", "")
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("raw ICMP sockets need root or CAP_NET_RAW: %w", err)
+		}
+		return fmt.Errorf("raw ICMP sockets unavailable: %w", err)
+	}
+	return conn.Close()
+}
+
+// echoICMP sends one ICMP echo to ip and waits for the reply
+func (p *Pinger) echoICMP(ip net.IP) (time.Duration, models.ResultStatus, error) {
+	if err := p.binding.CheckIP(ip); err != nil {
+		return 0, models.StatusExcluded, err
+	}
+
+	// Determine protocol
+	var network string
+	var proto int
+	if ip.To4() != nil {
+		network = "ip4:icmp"
+		proto = 1 // ICMPv4
+	} else {
+		network = "ip6:ipv6-icmp"
+		proto = 58 // ICMPv6
+	}
+
+	// Bind to the configured source address, if any
+	listenAddr := ""
+	localIP, err := p.binding.LocalIP(proto == 58)
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("source binding failed: %v", err)
+	}
+	if localIP != nil {
+		listenAddr = localIP.String()
+	}
+
+	// Create ICMP connection
+	conn, err := icmp.ListenPacket(network, listenAddr)
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("ICMP listen failed (need root?): %v", err)
+	}
+	defer conn.Close()
+
+	// Set timeout
+	conn.SetDeadline(time.Now().Add(p.config.Timeout))
+
+	// Create ICMP message
+	msg := &icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   1234,
+			Seq:  1,
+			Data: []byte("3gpp-scanner"),
+		},
+	}
+
+	if proto == 58 {
+		msg.Type = ipv6.ICMPTypeEchoRequest
+	}
+
+	msgBytes, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("ICMP marshal failed: %v", err)
+	}
+
+	// Send ping
+	start := time.Now()
+	_, err = conn.WriteTo(msgBytes, &net.IPAddr{IP: ip})
+	if err != nil {
+		return 0, ConnStatus(err), fmt.Errorf("ICMP send failed: %v", err)
+	}
+
+	// Receive reply
+	reply := make([]byte, 1500)
+	n, _, err := conn.ReadFrom(reply)
+	latency := time.Since(start)
+
+	if err != nil {
+		return 0, ConnStatus(err), fmt.Errorf("ICMP receive failed: %v", err)
+	}
+
+	// Parse reply
+	_, err = icmp.ParseMessage(proto, reply[:n])
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("ICMP parse failed: %v", err)
+	}
+
+	return latency, models.StatusOK, nil
+}
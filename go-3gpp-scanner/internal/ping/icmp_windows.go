@@ -0,0 +1,190 @@
+package ping
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"3gpp-scanner/internal/models"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows has no unprivileged raw ICMP sockets; echoes go through the
+// ICMP helper API of iphlpapi.dll instead, which needs no elevation
+var (
+	iphlpapi            = windows.NewLazySystemDLL("iphlpapi.dll")
+	procIcmpCreateFile  = iphlpapi.NewProc("IcmpCreateFile")
+	procIcmp6CreateFile = iphlpapi.NewProc("Icmp6CreateFile")
+	procIcmpCloseHandle = iphlpapi.NewProc("IcmpCloseHandle")
+	procIcmpSendEcho2Ex = iphlpapi.NewProc("IcmpSendEcho2Ex")
+	procIcmp6SendEcho2  = iphlpapi.NewProc("Icmp6SendEcho2")
+)
+
+// IP_STATUS values of the ICMP helper API
+const (
+	ipSuccess     = 0
+	ipReqTimedOut = 11010
+	ipStatusBase  = 11000
+	ipStatusLimit = 11050
+)
+
+// icmpReplyExtra is the room IcmpSendEcho2Ex and Icmp6SendEcho2 need in the
+// reply buffer beyond the reply and echoed data: 8 bytes for an ICMP error
+// message and an IO_STATUS_BLOCK, 16 bytes on 64-bit Windows
+const icmpReplyExtra = 8 + unsafe.Sizeof(windows.IO_STATUS_BLOCK{})
+
+// icmpEchoReply is ICMP_ECHO_REPLY, the IPv4 reply layout
+type icmpEchoReply struct {
+	Address       uint32
+	Status        uint32
+	RoundTripTime uint32
+	DataSize      uint16
+	Reserved      uint16
+	Data          uintptr
+	Options       struct {
+		TTL, TOS, Flags, OptionsSize uint8
+		OptionsData                  uintptr
+	}
+}
+
+// icmpv6EchoReplyStatus is the offset of Status in ICMPV6_ECHO_REPLY,
+// after the packed 26-byte IPV6_ADDRESS_EX
+const icmpv6EchoReplyStatus = 26
+
+// ICMPAvailable reports whether the ICMP helper API can be used, as the
+// icmp method needs. It works without elevation, unlike raw sockets.
+func ICMPAvailable() error {
+	handle, err := icmpCreateFile(false)
+	if err != nil {
+		return fmt.Errorf("ICMP helper API unavailable: %w", err)
+	}
+	icmpCloseHandle(handle)
+	return nil
+}
+
+// echoICMP sends one ICMP echo to ip and waits for the reply
+func (p *Pinger) echoICMP(ip net.IP) (time.Duration, models.ResultStatus, error) {
+	if err := p.binding.CheckIP(ip); err != nil {
+		return 0, models.StatusExcluded, err
+	}
+
+	ip4 := ip.To4()
+	localIP, err := p.binding.LocalIP(ip4 == nil)
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("source binding failed: %v", err)
+	}
+
+	handle, err := icmpCreateFile(ip4 == nil)
+	if err != nil {
+		return 0, models.StatusNetworkError, fmt.Errorf("ICMP handle failed: %v", err)
+	}
+	defer icmpCloseHandle(handle)
+
+	data := []byte("3gpp-scanner")
+	reply := make([]byte, unsafe.Sizeof(icmpEchoReply{})+uintptr(len(data))+icmpReplyExtra)
+	timeout := uint32(p.config.Timeout / time.Millisecond)
+	if timeout == 0 {
+		timeout = 1
+	}
+
+	var replies uintptr
+	var callErr error
+	start := time.Now()
+	if ip4 != nil {
+		var source uint32
+		if localIP != nil {
+			source = inAddr(localIP.To4())
+		}
+		replies, _, callErr = procIcmpSendEcho2Ex.Call(
+			uintptr(handle), 0, 0, 0,
+			uintptr(source), uintptr(inAddr(ip4)),
+			uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), 0,
+			uintptr(unsafe.Pointer(&reply[0])), uintptr(len(reply)),
+			uintptr(timeout))
+	} else {
+		source := windows.RawSockaddrInet6{Family: windows.AF_INET6}
+		if localIP != nil {
+			copy(source.Addr[:], localIP.To16())
+		}
+		dest := windows.RawSockaddrInet6{Family: windows.AF_INET6}
+		copy(dest.Addr[:], ip.To16())
+		replies, _, callErr = procIcmp6SendEcho2.Call(
+			uintptr(handle), 0, 0, 0,
+			uintptr(unsafe.Pointer(&source)), uintptr(unsafe.Pointer(&dest)),
+			uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), 0,
+			uintptr(unsafe.Pointer(&reply[0])), uintptr(len(reply)),
+			uintptr(timeout))
+	}
+	latency := time.Since(start)
+
+	// Without a reply the IP_STATUS is the last error
+	var status uint32
+	if replies == 0 {
+		errno, ok := callErr.(syscall.Errno)
+		if !ok {
+			return 0, models.StatusNetworkError, fmt.Errorf("ICMP send failed: %v", callErr)
+		}
+		status = uint32(errno)
+	} else {
+		status = replyStatus(reply, ip4 == nil)
+	}
+
+	resultStatus, err := mapIPStatus(status)
+	if err != nil {
+		return 0, resultStatus, err
+	}
+	return latency, resultStatus, nil
+}
+
+// replyStatus reads the IP_STATUS of the first reply in a reply buffer
+func replyStatus(reply []byte, ipv6 bool) uint32 {
+	if ipv6 {
+		return binary.LittleEndian.Uint32(reply[icmpv6EchoReplyStatus:])
+	}
+	return (*icmpEchoReply)(unsafe.Pointer(&reply[0])).Status
+}
+
+// mapIPStatus maps an IP_STATUS to a result status and error
+func mapIPStatus(status uint32) (models.ResultStatus, error) {
+	switch {
+	case status == ipSuccess:
+		return models.StatusOK, nil
+	case status == ipReqTimedOut:
+		return models.StatusTimeout, fmt.Errorf("ICMP receive failed: request timed out")
+	case status > ipStatusBase && status < ipStatusLimit:
+		return models.StatusNetworkError, fmt.Errorf("ICMP echo failed: IP status %d", status)
+	default:
+		return models.StatusNetworkError, fmt.Errorf("ICMP send failed: %v", syscall.Errno(status))
+	}
+}
+
+// icmpCreateFile opens an ICMP helper handle for IPv4 or IPv6
+func icmpCreateFile(ipv6 bool) (windows.Handle, error) {
+	proc := procIcmpCreateFile
+	if ipv6 {
+		proc = procIcmp6CreateFile
+	}
+	if err := proc.Find(); err != nil {
+		return windows.InvalidHandle, err
+	}
+	r, _, err := proc.Call()
+	handle := windows.Handle(r)
+	if handle == windows.InvalidHandle {
+		return handle, err
+	}
+	return handle, nil
+}
+
+func icmpCloseHandle(handle windows.Handle) {
+	procIcmpCloseHandle.Call(uintptr(handle))
+}
+
+// inAddr returns an IPv4 address as the IPAddr the API takes, which holds
+// the address bytes in network order
+func inAddr(ip4 net.IP) uint32 {
+	return binary.LittleEndian.Uint32(ip4)
+}
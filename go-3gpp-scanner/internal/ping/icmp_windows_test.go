@@ -0,0 +1,63 @@
+//go:build windows
+
+package ping
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"3gpp-scanner/internal/models"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestMapIPStatus(t *testing.T) {
+	tests := []struct {
+		status  uint32
+		want    models.ResultStatus
+		errPart string
+	}{
+		{ipSuccess, models.StatusOK, ""},
+		{ipReqTimedOut, models.StatusTimeout, "timed out"},
+		{11003, models.StatusNetworkError, "IP status 11003"}, // IP_DEST_HOST_UNREACHABLE
+		{uint32(windows.ERROR_NETWORK_UNREACHABLE), models.StatusNetworkError, "ICMP send failed"},
+	}
+	for _, tt := range tests {
+		got, err := mapIPStatus(tt.status)
+		if got != tt.want {
+			t.Errorf("mapIPStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+		if tt.errPart == "" && err != nil {
+			t.Errorf("mapIPStatus(%d) error = %v, want nil", tt.status, err)
+		}
+		if tt.errPart != "" && (err == nil || !strings.Contains(err.Error(), tt.errPart)) {
+			t.Errorf("mapIPStatus(%d) error = %v, want it to contain %q", tt.status, err, tt.errPart)
+		}
+	}
+}
+
+func TestReplyStatus(t *testing.T) {
+	reply := make([]byte, unsafe.Sizeof(icmpEchoReply{})+icmpReplyExtra)
+
+	// ICMP_ECHO_REPLY: Address, then Status
+	binary.LittleEndian.PutUint32(reply[4:], ipReqTimedOut)
+	if got := replyStatus(reply, false); got != ipReqTimedOut {
+		t.Errorf("IPv4 status = %d, want %d", got, ipReqTimedOut)
+	}
+
+	// ICMPV6_ECHO_REPLY: the 26-byte address, then Status
+	clear(reply)
+	binary.LittleEndian.PutUint32(reply[26:], 11003)
+	if got := replyStatus(reply, true); got != 11003 {
+		t.Errorf("IPv6 status = %d, want 11003", got)
+	}
+}
+
+func TestReplyBufferRoom(t *testing.T) {
+	want := 8 + 2*unsafe.Sizeof(uintptr(0)) // ICMP error and IO_STATUS_BLOCK
+	if icmpReplyExtra != want {
+		t.Errorf("icmpReplyExtra = %d, want %d", icmpReplyExtra, want)
+	}
+}
@@ -0,0 +1,273 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Path MTU search bounds. The minimums are the smallest MTUs every link
+// must support; the ceiling is the Ethernet MTU, which tunnels rarely exceed.
+const (
+	minMTUv4 = 576
+	minMTUv6 = 1280
+	maxMTU   = 1500
+
+	headerV4 = 20 + 8 // IPv4 + ICMP echo header
+	headerV6 = 40 + 8 // IPv6 + ICMPv6 echo header
+
+	// mtuAttempts is how often a probe size is sent before an unanswered
+	// echo ends the search
+	mtuAttempts = 3
+)
+
+// errTooBig reports that a probe did not fit the path
+var errTooBig = errors.New("packet too big")
+
+// probeSeq numbers MTU probes so concurrent workers can tell replies apart
+var probeSeq atomic.Uint32
+
+// mtuProbe sends DF-flagged ICMP echoes of a given size to one host
+type mtuProbe struct {
+	conn    net.PacketConn
+	ip      net.IP
+	ipv6    bool
+	id      int
+	timeout time.Duration
+}
+
+// probePathMTU binary-searches the largest DF-flagged echo that reaches
+// the host. IPsec (IKE over UDP 500/4500) needs room for ESP overhead, so
+// a path MTU well below 1500 often explains ePDG tunnel failures.
+func (p *Pinger) probePathMTU(fqdn string) (int, error) {
+	ips, err := net.LookupIP(fqdn)
+	if err != nil {
+		return 0, fmt.Errorf("DNS lookup failed: %w", err)
+	}
+	if len(ips) == 0 {
+		return 0, fmt.Errorf("no IP addresses found")
+	}
+	ip := ips[0]
+	if err := p.binding.CheckIP(ip); err != nil {
+		return 0, err
+	}
+	isV6 := ip.To4() == nil
+
+	network := "ip4:icmp"
+	if isV6 {
+		network = "ip6:ipv6-icmp"
+	}
+
+	listenAddr := ""
+	localIP, err := p.binding.LocalIP(isV6)
+	if err != nil {
+		return 0, fmt.Errorf("source binding failed: %w", err)
+	}
+	if localIP != nil {
+		listenAddr = localIP.String()
+	}
+
+	conn, err := net.ListenPacket(network, listenAddr)
+	if err != nil {
+		return 0, fmt.Errorf("ICMP listen failed (need root?): %w", err)
+	}
+	defer conn.Close()
+
+	if err := setDontFragment(conn, isV6); err != nil {
+		return 0, fmt.Errorf("cannot set DF flag: %w", err)
+	}
+
+	probe := &mtuProbe{
+		conn:    conn,
+		ip:      ip,
+		ipv6:    isV6,
+		id:      os.Getpid() & 0xffff,
+		timeout: p.config.Timeout,
+	}
+
+	lo := minMTUv4
+	if isV6 {
+		lo = minMTUv6
+	}
+	return searchMTU(lo, maxMTU, probe.send)
+}
+
+// searchMTU binary-searches the largest size in lo..hi that send gets
+// through. Only a size rejected as too big lowers the upper bound, down
+// to the next-hop MTU a router reported for that very probe. A lost echo
+// says nothing about its size, so it is sent again; a size that never
+// gets an answer ends the search inconclusively.
+func searchMTU(lo, hi int, send func(size int) (hint int, err error)) (int, error) {
+	try := func(size int) (hint int, err error) {
+		for attempt := 0; attempt < mtuAttempts; attempt++ {
+			if hint, err = send(size); !isTimeout(err) {
+				break
+			}
+		}
+		return hint, err
+	}
+
+	// The minimum must get through, otherwise the host is simply unreachable
+	if _, err := try(lo); err != nil {
+		return 0, fmt.Errorf("host unreachable at minimum MTU %d: %w", lo, err)
+	}
+
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		hint, err := try(mid)
+		switch {
+		case err == nil:
+			lo = mid
+		case errors.Is(err, errTooBig):
+			hi = mid - 1
+			if hint >= lo && hint < hi {
+				hi = hint
+			}
+		case isTimeout(err):
+			return 0, fmt.Errorf("path MTU inconclusive: %d bytes got through, %d bytes went unanswered %d times: %w", lo, mid, mtuAttempts, err)
+		default:
+			return 0, err
+		}
+	}
+
+	return lo, nil
+}
+
+// send transmits one echo of the given total packet size and waits for
+// the matching reply. It returns errTooBig when the packet is rejected
+// locally or a router reports that fragmentation was needed, along with
+// the next-hop MTU the router reported, if any.
+func (m *mtuProbe) send(size int) (int, error) {
+	header, proto := headerV4, 1
+	var echoType icmp.Type = ipv4.ICMPTypeEcho
+	if m.ipv6 {
+		header, proto = headerV6, 58
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+
+	seq := int(probeSeq.Add(1) & 0xffff)
+	msg := &icmp.Message{
+		Type: echoType,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   m.id,
+			Seq:  seq,
+			Data: make([]byte, size-header),
+		},
+	}
+	msgBytes, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, fmt.Errorf("ICMP marshal failed: %w", err)
+	}
+
+	if _, err := m.conn.WriteTo(msgBytes, &net.IPAddr{IP: m.ip}); err != nil {
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return 0, errTooBig
+		}
+		return 0, fmt.Errorf("ICMP send failed: %w", err)
+	}
+
+	deadline := time.Now().Add(m.timeout)
+	m.conn.SetReadDeadline(deadline)
+
+	reply := make([]byte, maxMTU+header)
+	for {
+		n, peer, err := m.conn.ReadFrom(reply)
+		if err != nil {
+			if errors.Is(err, syscall.EMSGSIZE) {
+				return 0, errTooBig
+			}
+			return 0, err
+		}
+
+		if done, hint, err := m.match(proto, reply[:n], peer, seq); done {
+			return hint, err
+		}
+	}
+}
+
+// match reports whether an ICMP message answers the echo numbered seq:
+// the echo reply itself, or an error quoting it. Raw sockets see every
+// ICMP packet, including the errors of other workers' probes, so a
+// message that quotes another datagram must not end this one.
+func (m *mtuProbe) match(proto int, msg []byte, peer net.Addr, seq int) (done bool, hint int, err error) {
+	parsed, err := icmp.ParseMessage(proto, msg)
+	if err != nil {
+		return false, 0, nil
+	}
+
+	switch body := parsed.Body.(type) {
+	case *icmp.Echo:
+		if body.ID == m.id && body.Seq == seq && peerIP(peer).Equal(m.ip) {
+			return true, 0, nil
+		}
+	case *icmp.PacketTooBig:
+		if m.quotes(body.Data, seq) {
+			return true, body.MTU, errTooBig
+		}
+	case *icmp.DstUnreach:
+		// Type 3 code 4: fragmentation needed and DF set
+		if parsed.Type == ipv4.ICMPTypeDestinationUnreachable && parsed.Code == 4 && m.quotes(body.Data, seq) {
+			return true, nextHopMTU(msg), errTooBig
+		}
+	}
+	return false, 0, nil
+}
+
+// quotes reports whether the datagram quoted by an ICMP error is the echo
+// numbered seq to this probe's host: its IP header and the first 8 bytes
+// of the echo, which carry the ID and sequence number
+func (m *mtuProbe) quotes(datagram []byte, seq int) bool {
+	var dst net.IP
+	var echo []byte
+	var echoType byte
+	if m.ipv6 {
+		// Probes carry no extension headers
+		if len(datagram) < ipv6.HeaderLen+8 || datagram[6] != 58 {
+			return false
+		}
+		dst, echo, echoType = datagram[24:40], datagram[ipv6.HeaderLen:], byte(ipv6.ICMPTypeEchoRequest)
+	} else {
+		if len(datagram) < ipv4.HeaderLen {
+			return false
+		}
+		headerLen := int(datagram[0]&0x0f) * 4
+		if headerLen < ipv4.HeaderLen || len(datagram) < headerLen+8 || datagram[9] != 1 {
+			return false
+		}
+		dst, echo, echoType = datagram[16:20], datagram[headerLen:], byte(ipv4.ICMPTypeEcho)
+	}
+	return dst.Equal(m.ip) && echo[0] == echoType &&
+		int(echo[4])<<8|int(echo[5]) == m.id && int(echo[6])<<8|int(echo[7]) == seq
+}
+
+// nextHopMTU extracts the MTU a router advertises in an ICMPv4
+// fragmentation-needed message (bytes 6-7 of the ICMP header)
+func nextHopMTU(msg []byte) int {
+	if len(msg) < 8 {
+		return 0
+	}
+	return int(msg[6])<<8 | int(msg[7])
+}
+
+// peerIP returns the IP of a packet source address
+func peerIP(addr net.Addr) net.IP {
+	if ipAddr, ok := addr.(*net.IPAddr); ok {
+		return ipAddr.IP
+	}
+	return nil
+}
+
+// isTimeout reports whether err is a read deadline expiring
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
@@ -0,0 +1,33 @@
+package ping
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// setDontFragment disables fragmentation on a raw ICMP socket so that
+// oversized probes fail instead of being split
+func setDontFragment(conn net.PacketConn, ipv6 bool) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("connection does not expose a socket")
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		if ipv6 {
+			sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO)
+		} else {
+			sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
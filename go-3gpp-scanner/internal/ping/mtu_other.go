@@ -0,0 +1,14 @@
+//go:build !linux
+
+package ping
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// setDontFragment is only implemented on Linux
+func setDontFragment(conn net.PacketConn, ipv6 bool) error {
+	return fmt.Errorf("path MTU probing is not supported on %s", runtime.GOOS)
+}
@@ -2,23 +2,31 @@ package ping
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/exclude"
 	"3gpp-scanner/internal/models"
-
-	"golang.org/x/net/icmp"
-	"golang.org/x/net/ipv4"
-	"golang.org/x/net/ipv6"
+	"3gpp-scanner/internal/netbind"
 )
 
 // Pinger handles connectivity testing
 type Pinger struct {
 	config       *models.PingConfig
+	binding      netbind.Binding
 	progressFunc func(current, total int, successful int)
+	resultFunc   func(result models.PingResult)
+	audit        *audit.Log
+	addrProbes   atomic.Int64 // Addresses probed by the last deduplicated run
 }
 
 // NewPinger creates a new pinger
@@ -26,7 +34,28 @@ func NewPinger(config *models.PingConfig) *Pinger {
 	if len(config.TCPPorts) == 0 {
 		config.TCPPorts = []int{443, 4500} // Default ports for ePDG
 	}
-	return &Pinger{config: config}
+	if config.HTTPSPort == 0 {
+		config.HTTPSPort = 443
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = "3gpp-scanner"
+	}
+	return &Pinger{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+	}
+}
+
+// SetExclude keeps the pinger away from listed targets: excluded FQDNs
+// are not resolved, and connections to excluded addresses are refused
+// with StatusExcluded
+func (p *Pinger) SetExclude(list exclude.Checker) {
+	p.binding.Exclude = list
+}
+
+// SetAudit records every ping, MTU and dual-stack probe in an audit log
+func (p *Pinger) SetAudit(log *audit.Log) {
+	p.audit = log
 }
 
 // SetProgressCallback sets a callback function for progress updates
@@ -34,10 +63,29 @@ func (p *Pinger) SetProgressCallback(callback func(current, total int, successfu
 	p.progressFunc = callback
 }
 
-// Ping tests connectivity to multiple FQDNs
+// SetResultCallback sets a function called with each kept result as it
+// arrives, from a single goroutine
+func (p *Pinger) SetResultCallback(callback func(result models.PingResult)) {
+	p.resultFunc = callback
+}
+
+// AddressesProbed returns the number of distinct addresses probed by the
+// last Ping with DedupeByIP
+func (p *Pinger) AddressesProbed() int {
+	return int(p.addrProbes.Load())
+}
+
+// Ping tests connectivity to multiple FQDNs. With DedupeByIP, all FQDNs
+// are resolved first and each distinct address is probed once, its result
+// shared by every FQDN resolving to it.
 func (p *Pinger) Ping(ctx context.Context, fqdns []string) ([]models.PingResult, error) {
 	results := make([]models.PingResult, 0, len(fqdns))
-	resultsMux := &sync.Mutex{}
+
+	var d *dedupe
+	if p.config.DedupeByIP {
+		p.addrProbes.Store(0)
+		d = p.resolveAll(ctx, fqdns)
+	}
 
 	totalJobs := len(fqdns)
 	jobs := make(chan string, totalJobs)
@@ -49,37 +97,87 @@ func (p *Pinger) Ping(ctx context.Context, fqdns []string) ([]models.PingResult,
 	// Progress tracking
 	var processed, successful atomic.Int64
 
+	// A single collector owns the results slice; workers only send
+	out := make(chan models.PingResult, p.config.Workers)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for result := range out {
+			results = append(results, result)
+			if p.resultFunc != nil {
+				p.resultFunc(result)
+			}
+		}
+	}()
+
 	var wg sync.WaitGroup
 	for i := 0; i < p.config.Workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			p.worker(ctx, jobs, &results, resultsMux, &processed, &successful, totalJobs)
+			p.worker(ctx, d, jobs, out, &processed, &successful, totalJobs)
 		}()
 	}
 
 	wg.Wait()
+	close(out)
+	<-collected
+
 	return results, nil
 }
 
-// worker processes ping jobs
-func (p *Pinger) worker(ctx context.Context, jobs <-chan string, results *[]models.PingResult, mux *sync.Mutex, processed, successful *atomic.Int64, totalJobs int) {
+// worker processes ping jobs, from the shared address probes of d when
+// deduplicating
+func (p *Pinger) worker(ctx context.Context, d *dedupe, jobs <-chan string, out chan<- models.PingResult, processed, successful *atomic.Int64, totalJobs int) {
 	for fqdn := range jobs {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 			var result models.PingResult
-			if p.config.Method == "tcp" {
-				result = p.pingTCP(fqdn)
+			nameErr := p.binding.CheckName(fqdn)
+			if nameErr != nil {
+				result = models.PingResult{
+					FQDN:      fqdn,
+					Method:    p.config.Method,
+					Status:    models.StatusExcluded,
+					Error:     nameErr.Error(),
+					Timestamp: time.Now(),
+				}
+				p.record(fqdn, p.config.Method, result.IP, result.Status, result.Error)
+			} else if d != nil {
+				// Address probes are recorded once, when sent
+				result = p.pingDeduped(d, fqdn)
 			} else {
-				result = p.pingICMP(fqdn)
+				result = p.PingOne(fqdn)
+				p.record(fqdn, p.config.Method, result.IP, result.Status, result.Error)
 			}
 
-			if p.config.Verbose || result.Success {
-				mux.Lock()
-				*results = append(*results, result)
-				mux.Unlock()
+			// A refused name is not resolved or contacted by any probe
+			if p.config.DualStack && nameErr == nil {
+				result.DualStack = p.probeDualStack(ctx, fqdn)
+				if ds := result.DualStack; ds != nil {
+					for _, family := range []*models.FamilyProbe{ds.IPv4, ds.IPv6} {
+						if family != nil {
+							p.record(fqdn, p.config.Method, family.IP, family.Status, "")
+						}
+					}
+				}
+			}
+
+			if p.config.ProbeMTU && result.Success && nameErr == nil {
+				mtu, err := p.probePathMTU(fqdn)
+				if err == nil {
+					result.PathMTU = mtu
+					p.record(fqdn, "mtu", result.IP, models.StatusOK, "")
+				} else {
+					p.record(fqdn, "mtu", result.IP, ConnStatus(err), err.Error())
+					slog.Debug("MTU probe failed", "fqdn", fqdn, "error", err)
+				}
+			}
+
+			if result.Success || p.config.IncludeFailures {
+				out <- result
 			}
 
 			if result.Success {
@@ -95,6 +193,20 @@ func (p *Pinger) worker(ctx context.Context, jobs <-chan string, results *[]mode
 	}
 }
 
+// record writes one probe to the audit log, if any
+func (p *Pinger) record(fqdn, probe, ip string, status models.ResultStatus, errMsg string) {
+	if p.audit == nil {
+		return
+	}
+	entry := audit.Entry{Probe: probe, Target: fqdn, IP: ip, Result: string(status), Error: errMsg}
+	if dst := net.ParseIP(ip); dst != nil && status != models.StatusExcluded {
+		if src, err := p.binding.SourceFor(dst); err == nil {
+			entry.SourceIP = src.String()
+		}
+	}
+	p.audit.Record(entry)
+}
+
 // pingICMP performs ICMP ping
 func (p *Pinger) pingICMP(fqdn string) models.PingResult {
 	result := models.PingResult{
@@ -103,92 +215,27 @@ func (p *Pinger) pingICMP(fqdn string) models.PingResult {
 		Timestamp: time.Now(),
 	}
 
-	// Resolve IP
-	ips, err := net.LookupIP(fqdn)
-	if err != nil {
-		result.Error = fmt.Sprintf("DNS lookup failed: %v", err)
-		return result
-	}
-
-	if len(ips) == 0 {
-		result.Error = "No IP addresses found"
+	ips, ok := resolve(&result)
+	if !ok {
 		return result
 	}
+	p.echoTo(&result, ips[0])
+	return result
+}
 
-	ip := ips[0]
+// echoTo pings one address of the result's FQDN over ICMP
+func (p *Pinger) echoTo(result *models.PingResult, ip net.IP) {
 	result.IP = ip.String()
 
-	// Determine protocol
-	var network string
-	var proto int
-	if ip.To4() != nil {
-		network = "ip4:icmp"
-		proto = 1 // ICMPv4
-	} else {
-		network = "ip6:ipv6-icmp"
-		proto = 58 // ICMPv6
-	}
-
-	// Create ICMP connection
-	conn, err := icmp.ListenPacket(network, "")
+	latency, status, err := p.echoICMP(ip)
+	result.Status = status
 	if err != nil {
-		result.Error = fmt.Sprintf("ICMP listen failed (need root?): %v", err)
-		return result
-	}
-	defer conn.Close()
-
-	// Set timeout
-	conn.SetDeadline(time.Now().Add(p.config.Timeout))
-
-	// Create ICMP message
-	msg := &icmp.Message{
-		Type: ipv4.ICMPTypeEcho,
-		Code: 0,
-		Body: &icmp.Echo{
-			ID:   1234,
-			Seq:  1,
-			Data: []byte("3gpp-scanner"),
-		},
-	}
-
-	if proto == 58 {
-		msg.Type = ipv6.ICMPTypeEchoRequest
-	}
-
-	msgBytes, err := msg.Marshal(nil)
-	if err != nil {
-		result.Error = fmt.Sprintf("ICMP marshal failed: %v", err)
-		return result
-	}
-
-	// Send ping
-	start := time.Now()
-	_, err = conn.WriteTo(msgBytes, &net.IPAddr{IP: ip})
-	if err != nil {
-		result.Error = fmt.Sprintf("ICMP send failed: %v", err)
-		return result
-	}
-
-	// Receive reply
-	reply := make([]byte, 1500)
-	n, _, err := conn.ReadFrom(reply)
-	latency := time.Since(start)
-
-	if err != nil {
-		result.Error = fmt.Sprintf("ICMP receive failed: %v", err)
-		return result
-	}
-
-	// Parse reply
-	_, err = icmp.ParseMessage(proto, reply[:n])
-	if err != nil {
-		result.Error = fmt.Sprintf("ICMP parse failed: %v", err)
-		return result
+		result.Error = err.Error()
+		return
 	}
 
 	result.Success = true
 	result.Latency = latency
-	return result
 }
 
 // pingTCP performs TCP connectivity check
@@ -199,31 +246,155 @@ func (p *Pinger) pingTCP(fqdn string) models.PingResult {
 		Timestamp: time.Now(),
 	}
 
-	// Try each configured port
+	dialer, err := p.binding.TCPDialer(p.config.Timeout)
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+
+	ips, ok := resolve(&result)
+	if !ok {
+		return result
+	}
+	p.connectPorts(&result, dialer, ips)
+	return result
+}
+
+// connectPorts tries each configured port on ips. A timeout or refusal on
+// any port is reported in preference to a generic network error.
+func (p *Pinger) connectPorts(result *models.PingResult, dialer *net.Dialer, ips []net.IP) {
+	result.Status = models.StatusNetworkError
 	for _, port := range p.config.TCPPorts {
-		address := fmt.Sprintf("%s:%d", fqdn, port)
 		start := time.Now()
-
-		conn, err := net.DialTimeout("tcp", address, p.config.Timeout)
+		conn, err := p.dialFirst(dialer, ips, port)
 		latency := time.Since(start)
 
 		if err == nil {
+			setPeer(result, conn.RemoteAddr())
 			conn.Close()
 			result.Success = true
+			result.Status = models.StatusOK
 			result.Latency = latency
-			result.IP = address
-			return result
+			return
 		}
+
+		if errors.Is(err, exclude.ErrExcluded) {
+			result.Status = models.StatusExcluded
+			result.Error = err.Error()
+			return
+		}
+		if status := ConnStatus(err); status != models.StatusNetworkError {
+			result.Status = status
+		}
+		result.Port = port
+		setDialed(result, err)
 	}
 
 	result.Error = fmt.Sprintf("All TCP ports unreachable: %v", p.config.TCPPorts)
-	return result
+}
+
+// resolve looks up the addresses of the result's FQDN and records them in
+// ResolvedIPs. On failure it sets the status and error and returns false.
+func resolve(result *models.PingResult) ([]net.IP, bool) {
+	ips, err := net.LookupIP(result.FQDN)
+	if err != nil {
+		result.Status = LookupStatus(err)
+		result.Error = fmt.Sprintf("DNS lookup failed: %v", err)
+		return nil, false
+	}
+	if len(ips) == 0 {
+		result.Status = models.StatusNoAnswer
+		result.Error = "No IP addresses found"
+		return nil, false
+	}
+	result.ResolvedIPs = make([]string, len(ips))
+	for i, ip := range ips {
+		result.ResolvedIPs[i] = ip.String()
+	}
+	return ips, true
+}
+
+// dialFirst connects to port on the first of ips that accepts. Like a
+// dial by host name, the timeout is split across the remaining addresses,
+// and the error is that of the first address.
+func (p *Pinger) dialFirst(dialer *net.Dialer, ips []net.IP, port int) (net.Conn, error) {
+	deadline := time.Now().Add(p.config.Timeout)
+	var firstErr error
+	for i, ip := range ips {
+		remaining := time.Until(deadline)
+		if remaining <= 0 && firstErr != nil {
+			break
+		}
+		d := *dialer
+		d.Timeout = remaining / time.Duration(len(ips)-i)
+		conn, err := d.Dial("tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// setPeer records the address and port of a connection's remote end
+func setPeer(result *models.PingResult, addr net.Addr) {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		result.IP = tcpAddr.IP.String()
+		result.Port = tcpAddr.Port
+	}
+}
+
+// setDialed records the remote address of a failed dial, so failures
+// show where traffic went
+func setDialed(result *models.PingResult, err error) {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Addr != nil {
+		setPeer(result, opErr.Addr)
+	}
+}
+
+// LookupStatus classifies a failed host lookup
+func LookupStatus(err error) models.ResultStatus {
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return models.StatusNetworkError
+	}
+	switch {
+	case dnsErr.IsNotFound:
+		return models.StatusNXDomain
+	case dnsErr.IsTimeout:
+		return models.StatusTimeout
+	default:
+		return models.StatusServFail
+	}
+}
+
+// ConnStatus classifies a failed connection, send or receive
+func ConnStatus(err error) models.ResultStatus {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, exclude.ErrExcluded):
+		return models.StatusExcluded
+	case errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return models.StatusTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return models.StatusRefused
+	default:
+		return models.StatusNetworkError
+	}
 }
 
 // PingOne performs a single ping test
 func (p *Pinger) PingOne(fqdn string) models.PingResult {
-	if p.config.Method == "tcp" {
+	switch p.config.Method {
+	case "tcp":
 		return p.pingTCP(fqdn)
+	case "https":
+		return p.pingHTTPS(fqdn)
+	default:
+		return p.pingICMP(fqdn)
 	}
-	return p.pingICMP(fqdn)
 }
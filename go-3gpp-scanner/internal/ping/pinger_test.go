@@ -0,0 +1,589 @@
+package ping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/scope"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// closedPort returns a local TCP port with nothing listening on it
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestPingTCPCollectsResults(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	open := ln.Addr().(*net.TCPAddr).Port
+
+	fqdns := make([]string, 20)
+	for i := range fqdns {
+		fqdns[i] = "127.0.0.1"
+	}
+
+	pinger := NewPinger(&models.PingConfig{
+		Method:   "tcp",
+		Timeout:  time.Second,
+		Workers:  5,
+		TCPPorts: []int{open},
+	})
+	streamed := 0
+	pinger.SetResultCallback(func(models.PingResult) { streamed++ })
+
+	results, err := pinger.Ping(context.Background(), fqdns)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if len(results) != len(fqdns) {
+		t.Errorf("Expected %d results, got %d", len(fqdns), len(results))
+	}
+	if streamed != len(results) {
+		t.Errorf("Expected the callback for all %d results, got %d", len(results), streamed)
+	}
+}
+
+func TestPingTCPReportsPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	open := ln.Addr().(*net.TCPAddr).Port
+	closed := closedPort(t)
+
+	// The closed port is tried first; the result names the one that answered
+	pinger := NewPinger(&models.PingConfig{Method: "tcp", Timeout: time.Second, Workers: 1, TCPPorts: []int{closed, open}})
+	result := pinger.PingOne("127.0.0.1")
+	if !result.Success || result.IP != "127.0.0.1" || result.Port != open {
+		t.Errorf("Expected success on 127.0.0.1 port %d, got %+v", open, result)
+	}
+	if len(result.ResolvedIPs) != 1 || result.ResolvedIPs[0] != "127.0.0.1" {
+		t.Errorf("Expected resolved IPs [127.0.0.1], got %v", result.ResolvedIPs)
+	}
+
+	// Failures name the last address and port tried
+	pinger = NewPinger(&models.PingConfig{Method: "tcp", Timeout: time.Second, Workers: 1, TCPPorts: []int{closed}})
+	result = pinger.PingOne("127.0.0.1")
+	if result.Success || result.IP != "127.0.0.1" || result.Port != closed || len(result.ResolvedIPs) != 1 {
+		t.Errorf("Expected failure on 127.0.0.1 port %d, got %+v", closed, result)
+	}
+}
+
+func TestPingIncludeFailures(t *testing.T) {
+	port := closedPort(t)
+	fqdns := []string{"127.0.0.1", "127.0.0.1", "127.0.0.1"}
+
+	config := &models.PingConfig{
+		Method:   "tcp",
+		Timeout:  time.Second,
+		Workers:  2,
+		TCPPorts: []int{port},
+	}
+
+	// Failures are dropped without IncludeFailures
+	results, _ := NewPinger(config).Ping(context.Background(), fqdns)
+	if len(results) != 0 {
+		t.Errorf("Expected no results without IncludeFailures, got %d", len(results))
+	}
+
+	config.IncludeFailures = true
+	results, _ = NewPinger(config).Ping(context.Background(), fqdns)
+	if len(results) != len(fqdns) {
+		t.Fatalf("Expected %d results with IncludeFailures, got %d", len(fqdns), len(results))
+	}
+	for _, r := range results {
+		if r.Success || r.Error == "" {
+			t.Errorf("Expected failed result with error, got %+v", r)
+		}
+		if r.Status != models.StatusRefused {
+			t.Errorf("Expected status %s for closed port, got %s", models.StatusRefused, r.Status)
+		}
+	}
+}
+
+func TestPingExclude(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan struct{}, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+
+	list, err := exclude.Parse(strings.NewReader("127.0.0.0/8\nexcluded.example.org\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	pinger := NewPinger(&models.PingConfig{
+		Method:          "tcp",
+		Timeout:         time.Second,
+		Workers:         1,
+		TCPPorts:        []int{ln.Addr().(*net.TCPAddr).Port},
+		IncludeFailures: true,
+	})
+	pinger.SetExclude(list)
+
+	// localhost resolves, but to an excluded address
+	results, _ := pinger.Ping(context.Background(), []string{"localhost", "excluded.example.org"})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Success || r.Status != models.StatusExcluded {
+			t.Errorf("Expected %s, got %+v", models.StatusExcluded, r)
+		}
+	}
+	if len(accepted) != 0 {
+		t.Errorf("Excluded address was contacted")
+	}
+}
+
+func TestPingExcludeDualStack(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan struct{}, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+
+	list, err := exclude.Parse(strings.NewReader("localhost\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path, "ping")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pinger := NewPinger(&models.PingConfig{
+		Method:          "tcp",
+		Timeout:         time.Second,
+		Workers:         1,
+		TCPPorts:        []int{ln.Addr().(*net.TCPAddr).Port},
+		DualStack:       true,
+		IncludeFailures: true,
+	})
+	pinger.SetExclude(list)
+	pinger.SetAudit(log)
+
+	results, _ := pinger.Ping(context.Background(), []string{"localhost"})
+	log.Close()
+	if len(results) != 1 || results[0].Status != models.StatusExcluded {
+		t.Fatalf("Expected one excluded result, got %+v", results)
+	}
+	if results[0].DualStack != nil {
+		t.Errorf("Excluded name was probed over both families: %+v", results[0].DualStack)
+	}
+	if len(accepted) != 0 {
+		t.Errorf("Excluded name was contacted")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("Expected only the refusal in the audit log, got %d entries:\n%s", lines, data)
+	}
+}
+
+func TestPingScopeDualStack(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan struct{}, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+
+	// A PLMN-only scope vets names but allows every address
+	path := filepath.Join(t.TempDir(), "scope.yaml")
+	if err := os.WriteFile(path, []byte(`plmns: ["262-01"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := scope.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pinger := NewPinger(&models.PingConfig{
+		Method:          "tcp",
+		Timeout:         time.Second,
+		Workers:         1,
+		TCPPorts:        []int{ln.Addr().(*net.TCPAddr).Port},
+		DualStack:       true,
+		IncludeFailures: true,
+	})
+	pinger.SetExclude(s)
+
+	results, _ := pinger.Ping(context.Background(), []string{"localhost"})
+	if len(results) != 1 || results[0].Status != models.StatusExcluded {
+		t.Fatalf("Expected one out-of-scope result, got %+v", results)
+	}
+	if results[0].DualStack != nil || len(accepted) != 0 {
+		t.Errorf("Out-of-scope name was probed over both families: %+v", results[0].DualStack)
+	}
+}
+
+func TestPingAudit(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path, "ping")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pinger := NewPinger(&models.PingConfig{
+		Method:   "tcp",
+		Timeout:  time.Second,
+		Workers:  1,
+		TCPPorts: []int{ln.Addr().(*net.TCPAddr).Port},
+	})
+	pinger.SetAudit(log)
+	if _, err := pinger.Ping(context.Background(), []string{"127.0.0.1"}); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry audit.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", data, err)
+	}
+	if entry.Command != "ping" || entry.Probe != "tcp" || entry.Target != "127.0.0.1" ||
+		entry.SourceIP != "127.0.0.1" || entry.Result != string(models.StatusOK) {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+}
+
+func TestProbePathMTULoopback(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("path MTU probing is Linux-only")
+	}
+	conn, err := net.ListenPacket("ip4:icmp", "")
+	if err != nil {
+		t.Skipf("raw ICMP sockets unavailable: %v", err)
+	}
+	conn.Close()
+
+	pinger := NewPinger(&models.PingConfig{Timeout: time.Second, Workers: 1})
+	mtu, err := pinger.probePathMTU("127.0.0.1")
+	if err != nil {
+		t.Fatalf("probePathMTU failed: %v", err)
+	}
+	// Loopback MTU exceeds the search ceiling
+	if mtu != maxMTU {
+		t.Errorf("Expected path MTU %d on loopback, got %d", maxMTU, mtu)
+	}
+}
+
+// pathSender simulates a path of a given MTU for searchMTU. Oversized
+// probes get a Packet Too Big reporting hint, or are dropped when hint is
+// negative; lost counts down probes dropped regardless of size.
+func pathSender(mtu, hint int, lost *int, sizes *[]int) func(int) (int, error) {
+	return func(size int) (int, error) {
+		*sizes = append(*sizes, size)
+		switch {
+		case *lost > 0:
+			*lost--
+			return 0, os.ErrDeadlineExceeded
+		case size <= mtu:
+			return 0, nil
+		case hint < 0:
+			return 0, os.ErrDeadlineExceeded
+		default:
+			return hint, errTooBig
+		}
+	}
+}
+
+func TestSearchMTU(t *testing.T) {
+	// A router reporting the next-hop MTU narrows the search at once
+	var sizes []int
+	lost := 0
+	mtu, err := searchMTU(minMTUv4, maxMTU, pathSender(1400, 1400, &lost, &sizes))
+	if err != nil || mtu != 1400 {
+		t.Errorf("searchMTU = %d, %v, want 1400", mtu, err)
+	}
+	if len(sizes) != 9 {
+		t.Errorf("Expected the hint to cut the search to 9 probes, got %v", sizes)
+	}
+
+	// A lost echo is sent again instead of lowering the bound
+	sizes = nil
+	lost = 2
+	if mtu, err := searchMTU(minMTUv4, maxMTU, pathSender(1400, 0, &lost, &sizes)); err != nil || mtu != 1400 {
+		t.Errorf("searchMTU with losses = %d, %v, want 1400", mtu, err)
+	}
+	if sizes[0] != minMTUv4 || sizes[1] != minMTUv4 || sizes[2] != minMTUv4 {
+		t.Errorf("Expected the minimum probe sent three times, got %v", sizes[:3])
+	}
+
+	// Silently dropped probes end the search without a result
+	sizes = nil
+	lost = 0
+	_, err = searchMTU(minMTUv4, maxMTU, pathSender(1400, -1, &lost, &sizes))
+	if err == nil || !isTimeout(err) || !strings.Contains(err.Error(), "inconclusive") {
+		t.Errorf("Expected an inconclusive timeout, got %v", err)
+	}
+	if _, err := searchMTU(minMTUv4, maxMTU, pathSender(0, -1, &lost, &sizes)); err == nil || !strings.Contains(err.Error(), "host unreachable") {
+		t.Errorf("Expected an unreachable host, got %v", err)
+	}
+}
+
+// quotedEcho returns an IP datagram carrying an echo request to dst, as
+// quoted by ICMP errors
+func quotedEcho(t *testing.T, dst net.IP, id, seq int) []byte {
+	t.Helper()
+	echoType := icmp.Type(ipv4.ICMPTypeEcho)
+	if dst.To4() == nil {
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+	echo, err := (&icmp.Message{Type: echoType, Body: &icmp.Echo{ID: id, Seq: seq, Data: make([]byte, 8)}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.To4() == nil {
+		header := make([]byte, ipv6.HeaderLen)
+		header[0], header[6], header[7] = 6<<4, 58, 64
+		copy(header[24:40], dst.To16())
+		return append(header, echo...)
+	}
+	header, err := (&ipv4.Header{Version: 4, Len: ipv4.HeaderLen, TotalLen: ipv4.HeaderLen + len(echo), TTL: 64, Protocol: 1,
+		Src: net.IPv4(198, 51, 100, 1), Dst: dst}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(header, echo...)
+}
+
+func TestMTUProbeMatch(t *testing.T) {
+	router := &net.IPAddr{IP: net.ParseIP("198.51.100.254")}
+
+	// Fragmentation needed, next-hop MTU 1400, quoting a given echo
+	fragNeeded := func(dst net.IP, id, seq int) []byte {
+		msg, err := (&icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 4,
+			Body: &icmp.DstUnreach{Data: quotedEcho(t, dst, id, seq)}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg[6], msg[7] = 1400>>8, 1400&0xff
+		return msg
+	}
+	v4 := &mtuProbe{ip: net.ParseIP("192.0.2.1"), id: 7}
+	tests := []struct {
+		name string
+		msg  []byte
+		want bool
+	}{
+		{"own", fragNeeded(v4.ip, 7, 42), true},
+		{"other host", fragNeeded(net.ParseIP("192.0.2.2"), 7, 42), false},
+		{"other sequence", fragNeeded(v4.ip, 7, 41), false},
+		{"other process", fragNeeded(v4.ip, 8, 42), false},
+		{"truncated", fragNeeded(v4.ip, 7, 42)[:8+ipv4.HeaderLen+4], false},
+	}
+	for _, tt := range tests {
+		done, hint, err := v4.match(1, tt.msg, router, 42)
+		if done != tt.want {
+			t.Errorf("%s: done = %v, want %v", tt.name, done, tt.want)
+		}
+		if done && (hint != 1400 || !errors.Is(err, errTooBig)) {
+			t.Errorf("%s: got hint %d, %v, want 1400 and errTooBig", tt.name, hint, err)
+		}
+	}
+
+	// Packet too big for another worker's host leaves the probe waiting
+	v6 := &mtuProbe{ip: net.ParseIP("2001:db8::1"), ipv6: true, id: 7}
+	tooBig := func(dst net.IP) []byte {
+		msg, err := (&icmp.Message{Type: ipv6.ICMPTypePacketTooBig,
+			Body: &icmp.PacketTooBig{MTU: 1280, Data: quotedEcho(t, dst, 7, 42)}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	if done, _, _ := v6.match(58, tooBig(net.ParseIP("2001:db8::2")), router, 42); done {
+		t.Error("Accepted a packet too big quoting another host")
+	}
+	if done, hint, err := v6.match(58, tooBig(v6.ip), router, 42); !done || hint != 1280 || !errors.Is(err, errTooBig) {
+		t.Errorf("Own packet too big = %v, %d, %v", done, hint, err)
+	}
+}
+
+func TestProbeDualStackLocalhost(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	pinger := NewPinger(&models.PingConfig{
+		Method:   "tcp",
+		Timeout:  time.Second,
+		Workers:  1,
+		TCPPorts: []int{ln.Addr().(*net.TCPAddr).Port},
+	})
+
+	ds := pinger.probeDualStack(context.Background(), "127.0.0.1")
+	if ds == nil || ds.IPv4 == nil {
+		t.Fatalf("Expected an IPv4 probe, got %+v", ds)
+	}
+	if !ds.IPv4.Success {
+		t.Errorf("Expected IPv4 probe to succeed, got status %s", ds.IPv4.Status)
+	}
+	if ds.IPv6 != nil {
+		t.Errorf("Expected no IPv6 probe for an IPv4 literal, got %+v", ds.IPv6)
+	}
+}
+
+func TestPingHTTPS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD request, got %s", r.Method)
+		}
+		if r.UserAgent() != "3gpp-scanner/test" {
+			t.Errorf("Expected the configured User-Agent, got %q", r.UserAgent())
+		}
+		w.Header().Set("Server", "Acme-BSF/1.0")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	pinger := NewPinger(&models.PingConfig{
+		Method:    "https",
+		Timeout:   2 * time.Second,
+		Workers:   1,
+		HTTPSPort: port,
+		UserAgent: "3gpp-scanner/test",
+	})
+
+	result := pinger.PingOne("127.0.0.1")
+	if !result.Success {
+		t.Fatalf("Expected HTTPS probe to succeed, got %s: %s", result.Status, result.Error)
+	}
+	if result.IP != "127.0.0.1" || result.Port != port || len(result.ResolvedIPs) != 1 {
+		t.Errorf("Expected 127.0.0.1 port %d, got IP %q port %d resolved %v", port, result.IP, result.Port, result.ResolvedIPs)
+	}
+	if result.TLSHandshake <= 0 {
+		t.Errorf("Expected TLS handshake time to be recorded")
+	}
+	if result.HTTPStatus != http.StatusNoContent {
+		t.Errorf("Expected HTTP status 204, got %d", result.HTTPStatus)
+	}
+	if result.HTTPServer != "Acme-BSF/1.0" {
+		t.Errorf("Expected Server header to be recorded, got %q", result.HTTPServer)
+	}
+	if !strings.Contains(result.CertIssuer, "Acme Co") || len(result.CertSANs) == 0 {
+		t.Errorf("Expected certificate issuer and SANs, got %q %v", result.CertIssuer, result.CertSANs)
+	}
+	// The test certificate covers 127.0.0.1 and example.com
+	if !result.CertValid {
+		t.Errorf("Expected certificate to be valid for 127.0.0.1")
+	}
+
+	// Nothing listening on the port
+	closed := NewPinger(&models.PingConfig{Method: "https", Timeout: time.Second, Workers: 1, HTTPSPort: closedPort(t)})
+	if result := closed.PingOne("127.0.0.1"); result.Success || result.Status != models.StatusRefused {
+		t.Errorf("Expected refused connection, got success=%t status=%s", result.Success, result.Status)
+	}
+}
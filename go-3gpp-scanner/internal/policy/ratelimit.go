@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// MCCRule describes how targets under a single MCC may be probed
+type MCCRule struct {
+	MaxQPS  float64 `json:"max_qps,omitempty"` // 0 means no per-country limit
+	Exclude bool    `json:"exclude,omitempty"` // Never probe this MCC
+	Comment string  `json:"comment,omitempty"`
+}
+
+// RatePolicy maps MCCs to probing rules. It is loaded from a JSON file:
+//
+//	{
+//	  "mcc": {
+//	    "262": {"max_qps": 1, "comment": "regulator asked for low volume"},
+//	    "460": {"exclude": true}
+//	  }
+//	}
+type RatePolicy struct {
+	MCC map[string]MCCRule `json:"mcc"`
+
+	// Country codes/names excluded via CLI flags (not part of the file)
+	excludedCountries map[string]bool
+}
+
+// NewRatePolicy creates an empty policy
+func NewRatePolicy() *RatePolicy {
+	return &RatePolicy{
+		MCC:               make(map[string]MCCRule),
+		excludedCountries: make(map[string]bool),
+	}
+}
+
+// LoadRatePolicy reads a rate-limit policy from a JSON file
+func LoadRatePolicy(filePath string) (*RatePolicy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	p := NewRatePolicy()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if p.MCC == nil {
+		p.MCC = make(map[string]MCCRule)
+	}
+
+	normalized := make(map[string]MCCRule, len(p.MCC))
+	for mcc, rule := range p.MCC {
+		if rule.MaxQPS < 0 {
+			return nil, fmt.Errorf("policy for MCC %s: max_qps cannot be negative", mcc)
+		}
+		normalized[normalizeMCC(mcc)] = rule
+	}
+	p.MCC = normalized
+
+	return p, nil
+}
+
+// ExcludeMCCs marks the given MCCs as excluded
+func (p *RatePolicy) ExcludeMCCs(mccs []string) {
+	for _, mcc := range mccs {
+		mcc = normalizeMCC(mcc)
+		if mcc == "" {
+			continue
+		}
+		rule := p.MCC[mcc]
+		rule.Exclude = true
+		p.MCC[mcc] = rule
+	}
+}
+
+// ExcludeCountries marks countries (ISO code or name, case-insensitive) as excluded
+func (p *RatePolicy) ExcludeCountries(countries []string) {
+	for _, c := range countries {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != "" {
+			p.excludedCountries[c] = true
+		}
+	}
+}
+
+// Excluded reports whether an MCC-MNC entry must not be probed
+func (p *RatePolicy) Excluded(entry models.MCCMNCEntry) bool {
+	if p.MCC[normalizeMCC(entry.MCC)].Exclude {
+		return true
+	}
+	if len(p.excludedCountries) == 0 {
+		return false
+	}
+	return p.excludedCountries[strings.ToLower(entry.CountryCode)] ||
+		p.excludedCountries[strings.ToLower(entry.CountryName)]
+}
+
+// Filter splits entries into those allowed by the policy and the number excluded
+func (p *RatePolicy) Filter(entries []models.MCCMNCEntry) ([]models.MCCMNCEntry, int) {
+	kept := make([]models.MCCMNCEntry, 0, len(entries))
+	excluded := 0
+	for _, entry := range entries {
+		if p.Excluded(entry) {
+			excluded++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept, excluded
+}
+
+// RateLimits returns the per-MCC QPS caps defined by the policy
+func (p *RatePolicy) RateLimits() map[string]float64 {
+	limits := make(map[string]float64)
+	for mcc, rule := range p.MCC {
+		if rule.MaxQPS > 0 && !rule.Exclude {
+			limits[mcc] = rule.MaxQPS
+		}
+	}
+	return limits
+}
+
+// normalizeMCC trims whitespace and zero-pads an MCC to 3 digits
+func normalizeMCC(mcc string) string {
+	mcc = strings.TrimSpace(mcc)
+	for len(mcc) > 0 && len(mcc) < 3 {
+		mcc = "0" + mcc
+	}
+	return mcc
+}
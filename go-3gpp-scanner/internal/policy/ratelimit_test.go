@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"os"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestLoadRatePolicy(t *testing.T) {
+	tmpFile := t.TempDir() + "/policy.json"
+	data := `{"mcc": {"262": {"max_qps": 2}, "460": {"exclude": true}, "9": {"max_qps": 1}}}`
+	if err := os.WriteFile(tmpFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+
+	p, err := LoadRatePolicy(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadRatePolicy failed: %v", err)
+	}
+
+	limits := p.RateLimits()
+	if limits["262"] != 2 {
+		t.Errorf("Expected MCC 262 limit 2, got %v", limits["262"])
+	}
+	if limits["009"] != 1 {
+		t.Errorf("Expected MCC 009 limit 1 after normalization, got %v", limits["009"])
+	}
+	if _, ok := limits["460"]; ok {
+		t.Errorf("Excluded MCC 460 should not have a rate limit")
+	}
+}
+
+func TestLoadRatePolicyNegativeQPS(t *testing.T) {
+	tmpFile := t.TempDir() + "/policy.json"
+	if err := os.WriteFile(tmpFile, []byte(`{"mcc": {"262": {"max_qps": -1}}}`), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+
+	if _, err := LoadRatePolicy(tmpFile); err == nil {
+		t.Errorf("Expected error for negative max_qps")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	p := NewRatePolicy()
+	p.ExcludeMCCs([]string{"460"})
+	p.ExcludeCountries([]string{"de", "United Kingdom"})
+
+	entries := []models.MCCMNCEntry{
+		{MCC: "310", MNC: "410", CountryCode: "US", CountryName: "United States"},
+		{MCC: "460", MNC: "00", CountryCode: "CN", CountryName: "China"},
+		{MCC: "262", MNC: "01", CountryCode: "DE", CountryName: "Germany"},
+		{MCC: "234", MNC: "10", CountryCode: "GB", CountryName: "United Kingdom"},
+	}
+
+	kept, excluded := p.Filter(entries)
+	if excluded != 3 {
+		t.Errorf("Expected 3 excluded entries, got %d", excluded)
+	}
+	if len(kept) != 1 || kept[0].MCC != "310" {
+		t.Errorf("Expected only MCC 310 to remain, got %+v", kept)
+	}
+}
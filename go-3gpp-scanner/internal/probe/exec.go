@@ -0,0 +1,211 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// helloTimeout bounds how long a plugin may take to announce itself
+const helloTimeout = 10 * time.Second
+
+// pluginHello is the first line a plugin writes after starting
+type pluginHello struct {
+	Name       string   `json:"name"`
+	Subdomains []string `json:"subdomains,omitempty"` // Empty: probe every target
+}
+
+// pluginRequest is one line sent to the plugin's stdin
+type pluginRequest struct {
+	ID        int64            `json:"id"`
+	Target    models.DNSResult `json:"target"`
+	TimeoutMs int64            `json:"timeout_ms,omitempty"`
+}
+
+// pluginReply is one line read from the plugin's stdout
+type pluginReply struct {
+	ID        int64               `json:"id"`
+	Success   bool                `json:"success"`
+	Status    models.ResultStatus `json:"status,omitempty"`
+	IP        string              `json:"ip,omitempty"`
+	LatencyMs float64             `json:"latency_ms,omitempty"`
+	Details   map[string]string   `json:"details,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// ExecPlugin is a probe implemented by an external program speaking
+// line-delimited JSON over stdio. The program first writes a hello line
+// ({"name": ..., "subdomains": [...]}), then answers each request line
+// ({"id", "target", "timeout_ms"}) with a reply line carrying the same id.
+// Requests are sent one at a time.
+type ExecPlugin struct {
+	name       string
+	subdomains map[string]bool
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan pluginReply
+	closing chan struct{}
+	exited  chan struct{}
+	readErr error
+
+	mu     sync.Mutex // Serializes requests
+	nextID int64
+}
+
+// StartPlugin launches a plugin program and waits for its hello line
+func StartPlugin(path string, args ...string) (*ExecPlugin, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+
+	p := &ExecPlugin{
+		cmd:     cmd,
+		stdin:   stdin,
+		replies: make(chan pluginReply),
+		closing: make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+
+	hello := make(chan pluginHello, 1)
+	go p.read(stdout, hello)
+
+	select {
+	case h := <-hello:
+		p.name = h.Name
+		if p.name == "" {
+			p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if len(h.Subdomains) > 0 {
+			p.subdomains = make(map[string]bool)
+			for _, s := range h.Subdomains {
+				p.subdomains[s] = true
+			}
+		}
+		return p, nil
+	case <-p.exited:
+		p.Close()
+		return nil, fmt.Errorf("plugin %s exited before hello: %v", path, p.readErr)
+	case <-time.After(helloTimeout):
+		p.Close()
+		return nil, fmt.Errorf("plugin %s sent no hello within %s", path, helloTimeout)
+	}
+}
+
+// read decodes the hello line and then replies until stdout closes
+func (p *ExecPlugin) read(stdout io.Reader, hello chan<- pluginHello) {
+	defer close(p.exited)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		p.readErr = scanner.Err()
+		return
+	}
+	var h pluginHello
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+		p.readErr = fmt.Errorf("invalid hello line: %w", err)
+		return
+	}
+	hello <- h
+
+	for scanner.Scan() {
+		var reply pluginReply
+		if err := json.Unmarshal(scanner.Bytes(), &reply); err != nil {
+			p.readErr = fmt.Errorf("invalid reply line: %w", err)
+			return
+		}
+		select {
+		case p.replies <- reply:
+		case <-p.closing:
+			return
+		}
+	}
+	p.readErr = scanner.Err()
+}
+
+// Name returns the name announced by the plugin
+func (p *ExecPlugin) Name() string {
+	return p.name
+}
+
+// Match applies the plugin to the subdomains it announced, or to all
+func (p *ExecPlugin) Match(target models.DNSResult) bool {
+	return p.subdomains == nil || p.subdomains[target.Subdomain]
+}
+
+// Probe sends one target to the plugin and waits for the matching reply.
+// Replies to earlier, timed-out requests are discarded.
+func (p *ExecPlugin) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	req := pluginRequest{ID: p.nextID, Target: target}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.TimeoutMs = time.Until(deadline).Milliseconds()
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return models.ProbeResult{Error: err.Error()}
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		return models.ProbeResult{Error: fmt.Sprintf("plugin write failed: %v", err)}
+	}
+
+	for {
+		select {
+		case reply := <-p.replies:
+			if reply.ID != req.ID {
+				continue
+			}
+			return models.ProbeResult{
+				IP:      reply.IP,
+				Success: reply.Success,
+				Status:  reply.Status,
+				Latency: time.Duration(reply.LatencyMs * float64(time.Millisecond)),
+				Details: reply.Details,
+				Error:   reply.Error,
+			}
+		case <-p.exited:
+			return models.ProbeResult{Error: fmt.Sprintf("plugin exited: %v", p.readErr)}
+		case <-ctx.Done():
+			return models.ProbeResult{Status: models.StatusTimeout, Error: "plugin did not reply in time"}
+		}
+	}
+}
+
+// Close ends the plugin by closing its stdin and waits for it to exit,
+// killing it after a grace period
+func (p *ExecPlugin) Close() error {
+	// Unblocks a reader holding a reply to a timed-out request
+	close(p.closing)
+	p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(2 * time.Second):
+		p.cmd.Process.Kill()
+	}
+	return p.cmd.Wait()
+}
@@ -0,0 +1,306 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+
+	"golang.org/x/time/rate"
+)
+
+func init() {
+	Register("gtp", newGTPProbe)
+}
+
+// GTP-C constants (3GPP TS 29.274 for v2, TS 29.060 for v1)
+const (
+	gtpcPort = 2123
+
+	gtpEchoRequest         = 1
+	gtpEchoResponse        = 2
+	gtpV2VersionNotSupport = 3
+
+	gtpV2IERecovery = 3  // TLIV: type, length(2), spare/instance, value
+	gtpV1IERecovery = 14 // TV: type, value
+
+	// defaultTelecomRate applies when ProbeConfig.TelecomRate is unset
+	defaultTelecomRate = 1.0
+)
+
+// gtpProbe sends GTP-C Echo Requests to EPC hosts to detect exposed GTP
+// control plane endpoints. GTPv2 is tried first, then GTPv1. Each IP is
+// probed at most once per run, and all probes share one rate limiter that
+// every request sent takes a token from.
+type gtpProbe struct {
+	config  *models.ProbeConfig
+	binding netbind.Binding
+	port    int
+	limiter *rate.Limiter
+	seq     atomic.Uint32
+
+	mu   sync.Mutex
+	byIP map[string]*gtpOutcome
+}
+
+// gtpOutcome is the cached result for one IP; done closes when ready
+type gtpOutcome struct {
+	done   chan struct{}
+	result models.ProbeResult
+}
+
+func newGTPProbe(config *models.ProbeConfig) (Probe, error) {
+	if !config.ActiveTelecom {
+		return nil, fmt.Errorf("gtp: %w", ErrActiveTelecom)
+	}
+	perSecond := config.TelecomRate
+	if perSecond <= 0 {
+		perSecond = defaultTelecomRate
+	}
+	return &gtpProbe{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		port:    gtpcPort,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), 1),
+		byIP:    make(map[string]*gtpOutcome),
+	}, nil
+}
+
+// Name implements Probe
+func (p *gtpProbe) Name() string {
+	return "gtp"
+}
+
+// Match applies the probe to EPC-related FQDNs (epdg.epc, ...)
+func (p *gtpProbe) Match(target models.DNSResult) bool {
+	for _, label := range strings.Split(target.Subdomain, ".") {
+		if label == "epc" {
+			return true
+		}
+	}
+	return false
+}
+
+// Limiter implements Limited
+func (p *gtpProbe) Limiter() *rate.Limiter {
+	return p.limiter
+}
+
+// Probe implements Probe. Hosts sharing an IP reuse the first outcome.
+func (p *gtpProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+
+	p.mu.Lock()
+	outcome, seen := p.byIP[ip]
+	if !seen {
+		outcome = &gtpOutcome{done: make(chan struct{})}
+		p.byIP[ip] = outcome
+	}
+	p.mu.Unlock()
+
+	if seen {
+		select {
+		case <-outcome.done:
+		case <-ctx.Done():
+			return models.ProbeResult{IP: ip, Status: models.StatusTimeout, Error: "waiting for probe of shared IP"}
+		}
+		result := outcome.result
+		result.Details = copyDetails(result.Details)
+		if result.Details == nil {
+			result.Details = make(map[string]string)
+		}
+		result.Details["shared_ip"] = "true"
+		return result
+	}
+
+	outcome.result = p.echo(ctx, ip)
+	close(outcome.done)
+	return outcome.result
+}
+
+// echo tries a GTPv2 and then a GTPv1 Echo Request against one IP. The
+// runner took the token for the first request; the fallback waits for its
+// own so it does not exceed the rate.
+func (p *gtpProbe) echo(ctx context.Context, ip string) models.ProbeResult {
+	result := models.ProbeResult{IP: ip}
+	var failures []string
+
+	for i, version := range []int{2, 1} {
+		if i > 0 {
+			if err := p.limiter.Wait(ctx); err != nil {
+				result.Status = models.StatusTimeout
+				failures = append(failures, fmt.Sprintf("v%d: rate limit wait aborted: %v", version, err))
+				break
+			}
+		}
+		deadline := deadlineShare(ctx, 2-i, p.config.Timeout)
+		start := time.Now()
+		details, err := p.exchange(ctx, ip, version, deadline)
+		if err != nil {
+			result.Status = ping.ConnStatus(err)
+			failures = append(failures, fmt.Sprintf("v%d: %v", version, err))
+			continue
+		}
+		result.Success = true
+		result.Status = models.StatusOK
+		result.Latency = time.Since(start)
+		result.Details = details
+		return result
+	}
+
+	result.Error = strings.Join(failures, "; ")
+	return result
+}
+
+// exchange sends one Echo Request and validates the reply
+func (p *gtpProbe) exchange(ctx context.Context, ip string, version int, deadline time.Time) (map[string]string, error) {
+	dialer, err := p.binding.UDPDialer(time.Until(deadline), netbind.IsIPv6Address(ip))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(p.port)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	seq := p.seq.Add(1)
+	var req []byte
+	if version == 2 {
+		req = gtpV2EchoRequest(seq)
+	} else {
+		req = gtpV1EchoRequest(uint16(seq))
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var details map[string]string
+		if version == 2 {
+			details, err = parseGTPv2Echo(buf[:n], seq)
+		} else {
+			details, err = parseGTPv1Echo(buf[:n], uint16(seq))
+		}
+		if err == nil {
+			return details, nil
+		}
+		// Ignore stray datagrams until the deadline
+	}
+}
+
+// gtpV2EchoRequest builds a GTPv2-C Echo Request with a Recovery IE
+func gtpV2EchoRequest(seq uint32) []byte {
+	msg := make([]byte, 13)
+	msg[0] = 0x40 // Version 2, no piggybacking, no TEID
+	msg[1] = gtpEchoRequest
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(msg)-4))
+	msg[4] = byte(seq >> 16)
+	msg[5] = byte(seq >> 8)
+	msg[6] = byte(seq)
+	// msg[7] spare
+	msg[8] = gtpV2IERecovery
+	binary.BigEndian.PutUint16(msg[9:11], 1)
+	// msg[11] spare/instance, msg[12] restart counter 0
+	return msg
+}
+
+// gtpV1EchoRequest builds a GTPv1-C Echo Request with a sequence number
+func gtpV1EchoRequest(seq uint16) []byte {
+	msg := make([]byte, 12)
+	msg[0] = 0x32 // Version 1, protocol type GTP, sequence number present
+	msg[1] = gtpEchoRequest
+	binary.BigEndian.PutUint16(msg[2:4], 4) // Sequence, N-PDU, next extension
+	// msg[4:8] TEID 0
+	binary.BigEndian.PutUint16(msg[8:10], seq)
+	return msg
+}
+
+// parseGTPv2Echo validates a GTPv2-C reply to the given sequence number
+func parseGTPv2Echo(msg []byte, seq uint32) (map[string]string, error) {
+	if len(msg) < 8 || msg[0]>>5 != 2 {
+		return nil, fmt.Errorf("not a GTPv2-C message")
+	}
+	offset := 4
+	if msg[0]&0x08 != 0 { // TEID present
+		offset += 4
+	}
+	if len(msg) < offset+4 {
+		return nil, fmt.Errorf("truncated GTPv2-C header")
+	}
+	got := uint32(msg[offset])<<16 | uint32(msg[offset+1])<<8 | uint32(msg[offset+2])
+	if got != seq&0xFFFFFF {
+		return nil, fmt.Errorf("sequence mismatch")
+	}
+
+	details := map[string]string{"version": "2"}
+	switch msg[1] {
+	case gtpEchoResponse:
+		details["response"] = "echo response"
+	case gtpV2VersionNotSupport:
+		details["response"] = "version not supported"
+		return details, nil
+	default:
+		return nil, fmt.Errorf("unexpected GTPv2-C message type %d", msg[1])
+	}
+
+	// Information elements follow the header
+	end := 4 + int(binary.BigEndian.Uint16(msg[2:4]))
+	for i := offset + 4; i+4 <= end && i+4 <= len(msg); {
+		ieLen := int(binary.BigEndian.Uint16(msg[i+1 : i+3]))
+		if msg[i] == gtpV2IERecovery && ieLen >= 1 && i+4 < len(msg) {
+			details["restart_counter"] = strconv.Itoa(int(msg[i+4]))
+		}
+		i += 4 + ieLen
+	}
+	return details, nil
+}
+
+// parseGTPv1Echo validates a GTPv1-C reply to the given sequence number
+func parseGTPv1Echo(msg []byte, seq uint16) (map[string]string, error) {
+	if len(msg) < 12 || msg[0]>>5 != 1 || msg[0]&0x10 == 0 {
+		return nil, fmt.Errorf("not a GTPv1-C message")
+	}
+	if msg[1] != gtpEchoResponse {
+		return nil, fmt.Errorf("unexpected GTPv1-C message type %d", msg[1])
+	}
+	if binary.BigEndian.Uint16(msg[8:10]) != seq {
+		return nil, fmt.Errorf("sequence mismatch")
+	}
+
+	details := map[string]string{"version": "1", "response": "echo response"}
+	if len(msg) >= 14 && msg[12] == gtpV1IERecovery {
+		details["restart_counter"] = strconv.Itoa(int(msg[13]))
+	}
+	return details, nil
+}
+
+// copyDetails returns a copy so cached results are never shared mutably
+func copyDetails(details map[string]string) map[string]string {
+	if details == nil {
+		return nil
+	}
+	c := make(map[string]string, len(details))
+	for k, v := range details {
+		c[k] = v
+	}
+	return c
+}
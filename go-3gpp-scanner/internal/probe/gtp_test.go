@@ -0,0 +1,149 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+// gtpServer answers GTP-C Echo Requests on a local port. With v1Only it
+// ignores GTPv2, like an SGSN that only speaks GTPv1.
+func gtpServer(t *testing.T, v1Only bool) (int, *atomic.Int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var requests atomic.Int32
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			requests.Add(1)
+			req := buf[:n]
+			switch {
+			case req[0]>>5 == 2 && !v1Only:
+				resp := []byte{0x40, gtpEchoResponse, 0, 9, req[4], req[5], req[6], 0,
+					gtpV2IERecovery, 0, 1, 0, 7}
+				conn.WriteTo(resp, addr)
+			case req[0]>>5 == 1:
+				resp := make([]byte, 14)
+				resp[0] = 0x32
+				resp[1] = gtpEchoResponse
+				binary.BigEndian.PutUint16(resp[2:4], 6)
+				copy(resp[8:10], req[8:10])
+				resp[12] = gtpV1IERecovery
+				resp[13] = 3
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port, &requests
+}
+
+func newTestGTPProbe(t *testing.T, port int) *gtpProbe {
+	p, err := newGTPProbe(&models.ProbeConfig{ActiveTelecom: true, TelecomRate: 100, Timeout: 400 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("newGTPProbe failed: %v", err)
+	}
+	gtp := p.(*gtpProbe)
+	gtp.port = port
+	return gtp
+}
+
+func TestGTPProbeRequiresOptIn(t *testing.T) {
+	if _, err := New("gtp", &models.ProbeConfig{}); !errors.Is(err, ErrActiveTelecom) {
+		t.Errorf("Expected ErrActiveTelecom, got %v", err)
+	}
+}
+
+func TestGTPProbe(t *testing.T) {
+	port, requests := gtpServer(t, false)
+	p := newTestGTPProbe(t, port)
+
+	target := models.DNSResult{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "epdg.epc", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || p.Match(models.DNSResult{Subdomain: "ims"}) {
+		t.Errorf("Expected GTP probe to apply to EPC hosts only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+	if !result.Success || result.Details["version"] != "2" || result.Details["restart_counter"] != "7" {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	// A second FQDN on the same IP reuses the outcome
+	shared := p.Probe(ctx, models.DNSResult{FQDN: "other.epc", Subdomain: "epc", IPs: []string{"127.0.0.1"}})
+	if !shared.Success || shared.Details["shared_ip"] != "true" {
+		t.Errorf("Expected cached result for shared IP, got %+v", shared)
+	}
+	if _, ok := result.Details["shared_ip"]; ok {
+		t.Errorf("Cached copy must not alter the first result")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected one request on the wire, got %d", n)
+	}
+}
+
+func TestGTPProbeV1Fallback(t *testing.T) {
+	port, _ := gtpServer(t, true)
+	p := newTestGTPProbe(t, port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result := p.Probe(ctx, models.DNSResult{FQDN: "sgsn.epc", Subdomain: "epc", IPs: []string{"127.0.0.1"}})
+	if !result.Success || result.Details["version"] != "1" || result.Details["restart_counter"] != "3" {
+		t.Errorf("Expected GTPv1 fallback, got %+v", result)
+	}
+}
+
+func TestGTPProbeRateLimitsFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		v1Only bool
+		tokens float64
+	}{
+		{name: "v2 answers", v1Only: false, tokens: 1},
+		{name: "v1 fallback", v1Only: true, tokens: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			port, _ := gtpServer(t, tc.v1Only)
+			p := newTestGTPProbe(t, port)
+			p.limiter = rate.NewLimiter(0.01, 1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if result := p.Probe(ctx, models.DNSResult{FQDN: "sgsn.epc", Subdomain: "epc", IPs: []string{"127.0.0.1"}}); !result.Success {
+				t.Fatalf("Probe failed: %+v", result)
+			}
+			// The runner pays for the first request; only the fallback
+			// takes a token here
+			if got := p.limiter.Tokens(); got < tc.tokens-0.1 || got > tc.tokens+0.1 {
+				t.Errorf("Expected %v tokens left, got %v", tc.tokens, got)
+			}
+		})
+	}
+}
+
+func TestParseGTPv2EchoRejectsMismatch(t *testing.T) {
+	resp := []byte{0x40, gtpEchoResponse, 0, 4, 0, 0, 9, 0}
+	if _, err := parseGTPv2Echo(resp, 8); err == nil {
+		t.Errorf("Expected sequence mismatch error")
+	}
+	if _, err := parseGTPv2Echo([]byte{0x20, 2, 0, 0}, 1); err == nil {
+		t.Errorf("Expected error for non-GTPv2 message")
+	}
+}
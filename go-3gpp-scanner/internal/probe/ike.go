@@ -0,0 +1,290 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+)
+
+func init() {
+	Register("ike", newIKEProbe)
+}
+
+// IKEv2 constants (RFC 7296)
+const (
+	ikePort          = 500
+	ikeHeaderLen     = 28
+	ikeVersion2      = 0x20
+	ikeSAInit        = 34
+	ikeFlagInitiator = 0x08
+	ikeFlagResponse  = 0x20
+
+	ikePayloadSA       = 33
+	ikePayloadKE       = 34
+	ikePayloadNonce    = 40
+	ikePayloadNotify   = 41
+	ikePayloadVendorID = 43
+
+	ikeDHGroup14 = 14 // 2048-bit MODP, the group ePDGs must support (TS 33.210)
+)
+
+// ikeNotifyNames names the notify types an IKE_SA_INIT response carries
+var ikeNotifyNames = map[uint16]string{
+	7:     "INVALID_SYNTAX",
+	14:    "NO_PROPOSAL_CHOSEN",
+	17:    "INVALID_KE_PAYLOAD",
+	16388: "NAT_DETECTION_SOURCE_IP",
+	16389: "NAT_DETECTION_DESTINATION_IP",
+	16390: "COOKIE",
+	16404: "MULTIPLE_AUTH_SUPPORTED",
+	16418: "REDIRECT_SUPPORTED",
+	16430: "IKEV2_FRAGMENTATION_SUPPORTED",
+	16431: "SIGNATURE_HASH_ALGORITHMS",
+}
+
+// ikeProbe sends an IKEv2 IKE_SA_INIT request to ePDG hosts, as a phone
+// attaching over Wi-Fi would, and records the response type, notify
+// payloads and the Vendor ID payloads that identify the IKE stack. The
+// exchange stops there: no IKE SA is established.
+type ikeProbe struct {
+	config  *models.ProbeConfig
+	binding netbind.Binding
+	port    int
+}
+
+func newIKEProbe(config *models.ProbeConfig) (Probe, error) {
+	return &ikeProbe{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		port:    ikePort,
+	}, nil
+}
+
+// Name implements Probe
+func (p *ikeProbe) Name() string {
+	return "ike"
+}
+
+// Match applies the probe to ePDG FQDNs
+func (p *ikeProbe) Match(target models.DNSResult) bool {
+	label, _, _ := strings.Cut(target.Subdomain, ".")
+	return label == "epdg"
+}
+
+// Probe implements Probe. Success means an IKE_SA_INIT response to our
+// SPI, whether it accepts the proposal or answers with a notify.
+func (p *ikeProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+	result := models.ProbeResult{IP: ip}
+
+	dialer, err := p.binding.UDPDialer(p.config.Timeout, netbind.IsIPv6Address(ip))
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(p.port)))
+	if err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadlineShare(ctx, 1, p.config.Timeout))
+
+	req, err := ikeSAInitRequest()
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			result.Status = ping.ConnStatus(err)
+			result.Error = fmt.Sprintf("no IKE response: %v", err)
+			return result
+		}
+		details, err := parseIKEResponse(buf[:n], req[0:8])
+		if err != nil {
+			// Ignore stray datagrams until the deadline
+			continue
+		}
+		result.Success = true
+		result.Status = models.StatusOK
+		result.Latency = time.Since(start)
+		result.Details = details
+		return result
+	}
+}
+
+// ikeSAInitRequest builds an IKE_SA_INIT request offering AES-CBC-256,
+// HMAC-SHA2-256 and DH group 14, with a random SPI, KE value and nonce
+func ikeSAInitRequest() ([]byte, error) {
+	spi := make([]byte, 8)
+	ke := make([]byte, 256)
+	nonce := make([]byte, 32)
+	for _, b := range [][]byte{spi, ke, nonce} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+
+	transform := func(last bool, typ byte, id uint16, attr []byte) []byte {
+		t := make([]byte, 8, 8+len(attr))
+		if !last {
+			t[0] = 3 // More transforms follow
+		}
+		binary.BigEndian.PutUint16(t[2:4], uint16(8+len(attr)))
+		t[4] = typ
+		binary.BigEndian.PutUint16(t[6:8], id)
+		return append(t, attr...)
+	}
+	keyLength := []byte{0x80, 0x0E, 0x01, 0x00} // Key length attribute, 256 bits
+
+	var transforms []byte
+	transforms = append(transforms, transform(false, 1, 12, keyLength)...) // ENCR_AES_CBC
+	transforms = append(transforms, transform(false, 2, 5, nil)...)        // PRF_HMAC_SHA2_256
+	transforms = append(transforms, transform(false, 3, 12, nil)...)       // AUTH_HMAC_SHA2_256_128
+	transforms = append(transforms, transform(true, 4, ikeDHGroup14, nil)...)
+
+	proposal := make([]byte, 8, 8+len(transforms))
+	binary.BigEndian.PutUint16(proposal[2:4], uint16(8+len(transforms)))
+	proposal[4] = 1 // Proposal number
+	proposal[5] = 1 // Protocol IKE
+	proposal[7] = 4 // Transforms
+	proposal = append(proposal, transforms...)
+
+	keData := make([]byte, 4, 4+len(ke))
+	binary.BigEndian.PutUint16(keData[0:2], ikeDHGroup14)
+	keData = append(keData, ke...)
+
+	var body []byte
+	body = append(body, ikePayload(ikePayloadKE, proposal)...)
+	body = append(body, ikePayload(ikePayloadNonce, keData)...)
+	body = append(body, ikePayload(0, nonce)...)
+
+	msg := make([]byte, ikeHeaderLen, ikeHeaderLen+len(body))
+	copy(msg[0:8], spi)
+	msg[16] = ikePayloadSA
+	msg[17] = ikeVersion2
+	msg[18] = ikeSAInit
+	msg[19] = ikeFlagInitiator
+	binary.BigEndian.PutUint32(msg[24:28], uint32(ikeHeaderLen+len(body)))
+	return append(msg, body...), nil
+}
+
+// ikePayload wraps data in a generic payload header naming the next payload
+func ikePayload(next byte, data []byte) []byte {
+	p := make([]byte, 4, 4+len(data))
+	p[0] = next
+	binary.BigEndian.PutUint16(p[2:4], uint16(4+len(data)))
+	return append(p, data...)
+}
+
+// parseIKEResponse validates an IKE_SA_INIT response to the initiator
+// SPI and collects its notify and Vendor ID payloads
+func parseIKEResponse(msg, spi []byte) (map[string]string, error) {
+	if len(msg) < ikeHeaderLen {
+		return nil, fmt.Errorf("truncated IKE header")
+	}
+	if !bytes.Equal(msg[0:8], spi) {
+		return nil, fmt.Errorf("initiator SPI mismatch")
+	}
+	if msg[18] != ikeSAInit || msg[19]&ikeFlagResponse == 0 {
+		return nil, fmt.Errorf("not an IKE_SA_INIT response")
+	}
+
+	details := map[string]string{"version": fmt.Sprintf("%d.%d", msg[17]>>4, msg[17]&0x0F)}
+	var notifies, vendorIDs, vendorText []string
+	accepted := false
+
+	next := msg[16]
+	end := int(binary.BigEndian.Uint32(msg[24:28]))
+	if end > len(msg) {
+		end = len(msg)
+	}
+	for i := ikeHeaderLen; next != 0 && i+4 <= end; {
+		length := int(binary.BigEndian.Uint16(msg[i+2 : i+4]))
+		if length < 4 || i+length > end {
+			break
+		}
+		data := msg[i+4 : i+length]
+		switch next {
+		case ikePayloadSA:
+			accepted = true
+		case ikePayloadNotify:
+			if len(data) >= 4 {
+				notifies = append(notifies, IKENotifyName(binary.BigEndian.Uint16(data[2:4])))
+			}
+		case ikePayloadVendorID:
+			vendorIDs = append(vendorIDs, hex.EncodeToString(data))
+			if text := printable(data); text != "" {
+				vendorText = append(vendorText, text)
+			}
+		}
+		next = msg[i]
+		i += length
+	}
+
+	if accepted {
+		details["response"] = "proposal accepted"
+	} else {
+		details["response"] = "notify"
+	}
+	if len(notifies) > 0 {
+		details["notify"] = strings.Join(notifies, ",")
+	}
+	if len(vendorIDs) > 0 {
+		details["vendor_ids"] = strings.Join(vendorIDs, ",")
+	}
+	if len(vendorText) > 0 {
+		details["vendor_id_text"] = strings.Join(vendorText, ",")
+	}
+	return details, nil
+}
+
+// IKENotifyName names a notify type, falling back to its number
+func IKENotifyName(typ uint16) string {
+	if name, ok := ikeNotifyNames[typ]; ok {
+		return name
+	}
+	return strconv.Itoa(int(typ))
+}
+
+// printable returns data as text when it is mostly printable ASCII, as
+// some stacks send readable Vendor IDs instead of hashes
+func printable(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if c >= 0x20 && c < 0x7F {
+			b.WriteByte(c)
+		}
+	}
+	if len(data) == 0 || b.Len()*10 < len(data)*9 {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,109 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// ikeReply builds an IKE_SA_INIT response to req from payloads given as
+// (type, data) pairs
+func ikeReply(req []byte, payloads ...[]byte) []byte {
+	var body []byte
+	for i := 0; i < len(payloads); i += 2 {
+		var next byte
+		if i+2 < len(payloads) {
+			next = payloads[i+2][0]
+		}
+		body = append(body, ikePayload(next, payloads[i+1])...)
+	}
+	msg := make([]byte, ikeHeaderLen)
+	copy(msg[0:8], req[0:8])
+	copy(msg[8:16], []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	if len(payloads) > 0 {
+		msg[16] = payloads[0][0]
+	}
+	msg[17] = ikeVersion2
+	msg[18] = ikeSAInit
+	msg[19] = ikeFlagResponse
+	binary.BigEndian.PutUint32(msg[24:28], uint32(ikeHeaderLen+len(body)))
+	return append(msg, body...)
+}
+
+func TestIKESAInitRequest(t *testing.T) {
+	req, err := ikeSAInitRequest()
+	if err != nil {
+		t.Fatalf("ikeSAInitRequest: %v", err)
+	}
+	if int(binary.BigEndian.Uint32(req[24:28])) != len(req) {
+		t.Fatalf("Header length %d does not match message length %d", binary.BigEndian.Uint32(req[24:28]), len(req))
+	}
+
+	// Walk the payload chain: SA, KE, Nonce, then the end of the message
+	var types []byte
+	next, i := req[16], ikeHeaderLen
+	for next != 0 {
+		types = append(types, next)
+		length := int(binary.BigEndian.Uint16(req[i+2 : i+4]))
+		next = req[i]
+		i += length
+	}
+	if i != len(req) || string(types) != string([]byte{ikePayloadSA, ikePayloadKE, ikePayloadNonce}) {
+		t.Errorf("Unexpected payload chain %v ending at %d of %d", types, i, len(req))
+	}
+}
+
+func TestIKEProbe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	defer conn.Close()
+
+	strongSwan, _ := hex.DecodeString("882fe56d6fd20dbc2251613b2ebe5beb")
+	go func() {
+		buf := make([]byte, 4096)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil || n < ikeHeaderLen || buf[18] != ikeSAInit {
+			return
+		}
+		notify := []byte{0, 0, 0, 17, 0, 14} // INVALID_KE_PAYLOAD, group 14
+		// A stray datagram with another SPI is ignored
+		conn.WriteTo(ikeReply(make([]byte, 8), []byte{ikePayloadNotify}, notify), addr)
+		conn.WriteTo(ikeReply(buf[:n],
+			[]byte{ikePayloadNotify}, notify,
+			[]byte{ikePayloadVendorID}, strongSwan,
+			[]byte{ikePayloadVendorID}, []byte("Acme ePDG 3.1"),
+		), addr)
+	}()
+
+	p := &ikeProbe{config: &models.ProbeConfig{Timeout: time.Second}, port: conn.LocalAddr().(*net.UDPAddr).Port}
+	target := models.DNSResult{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "epdg.epc", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || p.Match(models.DNSResult{Subdomain: "ims"}) {
+		t.Errorf("Expected IKE probe to apply to ePDG hosts only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+	if !result.Success {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+	want := map[string]string{
+		"version":        "2.0",
+		"response":       "notify",
+		"notify":         "INVALID_KE_PAYLOAD",
+		"vendor_ids":     "882fe56d6fd20dbc2251613b2ebe5beb," + hex.EncodeToString([]byte("Acme ePDG 3.1")),
+		"vendor_id_text": "Acme ePDG 3.1",
+	}
+	for k, v := range want {
+		if result.Details[k] != v {
+			t.Errorf("Details[%s] = %q, want %q", k, result.Details[k], v)
+		}
+	}
+}
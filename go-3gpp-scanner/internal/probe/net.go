@@ -0,0 +1,49 @@
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// targetIP returns the address to probe: the first address recorded by
+// the scan, or a fresh lookup when the result carries none
+func targetIP(ctx context.Context, target models.DNSResult) (string, error) {
+	if len(target.IPs) > 0 {
+		return target.IPs[0], nil
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, target.FQDN)
+	if err != nil {
+		return "", err
+	}
+	return ips[0], nil
+}
+
+// deadlineShare splits the time left until the context deadline evenly
+// across the remaining attempts, so an early attempt that times out
+// leaves room for the later ones
+func deadlineShare(ctx context.Context, remaining int, fallback time.Duration) time.Time {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Now().Add(fallback)
+	}
+	if remaining < 1 {
+		remaining = 1
+	}
+	return time.Now().Add(time.Until(deadline) / time.Duration(remaining))
+}
+
+// randomToken returns n random bytes hex-encoded, for protocol
+// transaction identifiers
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
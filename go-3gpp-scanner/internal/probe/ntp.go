@@ -0,0 +1,182 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+)
+
+func init() {
+	Register("ntp", newNTPProbe)
+}
+
+// NTP constants (RFC 5905)
+const (
+	ntpPort       = 123
+	ntpPacketLen  = 48
+	ntpModeClient = 3
+	ntpModeServer = 4
+	ntpVersion    = 4
+
+	// ntpEpochOffset is the number of seconds from 1900 to 1970
+	ntpEpochOffset = 2208988800
+)
+
+// ntpProbe sends an NTP mode 3 (client) query to ntp hosts and records
+// the stratum, reference ID and clock offset of servers that answer
+type ntpProbe struct {
+	config  *models.ProbeConfig
+	binding netbind.Binding
+	port    int
+}
+
+func newNTPProbe(config *models.ProbeConfig) (Probe, error) {
+	return &ntpProbe{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		port:    ntpPort,
+	}, nil
+}
+
+// Name implements Probe
+func (p *ntpProbe) Name() string {
+	return "ntp"
+}
+
+// Match applies the probe to ntp FQDNs
+func (p *ntpProbe) Match(target models.DNSResult) bool {
+	for _, label := range strings.Split(target.Subdomain, ".") {
+		if label == "ntp" {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe implements Probe. Success means a server (mode 4) reply to the
+// query; a Kiss-o'-Death reply also succeeds, with its code as ref_id.
+func (p *ntpProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+	result := models.ProbeResult{IP: ip}
+
+	dialer, err := p.binding.UDPDialer(p.config.Timeout, netbind.IsIPv6Address(ip))
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(p.port)))
+	if err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadlineShare(ctx, 1, p.config.Timeout))
+
+	sent := time.Now()
+	req := ntpClientQuery(sent)
+	if _, err := conn.Write(req); err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			result.Status = ping.ConnStatus(err)
+			result.Error = fmt.Sprintf("no NTP reply: %v", err)
+			return result
+		}
+		received := time.Now()
+		details, err := parseNTPReply(buf[:n], req[40:48], sent, received)
+		if err != nil {
+			// Ignore stray datagrams until the deadline
+			continue
+		}
+		result.Success = true
+		result.Status = models.StatusOK
+		result.Latency = received.Sub(sent)
+		result.Details = details
+		return result
+	}
+}
+
+// ntpClientQuery builds a mode 3 packet with the send time as transmit
+// timestamp, which the server echoes as originate timestamp
+func ntpClientQuery(now time.Time) []byte {
+	msg := make([]byte, ntpPacketLen)
+	msg[0] = ntpVersion<<3 | ntpModeClient // LI 0
+	binary.BigEndian.PutUint64(msg[40:48], ntpTimestamp(now))
+	return msg
+}
+
+// parseNTPReply validates a server reply to the query whose transmit
+// timestamp was origin, and computes the clock offset from the four
+// timestamps (RFC 5905, section 8)
+func parseNTPReply(msg, origin []byte, sent, received time.Time) (map[string]string, error) {
+	if len(msg) < ntpPacketLen {
+		return nil, fmt.Errorf("truncated NTP packet")
+	}
+	if mode := msg[0] & 0x07; mode != ntpModeServer {
+		return nil, fmt.Errorf("unexpected NTP mode %d", mode)
+	}
+	if string(msg[24:32]) != string(origin) {
+		return nil, fmt.Errorf("originate timestamp mismatch")
+	}
+
+	stratum := int(msg[1])
+	details := map[string]string{
+		"version": strconv.Itoa(int(msg[0] >> 3 & 0x07)),
+		"stratum": strconv.Itoa(stratum),
+		"ref_id":  ntpRefID(stratum, msg[12:16]),
+	}
+	if stratum == 0 {
+		// Kiss-o'-Death: the timestamps carry no time
+		details["kiss_of_death"] = "true"
+		return details, nil
+	}
+
+	serverReceive := ntpTime(binary.BigEndian.Uint64(msg[32:40]))
+	serverTransmit := ntpTime(binary.BigEndian.Uint64(msg[40:48]))
+	offset := (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2
+	details["offset_ms"] = strconv.FormatFloat(float64(offset)/float64(time.Millisecond), 'f', 3, 64)
+	return details, nil
+}
+
+// ntpRefID renders the reference ID: an ASCII source or kiss code for
+// stratum 0 and 1, otherwise the IPv4 address (or IPv6 hash) of the
+// upstream server
+func ntpRefID(stratum int, id []byte) string {
+	if stratum <= 1 {
+		return strings.TrimRight(string(id), "\x00 ")
+	}
+	return net.IP(id).String()
+}
+
+// ntpTimestamp converts a time to the 64-bit NTP format
+func ntpTimestamp(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// ntpTime converts a 64-bit NTP timestamp to a time
+func ntpTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := int64((ts & 0xFFFFFFFF) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nanos)
+}
@@ -0,0 +1,92 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestNTPProbe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	defer conn.Close()
+
+	// Server clock runs 250ms ahead of the client
+	skew := 250 * time.Millisecond
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil || n < ntpPacketLen || buf[0]&0x07 != ntpModeClient {
+			return
+		}
+		reply := make([]byte, ntpPacketLen)
+		reply[0] = ntpVersion<<3 | ntpModeServer
+		reply[1] = 2
+		copy(reply[12:16], net.ParseIP("192.0.2.10").To4())
+		copy(reply[24:32], buf[40:48])
+		now := time.Now().Add(skew)
+		binary.BigEndian.PutUint64(reply[32:40], ntpTimestamp(now))
+		binary.BigEndian.PutUint64(reply[40:48], ntpTimestamp(now))
+		conn.WriteTo(reply, addr)
+	}()
+
+	p := &ntpProbe{config: &models.ProbeConfig{Timeout: time.Second}, port: conn.LocalAddr().(*net.UDPAddr).Port}
+	target := models.DNSResult{FQDN: "ntp.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "ntp", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || p.Match(models.DNSResult{Subdomain: "ims"}) {
+		t.Errorf("Expected NTP probe to apply to ntp hosts only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+	if !result.Success {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+	if result.Details["stratum"] != "2" || result.Details["ref_id"] != "192.0.2.10" || result.Details["version"] != "4" {
+		t.Errorf("Unexpected details: %v", result.Details)
+	}
+	offset, err := strconv.ParseFloat(result.Details["offset_ms"], 64)
+	if err != nil || offset < 200 || offset > 300 {
+		t.Errorf("Expected offset near 250ms, got %q", result.Details["offset_ms"])
+	}
+}
+
+func TestParseNTPReplyKissOfDeath(t *testing.T) {
+	origin := make([]byte, 8)
+	binary.BigEndian.PutUint64(origin, ntpTimestamp(time.Now()))
+
+	reply := make([]byte, ntpPacketLen)
+	reply[0] = ntpVersion<<3 | ntpModeServer
+	copy(reply[12:16], "RATE")
+	copy(reply[24:32], origin)
+
+	details, err := parseNTPReply(reply, origin, time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("parseNTPReply: %v", err)
+	}
+	if details["ref_id"] != "RATE" || details["kiss_of_death"] != "true" {
+		t.Errorf("Unexpected details: %v", details)
+	}
+	if _, ok := details["offset_ms"]; ok {
+		t.Errorf("Kiss-o'-Death reply must not report an offset")
+	}
+
+	other := make([]byte, 8)
+	if _, err := parseNTPReply(reply, other, time.Now(), time.Now()); err == nil {
+		t.Errorf("Expected originate timestamp mismatch")
+	}
+}
+
+func TestNTPTimestampRoundTrip(t *testing.T) {
+	now := time.Unix(1760000000, 123456000)
+	if got := ntpTime(ntpTimestamp(now)); got.Sub(now).Abs() > time.Microsecond {
+		t.Errorf("round trip = %v, want %v", got, now)
+	}
+}
@@ -0,0 +1,292 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+
+	"golang.org/x/time/rate"
+)
+
+// Probe is a protocol check run against discovered hosts. Built-in
+// probes register a Factory; external checks attach as subprocess
+// plugins (see ExecPlugin).
+type Probe interface {
+	// Name identifies the probe in results and on the command line
+	Name() string
+	// Match reports whether the probe applies to a scan result, e.g.
+	// only to ims and xcap FQDNs
+	Match(target models.DNSResult) bool
+	// Probe checks one target. The context carries the per-probe
+	// deadline; FQDN, Probe and Timestamp are filled in by the runner.
+	Probe(ctx context.Context, target models.DNSResult) models.ProbeResult
+}
+
+// Limited is implemented by probes that must be rate limited. The runner
+// waits on the limiter before the probe's timeout starts, so queueing
+// behind the limit is not mistaken for an unresponsive host.
+type Limited interface {
+	Limiter() *rate.Limiter
+}
+
+// ErrActiveTelecom is returned by factories of probes that speak telecom
+// control protocols when ProbeConfig.ActiveTelecom is not set
+var ErrActiveTelecom = errors.New("active telecom probe not enabled")
+
+// Factory creates a probe for a run configuration
+type Factory func(config *models.ProbeConfig) (Probe, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a built-in probe available by name. It panics when the
+// name is taken, as registration happens in init functions.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("probe %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// New creates the registered probe with the given name
+func New(name string, config *models.ProbeConfig) (Probe, error) {
+	registryMu.Lock()
+	factory, ok := registry[name]
+	registryMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown probe: %s (available: %v)", name, Names())
+	}
+	return factory(config)
+}
+
+// Names lists the registered probes in alphabetical order
+func Names() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Runner runs probes against scan results with a worker pool
+type Runner struct {
+	config       *models.ProbeConfig
+	probes       []Probe
+	progressFunc func(current, total int, successful int)
+	exclude      exclude.Checker
+	audit        *audit.Log
+}
+
+// job pairs a probe with a matching target
+type job struct {
+	probe  Probe
+	target models.DNSResult
+}
+
+// NewRunner creates a runner for the given probes
+func NewRunner(config *models.ProbeConfig, probes []Probe) *Runner {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	return &Runner{config: config, probes: probes}
+}
+
+// SetProgressCallback sets a callback function for progress updates
+func (r *Runner) SetProgressCallback(callback func(current, total int, successful int)) {
+	r.progressFunc = callback
+}
+
+// SetAudit records every probe run in an audit log
+func (r *Runner) SetAudit(log *audit.Log) {
+	r.audit = log
+}
+
+// SetExclude keeps probes away from listed targets. Targets are resolved
+// first when the list holds addresses, so plugins only ever see vetted
+// IPs; excluded targets get StatusExcluded without being contacted.
+func (r *Runner) SetExclude(list exclude.Checker) {
+	r.exclude = list
+}
+
+// Jobs counts the probe runs the targets will cause
+func (r *Runner) Jobs(targets []models.DNSResult) int {
+	n := 0
+	for _, target := range targets {
+		for _, p := range r.probes {
+			if p.Match(target) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Run probes every target with each matching probe
+func (r *Runner) Run(ctx context.Context, targets []models.DNSResult) []models.ProbeResult {
+	var queue []job
+	for _, target := range targets {
+		for _, p := range r.probes {
+			if p.Match(target) {
+				queue = append(queue, job{probe: p, target: target})
+			}
+		}
+	}
+
+	totalJobs := len(queue)
+	results := make([]models.ProbeResult, 0, totalJobs)
+	jobs := make(chan job, totalJobs)
+	for _, j := range queue {
+		jobs <- j
+	}
+	close(jobs)
+
+	var processed, successful atomic.Int64
+
+	// Workers send results here and one goroutine appends them, so the
+	// slice needs no lock
+	out := make(chan models.ProbeResult, r.config.Workers)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for result := range out {
+			results = append(results, result)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				result := r.runOne(ctx, j)
+				r.record(result)
+				out <- result
+
+				if result.Success {
+					successful.Add(1)
+				}
+				current := int(processed.Add(1))
+				if r.progressFunc != nil {
+					r.progressFunc(current, totalJobs, int(successful.Load()))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(out)
+	<-collected
+
+	return results
+}
+
+// record writes one probe run to the audit log, if any
+func (r *Runner) record(result models.ProbeResult) {
+	if r.audit == nil {
+		return
+	}
+	entry := audit.Entry{
+		Time:   result.Timestamp,
+		Probe:  result.Probe,
+		Target: result.FQDN,
+		IP:     result.IP,
+		Result: string(result.Status),
+		Error:  result.Error,
+	}
+	if dst := net.ParseIP(result.IP); dst != nil && result.Status != models.StatusExcluded {
+		binding := netbind.Binding{SourceIP: r.config.SourceIP, Interface: r.config.Interface}
+		if src, err := binding.SourceFor(dst); err == nil {
+			entry.SourceIP = src.String()
+		}
+	}
+	r.audit.Record(entry)
+}
+
+// runOne runs a single probe under the configured timeout
+func (r *Runner) runOne(ctx context.Context, j job) models.ProbeResult {
+	if r.exclude != nil {
+		// A target that cannot be vetted is not probed
+		if len(j.target.IPs) == 0 && r.exclude.HasNetworks() {
+			ips, err := net.DefaultResolver.LookupHost(ctx, j.target.FQDN)
+			if err != nil {
+				return models.ProbeResult{
+					FQDN:      j.target.FQDN,
+					Probe:     j.probe.Name(),
+					Status:    ping.LookupStatus(err),
+					Error:     fmt.Sprintf("DNS lookup failed: %v", err),
+					Timestamp: time.Now(),
+				}
+			}
+			j.target.IPs = ips
+		}
+		if err := r.exclude.CheckTarget(j.target.FQDN, j.target.IPs); err != nil {
+			return models.ProbeResult{
+				FQDN:      j.target.FQDN,
+				Probe:     j.probe.Name(),
+				Status:    models.StatusExcluded,
+				Error:     err.Error(),
+				Timestamp: time.Now(),
+			}
+		}
+	}
+
+	if limited, ok := j.probe.(Limited); ok {
+		if err := limited.Limiter().Wait(ctx); err != nil {
+			return models.ProbeResult{
+				FQDN:      j.target.FQDN,
+				Probe:     j.probe.Name(),
+				Status:    models.StatusTimeout,
+				Error:     fmt.Sprintf("rate limit wait aborted: %v", err),
+				Timestamp: time.Now(),
+			}
+		}
+	}
+
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+
+	result := j.probe.Probe(ctx, j.target)
+	result.FQDN = j.target.FQDN
+	result.Probe = j.probe.Name()
+	if result.Status == "" {
+		if result.Success {
+			result.Status = models.StatusOK
+		} else if ctx.Err() != nil {
+			result.Status = models.StatusTimeout
+		} else {
+			result.Status = models.StatusNetworkError
+		}
+	}
+	result.Timestamp = time.Now()
+
+	if !result.Success {
+		slog.Debug("probe failed", "probe", result.Probe, "fqdn", result.FQDN, "status", result.Status, "error", result.Error)
+	}
+	return result
+}
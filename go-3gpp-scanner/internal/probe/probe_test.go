@@ -0,0 +1,213 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/audit"
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+)
+
+// TestMain doubles as the plugin program for the ExecPlugin tests
+func TestMain(m *testing.M) {
+	if os.Getenv("PROBE_TEST_PLUGIN") == "1" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin answers ims targets; "slow" targets reply late
+func runTestPlugin() {
+	fmt.Println(`{"name":"echo","subdomains":["ims"]}`)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req pluginRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(1)
+		}
+		if strings.HasPrefix(req.Target.FQDN, "slow") {
+			time.Sleep(300 * time.Millisecond)
+		}
+		reply, _ := json.Marshal(pluginReply{
+			ID:        req.ID,
+			Success:   true,
+			IP:        "192.0.2.1",
+			LatencyMs: 1.5,
+			Details:   map[string]string{"operator": req.Target.Operator},
+		})
+		fmt.Println(string(reply))
+	}
+}
+
+type fakeProbe struct {
+	subdomain string
+	fail      bool
+}
+
+func (f *fakeProbe) Name() string { return "fake" }
+
+func (f *fakeProbe) Match(target models.DNSResult) bool { return target.Subdomain == f.subdomain }
+
+func (f *fakeProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	if f.fail {
+		<-ctx.Done()
+		return models.ProbeResult{Error: "no reply"}
+	}
+	return models.ProbeResult{Success: true, IP: target.IPs[0]}
+}
+
+func TestRegistry(t *testing.T) {
+	Register("test-fake", func(*models.ProbeConfig) (Probe, error) { return &fakeProbe{}, nil })
+
+	if _, err := New("test-fake", &models.ProbeConfig{}); err != nil {
+		t.Errorf("Expected registered probe, got %v", err)
+	}
+	if _, err := New("missing", &models.ProbeConfig{}); err == nil {
+		t.Errorf("Expected error for unknown probe")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected duplicate registration to panic")
+		}
+	}()
+	Register("test-fake", nil)
+}
+
+func TestRunner(t *testing.T) {
+	targets := []models.DNSResult{
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "ims", IPs: []string{"192.0.2.1"}},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "epdg.epc", IPs: []string{"192.0.2.2"}},
+	}
+
+	runner := NewRunner(&models.ProbeConfig{Workers: 2, Timeout: 50 * time.Millisecond}, []Probe{
+		&fakeProbe{subdomain: "ims"},
+		&fakeProbe{subdomain: "epdg.epc", fail: true},
+	})
+	if n := runner.Jobs(targets); n != 2 {
+		t.Fatalf("Expected 2 jobs, got %d", n)
+	}
+
+	results := runner.Run(context.Background(), targets)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Probe != "fake" || r.Timestamp.IsZero() {
+			t.Errorf("Expected runner to fill probe name and timestamp: %+v", r)
+		}
+		switch r.FQDN {
+		case targets[0].FQDN:
+			if !r.Success || r.Status != models.StatusOK {
+				t.Errorf("Expected success, got %+v", r)
+			}
+		case targets[1].FQDN:
+			if r.Success || r.Status != models.StatusTimeout {
+				t.Errorf("Expected timeout status, got %+v", r)
+			}
+		}
+	}
+}
+
+func TestRunnerExclude(t *testing.T) {
+	targets := []models.DNSResult{
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "ims", IPs: []string{"192.0.2.1"}},
+		{FQDN: "ims.mnc002.mcc262.pub.3gppnetwork.org", Subdomain: "ims", IPs: []string{"198.51.100.1"}},
+	}
+	list, err := exclude.Parse(strings.NewReader("198.51.100.0/24"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	runner := NewRunner(&models.ProbeConfig{Workers: 1, Timeout: time.Second}, []Probe{&fakeProbe{subdomain: "ims"}})
+	runner.SetExclude(list)
+	for _, r := range runner.Run(context.Background(), targets) {
+		want := models.StatusOK
+		if r.FQDN == targets[1].FQDN {
+			want = models.StatusExcluded
+		}
+		if r.Status != want {
+			t.Errorf("%s: expected %s, got %+v", r.FQDN, want, r)
+		}
+	}
+	if skipped := list.Skipped(); len(skipped) != 1 || skipped[0].Target != targets[1].FQDN {
+		t.Errorf("Expected one recorded skip, got %v", skipped)
+	}
+}
+
+func TestRunnerAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path, "probe")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	targets := []models.DNSResult{{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "ims", IPs: []string{"127.0.0.1"}}}
+	runner := NewRunner(&models.ProbeConfig{Workers: 1, Timeout: time.Second}, []Probe{&fakeProbe{subdomain: "ims"}})
+	runner.SetAudit(log)
+	runner.Run(context.Background(), targets)
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry audit.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", data, err)
+	}
+	if entry.Command != "probe" || entry.Probe != "fake" || entry.Target != targets[0].FQDN ||
+		entry.IP != "127.0.0.1" || entry.SourceIP != "127.0.0.1" || entry.Result != string(models.StatusOK) {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+}
+
+func TestExecPlugin(t *testing.T) {
+	os.Setenv("PROBE_TEST_PLUGIN", "1")
+	plugin, err := StartPlugin(os.Args[0])
+	os.Unsetenv("PROBE_TEST_PLUGIN")
+	if err != nil {
+		t.Fatalf("StartPlugin failed: %v", err)
+	}
+	defer plugin.Close()
+
+	if plugin.Name() != "echo" {
+		t.Errorf("Expected announced name, got %q", plugin.Name())
+	}
+	if plugin.Match(models.DNSResult{Subdomain: "bsf"}) {
+		t.Errorf("Expected plugin to skip subdomains it did not announce")
+	}
+
+	// A timed-out request must not hand its late reply to the next one
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	slow := plugin.Probe(ctx, models.DNSResult{FQDN: "slow.ims", Operator: "Slow"})
+	cancel()
+	if slow.Status != models.StatusTimeout {
+		t.Errorf("Expected timeout, got %+v", slow)
+	}
+
+	result := plugin.Probe(context.Background(), models.DNSResult{FQDN: "ims.example", Operator: "Telekom"})
+	if !result.Success || result.IP != "192.0.2.1" || result.Details["operator"] != "Telekom" {
+		t.Errorf("Unexpected plugin result: %+v", result)
+	}
+	if result.Latency != 1500*time.Microsecond {
+		t.Errorf("Expected 1.5 ms latency, got %v", result.Latency)
+	}
+}
+
+func TestStartPluginMissing(t *testing.T) {
+	if _, err := StartPlugin("/nonexistent/plugin"); err == nil {
+		t.Errorf("Expected error for missing plugin program")
+	}
+}
@@ -0,0 +1,267 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+)
+
+func init() {
+	Register("sip", newSIPProbe)
+}
+
+// sipTransport is one way of reaching a SIP server
+type sipTransport struct {
+	name string // "udp", "tcp" or "tls"
+	port int
+}
+
+// defaultSIPTransports are tried in order for every IMS host
+var defaultSIPTransports = []sipTransport{
+	{name: "udp", port: 5060},
+	{name: "tcp", port: 5060},
+	{name: "tls", port: 5061},
+}
+
+// sipProbe sends SIP OPTIONS to IMS hosts over UDP, TCP and TLS. The
+// response code per transport and the Server / User-Agent headers of the
+// first response identify the IMS core and its vendor.
+type sipProbe struct {
+	config     *models.ProbeConfig
+	binding    netbind.Binding
+	transports []sipTransport
+}
+
+// sipResponse is the parsed head of a SIP response
+type sipResponse struct {
+	code    int
+	reason  string
+	headers map[string]string // Lowercase names, compact forms expanded
+}
+
+// sipCompactHeaders maps RFC 3261 compact header forms to full names
+var sipCompactHeaders = map[string]string{
+	"l": "content-length",
+	"i": "call-id",
+	"v": "via",
+	"f": "from",
+	"t": "to",
+	"m": "contact",
+}
+
+func newSIPProbe(config *models.ProbeConfig) (Probe, error) {
+	return &sipProbe{
+		config:     config,
+		binding:    netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		transports: defaultSIPTransports,
+	}, nil
+}
+
+// Name implements Probe
+func (p *sipProbe) Name() string {
+	return "sip"
+}
+
+// Match applies the probe to ims and xcap FQDNs
+func (p *sipProbe) Match(target models.DNSResult) bool {
+	label, _, _ := strings.Cut(target.Subdomain, ".")
+	return label == "ims" || label == "xcap"
+}
+
+// Probe implements Probe. Every transport is tried; the probe succeeds
+// when any of them returns a final SIP response, whatever its code.
+func (p *sipProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+
+	result := models.ProbeResult{IP: ip, Details: make(map[string]string)}
+	var failures []string
+	for i, t := range p.transports {
+		deadline := deadlineShare(ctx, len(p.transports)-i, p.config.Timeout)
+		start := time.Now()
+		resp, err := p.options(ctx, t, ip, target.FQDN, deadline)
+		if err != nil {
+			result.Details[t.name] = string(ping.ConnStatus(err))
+			failures = append(failures, fmt.Sprintf("%s: %v", t.name, err))
+			if !result.Success {
+				result.Status = ping.ConnStatus(err)
+			}
+			continue
+		}
+
+		result.Details[t.name] = strings.TrimSpace(fmt.Sprintf("%d %s", resp.code, resp.reason))
+		if !result.Success {
+			result.Success = true
+			result.Status = models.StatusOK
+			result.Latency = time.Since(start)
+			for _, h := range []string{"server", "user-agent", "allow"} {
+				if v := resp.headers[h]; v != "" {
+					result.Details[strings.ReplaceAll(h, "-", "_")] = v
+				}
+			}
+		}
+	}
+
+	if !result.Success {
+		result.Error = strings.Join(failures, "; ")
+	}
+	return result
+}
+
+// options sends one OPTIONS request over a transport and waits for the
+// final response, skipping provisional (1xx) ones
+func (p *sipProbe) options(ctx context.Context, t sipTransport, ip, fqdn string, deadline time.Time) (*sipResponse, error) {
+	address := net.JoinHostPort(ip, strconv.Itoa(t.port))
+	timeout := time.Until(deadline)
+
+	var conn net.Conn
+	var err error
+	if t.name == "udp" {
+		dialer, derr := p.binding.UDPDialer(timeout, netbind.IsIPv6Address(ip))
+		if derr != nil {
+			return nil, derr
+		}
+		conn, err = dialer.DialContext(ctx, "udp", address)
+	} else {
+		dialer, derr := p.binding.TCPDialer(timeout)
+		if derr != nil {
+			return nil, derr
+		}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if t.name == "tls" {
+		// SIP over TLS needs only a session here, not a trusted server
+		tlsConn := ping.ClientTLS(conn, fqdn)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	if _, err := conn.Write(buildSIPOptions(t.name, fqdn, conn.LocalAddr().String())); err != nil {
+		return nil, err
+	}
+
+	if t.name == "udp" {
+		buf := make([]byte, 65535)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := readSIPResponse(bufio.NewReader(bytes.NewReader(buf[:n])))
+			if err != nil {
+				return nil, err
+			}
+			if resp.code >= 200 {
+				return resp, nil
+			}
+		}
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		resp, err := readSIPResponse(reader)
+		if err != nil {
+			return nil, err
+		}
+		if resp.code >= 200 {
+			return resp, nil
+		}
+		// Skip the body of a provisional response on a stream
+		if n, _ := strconv.Atoi(resp.headers["content-length"]); n > 0 {
+			if _, err := io.CopyN(io.Discard, reader, int64(n)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// buildSIPOptions builds an RFC 3261 OPTIONS request from the local address
+func buildSIPOptions(transport, fqdn, local string) []byte {
+	host, port, err := net.SplitHostPort(local)
+	if err != nil {
+		host, port = local, "5060"
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	tag := randomToken(4)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "OPTIONS sip:%s SIP/2.0\r\n", fqdn)
+	fmt.Fprintf(&b, "Via: SIP/2.0/%s %s:%s;branch=z9hG4bK%s;rport\r\n", strings.ToUpper(transport), host, port, randomToken(8))
+	b.WriteString("Max-Forwards: 70\r\n")
+	fmt.Fprintf(&b, "From: <sip:probe@%s>;tag=%s\r\n", host, tag)
+	fmt.Fprintf(&b, "To: <sip:%s>\r\n", fqdn)
+	fmt.Fprintf(&b, "Call-ID: %s@%s\r\n", randomToken(8), host)
+	b.WriteString("CSeq: 1 OPTIONS\r\n")
+	fmt.Fprintf(&b, "Contact: <sip:probe@%s:%s;transport=%s>\r\n", host, port, transport)
+	b.WriteString("Accept: application/sdp\r\n")
+	b.WriteString("User-Agent: 3gpp-scanner\r\n")
+	b.WriteString("Content-Length: 0\r\n\r\n")
+	return []byte(b.String())
+}
+
+// readSIPResponse reads a status line and headers up to the blank line
+func readSIPResponse(r *bufio.Reader) (*sipResponse, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "SIP/") {
+		return nil, fmt.Errorf("not a SIP response: %q", strings.TrimSpace(line))
+	}
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIP status code: %q", fields[1])
+	}
+
+	resp := &sipResponse{code: code, headers: make(map[string]string)}
+	if len(fields) == 3 {
+		resp.reason = fields[2]
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			// A datagram may end without the final blank line
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if full, ok := sipCompactHeaders[name]; ok {
+				name = full
+			}
+			if _, seen := resp.headers[name]; !seen {
+				resp.headers[name] = strings.TrimSpace(value)
+			}
+		}
+		if err != nil {
+			return resp, nil
+		}
+	}
+}
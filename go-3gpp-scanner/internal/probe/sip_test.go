@@ -0,0 +1,138 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// sipReply answers an OPTIONS request, echoing its Via and Call-ID
+func sipReply(request, status, extra string) string {
+	var via, callID string
+	for _, line := range strings.Split(request, "\r\n") {
+		if strings.HasPrefix(line, "Via:") {
+			via = line
+		}
+		if strings.HasPrefix(line, "Call-ID:") {
+			callID = line
+		}
+	}
+	return "SIP/2.0 " + status + "\r\n" + via + "\r\n" + callID + "\r\n" + extra + "Content-Length: 0\r\n\r\n"
+}
+
+func TestSIPProbe(t *testing.T) {
+	// UDP server: provisional response first, then the final one
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	defer udp.Close()
+	go func() {
+		buf := make([]byte, 4096)
+		n, addr, err := udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		req := string(buf[:n])
+		if !strings.HasPrefix(req, "OPTIONS sip:ims.mnc001.mcc262.pub.3gppnetwork.org SIP/2.0") {
+			return
+		}
+		udp.WriteTo([]byte(sipReply(req, "100 Trying", "")), addr)
+		udp.WriteTo([]byte(sipReply(req, "200 OK", "Server: Acme-IMS/4.2\r\nAllow: INVITE, OPTIONS\r\n")), addr)
+	}()
+
+	// TCP server: rejects the request with a final response
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen tcp: %v", err)
+	}
+	defer tcp.Close()
+	go func() {
+		conn, err := tcp.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var req strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			req.WriteString(line)
+			if line == "\r\n" {
+				break
+			}
+		}
+		conn.Write([]byte(sipReply(req.String(), "403 Forbidden", "User-Agent: Edge-SBC\r\n")))
+	}()
+
+	p := &sipProbe{
+		config: &models.ProbeConfig{Timeout: time.Second},
+		transports: []sipTransport{
+			{name: "udp", port: udp.LocalAddr().(*net.UDPAddr).Port},
+			{name: "tcp", port: tcp.Addr().(*net.TCPAddr).Port},
+			{name: "tls", port: closedPort(t)},
+		},
+	}
+
+	target := models.DNSResult{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "ims", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || p.Match(models.DNSResult{Subdomain: "epdg.epc"}) {
+		t.Errorf("Expected SIP probe to apply to ims only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+
+	if !result.Success || result.Status != models.StatusOK {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+	want := map[string]string{
+		"udp":    "200 OK",
+		"tcp":    "403 Forbidden",
+		"tls":    "REFUSED",
+		"server": "Acme-IMS/4.2",
+		"allow":  "INVITE, OPTIONS",
+	}
+	for k, v := range want {
+		if result.Details[k] != v {
+			t.Errorf("Details[%s] = %q, want %q", k, result.Details[k], v)
+		}
+	}
+	if _, ok := result.Details["user_agent"]; ok {
+		t.Errorf("Expected headers from the first response only")
+	}
+}
+
+func TestReadSIPResponseCompactHeaders(t *testing.T) {
+	raw := "SIP/2.0 486 Busy Here\r\nl: 0\r\nServer: X\r\n\r\n"
+	resp, err := readSIPResponse(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("readSIPResponse failed: %v", err)
+	}
+	if resp.code != 486 || resp.reason != "Busy Here" || resp.headers["content-length"] != "0" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	if _, err := readSIPResponse(bufio.NewReader(strings.NewReader("HTTP/1.1 200 OK\r\n\r\n"))); err == nil {
+		t.Errorf("Expected error for non-SIP response")
+	}
+}
+
+// closedPort returns a local TCP port with no listener
+func closedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
@@ -0,0 +1,290 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+)
+
+func init() {
+	Register("stun", newSTUNProbe)
+}
+
+// STUN constants (RFC 5389, RFC 5766 for TURN, RFC 5780 for NAT discovery)
+const (
+	stunPort        = 3478
+	stunMagicCookie = 0x2112A442
+	stunHeaderLen   = 20
+
+	stunBindingRequest  = 0x0001
+	stunBindingSuccess  = 0x0101
+	stunAllocateRequest = 0x0003
+	stunAllocateSuccess = 0x0103
+	stunAllocateError   = 0x0113
+
+	stunAttrMappedAddress      = 0x0001
+	stunAttrErrorCode          = 0x0009
+	stunAttrRealm              = 0x0014
+	stunAttrRequestedTransport = 0x0019
+	stunAttrXORMappedAddress   = 0x0020
+	stunAttrSoftware           = 0x8022
+	stunAttrResponseOrigin     = 0x802B
+	stunAttrOtherAddress       = 0x802C
+
+	protocolUDP = 17
+)
+
+// stunProbe sends a STUN Binding request to UDP 3478 and records the
+// reflexive (mapped) address the server saw, which reveals NAT between
+// scanner and operator. An unauthenticated TURN Allocate then shows
+// whether the server also relays: TURN servers demand credentials.
+type stunProbe struct {
+	config  *models.ProbeConfig
+	binding netbind.Binding
+	port    int
+}
+
+func newSTUNProbe(config *models.ProbeConfig) (Probe, error) {
+	return &stunProbe{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		port:    stunPort,
+	}, nil
+}
+
+// Name implements Probe
+func (p *stunProbe) Name() string {
+	return "stun"
+}
+
+// Match applies the probe to ePDG hosts, alongside which operators
+// expose STUN/TURN, and to FQDNs named after STUN or TURN
+func (p *stunProbe) Match(target models.DNSResult) bool {
+	for _, label := range strings.Split(target.Subdomain, ".") {
+		switch label {
+		case "epdg", "stun", "turn":
+			return true
+		}
+	}
+	return false
+}
+
+// Probe implements Probe. Success means a Binding success response.
+func (p *stunProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+	result := models.ProbeResult{IP: ip}
+
+	dialer, err := p.binding.UDPDialer(p.config.Timeout, netbind.IsIPv6Address(ip))
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(p.port)))
+	if err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	resp, err := stunTransaction(conn, stunBindingRequest, nil, deadlineShare(ctx, 2, p.config.Timeout))
+	if err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = fmt.Sprintf("binding request failed: %v", err)
+		return result
+	}
+	if resp.typ != stunBindingSuccess {
+		result.Status = models.StatusRefused
+		result.Error = fmt.Sprintf("binding rejected (message type 0x%04x)", resp.typ)
+		return result
+	}
+	result.Success = true
+	result.Status = models.StatusOK
+	result.Latency = time.Since(start)
+	result.Details = stunDetails(resp)
+
+	// TURN: an Allocate without credentials is answered with 401
+	transport := make([]byte, 4)
+	transport[0] = protocolUDP
+	resp, err = stunTransaction(conn, stunAllocateRequest,
+		[]stunAttr{{typ: stunAttrRequestedTransport, value: transport}}, deadlineShare(ctx, 1, p.config.Timeout))
+	switch {
+	case err != nil:
+		result.Details["turn"] = "no"
+	case resp.typ == stunAllocateSuccess:
+		result.Details["turn"] = "open relay"
+	case resp.typ == stunAllocateError:
+		result.Details["turn"] = "yes"
+		if code, ok := resp.attrs[stunAttrErrorCode]; ok && len(code) >= 4 {
+			result.Details["turn_error"] = strconv.Itoa(int(code[2]&0x07)*100 + int(code[3]))
+		}
+		if realm, ok := resp.attrs[stunAttrRealm]; ok {
+			result.Details["turn_realm"] = string(realm)
+		}
+	default:
+		result.Details["turn"] = "no"
+	}
+
+	return result
+}
+
+// stunAttr is one type-length-value attribute
+type stunAttr struct {
+	typ   uint16
+	value []byte
+}
+
+// stunMessage is a parsed STUN response
+type stunMessage struct {
+	typ   uint16
+	txID  []byte
+	attrs map[uint16][]byte // First occurrence of each attribute type
+}
+
+// stunTransaction sends a request and waits for the response carrying
+// the same transaction ID
+func stunTransaction(conn net.Conn, msgType uint16, attrs []stunAttr, deadline time.Time) (*stunMessage, error) {
+	txID := make([]byte, 12)
+	if _, err := rand.Read(txID); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	for _, a := range attrs {
+		attr := make([]byte, 4, 4+len(a.value)+3)
+		binary.BigEndian.PutUint16(attr[0:2], a.typ)
+		binary.BigEndian.PutUint16(attr[2:4], uint16(len(a.value)))
+		attr = append(attr, a.value...)
+		for len(attr)%4 != 0 {
+			attr = append(attr, 0)
+		}
+		body = append(body, attr...)
+	}
+
+	msg := make([]byte, stunHeaderLen, stunHeaderLen+len(body))
+	binary.BigEndian.PutUint16(msg[0:2], msgType)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(body)))
+	binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+	copy(msg[8:20], txID)
+	msg = append(msg, body...)
+
+	conn.SetDeadline(deadline)
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp := buf[:n]
+		if n < stunHeaderLen || binary.BigEndian.Uint32(resp[4:8]) != stunMagicCookie || !bytes.Equal(resp[8:20], txID) {
+			continue
+		}
+		return &stunMessage{typ: binary.BigEndian.Uint16(resp[0:2]), txID: txID, attrs: parseSTUNAttrs(resp)}, nil
+	}
+}
+
+// parseSTUNAttrs splits the attributes of a STUN message by type,
+// keeping the first occurrence of each
+func parseSTUNAttrs(msg []byte) map[uint16][]byte {
+	attrs := make(map[uint16][]byte)
+	end := stunHeaderLen + int(binary.BigEndian.Uint16(msg[2:4]))
+	if end > len(msg) {
+		end = len(msg)
+	}
+	for i := stunHeaderLen; i+4 <= end; {
+		typ := binary.BigEndian.Uint16(msg[i : i+2])
+		length := int(binary.BigEndian.Uint16(msg[i+2 : i+4]))
+		if i+4+length > end {
+			break
+		}
+		if _, seen := attrs[typ]; !seen {
+			attrs[typ] = msg[i+4 : i+4+length]
+		}
+		i += 4 + (length+3)/4*4
+	}
+	return attrs
+}
+
+// stunDetails extracts the interesting attributes of a Binding response
+func stunDetails(resp *stunMessage) map[string]string {
+	details := make(map[string]string)
+
+	// XOR addresses are masked with the magic cookie and transaction ID
+	xorKey := make([]byte, 16)
+	binary.BigEndian.PutUint32(xorKey[0:4], stunMagicCookie)
+	copy(xorKey[4:], resp.txID)
+
+	if v, ok := resp.attrs[stunAttrXORMappedAddress]; ok {
+		if addr := stunAddress(v, xorKey); addr != "" {
+			details["mapped_address"] = addr
+		}
+	} else if v, ok := resp.attrs[stunAttrMappedAddress]; ok {
+		if addr := stunAddress(v, nil); addr != "" {
+			details["mapped_address"] = addr
+		}
+	}
+	if v, ok := resp.attrs[stunAttrOtherAddress]; ok {
+		if addr := stunAddress(v, nil); addr != "" {
+			details["other_address"] = addr
+		}
+	}
+	if v, ok := resp.attrs[stunAttrResponseOrigin]; ok {
+		if addr := stunAddress(v, nil); addr != "" {
+			details["response_origin"] = addr
+		}
+	}
+	if v, ok := resp.attrs[stunAttrSoftware]; ok {
+		details["software"] = strings.TrimRight(string(v), "\x00")
+	}
+	return details
+}
+
+// stunAddress decodes a MAPPED-ADDRESS style attribute, unmasking it
+// with xorKey for XOR-MAPPED-ADDRESS
+func stunAddress(v []byte, xorKey []byte) string {
+	if len(v) < 8 {
+		return ""
+	}
+	var ip net.IP
+	switch v[1] {
+	case 0x01: // IPv4
+		ip = make(net.IP, 4)
+		copy(ip, v[4:8])
+	case 0x02: // IPv6
+		if len(v) < 20 {
+			return ""
+		}
+		ip = make(net.IP, 16)
+		copy(ip, v[4:20])
+	default:
+		return ""
+	}
+
+	port := binary.BigEndian.Uint16(v[2:4])
+	if xorKey != nil {
+		port ^= binary.BigEndian.Uint16(xorKey[0:2])
+		for i := range ip {
+			ip[i] ^= xorKey[i]
+		}
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+}
@@ -0,0 +1,115 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// stunResponse builds a response to req with the given attributes
+func stunResponse(req []byte, msgType uint16, attrs []stunAttr) []byte {
+	var body []byte
+	for _, a := range attrs {
+		attr := make([]byte, 4)
+		binary.BigEndian.PutUint16(attr[0:2], a.typ)
+		binary.BigEndian.PutUint16(attr[2:4], uint16(len(a.value)))
+		attr = append(attr, a.value...)
+		for len(attr)%4 != 0 {
+			attr = append(attr, 0)
+		}
+		body = append(body, attr...)
+	}
+	msg := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(msg[0:2], msgType)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(body)))
+	copy(msg[4:20], req[4:20])
+	return append(msg, body...)
+}
+
+// xorAddress encodes an IPv4 UDP address as XOR-MAPPED-ADDRESS
+func xorAddress(addr *net.UDPAddr) []byte {
+	v := make([]byte, 8)
+	v[1] = 0x01
+	binary.BigEndian.PutUint16(v[2:4], uint16(addr.Port)^uint16(stunMagicCookie>>16))
+	binary.BigEndian.PutUint32(v[4:8], binary.BigEndian.Uint32(addr.IP.To4())^stunMagicCookie)
+	return v
+}
+
+func TestSTUNProbe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			switch binary.BigEndian.Uint16(req[0:2]) {
+			case stunBindingRequest:
+				conn.WriteTo(stunResponse(req, stunBindingSuccess, []stunAttr{
+					{typ: stunAttrXORMappedAddress, value: xorAddress(addr.(*net.UDPAddr))},
+					{typ: stunAttrSoftware, value: []byte("AcmeTURN 1.0")},
+				}), addr)
+			case stunAllocateRequest:
+				conn.WriteTo(stunResponse(req, stunAllocateError, []stunAttr{
+					{typ: stunAttrErrorCode, value: []byte{0, 0, 4, 1, 'U', 'n', 'a', 'u', 't', 'h'}},
+					{typ: stunAttrRealm, value: []byte("ims.example")},
+				}), addr)
+			}
+		}
+	}()
+
+	p := &stunProbe{config: &models.ProbeConfig{Timeout: time.Second}, port: conn.LocalAddr().(*net.UDPAddr).Port}
+	target := models.DNSResult{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "epdg.epc", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || p.Match(models.DNSResult{Subdomain: "bsf"}) {
+		t.Errorf("Expected STUN probe to apply to ePDG hosts only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+	if !result.Success {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+
+	host, _, err := net.SplitHostPort(result.Details["mapped_address"])
+	if err != nil || host != "127.0.0.1" {
+		t.Errorf("Expected reflexive address 127.0.0.1:port, got %q", result.Details["mapped_address"])
+	}
+	want := map[string]string{"software": "AcmeTURN 1.0", "turn": "yes", "turn_error": "401", "turn_realm": "ims.example"}
+	for k, v := range want {
+		if result.Details[k] != v {
+			t.Errorf("Details[%s] = %q, want %q", k, result.Details[k], v)
+		}
+	}
+}
+
+func TestSTUNAddressIPv6XOR(t *testing.T) {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+	for i := 4; i < 16; i++ {
+		key[i] = byte(i)
+	}
+
+	ip := net.ParseIP("2001:db8::1")
+	v := make([]byte, 20)
+	v[1] = 0x02
+	binary.BigEndian.PutUint16(v[2:4], 3478^binary.BigEndian.Uint16(key[0:2]))
+	for i := 0; i < 16; i++ {
+		v[4+i] = ip[i] ^ key[i]
+	}
+
+	if got := stunAddress(v, key); got != "[2001:db8::1]:3478" {
+		t.Errorf("stunAddress = %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/netbind"
+	"3gpp-scanner/internal/ping"
+)
+
+func init() {
+	Register("tls", newTLSProbe)
+}
+
+// tlsPort is the HTTPS port of XCAP, BSF and entitlement servers
+const tlsPort = 443
+
+// tlsProbe completes a TLS handshake with HTTPS hosts and records the leaf
+// certificate, so its expiry can be watched (see alerts cert-expiry)
+type tlsProbe struct {
+	config  *models.ProbeConfig
+	binding netbind.Binding
+	port    int
+}
+
+func newTLSProbe(config *models.ProbeConfig) (Probe, error) {
+	return &tlsProbe{
+		config:  config,
+		binding: netbind.Binding{SourceIP: config.SourceIP, Interface: config.Interface},
+		port:    tlsPort,
+	}, nil
+}
+
+// Name implements Probe
+func (p *tlsProbe) Name() string {
+	return "tls"
+}
+
+// Match applies the probe to the HTTPS services: xcap (Ut), bsf (GBA)
+// and aes (entitlement) FQDNs
+func (p *tlsProbe) Match(target models.DNSResult) bool {
+	label, _, _ := strings.Cut(target.Subdomain, ".")
+	return label == "xcap" || label == "bsf" || label == "aes"
+}
+
+// Probe implements Probe. Success means a completed handshake; any
+// certificate is accepted, as it is recorded rather than trusted.
+func (p *tlsProbe) Probe(ctx context.Context, target models.DNSResult) models.ProbeResult {
+	ip, err := targetIP(ctx, target)
+	if err != nil {
+		return models.ProbeResult{Status: ping.LookupStatus(err), Error: fmt.Sprintf("DNS lookup failed: %v", err)}
+	}
+	result := models.ProbeResult{IP: ip}
+
+	dialer, err := p.binding.TCPDialer(p.config.Timeout)
+	if err != nil {
+		result.Status = models.StatusNetworkError
+		result.Error = fmt.Sprintf("source binding failed: %v", err)
+		return result
+	}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(p.port)))
+	if err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	tlsConn := ping.ClientTLS(conn, target.FQDN)
+	tlsConn.SetDeadline(deadlineShare(ctx, 1, p.config.Timeout))
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		result.Status = ping.ConnStatus(err)
+		result.Error = fmt.Sprintf("TLS handshake failed: %v", err)
+		return result
+	}
+	result.Latency = time.Since(start)
+	result.Success = true
+	result.Status = models.StatusOK
+	result.Details = tlsDetails(tlsConn.ConnectionState(), target.FQDN)
+	return result
+}
+
+// tlsDetails describes the negotiated version and the leaf certificate.
+// not_after is RFC 3339 UTC, as alerts cert-expiry reads it.
+func tlsDetails(state tls.ConnectionState, fqdn string) map[string]string {
+	details := map[string]string{"tls_version": tls.VersionName(state.Version)}
+	if len(state.PeerCertificates) == 0 {
+		return details
+	}
+	for key, value := range CertDetails(state.PeerCertificates[0], fqdn) {
+		details[key] = value
+	}
+	return details
+}
+
+// CertDetails describes a leaf certificate the way the tls probe reports
+// it, for results of other scanners to match
+func CertDetails(cert *x509.Certificate, fqdn string) map[string]string {
+	details := map[string]string{
+		"subject":        cert.Subject.String(),
+		"issuer":         cert.Issuer.String(),
+		"not_before":     cert.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":      cert.NotAfter.UTC().Format(time.RFC3339),
+		"hostname_match": strconv.FormatBool(cert.VerifyHostname(fqdn) == nil),
+	}
+	if len(cert.DNSNames) > 0 {
+		details["sans"] = strings.Join(cert.DNSNames, ";")
+	}
+	return details
+}
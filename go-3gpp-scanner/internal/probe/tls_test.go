@@ -0,0 +1,42 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestTLSProbe(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	p := &tlsProbe{config: &models.ProbeConfig{Timeout: time.Second}, port: server.Listener.Addr().(*net.TCPAddr).Port}
+	target := models.DNSResult{FQDN: "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org", Subdomain: "xcap.ims", IPs: []string{"127.0.0.1"}}
+	if !p.Match(target) || !p.Match(models.DNSResult{Subdomain: "bsf"}) || p.Match(models.DNSResult{Subdomain: "epdg.epc"}) {
+		t.Errorf("Expected TLS probe to apply to xcap, bsf and aes hosts only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := p.Probe(ctx, target)
+	if !result.Success {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+	notAfter, err := time.Parse(time.RFC3339, result.Details["not_after"])
+	if err != nil || !notAfter.Equal(server.Certificate().NotAfter.Truncate(time.Second)) {
+		t.Errorf("Unexpected not_after %q (certificate: %v)", result.Details["not_after"], server.Certificate().NotAfter)
+	}
+	if result.Details["hostname_match"] != "false" || result.Details["tls_version"] == "" || result.Details["sans"] != "example.com;*.example.com" {
+		t.Errorf("Unexpected details: %v", result.Details)
+	}
+
+	server.Close()
+	if result := p.Probe(ctx, target); result.Success || result.Status != models.StatusRefused {
+		t.Errorf("Expected refused connection, got %+v", result)
+	}
+}
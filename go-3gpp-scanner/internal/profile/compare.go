@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+)
+
+// Compare summarizes one operator for a side-by-side comparison. FQDNs
+// come from the database; scan results of the same operator add FQDNs
+// and IPs, and pings of any of its FQDNs count towards reachability.
+func Compare(operator string, fqdns []string, results []models.DNSResult, pings []models.PingResult) models.OperatorComparison {
+	c := models.OperatorComparison{
+		Operator: operator,
+		Services: make(map[string]int),
+	}
+
+	fqdnSet := make(map[string]bool)
+	addFQDN := func(fqdn string) {
+		fqdn = dns.NormalizeFQDN(fqdn)
+		if fqdn == "" || fqdnSet[fqdn] {
+			return
+		}
+		fqdnSet[fqdn] = true
+		c.Services[serviceType(fqdn)]++
+	}
+	for _, fqdn := range fqdns {
+		addFQDN(fqdn)
+	}
+
+	ipSet := make(map[string]bool)
+	for _, r := range results {
+		if r.Operator != operator {
+			continue
+		}
+		addFQDN(r.FQDN)
+		for _, ip := range r.IPs {
+			ipSet[ip] = true
+		}
+	}
+
+	probed := make(map[string]bool)
+	reachable := make(map[string]bool)
+	for _, ping := range pings {
+		fqdn := dns.NormalizeFQDN(ping.FQDN)
+		if !fqdnSet[fqdn] {
+			continue
+		}
+		probed[fqdn] = true
+		if ping.Success {
+			reachable[fqdn] = true
+		}
+	}
+
+	c.TotalFQDNs = len(fqdnSet)
+	c.IPs = len(ipSet)
+	c.Probed = len(probed)
+	c.Reachable = len(reachable)
+	return c
+}
+
+// FormatComparison renders operators side by side, one column each. IP
+// and reachability rows read "-" when no scan or ping export was given.
+func FormatComparison(rows []models.OperatorComparison, haveResults, havePings bool) string {
+	serviceSet := make(map[string]bool)
+	for _, row := range rows {
+		for s := range row.Services {
+			serviceSet[s] = true
+		}
+	}
+	services := make([]string, 0, len(serviceSet))
+	for s := range serviceSet {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+
+	table := [][]string{{""}}
+	for _, row := range rows {
+		table[0] = append(table[0], row.Operator)
+	}
+	addRow := func(label string, cell func(models.OperatorComparison) string) {
+		line := []string{label}
+		for _, row := range rows {
+			line = append(line, cell(row))
+		}
+		table = append(table, line)
+	}
+
+	addRow("FQDNs", func(r models.OperatorComparison) string { return fmt.Sprintf("%d", r.TotalFQDNs) })
+	for _, s := range services {
+		s := s
+		addRow("  "+s, func(r models.OperatorComparison) string { return fmt.Sprintf("%d", r.Services[s]) })
+	}
+	addRow("IPs", func(r models.OperatorComparison) string {
+		if !haveResults {
+			return "-"
+		}
+		return fmt.Sprintf("%d", r.IPs)
+	})
+	addRow("Reachable", func(r models.OperatorComparison) string {
+		if !havePings {
+			return "-"
+		}
+		return fmt.Sprintf("%d/%d", r.Reachable, r.Probed)
+	})
+	addRow("ASNs", func(models.OperatorComparison) string { return "n/a" })
+
+	widths := make([]int, len(table[0]))
+	for _, line := range table {
+		for i, cell := range line {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== Operator Comparison ===\n\n")
+	for _, line := range table {
+		for i, cell := range line {
+			if i == 0 {
+				sb.WriteString(fmt.Sprintf("%-*s", widths[i], cell))
+			} else {
+				sb.WriteString(fmt.Sprintf("  %*s", widths[i], cell))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nASNs are not collected by the scanner.\n")
+	if !haveResults {
+		sb.WriteString("IP counts need a JSON scan export.\n")
+	}
+	if !havePings {
+		sb.WriteString("Reachability needs a JSON ping export.\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestCompare(t *testing.T) {
+	src := testSources()
+	row := Compare("Telekom", src.FQDNs, src.Results, append(src.Pings,
+		models.PingResult{FQDN: "bsf.mnc001.mcc262.pub.3gppnetwork.org", Success: false},
+	))
+
+	if row.TotalFQDNs != 3 || row.Services["epdg"] != 1 || row.Services["bsf"] != 1 {
+		t.Errorf("Unexpected services: %d %v", row.TotalFQDNs, row.Services)
+	}
+	if row.IPs != 2 {
+		t.Errorf("Expected 2 IPs, got %d", row.IPs)
+	}
+	if row.Probed != 2 || row.Reachable != 1 {
+		t.Errorf("Expected 1/2 reachable, got %d/%d", row.Reachable, row.Probed)
+	}
+}
+
+func TestFormatComparison(t *testing.T) {
+	src := testSources()
+	rows := []models.OperatorComparison{
+		Compare("Telekom", src.FQDNs, src.Results, src.Pings),
+		Compare("Vodafone", nil, src.Results, src.Pings),
+	}
+
+	formatted := FormatComparison(rows, true, false)
+	lines := strings.Split(formatted, "\n")
+	var header, epdg, reach string
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "Telekom"):
+			header = line
+		case strings.HasPrefix(line, "  epdg"):
+			epdg = line
+		case strings.HasPrefix(line, "Reachable"):
+			reach = line
+		}
+	}
+	if !strings.Contains(header, "Vodafone") {
+		t.Errorf("Expected both operators in header, got:\n%s", formatted)
+	}
+	if len(strings.Fields(epdg)) != 3 {
+		t.Errorf("Expected one epdg count per operator, got %q", epdg)
+	}
+	if !strings.Contains(reach, "-") || !strings.Contains(formatted, "Reachability needs a JSON ping export") {
+		t.Errorf("Expected reachability to be marked unavailable, got:\n%s", formatted)
+	}
+}
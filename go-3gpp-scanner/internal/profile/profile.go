@@ -0,0 +1,383 @@
+package profile
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/models"
+)
+
+// Sections the scanner does not collect; listed in every profile so a
+// reader knows their absence is not a negative finding
+var notCollected = []string{
+	"ASNs: not collected by the scanner",
+	"Certificates: not collected by the scanner",
+}
+
+// Sources is the data a profile is assembled from. Operators, FQDNs,
+// Notes and History come from the database; Results and Pings are
+// optional JSON exports and nil when not supplied.
+type Sources struct {
+	Operators []string
+	FQDNs     []string
+	Results   []models.DNSResult
+	Pings     []models.PingResult
+	Notes     []models.Note
+	History   []models.IPResolution
+	Country   string
+}
+
+// Zone returns the operator zone label pair, e.g. "mnc001.mcc262"
+func Zone(mcc, mnc string) string {
+	return "mnc" + models.PadMNC(mnc) + ".mcc" + models.NormalizeMCC(mcc)
+}
+
+// Build aggregates the sources into a profile of one MCC/MNC. Scan
+// results of other operators, including those sharing the zone with a
+// different MNC width, and pings outside the zone are ignored.
+func Build(mcc, mnc string, src Sources) *models.OperatorProfile {
+	mcc, mnc = models.NormalizeMCC(mcc), models.NormalizeMNC(mnc)
+	p := &models.OperatorProfile{
+		MCC:         mcc,
+		MNC:         mnc,
+		Country:     src.Country,
+		Operators:   append([]string{}, src.Operators...),
+		FQDNsByType: make(map[string][]string),
+		IPs:         []string{},
+		Pings:       []models.PingResult{},
+	}
+
+	fqdnSet := make(map[string]bool)
+	addFQDN := func(fqdn string) {
+		fqdn = dns.NormalizeFQDN(fqdn)
+		if fqdn == "" || fqdnSet[fqdn] {
+			return
+		}
+		fqdnSet[fqdn] = true
+		label := serviceType(fqdn)
+		p.FQDNsByType[label] = append(p.FQDNsByType[label], fqdn)
+	}
+	for _, fqdn := range src.FQDNs {
+		addFQDN(fqdn)
+	}
+
+	operatorSet := make(map[string]bool)
+	for _, op := range p.Operators {
+		operatorSet[op] = true
+	}
+	ipSet := make(map[string]bool)
+	var seen []time.Time
+
+	for _, r := range src.Results {
+		if models.NormalizeMCC(r.MCC) != mcc || models.NormalizeMNC(r.MNC) != mnc {
+			continue
+		}
+		addFQDN(r.FQDN)
+		if r.Operator != "" && !operatorSet[r.Operator] {
+			operatorSet[r.Operator] = true
+			p.Operators = append(p.Operators, r.Operator)
+		}
+		for _, ip := range r.IPs {
+			if !ipSet[ip] {
+				ipSet[ip] = true
+				p.IPs = append(p.IPs, ip)
+			}
+		}
+		if !r.Timestamp.IsZero() {
+			seen = append(seen, r.Timestamp)
+		}
+	}
+
+	zone := "." + Zone(mcc, mnc) + "."
+	for _, ping := range src.Pings {
+		fqdn := dns.NormalizeFQDN(ping.FQDN)
+		if !fqdnSet[fqdn] && !strings.Contains(fqdn, zone) {
+			continue
+		}
+		p.Pings = append(p.Pings, ping)
+		if !ping.Timestamp.IsZero() {
+			seen = append(seen, ping.Timestamp)
+		}
+	}
+
+	for _, r := range src.History {
+		fqdn := dns.NormalizeFQDN(r.FQDN)
+		if fqdnSet[fqdn] || strings.Contains(fqdn, zone) {
+			p.IPHistory = append(p.IPHistory, r)
+		}
+	}
+
+	for _, note := range src.Notes {
+		if (note.Kind == "fqdn" && fqdnSet[dns.NormalizeFQDN(note.Target)]) ||
+			(note.Kind == "operator" && operatorSet[note.Target]) {
+			p.Notes = append(p.Notes, note)
+		}
+	}
+
+	for _, fqdns := range p.FQDNsByType {
+		sort.Strings(fqdns)
+	}
+	sort.Strings(p.Operators)
+	sort.Strings(p.IPs)
+	sort.SliceStable(p.Notes, func(i, j int) bool { return p.Notes[i].Created.Before(p.Notes[j].Created) })
+	sort.SliceStable(p.Pings, func(i, j int) bool {
+		if !p.Pings[i].Timestamp.Equal(p.Pings[j].Timestamp) {
+			return p.Pings[i].Timestamp.Before(p.Pings[j].Timestamp)
+		}
+		return p.Pings[i].FQDN < p.Pings[j].FQDN
+	})
+	p.TotalFQDNs = len(fqdnSet)
+
+	if len(seen) > 0 {
+		sort.Slice(seen, func(i, j int) bool { return seen[i].Before(seen[j]) })
+		first, last := seen[0], seen[len(seen)-1]
+		p.FirstSeen, p.LastSeen = &first, &last
+	}
+
+	if src.Results == nil {
+		p.Unavailable = append(p.Unavailable, "IP addresses: no JSON scan export supplied")
+	}
+	if src.Pings == nil {
+		p.Unavailable = append(p.Unavailable, "Ping history: no JSON ping export supplied")
+	}
+	if len(p.IPHistory) == 0 {
+		p.Unavailable = append(p.Unavailable, "Passive DNS history: none stored; collect it with pdns")
+	}
+	if len(seen) == 0 {
+		p.Unavailable = append(p.Unavailable, "First/last seen: the database stores no timestamps; supply a scan or ping export")
+	}
+	p.Unavailable = append(p.Unavailable, notCollected...)
+
+	return p
+}
+
+// serviceType is the first label of an FQDN (epdg, ims, bsf, ...)
+func serviceType(fqdn string) string {
+	if i := strings.Index(fqdn, "."); i > 0 {
+		return fqdn[:i]
+	}
+	return fqdn
+}
+
+// Types returns the FQDN types of a profile, most FQDNs first
+func Types(p *models.OperatorProfile) []string {
+	types := make([]string, 0, len(p.FQDNsByType))
+	for t := range p.FQDNsByType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ni, nj := len(p.FQDNsByType[types[i]]), len(p.FQDNsByType[types[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// Title is the one-line heading of a profile
+func Title(p *models.OperatorProfile) string {
+	title := fmt.Sprintf("MCC %s / MNC %s", p.MCC, p.MNC)
+	if p.Country != "" {
+		title += " (" + p.Country + ")"
+	}
+	return title
+}
+
+// FormatText renders a profile as a readable dossier
+func FormatText(p *models.OperatorProfile) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("=== Operator Profile: %s ===\n\n", Title(p)))
+	operators := "unknown"
+	if len(p.Operators) > 0 {
+		operators = strings.Join(p.Operators, ", ")
+	}
+	sb.WriteString(fmt.Sprintf("Operators: %s\n", operators))
+	sb.WriteString(fmt.Sprintf("Zone: %s.pub.3gppnetwork.org\n", Zone(p.MCC, p.MNC)))
+	sb.WriteString(fmt.Sprintf("Total FQDNs: %d\n", p.TotalFQDNs))
+	sb.WriteString(fmt.Sprintf("Total IPs: %d\n", len(p.IPs)))
+	if p.FirstSeen != nil {
+		sb.WriteString(fmt.Sprintf("First seen: %s\n", p.FirstSeen.Format(time.RFC3339)))
+		sb.WriteString(fmt.Sprintf("Last seen: %s\n", p.LastSeen.Format(time.RFC3339)))
+	}
+	sb.WriteString("\n")
+
+	if p.TotalFQDNs > 0 {
+		sb.WriteString("FQDNs by Type:\n")
+		for _, t := range Types(p) {
+			sb.WriteString(fmt.Sprintf("  %s (%d):\n", t, len(p.FQDNsByType[t])))
+			for _, fqdn := range p.FQDNsByType[t] {
+				sb.WriteString(fmt.Sprintf("    %s\n", fqdn))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(p.IPs) > 0 {
+		sb.WriteString("IP Addresses:\n")
+		for _, ip := range p.IPs {
+			sb.WriteString(fmt.Sprintf("  %s\n", ip))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(p.Pings) > 0 {
+		sb.WriteString("Ping History:\n")
+		for _, ping := range p.Pings {
+			sb.WriteString(fmt.Sprintf("  %s  %-5s %s  %s\n",
+				ping.Timestamp.Format(time.RFC3339), ping.Method, ping.FQDN, PingOutcome(ping)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(p.IPHistory) > 0 {
+		sb.WriteString("Passive DNS History:\n")
+		for _, r := range p.IPHistory {
+			sb.WriteString(fmt.Sprintf("  %s  %s  %s .. %s  (%s)\n",
+				r.FQDN, r.IP, r.FirstSeen.Format(time.DateOnly), r.LastSeen.Format(time.DateOnly), HistorySource(r)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(p.Notes) > 0 {
+		sb.WriteString("Notes:\n")
+		for _, note := range p.Notes {
+			sb.WriteString(fmt.Sprintf("  #%d  %s  %s: %s\n",
+				note.ID, note.Created.Format(time.RFC3339), note.Target, note.Text))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(p.Unavailable) > 0 {
+		sb.WriteString("Not Available:\n")
+		for _, note := range p.Unavailable {
+			sb.WriteString(fmt.Sprintf("  %s\n", note))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// PingOutcome summarizes one ping as latency or failure status
+func PingOutcome(ping models.PingResult) string {
+	if ping.Success {
+		return fmt.Sprintf("%.2f ms", float64(ping.Latency.Microseconds())/1000.0)
+	}
+	if ping.Status != "" {
+		return string(ping.Status)
+	}
+	return "failed"
+}
+
+// HistorySource names the provider of a resolution and how often it was
+// observed, if counted
+func HistorySource(r models.IPResolution) string {
+	if r.Count > 0 {
+		return fmt.Sprintf("%s, %d times", r.Source, r.Count)
+	}
+	return r.Source
+}
+
+var profileTemplate = template.Must(template.New("profile").Funcs(template.FuncMap{
+	"outcome": PingOutcome,
+	"source":  HistorySource,
+	"date":    func(t time.Time) string { return t.Format(time.DateOnly) },
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Operator profile: {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+.note { color: #777; }
+</style>
+</head>
+<body>
+<h1>Operator profile: {{.Title}}</h1>
+<p>
+Operators: {{if .P.Operators}}{{range $i, $op := .P.Operators}}{{if $i}}, {{end}}{{$op}}{{end}}{{else}}unknown{{end}}<br>
+Zone: {{.Zone}}.pub.3gppnetwork.org<br>
+Total FQDNs: {{.P.TotalFQDNs}}<br>
+Total IPs: {{len .P.IPs}}
+{{- if .P.FirstSeen}}<br>
+First seen: {{rfc3339 .P.FirstSeen}}<br>
+Last seen: {{rfc3339 .P.LastSeen}}{{end}}
+</p>
+{{- if .Types}}
+<h2>FQDNs by type</h2>
+{{- range .Types}}
+<h3>{{.Name}} ({{len .FQDNs}})</h3>
+<ul>{{range .FQDNs}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- end}}
+{{- if .P.IPs}}
+<h2>IP addresses</h2>
+<ul>{{range .P.IPs}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- if .P.Pings}}
+<h2>Ping history</h2>
+<table>
+<tr><th>Time</th><th>Method</th><th>FQDN</th><th>Result</th></tr>
+{{- range .P.Pings}}
+<tr><td>{{rfc3339 .Timestamp}}</td><td>{{.Method}}</td><td>{{.FQDN}}</td><td>{{outcome .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .P.IPHistory}}
+<h2>Passive DNS history</h2>
+<table>
+<tr><th>FQDN</th><th>IP</th><th>First seen</th><th>Last seen</th><th>Source</th></tr>
+{{- range .P.IPHistory}}
+<tr><td>{{.FQDN}}</td><td>{{.IP}}</td><td>{{date .FirstSeen}}</td><td>{{date .LastSeen}}</td><td>{{source .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .P.Notes}}
+<h2>Notes</h2>
+<table>
+<tr><th>#</th><th>Time</th><th>Target</th><th>Note</th></tr>
+{{- range .P.Notes}}
+<tr><td>{{.ID}}</td><td>{{rfc3339 .Created}}</td><td>{{.Target}}</td><td>{{.Text}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .P.Unavailable}}
+<h2>Not available</h2>
+<ul class="note">{{range .P.Unavailable}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML renders a profile as a standalone HTML page
+func HTML(p *models.OperatorProfile) (string, error) {
+	type typeGroup struct {
+		Name  string
+		FQDNs []string
+	}
+	var groups []typeGroup
+	for _, t := range Types(p) {
+		groups = append(groups, typeGroup{Name: t, FQDNs: p.FQDNsByType[t]})
+	}
+
+	var sb strings.Builder
+	err := profileTemplate.Execute(&sb, struct {
+		P     *models.OperatorProfile
+		Title string
+		Zone  string
+		Types []typeGroup
+	}{p, Title(p), Zone(p.MCC, p.MNC), groups})
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
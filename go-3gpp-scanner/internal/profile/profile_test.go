@@ -0,0 +1,118 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func testSources() Sources {
+	t0 := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	return Sources{
+		Operators: []string{"Telekom"},
+		FQDNs: []string{
+			"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org",
+			"ims.mnc001.mcc262.pub.3gppnetwork.org",
+		},
+		Results: []models.DNSResult{
+			{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "192.0.2.2"}, MCC: "262", MNC: "01", Operator: "Telekom", Timestamp: t0},
+			{FQDN: "bsf.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1"}, MCC: "262", MNC: "01", Operator: "Telekom", Timestamp: t0.Add(time.Hour)},
+			{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", IPs: []string{"198.51.100.1"}, MCC: "262", MNC: "02", Operator: "Vodafone", Timestamp: t0},
+			{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"198.51.100.9"}, MCC: "262", MNC: "001", Operator: "Other", Timestamp: t0},
+		},
+		Pings: []models.PingResult{
+			{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Method: "tcp", Success: true, Latency: 12 * time.Millisecond, Timestamp: t0.Add(48 * time.Hour)},
+			{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", Method: "tcp", Success: true, Timestamp: t0.Add(72 * time.Hour)},
+		},
+		Notes: []models.Note{
+			{ID: 2, Kind: "operator", Target: "Telekom", Text: "contact via PSIRT", Created: t0.Add(2 * time.Hour)},
+			{ID: 1, Kind: "fqdn", Target: "bsf.mnc001.mcc262.pub.3gppnetwork.org", Text: "<b>live</b>", Created: t0},
+			{ID: 3, Kind: "operator", Target: "Vodafone", Text: "other operator", Created: t0},
+		},
+		History: []models.IPResolution{
+			{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IP: "203.0.113.9", FirstSeen: t0.AddDate(-2, 0, 0), LastSeen: t0.AddDate(-1, 0, 0), Count: 40, Source: "dnsdb"},
+			{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", IP: "198.51.100.9", FirstSeen: t0, LastSeen: t0, Source: "virustotal"},
+		},
+		Country: "Germany",
+	}
+}
+
+func TestBuild(t *testing.T) {
+	p := Build("262", "01", testSources())
+
+	if p.TotalFQDNs != 3 {
+		t.Errorf("Expected 3 FQDNs (DB plus scan export), got %d", p.TotalFQDNs)
+	}
+	if len(p.FQDNsByType["epdg"]) != 1 || len(p.FQDNsByType["bsf"]) != 1 {
+		t.Errorf("Unexpected FQDN grouping: %v", p.FQDNsByType)
+	}
+	if len(p.IPs) != 2 {
+		t.Errorf("Expected 2 IPs of the operator only, not of MNC 001, got %v", p.IPs)
+	}
+	if len(p.Pings) != 1 {
+		t.Errorf("Expected pings of other operators to be dropped, got %d", len(p.Pings))
+	}
+	if len(p.IPHistory) != 1 || p.IPHistory[0].IP != "203.0.113.9" {
+		t.Errorf("Expected the operator's passive DNS history only, got %+v", p.IPHistory)
+	}
+	if len(p.Notes) != 2 || p.Notes[0].ID != 1 || p.Notes[1].ID != 2 {
+		t.Errorf("Expected the FQDN and operator notes oldest first, got %+v", p.Notes)
+	}
+	if p.FirstSeen == nil || p.FirstSeen.Hour() != 12 || p.LastSeen.Day() != 12 {
+		t.Errorf("Unexpected first/last seen: %v %v", p.FirstSeen, p.LastSeen)
+	}
+	for _, note := range p.Unavailable {
+		if strings.HasPrefix(note, "IP addresses") || strings.HasPrefix(note, "Ping history") {
+			t.Errorf("Unexpected unavailable note with both exports supplied: %s", note)
+		}
+	}
+}
+
+func TestBuildDatabaseOnly(t *testing.T) {
+	src := testSources()
+	src.Results, src.Pings, src.History = nil, nil, nil
+	p := Build("262", "01", src)
+
+	if p.TotalFQDNs != 2 || p.FirstSeen != nil {
+		t.Errorf("Expected DB FQDNs only and no timestamps, got %d FQDNs", p.TotalFQDNs)
+	}
+	joined := strings.Join(p.Unavailable, "\n")
+	for _, want := range []string{"IP addresses", "Ping history", "Passive DNS history", "First/last seen", "ASNs", "Certificates"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected %q to be listed as unavailable", want)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	formatted := FormatText(Build("262", "01", testSources()))
+
+	for _, want := range []string{
+		"Operator Profile: MCC 262 / MNC 01 (Germany)",
+		"Zone: mnc001.mcc262.pub.3gppnetwork.org",
+		"epdg (1):",
+		"192.0.2.2",
+		"12.00 ms",
+		"First seen: 2026-01-10T12:00:00Z",
+		"#2  2026-01-10T14:00:00Z  Telekom: contact via PSIRT",
+		"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org  203.0.113.9  2024-01-10 .. 2025-01-10  (dnsdb, 40 times)",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Expected %q in profile, got:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	page, err := HTML(Build("262", "01", testSources()))
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	for _, want := range []string{"<h1>Operator profile: MCC 262 / MNC 01 (Germany)</h1>", "<li>192.0.2.1</li>", "2026-01-12T12:00:00Z", "&lt;b&gt;live&lt;/b&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %q in page", want)
+		}
+	}
+}
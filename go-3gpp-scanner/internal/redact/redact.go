@@ -0,0 +1,253 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Redaction methods for IP addresses
+const (
+	MethodHash     = "hash"     // Keyed hash; distinct IPs stay distinct
+	MethodTruncate = "truncate" // Keep only the /24 (IPv4) or /48 (IPv6) prefix
+)
+
+// mncLabel matches the MNC label of a 3GPP FQDN, also inside text such
+// as a certificate subject ("CN=mnc001.mcc262...")
+var mncLabel = regexp.MustCompile(`(?i)(^|[^0-9a-z-])mnc\d+\.`)
+
+// addressLike matches candidate IP addresses, with or without a port, in
+// free text such as dial errors
+var addressLike = regexp.MustCompile(`[0-9A-Fa-f]*[.:][0-9A-Fa-f.:]+`)
+
+// Options selects which fields to redact
+type Options struct {
+	IPs       bool
+	Operators bool
+	Method    string // MethodHash or MethodTruncate (IPs only)
+	Salt      string // Hash key; a random salt is generated when empty
+}
+
+// Redactor rewrites sensitive fields of results before publication.
+// Hashing is keyed and consistent within a Redactor, so aggregate counts
+// (distinct IPs, FQDNs per operator) remain valid.
+type Redactor struct {
+	opts Options
+	key  []byte
+}
+
+// ParseFields converts a --redact value list (ips, operators) into Options
+func ParseFields(fields []string) (Options, error) {
+	var opts Options
+	for _, f := range fields {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "ips":
+			opts.IPs = true
+		case "operators":
+			opts.Operators = true
+		case "":
+		default:
+			return opts, fmt.Errorf("invalid redact field: %s (must be ips or operators)", f)
+		}
+	}
+	return opts, nil
+}
+
+// New creates a redactor
+func New(opts Options) (*Redactor, error) {
+	if opts.Method == "" {
+		opts.Method = MethodHash
+	}
+	if opts.Method != MethodHash && opts.Method != MethodTruncate {
+		return nil, fmt.Errorf("invalid redact method: %s (must be hash or truncate)", opts.Method)
+	}
+
+	key := []byte(opts.Salt)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+
+	return &Redactor{opts: opts, key: key}, nil
+}
+
+// Enabled reports whether any field will be redacted
+func (r *Redactor) Enabled() bool {
+	return r != nil && (r.opts.IPs || r.opts.Operators)
+}
+
+// DNSResults returns redacted copies of DNS results
+func (r *Redactor) DNSResults(results []models.DNSResult) []models.DNSResult {
+	if !r.Enabled() {
+		return results
+	}
+
+	out := make([]models.DNSResult, len(results))
+	for i, res := range results {
+		if r.opts.IPs {
+			ips := make([]string, len(res.IPs))
+			for j, ip := range res.IPs {
+				ips[j] = r.IP(ip)
+			}
+			res.IPs = ips
+		}
+		if r.opts.Operators {
+			// Exclusion errors of failed queries name the FQDN
+			redacted := r.FQDN(res.FQDN)
+			res.Error = strings.ReplaceAll(res.Error, res.FQDN, redacted)
+			res.FQDN = redacted
+			res.Operator = r.Operator(res.Operator)
+			res.MNC = ""
+			// The MCC-MNC list details narrow the pseudonym down to one operator
+			res.Brand = ""
+			res.OperatorStatus = ""
+			res.Country = ""
+			res.CountryCode = ""
+		}
+		out[i] = res
+	}
+	return out
+}
+
+// PingResults returns redacted copies of ping results
+func (r *Redactor) PingResults(results []models.PingResult) []models.PingResult {
+	if !r.Enabled() {
+		return results
+	}
+
+	out := make([]models.PingResult, len(results))
+	for i, res := range results {
+		if r.opts.IPs {
+			if res.IP != "" {
+				res.IP = r.IP(res.IP)
+			}
+			resolved := make([]string, len(res.ResolvedIPs))
+			for j, ip := range res.ResolvedIPs {
+				resolved[j] = r.IP(ip)
+			}
+			res.ResolvedIPs = resolved
+			if res.DualStack != nil {
+				res.DualStack = r.dualStack(*res.DualStack)
+			}
+			// Dial errors name the address tried
+			res.Error = r.addresses(res.Error)
+		}
+		if r.opts.Operators {
+			// Lookup errors name the FQDN
+			redacted := r.FQDN(res.FQDN)
+			res.Error = strings.ReplaceAll(res.Error, res.FQDN, redacted)
+			res.FQDN = redacted
+			// Certificates of operator hosts name them too
+			res.CertSubject = r.FQDN(res.CertSubject)
+			res.CertIssuer = r.FQDN(res.CertIssuer)
+			if res.CertSANs != nil {
+				sans := make([]string, len(res.CertSANs))
+				for j, san := range res.CertSANs {
+					sans[j] = r.FQDN(san)
+				}
+				res.CertSANs = sans
+			}
+		}
+		out[i] = res
+	}
+	return out
+}
+
+// IP redacts an address, preserving any ":port" suffix
+func (r *Redactor) IP(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+
+	var redacted string
+	if r.opts.Method == MethodTruncate {
+		redacted = truncateIP(host)
+	} else {
+		redacted = "ip-" + r.token(host)
+	}
+
+	if port != "" {
+		return net.JoinHostPort(redacted, port)
+	}
+	return redacted
+}
+
+// dualStack redacts the address probed in each family, copying the
+// probes so the input stays unchanged
+func (r *Redactor) dualStack(ds models.DualStack) *models.DualStack {
+	for _, family := range []**models.FamilyProbe{&ds.IPv4, &ds.IPv6} {
+		if *family != nil {
+			probe := **family
+			probe.IP = r.IP(probe.IP)
+			*family = &probe
+		}
+	}
+	return &ds
+}
+
+// addresses redacts the IP addresses in a message, such as
+// "dial tcp 192.0.2.1:443: i/o timeout", keeping the rest
+func (r *Redactor) addresses(text string) string {
+	return addressLike.ReplaceAllStringFunc(text, func(m string) string {
+		// A colon may end the address and start the next part
+		addr := strings.TrimRight(m, ":.")
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if net.ParseIP(host) == nil {
+			return m
+		}
+		return r.IP(addr) + m[len(addr):]
+	})
+}
+
+// Operator replaces an operator name with a stable pseudonym
+func (r *Redactor) Operator(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "operator-" + r.token(strings.ToLower(name))
+}
+
+// FQDN replaces the MNC label with a stable pseudonym, keeping the
+// service labels and MCC so subdomain and country statistics still work
+func (r *Redactor) FQDN(fqdn string) string {
+	return mncLabel.ReplaceAllStringFunc(fqdn, func(m string) string {
+		prefix := ""
+		if !strings.HasPrefix(strings.ToLower(m), "mnc") {
+			prefix, m = m[:1], m[1:]
+		}
+		label := strings.TrimSuffix(m, ".")
+		return prefix + "mncx" + r.token(strings.ToLower(label)) + "."
+	})
+}
+
+// token returns a short keyed hash of a value
+func (r *Redactor) token(value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// truncateIP keeps the /24 of an IPv4 address or the /48 of an IPv6 address
+func truncateIP(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "redacted"
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
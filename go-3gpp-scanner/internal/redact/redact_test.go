@@ -0,0 +1,193 @@
+package redact
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestParseFields(t *testing.T) {
+	opts, err := ParseFields([]string{"ips", "Operators"})
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if !opts.IPs || !opts.Operators {
+		t.Errorf("Expected both fields enabled, got %+v", opts)
+	}
+
+	if _, err := ParseFields([]string{"fqdns"}); err == nil {
+		t.Errorf("Expected error for unknown field")
+	}
+}
+
+func TestHashPreservesDistinctness(t *testing.T) {
+	r, err := New(Options{IPs: true, Operators: true, Salt: "test"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	results := []models.DNSResult{
+		{FQDN: "ims.mnc001.mcc310.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "192.0.2.2"}, MNC: "01", MCC: "310", Operator: "Verizon", Subdomain: "ims",
+			Brand: "Verizon Wireless", OperatorStatus: "Operational", Country: "United States of America", CountryCode: "US"},
+		{FQDN: "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org", IPs: []string{"192.0.2.1"}, MNC: "01", MCC: "310", Operator: "Verizon", Subdomain: "epdg.epc"},
+	}
+
+	redacted := r.DNSResults(results)
+
+	if redacted[0].IPs[0] == "192.0.2.1" {
+		t.Errorf("IP was not redacted")
+	}
+	if redacted[0].IPs[0] != redacted[1].IPs[0] {
+		t.Errorf("Same IP should hash to same token")
+	}
+	if redacted[0].IPs[0] == redacted[0].IPs[1] {
+		t.Errorf("Different IPs should hash to different tokens")
+	}
+	if redacted[0].Operator == "Verizon" || redacted[0].Operator != redacted[1].Operator {
+		t.Errorf("Operator pseudonym should be consistent and hide the name, got %q", redacted[0].Operator)
+	}
+	if d := redacted[0]; d.Brand != "" || d.OperatorStatus != "" || d.Country != "" || d.CountryCode != "" || d.MNC != "" {
+		t.Errorf("Operator details should be cleared, got %+v", d)
+	}
+	if results[0].Brand != "Verizon Wireless" {
+		t.Errorf("Input brand was modified")
+	}
+	if strings.Contains(redacted[0].FQDN, "mnc001") || !strings.Contains(redacted[0].FQDN, ".mcc310.") {
+		t.Errorf("FQDN should hide MNC but keep MCC, got %s", redacted[0].FQDN)
+	}
+	if !strings.HasPrefix(redacted[1].FQDN, "epdg.epc.") {
+		t.Errorf("FQDN should keep service labels, got %s", redacted[1].FQDN)
+	}
+
+	// Originals must not be modified
+	if results[0].IPs[0] != "192.0.2.1" || results[0].Operator != "Verizon" {
+		t.Errorf("Input results were modified")
+	}
+}
+
+func TestFQDNDistinctMNCs(t *testing.T) {
+	r, err := New(Options{Salt: "test"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Every possible MNC label of one country keeps its own pseudonym
+	seen := make(map[string]string)
+	for mnc := 0; mnc < 1000; mnc++ {
+		fqdn := fmt.Sprintf("ims.mnc%03d.mcc262.pub.3gppnetwork.org", mnc)
+		redacted := r.FQDN(fqdn)
+		if other, ok := seen[redacted]; ok {
+			t.Fatalf("%s and %s both redact to %s", other, fqdn, redacted)
+		}
+		seen[redacted] = fqdn
+	}
+}
+
+func TestPingResults(t *testing.T) {
+	r, err := New(Options{IPs: true, Salt: "test"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	results := []models.PingResult{{
+		FQDN: "epdg.example", IP: "192.0.2.1", Port: 443, ResolvedIPs: []string{"192.0.2.1", "192.0.2.2"},
+		DualStack: &models.DualStack{
+			IPv4: &models.FamilyProbe{IP: "192.0.2.1", Success: true},
+			IPv6: &models.FamilyProbe{IP: "2001:db8::1"},
+		},
+	}}
+	redacted := r.PingResults(results)
+	if redacted[0].IP == "192.0.2.1" || redacted[0].IP != redacted[0].ResolvedIPs[0] || redacted[0].ResolvedIPs[1] == "192.0.2.2" {
+		t.Errorf("Addresses not redacted consistently: %+v", redacted[0])
+	}
+	if redacted[0].Port != 443 || results[0].ResolvedIPs[1] != "192.0.2.2" {
+		t.Errorf("Port should be kept and the input unchanged: %+v %+v", redacted[0], results[0])
+	}
+	if ds := redacted[0].DualStack; ds.IPv4.IP != redacted[0].IP || ds.IPv6.IP != r.IP("2001:db8::1") || !ds.IPv4.Success {
+		t.Errorf("Dual-stack addresses not redacted: %+v %+v", ds.IPv4, ds.IPv6)
+	}
+	if results[0].DualStack.IPv4.IP != "192.0.2.1" || results[0].DualStack.IPv6.IP != "2001:db8::1" {
+		t.Errorf("Input dual-stack probes were modified")
+	}
+}
+
+func TestPingResultErrors(t *testing.T) {
+	r, err := New(Options{IPs: true, Operators: true, Salt: "test"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	fqdn := "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org"
+	results := []models.PingResult{
+		{FQDN: fqdn, IP: "192.0.2.1", Error: "TCP connect failed: dial tcp 192.0.2.1:443: i/o timeout"},
+		{FQDN: fqdn, IP: "2001:db8::1", Error: "dial tcp [2001:db8::1]:443: connect: connection refused"},
+		{FQDN: fqdn, Error: "DNS lookup failed: lookup " + fqdn + ": no such host"},
+	}
+	redacted := r.PingResults(results)
+
+	if got := redacted[0].Error; strings.Contains(got, "192.0.2.1") || !strings.Contains(got, r.IP("192.0.2.1:443")+": i/o timeout") {
+		t.Errorf("IPv4 address not redacted in error: %s", got)
+	}
+	if got := redacted[1].Error; strings.Contains(got, "2001:db8::1") || !strings.Contains(got, "["+r.IP("2001:db8::1")+"]:443") {
+		t.Errorf("IPv6 address not redacted in error: %s", got)
+	}
+	if got := redacted[2].Error; strings.Contains(got, "mnc001") || !strings.Contains(got, redacted[2].FQDN+": no such host") {
+		t.Errorf("FQDN not redacted in error: %s", got)
+	}
+	if results[0].Error != "TCP connect failed: dial tcp 192.0.2.1:443: i/o timeout" {
+		t.Errorf("Input results were modified")
+	}
+}
+
+func TestPingResultCertificates(t *testing.T) {
+	r, err := New(Options{Operators: true, Salt: "test"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	fqdn := "xcap.ims.mnc001.mcc262.pub.3gppnetwork.org"
+	results := []models.PingResult{{
+		FQDN:        fqdn,
+		CertSubject: "CN=" + fqdn + ",O=Example Mobile",
+		CertIssuer:  "CN=ca.mnc001.mcc262.pub.3gppnetwork.org",
+		CertSANs:    []string{fqdn, "*.ims.mnc001.mcc262.pub.3gppnetwork.org", "mnc001.mcc262.pub.3gppnetwork.org"},
+	}}
+	redacted := r.PingResults(results)[0]
+
+	label := strings.Split(redacted.FQDN, ".")[2]
+	for _, field := range append([]string{redacted.CertSubject, redacted.CertIssuer}, redacted.CertSANs...) {
+		if strings.Contains(field, "mnc001") || !strings.Contains(field, label+".mcc262.") {
+			t.Errorf("Certificate field not redacted consistently with the FQDN %s: %s", redacted.FQDN, field)
+		}
+	}
+	if redacted.CertSubject != "CN="+redacted.FQDN+",O=Example Mobile" {
+		t.Errorf("Subject should keep its other attributes, got %s", redacted.CertSubject)
+	}
+	if results[0].CertSANs[0] != fqdn {
+		t.Errorf("Input results were modified")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	r, err := New(Options{IPs: true, Method: MethodTruncate})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"192.0.2.55", "192.0.2.0/24"},
+		{"2001:db8:1234:5678::1", "2001:db8:1234::/48"},
+		{"192.0.2.55:443", "192.0.2.0/24:443"},
+	}
+
+	for _, tt := range tests {
+		if got := r.IP(tt.input); got != tt.expected {
+			t.Errorf("IP(%s) = %s, expected %s", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package remote
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const userAgent = "3gpp-scanner (+https://github.com/asnd/sec100)"
+
+// emptySHA256 is the payload hash of requests without a body
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// ErrNotFound is returned for objects the store does not have
+var ErrNotFound = errors.New("object not found")
+
+// Client talks to Amazon S3 or an S3-compatible store (MinIO, Ceph, R2,
+// ...) with AWS Signature Version 4
+type Client struct {
+	// Endpoint is the base URL of an S3-compatible store, e.g.
+	// http://minio.local:9000, addressed path-style. Empty selects AWS,
+	// addressed virtual-hosted style.
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	HTTP         *http.Client
+
+	now func() time.Time
+}
+
+// Object is an entry of a bucket listing
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Put uploads size bytes of body to bucket/key. payloadSHA256 is the hex
+// SHA-256 of the body, which the signature covers.
+func (c *Client) Put(ctx context.Context, bucket, key string, body io.Reader, size int64, payloadSHA256 string) error {
+	req, err := c.newRequest(ctx, http.MethodPut, bucket, key, nil, body, payloadSHA256)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads bucket/key; the caller must close the body
+func (c *Client) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, bucket, key, nil, nil, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List returns the objects of a bucket whose keys start with prefix
+func (c *Client) List(ctx context.Context, bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := c.newRequest(ctx, http.MethodGet, bucket, "", query, nil, emptySHA256)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Contents []struct {
+				Key          string
+				Size         int64
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse listing: %w", err)
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// newRequest builds a signed request for an object, or for the bucket
+// when key is empty
+func (c *Client) newRequest(ctx context.Context, method, bucket, key string, query url.Values, body io.Reader, payloadSHA256 string) (*http.Request, error) {
+	var u *url.URL
+	if c.Endpoint == "" {
+		region := c.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		u = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region), Path: "/" + key}
+	} else {
+		base, err := url.Parse(strings.TrimSuffix(c.Endpoint, "/"))
+		if err != nil || base.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint: %s", c.Endpoint)
+		}
+		u = base.JoinPath(bucket, key)
+		if key == "" {
+			u.Path += "/"
+		}
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.sign(req, payloadSHA256)
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// do sends a request and turns error responses into errors; the caller
+// must close the body of a successful response
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var s3Err struct {
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("%s %s: unexpected status code: %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// sign adds the AWS Signature Version 4 of a request, covering the host
+// and every header set so far
+func (c *Client) sign(req *http.Request, payloadSHA256 string) {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	region := c.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadSHA256)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadSHA256,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 signs them
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and
+// slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,426 @@
+// Package remote shares databases and exported results through Amazon S3
+// or an S3-compatible store. Every push stores an immutable version under
+// <prefix>/<name>/ and moves the <prefix>/<name>/LATEST pointer to it, so
+// team members pulling get the newest dataset while older ones stay
+// retrievable, and concurrent pushes never overwrite each other.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/database"
+)
+
+// timeLayout starts version IDs so they sort chronologically
+const timeLayout = "20060102T150405Z"
+
+// latestKey names the object holding the ID of the current version
+const latestKey = "LATEST"
+
+// hashLen is the number of SHA-256 hex digits kept in version IDs
+const hashLen = 16
+
+// validName restricts synced file names to safe object key parts
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Location is a bucket and key prefix, written s3://bucket/prefix
+type Location struct {
+	Bucket string
+	Prefix string
+}
+
+// ParseLocation parses an s3://bucket[/prefix] URL
+func ParseLocation(s string) (Location, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return Location{}, fmt.Errorf("invalid remote %q (expected s3://bucket/prefix)", s)
+	}
+	return Location{Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+func (l Location) String() string {
+	if l.Prefix == "" {
+		return "s3://" + l.Bucket
+	}
+	return "s3://" + l.Bucket + "/" + l.Prefix
+}
+
+// Version is one pushed copy of a file
+type Version struct {
+	Name    string // File name, e.g. database.db
+	ID      string // e.g. 20260101T120000Z-1a2b3c4d5e6f7a8b
+	Created time.Time
+	SHA256  string // Leading hex digits of the content digest
+	Size    int64
+	Latest  bool
+}
+
+// Syncer pushes files to and pulls them from a remote location
+type Syncer struct {
+	Client    *Client
+	Location  Location
+	DBOptions []database.Option // Connection options used when copying databases
+	now       func() time.Time
+}
+
+// NewSyncer creates a syncer for a location
+func NewSyncer(client *Client, location Location) *Syncer {
+	return &Syncer{Client: client, Location: location, now: time.Now}
+}
+
+// NewClient creates a client with credentials from the standard AWS
+// environment variables. An empty region falls back to $AWS_REGION,
+// $AWS_DEFAULT_REGION and us-east-1.
+func NewClient(endpoint, region string) (*Client, error) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("no credentials (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region == "" {
+			region = os.Getenv(env)
+		}
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	// No overall timeout: databases can take long to transfer
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Minute
+	return &Client{
+		Endpoint:     endpoint,
+		Region:       region,
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		HTTP:         &http.Client{Transport: transport},
+	}, nil
+}
+
+// Push uploads a file as a new version named after its base name. SQLite
+// databases are copied through SQLite first, so the upload is consistent
+// even while a scan writes to them. Nothing is uploaded when the latest
+// version has the same content; uploaded reports whether a version was
+// created.
+func (s *Syncer) Push(ctx context.Context, path string) (v *Version, uploaded bool, err error) {
+	name := filepath.Base(path)
+	if !validName.MatchString(name) {
+		return nil, false, fmt.Errorf("invalid file name %q (use letters, digits, ., - and _)", name)
+	}
+	src, cleanup, err := s.contentFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cleanup()
+	sum, size, err := hashFile(src)
+	if err != nil {
+		return nil, false, err
+	}
+
+	latest, err := s.latest(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest != nil && latest.SHA256 == sum[:hashLen] {
+		return latest, false, nil
+	}
+
+	created := s.now().UTC().Truncate(time.Second)
+	v = &Version{
+		Name:    name,
+		ID:      created.Format(timeLayout) + "-" + sum[:hashLen],
+		Created: created,
+		SHA256:  sum[:hashLen],
+		Size:    size,
+		Latest:  true,
+	}
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	if err := s.Client.Put(ctx, s.Location.Bucket, s.key(name, v.ID), file, size, sum); err != nil {
+		return nil, false, fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	if err := s.putLatest(ctx, name, v.ID); err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// Pull downloads a version of name to dst: the latest one when id is
+// empty, otherwise the one whose ID is or uniquely starts with id. A dst
+// with the same content is left alone (downloaded is false); one with
+// other content is only replaced when force is set.
+func (s *Syncer) Pull(ctx context.Context, name, id, dst string, force bool) (v *Version, downloaded bool, err error) {
+	if !validName.MatchString(name) {
+		return nil, false, fmt.Errorf("invalid file name %q (use letters, digits, ., - and _)", name)
+	}
+	if id == "" {
+		v, err = s.latest(ctx, name)
+		if err == nil && v == nil {
+			err = fmt.Errorf("%s has not been pushed to %s", name, s.Location)
+		}
+	} else {
+		v, err = s.find(ctx, name, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		src, cleanup, err := s.contentFile(dst)
+		if err != nil {
+			return nil, false, err
+		}
+		sum, _, err := hashFile(src)
+		cleanup()
+		if err != nil {
+			return nil, false, err
+		}
+		if sum[:hashLen] == v.SHA256 {
+			return v, false, nil
+		}
+		if !force {
+			return nil, false, fmt.Errorf("%s differs from %s version %s (use --force to replace it)", dst, name, v.ID)
+		}
+	}
+
+	// Download next to dst so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".pull-")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	body, err := s.Client.Get(ctx, s.Location.Bucket, s.key(name, v.ID))
+	if err != nil {
+		tmp.Close()
+		return nil, false, fmt.Errorf("failed to download %s version %s: %w", name, v.ID, err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	body.Close()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download %s version %s: %w", name, v.ID, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum[:hashLen] != v.SHA256 {
+		return nil, false, fmt.Errorf("%s version %s is corrupted (content digest %s)", name, v.ID, sum[:hashLen])
+	}
+
+	// Stale WAL files would be replayed into a replaced database
+	if isSQLite(tmp.Name()) {
+		for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+			if err := os.Remove(dst + suffix); err != nil && !os.IsNotExist(err) {
+				return nil, false, fmt.Errorf("failed to remove %s: %w", dst+suffix, err)
+			}
+		}
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return nil, false, fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	return v, true, nil
+}
+
+// Versions returns the versions of name from oldest to newest
+func (s *Syncer) Versions(ctx context.Context, name string) ([]Version, error) {
+	objects, err := s.Client.List(ctx, s.Location.Bucket, s.key(name)+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.Location, err)
+	}
+	latestID, err := s.latestID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+	for _, obj := range objects {
+		id := strings.TrimPrefix(obj.Key, s.key(name)+"/")
+		stamp, sum, ok := strings.Cut(id, "-")
+		if !ok || len(sum) != hashLen {
+			continue // LATEST or not written by Push
+		}
+		created, err := time.Parse(timeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{
+			Name:    name,
+			ID:      id,
+			Created: created,
+			SHA256:  sum,
+			Size:    obj.Size,
+			Latest:  id == latestID,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID < versions[j].ID })
+	return versions, nil
+}
+
+// Names returns the names of the files pushed to the location
+func (s *Syncer) Names(ctx context.Context) ([]string, error) {
+	prefix := s.key()
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := s.Client.List(ctx, s.Location.Bucket, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.Location, err)
+	}
+	var names []string
+	for _, obj := range objects {
+		name, rest, ok := strings.Cut(strings.TrimPrefix(obj.Key, prefix), "/")
+		if ok && rest == latestKey {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// latest returns the current version of name, or nil when it was never pushed
+func (s *Syncer) latest(ctx context.Context, name string) (*Version, error) {
+	id, err := s.latestID(ctx, name)
+	if err != nil || id == "" {
+		return nil, err
+	}
+	return s.find(ctx, name, id)
+}
+
+// latestID reads the LATEST pointer of name; empty when it was never pushed
+func (s *Syncer) latestID(ctx context.Context, name string) (string, error) {
+	body, err := s.Client.Get(ctx, s.Location.Bucket, s.key(name, latestKey))
+	if err == ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the latest version of %s: %w", name, err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the latest version of %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (s *Syncer) putLatest(ctx context.Context, name, id string) error {
+	data := []byte(id + "\n")
+	sum := sha256.Sum256(data)
+	if err := s.Client.Put(ctx, s.Location.Bucket, s.key(name, latestKey), bytes.NewReader(data), int64(len(data)), hex.EncodeToString(sum[:])); err != nil {
+		return fmt.Errorf("uploaded %s version %s, but failed to mark it latest: %w", name, id, err)
+	}
+	return nil
+}
+
+// find returns the version of name whose ID is id, or the only one whose
+// ID starts with it
+func (s *Syncer) find(ctx context.Context, name, id string) (*Version, error) {
+	versions, err := s.Versions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var matches []Version
+	for _, v := range versions {
+		if v.ID == id {
+			return &v, nil
+		}
+		if strings.HasPrefix(v.ID, id) {
+			matches = append(matches, v)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("version not found: %s/%s", name, id)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("version %s of %s is ambiguous (%d matches)", id, name, len(matches))
+	}
+}
+
+// key joins the prefix and parts into an object key
+func (s *Syncer) key(parts ...string) string {
+	if s.Location.Prefix != "" {
+		parts = append([]string{s.Location.Prefix}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// contentFile returns the file whose bytes represent path: a consistent
+// copy for SQLite databases, path itself otherwise. cleanup removes the
+// copy.
+func (s *Syncer) contentFile(path string) (file string, cleanup func(), err error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", nil, err
+	}
+	if !isSQLite(path) {
+		return path, func() {}, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "3gpp-scanner-sync-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
+	copyPath := filepath.Join(tmpDir, "copy.db")
+
+	// Read-only: neither a push nor the check before a pull may migrate
+	// the file
+	db, err := database.OpenReadOnly(path, s.DBOptions...)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("database error: %w", err)
+	}
+	err = db.BackupTo(copyPath)
+	db.Close()
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return copyPath, cleanup, nil
+}
+
+// isSQLite reports whether a file starts with the SQLite header
+func isSQLite(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, len(database.SQLiteHeader))
+	_, err = io.ReadFull(file, header)
+	return err == nil && bytes.Equal(header, database.SQLiteHeader)
+}
+
+// hashFile returns the hex SHA-256 and size of a file
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	n, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
@@ -0,0 +1,274 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// fakeS3 serves a path-style bucket from memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != "team" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		f.puts++
+	case r.Method == http.MethodGet && key == "":
+		type content struct {
+			Key  string
+			Size int64
+		}
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []content
+		}
+		for k, data := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, content{Key: k, Size: int64(len(data))})
+			}
+		}
+		sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestSyncer(t *testing.T) (*Syncer, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := &Client{Endpoint: server.URL, AccessKey: "key", SecretKey: "secret"}
+	syncer := NewSyncer(client, Location{Bucket: "team", Prefix: "lab/scans"})
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	syncer.now = func() time.Time { clock = clock.Add(time.Minute); return clock }
+	return syncer, fake
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Location
+		wantErr bool
+	}{
+		{"s3://team", Location{Bucket: "team"}, false},
+		{"s3://team/lab/scans/", Location{Bucket: "team", Prefix: "lab/scans"}, false},
+		{"https://team/lab", Location{}, true},
+		{"s3:///lab", Location{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLocation(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLocation(%q) = %+v, %v", tt.input, got, err)
+		}
+	}
+}
+
+func TestPushPullFile(t *testing.T) {
+	syncer, fake := newTestSyncer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.json")
+	os.WriteFile(path, []byte(`[{"fqdn":"a"}]`), 0o644)
+
+	first, uploaded, err := syncer.Push(ctx, path)
+	if err != nil || !uploaded {
+		t.Fatalf("Push: %v (uploaded %v)", err, uploaded)
+	}
+	if !strings.HasPrefix(first.ID, "20260102T030505Z-") || first.Size != 14 {
+		t.Errorf("Unexpected version: %+v", first)
+	}
+	if string(fake.objects["lab/scans/results.json/LATEST"]) != first.ID+"\n" {
+		t.Errorf("LATEST not updated: %q", fake.objects["lab/scans/results.json/LATEST"])
+	}
+
+	if _, uploaded, err := syncer.Push(ctx, path); err != nil || uploaded {
+		t.Errorf("Expected an unchanged file to be skipped, got uploaded %v, %v", uploaded, err)
+	}
+
+	os.WriteFile(path, []byte(`[{"fqdn":"b"}]`), 0o644)
+	second, uploaded, err := syncer.Push(ctx, path)
+	if err != nil || !uploaded || second.ID == first.ID {
+		t.Fatalf("Push changed file: %+v, %v, %v", second, uploaded, err)
+	}
+
+	versions, err := syncer.Versions(ctx, "results.json")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Versions: %+v, %v", versions, err)
+	}
+	if versions[0].Latest || !versions[1].Latest || versions[1].ID != second.ID {
+		t.Errorf("Expected the second version to be latest: %+v", versions)
+	}
+	names, err := syncer.Names(ctx)
+	if err != nil || len(names) != 1 || names[0] != "results.json" {
+		t.Errorf("Names: %v, %v", names, err)
+	}
+
+	// The local copy is up to date with the latest version
+	if _, downloaded, err := syncer.Pull(ctx, "results.json", "", path, false); err != nil || downloaded {
+		t.Errorf("Expected pull of the current content to be skipped: %v, %v", downloaded, err)
+	}
+
+	// An older version only replaces the local file with force
+	if _, _, err := syncer.Pull(ctx, "results.json", first.ID[:18], path, false); err == nil {
+		t.Error("Expected pull over a differing file to fail without force")
+	}
+	if _, downloaded, err := syncer.Pull(ctx, "results.json", first.ID[:18], path, true); err != nil || !downloaded {
+		t.Fatalf("Pull with force: %v, %v", downloaded, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `[{"fqdn":"a"}]` {
+		t.Errorf("Unexpected content after pull: %s", data)
+	}
+
+	// A corrupted object is refused
+	fake.objects["lab/scans/results.json/"+second.ID] = []byte("tampered")
+	other := filepath.Join(dir, "other.json")
+	if _, _, err := syncer.Pull(ctx, "results.json", "", other, false); err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Errorf("Expected corruption error, got %v", err)
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Error("Expected no file after a failed pull")
+	}
+
+	if _, _, err := syncer.Pull(ctx, "missing.json", "", other, false); err == nil {
+		t.Error("Expected error for a file never pushed")
+	}
+}
+
+func TestPushPullDatabase(t *testing.T) {
+	syncer, fake := newTestSyncer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "database.db")
+
+	db, err := database.NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+	if err := db.InsertResults([]models.DNSResult{{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", MNC: "01", MCC: "262", Operator: "Telekom"}}); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+
+	// Pushed while the database is open, as during a scan
+	v, uploaded, err := syncer.Push(ctx, path)
+	if err != nil || !uploaded {
+		t.Fatalf("Push: %v, %v", uploaded, err)
+	}
+	if _, uploaded, err := syncer.Push(ctx, path); err != nil || uploaded {
+		t.Errorf("Expected an unchanged database to be skipped, got uploaded %v, %v", uploaded, err)
+	}
+	if fake.puts != 2 {
+		t.Errorf("Expected one version and one LATEST upload, got %d", fake.puts)
+	}
+
+	pulled := filepath.Join(t.TempDir(), "database.db")
+	if _, downloaded, err := syncer.Pull(ctx, "database.db", v.ID, pulled, false); err != nil || !downloaded {
+		t.Fatalf("Pull: %v, %v", downloaded, err)
+	}
+	copyDB, err := database.NewDB(pulled)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer copyDB.Close()
+	all, err := copyDB.GetAllFQDNs()
+	if err != nil || len(all) != 1 {
+		t.Errorf("Expected the pulled database to hold the result, got %v, %v", all, err)
+	}
+}
+
+func TestPushLeavesDatabaseAlone(t *testing.T) {
+	syncer, _ := newTestSyncer(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	// A database of an older release, which NewDB would migrate
+	conn, err := sql.Open(database.DriverName, path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := conn.Exec("CREATE TABLE dns_results (id INTEGER PRIMARY KEY, fqdn TEXT)"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	conn.Close()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	v, _, err := syncer.Push(ctx, path)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, before) {
+		t.Error("Push changed the database")
+	}
+
+	// Checking the content of dst before a pull leaves it alone too
+	other := filepath.Join(t.TempDir(), "old.db")
+	conn, err = sql.Open(database.DriverName, other)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := conn.Exec("CREATE TABLE dns_results (fqdn TEXT)"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	conn.Close()
+	before, _ = os.ReadFile(other)
+	if _, _, err := syncer.Pull(ctx, "old.db", v.ID, other, false); err == nil {
+		t.Error("Expected error for a different database without force")
+	}
+	if after, _ := os.ReadFile(other); !bytes.Equal(after, before) {
+		t.Error("Pull changed the database before replacing it")
+	}
+}
+
+func TestPushInvalidName(t *testing.T) {
+	syncer, _ := newTestSyncer(t)
+	path := filepath.Join(t.TempDir(), "my results.json")
+	os.WriteFile(path, []byte("{}"), 0o644)
+	if _, _, err := syncer.Push(context.Background(), path); err == nil {
+		t.Error("Expected error for a name with spaces")
+	}
+}
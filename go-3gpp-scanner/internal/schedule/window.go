@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time-of-day range during which probing is allowed.
+// Windows may cross midnight (e.g. 22:00-06:00).
+type Window struct {
+	Start time.Duration // Offset from local midnight
+	End   time.Duration // Offset from local midnight
+}
+
+// ParseWindow parses a window in "HH:MM-HH:MM" form
+func ParseWindow(s string) (*Window, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid run window %q (expected HH:MM-HH:MM)", s)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid run window start: %w", err)
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid run window end: %w", err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid run window %q: start and end are equal", s)
+	}
+
+	return &Window{Start: start, End: end}, nil
+}
+
+// Contains reports whether t falls inside the window
+func (w *Window) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	// Window crosses midnight
+	return offset >= w.Start || offset < w.End
+}
+
+// NextStart returns the next time the window opens at or after t.
+// If t is already inside the window, t is returned.
+func (w *Window) NextStart(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	next := midnight.Add(w.Start)
+	if !next.After(t) {
+		next = midnight.AddDate(0, 0, 1).Add(w.Start)
+	}
+	return next
+}
+
+// String formats the window as HH:MM-HH:MM
+func (w *Window) String() string {
+	return fmt.Sprintf("%s-%s", formatClock(w.Start), formatClock(w.End))
+}
+
+// parseClock parses "HH:MM" into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
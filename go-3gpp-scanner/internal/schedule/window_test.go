@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectError bool
+	}{
+		{"22:00-06:00", false},
+		{"09:30-17:00", false},
+		{"22:00", true},
+		{"25:00-06:00", true},
+		{"10:00-10:00", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseWindow(tt.input)
+		if tt.expectError && err == nil {
+			t.Errorf("ParseWindow(%q): expected error but got none", tt.input)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("ParseWindow(%q): unexpected error: %v", tt.input, err)
+		}
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	overnight, _ := ParseWindow("22:00-06:00")
+	daytime, _ := ParseWindow("09:00-17:00")
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		window   *Window
+		t        time.Time
+		expected bool
+	}{
+		{overnight, at(23, 0), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(6, 0), false},
+		{overnight, at(12, 0), false},
+		{daytime, at(9, 0), true},
+		{daytime, at(16, 59), true},
+		{daytime, at(17, 0), false},
+		{daytime, at(8, 0), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.window.Contains(tt.t); got != tt.expected {
+			t.Errorf("%s.Contains(%s) = %v, expected %v", tt.window, tt.t.Format("15:04"), got, tt.expected)
+		}
+	}
+}
+
+func TestWindowNextStart(t *testing.T) {
+	w, _ := ParseWindow("22:00-06:00")
+
+	noon := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	expected := time.Date(2024, 1, 15, 22, 0, 0, 0, time.UTC)
+	if got := w.NextStart(noon); !got.Equal(expected) {
+		t.Errorf("NextStart(noon) = %v, expected %v", got, expected)
+	}
+
+	inside := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+	if got := w.NextStart(inside); !got.Equal(inside) {
+		t.Errorf("NextStart inside window should return t, got %v", got)
+	}
+
+	if w.String() != "22:00-06:00" {
+		t.Errorf("Expected String() 22:00-06:00, got %s", w.String())
+	}
+}
@@ -0,0 +1,283 @@
+// Package schema publishes JSON Schemas of the exported result types and
+// validates exports against them, so downstream integrations can rely on a
+// stable, documented format.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema names, one per exported result type
+const (
+	DNSResult   = "dns-result"
+	PingResult  = "ping-result"
+	ProbeResult = "probe-result"
+	Result      = "result"
+	ZoneInfo    = "zone-info"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Names lists the published schemas in order
+func Names() []string {
+	entries, _ := files.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the JSON Schema document of a name
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile("schemas/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema: %s (must be one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
+
+// Validate checks a JSON document against a named schema and returns the
+// first violation with its location, e.g. "[3].status"
+func Validate(name string, data []byte) error {
+	doc, err := Get(name)
+	if err != nil {
+		return err
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return fmt.Errorf("invalid schema %s: %w", name, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	v := validator{root: root}
+	return v.validate(root, value, "")
+}
+
+// ValidateFile checks a JSON file against a named schema
+func ValidateFile(name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := Validate(name, data); err != nil {
+		return fmt.Errorf("%s does not match schema %s: %w", path, name, err)
+	}
+	return nil
+}
+
+// validator implements the subset of JSON Schema the published schemas
+// use: type, enum, minimum, pattern, format date-time, properties,
+// required, additionalProperties, items and local $ref
+type validator struct {
+	root map[string]interface{}
+}
+
+func (v validator) validate(schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return v.validate(resolved, value, path)
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return violation(path, "expected %s, got %s", describeTypes(types), typeOf(value))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return violation(path, "%v is not one of %v", value, enum)
+		}
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if n, isNumber := value.(json.Number); isNumber {
+			if f, _ := n.Float64(); f < minimum {
+				return violation(path, "%s is less than %v", n, minimum)
+			}
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, isString := value.(string); isString {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if !re.MatchString(s) {
+				return violation(path, "%q does not match %s", s, pattern)
+			}
+		}
+	}
+	if schema["format"] == "date-time" {
+		if s, isString := value.(string); isString {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return violation(path, "%q is not an RFC 3339 date-time", s)
+			}
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return v.validateObject(schema, value, path)
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (v validator) validateObject(schema map[string]interface{}, value map[string]interface{}, path string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := value[name.(string)]; !present {
+				return violation(path, "missing required property %q", name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sub := path + "." + key
+		if property, ok := properties[key].(map[string]interface{}); ok {
+			if err := v.validate(property, value[key], sub); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return violation(path, "unexpected property %q", key)
+			}
+		case map[string]interface{}:
+			if err := v.validate(additional, value[key], sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve looks up a local reference such as #/$defs/familyProbe
+func (v validator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref: %s", ref)
+	}
+	var node interface{} = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref: %s", ref)
+		}
+		node = m[part]
+	}
+	resolved, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unresolvable $ref: %s", ref)
+	}
+	return resolved, nil
+}
+
+// matchesType reports whether a value has the schema type, or one of the
+// types of a list
+func matchesType(types interface{}, value interface{}) bool {
+	switch types := types.(type) {
+	case string:
+		return isType(types, value)
+	case []interface{}:
+		for _, t := range types {
+			if s, ok := t.(string); ok && isType(s, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(typ string, value interface{}) bool {
+	switch typ {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return typeOf(value) == typ
+	}
+}
+
+// typeOf names the JSON type of a decoded value
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func describeTypes(types interface{}) string {
+	if list, ok := types.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, t := range list {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+func violation(path, format string, args ...interface{}) error {
+	if path == "" {
+		path = "document"
+	}
+	return fmt.Errorf("%s: %s", strings.TrimPrefix(path, "."), fmt.Sprintf(format, args...))
+}
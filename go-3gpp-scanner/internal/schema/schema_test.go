@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// fullExports are exports of every result type with all fields set, so a
+// field missing from its schema fails additionalProperties
+func fullExports() map[string]interface{} {
+	now := time.Now()
+	dns := models.DNSResult{
+		FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", IPs: []string{"192.0.2.1"}, CNAMEs: []string{"epdg.example."},
+		Subdomain: "epdg.epc", MNC: "01", MCC: "262", Operator: "Telekom", Status: models.StatusOK, Timestamp: now,
+		Country: "Germany", CountryCode: "DE", Brand: "Telekom", OperatorStatus: "Operational",
+	}
+	family := &models.FamilyProbe{IP: "192.0.2.1", Success: true, Latency: time.Millisecond, Status: models.StatusOK}
+	ping := models.PingResult{
+		FQDN: dns.FQDN, Success: true, Latency: 20 * time.Millisecond, IP: "192.0.2.1", Port: 443, ResolvedIPs: []string{"192.0.2.1"}, Method: "https", Status: models.StatusOK,
+		PathMTU: 1500, TLSHandshake: time.Millisecond, HTTPStatus: 200, HTTPServer: "nginx", CertIssuer: "CA", CertSubject: "epdg",
+		CertSANs: []string{dns.FQDN}, CertValid: true, DualStack: &models.DualStack{IPv4: family, IPv6: family, Faster: "ipv4"},
+		Error: "x", Timestamp: now,
+	}
+	probe := models.ProbeResult{
+		FQDN: dns.FQDN, IP: "192.0.2.1", Probe: "ike", Success: true, Status: models.StatusOK, Latency: time.Millisecond,
+		Details: map[string]string{"vendor_id": "strongSwan"}, Error: "x", Timestamp: now,
+	}
+	zone := models.ZoneInfo{
+		Zone: "mnc001.mcc262.pub.3gppnetwork.org", MNC: "001", MCC: "262", SOASerial: 2024010101, PrimaryNS: "ns1.example",
+		Mailbox: "hostmaster.example", NS: []string{"ns1.example"}, MX: []string{"mx.example"}, Error: "x", Timestamp: now,
+	}
+	envelope := ping.Result()
+	envelope.Error = "x"
+
+	return map[string]interface{}{
+		DNSResult:   []models.DNSResult{dns, {FQDN: "ims.example", Timestamp: now}},
+		PingResult:  []models.PingResult{ping, {FQDN: "ims.example", Method: "tcp"}},
+		ProbeResult: []models.ProbeResult{probe},
+		Result:      []models.Result{envelope, dns.Result(), probe.Result()},
+		ZoneInfo:    []models.ZoneInfo{zone},
+	}
+}
+
+func TestSchemasMatchModels(t *testing.T) {
+	exports := fullExports()
+	if len(Names()) != len(exports) {
+		t.Errorf("Names() = %v, want one schema per export type", Names())
+	}
+	for _, name := range Names() {
+		data, err := json.Marshal(exports[name])
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		if err := Validate(name, data); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		data    string
+		wantErr string
+	}{
+		{"not an array", DNSResult, `{}`, "expected array"},
+		{"missing required", ProbeResult, `[{"fqdn": "a", "success": true, "timestamp": "2026-01-01T00:00:00Z"}]`, `[0]: missing required property "probe"`},
+		{"unknown property", ZoneInfo, `[{"zone": "z", "mnc": "001", "mcc": "262", "timestamp": "2026-01-01T00:00:00Z", "ttl": 5}]`, `unexpected property "ttl"`},
+		{"bad status", Result, `[{"target": "a", "type": "ike", "success": false, "status": "LOST", "timestamp": "2026-01-01T00:00:00Z"}]`, "[0].status: LOST is not one of"},
+		{"bad timestamp", Result, `[{"target": "a", "type": "ike", "success": false, "timestamp": "yesterday"}]`, "[0].timestamp"},
+		{"fractional integer", PingResult, `[{"fqdn": "a", "success": true, "method": "tcp", "latency": 1.5, "timestamp": "2026-01-01T00:00:00Z"}]`, "[0].latency: expected integer"},
+		{"numeric mnc", DNSResult, `[{"fqdn": "a", "ips": [], "subdomain": "ims", "mnc": 1, "mcc": "262", "operator": "", "timestamp": "2026-01-01T00:00:00Z"}]`, "[0].mnc: expected string, got number"},
+		{"short mcc", ZoneInfo, `[{"zone": "z", "mnc": "001", "mcc": "26", "timestamp": "2026-01-01T00:00:00Z"}]`, `[0].mcc: "26" does not match`},
+		{"negative serial", ZoneInfo, `[{"zone": "z", "mnc": "001", "mcc": "262", "soa_serial": -1, "timestamp": "2026-01-01T00:00:00Z"}]`, "[0].soa_serial: -1 is less than 0"},
+		{"bad nested ref", PingResult, `[{"fqdn": "a", "success": true, "method": "tcp", "timestamp": "2026-01-01T00:00:00Z", "dual_stack": {"ipv4": {"ip": "x"}}}]`, `[0].dual_stack.ipv4: missing required property "success"`},
+		{"non-string metadata", Result, `[{"target": "a", "type": "ike", "success": false, "timestamp": "2026-01-01T00:00:00Z", "metadata": {"n": 1}}]`, "[0].metadata.n: expected string"},
+		{"unknown schema", "stats", `[]`, "unknown schema: stats"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, []byte(`[{"fqdn": "a"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidateFile(DNSResult, path)
+	if err == nil || !strings.Contains(err.Error(), "results.json does not match schema dns-result") {
+		t.Errorf("ValidateFile = %v", err)
+	}
+}
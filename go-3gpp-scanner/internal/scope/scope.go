@@ -0,0 +1,304 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"3gpp-scanner/internal/dns"
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error reports why a target is out of scope; it matches
+// exclude.ErrExcluded, so targets outside the scope are handled like
+// excluded ones
+type Error struct {
+	Target string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s is out of scope: %s", e.Target, e.Reason)
+}
+
+// Is makes errors.Is(err, exclude.ErrExcluded) hold
+func (e *Error) Is(target error) bool {
+	return target == exclude.ErrExcluded
+}
+
+// zonePLMN returns a PLMN with the three-digit MNC of its zone name, so
+// 234-15 in a scope matches mnc015.mcc234
+func zonePLMN(mcc, mnc string) dns.PLMN {
+	return dns.PLMN{MCC: models.NormalizeMCC(mcc), MNC: models.PadMNC(mnc)}
+}
+
+// Scope is the engagement manifest: the networks and address ranges a run
+// may touch. It is loaded from YAML (or JSON):
+//
+//	name: ACME mobile core assessment 2026-10
+//	mccs: ["262"]                # Every operator in Germany
+//	plmns: ["234-15", "234-010"] # Single networks, MCC-MNC
+//	operators: ["Telia"]         # Operator or brand names from the MCC-MNC list
+//	cidrs: ["203.0.113.0/24"]    # Addresses that may be contacted
+//
+// A target is in scope when its FQDN belongs to one of the listed
+// networks (if any are listed) and every address it resolves to lies in
+// one of the CIDRs (if any are listed). Everything else is refused, so
+// names without an mncXXX.mccYYY zone are out of scope as soon as
+// networks are declared. A nil *Scope allows everything.
+type Scope struct {
+	Name      string   `yaml:"name"`
+	MCCs      []string `yaml:"mccs"`
+	PLMNs     []string `yaml:"plmns"`
+	Operators []string `yaml:"operators"`
+	CIDRs     []string `yaml:"cidrs"`
+
+	mccs       map[string]bool
+	plmns      map[dns.PLMN]bool     // Keyed by zonePLMN
+	operators  map[string]bool       // Lowercased names
+	operatorOf map[dns.PLMN][]string // Lowercased operator and brand, see SetOperators
+	networks   []*net.IPNet
+
+	mu      sync.Mutex
+	skipped map[string]string // Target -> reason
+}
+
+// Load reads a scope file; see Scope for the format
+func Load(path string) (*Scope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scope file: %w", err)
+	}
+
+	s := &Scope{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse scope file: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// compile validates the declared rules and builds the lookup tables
+func (s *Scope) compile() error {
+	s.mccs = make(map[string]bool)
+	s.plmns = make(map[dns.PLMN]bool)
+	s.operators = make(map[string]bool)
+	s.skipped = make(map[string]string)
+
+	for _, mcc := range s.MCCs {
+		mcc = strings.TrimSpace(mcc)
+		if !models.IsCode(mcc) || len(mcc) != 3 {
+			return fmt.Errorf("invalid MCC %q (must be three digits)", mcc)
+		}
+		s.mccs[mcc] = true
+	}
+	for _, entry := range s.PLMNs {
+		p, err := dns.ParsePLMN(entry)
+		if err != nil {
+			return err
+		}
+		s.plmns[zonePLMN(p.MCC, p.MNC)] = true
+	}
+	for _, name := range s.Operators {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return fmt.Errorf("operator names cannot be empty")
+		}
+		s.operators[name] = true
+	}
+	for _, cidr := range s.CIDRs {
+		cidr = strings.TrimSpace(cidr)
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			s.networks = append(s.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		s.networks = append(s.networks, network)
+	}
+
+	if !s.hasNetworkRules() && len(s.networks) == 0 {
+		return fmt.Errorf("scope declares no mccs, plmns, operators or cidrs")
+	}
+	return nil
+}
+
+// hasNetworkRules reports whether targets are restricted by network
+func (s *Scope) hasNetworkRules() bool {
+	return len(s.mccs) > 0 || len(s.plmns) > 0 || len(s.operators) > 0
+}
+
+// NeedsOperators reports whether names can only be vetted with the
+// MCC-MNC list (see SetOperators)
+func (s *Scope) NeedsOperators() bool {
+	return s != nil && len(s.operators) > 0
+}
+
+// SetOperators supplies the MCC-MNC list used to map the PLMN of an FQDN
+// to its operator. Without it, names are never in scope by operator.
+func (s *Scope) SetOperators(entries []models.MCCMNCEntry) {
+	s.operatorOf = make(map[dns.PLMN][]string)
+	for _, entry := range entries {
+		p, ok := entryPLMN(entry)
+		if !ok {
+			continue
+		}
+		for _, name := range []string{entry.Operator, entry.Brand} {
+			if name != "" {
+				s.operatorOf[p] = append(s.operatorOf[p], strings.ToLower(name))
+			}
+		}
+	}
+}
+
+// entryPLMN returns the zonePLMN of a list entry
+func entryPLMN(entry models.MCCMNCEntry) (dns.PLMN, bool) {
+	p := zonePLMN(entry.MCC, entry.MNC)
+	return p, models.IsCode(p.MCC) && models.IsCode(p.MNC)
+}
+
+// Summary describes the declared rules in one line
+func (s *Scope) Summary() string {
+	var parts []string
+	add := func(n int, what string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, what))
+		}
+	}
+	add(len(s.mccs), "MCCs")
+	add(len(s.plmns), "PLMNs")
+	add(len(s.operators), "operators")
+	add(len(s.networks), "CIDRs")
+	return strings.Join(parts, ", ")
+}
+
+// inNetworks reports whether a PLMN belongs to the declared networks
+func (s *Scope) inNetworks(p dns.PLMN, operators []string) bool {
+	if s.mccs[p.MCC] || s.plmns[p] {
+		return true
+	}
+	for _, name := range operators {
+		if s.operators[strings.ToLower(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterEntries keeps the MCC-MNC entries inside the scope. Entries are
+// planning input rather than targets, so dropped ones are not recorded
+// as skips.
+func (s *Scope) FilterEntries(entries []models.MCCMNCEntry) []models.MCCMNCEntry {
+	if s == nil || !s.hasNetworkRules() {
+		return entries
+	}
+	kept := make([]models.MCCMNCEntry, 0, len(entries))
+	for _, entry := range entries {
+		p, ok := entryPLMN(entry)
+		if ok && s.inNetworks(p, []string{entry.Operator, entry.Brand}) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// HasNetworks implements exclude.Checker
+func (s *Scope) HasNetworks() bool {
+	return s != nil && len(s.networks) > 0
+}
+
+// CheckName implements exclude.Checker: it returns an *Error when the
+// FQDN lies outside the declared networks and records the skip
+func (s *Scope) CheckName(fqdn string) error {
+	if s == nil || !s.hasNetworkRules() {
+		return nil
+	}
+	_, mnc, mcc, ok := dns.ZoneFromFQDN(fqdn, "")
+	if !ok {
+		return s.record(fqdn, "no mncXXX.mccYYY zone in name")
+	}
+	p := zonePLMN(mcc, mnc)
+	if !s.inNetworks(p, s.operatorOf[p]) {
+		return s.record(fqdn, "PLMN "+p.String()+" not in scope")
+	}
+	return nil
+}
+
+// CheckIP implements exclude.Checker: it returns an *Error when CIDRs are
+// declared and the address lies outside all of them. Without CIDRs every
+// address passes, so callers must gate each probe of a name on CheckName.
+func (s *Scope) CheckIP(ip net.IP) error {
+	if s == nil || s.containsIP(ip) {
+		return nil
+	}
+	return s.record(ip.String(), "outside scope CIDRs")
+}
+
+// CheckTarget implements exclude.Checker; a skip caused by an address is
+// recorded under the FQDN
+func (s *Scope) CheckTarget(fqdn string, ips []string) error {
+	if err := s.CheckName(fqdn); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if !s.containsIP(net.ParseIP(ip)) {
+			return s.record(fqdn, "address "+ip+" outside scope CIDRs")
+		}
+	}
+	return nil
+}
+
+// containsIP reports whether an address may be contacted
+func (s *Scope) containsIP(ip net.IP) bool {
+	if len(s.networks) == 0 {
+		return true
+	}
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// record notes a target refused by the scope and returns its error
+func (s *Scope) record(target, reason string) error {
+	s.mu.Lock()
+	s.skipped[target] = reason
+	s.mu.Unlock()
+	return &Error{Target: target, Reason: reason}
+}
+
+// Skipped implements exclude.Checker, sorted by target. The rule of each
+// skip is the reason the target was out of scope.
+func (s *Scope) Skipped() []exclude.Skip {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	skips := make([]exclude.Skip, 0, len(s.skipped))
+	for target, reason := range s.skipped {
+		skips = append(skips, exclude.Skip{Target: target, Rule: reason})
+	}
+	sort.Slice(skips, func(i, j int) bool { return skips[i].Target < skips[j].Target })
+	return skips
+}
@@ -0,0 +1,179 @@
+package scope
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3gpp-scanner/internal/exclude"
+	"3gpp-scanner/internal/models"
+)
+
+func writeScope(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scope.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	s, err := Load(writeScope(t, `
+name: test engagement
+mccs: ["262"]
+plmns: ["234-15", "310-410"]
+operators: [Telia]
+cidrs: ["203.0.113.0/24", "198.51.100.7"]
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.Name != "test engagement" {
+		t.Errorf("Name = %q", s.Name)
+	}
+	if got := s.Summary(); got != "1 MCCs, 2 PLMNs, 1 operators, 2 CIDRs" {
+		t.Errorf("Summary = %q", got)
+	}
+	if !s.NeedsOperators() || !s.HasNetworks() {
+		t.Errorf("expected operator and network rules")
+	}
+
+	// JSON is valid YAML
+	if _, err := Load(writeScope(t, `{"plmns": ["262-01"]}`)); err != nil {
+		t.Errorf("Load JSON: %v", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":     "name: nothing declared\n",
+		"short MCC": `mccs: ["26"]`,
+		"bad PLMN":  `plmns: ["262/01"]`,
+		"bad CIDR":  `cidrs: ["203.0.113.0/33"]`,
+		"operator":  `operators: [""]`,
+		"syntax":    "mccs: [262",
+	}
+	for name, content := range tests {
+		if _, err := Load(writeScope(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	s, err := Load(writeScope(t, `
+mccs: ["262"]
+plmns: ["234-15"]
+operators: [Telia]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetOperators([]models.MCCMNCEntry{
+		{MCC: "240", MNC: "01", Operator: "Telia Sverige", Brand: "Telia"},
+		{MCC: "240", MNC: "02", Operator: "HI3G Access", Brand: "3"},
+	})
+
+	tests := []struct {
+		fqdn    string
+		inScope bool
+	}{
+		{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", true},
+		{"ims.mnc015.mcc234.pub.3gppnetwork.org", true},
+		{"ims.mnc001.mcc240.pub.3gppnetwork.org", true},
+		{"ims.mnc002.mcc240.pub.3gppnetwork.org", false},
+		{"ims.mnc010.mcc234.pub.3gppnetwork.org", false},
+		{"www.example.org", false},
+	}
+	for _, tt := range tests {
+		err := s.CheckName(tt.fqdn)
+		if tt.inScope && err != nil {
+			t.Errorf("%s: unexpected refusal: %v", tt.fqdn, err)
+		}
+		if !tt.inScope && !errors.Is(err, exclude.ErrExcluded) {
+			t.Errorf("%s: expected ErrExcluded, got %v", tt.fqdn, err)
+		}
+	}
+
+	skipped := s.Skipped()
+	if len(skipped) != 3 {
+		t.Fatalf("Skipped = %v", skipped)
+	}
+	if skipped[0].Target != "ims.mnc002.mcc240.pub.3gppnetwork.org" || skipped[0].Rule != "PLMN 240-002 not in scope" {
+		t.Errorf("Skipped[0] = %v", skipped[0])
+	}
+}
+
+func TestCheckAddresses(t *testing.T) {
+	s, err := Load(writeScope(t, `cidrs: ["203.0.113.0/24"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without network rules any name is in scope
+	if err := s.CheckName("www.example.org"); err != nil {
+		t.Errorf("CheckName: %v", err)
+	}
+	if err := s.CheckIP(net.ParseIP("203.0.113.9")); err != nil {
+		t.Errorf("CheckIP: %v", err)
+	}
+	if err := s.CheckIP(net.ParseIP("192.0.2.1")); !errors.Is(err, exclude.ErrExcluded) {
+		t.Errorf("CheckIP: expected ErrExcluded, got %v", err)
+	}
+
+	err = s.CheckTarget("ims.mnc001.mcc262.pub.3gppnetwork.org", []string{"203.0.113.1", "198.51.100.9"})
+	if !errors.Is(err, exclude.ErrExcluded) {
+		t.Fatalf("CheckTarget: expected ErrExcluded, got %v", err)
+	}
+	want := exclude.Skip{Target: "ims.mnc001.mcc262.pub.3gppnetwork.org", Rule: "address 198.51.100.9 outside scope CIDRs"}
+	found := false
+	for _, skip := range s.Skipped() {
+		if skip == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Skipped = %v, want an entry %v", s.Skipped(), want)
+	}
+}
+
+func TestFilterEntries(t *testing.T) {
+	s, err := Load(writeScope(t, `
+plmns: ["262-01"]
+operators: [telia]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "01", Operator: "Telekom Deutschland"},
+		{MCC: "262", MNC: "02", Operator: "Vodafone D2"},
+		{MCC: "240", MNC: "01", Operator: "Telia Sverige", Brand: "Telia"},
+		{MCC: "240", MNC: "", Operator: "Telia"},
+	}
+	kept := s.FilterEntries(entries)
+	if len(kept) != 2 || kept[0].MNC != "01" || kept[1].MCC != "240" {
+		t.Errorf("FilterEntries kept %v", kept)
+	}
+	if len(s.Skipped()) != 0 {
+		t.Errorf("FilterEntries recorded skips: %v", s.Skipped())
+	}
+}
+
+func TestNilScope(t *testing.T) {
+	var s *Scope
+	if s.HasNetworks() || s.NeedsOperators() || s.Skipped() != nil {
+		t.Errorf("nil scope should be empty")
+	}
+	if err := s.CheckTarget("www.example.org", []string{"192.0.2.1"}); err != nil {
+		t.Errorf("nil scope refused a target: %v", err)
+	}
+	if got := s.FilterEntries([]models.MCCMNCEntry{{MCC: "262"}}); len(got) != 1 {
+		t.Errorf("nil scope filtered entries: %v", got)
+	}
+
+	var _ exclude.Checker = s
+}
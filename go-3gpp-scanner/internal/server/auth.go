@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Role is what an API key may do
+type Role string
+
+// Roles, from least to most privileged
+const (
+	RoleReadOnly  Role = "read-only"
+	RoleReadWrite Role = "read-write"
+)
+
+// allows reports whether a role includes another
+func (r Role) allows(required Role) bool {
+	return r == RoleReadWrite || r == required
+}
+
+// Key is one API key. Only the SHA-256 of the key is stored, so a leaked
+// key file does not grant access.
+type Key struct {
+	Name   string `json:"name"`
+	Role   Role   `json:"role"`
+	SHA256 string `json:"sha256"`
+}
+
+// Keys authenticates API requests. It is loaded from a JSON file:
+//
+//	{
+//	  "keys": [
+//	    {"name": "alice", "role": "read-write", "sha256": "9f86d0..."},
+//	    {"name": "dashboard", "role": "read-only", "sha256": "60303a..."}
+//	  ]
+//	}
+type Keys struct {
+	Keys []Key `json:"keys"`
+
+	byHash map[string]Key
+}
+
+// LoadKeys reads API keys from a JSON file
+func LoadKeys(path string) (*Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	k := &Keys{}
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys: %w", err)
+	}
+	if len(k.Keys) == 0 {
+		return nil, fmt.Errorf("no keys in %s", path)
+	}
+
+	k.byHash = make(map[string]Key, len(k.Keys))
+	for i, key := range k.Keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("key %d: name required", i+1)
+		}
+		if key.Role != RoleReadOnly && key.Role != RoleReadWrite {
+			return nil, fmt.Errorf("key %s: invalid role %q (must be %s or %s)", key.Name, key.Role, RoleReadOnly, RoleReadWrite)
+		}
+		hash := strings.ToLower(key.SHA256)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("key %s: sha256 must be 64 hex digits", key.Name)
+		}
+		if _, dup := k.byHash[hash]; dup {
+			return nil, fmt.Errorf("key %s: duplicate key", key.Name)
+		}
+		k.byHash[hash] = key
+	}
+	return k, nil
+}
+
+// lookup returns the key matching a presented secret
+func (k *Keys) lookup(secret string) (Key, bool) {
+	key, ok := k.byHash[HashKey(secret)]
+	return key, ok
+}
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "3gs_" + hex.EncodeToString(b), nil
+}
+
+// HashKey returns the hex SHA-256 of a key, as stored in the key file
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// presentedKey returns the key sent as "Authorization: Bearer KEY" or
+// "X-API-Key: KEY"
+func presentedKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, key, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(key)
+		}
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+type clientKey struct{}
+
+// client identifies the caller of a request for rate limiting and logs:
+// the key name, or the remote address without keys
+func client(r *http.Request) string {
+	if name, ok := r.Context().Value(clientKey{}).(string); ok {
+		return name
+	}
+	return ""
+}
+
+// authorize wraps a handler so it only runs for callers holding the
+// required role. Without keys every caller is read-only.
+func (s *Server) authorize(required Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, name := RoleReadOnly, remoteHost(r)
+		if s.opts.Keys != nil {
+			secret := presentedKey(r)
+			key, ok := s.opts.Keys.lookup(secret)
+			if !ok {
+				// Failed attempts count against the peer, limiting guessing
+				if !s.limiter.allow(name) {
+					w.Header().Set("Retry-After", "1")
+					writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded"))
+					return
+				}
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				if secret == "" {
+					writeError(w, http.StatusUnauthorized, fmt.Errorf("API key required"))
+				} else {
+					writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid API key"))
+				}
+				return
+			}
+			role, name = key.Role, "key:"+key.Name
+		}
+
+		if !role.allows(required) {
+			if s.opts.Keys == nil {
+				writeError(w, http.StatusForbidden, fmt.Errorf("the server is read-only; writes need an API key with the %s role", RoleReadWrite))
+			} else {
+				writeError(w, http.StatusForbidden, fmt.Errorf("API key lacks the %s role", RoleReadWrite))
+			}
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), clientKey{}, name))
+		if !s.limiter.allow(name) {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// remoteHost is the IP address of the peer of a request
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeKeys writes a key file with a read-only and a read-write key and
+// returns the keys
+func writeKeys(t *testing.T) (path, reader, writer string) {
+	t.Helper()
+	reader, writer = "reader-secret", "writer-secret"
+	path = filepath.Join(t.TempDir(), "keys.json")
+	data := fmt.Sprintf(`{"keys": [
+		{"name": "dashboard", "role": "read-only", "sha256": %q},
+		{"name": "alice", "role": "read-write", "sha256": %q}
+	]}`, HashKey(reader), HashKey(writer))
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, reader, writer
+}
+
+func do(t *testing.T, method, url, key, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestAuthorization(t *testing.T) {
+	path, reader, writer := writeKeys(t)
+	keys, err := LoadKeys(path)
+	if err != nil {
+		t.Fatalf("LoadKeys: %v", err)
+	}
+	srv := testServer(t, Options{Keys: keys})
+	tag := `{"tag": "live", "kind": "fqdn", "targets": ["epdg.epc.mnc000.mcc310.pub.3gppnetwork.org"]}`
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+	}{
+		{name: "no key", method: "GET", path: "/api/fqdns", wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", method: "GET", path: "/api/fqdns", key: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "read-only reads", method: "GET", path: "/api/fqdns", key: reader, wantStatus: http.StatusOK},
+		{name: "read-only writes", method: "POST", path: "/api/tags", key: reader, body: tag, wantStatus: http.StatusForbidden},
+		{name: "read-write writes", method: "POST", path: "/api/tags", key: writer, body: tag, wantStatus: http.StatusOK},
+		{name: "read-write reads", method: "GET", path: "/api/tags", key: writer, wantStatus: http.StatusOK},
+		{name: "invalid tag", method: "POST", path: "/api/tags", key: writer, body: `{"tag": "a b", "kind": "fqdn", "targets": ["x"]}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", method: "POST", path: "/api/notes", key: writer, body: `{"fqdn": "x"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing note", method: "DELETE", path: "/api/notes/42", key: writer, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, tt.method, srv.URL+tt.path, tt.key, tt.body)
+			if status != tt.wantStatus {
+				t.Errorf("got %d, want %d: %s", status, tt.wantStatus, body)
+			}
+		})
+	}
+
+	// Notes round trip with the read-write key
+	status, body := do(t, "POST", srv.URL+"/api/notes", writer, `{"kind": "operator", "target": "Operator 1", "text": "PSIRT contact"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("add note: %d %s", status, body)
+	}
+	var note struct{ ID int64 }
+	json.Unmarshal([]byte(body), &note)
+	if status, body := do(t, "GET", srv.URL+"/api/notes?kind=operator", reader, ""); status != http.StatusOK || !strings.Contains(body, "PSIRT contact") {
+		t.Errorf("list notes: %d %s", status, body)
+	}
+	if status, _ := do(t, "DELETE", fmt.Sprintf("%s/api/notes/%d", srv.URL, note.ID), writer, ""); status != http.StatusNoContent {
+		t.Errorf("delete note: %d", status)
+	}
+}
+
+func TestWritesNeedKeys(t *testing.T) {
+	srv := testServer(t, Options{})
+	status, body := do(t, "POST", srv.URL+"/api/tags", "", `{"tag": "live", "kind": "fqdn", "targets": ["x"]}`)
+	if status != http.StatusForbidden || !strings.Contains(body, "read-only") {
+		t.Errorf("got %d %s, want 403", status, body)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	path, reader, writer := writeKeys(t)
+	keys, err := LoadKeys(path)
+	if err != nil {
+		t.Fatalf("LoadKeys: %v", err)
+	}
+	srv := testServer(t, Options{Keys: keys, RateLimit: 0.001, RateBurst: 2})
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if status, _ := do(t, "GET", srv.URL+"/api/tags", reader, ""); status != want {
+			t.Errorf("request %d: got %d, want %d", i+1, status, want)
+		}
+	}
+	// Buckets are per client
+	if status, _ := do(t, "GET", srv.URL+"/api/tags", writer, ""); status != http.StatusOK {
+		t.Errorf("other key: got %d", status)
+	}
+	// Failed authentication counts against the peer address
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if status, _ := do(t, "GET", srv.URL+"/api/tags", "guess", ""); status != want {
+			t.Errorf("guess %d: got %d, want %d", i+1, status, want)
+		}
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "empty", data: `{"keys": []}`, wantErr: "no keys"},
+		{name: "bad role", data: `{"keys": [{"name": "a", "role": "admin", "sha256": "` + HashKey("x") + `"}]}`, wantErr: "invalid role"},
+		{name: "bad hash", data: `{"keys": [{"name": "a", "role": "read-only", "sha256": "abc"}]}`, wantErr: "64 hex digits"},
+		{name: "no name", data: `{"keys": [{"role": "read-only", "sha256": "` + HashKey("x") + `"}]}`, wantErr: "name required"},
+		{name: "duplicate", data: `{"keys": [{"name": "a", "role": "read-only", "sha256": "` + HashKey("x") + `"}, {"name": "b", "role": "read-write", "sha256": "` + HashKey("x") + `"}]}`, wantErr: "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			os.WriteFile(path, []byte(tt.data), 0600)
+			if _, err := LoadKeys(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	key, err := GenerateKey()
+	if err != nil || len(key) < 40 {
+		t.Errorf("GenerateKey = %q, %v", key, err)
+	}
+}
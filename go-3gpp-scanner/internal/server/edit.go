@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// maxBodyBytes bounds request bodies of the write endpoints
+const maxBodyBytes = 1 << 20
+
+// TagChange is the body of POST and DELETE /api/tags
+type TagChange struct {
+	Tag     string   `json:"tag"`
+	Kind    string   `json:"kind"` // fqdn or operator
+	Targets []string `json:"targets"`
+}
+
+// Changed is the response of POST and DELETE /api/tags
+type Changed struct {
+	Changed int `json:"changed"` // Labels added or removed
+}
+
+// NoteRequest is the body of POST /api/notes
+type NoteRequest struct {
+	Kind   string `json:"kind"` // fqdn or operator
+	Target string `json:"target"`
+	Text   string `json:"text"`
+}
+
+// decodeBody reads a JSON request body into v
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// handleTagChange adds (POST) or removes (DELETE) a tag
+func (s *Server) handleTagChange(w http.ResponseWriter, r *http.Request) {
+	var req TagChange
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Kind != database.TagKindFQDN && req.Kind != database.TagKindOperator {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid tag kind: %s", req.Kind))
+		return
+	}
+	if err := database.ValidateTag(req.Tag); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Targets) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("targets required"))
+		return
+	}
+
+	change, verb := s.db.AddTag, "added"
+	if r.Method == http.MethodDelete {
+		change, verb = s.db.RemoveTag, "removed"
+	}
+	n, err := change(req.Kind, req.Tag, req.Targets)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	slog.Info("tag "+verb, "client", client(r), "tag", req.Tag, "kind", req.Kind, "changed", n)
+	writeJSON(w, http.StatusOK, Changed{Changed: n})
+}
+
+// handleNotes lists notes, optionally of one kind and target
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := s.db.QueryNotes(r.URL.Query().Get("kind"), r.URL.Query().Get("target"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if notes == nil {
+		notes = []models.Note{}
+	}
+	writeJSON(w, http.StatusOK, notes)
+}
+
+func (s *Server) handleNoteAdd(w http.ResponseWriter, r *http.Request) {
+	var req NoteRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Kind != database.TagKindFQDN && req.Kind != database.TagKindOperator {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid note kind: %s", req.Kind))
+		return
+	}
+	if req.Target == "" || strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("target and text required"))
+		return
+	}
+
+	note, err := s.db.AddNote(req.Kind, req.Target, req.Text)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	slog.Info("note added", "client", client(r), "id", note.ID, "kind", note.Kind, "target", note.Target)
+	writeJSON(w, http.StatusCreated, note)
+}
+
+func (s *Server) handleNoteDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid note ID: %s", r.PathValue("id")))
+		return
+	}
+	if err := s.db.DeleteNote(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrNoteNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	slog.Info("note removed", "client", client(r), "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
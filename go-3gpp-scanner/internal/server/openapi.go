@@ -0,0 +1,215 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPI builds an OpenAPI 3.0 description of the API from the endpoint
+// definitions, with JSON schemas derived from the Go types by reflection
+func (s *Server) OpenAPI() map[string]interface{} {
+	g := &schemaGen{schemas: make(map[string]interface{})}
+	errorResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content":     jsonContent(g.schema(reflect.TypeOf(APIError{}))),
+		}
+	}
+
+	paths := make(map[string]interface{})
+	for _, e := range s.endpoints() {
+		op := map[string]interface{}{
+			"operationId": operationID(e),
+			"summary":     e.summary,
+			"description": "Requires the " + string(e.role) + " role.",
+		}
+
+		if len(e.params) > 0 {
+			var params []interface{}
+			for _, p := range e.params {
+				params = append(params, map[string]interface{}{
+					"name":        p.name,
+					"in":          p.in,
+					"required":    p.in == "path",
+					"description": p.description,
+					"schema":      map[string]interface{}{"type": p.typ},
+				})
+			}
+			op["parameters"] = params
+		}
+		if e.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(g.schema(reflect.TypeOf(e.request))),
+			}
+		}
+
+		success := map[string]interface{}{"description": http.StatusText(e.status)}
+		switch {
+		case e.contentType != "":
+			success["content"] = map[string]interface{}{
+				e.contentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		case e.response != nil:
+			success["content"] = jsonContent(g.schema(reflect.TypeOf(e.response)))
+		}
+		responses := map[string]interface{}{strconv.Itoa(e.status): success}
+		if len(e.params) > 0 || e.request != nil {
+			responses["400"] = errorResponse("Invalid parameters or body")
+		}
+		if s.opts.Keys != nil {
+			responses["401"] = errorResponse("Missing or invalid API key")
+		}
+		if e.role == RoleReadWrite {
+			responses["403"] = errorResponse("The key lacks the read-write role, or the server has no keys")
+		}
+		if strings.Contains(e.path, "{id}") {
+			responses["404"] = errorResponse("Not found")
+		}
+		if s.opts.RateLimit > 0 {
+			responses["429"] = errorResponse("Rate limit exceeded")
+		}
+		op["responses"] = responses
+
+		item, _ := paths[e.path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[e.path] = item
+		}
+		item[strings.ToLower(e.method)] = op
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "3gpp-scanner API",
+			"description": "Query scan results stored by 3gpp-scanner and manage tags and notes.",
+			"version":     s.version(),
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	}
+	if s.opts.Keys != nil {
+		components := spec["components"].(map[string]interface{})
+		components["securitySchemes"] = map[string]interface{}{
+			"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+		}
+		spec["security"] = []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		}
+	}
+	return spec
+}
+
+func (s *Server) version() string {
+	if s.opts.Version == "" {
+		return "dev"
+	}
+	return s.opts.Version
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
+
+// operationID derives an operation name from the method and path, e.g.
+// "POST /api/tags" becomes "postTags" and "/api/fqdns.csv" "getFqdnsCsv"
+func operationID(e endpoint) string {
+	id := strings.ToLower(e.method)
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(e.path, "/api/"), func(r rune) bool {
+		return r == '/' || r == '.' || r == '{' || r == '}'
+	}) {
+		if part == "id" {
+			part = "byId"
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// schemaGen turns Go types into JSON schemas, collecting named structs as
+// reusable components
+type schemaGen struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGen) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		s := g.schema(t.Elem())
+		if _, ref := s["$ref"]; ref {
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema registers a struct under its type name and returns a
+// reference to it
+func (g *schemaGen) structSchema(t reflect.Type) map[string]interface{} {
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := g.schemas[t.Name()]; ok {
+		return ref
+	}
+	g.schemas[t.Name()] = nil // Placeholder for recursive types
+
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	g.schemas[t.Name()] = s
+	return ref
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	srv := testServer(t, Options{Version: "1.2.3", RateLimit: 10, RateBurst: 10})
+	status, body := get(t, srv.URL+"/openapi.json")
+	if status != 200 {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+
+	// Compact, so schemas can be matched as substrings
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(body)); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas         map[string]json.RawMessage `json:"schemas"`
+			SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(compact.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" || spec.Info.Version != "1.2.3" {
+		t.Errorf("openapi = %q, version = %q", spec.OpenAPI, spec.Info.Version)
+	}
+
+	// Every registered route is described
+	for _, e := range (&Server{}).endpoints() {
+		if _, ok := spec.Paths[e.path][strings.ToLower(e.method)]; !ok {
+			t.Errorf("missing %s %s", e.method, e.path)
+		}
+	}
+	for _, name := range []string{"Page", "Row", "TagCount", "TagChange", "Changed", "Note", "NoteRequest", "APIError"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("missing schema %s", name)
+		}
+	}
+	if !strings.Contains(string(spec.Components.Schemas["Page"]), `"$ref":"#/components/schemas/Row"`) {
+		t.Errorf("Page schema does not reference Row: %s", spec.Components.Schemas["Page"])
+	}
+	if !strings.Contains(string(spec.Components.Schemas["Note"]), `"format":"date-time"`) {
+		t.Errorf("Note times not date-time: %s", spec.Components.Schemas["Note"])
+	}
+	if !strings.Contains(string(spec.Paths["/api/fqdns"]["get"]), `"429"`) {
+		t.Errorf("rate-limited endpoint lacks 429: %s", spec.Paths["/api/fqdns"]["get"])
+	}
+	if len(spec.Components.SecuritySchemes) != 0 {
+		t.Errorf("security schemes without keys: %v", spec.Components.SecuritySchemes)
+	}
+}
+
+func TestOpenAPIWithKeys(t *testing.T) {
+	path, _, _ := writeKeys(t)
+	keys, err := LoadKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testServer(t, Options{Keys: keys})
+
+	// The spec itself needs no key, so clients can be generated before
+	// one is issued
+	status, body := get(t, srv.URL+"/openapi.json")
+	if status != 200 {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	for _, want := range []string{`"securitySchemes"`, `"X-API-Key"`, `"bearer"`, `"401"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("spec missing %s", want)
+		}
+	}
+}
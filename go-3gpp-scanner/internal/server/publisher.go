@@ -0,0 +1,184 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+const (
+	// publishInterval is how often a Publisher sends queued results
+	publishInterval = 500 * time.Millisecond
+
+	// publishBatch is the most results a Publisher sends at once, well
+	// below the server's request body limit
+	publishBatch = 200
+
+	// publishQueue is how many results a Publisher holds while the server
+	// is slow or down; more are dropped
+	publishQueue = 10000
+)
+
+// Publisher sends results of a running scan or ping to the
+// /api/results endpoint of a serve instance, which streams them to its
+// /api/stream clients. Sending happens in the background in batches, so a
+// slow or unreachable server never holds the scan up; results that cannot
+// be sent are dropped and counted.
+type Publisher struct {
+	url    string
+	key    string
+	client *http.Client
+
+	mu      sync.Mutex
+	pending Results
+	dropped int
+	failing bool // The last send failed; logged once until one succeeds
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPublisher starts a publisher to the serve instance at baseURL, e.g.
+// http://127.0.0.1:8080, authenticating with key if not empty. tlsConfig
+// sets how an https:// server is trusted (see ClientTLSConfig); nil uses
+// the system roots.
+func NewPublisher(baseURL, key string, tlsConfig *tls.Config) *Publisher {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+	p := &Publisher{
+		url:    strings.TrimSuffix(baseURL, "/") + "/api/results",
+		key:    key,
+		client: client,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// PublishDNS queues a DNS result
+func (p *Publisher) PublishDNS(result models.DNSResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queued() >= publishQueue {
+		p.dropped++
+		return
+	}
+	p.pending.DNS = append(p.pending.DNS, result)
+}
+
+// PublishPing queues a ping result
+func (p *Publisher) PublishPing(result models.PingResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queued() >= publishQueue {
+		p.dropped++
+		return
+	}
+	p.pending.Ping = append(p.pending.Ping, result)
+}
+
+func (p *Publisher) queued() int {
+	return len(p.pending.DNS) + len(p.pending.Ping)
+}
+
+// Close sends the remaining results and returns how many were dropped
+func (p *Publisher) Close() int {
+	close(p.stop)
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dropped
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.stop:
+			p.flush()
+			return
+		}
+	}
+}
+
+// flush sends queued results in batches until the queue is empty or a
+// send fails
+func (p *Publisher) flush() {
+	for {
+		batch := p.take()
+		n := len(batch.DNS) + len(batch.Ping)
+		if n == 0 {
+			return
+		}
+		err := p.send(batch)
+
+		p.mu.Lock()
+		if err != nil {
+			p.dropped += n
+			if !p.failing {
+				slog.Warn("failed to publish results, dropping them", "url", p.url, "error", err)
+			}
+		}
+		p.failing = err != nil
+		p.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// take removes up to publishBatch results from the queue
+func (p *Publisher) take() Results {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var batch Results
+	n := min(len(p.pending.DNS), publishBatch)
+	batch.DNS, p.pending.DNS = p.pending.DNS[:n:n], p.pending.DNS[n:]
+	n = min(len(p.pending.Ping), publishBatch-n)
+	batch.Ping, p.pending.Ping = p.pending.Ping[:n:n], p.pending.Ping[n:]
+	return batch
+}
+
+func (p *Publisher) send(batch Results) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.key != "" {
+		req.Header.Set("Authorization", "Bearer "+p.key)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr APIError
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		return fmt.Errorf("%s: %s", resp.Status, apiErr.Error)
+	}
+	return nil
+}
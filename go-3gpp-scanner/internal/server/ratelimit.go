@@ -0,0 +1,66 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long an idle client keeps its limiter
+const idleLimiterTTL = 10 * time.Minute
+
+// clientLimiter rate-limits requests per client with a token bucket each.
+// A zero rate disables limiting.
+type clientLimiter struct {
+	rate  rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*clientBucket
+	pruned  time.Time
+}
+
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientLimiter(perSecond float64, burst int) *clientLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &clientLimiter{
+		rate:    rate.Limit(perSecond),
+		burst:   burst,
+		clients: make(map[string]*clientBucket),
+		pruned:  time.Now(),
+	}
+}
+
+// allow takes a token from the client's bucket
+func (l *clientLimiter) allow(client string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.pruned) > idleLimiterTTL {
+		for name, b := range l.clients {
+			if now.Sub(b.lastSeen) > idleLimiterTTL {
+				delete(l.clients, name)
+			}
+		}
+		l.pruned = now
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = &clientBucket{limiter: rate.NewLimiter(l.rate, l.burst)}
+		l.clients[client] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
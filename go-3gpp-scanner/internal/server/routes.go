@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// param is a query or path parameter of an endpoint
+type param struct {
+	name        string
+	in          string // query or path
+	typ         string // OpenAPI type: string or integer
+	description string
+}
+
+// endpoint defines one API route. The same definitions register the
+// handlers and generate /openapi.json, so the spec cannot drift.
+type endpoint struct {
+	method      string
+	path        string
+	role        Role
+	summary     string
+	params      []param
+	request     interface{} // Zero value of the JSON body type, or nil
+	status      int         // Success status
+	response    interface{} // Zero value of the JSON response type, or nil
+	contentType string      // Non-JSON success content type
+	handler     http.HandlerFunc
+}
+
+// filterParams are the parameters of the FQDN endpoints, see parseFilter
+var filterParams = []param{
+	{"q", "query", "string", "Substring of the FQDN or operator name"},
+	{"mcc", "query", "string", "Mobile Country Code, e.g. 262"},
+	{"mnc", "query", "string", "Mobile Network Code as listed, e.g. 01 or 001 (requires mcc)"},
+	{"operator", "query", "string", "Operator name"},
+	{"subdomain", "query", "string", "Only FQDNs below this subdomain, e.g. epdg"},
+	{"tag", "query", "string", "Only FQDNs carrying this tag, directly or through their operator"},
+	{"order", "query", "string", "fqdn, operator or inserted; - prefix to reverse"},
+	{"limit", "query", "integer", "Maximum rows"},
+	{"offset", "query", "integer", "Rows to skip"},
+}
+
+// endpoints lists the API routes of the server
+func (s *Server) endpoints() []endpoint {
+	return []endpoint{
+		{
+			method: "GET", path: "/api/fqdns", role: RoleReadOnly,
+			summary: "Query FQDN rows with operator, vendor and tags",
+			params:  filterParams, status: http.StatusOK, response: Page{},
+			handler: s.handleFQDNs,
+		},
+		{
+			method: "GET", path: "/api/fqdns.csv", role: RoleReadOnly,
+			summary: "Download matching FQDN rows as CSV",
+			params:  filterParams, status: http.StatusOK, contentType: "text/csv",
+			handler: s.handleFQDNsCSV,
+		},
+		{
+			method: "GET", path: "/api/tags", role: RoleReadOnly,
+			summary: "List tags with their FQDN and operator counts",
+			status:  http.StatusOK, response: []database.TagCount{},
+			handler: s.handleTags,
+		},
+		{
+			method: "POST", path: "/api/tags", role: RoleReadWrite,
+			summary: "Tag FQDNs or operators",
+			request: TagChange{}, status: http.StatusOK, response: Changed{},
+			handler: s.handleTagChange,
+		},
+		{
+			method: "DELETE", path: "/api/tags", role: RoleReadWrite,
+			summary: "Remove a tag from FQDNs or operators",
+			request: TagChange{}, status: http.StatusOK, response: Changed{},
+			handler: s.handleTagChange,
+		},
+		{
+			method: "GET", path: "/api/notes", role: RoleReadOnly,
+			summary: "List analyst notes, oldest first",
+			params: []param{
+				{"kind", "query", "string", "fqdn or operator"},
+				{"target", "query", "string", "FQDN or operator name"},
+			},
+			status: http.StatusOK, response: []models.Note{},
+			handler: s.handleNotes,
+		},
+		{
+			method: "POST", path: "/api/notes", role: RoleReadWrite,
+			summary: "Add a note to an FQDN or operator",
+			request: NoteRequest{}, status: http.StatusCreated, response: models.Note{},
+			handler: s.handleNoteAdd,
+		},
+		{
+			method: "DELETE", path: "/api/notes/{id}", role: RoleReadWrite,
+			summary: "Remove a note",
+			params:  []param{{"id", "path", "integer", "Note ID"}},
+			status:  http.StatusNoContent,
+			handler: s.handleNoteDelete,
+		},
+		{
+			method: "GET", path: "/api/stream", role: RoleReadOnly,
+			summary: "Stream results of running scans as server-sent events (dns and ping events, JSON data)",
+			params:  []param{{"type", "query", "string", "Only dns, ping or result events"}},
+			status:  http.StatusOK, contentType: "text/event-stream",
+			handler: s.handleStream,
+		},
+		{
+			method: "GET", path: "/api/results", role: RoleReadOnly,
+			summary: "List stored probe results in the common envelope, oldest first",
+			params: []param{
+				{"target", "query", "string", "FQDN"},
+				{"type", "query", "string", "Result type: dns, ping or a probe name"},
+			},
+			status: http.StatusOK, response: []models.Result{},
+			handler: s.handleResults,
+		},
+		{
+			method: "POST", path: "/api/results", role: RoleReadWrite,
+			summary: "Publish results of a running scan to stream clients",
+			request: Results{}, status: http.StatusOK, response: Published{},
+			handler: s.handlePublish,
+		},
+	}
+}
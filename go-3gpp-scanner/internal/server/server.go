@@ -0,0 +1,251 @@
+// Package server exposes the database over HTTP: a JSON and CSV API, a
+// live stream of results from running scans, protected by optional
+// role-based API keys and per-client rate limits, and an optional embedded
+// web viewer.
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// Page sizes of /api/fqdns
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Options configures a Server
+type Options struct {
+	Viewer    bool    // Serve the web viewer at /
+	Keys      *Keys   // API keys; nil serves everyone read-only
+	RateLimit float64 // Requests per second per client; 0 disables limiting
+	RateBurst int     // Requests a client may send at once
+	Version   string  // Scanner version reported in /openapi.json
+}
+
+// Server answers HTTP requests from a database
+type Server struct {
+	db      *database.DB
+	opts    Options
+	limiter *clientLimiter
+	hub     *hub
+}
+
+// New creates a server backed by db
+func New(db *database.DB, opts Options) *Server {
+	return &Server{db: db, opts: opts, limiter: newClientLimiter(opts.RateLimit, opts.RateBurst), hub: newHub()}
+}
+
+// Handler returns the HTTP handler of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, e := range s.endpoints() {
+		mux.HandleFunc(e.method+" "+e.path, s.authorize(e.role, e.handler))
+	}
+	// The spec describes the API without data, so it needs no key
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	if s.opts.Viewer {
+		mux.Handle("GET /", viewerHandler())
+	}
+	return mux
+}
+
+// Row is one FQDN in API responses, with the vendor stored by the
+// fingerprint command and its tags
+type Row struct {
+	FQDN     string   `json:"fqdn"`
+	Operator string   `json:"operator"`
+	Vendor   string   `json:"vendor,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Page is the response of /api/fqdns
+type Page struct {
+	Total  int   `json:"total"`
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+	Rows   []Row `json:"rows"`
+}
+
+// parseFilter reads the filter, order and paging parameters shared by the
+// FQDN endpoints
+func parseFilter(r *http.Request) (database.FQDNFilter, database.QueryOptions, error) {
+	q := r.URL.Query()
+	f := database.FQDNFilter{
+		Operator:  q.Get("operator"),
+		Subdomain: q.Get("subdomain"),
+		Tag:       q.Get("tag"),
+		Search:    q.Get("q"),
+	}
+	opts := database.QueryOptions{OrderBy: q.Get("order")}
+
+	// MNCs keep their digits: mnc=01 and mnc=001 are different networks
+	for _, p := range []struct {
+		name      string
+		dst       *string
+		normalize func(string) string
+	}{{"mcc", &f.MCC, models.NormalizeMCC}, {"mnc", &f.MNC, models.NormalizeMNC}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		if !models.IsCode(v) {
+			return f, opts, fmt.Errorf("invalid %s: %s", p.name, v)
+		}
+		*p.dst = p.normalize(v)
+	}
+
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &opts.Limit}, {"offset", &opts.Offset}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		if *p.dst, err = strconv.Atoi(v); err != nil || *p.dst < 0 {
+			return f, opts, fmt.Errorf("invalid %s: %s", p.name, v)
+		}
+	}
+	if f.MNC != "" && f.MCC == "" {
+		return f, opts, fmt.Errorf("mnc requires mcc")
+	}
+	if err := database.ValidateOrderBy(opts.OrderBy); err != nil {
+		return f, opts, err
+	}
+	return f, opts, nil
+}
+
+// rows queries FQDN rows and decorates them with vendors and tags
+func (s *Server) rows(f database.FQDNFilter, opts database.QueryOptions) ([]Row, error) {
+	fqdns, err := s.db.QueryRows(f, opts)
+	if err != nil {
+		return nil, err
+	}
+	vendors, err := s.db.QueryVendors()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.db.FQDNTags()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, len(fqdns))
+	for i, r := range fqdns {
+		rows[i] = Row{FQDN: r.FQDN, Operator: r.Operator, Vendor: vendors[r.FQDN], Tags: tags[r.FQDN]}
+	}
+	return rows, nil
+}
+
+func (s *Server) handleFQDNs(w http.ResponseWriter, r *http.Request) {
+	f, opts, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageSize
+	}
+	if opts.Limit > maxPageSize {
+		opts.Limit = maxPageSize
+	}
+
+	total, err := s.db.CountRows(f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	rows, err := s.rows(f, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Page{Total: total, Offset: opts.Offset, Limit: opts.Limit, Rows: rows})
+}
+
+// handleFQDNsCSV downloads every row matching the filter unless a limit
+// is given
+func (s *Server) handleFQDNsCSV(w http.ResponseWriter, r *http.Request) {
+	f, opts, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rows, err := s.rows(f, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="fqdns.csv"`)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"FQDN", "Operator", "Vendor", "Tags"})
+	for _, row := range rows {
+		writer.Write([]string{row.FQDN, row.Operator, row.Vendor, strings.Join(row.Tags, ",")})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		slog.Debug("CSV download aborted", "error", err)
+	}
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.db.CountTags()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if counts == nil {
+		counts = []database.TagCount{}
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
+
+// handleResults lists stored results, optionally of one target and type
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	results, err := s.db.QueryResults(r.URL.Query().Get("target"), r.URL.Query().Get("type"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if results == nil {
+		results = []models.Result{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Debug("response aborted", "error", err)
+	}
+}
+
+// APIError is the body of error responses
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an APIError with a status code
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		slog.Warn("request failed", "error", err)
+	}
+	writeJSON(w, status, APIError{Error: err.Error()})
+}
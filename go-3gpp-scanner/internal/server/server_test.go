@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// testServer serves a database of 30 FQDNs over three operators, with
+// Operator 1 tagged "customer-x" and an IKE probe result of the first FQDN
+func testServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	results := make([]models.DNSResult, 30)
+	for i := range results {
+		results[i] = models.DNSResult{
+			FQDN:     fmt.Sprintf("epdg.epc.mnc%03d.mcc310.pub.3gppnetwork.org", i),
+			MNC:      fmt.Sprintf("%02d", i%3),
+			MCC:      "310",
+			Operator: fmt.Sprintf("Operator %d", i%3),
+		}
+	}
+	if err := db.InsertResults(results); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+	if _, err := db.AddTag(database.TagKindOperator, "customer-x", []string{"Operator 1"}); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	probe := models.ProbeResult{FQDN: results[0].FQDN, Probe: "ike", IP: "192.0.2.1", Success: true, Details: map[string]string{"vendor_id": "strongSwan"}}
+	if err := db.SaveResults([]models.Result{probe.Result()}); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+
+	srv := httptest.NewServer(New(db, opts).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestFQDNs(t *testing.T) {
+	srv := testServer(t, Options{})
+
+	tests := []struct {
+		name      string
+		query     string
+		wantTotal int
+		wantRows  int
+		wantFirst string
+	}{
+		{name: "all", query: "", wantTotal: 30, wantRows: 30, wantFirst: "epdg.epc.mnc000.mcc310.pub.3gppnetwork.org"},
+		{name: "page", query: "?limit=5&offset=10&order=-fqdn", wantTotal: 30, wantRows: 5, wantFirst: "epdg.epc.mnc019.mcc310.pub.3gppnetwork.org"},
+		{name: "tag", query: "?tag=customer-x", wantTotal: 10, wantRows: 10, wantFirst: "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org"},
+		{name: "search", query: "?q=mnc02", wantTotal: 10, wantRows: 10, wantFirst: "epdg.epc.mnc020.mcc310.pub.3gppnetwork.org"},
+		{name: "operator", query: "?operator=Operator+2&limit=2", wantTotal: 10, wantRows: 2, wantFirst: "epdg.epc.mnc002.mcc310.pub.3gppnetwork.org"},
+		{name: "mnc", query: "?mcc=310&mnc=2&limit=1", wantTotal: 10, wantRows: 1, wantFirst: "epdg.epc.mnc002.mcc310.pub.3gppnetwork.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(t, srv.URL+"/api/fqdns"+tt.query)
+			if status != http.StatusOK {
+				t.Fatalf("status %d: %s", status, body)
+			}
+			var page Page
+			if err := json.Unmarshal([]byte(body), &page); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if page.Total != tt.wantTotal || len(page.Rows) != tt.wantRows || page.Rows[0].FQDN != tt.wantFirst {
+				t.Errorf("got total %d, %d rows starting %s", page.Total, len(page.Rows), page.Rows[0].FQDN)
+			}
+		})
+	}
+
+	status, body := get(t, srv.URL+"/api/fqdns?tag=customer-x&limit=1")
+	if status != http.StatusOK || !strings.Contains(body, `"tags": [`) || !strings.Contains(body, `"operator": "Operator 1"`) {
+		t.Errorf("tagged row: %d %s", status, body)
+	}
+
+	for _, query := range []string{"?mnc=1", "?mcc=310&mnc=1234", "?limit=x", "?order=ip"} {
+		if status, body := get(t, srv.URL+"/api/fqdns"+query); status != http.StatusBadRequest || !strings.Contains(body, `"error"`) {
+			t.Errorf("%s: got %d %s, want 400", query, status, body)
+		}
+	}
+}
+
+func TestResults(t *testing.T) {
+	srv := testServer(t, Options{})
+
+	for query, want := range map[string]int{"": 1, "?type=ike": 1, "?type=sip": 0, "?target=epdg.epc.mnc001.mcc310.pub.3gppnetwork.org": 0} {
+		status, body := get(t, srv.URL+"/api/results"+query)
+		if status != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", query, status, body)
+		}
+		var results []models.Result
+		if err := json.Unmarshal([]byte(body), &results); err != nil {
+			t.Fatalf("%s: decode: %v", query, err)
+		}
+		if len(results) != want {
+			t.Errorf("%s: got %d results, want %d", query, len(results), want)
+		}
+		if len(results) > 0 && results[0].Metadata["vendor_id"] != "strongSwan" {
+			t.Errorf("%s: result = %+v", query, results[0])
+		}
+	}
+}
+
+func TestFQDNsCSV(t *testing.T) {
+	srv := testServer(t, Options{})
+
+	status, body := get(t, srv.URL+"/api/fqdns.csv?tag=customer-x")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 11 || lines[0] != "FQDN,Operator,Vendor,Tags" || !strings.HasSuffix(lines[1], ",Operator 1,,customer-x") {
+		t.Errorf("unexpected CSV:\n%s", body)
+	}
+}
+
+func TestViewer(t *testing.T) {
+	status, body := get(t, testServer(t, Options{Viewer: true}).URL+"/")
+	if status != http.StatusOK || !strings.Contains(body, "api/fqdns") {
+		t.Errorf("viewer: %d", status)
+	}
+
+	if status, _ := get(t, testServer(t, Options{}).URL+"/"); status != http.StatusNotFound {
+		t.Errorf("viewer disabled: got %d, want 404", status)
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Stream event types
+const (
+	EventDNS    = "dns"
+	EventPing   = "ping"
+	EventResult = "result" // Any result in the common envelope, e.g. of a probe
+)
+
+const (
+	// subscriberBuffer is how many events a slow stream client may fall
+	// behind before events to it are dropped
+	subscriberBuffer = 256
+
+	// keepAliveInterval is how often an idle stream sends a comment, so
+	// proxies do not close it
+	keepAliveInterval = 15 * time.Second
+)
+
+// Results is the body of POST /api/results: results of a running scan,
+// ping or probe, published to /api/stream clients but not stored
+type Results struct {
+	DNS     []models.DNSResult  `json:"dns,omitempty"`
+	Ping    []models.PingResult `json:"ping,omitempty"`
+	Results []models.Result     `json:"results,omitempty"`
+}
+
+// Published is the response of POST /api/results
+type Published struct {
+	Published int `json:"published"` // Results sent to stream clients
+	Clients   int `json:"clients"`   // Connected stream clients
+}
+
+// event is one server-sent event
+type event struct {
+	typ  string
+	data []byte
+}
+
+// hub fans published events out to stream subscribers. Publishing never
+// blocks: a subscriber whose buffer is full misses events.
+type hub struct {
+	mu     sync.Mutex
+	subs   map[chan event]struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan event]struct{}), closed: make(chan struct{})}
+}
+
+func (h *hub) subscribe() chan event {
+	ch := make(chan event, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+}
+
+// publish sends an event to every subscriber
+func (h *hub) publish(e event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+			slog.Debug("stream client too slow, dropping event", "type", e.typ)
+		}
+	}
+}
+
+// clients returns the number of subscribers
+func (h *hub) clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// close ends all streams
+func (h *hub) close() {
+	h.once.Do(func() { close(h.closed) })
+}
+
+// Close ends open /api/stream connections, which http.Server.Shutdown
+// would otherwise wait for. Register it with RegisterOnShutdown.
+func (s *Server) Close() {
+	s.hub.close()
+}
+
+// handleStream sends published results as server-sent events until the
+// client goes away. ?type=dns, ping or result selects one kind.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	only := r.URL.Query().Get("type")
+	if only != "" && only != EventDNS && only != EventPing && only != EventResult {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid type: %s (must be %s, %s or %s)", only, EventDNS, EventPing, EventResult))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	ch := s.hub.subscribe()
+	defer s.hub.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e := <-ch:
+			if only != "" && e.typ != only {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.typ, e.data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.hub.closed:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handlePublish sends results posted by a running scan to stream clients
+func (s *Server) handlePublish(w http.ResponseWriter, r *http.Request) {
+	var req Results
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var resp Published
+	publish := func(typ string, v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			slog.Warn("failed to encode result", "error", err)
+			return
+		}
+		s.hub.publish(event{typ: typ, data: data})
+		resp.Published++
+	}
+	for _, result := range req.DNS {
+		publish(EventDNS, result)
+	}
+	for _, result := range req.Ping {
+		publish(EventPing, result)
+	}
+	for _, result := range req.Results {
+		publish(EventResult, result)
+	}
+	resp.Clients = s.hub.clients()
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// readEvents reads n server-sent events from a stream, skipping comments
+func readEvents(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var events []string
+	var current []string
+	for len(events) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v (got %v)", err, events)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if len(current) > 0 {
+				events = append(events, strings.Join(current, "\n"))
+				current = nil
+			}
+		case !strings.HasPrefix(line, ":"):
+			current = append(current, line)
+		}
+	}
+	return events
+}
+
+// openStream connects to /api/stream and waits until it is subscribed
+func openStream(t *testing.T, url, key string) *bufio.Reader {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+	// The first comment is sent after subscribing
+	if line, err := r.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	return r
+}
+
+func TestStream(t *testing.T) {
+	path, reader, writer := writeKeys(t)
+	keys, err := LoadKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testServer(t, Options{Keys: keys})
+
+	// Streams need a key like every other endpoint
+	if status, _ := get(t, srv.URL+"/api/stream"); status != http.StatusUnauthorized {
+		t.Errorf("stream without key: status = %d", status)
+	}
+	if status, _ := do(t, "GET", srv.URL+"/api/stream?type=bogus", reader, ""); status != http.StatusBadRequest {
+		t.Errorf("invalid type: status = %d", status)
+	}
+
+	all := openStream(t, srv.URL+"/api/stream", reader)
+	pings := openStream(t, srv.URL+"/api/stream?type=ping", reader)
+
+	body := `{"dns": [{"fqdn": "epdg.epc.mnc001.mcc001.pub.3gppnetwork.org", "ips": ["192.0.2.1"]}],
+		"ping": [{"fqdn": "epdg.epc.mnc001.mcc001.pub.3gppnetwork.org", "success": true, "method": "tcp"}],
+		"results": [{"target": "epdg.epc.mnc001.mcc001.pub.3gppnetwork.org", "type": "ike", "metadata": {"vendor_id": "strongSwan"}}]}`
+	status, out := do(t, "POST", srv.URL+"/api/results", writer, body)
+	if status != http.StatusOK || !strings.Contains(out, `"published": 3`) || !strings.Contains(out, `"clients": 2`) {
+		t.Fatalf("publish: %d %s", status, out)
+	}
+
+	events := readEvents(t, all, 3)
+	if !strings.HasPrefix(events[0], "event: dns\ndata: {") || !strings.Contains(events[0], `"192.0.2.1"`) {
+		t.Errorf("dns event = %q", events[0])
+	}
+	if !strings.HasPrefix(events[1], "event: ping\ndata: {") || !strings.Contains(events[1], `"success":true`) {
+		t.Errorf("ping event = %q", events[1])
+	}
+	if !strings.HasPrefix(events[2], "event: result\ndata: {") || !strings.Contains(events[2], `"vendor_id":"strongSwan"`) {
+		t.Errorf("result event = %q", events[2])
+	}
+	if events := readEvents(t, pings, 1); !strings.HasPrefix(events[0], "event: ping\n") {
+		t.Errorf("filtered stream event = %q", events[0])
+	}
+}
+
+func TestPublishNeedsWriteKey(t *testing.T) {
+	// Without keys the server is read-only, so nothing can be published
+	srv := testServer(t, Options{})
+	if status, _ := do(t, "POST", srv.URL+"/api/results", "", `{"dns": []}`); status != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", status)
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	path, reader, writer := writeKeys(t)
+	keys, err := LoadKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := testServer(t, Options{Keys: keys})
+
+	r := openStream(t, srv.URL+"/api/stream?type=dns", reader)
+
+	p := NewPublisher(srv.URL+"/", writer, nil)
+	for i := 0; i < publishBatch+5; i++ {
+		p.PublishDNS(models.DNSResult{FQDN: "ims.example", Timestamp: time.Now()})
+	}
+	if dropped := p.Close(); dropped != 0 {
+		t.Errorf("dropped = %d", dropped)
+	}
+	if events := readEvents(t, r, publishBatch+5); len(events) != publishBatch+5 {
+		t.Errorf("got %d events", len(events))
+	}
+
+	// A read-only key cannot publish; results are dropped, not retried
+	p = NewPublisher(srv.URL, reader, nil)
+	p.PublishPing(models.PingResult{FQDN: "ims.example"})
+	if dropped := p.Close(); dropped != 1 {
+		t.Errorf("read-only key: dropped = %d, want 1", dropped)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// selfSignedValidity is how long generated certificates are valid
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// GenerateSelfSigned creates a self-signed ECDSA certificate for hosts
+// (names or IP addresses) and returns it and its key PEM-encoded
+func GenerateSelfSigned(hosts []string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "3gpp-scanner serve", Organization: []string{"3gpp-scanner"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else if h != "" {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// LoadOrCreateCert loads a certificate and key. With create, a missing
+// pair is generated for hosts first and written to the files, so the
+// certificate stays the same across restarts; empty paths keep the
+// generated pair in memory only. Only a pair missing both files is
+// generated: a lone key or certificate is an error, never overwritten.
+// created reports whether the pair was generated.
+func LoadOrCreateCert(certFile, keyFile string, create bool, hosts []string) (cert tls.Certificate, created bool, err error) {
+	if create && certFile != "" {
+		switch certExists, keyExists := fileExists(certFile), fileExists(keyFile); {
+		case certExists && !keyExists:
+			return tls.Certificate{}, false, fmt.Errorf("certificate %s exists without its key %s", certFile, keyFile)
+		case keyExists && !certExists:
+			return tls.Certificate{}, false, fmt.Errorf("key %s exists without its certificate %s", keyFile, certFile)
+		case certExists:
+			create = false
+		}
+	}
+	if create {
+		certPEM, keyPEM, err := GenerateSelfSigned(hosts)
+		if err != nil {
+			return tls.Certificate{}, false, fmt.Errorf("failed to generate certificate: %w", err)
+		}
+		if certFile != "" {
+			if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+				return tls.Certificate{}, false, fmt.Errorf("failed to write key: %w", err)
+			}
+			if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+				return tls.Certificate{}, false, fmt.Errorf("failed to write certificate: %w", err)
+			}
+		}
+		cert, err = tls.X509KeyPair(certPEM, keyPEM)
+		return cert, true, err
+	}
+
+	cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return cert, false, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate as colon
+// separated hex, as shown by browsers and openssl, for pinning
+func Fingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	return fingerprintDER(cert.Certificate[0])
+}
+
+// fingerprintDER formats the SHA-256 fingerprint of a DER certificate
+func fingerprintDER(der []byte) string {
+	sum := sha256.Sum256(der)
+	hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+	pairs := make([]string, 0, len(sum))
+	for i := 0; i < len(hexSum); i += 2 {
+		pairs = append(pairs, hexSum[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+// ClientTLSConfig returns the TLS settings for a client of a serve
+// instance. caFile trusts the PEM certificates it holds instead of the
+// system roots. fingerprint pins the server certificate to the one serve
+// printed at startup, whoever signed it, which suits self-signed lab
+// servers. With neither it returns nil, the defaults.
+func ClientTLSConfig(caFile, fingerprint string) (*tls.Config, error) {
+	switch {
+	case caFile != "" && fingerprint != "":
+		return nil, fmt.Errorf("trust a CA file or pin a fingerprint, not both")
+	case caFile != "":
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates in %s", caFile)
+		}
+		return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+	case fingerprint != "":
+		want, err := ParseFingerprint(fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// The chain is not verified; the pin replaces it
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return fmt.Errorf("server sent no certificate")
+				}
+				if got := fingerprintDER(rawCerts[0]); got != want {
+					return fmt.Errorf("server certificate fingerprint %s does not match the pinned %s", got, want)
+				}
+				return nil
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ParseFingerprint normalizes a SHA-256 fingerprint given with or without
+// colons, in either case, to the format of Fingerprint
+func ParseFingerprint(s string) (string, error) {
+	hexSum := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid fingerprint %q (must be 32 hex bytes, as printed by serve)", s)
+	}
+	pairs := make([]string, 0, len(sum))
+	for i := 0; i < len(hexSum); i += 2 {
+		pairs = append(pairs, hexSum[i:i+2])
+	}
+	return strings.Join(pairs, ":"), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestLoadOrCreateCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	cert, created, err := LoadOrCreateCert(certFile, keyFile, true, []string{"localhost", "127.0.0.1"})
+	if err != nil || !created {
+		t.Fatalf("create: created=%v, %v", created, err)
+	}
+	again, created, err := LoadOrCreateCert(certFile, keyFile, true, nil)
+	if err != nil || created {
+		t.Fatalf("reload: created=%v, %v", created, err)
+	}
+	if Fingerprint(cert) != Fingerprint(again) || len(Fingerprint(cert)) != 95 {
+		t.Errorf("fingerprint changed or malformed: %s vs %s", Fingerprint(cert), Fingerprint(again))
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.VerifyHostname("localhost") != nil || leaf.VerifyHostname("127.0.0.1") != nil {
+		t.Errorf("certificate does not cover its hosts: %v %v", leaf.DNSNames, leaf.IPAddresses)
+	}
+
+	if _, _, err := LoadOrCreateCert(filepath.Join(dir, "missing.pem"), keyFile, false, nil); err == nil {
+		t.Error("loading a missing certificate succeeded")
+	}
+
+	// A key without its certificate is the user's, not to be replaced
+	if err := os.Remove(certFile); err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadOrCreateCert(certFile, keyFile, true, nil); err == nil {
+		t.Error("creating next to a lone key succeeded")
+	}
+	if after, _ := os.ReadFile(keyFile); !bytes.Equal(after, keyPEM) {
+		t.Error("the lone key was overwritten")
+	}
+	if fileExists(certFile) {
+		t.Error("a certificate was written for the lone key")
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	cert, _, err := LoadOrCreateCert("", "", true, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Clients trusting the generated certificate connect without warnings
+	pool := x509.NewCertPool()
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	pool.AddCert(leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET over TLS: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestPublisherTLS(t *testing.T) {
+	certPEM, keyPEM, err := GenerateSelfSigned([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var posts atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		w.Write([]byte(`{"published": 1}`))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "serve.crt")
+	if err := os.WriteFile(caFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := LoadOrCreateCert("", "", true, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		caFile      string
+		fingerprint string
+		want        bool
+	}{
+		{"system roots", "", "", false},
+		{"CA file", caFile, "", true},
+		{"pinned", "", strings.ToLower(strings.ReplaceAll(Fingerprint(cert), ":", "")), true},
+		{"other pin", "", Fingerprint(other), false},
+	}
+	for _, tt := range tests {
+		config, err := ClientTLSConfig(tt.caFile, tt.fingerprint)
+		if err != nil {
+			t.Fatalf("%s: ClientTLSConfig: %v", tt.name, err)
+		}
+		before := posts.Load()
+		p := NewPublisher(srv.URL, "", config)
+		p.PublishDNS(models.DNSResult{FQDN: "ims.example"})
+		dropped := p.Close()
+		if delivered := dropped == 0 && posts.Load() > before; delivered != tt.want {
+			t.Errorf("%s: delivered = %v (dropped %d), want %v", tt.name, delivered, dropped, tt.want)
+		}
+	}
+
+	if _, err := ClientTLSConfig(caFile, Fingerprint(cert)); err == nil {
+		t.Error("Expected error for a CA file and a pin together")
+	}
+	if _, err := ClientTLSConfig("", "AB:CD"); err == nil {
+		t.Error("Expected error for a short fingerprint")
+	}
+}
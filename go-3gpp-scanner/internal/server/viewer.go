@@ -0,0 +1,19 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed viewer
+var viewerFiles embed.FS
+
+// viewerHandler serves the single-page viewer, which reads /api/fqdns
+func viewerHandler() http.Handler {
+	sub, err := fs.Sub(viewerFiles, "viewer")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	return http.FileServer(http.FS(sub))
+}
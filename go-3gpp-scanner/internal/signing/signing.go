@@ -0,0 +1,235 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestSuffix is appended to an exported file's name to form its manifest path
+const ManifestSuffix = ".manifest.json"
+
+// FileDigest records the hash of one signed file
+type FileDigest struct {
+	Path   string `json:"path"` // Relative to the manifest's directory
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest is a detached, signed description of exported files
+type Manifest struct {
+	Version   int          `json:"version"`
+	Tool      string       `json:"tool"`
+	Created   time.Time    `json:"created"`
+	Files     []FileDigest `json:"files"`
+	PublicKey string       `json:"public_key"`          // base64 Ed25519 public key
+	Signature string       `json:"signature,omitempty"` // base64 signature over the unsigned manifest
+}
+
+// GenerateKey creates an Ed25519 key pair and writes PEM-encoded PKCS#8
+// private and PKIX public keys
+func GenerateKey(privPath, pubPath string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	if err := writePEM(privPath, "PRIVATE KEY", privDER, 0600); err != nil {
+		return err
+	}
+	return writePEM(pubPath, "PUBLIC KEY", pubDER, 0644)
+}
+
+// LoadPrivateKey reads a PEM PKCS#8 Ed25519 private key (as produced by
+// GenerateKey or `openssl genpkey -algorithm ed25519`)
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not Ed25519")
+	}
+	return priv, nil
+}
+
+// LoadPublicKey reads a PEM PKIX Ed25519 public key
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not Ed25519")
+	}
+	return pub, nil
+}
+
+// Fingerprint returns a short hex identifier of a public key
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignFiles hashes the given files and writes a signed manifest to manifestPath
+func SignFiles(priv ed25519.PrivateKey, tool string, files []string, manifestPath string) (*Manifest, error) {
+	baseDir := filepath.Dir(manifestPath)
+
+	m := &Manifest{
+		Version:   1,
+		Tool:      tool,
+		Created:   time.Now().UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+	}
+
+	for _, file := range files {
+		digest, size, err := hashFile(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			rel = file
+		}
+		m.Files = append(m.Files, FileDigest{Path: filepath.ToSlash(rel), SHA256: digest, Size: size})
+	}
+
+	payload, err := m.signedPayload()
+	if err != nil {
+		return nil, err
+	}
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// VerifyManifest checks a manifest's signature and the hashes of the files
+// it lists. If trusted is non-nil, the manifest must be signed by that key.
+func VerifyManifest(manifestPath string, trusted ed25519.PublicKey) (*Manifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	pubBytes, err := base64.StdEncoding.DecodeString(m.PublicKey)
+	if err != nil || len(pubBytes) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("manifest has an invalid public key")
+	}
+	pub := ed25519.PublicKey(pubBytes)
+	if trusted != nil && !pub.Equal(trusted) {
+		return nil, fmt.Errorf("manifest signed by untrusted key %s", Fingerprint(pub))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("manifest has an invalid signature encoding")
+	}
+	payload, err := m.signedPayload()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	baseDir := filepath.Dir(manifestPath)
+	for _, f := range m.Files {
+		digest, size, err := hashFile(filepath.Join(baseDir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, err
+		}
+		if digest != f.SHA256 || size != f.Size {
+			return nil, fmt.Errorf("%s has been modified (hash mismatch)", f.Path)
+		}
+	}
+
+	return &m, nil
+}
+
+// signedPayload is the canonical encoding covered by the signature
+func (m *Manifest) signedPayload() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return data, nil
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
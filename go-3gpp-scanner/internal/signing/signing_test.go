@@ -0,0 +1,74 @@
+package signing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "key.pem")
+	pubPath := filepath.Join(dir, "key.pub.pem")
+
+	if err := GenerateKey(privPath, pubPath); err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	priv, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey failed: %v", err)
+	}
+	pub, err := LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey failed: %v", err)
+	}
+
+	dataPath := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(dataPath, []byte(`[{"fqdn":"ims.mnc001.mcc310.pub.3gppnetwork.org"}]`), 0644); err != nil {
+		t.Fatalf("Failed to write data file: %v", err)
+	}
+
+	manifestPath := dataPath + ManifestSuffix
+	if _, err := SignFiles(priv, "3gpp-scanner/test", []string{dataPath}, manifestPath); err != nil {
+		t.Fatalf("SignFiles failed: %v", err)
+	}
+
+	m, err := VerifyManifest(manifestPath, pub)
+	if err != nil {
+		t.Fatalf("VerifyManifest failed: %v", err)
+	}
+	if len(m.Files) != 1 || m.Files[0].Path != "results.json" {
+		t.Errorf("Unexpected manifest files: %+v", m.Files)
+	}
+
+	// Tampering with the data must be detected
+	if err := os.WriteFile(dataPath, []byte(`[]`), 0644); err != nil {
+		t.Fatalf("Failed to modify data file: %v", err)
+	}
+	if _, err := VerifyManifest(manifestPath, pub); err == nil {
+		t.Errorf("Expected verification failure after tampering")
+	}
+}
+
+func TestVerifyUntrustedKey(t *testing.T) {
+	dir := t.TempDir()
+	GenerateKey(filepath.Join(dir, "a.pem"), filepath.Join(dir, "a.pub"))
+	GenerateKey(filepath.Join(dir, "b.pem"), filepath.Join(dir, "b.pub"))
+
+	privA, _ := LoadPrivateKey(filepath.Join(dir, "a.pem"))
+	pubB, _ := LoadPublicKey(filepath.Join(dir, "b.pub"))
+
+	dataPath := filepath.Join(dir, "results.csv")
+	os.WriteFile(dataPath, []byte("FQDN\n"), 0644)
+	manifestPath := dataPath + ManifestSuffix
+	if _, err := SignFiles(privA, "test", []string{dataPath}, manifestPath); err != nil {
+		t.Fatalf("SignFiles failed: %v", err)
+	}
+
+	if _, err := VerifyManifest(manifestPath, pubB); err == nil {
+		t.Errorf("Expected error when verifying against a different trusted key")
+	}
+	if _, err := VerifyManifest(manifestPath, nil); err != nil {
+		t.Errorf("Expected self-consistent manifest to verify without a trusted key: %v", err)
+	}
+}
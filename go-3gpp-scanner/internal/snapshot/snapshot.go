@@ -0,0 +1,252 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/database"
+)
+
+// timeLayout names snapshots so they sort chronologically
+const timeLayout = "20060102T150405Z"
+
+// fileSuffix marks gzip-compressed database copies
+const fileSuffix = ".db.gz"
+
+// validLabel restricts labels to safe file name parts
+var validLabel = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Snapshot is a compressed point-in-time copy of a database
+type Snapshot struct {
+	Name    string // File name without suffix, e.g. 20260101T120000Z-pre-import
+	Path    string
+	Created time.Time
+	Label   string
+	Size    int64 // Compressed size in bytes
+}
+
+// Store keeps the snapshots of one database in a directory
+type Store struct {
+	DBPath    string
+	Dir       string
+	DBOptions []database.Option // Connection options used when copying
+	now       func() time.Time
+}
+
+// NewStore creates a store for a database. An empty dir selects
+// "<db>.snapshots" next to the database.
+func NewStore(dbPath, dir string) *Store {
+	if dir == "" {
+		dir = dbPath + ".snapshots"
+	}
+	return &Store{DBPath: dbPath, Dir: dir, now: time.Now}
+}
+
+// Create snapshots the database, optionally labelled
+func (s *Store) Create(label string) (*Snapshot, error) {
+	if label != "" && !validLabel.MatchString(label) {
+		return nil, fmt.Errorf("invalid label %q (use letters, digits, - and _)", label)
+	}
+	if _, err := os.Stat(s.DBPath); err != nil {
+		return nil, fmt.Errorf("database not found: %w", err)
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	created := s.now().UTC()
+	name := created.Format(timeLayout)
+	if label != "" {
+		name += "-" + label
+	}
+	path := filepath.Join(s.Dir, name+fileSuffix)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	// Copy through SQLite so concurrent writers cannot tear the copy, and
+	// read-only so the snapshot keeps the schema it was taken of
+	tmpDir, err := os.MkdirTemp(s.Dir, ".create-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	copyPath := filepath.Join(tmpDir, "copy.db")
+
+	db, err := database.OpenReadOnly(s.DBPath, s.DBOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	err = db.BackupTo(copyPath)
+	db.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := compressFile(copyPath, path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Name: name, Path: path, Created: created, Label: label, Size: info.Size()}, nil
+}
+
+// List returns the snapshots from oldest to newest
+func (s *Store) List() ([]Snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*"+fileSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), fileSuffix)
+		stamp, label, _ := strings.Cut(name, "-")
+		created, err := time.Parse(timeLayout, stamp)
+		if err != nil {
+			continue // Not written by Create
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{Name: name, Path: file, Created: created, Label: label, Size: info.Size()})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots, nil
+}
+
+// Find returns the snapshot with the given name, or the only one whose
+// name starts with it
+func (s *Store) Find(name string) (*Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var matches []Snapshot
+	for _, snap := range snapshots {
+		if snap.Name == name {
+			return &snap, nil
+		}
+		if strings.HasPrefix(snap.Name, name) {
+			matches = append(matches, snap)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("snapshot name %s is ambiguous (%d matches)", name, len(matches))
+	}
+}
+
+// Restore replaces the database with a snapshot. The current database is
+// snapshotted first (label "pre-restore") so the restore can be undone;
+// that snapshot is returned, or nil when there was no database.
+func (s *Store) Restore(name string) (*Snapshot, error) {
+	snap, err := s.Find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decompress next to the database so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(s.DBPath), filepath.Base(s.DBPath)+".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := decompressFile(snap.Path, tmpPath); err != nil {
+		return nil, err
+	}
+
+	var backup *Snapshot
+	if _, err := os.Stat(s.DBPath); err == nil {
+		if backup, err = s.Create("pre-restore"); err != nil {
+			return nil, fmt.Errorf("failed to snapshot current database: %w", err)
+		}
+	}
+
+	// Stale WAL files would be replayed into the restored database
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(s.DBPath + suffix); err != nil && !os.IsNotExist(err) {
+			return backup, fmt.Errorf("failed to remove %s: %w", s.DBPath+suffix, err)
+		}
+	}
+	if err := os.Rename(tmpPath, s.DBPath); err != nil {
+		return backup, fmt.Errorf("failed to replace database: %w", err)
+	}
+	return backup, nil
+}
+
+// compressFile gzips src into dst
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	return out.Close()
+}
+
+// decompressFile gunzips src into dst and checks it is a SQLite database
+func decompressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer in.Close()
+
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("snapshot %s is corrupt: %w", filepath.Base(src), err)
+	}
+	defer zr.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	header := make([]byte, len(database.SQLiteHeader))
+	if _, err := io.ReadFull(zr, header); err != nil || !bytes.Equal(header, database.SQLiteHeader) {
+		return fmt.Errorf("snapshot %s is not a SQLite database", filepath.Base(src))
+	}
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, zr); err != nil {
+		return fmt.Errorf("snapshot %s is corrupt: %w", filepath.Base(src), err)
+	}
+	return out.Close()
+}
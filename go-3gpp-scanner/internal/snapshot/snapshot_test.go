@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/database"
+	"3gpp-scanner/internal/models"
+)
+
+// insert adds one FQDN to the database at path
+func insert(t *testing.T, path, fqdn string) {
+	t.Helper()
+	db, err := database.NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+	if err := db.InsertResults([]models.DNSResult{{FQDN: fqdn, MNC: "01", MCC: "262", Operator: "Telekom"}}); err != nil {
+		t.Fatalf("InsertResults: %v", err)
+	}
+}
+
+// fqdns lists the FQDNs in the database at path
+func fqdns(t *testing.T, path string) []string {
+	t.Helper()
+	db, err := database.NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer db.Close()
+	all, err := db.GetAllFQDNs()
+	if err != nil {
+		t.Fatalf("GetAllFQDNs: %v", err)
+	}
+	return all
+}
+
+func TestCreateLeavesDatabaseAlone(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A database of an older release, which NewDB would migrate
+	conn, err := sql.Open(database.DriverName, dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := conn.Exec("CREATE TABLE dns_results (id INTEGER PRIMARY KEY, fqdn TEXT)"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	conn.Close()
+	before, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if _, err := NewStore(dbPath, "").Create("pre-upgrade"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if after, _ := os.ReadFile(dbPath); !bytes.Equal(after, before) {
+		t.Error("Create changed the database")
+	}
+}
+
+func TestCreateListRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "database.db")
+	insert(t, dbPath, "ims.mnc001.mcc262.pub.3gppnetwork.org")
+
+	store := NewStore(dbPath, "")
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	snap, err := store.Create("pre-import")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if snap.Name != "20260102T030406Z-pre-import" || snap.Label != "pre-import" || snap.Size == 0 {
+		t.Errorf("Unexpected snapshot: %+v", snap)
+	}
+	if filepath.Dir(snap.Path) != dbPath+".snapshots" {
+		t.Errorf("Expected snapshot next to the database, got %s", snap.Path)
+	}
+
+	// A risky import goes wrong
+	insert(t, dbPath, "bogus.example")
+	if got := fqdns(t, dbPath); len(got) != 2 {
+		t.Fatalf("Expected 2 FQDNs before restore, got %v", got)
+	}
+
+	backup, err := store.Restore("20260102T0304")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if backup == nil || backup.Label != "pre-restore" {
+		t.Errorf("Expected a pre-restore snapshot, got %+v", backup)
+	}
+	if got := fqdns(t, dbPath); len(got) != 1 || got[0] != "ims.mnc001.mcc262.pub.3gppnetwork.org" {
+		t.Errorf("Expected the snapshot contents after restore, got %v", got)
+	}
+
+	snapshots, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Label != "pre-import" || snapshots[1].Label != "pre-restore" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+
+	// Both snapshots share the prefix now
+	if _, err := store.Find("2026"); err == nil {
+		t.Errorf("Expected ambiguous name error")
+	}
+	if _, err := store.Find("missing"); err == nil {
+		t.Errorf("Expected not found error")
+	}
+}
+
+func TestCreateRejectsBadInput(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "missing.db"), "")
+	if _, err := store.Create(""); err == nil {
+		t.Errorf("Expected error for a missing database")
+	}
+
+	dbPath := filepath.Join(dir, "database.db")
+	insert(t, dbPath, "ims.example")
+	if _, err := NewStore(dbPath, "").Create("../escape"); err == nil {
+		t.Errorf("Expected error for an unsafe label")
+	}
+}
+
+func TestRestoreRejectsCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "database.db")
+	insert(t, dbPath, "ims.example")
+
+	store := NewStore(dbPath, filepath.Join(dir, "snaps"))
+	if err := os.MkdirAll(store.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(store.Dir, "20260101T000000Z"+fileSuffix)
+	if err := os.WriteFile(bad, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Restore("20260101T000000Z"); err == nil {
+		t.Fatalf("Expected error restoring a corrupt snapshot")
+	}
+	if got := fqdns(t, dbPath); len(got) != 1 {
+		t.Errorf("Database must be untouched after a failed restore, got %v", got)
+	}
+}
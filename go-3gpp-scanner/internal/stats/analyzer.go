@@ -2,12 +2,15 @@ package stats
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
 
+	"3gpp-scanner/internal/countries"
+	"3gpp-scanner/internal/ipclass"
 	"3gpp-scanner/internal/models"
 )
 
@@ -23,7 +26,7 @@ func NewAnalyzer() *Analyzer {
 	return &Analyzer{
 		mccPattern:       regexp.MustCompile(`mcc(\d+)\.`),
 		mncPattern:       regexp.MustCompile(`mnc(\d+)\.`),
-		subdomainPattern: regexp.MustCompile(`^([^.]+)\.`),
+		subdomainPattern: regexp.MustCompile(`^(\S+?)\.mnc(\d+)\.mcc(\d+)\.`),
 	}
 }
 
@@ -43,6 +46,7 @@ func (a *Analyzer) AnalyzeFile(filePath string) (*models.Stats, error) {
 
 	scanner := bufio.NewScanner(file)
 	ipSet := make(map[string]bool)
+	fqdnIPs := make(map[string][]string)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -58,10 +62,10 @@ func (a *Analyzer) AnalyzeFile(filePath string) (*models.Stats, error) {
 			stats.MCCDistribution[mcc]++
 		}
 
-		// Extract subdomain type
-		if matches := a.subdomainPattern.FindStringSubmatch(line); len(matches) > 1 {
-			subdomain := matches[1]
-			stats.SubdomainCounts[subdomain]++
+		// Extract the service: everything in front of the operator zone
+		// (mncXXX.mccYYY.<root>), e.g. "epdg.epc"
+		if matches := a.subdomainPattern.FindStringSubmatch(strings.ToLower(line)); len(matches) > 3 {
+			countService(stats, matches[1], matches[2], matches[3])
 		}
 
 		// Track IPs if the line contains them
@@ -70,6 +74,7 @@ func (a *Analyzer) AnalyzeFile(filePath string) (*models.Stats, error) {
 			for _, part := range parts[1:] {
 				ipSet[part] = true
 			}
+			fqdnIPs[parts[0]] = append(fqdnIPs[parts[0]], parts[1:]...)
 		}
 	}
 
@@ -78,9 +83,52 @@ func (a *Analyzer) AnalyzeFile(filePath string) (*models.Stats, error) {
 	}
 
 	stats.TotalIPs = len(ipSet)
+	countIPClasses(stats, fqdnIPs)
 	return stats, nil
 }
 
+// LoadResults reads DNS results from a JSON export written by scan --output
+func LoadResults(filePath string) ([]models.DNSResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var results []models.DNSResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse results JSON: %w", err)
+	}
+	return results, nil
+}
+
+// LoadPingResults reads ping results from a JSON export written by ping --output
+func LoadPingResults(filePath string) ([]models.PingResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var results []models.PingResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse ping results JSON: %w", err)
+	}
+	return results, nil
+}
+
+// LoadProbeResults reads probe results from a JSON export written by probe --output
+func LoadProbeResults(filePath string) ([]models.ProbeResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var results []models.ProbeResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse probe results JSON: %w", err)
+	}
+	return results, nil
+}
+
 // AnalyzeResults analyzes DNS results directly
 func (a *Analyzer) AnalyzeResults(results []models.DNSResult) *models.Stats {
 	stats := &models.Stats{
@@ -91,34 +139,103 @@ func (a *Analyzer) AnalyzeResults(results []models.DNSResult) *models.Stats {
 
 	operatorSet := make(map[string]bool)
 	ipSet := make(map[string]bool)
+	fqdnIPs := make(map[string][]string)
 
 	for _, result := range results {
 		stats.TotalFQDNs++
 
+		// Outcome classification
+		if result.Status != "" {
+			if stats.StatusCounts == nil {
+				stats.StatusCounts = make(map[string]int)
+			}
+			stats.StatusCounts[string(result.Status)]++
+		}
+
 		// MCC distribution
-		mcc := fmt.Sprintf("%d", result.MCC)
-		stats.MCCDistribution[mcc]++
+		stats.MCCDistribution[models.NormalizeMCC(result.MCC)]++
 
 		// Subdomain counts
-		stats.SubdomainCounts[result.Subdomain]++
+		countService(stats, result.Subdomain, result.MNC, result.MCC)
+
+		// Country of the operator, stored since results carry it
+		if result.CountryCode != "" {
+			stats.CountryCounts[strings.ToUpper(result.CountryCode)]++
+		}
 
-		// Unique operators
+		// Unique operators, and FQDNs per operator
 		operatorSet[result.Operator] = true
+		if stats.OperatorCounts == nil {
+			stats.OperatorCounts = make(map[string]int)
+			stats.OperatorSubdomainCounts = make(map[string]map[string]int)
+		}
+		stats.OperatorCounts[result.Operator]++
+		addCrossTab(stats.OperatorSubdomainCounts, result.Operator, result.Subdomain, 1)
 
 		// Track IPs
 		for _, ip := range result.IPs {
 			ipSet[ip] = true
 		}
+		fqdnIPs[result.FQDN] = append(fqdnIPs[result.FQDN], result.IPs...)
 	}
 
 	stats.UniqueOperators = len(operatorSet)
 	stats.TotalIPs = len(ipSet)
+	countIPClasses(stats, fqdnIPs)
 
 	return stats
 }
 
-// FormatStats formats statistics for display
+// countIPClasses fills the IP version and class counts and the FQDNs
+// resolving to private addresses
+func countIPClasses(stats *models.Stats, fqdnIPs map[string][]string) {
+	versions, classes, private := ipclass.Tally(fqdnIPs)
+	if len(versions) > 0 {
+		stats.IPVersionCounts = versions
+		stats.IPClassCounts = classes
+	}
+	stats.PrivateIPFQDNs = private
+}
+
+// countService counts an FQDN of a service in the subdomain distribution
+// and its MCC and network cross-tabs
+func countService(stats *models.Stats, subdomain, mnc, mcc string) {
+	stats.SubdomainCounts[subdomain]++
+	if stats.SubdomainMCCCounts == nil {
+		stats.SubdomainMCCCounts = make(map[string]map[string]int)
+		stats.SubdomainNetworkCounts = make(map[string]map[string]int)
+	}
+	addCrossTab(stats.SubdomainMCCCounts, subdomain, models.NormalizeMCC(mcc), 1)
+	addCrossTab(stats.SubdomainNetworkCounts, subdomain, PLMNKey(mcc, mnc), 1)
+}
+
+// addCrossTab adds n to the cell of a cross-tab
+func addCrossTab(tab map[string]map[string]int, row, column string, n int) {
+	if tab[row] == nil {
+		tab[row] = make(map[string]int)
+	}
+	tab[row][column] += n
+}
+
+// DefaultTop is the number of rows FormatStats shows of each distribution
+const DefaultTop = 10
+
+// FormatStats formats statistics for display, with the DefaultTop rows of
+// each distribution
 func FormatStats(stats *models.Stats) string {
+	return FormatStatsTop(stats, DefaultTop)
+}
+
+// FormatStatsTop formats statistics for display. The MCC, country,
+// operator and subdomain distributions show their top rows with a share of the total
+// each, and roll the rest up into an "others" row; top 0 shows all rows.
+func FormatStatsTop(stats *models.Stats, top int) string {
+	return FormatStatsNames(stats, top, "")
+}
+
+// FormatStatsNames formats statistics like FormatStatsTop, naming MCCs
+// and countries in a style, e.g. "Germany (262)" instead of "MCC 262"
+func FormatStatsNames(stats *models.Stats, top int, names countries.NameStyle) string {
 	var sb strings.Builder
 
 	sb.WriteString("=== 3GPP Scanner Statistics ===\n\n")
@@ -127,23 +244,82 @@ func FormatStats(stats *models.Stats) string {
 	sb.WriteString(fmt.Sprintf("Unique Operators: %d\n\n", stats.UniqueOperators))
 
 	// MCC Distribution
-	if len(stats.MCCDistribution) > 0 {
-		sb.WriteString("MCC Distribution (Top 10):\n")
-		mccPairs := sortMapByValue(stats.MCCDistribution)
-		for i, pair := range mccPairs {
-			if i >= 10 {
+	writeDistribution(&sb, "MCC Distribution", stats.MCCDistribution, top, func(mcc string) string {
+		if label := countries.MCCLabel(mcc, names); label != mcc {
+			return label
+		}
+		return "MCC " + mcc
+	})
+
+	// Country Distribution (filled in when an MCC-MNC list is available)
+	writeDistribution(&sb, "Country Distribution", stats.CountryCounts, top, func(code string) string {
+		if code == "" {
+			return "unknown"
+		}
+		return countries.CodeLabel(code, names)
+	})
+
+	// Operator Distribution (filled in from results, a database, or an
+	// MCC-MNC list)
+	writeDistribution(&sb, "Operator Distribution", stats.OperatorCounts, top, func(operator string) string {
+		if operator == "" {
+			return "unknown"
+		}
+		return operator
+	})
+
+	// Subdomain Distribution
+	writeDistribution(&sb, "Subdomain Distribution", stats.SubdomainCounts, top, nil)
+
+	// Address families and routability of the distinct IPs
+	if len(stats.IPVersionCounts) > 0 {
+		sb.WriteString("IP Addresses:\n")
+		sb.WriteString(fmt.Sprintf("  IPv4: %d, IPv6: %d\n", stats.IPVersionCounts[ipclass.IPv4], stats.IPVersionCounts[ipclass.IPv6]))
+		var classes []string
+		for _, class := range ipclass.Classes {
+			classes = append(classes, fmt.Sprintf("%s: %d", class, stats.IPClassCounts[string(class)]))
+		}
+		sb.WriteString("  " + strings.Join(classes, ", ") + "\n\n")
+	}
+	if len(stats.PrivateIPFQDNs) > 0 {
+		sb.WriteString(fmt.Sprintf("FQDNs Resolving to Private Addresses (%d):\n", len(stats.PrivateIPFQDNs)))
+		for i, fqdn := range stats.PrivateIPFQDNs {
+			if top > 0 && i >= top {
+				sb.WriteString(fmt.Sprintf("  ... %d more (--top=0 lists all)\n", len(stats.PrivateIPFQDNs)-top))
 				break
 			}
-			sb.WriteString(fmt.Sprintf("  MCC %s: %d\n", pair.Key, pair.Value))
+			sb.WriteString("  " + fqdn + "\n")
 		}
 		sb.WriteString("\n")
 	}
 
-	// Subdomain Distribution
-	if len(stats.SubdomainCounts) > 0 {
-		sb.WriteString("Subdomain Distribution:\n")
-		subPairs := sortMapByValue(stats.SubdomainCounts)
-		for _, pair := range subPairs {
+	// Subdomain cross-tabs, for the subdomains shown above
+	if len(stats.SubdomainMCCCounts) > 0 {
+		sb.WriteString("Subdomains by MCC (Top 5):\n")
+		sb.WriteString(formatCrossTab(stats.SubdomainMCCCounts, stats.SubdomainCounts, top, 5, func(mcc string) string {
+			return countries.MCCLabel(mcc, names)
+		}))
+		sb.WriteString("\n")
+	}
+	if len(stats.SubdomainNetworkCounts) > 0 {
+		sb.WriteString("Subdomains by Network (Top 5):\n")
+		sb.WriteString(formatCrossTab(stats.SubdomainNetworkCounts, stats.SubdomainCounts, top, 5, nil))
+		sb.WriteString("\n")
+	}
+
+	// Status Distribution
+	if len(stats.StatusCounts) > 0 {
+		sb.WriteString("Status Distribution:\n")
+		for _, pair := range sortMapByValue(stats.StatusCounts) {
+			sb.WriteString(fmt.Sprintf("  %s: %d\n", pair.Key, pair.Value))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Vendor Distribution (filled in once endpoints are fingerprinted)
+	if len(stats.VendorCounts) > 0 {
+		sb.WriteString("Vendor Distribution:\n")
+		for _, pair := range sortMapByValue(stats.VendorCounts) {
 			sb.WriteString(fmt.Sprintf("  %s: %d\n", pair.Key, pair.Value))
 		}
 		sb.WriteString("\n")
@@ -152,20 +328,124 @@ func FormatStats(stats *models.Stats) string {
 	return sb.String()
 }
 
+// writeDistribution writes a distribution section of FormatStatsTop, with
+// its keys shown by label if set
+func writeDistribution(sb *strings.Builder, title string, counts map[string]int, top int, label func(string) string) {
+	if len(counts) == 0 {
+		return
+	}
+
+	pairs := sortMapByValue(counts)
+	total := 0
+	for _, pair := range pairs {
+		total += pair.Value
+	}
+	if top > 0 && len(pairs) > top {
+		sb.WriteString(fmt.Sprintf("%s (Top %d):\n", title, top))
+	} else {
+		sb.WriteString(title + ":\n")
+	}
+
+	others := 0
+	for i, pair := range pairs {
+		if top > 0 && i >= top {
+			others += pair.Value
+			continue
+		}
+		key := pair.Key
+		if label != nil {
+			key = label(key)
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %d (%s)\n", key, pair.Value, percent(pair.Value, total)))
+	}
+	if rest := len(pairs) - top; top > 0 && rest > 0 {
+		sb.WriteString(fmt.Sprintf("  others (%d more): %d (%s)\n", rest, others, percent(others, total)))
+	}
+	sb.WriteString("\n")
+}
+
+// percent formats n as a share of total, e.g. "12.5%"
+func percent(n, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// formatCrossTab renders one line for each of the top rows of a cross-tab
+// (all if top is 0), in the order of totals, with its n largest columns
+// shown by label if set, e.g. "  ims: 310 12, 262 4 (+3 more)"
+func formatCrossTab(tab map[string]map[string]int, totals map[string]int, top, n int, label func(string) string) string {
+	var sb strings.Builder
+	for i, row := range sortMapByValue(totals) {
+		if top > 0 && i >= top {
+			break
+		}
+		columns := sortMapByValue(tab[row.Key])
+		if len(columns) == 0 {
+			continue
+		}
+		var cells []string
+		for i, column := range columns {
+			if i >= n {
+				break
+			}
+			key := column.Key
+			if label != nil {
+				key = label(key)
+			}
+			cells = append(cells, fmt.Sprintf("%s %d", key, column.Value))
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %s", row.Key, strings.Join(cells, ", ")))
+		if len(columns) > n {
+			sb.WriteString(fmt.Sprintf(" (+%d more)", len(columns)-n))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// FormatStatusCounts renders status counts on one line in taxonomy order,
+// e.g. "OK 12, NXDOMAIN 3400, TIMEOUT 5"
+func FormatStatusCounts(counts map[models.ResultStatus]int) string {
+	var parts []string
+	for _, status := range models.ResultStatuses {
+		if n := counts[status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", status, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PingStatusCounts tallies ping results by status
+func PingStatusCounts(results []models.PingResult) map[models.ResultStatus]int {
+	counts := make(map[models.ResultStatus]int)
+	for _, result := range results {
+		if result.Status != "" {
+			counts[result.Status]++
+		}
+	}
+	return counts
+}
+
 // KeyValue is a helper struct for sorting maps
 type KeyValue struct {
 	Key   string
 	Value int
 }
 
-// sortMapByValue sorts a map by value in descending order
+// sortMapByValue sorts a map by value in descending order, and by key
+// among equal values
 func sortMapByValue(m map[string]int) []KeyValue {
 	var pairs []KeyValue
 	for k, v := range m {
 		pairs = append(pairs, KeyValue{k, v})
 	}
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].Value > pairs[j].Value
+		if pairs[i].Value != pairs[j].Value {
+			return pairs[i].Value > pairs[j].Value
+		}
+		return pairs[i].Key < pairs[j].Key
 	})
 	return pairs
 }
@@ -2,9 +2,12 @@ package stats
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"3gpp-scanner/internal/countries"
 	"3gpp-scanner/internal/models"
 )
 
@@ -73,14 +76,56 @@ bsf.mnc005.mcc311.pub.3gppnetwork.org`
 	}
 }
 
+func TestAnalyzeFileMultiLabelServices(t *testing.T) {
+	tmpFile := t.TempDir() + "/test_fqdns.txt"
+	testData := `epdg.epc.mnc001.mcc310.pub.3gppnetwork.org 192.0.2.1
+XCAP.IMS.MNC001.MCC310.PUB.3GPPNETWORK.ORG
+xcap.ims.mnc005.mcc311.pub.3gppnetwork.org
+epdg.epc.mnc260.mcc310.pub.3gppnetwork.org
+www.example.org`
+
+	if err := os.WriteFile(tmpFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	stats, err := NewAnalyzer().AnalyzeFile(tmpFile)
+	if err != nil {
+		t.Fatalf("AnalyzeFile failed: %v", err)
+	}
+
+	wantCounts := map[string]int{"epdg.epc": 2, "xcap.ims": 2}
+	if !reflect.DeepEqual(stats.SubdomainCounts, wantCounts) {
+		t.Errorf("SubdomainCounts = %v, want %v", stats.SubdomainCounts, wantCounts)
+	}
+	wantMCC := map[string]map[string]int{
+		"epdg.epc": {"310": 2},
+		"xcap.ims": {"310": 1, "311": 1},
+	}
+	if !reflect.DeepEqual(stats.SubdomainMCCCounts, wantMCC) {
+		t.Errorf("SubdomainMCCCounts = %v, want %v", stats.SubdomainMCCCounts, wantMCC)
+	}
+	wantNetworks := map[string]map[string]int{
+		"epdg.epc": {"310-001": 1, "310-260": 1},
+		"xcap.ims": {"310-001": 1, "311-005": 1},
+	}
+	if !reflect.DeepEqual(stats.SubdomainNetworkCounts, wantNetworks) {
+		t.Errorf("SubdomainNetworkCounts = %v, want %v", stats.SubdomainNetworkCounts, wantNetworks)
+	}
+
+	text := FormatStats(stats)
+	if !strings.Contains(text, "Subdomains by Network (Top 5):") || !strings.Contains(text, "  epdg.epc: 310-") {
+		t.Errorf("FormatStats lacks the network cross-tab:\n%s", text)
+	}
+}
+
 func TestAnalyzeResults(t *testing.T) {
 	results := []models.DNSResult{
 		{
 			FQDN:      "ims.mnc001.mcc310.pub.3gppnetwork.org",
 			IPs:       []string{"192.0.2.1"},
 			Subdomain: "ims",
-			MNC:       1,
-			MCC:       310,
+			MNC:       "01",
+			MCC:       "310",
 			Operator:  "Verizon",
 			Timestamp: time.Now(),
 		},
@@ -88,8 +133,8 @@ func TestAnalyzeResults(t *testing.T) {
 			FQDN:      "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org",
 			IPs:       []string{"192.0.2.2"},
 			Subdomain: "epdg.epc",
-			MNC:       1,
-			MCC:       310,
+			MNC:       "01",
+			MCC:       "310",
 			Operator:  "Verizon",
 			Timestamp: time.Now(),
 		},
@@ -97,8 +142,8 @@ func TestAnalyzeResults(t *testing.T) {
 			FQDN:      "ims.mnc005.mcc311.pub.3gppnetwork.org",
 			IPs:       []string{"192.0.2.3", "192.0.2.4"},
 			Subdomain: "ims",
-			MNC:       5,
-			MCC:       311,
+			MNC:       "05",
+			MCC:       "311",
 			Operator:  "AT&T",
 			Timestamp: time.Now(),
 		},
@@ -130,6 +175,31 @@ func TestAnalyzeResults(t *testing.T) {
 	if stats.SubdomainCounts["ims"] != 2 {
 		t.Errorf("Expected 'ims' subdomain count 2, got %d", stats.SubdomainCounts["ims"])
 	}
+
+	if stats.OperatorCounts["Verizon"] != 2 || stats.OperatorSubdomainCounts["AT&T"]["ims"] != 1 {
+		t.Errorf("Unexpected operator counts %v and %v", stats.OperatorCounts, stats.OperatorSubdomainCounts)
+	}
+
+	if stats.SubdomainNetworkCounts["ims"]["311-005"] != 1 || stats.SubdomainMCCCounts["epdg.epc"]["310"] != 1 {
+		t.Errorf("Unexpected cross-tabs %v and %v", stats.SubdomainNetworkCounts, stats.SubdomainMCCCounts)
+	}
+
+	if len(stats.StatusCounts) != 0 {
+		t.Errorf("Expected no status counts for results without status, got %v", stats.StatusCounts)
+	}
+}
+
+func TestFormatStatusCounts(t *testing.T) {
+	counts := PingStatusCounts([]models.PingResult{
+		{FQDN: "a", Status: models.StatusTimeout},
+		{FQDN: "b", Status: models.StatusOK},
+		{FQDN: "c", Status: models.StatusTimeout},
+		{FQDN: "d"},
+	})
+
+	if got := FormatStatusCounts(counts); got != "OK 1, TIMEOUT 2" {
+		t.Errorf("FormatStatusCounts = %q, expected %q", got, "OK 1, TIMEOUT 2")
+	}
 }
 
 func TestFormatStats(t *testing.T) {
@@ -174,6 +244,60 @@ func TestFormatStats(t *testing.T) {
 	}
 }
 
+func TestFormatStatsTop(t *testing.T) {
+	stats := &models.Stats{
+		SubdomainCounts: map[string]int{
+			"epdg.epc": 40,
+			"ims":      30,
+			"bsf":      20,
+			"gan":      5,
+			"xcap.ims": 5,
+		},
+	}
+
+	formatted := FormatStatsTop(stats, 2)
+	for _, want := range []string{
+		"Subdomain Distribution (Top 2):\n",
+		"  epdg.epc: 40 (40.0%)\n  ims: 30 (30.0%)\n  others (3 more): 30 (30.0%)\n",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("FormatStatsTop(2) lacks %q:\n%s", want, formatted)
+		}
+	}
+
+	all := FormatStatsTop(stats, 0)
+	if strings.Contains(all, "others") || !strings.Contains(all, "Subdomain Distribution:\n") || !strings.Contains(all, "  gan: 5 (5.0%)\n  xcap.ims: 5 (5.0%)\n") {
+		t.Errorf("FormatStatsTop(0) should list all subdomains:\n%s", all)
+	}
+}
+
+func TestFormatStatsNames(t *testing.T) {
+	stats := &models.Stats{
+		TotalFQDNs:         30,
+		MCCDistribution:    map[string]int{"262": 20, "999": 10},
+		CountryCounts:      map[string]int{"DE": 20, "": 10},
+		SubdomainCounts:    map[string]int{"ims": 30},
+		SubdomainMCCCounts: map[string]map[string]int{"ims": {"262": 20, "999": 10}},
+	}
+
+	plain := FormatStatsTop(stats, 0)
+	for _, want := range []string{"  MCC 262: 20", "  DE: 20", "  ims: 262 20, 999 10"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("FormatStatsTop lacks %q:\n%s", want, plain)
+		}
+	}
+
+	local := FormatStatsNames(stats, 0, countries.NamesLocal)
+	for _, want := range []string{"  Deutschland (262): 20", "  MCC 999: 10", "  Deutschland: 20", "  unknown: 10", "  ims: Deutschland (262) 20, 999 10"} {
+		if !strings.Contains(local, want) {
+			t.Errorf("FormatStatsNames(local) lacks %q:\n%s", want, local)
+		}
+	}
+	if english := FormatStatsNames(stats, 0, countries.NamesEnglish); !strings.Contains(english, "  Germany (262): 20") {
+		t.Errorf("FormatStatsNames(english) lacks the English name:\n%s", english)
+	}
+}
+
 func TestSortMapByValue(t *testing.T) {
 	m := map[string]int{
 		"c": 10,
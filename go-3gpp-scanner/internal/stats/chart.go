@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// chartTopMCCs limits the MCC bar chart to the largest countries
+const chartTopMCCs = 20
+
+// SeriesPoint is the number of FQDNs discovered on one day
+type SeriesPoint struct {
+	Date  string `json:"date"`
+	FQDNs int    `json:"fqdns"`
+}
+
+// TimeSeries counts results per day of their timestamps. Each scan run
+// stamps its own results, so days approximate runs.
+func TimeSeries(results []models.DNSResult) []SeriesPoint {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.Timestamp.IsZero() {
+			continue
+		}
+		counts[result.Timestamp.Format("2006-01-02")]++
+	}
+
+	series := make([]SeriesPoint, 0, len(counts))
+	for date, n := range counts {
+		series = append(series, SeriesPoint{Date: date, FQDNs: n})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Date < series[j].Date })
+	return series
+}
+
+// VegaLiteSpec returns a Vega-Lite specification with an MCC bar chart,
+// a subdomain pie chart and, when series has more than one point, a
+// discovery time series, concatenated vertically
+func VegaLiteSpec(stats *models.Stats, series []SeriesPoint) ([]byte, error) {
+	type row map[string]interface{}
+
+	var mccRows []row
+	for i, pair := range sortMapByValue(stats.MCCDistribution) {
+		if i >= chartTopMCCs {
+			break
+		}
+		mccRows = append(mccRows, row{"mcc": pair.Key, "fqdns": pair.Value})
+	}
+
+	var subRows []row
+	for _, pair := range sortMapByValue(stats.SubdomainCounts) {
+		subRows = append(subRows, row{"subdomain": pair.Key, "fqdns": pair.Value})
+	}
+
+	charts := []row{
+		{
+			"title": fmt.Sprintf("FQDNs per MCC (top %d)", chartTopMCCs),
+			"data":  row{"values": mccRows},
+			"mark":  "bar",
+			"encoding": row{
+				"x": row{"field": "mcc", "type": "nominal", "sort": "-y", "title": "MCC"},
+				"y": row{"field": "fqdns", "type": "quantitative", "title": "FQDNs"},
+			},
+		},
+		{
+			"title": "FQDNs per subdomain",
+			"data":  row{"values": subRows},
+			"mark":  "arc",
+			"encoding": row{
+				"theta": row{"field": "fqdns", "type": "quantitative"},
+				"color": row{"field": "subdomain", "type": "nominal", "title": "Subdomain"},
+			},
+		},
+	}
+
+	if len(series) > 1 {
+		charts = append(charts, row{
+			"title": "FQDNs discovered per day",
+			"data":  row{"values": series},
+			"mark":  row{"type": "line", "point": true},
+			"encoding": row{
+				"x": row{"field": "date", "type": "temporal", "title": "Date"},
+				"y": row{"field": "fqdns", "type": "quantitative", "title": "FQDNs"},
+			},
+		})
+	}
+
+	spec := row{
+		"$schema": "https://vega.github.io/schema/vega-lite/v5.json",
+		"title":   fmt.Sprintf("3GPP Scanner: %d FQDNs", stats.TotalFQDNs),
+		"vconcat": charts,
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// GnuplotScript returns a self-contained gnuplot script with inline data
+// rendering the same charts as VegaLiteSpec. Gnuplot has no pie chart, so
+// subdomains are drawn as a bar chart.
+func GnuplotScript(stats *models.Stats, series []SeriesPoint) string {
+	var sb strings.Builder
+
+	plots := 2
+	if len(series) > 1 {
+		plots = 3
+	}
+
+	sb.WriteString("# 3GPP Scanner statistics\n")
+	sb.WriteString("# Render with: gnuplot -p script.gp (or set a terminal/output first)\n\n")
+
+	sb.WriteString("$mcc << EOD\n")
+	for i, pair := range sortMapByValue(stats.MCCDistribution) {
+		if i >= chartTopMCCs {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s %d\n", pair.Key, pair.Value))
+	}
+	sb.WriteString("EOD\n\n")
+
+	sb.WriteString("$subdomains << EOD\n")
+	for _, pair := range sortMapByValue(stats.SubdomainCounts) {
+		sb.WriteString(fmt.Sprintf("%q %d\n", pair.Key, pair.Value))
+	}
+	sb.WriteString("EOD\n\n")
+
+	if plots == 3 {
+		sb.WriteString("$series << EOD\n")
+		for _, point := range series {
+			sb.WriteString(fmt.Sprintf("%s %d\n", point.Date, point.FQDNs))
+		}
+		sb.WriteString("EOD\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("set multiplot layout %d,1 title \"3GPP Scanner: %d FQDNs\"\n", plots, stats.TotalFQDNs))
+	sb.WriteString("set style fill solid 0.8\n")
+	sb.WriteString("set boxwidth 0.8\n")
+	sb.WriteString("set yrange [0:*]\n")
+	sb.WriteString("set xtics rotate by -45\n\n")
+
+	sb.WriteString(fmt.Sprintf("set title \"FQDNs per MCC (top %d)\"\n", chartTopMCCs))
+	sb.WriteString("plot $mcc using 0:2:xtic(1) with boxes notitle\n\n")
+
+	sb.WriteString("set title \"FQDNs per subdomain\"\n")
+	sb.WriteString("plot $subdomains using 0:2:xtic(1) with boxes notitle\n\n")
+
+	if plots == 3 {
+		sb.WriteString("set title \"FQDNs discovered per day\"\n")
+		sb.WriteString("set xdata time\n")
+		sb.WriteString("set timefmt \"%Y-%m-%d\"\n")
+		sb.WriteString("set format x \"%Y-%m-%d\"\n")
+		sb.WriteString("plot $series using 1:2 with linespoints notitle\n\n")
+	}
+
+	sb.WriteString("unset multiplot\n")
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestTimeSeries(t *testing.T) {
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	series := TimeSeries([]models.DNSResult{
+		{FQDN: "a", Timestamp: day2},
+		{FQDN: "b", Timestamp: day1},
+		{FQDN: "c", Timestamp: day1.Add(time.Hour)},
+		{FQDN: "d"},
+	})
+
+	if len(series) != 2 {
+		t.Fatalf("Expected 2 points, got %d", len(series))
+	}
+	if series[0].Date != "2024-03-01" || series[0].FQDNs != 2 {
+		t.Errorf("Unexpected first point: %+v", series[0])
+	}
+	if series[1].Date != "2024-03-02" || series[1].FQDNs != 1 {
+		t.Errorf("Unexpected second point: %+v", series[1])
+	}
+}
+
+func TestVegaLiteSpec(t *testing.T) {
+	stats := &models.Stats{
+		TotalFQDNs:      3,
+		MCCDistribution: map[string]int{"310": 2, "262": 1},
+		SubdomainCounts: map[string]int{"ims": 2, "epdg": 1},
+	}
+
+	data, err := VegaLiteSpec(stats, nil)
+	if err != nil {
+		t.Fatalf("VegaLiteSpec failed: %v", err)
+	}
+
+	var spec struct {
+		Schema  string            `json:"$schema"`
+		VConcat []json.RawMessage `json:"vconcat"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Spec is not valid JSON: %v", err)
+	}
+	if !contains(spec.Schema, "vega-lite") {
+		t.Errorf("Unexpected schema %q", spec.Schema)
+	}
+	if len(spec.VConcat) != 2 {
+		t.Errorf("Expected 2 charts without a time series, got %d", len(spec.VConcat))
+	}
+
+	series := []SeriesPoint{{Date: "2024-03-01", FQDNs: 1}, {Date: "2024-03-02", FQDNs: 2}}
+	data, _ = VegaLiteSpec(stats, series)
+	json.Unmarshal(data, &spec)
+	if len(spec.VConcat) != 3 {
+		t.Errorf("Expected 3 charts with a time series, got %d", len(spec.VConcat))
+	}
+}
+
+func TestGnuplotScript(t *testing.T) {
+	stats := &models.Stats{
+		TotalFQDNs:      3,
+		MCCDistribution: map[string]int{"310": 2, "262": 1},
+		SubdomainCounts: map[string]int{"epdg.epc": 3},
+	}
+
+	script := GnuplotScript(stats, nil)
+	for _, want := range []string{"$mcc << EOD", "310 2", "\"epdg.epc\" 3", "layout 2,1", "unset multiplot"} {
+		if !contains(script, want) {
+			t.Errorf("Script does not contain %q:\n%s", want, script)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// prefixMembers are the addresses and FQDNs within one prefix
+type prefixMembers struct {
+	ips   map[netip.Addr]bool
+	fqdns map[string]bool
+}
+
+// AggregatePrefixes groups the addresses of each operator into prefixes of
+// v4Bits (IPv4) and v6Bits (IPv6) and merges sibling prefixes into their
+// parent as long as both halves are present, which yields the smallest
+// set of prefixes covering exactly the same space. Prefixes are sorted by
+// operator, then address.
+func AggregatePrefixes(results []models.DNSResult, v4Bits, v6Bits int) []models.OperatorPrefix {
+	operators := make(map[string]map[netip.Prefix]*prefixMembers)
+	for _, result := range results {
+		for _, s := range result.IPs {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				continue
+			}
+			addr = addr.Unmap()
+			bits := v6Bits
+			if addr.Is4() {
+				bits = v4Bits
+			}
+			prefix, err := addr.Prefix(bits)
+			if err != nil {
+				continue
+			}
+
+			prefixes := operators[result.Operator]
+			if prefixes == nil {
+				prefixes = make(map[netip.Prefix]*prefixMembers)
+				operators[result.Operator] = prefixes
+			}
+			members := prefixes[prefix]
+			if members == nil {
+				members = &prefixMembers{ips: make(map[netip.Addr]bool), fqdns: make(map[string]bool)}
+				prefixes[prefix] = members
+			}
+			members.ips[addr] = true
+			members.fqdns[result.FQDN] = true
+		}
+	}
+
+	var aggregated []models.OperatorPrefix
+	for operator, prefixes := range operators {
+		mergeSiblings(prefixes)
+		for prefix, members := range prefixes {
+			aggregated = append(aggregated, models.OperatorPrefix{
+				Operator: operator,
+				Prefix:   prefix.String(),
+				IPs:      len(members.ips),
+				FQDNs:    len(members.fqdns),
+			})
+		}
+	}
+
+	sort.Slice(aggregated, func(i, j int) bool {
+		if aggregated[i].Operator != aggregated[j].Operator {
+			return aggregated[i].Operator < aggregated[j].Operator
+		}
+		a, b := netip.MustParsePrefix(aggregated[i].Prefix), netip.MustParsePrefix(aggregated[j].Prefix)
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c < 0
+		}
+		return a.Bits() < b.Bits()
+	})
+	return aggregated
+}
+
+// mergeSiblings replaces pairs of sibling prefixes by their parent until
+// no pair is left
+func mergeSiblings(prefixes map[netip.Prefix]*prefixMembers) {
+	for merged := true; merged; {
+		merged = false
+		for prefix, members := range prefixes {
+			sibling, ok := siblingPrefix(prefix)
+			if !ok {
+				continue
+			}
+			other, present := prefixes[sibling]
+			if !present {
+				continue
+			}
+			parent, _ := prefix.Addr().Prefix(prefix.Bits() - 1)
+			for ip := range other.ips {
+				members.ips[ip] = true
+			}
+			for fqdn := range other.fqdns {
+				members.fqdns[fqdn] = true
+			}
+			delete(prefixes, prefix)
+			delete(prefixes, sibling)
+			prefixes[parent] = members
+			merged = true
+		}
+	}
+}
+
+// siblingPrefix returns the other half of a prefix's parent
+func siblingPrefix(prefix netip.Prefix) (netip.Prefix, bool) {
+	bits := prefix.Bits()
+	if bits <= 0 {
+		return netip.Prefix{}, false
+	}
+	addr := prefix.Addr().AsSlice()
+	addr[(bits-1)/8] ^= 0x80 >> ((bits - 1) % 8)
+	sibling, _ := netip.AddrFromSlice(addr)
+	return netip.PrefixFrom(sibling, bits), true
+}
+
+// FormatOperatorPrefixes renders aggregated prefixes grouped by operator
+func FormatOperatorPrefixes(prefixes []models.OperatorPrefix) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Address Prefixes by Operator ===\n\n")
+	if len(prefixes) == 0 {
+		sb.WriteString("No addresses found.\n")
+		return sb.String()
+	}
+
+	width := len("Prefix")
+	for _, p := range prefixes {
+		width = max(width, len(p.Prefix))
+	}
+
+	for i, p := range prefixes {
+		if i == 0 || p.Operator != prefixes[i-1].Operator {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			operator := p.Operator
+			if operator == "" {
+				operator = "unknown"
+			}
+			sb.WriteString(operator + ":\n")
+			sb.WriteString(fmt.Sprintf("  %-*s  %5s  %5s\n", width, "Prefix", "IPs", "FQDNs"))
+		}
+		sb.WriteString(fmt.Sprintf("  %-*s  %5d  %5d\n", width, p.Prefix, p.IPs, p.FQDNs))
+	}
+	return sb.String()
+}
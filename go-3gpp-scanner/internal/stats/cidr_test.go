@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestAggregatePrefixes(t *testing.T) {
+	results := []models.DNSResult{
+		{FQDN: "epdg.a", Operator: "Telekom", IPs: []string{"192.0.2.1", "192.0.3.7", "2001:db8:1::1"}},
+		{FQDN: "ims.a", Operator: "Telekom", IPs: []string{"192.0.2.9", "198.51.100.1", "2001:db8::5", "::ffff:198.51.100.2"}},
+		{FQDN: "bsf.a", Operator: "Telekom", IPs: []string{"192.0.5.1", "invalid"}},
+		{FQDN: "ims.b", Operator: "Vodafone", IPs: []string{"192.0.3.1"}},
+	}
+
+	got := AggregatePrefixes(results, 24, 48)
+	want := []models.OperatorPrefix{
+		// 192.0.2.0/24 and 192.0.3.0/24 merge; 192.0.5.0/24 has no sibling
+		{Operator: "Telekom", Prefix: "192.0.2.0/23", IPs: 3, FQDNs: 2},
+		{Operator: "Telekom", Prefix: "192.0.5.0/24", IPs: 1, FQDNs: 1},
+		{Operator: "Telekom", Prefix: "198.51.100.0/24", IPs: 2, FQDNs: 1},
+		{Operator: "Telekom", Prefix: "2001:db8::/47", IPs: 2, FQDNs: 2},
+		// Prefixes of different operators never merge
+		{Operator: "Vodafone", Prefix: "192.0.3.0/24", IPs: 1, FQDNs: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregatePrefixes =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Host routes merge up to the covering block of four addresses
+	hosts := AggregatePrefixes([]models.DNSResult{
+		{FQDN: "a", Operator: "X", IPs: []string{"10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.9"}},
+	}, 32, 128)
+	if len(hosts) != 2 || hosts[0].Prefix != "10.0.0.4/30" || hosts[0].IPs != 4 || hosts[1].Prefix != "10.0.0.9/32" {
+		t.Errorf("host prefixes = %+v", hosts)
+	}
+}
+
+func TestFormatOperatorPrefixes(t *testing.T) {
+	formatted := FormatOperatorPrefixes([]models.OperatorPrefix{
+		{Operator: "Telekom", Prefix: "192.0.2.0/23", IPs: 3, FQDNs: 2},
+		{Operator: "", Prefix: "2001:db8::/48", IPs: 1, FQDNs: 1},
+	})
+	for _, want := range []string{"Telekom:\n", "  192.0.2.0/23       3      2\n", "\nunknown:\n"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("FormatOperatorPrefixes lacks %q:\n%s", want, formatted)
+		}
+	}
+	if empty := FormatOperatorPrefixes(nil); !strings.Contains(empty, "No addresses found.") {
+		t.Errorf("FormatOperatorPrefixes(nil) = %q", empty)
+	}
+}
@@ -0,0 +1,235 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// HistogramBounds are the upper bounds of the latency histogram buckets
+var HistogramBounds = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+}
+
+var fqdnMCCPattern = regexp.MustCompile(`mcc(\d+)\.`)
+
+// SummarizeLatency computes percentiles, a histogram and per-country means
+// over the successful results. total is the number of hosts probed, which
+// may exceed len(results) when failures were not kept. countries maps
+// zero-padded MCCs to country names and may be nil.
+func SummarizeLatency(results []models.PingResult, total int, countries map[string]string) *models.LatencySummary {
+	summary := &models.LatencySummary{Total: total}
+
+	var latencies []time.Duration
+	type acc struct {
+		count int
+		sum   time.Duration
+	}
+	byMCC := make(map[string]*acc)
+
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		latencies = append(latencies, result.Latency)
+
+		if m := fqdnMCCPattern.FindStringSubmatch(result.FQDN); len(m) > 1 {
+			a := byMCC[m[1]]
+			if a == nil {
+				a = &acc{}
+				byMCC[m[1]] = a
+			}
+			a.count++
+			a.sum += result.Latency
+		}
+	}
+
+	summary.Successful = len(latencies)
+	summary.Histogram = histogram(latencies)
+	summary.DualStack = summarizeDualStack(results)
+	if len(latencies) == 0 {
+		return summary
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+	summary.Min = latencies[0]
+	summary.Max = latencies[len(latencies)-1]
+	summary.Mean = sum / time.Duration(len(latencies))
+	summary.P50 = Percentile(latencies, 50)
+	summary.P90 = Percentile(latencies, 90)
+	summary.P99 = Percentile(latencies, 99)
+
+	for mcc, a := range byMCC {
+		summary.ByCountry = append(summary.ByCountry, models.CountryLatency{
+			MCC:     mcc,
+			Country: countries[mcc],
+			Count:   a.count,
+			Mean:    a.sum / time.Duration(a.count),
+		})
+	}
+	sort.Slice(summary.ByCountry, func(i, j int) bool {
+		if summary.ByCountry[i].Count != summary.ByCountry[j].Count {
+			return summary.ByCountry[i].Count > summary.ByCountry[j].Count
+		}
+		return summary.ByCountry[i].MCC < summary.ByCountry[j].MCC
+	})
+
+	return summary
+}
+
+// summarizeDualStack compares address families over results that carry
+// dual-stack probes; it returns nil when there are none
+func summarizeDualStack(results []models.PingResult) *models.DualStackSummary {
+	var ds models.DualStackSummary
+	var sumV4, sumV6 time.Duration
+
+	for _, result := range results {
+		probe := result.DualStack
+		if probe == nil {
+			continue
+		}
+		ds.Hosts++
+
+		switch {
+		case probe.IPv4 != nil && probe.IPv6 != nil:
+			ds.DualStacked++
+		case probe.IPv4 != nil:
+			ds.IPv4Only++
+		default:
+			ds.IPv6Only++
+		}
+
+		switch probe.Faster {
+		case "ipv4":
+			ds.IPv4Faster++
+		case "ipv6":
+			ds.IPv6Faster++
+		default:
+			continue
+		}
+		ds.BothReachable++
+		sumV4 += probe.IPv4.Latency
+		sumV6 += probe.IPv6.Latency
+	}
+
+	if ds.Hosts == 0 {
+		return nil
+	}
+	if ds.BothReachable > 0 {
+		ds.MeanIPv4 = sumV4 / time.Duration(ds.BothReachable)
+		ds.MeanIPv6 = sumV6 / time.Duration(ds.BothReachable)
+	}
+	return &ds
+}
+
+// Percentile returns the nearest-rank percentile of sorted latencies
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// histogram buckets latencies by HistogramBounds
+func histogram(latencies []time.Duration) []models.LatencyBucket {
+	buckets := make([]models.LatencyBucket, len(HistogramBounds)+1)
+	for i, bound := range HistogramBounds {
+		buckets[i].UpperBound = bound
+	}
+	for _, l := range latencies {
+		i := sort.Search(len(HistogramBounds), func(i int) bool { return l <= HistogramBounds[i] })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// FormatLatencySummary formats a latency summary for display
+func FormatLatencySummary(summary *models.LatencySummary) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Latency Summary ===\n\n")
+	sb.WriteString(fmt.Sprintf("Hosts: %d, Replied: %d\n", summary.Total, summary.Successful))
+	if summary.Successful == 0 {
+		formatDualStack(&sb, summary.DualStack)
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("Min/Mean/Max: %s / %s / %s\n",
+		formatMs(summary.Min), formatMs(summary.Mean), formatMs(summary.Max)))
+	sb.WriteString(fmt.Sprintf("p50: %s  p90: %s  p99: %s\n\n",
+		formatMs(summary.P50), formatMs(summary.P90), formatMs(summary.P99)))
+
+	sb.WriteString("Histogram:\n")
+	for i, bucket := range summary.Histogram {
+		label := fmt.Sprintf("<= %s", bucket.UpperBound)
+		if bucket.UpperBound == 0 {
+			label = fmt.Sprintf("> %s", summary.Histogram[i-1].UpperBound)
+		}
+		bar := strings.Repeat("#", bucket.Count*40/summary.Successful)
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("  %8s %5d %s", label, bucket.Count, bar), " ") + "\n")
+	}
+	sb.WriteString("\n")
+
+	if len(summary.ByCountry) > 0 {
+		sb.WriteString("Mean latency by country (Top 10):\n")
+		for i, c := range summary.ByCountry {
+			if i >= 10 {
+				break
+			}
+			name := "MCC " + c.MCC
+			if c.Country != "" {
+				name = fmt.Sprintf("%s (%s)", c.Country, c.MCC)
+			}
+			sb.WriteString(fmt.Sprintf("  %s: %s over %d hosts\n", name, formatMs(c.Mean), c.Count))
+		}
+		sb.WriteString("\n")
+	}
+
+	formatDualStack(&sb, summary.DualStack)
+
+	return sb.String()
+}
+
+// formatDualStack writes the IPv4 vs IPv6 comparison section
+func formatDualStack(sb *strings.Builder, ds *models.DualStackSummary) {
+	if ds == nil {
+		return
+	}
+
+	sb.WriteString("IPv4 vs IPv6:\n")
+	sb.WriteString(fmt.Sprintf("  Hosts with records: %d (IPv4 only: %d, IPv6 only: %d, dual-stacked: %d)\n",
+		ds.Hosts, ds.IPv4Only, ds.IPv6Only, ds.DualStacked))
+	sb.WriteString(fmt.Sprintf("  Both families answered: %d\n", ds.BothReachable))
+	if ds.BothReachable > 0 {
+		sb.WriteString(fmt.Sprintf("  Faster: IPv4 %d, IPv6 %d\n", ds.IPv4Faster, ds.IPv6Faster))
+		sb.WriteString(fmt.Sprintf("  Mean latency: IPv4 %s, IPv6 %s\n", formatMs(ds.MeanIPv4), formatMs(ds.MeanIPv6)))
+	}
+	sb.WriteString("\n")
+}
+
+// formatMs renders a duration in milliseconds with two decimals
+func formatMs(d time.Duration) string {
+	return fmt.Sprintf("%.2f ms", float64(d.Microseconds())/1000.0)
+}
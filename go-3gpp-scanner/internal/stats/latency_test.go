@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+		{0, 1 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.expected {
+			t.Errorf("Percentile(%v) = %s, expected %s", tt.p, got, tt.expected)
+		}
+	}
+
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile of empty slice = %s, expected 0", got)
+	}
+}
+
+func TestSummarizeLatency(t *testing.T) {
+	results := []models.PingResult{
+		{FQDN: "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org", Success: true, Latency: 20 * time.Millisecond},
+		{FQDN: "epdg.epc.mnc002.mcc310.pub.3gppnetwork.org", Success: true, Latency: 40 * time.Millisecond},
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Success: true, Latency: 300 * time.Millisecond},
+		{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", Success: false, Error: "timeout"},
+	}
+
+	summary := SummarizeLatency(results, 5, map[string]string{"262": "Germany"})
+
+	if summary.Total != 5 || summary.Successful != 3 {
+		t.Errorf("Expected 5 total and 3 successful, got %d and %d", summary.Total, summary.Successful)
+	}
+	if summary.Min != 20*time.Millisecond || summary.Max != 300*time.Millisecond {
+		t.Errorf("Unexpected min/max: %s/%s", summary.Min, summary.Max)
+	}
+	if summary.P50 != 40*time.Millisecond {
+		t.Errorf("Expected p50 40ms, got %s", summary.P50)
+	}
+
+	// 20ms <= 25ms, 40ms <= 50ms, 300ms <= 500ms
+	counts := map[time.Duration]int{}
+	for _, b := range summary.Histogram {
+		counts[b.UpperBound] = b.Count
+	}
+	if counts[25*time.Millisecond] != 1 || counts[50*time.Millisecond] != 1 || counts[500*time.Millisecond] != 1 {
+		t.Errorf("Unexpected histogram: %+v", summary.Histogram)
+	}
+
+	if len(summary.ByCountry) != 2 {
+		t.Fatalf("Expected 2 countries, got %d", len(summary.ByCountry))
+	}
+	if summary.ByCountry[0].MCC != "310" || summary.ByCountry[0].Mean != 30*time.Millisecond {
+		t.Errorf("Unexpected first country: %+v", summary.ByCountry[0])
+	}
+	if summary.ByCountry[1].Country != "Germany" {
+		t.Errorf("Expected country name for MCC 262, got %q", summary.ByCountry[1].Country)
+	}
+
+	formatted := FormatLatencySummary(summary)
+	if !contains(formatted, "p90:") || !contains(formatted, "Germany (262)") {
+		t.Errorf("Formatted summary missing percentiles or country:\n%s", formatted)
+	}
+}
+
+func TestSummarizeDualStack(t *testing.T) {
+	results := []models.PingResult{
+		{FQDN: "a", Success: true, Latency: time.Millisecond, DualStack: &models.DualStack{
+			IPv4:   &models.FamilyProbe{Success: true, Latency: 20 * time.Millisecond},
+			IPv6:   &models.FamilyProbe{Success: true, Latency: 10 * time.Millisecond},
+			Faster: "ipv6",
+		}},
+		{FQDN: "b", Success: true, Latency: time.Millisecond, DualStack: &models.DualStack{
+			IPv4: &models.FamilyProbe{Success: true, Latency: 30 * time.Millisecond},
+		}},
+		{FQDN: "c", Success: true, Latency: time.Millisecond, DualStack: &models.DualStack{
+			IPv4: &models.FamilyProbe{Success: true, Latency: 5 * time.Millisecond},
+			IPv6: &models.FamilyProbe{Status: models.StatusTimeout},
+		}},
+	}
+
+	ds := SummarizeLatency(results, 3, nil).DualStack
+	if ds == nil {
+		t.Fatalf("Expected dual-stack summary")
+	}
+	if ds.Hosts != 3 || ds.IPv4Only != 1 || ds.DualStacked != 2 {
+		t.Errorf("Unexpected host counts: %+v", ds)
+	}
+	if ds.BothReachable != 1 || ds.IPv6Faster != 1 || ds.IPv4Faster != 0 {
+		t.Errorf("Unexpected comparison counts: %+v", ds)
+	}
+	if ds.MeanIPv4 != 20*time.Millisecond || ds.MeanIPv6 != 10*time.Millisecond {
+		t.Errorf("Unexpected means: %s / %s", ds.MeanIPv4, ds.MeanIPv6)
+	}
+
+	if SummarizeLatency(results[:0], 0, nil).DualStack != nil {
+		t.Errorf("Expected no dual-stack summary without dual-stack results")
+	}
+}
@@ -0,0 +1,295 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Signal weights of the host network mapping. A CNAME into another
+// operator's zone is close to proof; shared addresses are strong but also
+// occur with shared hosting; shared /24s are circumstantial.
+const (
+	weightCNAMEZone   = 0.6
+	weightSharedCNAME = 0.3
+	weightSharedIP    = 0.5
+	weightPrefix      = 0.2
+	weightMVNOHint    = 0.1
+)
+
+var zonePattern = regexp.MustCompile(`mnc(\d{3})\.mcc(\d{3})\.`)
+
+// plmn collects the infrastructure signals of one MCC-MNC
+type plmn struct {
+	key      string
+	operator string
+	fqdns    int
+	ips      map[string]bool
+	prefixes map[string]bool
+	cnames   map[string]bool
+	zoneRefs map[string]int // CNAMEs pointing into other MCC-MNC zones
+}
+
+// PLMNKey formats an MCC-MNC pair as "262-001", with the MNC in the
+// three-digit form of the operator's zone
+func PLMNKey(mcc, mnc string) string {
+	return models.NormalizeMCC(mcc) + "-" + models.PadMNC(mnc)
+}
+
+// MapHostNetworks suggests which MCC-MNCs ride on which host networks
+// from CNAMEs into other operator zones, shared CNAME targets, shared
+// IPs and shared /24 (IPv6: /48) prefixes of scan results. Entries of an
+// MCC-MNC list supply operator names and MVNO hints and may be nil.
+// Mappings below minConfidence are dropped.
+func MapHostNetworks(results []models.DNSResult, entries []models.MCCMNCEntry, minConfidence float64) []models.HostMapping {
+	plmns := make(map[string]*plmn)
+	get := func(key string) *plmn {
+		p, ok := plmns[key]
+		if !ok {
+			p = &plmn{
+				key:      key,
+				ips:      make(map[string]bool),
+				prefixes: make(map[string]bool),
+				cnames:   make(map[string]bool),
+				zoneRefs: make(map[string]int),
+			}
+			plmns[key] = p
+		}
+		return p
+	}
+
+	for _, r := range results {
+		p := get(PLMNKey(r.MCC, r.MNC))
+		p.fqdns++
+		if p.operator == "" {
+			p.operator = r.Operator
+		}
+		for _, ip := range r.IPs {
+			p.ips[ip] = true
+			if prefix := ipPrefix(ip); prefix != "" {
+				p.prefixes[prefix] = true
+			}
+		}
+		for _, cname := range r.CNAMEs {
+			cname = strings.ToLower(cname)
+			if m := zonePattern.FindStringSubmatch(cname); m != nil {
+				if target := PLMNKey(m[2], m[1]); target != p.key {
+					p.zoneRefs[target]++
+					continue
+				}
+			}
+			p.cnames[cname] = true
+		}
+	}
+
+	names, mvnos := plmnHints(entries)
+
+	// Candidate pairs share at least one infrastructure signal
+	pairs := make(map[[2]string]bool)
+	addPairs := func(index map[string][]string) {
+		for _, keys := range index {
+			for i := range keys {
+				for j := i + 1; j < len(keys); j++ {
+					pairs[orderedPair(keys[i], keys[j])] = true
+				}
+			}
+		}
+	}
+	ipIndex := make(map[string][]string)
+	prefixIndex := make(map[string][]string)
+	cnameIndex := make(map[string][]string)
+	for key, p := range plmns {
+		for ip := range p.ips {
+			ipIndex[ip] = append(ipIndex[ip], key)
+		}
+		for prefix := range p.prefixes {
+			prefixIndex[prefix] = append(prefixIndex[prefix], key)
+		}
+		for cname := range p.cnames {
+			cnameIndex[cname] = append(cnameIndex[cname], key)
+		}
+		for target := range p.zoneRefs {
+			pairs[orderedPair(key, target)] = true
+		}
+	}
+	addPairs(ipIndex)
+	addPairs(prefixIndex)
+	addPairs(cnameIndex)
+
+	var mappings []models.HostMapping
+	for pair := range pairs {
+		guest, host := get(pair[0]), get(pair[1])
+		if isGuest(host, guest, mvnos) {
+			guest, host = host, guest
+		}
+
+		m := scoreMapping(guest, host, mvnos[guest.key])
+		if m.Confidence < minConfidence {
+			continue
+		}
+		m.GuestOperator = firstNonEmpty(guest.operator, names[guest.key])
+		m.HostOperator = firstNonEmpty(host.operator, names[host.key])
+		mappings = append(mappings, m)
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].Confidence != mappings[j].Confidence {
+			return mappings[i].Confidence > mappings[j].Confidence
+		}
+		if mappings[i].Guest != mappings[j].Guest {
+			return mappings[i].Guest < mappings[j].Guest
+		}
+		return mappings[i].Host < mappings[j].Host
+	})
+	return mappings
+}
+
+// isGuest reports whether a rather than b is the guest of the pair: the
+// side whose CNAMEs point into the other's zone, else the side listed as
+// MVNO, else the smaller footprint
+func isGuest(a, b *plmn, mvnos map[string]bool) bool {
+	if (a.zoneRefs[b.key] > 0) != (b.zoneRefs[a.key] > 0) {
+		return a.zoneRefs[b.key] > 0
+	}
+	if mvnos[a.key] != mvnos[b.key] {
+		return mvnos[a.key]
+	}
+	if len(a.ips) != len(b.ips) {
+		return len(a.ips) < len(b.ips)
+	}
+	if a.fqdns != b.fqdns {
+		return a.fqdns < b.fqdns
+	}
+	return a.key > b.key
+}
+
+// scoreMapping weighs the signals linking a guest to a host. Shared
+// counts are relative to the guest, whose whole footprint should sit on
+// the host network.
+func scoreMapping(guest, host *plmn, mvnoHint bool) models.HostMapping {
+	m := models.HostMapping{Guest: guest.key, Host: host.key}
+	score := 0.0
+
+	if n := guest.zoneRefs[host.key]; n > 0 {
+		score += weightCNAMEZone
+		m.Signals = append(m.Signals, fmt.Sprintf("%d CNAMEs into host zone", n))
+	}
+	m.SharedCNAMEs = countShared(guest.cnames, host.cnames)
+	if m.SharedCNAMEs > 0 {
+		score += weightSharedCNAME * float64(m.SharedCNAMEs) / float64(len(guest.cnames))
+		m.Signals = append(m.Signals, fmt.Sprintf("%d/%d CNAME targets shared", m.SharedCNAMEs, len(guest.cnames)))
+	}
+	m.SharedIPs = countShared(guest.ips, host.ips)
+	if m.SharedIPs > 0 {
+		score += weightSharedIP * float64(m.SharedIPs) / float64(len(guest.ips))
+		m.Signals = append(m.Signals, fmt.Sprintf("%d/%d IPs shared", m.SharedIPs, len(guest.ips)))
+	}
+	if shared := countShared(guest.prefixes, host.prefixes); shared > 0 {
+		score += weightPrefix * float64(shared) / float64(len(guest.prefixes))
+		m.Signals = append(m.Signals, fmt.Sprintf("%d/%d prefixes shared", shared, len(guest.prefixes)))
+	}
+	if mvnoHint {
+		score += weightMVNOHint
+		m.Signals = append(m.Signals, "listed as MVNO")
+	}
+
+	m.Confidence = math.Round(math.Min(score, 1)*100) / 100
+	return m
+}
+
+// plmnHints extracts operator names and MVNO flags per MCC-MNC from an
+// MCC-MNC list, whose notes and brands mark MVNOs in free text
+func plmnHints(entries []models.MCCMNCEntry) (map[string]string, map[string]bool) {
+	names := make(map[string]string)
+	mvnos := make(map[string]bool)
+	for _, entry := range entries {
+		_, err1 := strconv.Atoi(entry.MCC)
+		_, err2 := strconv.Atoi(entry.MNC)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		key := PLMNKey(entry.MCC, entry.MNC)
+		names[key] = firstNonEmpty(entry.Operator, entry.Brand)
+		text := strings.ToLower(entry.Notes + " " + entry.Brand + " " + entry.Type)
+		if strings.Contains(text, "mvno") {
+			mvnos[key] = true
+		}
+	}
+	return names, mvnos
+}
+
+// ipPrefix returns the /24 of an IPv4 or the /48 of an IPv6 address
+func ipPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+func countShared(a, b map[string]bool) int {
+	n := 0
+	for k := range a {
+		if b[k] {
+			n++
+		}
+	}
+	return n
+}
+
+func orderedPair(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// FormatHostMappings renders host network mappings as a table
+func FormatHostMappings(mappings []models.HostMapping) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Host Network Mapping ===\n\n")
+	if len(mappings) == 0 {
+		sb.WriteString("No shared infrastructure found.\n")
+		return sb.String()
+	}
+
+	label := func(key, operator string) string {
+		if operator == "" {
+			return key
+		}
+		return fmt.Sprintf("%s (%s)", key, operator)
+	}
+	guestWidth, hostWidth := len("Guest"), len("Host")
+	for _, m := range mappings {
+		guestWidth = max(guestWidth, len(label(m.Guest, m.GuestOperator)))
+		hostWidth = max(hostWidth, len(label(m.Host, m.HostOperator)))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s  %-*s  %s  %s\n", guestWidth, "Guest", hostWidth, "Host", "Confidence", "Signals"))
+	for _, m := range mappings {
+		sb.WriteString(fmt.Sprintf("%-*s  %-*s  %10.2f  %s\n",
+			guestWidth, label(m.Guest, m.GuestOperator),
+			hostWidth, label(m.Host, m.HostOperator),
+			m.Confidence, strings.Join(m.Signals, "; ")))
+	}
+	return sb.String()
+}
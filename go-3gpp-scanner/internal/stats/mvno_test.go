@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestMapHostNetworks(t *testing.T) {
+	results := []models.DNSResult{
+		// Host network
+		{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", MCC: "262", MNC: "02", Operator: "Vodafone", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{FQDN: "ims.mnc002.mcc262.pub.3gppnetwork.org", MCC: "262", MNC: "02", Operator: "Vodafone", IPs: []string{"192.0.2.3"}},
+		// MVNO whose ePDG is a CNAME to the host's
+		{FQDN: "epdg.epc.mnc042.mcc262.pub.3gppnetwork.org", MCC: "262", MNC: "42", IPs: []string{"192.0.2.1"},
+			CNAMEs: []string{"epdg.epc.mnc002.mcc262.pub.3gppnetwork.org"}},
+		// Unrelated operator in a neighbouring prefix only
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", MCC: "262", MNC: "01", Operator: "Telekom", IPs: []string{"192.0.2.200"}},
+	}
+	entries := []models.MCCMNCEntry{
+		{MCC: "262", MNC: "42", Brand: "Discount Mobile", Notes: "MVNO"},
+	}
+
+	mappings := MapHostNetworks(results, entries, 0.4)
+	if len(mappings) != 1 {
+		t.Fatalf("Expected one mapping above threshold, got %+v", mappings)
+	}
+	m := mappings[0]
+	if m.Guest != "262-042" || m.Host != "262-002" {
+		t.Errorf("Expected 262-042 on 262-002, got %s on %s", m.Guest, m.Host)
+	}
+	if m.GuestOperator != "Discount Mobile" || m.HostOperator != "Vodafone" {
+		t.Errorf("Unexpected operator names: %q %q", m.GuestOperator, m.HostOperator)
+	}
+	if m.SharedIPs != 1 || m.Confidence != 1 {
+		t.Errorf("Expected 1 shared IP and full confidence, got %d %.2f", m.SharedIPs, m.Confidence)
+	}
+
+	// Prefix-only pairs are weak and only show up with a low threshold
+	all := MapHostNetworks(results, entries, 0)
+	if len(all) <= len(mappings) {
+		t.Errorf("Expected weaker prefix-only mappings at zero threshold, got %d", len(all))
+	}
+}
+
+func TestMapHostNetworksDirection(t *testing.T) {
+	results := []models.DNSResult{
+		{MCC: "310", MNC: "260", IPs: []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"}},
+		{MCC: "310", MNC: "240", IPs: []string{"198.51.100.2"}},
+	}
+
+	mappings := MapHostNetworks(results, nil, 0)
+	if len(mappings) != 1 || mappings[0].Guest != "310-240" {
+		t.Fatalf("Expected the smaller footprint to be the guest, got %+v", mappings)
+	}
+	if mappings[0].Confidence != 0.7 {
+		t.Errorf("Expected full IP and prefix overlap to score 0.7, got %.2f", mappings[0].Confidence)
+	}
+}
+
+func TestFormatHostMappings(t *testing.T) {
+	formatted := FormatHostMappings([]models.HostMapping{
+		{Guest: "262-042", Host: "262-002", HostOperator: "Vodafone", Confidence: 0.85, Signals: []string{"1/1 IPs shared"}},
+	})
+	if !strings.Contains(formatted, "262-002 (Vodafone)") || !strings.Contains(formatted, "0.85  1/1 IPs shared") {
+		t.Errorf("Unexpected table:\n%s", formatted)
+	}
+	if !strings.Contains(FormatHostMappings(nil), "No shared infrastructure") {
+		t.Errorf("Expected empty notice")
+	}
+}
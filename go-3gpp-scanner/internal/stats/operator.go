@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// NetworkOperators maps MCC-MNC pairs (see PLMNKey) to operator names
+// using an MCC-MNC list. The first entry of a pair with a name wins.
+func NetworkOperators(entries []models.MCCMNCEntry) map[string]string {
+	operators := make(map[string]string)
+	for _, entry := range entries {
+		_, errMCC := strconv.Atoi(entry.MCC)
+		_, errMNC := strconv.Atoi(entry.MNC)
+		name := strings.TrimSpace(entry.Operator)
+		if errMCC != nil || errMNC != nil || name == "" {
+			continue
+		}
+		key := PLMNKey(entry.MCC, entry.MNC)
+		if _, ok := operators[key]; !ok {
+			operators[key] = name
+		}
+	}
+	return operators
+}
+
+// OperatorCounts converts FQDN counts per subdomain and network (see
+// models.Stats.SubdomainNetworkCounts) into counts per operator, and per
+// operator and subdomain. Networks without a known operator are counted
+// under "".
+func OperatorCounts(subdomainNetworkCounts map[string]map[string]int, networkOperators map[string]string) (map[string]int, map[string]map[string]int) {
+	counts := make(map[string]int)
+	bySubdomain := make(map[string]map[string]int)
+	for subdomain, networks := range subdomainNetworkCounts {
+		for network, n := range networks {
+			operator := networkOperators[network]
+			counts[operator] += n
+			addCrossTab(bySubdomain, operator, subdomain, n)
+		}
+	}
+	return counts, bySubdomain
+}
+
+// FormatOperatorStats renders the top operators by FQDN count as a table,
+// with their share of all FQDNs and largest subdomains, and the rest
+// summed up in an "others" row; top 0 shows all operators
+func FormatOperatorStats(stats *models.Stats, top int) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Operator Statistics ===\n\n")
+	if len(stats.OperatorCounts) == 0 {
+		sb.WriteString("No operators known: analyze a JSON scan export or a database, or pass an MCC-MNC list.\n")
+		return sb.String()
+	}
+
+	pairs := sortMapByValue(stats.OperatorCounts)
+	total := 0
+	for _, pair := range pairs {
+		total += pair.Value
+	}
+	others := ""
+	if top > 0 && len(pairs) > top {
+		others = fmt.Sprintf("others (%d more)", len(pairs)-top)
+		pairs = pairs[:top]
+	}
+
+	label := func(operator string) string {
+		if operator == "" {
+			return "unknown"
+		}
+		return operator
+	}
+	width := max(len("Operator"), len(others))
+	for _, pair := range pairs {
+		width = max(width, len(label(pair.Key)))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s  %6s  %6s  %s\n", width, "Operator", "FQDNs", "Share", "Subdomains"))
+	shown := 0
+	for _, pair := range pairs {
+		shown += pair.Value
+		var subdomains []string
+		for i, sub := range sortMapByValue(stats.OperatorSubdomainCounts[pair.Key]) {
+			if i >= 3 {
+				subdomains = append(subdomains, "...")
+				break
+			}
+			subdomains = append(subdomains, fmt.Sprintf("%s %d", sub.Key, sub.Value))
+		}
+		sb.WriteString(fmt.Sprintf("%-*s  %6d  %6s  %s\n", width, label(pair.Key), pair.Value, percent(pair.Value, total), strings.Join(subdomains, ", ")))
+	}
+	if others != "" {
+		sb.WriteString(fmt.Sprintf("%-*s  %6d  %6s\n", width, others, total-shown, percent(total-shown, total)))
+	}
+	return sb.String()
+}
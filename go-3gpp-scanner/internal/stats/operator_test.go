@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestOperatorCounts(t *testing.T) {
+	operators := NetworkOperators([]models.MCCMNCEntry{
+		{MCC: "310", MNC: "260", Operator: "T-Mobile"},
+		{MCC: "310", MNC: "260", Operator: "Later Entry"},
+		{MCC: "311", MNC: "480", Operator: "Verizon"},
+		{MCC: "311", MNC: "xx", Operator: "Invalid"},
+	})
+	if want := map[string]string{"310-260": "T-Mobile", "311-480": "Verizon"}; !reflect.DeepEqual(operators, want) {
+		t.Errorf("NetworkOperators = %v, want %v", operators, want)
+	}
+
+	counts, bySubdomain := OperatorCounts(map[string]map[string]int{
+		"ims":      {"310-260": 3, "311-480": 1},
+		"epdg.epc": {"310-260": 2, "262-001": 4},
+	}, operators)
+	if want := map[string]int{"T-Mobile": 5, "Verizon": 1, "": 4}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("OperatorCounts = %v, want %v", counts, want)
+	}
+	if want := map[string]int{"ims": 3, "epdg.epc": 2}; !reflect.DeepEqual(bySubdomain["T-Mobile"], want) {
+		t.Errorf("T-Mobile subdomains = %v, want %v", bySubdomain["T-Mobile"], want)
+	}
+}
+
+func TestFormatOperatorStats(t *testing.T) {
+	stats := &models.Stats{
+		OperatorCounts: map[string]int{"Telekom": 6, "Vodafone": 3, "": 1},
+		OperatorSubdomainCounts: map[string]map[string]int{
+			"Telekom": {"ims": 3, "epdg.epc": 1, "bsf": 1, "xcap.ims": 1},
+		},
+	}
+
+	formatted := FormatOperatorStats(stats, 2)
+	for _, want := range []string{
+		"Operator          FQDNs   Share  Subdomains\n",
+		"Telekom               6   60.0%  ims 3, bsf 1, epdg.epc 1, ...\n",
+		"Vodafone              3   30.0%  \n",
+		"others (1 more)       1   10.0%\n",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("FormatOperatorStats lacks %q:\n%s", want, formatted)
+		}
+	}
+
+	if all := FormatOperatorStats(stats, 0); !strings.Contains(all, "unknown ") || strings.Contains(all, "others") {
+		t.Errorf("FormatOperatorStats(0) should list all operators:\n%s", all)
+	}
+	if empty := FormatOperatorStats(&models.Stats{}, 10); !strings.Contains(empty, "No operators known") {
+		t.Errorf("FormatOperatorStats without operators = %q", empty)
+	}
+}
@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// SummarizeOrigins counts the addresses and FQDNs announced by each origin
+// AS, using the looked up origins of the results' addresses. Summaries are
+// sorted by FQDN count, then AS number.
+func SummarizeOrigins(results []models.DNSResult, origins map[string]models.IPOrigin) []models.OriginSummary {
+	type members struct {
+		summary  models.OriginSummary
+		prefixes map[string]bool
+		ips      map[string]bool
+		fqdns    map[string]bool
+	}
+	byASN := make(map[int]*members)
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			origin, ok := origins[ip]
+			if !ok {
+				continue
+			}
+			m := byASN[origin.ASN]
+			if m == nil {
+				m = &members{
+					summary:  models.OriginSummary{ASN: origin.ASN, ASName: origin.ASName, Cloud: origin.Cloud},
+					prefixes: make(map[string]bool),
+					ips:      make(map[string]bool),
+					fqdns:    make(map[string]bool),
+				}
+				byASN[origin.ASN] = m
+			}
+			m.prefixes[origin.Prefix] = true
+			m.ips[ip] = true
+			m.fqdns[result.FQDN] = true
+		}
+	}
+
+	summaries := make([]models.OriginSummary, 0, len(byASN))
+	for _, m := range byASN {
+		s := m.summary
+		for prefix := range m.prefixes {
+			s.Prefixes = append(s.Prefixes, prefix)
+		}
+		sort.Strings(s.Prefixes)
+		s.IPs, s.FQDNs = len(m.ips), len(m.fqdns)
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].FQDNs != summaries[j].FQDNs {
+			return summaries[i].FQDNs > summaries[j].FQDNs
+		}
+		return summaries[i].ASN < summaries[j].ASN
+	})
+	return summaries
+}
+
+// CloudEndpoints lists the FQDNs resolving into cloud provider space,
+// sorted by FQDN and address
+func CloudEndpoints(results []models.DNSResult, origins map[string]models.IPOrigin) []models.CloudEndpoint {
+	endpoints := []models.CloudEndpoint{}
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			origin, ok := origins[ip]
+			if !ok || origin.Cloud == "" {
+				continue
+			}
+			endpoints = append(endpoints, models.CloudEndpoint{
+				FQDN:   result.FQDN,
+				IP:     ip,
+				Prefix: origin.Prefix,
+				ASN:    origin.ASN,
+				Cloud:  origin.Cloud,
+			})
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].FQDN != endpoints[j].FQDN {
+			return endpoints[i].FQDN < endpoints[j].FQDN
+		}
+		return endpoints[i].IP < endpoints[j].IP
+	})
+	return endpoints
+}
+
+// FormatOrigins renders the top origin ASes as a table, with their share
+// of the FQDNs, followed by the endpoints in cloud provider space; top 0
+// shows all ASes
+func FormatOrigins(summaries []models.OriginSummary, endpoints []models.CloudEndpoint, totalFQDNs, top int) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Origin AS Statistics ===\n\n")
+	if len(summaries) == 0 {
+		sb.WriteString("No origins known: no addresses, or none of them is announced.\n")
+		return sb.String()
+	}
+
+	more := 0
+	if top > 0 && len(summaries) > top {
+		more = len(summaries) - top
+		summaries = summaries[:top]
+	}
+
+	label := func(s models.OriginSummary) string {
+		name := s.ASName
+		if s.Cloud != "" {
+			name = strings.TrimSpace(name + " [" + s.Cloud + "]")
+		}
+		return name
+	}
+	width := len("Origin AS")
+	for _, s := range summaries {
+		width = max(width, len(fmt.Sprintf("AS%d", s.ASN)))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s  %6s  %6s  %5s  %-8s  %s\n", width, "Origin AS", "FQDNs", "Share", "IPs", "Prefixes", "Holder"))
+	for _, s := range summaries {
+		sb.WriteString(fmt.Sprintf("%-*s  %6d  %6s  %5d  %8d  %s\n", width, fmt.Sprintf("AS%d", s.ASN), s.FQDNs, percent(s.FQDNs, totalFQDNs), s.IPs, len(s.Prefixes), label(s)))
+	}
+	if more > 0 {
+		sb.WriteString(fmt.Sprintf("... %d more (--top=0 lists all)\n", more))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nEndpoints in Cloud Provider Space (%d):\n", len(endpoints)))
+	if len(endpoints) == 0 {
+		sb.WriteString("  none\n")
+	}
+	for _, e := range endpoints {
+		sb.WriteString(fmt.Sprintf("  %s  %s  %s (AS%d, %s)\n", e.FQDN, e.IP, e.Cloud, e.ASN, e.Prefix))
+	}
+	return sb.String()
+}
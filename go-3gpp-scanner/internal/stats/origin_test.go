@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestSummarizeOrigins(t *testing.T) {
+	results := []models.DNSResult{
+		{FQDN: "epdg.epc.mnc001.mcc310.pub.3gppnetwork.org", IPs: []string{"198.51.100.7", "198.51.100.8"}},
+		{FQDN: "epdg.epc.mnc005.mcc311.pub.3gppnetwork.org", IPs: []string{"198.51.100.7"}},
+		{FQDN: "ims.mnc005.mcc311.pub.3gppnetwork.org", IPs: []string{"192.0.2.1", "203.0.113.1"}},
+	}
+	origins := map[string]models.IPOrigin{
+		"198.51.100.7": {IP: "198.51.100.7", Prefix: "198.51.100.0/25", ASN: 16509, ASName: "AMAZON-02", Cloud: "AWS"},
+		"198.51.100.8": {IP: "198.51.100.8", Prefix: "198.51.100.0/24", ASN: 16509, ASName: "AMAZON-02", Cloud: "AWS"},
+		"192.0.2.1":    {IP: "192.0.2.1", Prefix: "192.0.2.0/24", ASN: 64500, ASName: "EXAMPLE"},
+	}
+
+	summaries := SummarizeOrigins(results, origins)
+	want := []models.OriginSummary{
+		{ASN: 16509, ASName: "AMAZON-02", Cloud: "AWS", Prefixes: []string{"198.51.100.0/24", "198.51.100.0/25"}, IPs: 2, FQDNs: 2},
+		{ASN: 64500, ASName: "EXAMPLE", Prefixes: []string{"192.0.2.0/24"}, IPs: 1, FQDNs: 1},
+	}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("SummarizeOrigins = %+v, want %+v", summaries, want)
+	}
+
+	endpoints := CloudEndpoints(results, origins)
+	if len(endpoints) != 3 || endpoints[0].FQDN != results[0].FQDN || endpoints[2].FQDN != results[1].FQDN {
+		t.Errorf("CloudEndpoints = %+v", endpoints)
+	}
+
+	text := FormatOrigins(summaries, endpoints, len(results), 1)
+	for _, want := range []string{
+		"Origin AS   FQDNs   Share    IPs  Prefixes  Holder\n",
+		"AS16509         2   66.7%      2         2  AMAZON-02 [AWS]\n",
+		"... 1 more (--top=0 lists all)\n",
+		"Endpoints in Cloud Provider Space (3):\n",
+		"  epdg.epc.mnc005.mcc311.pub.3gppnetwork.org  198.51.100.7  AWS (AS16509, 198.51.100.0/25)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("FormatOrigins lacks %q:\n%s", want, text)
+		}
+	}
+
+	if text := FormatOrigins(nil, nil, 0, 0); !strings.Contains(text, "No origins known") {
+		t.Errorf("FormatOrigins without origins:\n%s", text)
+	}
+}
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+)
+
+// Endpoint score weights. A DNS answer alone is what a stale record also
+// gives; every layer that answers on top of it adds evidence of a live
+// host, and an IKE response is what an ePDG in service must give.
+const (
+	scoreDNS       = 0.2
+	scoreReachable = 0.3
+	scoreValidCert = 0.2
+	scoreIKE       = 0.2
+	scoreProbe     = 0.1 // Any other probe answering
+)
+
+// ScoreEndpoints scores every FQDN of a scan export from its DNS status
+// and, when given, ping and probe results. Scores are ordered from the
+// most to the least likely live endpoint.
+func ScoreEndpoints(results []models.DNSResult, pings []models.PingResult, probes []models.ProbeResult) []models.EndpointScore {
+	reachable := make(map[string]string)
+	validCert := make(map[string]bool)
+	for _, p := range pings {
+		if p.Success {
+			reachable[p.FQDN] = p.Method
+		}
+		if p.CertValid {
+			validCert[p.FQDN] = true
+		}
+	}
+	ike := make(map[string]bool)
+	answered := make(map[string][]string)
+	for _, p := range probes {
+		if !p.Success {
+			continue
+		}
+		if p.Probe == "ike" {
+			ike[p.FQDN] = true
+		} else {
+			answered[p.FQDN] = append(answered[p.FQDN], p.Probe)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var scores []models.EndpointScore
+	for _, r := range results {
+		if seen[r.FQDN] {
+			continue
+		}
+		seen[r.FQDN] = true
+
+		s := models.EndpointScore{FQDN: r.FQDN, Operator: r.Operator, Signals: []string{}}
+		score := 0.0
+		if (r.Status == "" || r.Status == models.StatusOK) && len(r.IPs) > 0 {
+			score += scoreDNS
+			s.Signals = append(s.Signals, "DNS answer")
+		}
+		if method, ok := reachable[r.FQDN]; ok {
+			score += scoreReachable
+			s.Signals = append(s.Signals, "reachable ("+method+")")
+		}
+		if validCert[r.FQDN] {
+			score += scoreValidCert
+			s.Signals = append(s.Signals, "valid certificate")
+		}
+		if ike[r.FQDN] {
+			score += scoreIKE
+			s.Signals = append(s.Signals, "IKE response")
+		}
+		if names := answered[r.FQDN]; len(names) > 0 {
+			sort.Strings(names)
+			score += scoreProbe
+			s.Signals = append(s.Signals, "probe response ("+strings.Join(names, ",")+")")
+		}
+		s.Score = math.Round(math.Min(score, 1)*100) / 100
+		scores = append(scores, s)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].FQDN < scores[j].FQDN
+	})
+	return scores
+}
+
+// FormatEndpointScores renders endpoint scores as a table
+func FormatEndpointScores(scores []models.EndpointScore) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Endpoint Confidence ===\n\n")
+	if len(scores) == 0 {
+		sb.WriteString("No endpoints to score.\n")
+		return sb.String()
+	}
+
+	width := len("FQDN")
+	for _, s := range scores {
+		width = max(width, len(s.FQDN))
+	}
+
+	sb.WriteString(fmt.Sprintf("%-*s  %s  %s\n", width, "FQDN", "Score", "Signals"))
+	for _, s := range scores {
+		signals := strings.Join(s.Signals, "; ")
+		if signals == "" {
+			signals = "-"
+		}
+		sb.WriteString(fmt.Sprintf("%-*s  %5.2f  %s\n", width, s.FQDN, s.Score, signals))
+	}
+	return sb.String()
+}
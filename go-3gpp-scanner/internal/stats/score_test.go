@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestScoreEndpoints(t *testing.T) {
+	results := []models.DNSResult{
+		{FQDN: "stale.example", IPs: []string{"192.0.2.1"}, Status: models.StatusOK},
+		{FQDN: "epdg.example", IPs: []string{"192.0.2.2"}, Status: models.StatusOK},
+		{FQDN: "gone.example", Status: models.StatusNXDomain},
+		{FQDN: "bsf.example", IPs: []string{"192.0.2.3"}},
+	}
+	pings := []models.PingResult{
+		{FQDN: "epdg.example", Success: true, Method: "https", CertValid: true},
+		{FQDN: "bsf.example", Success: true, Method: "tcp"},
+		{FQDN: "stale.example", Success: false, Method: "tcp"},
+	}
+	probes := []models.ProbeResult{
+		{FQDN: "epdg.example", Probe: "ike", Success: true},
+		{FQDN: "epdg.example", Probe: "stun", Success: true},
+		{FQDN: "stale.example", Probe: "ike", Success: false},
+	}
+
+	scores := ScoreEndpoints(results, pings, probes)
+	want := []struct {
+		fqdn  string
+		score float64
+	}{
+		{"epdg.example", 1.0},
+		{"bsf.example", 0.5},
+		{"stale.example", 0.2},
+		{"gone.example", 0},
+	}
+	if len(scores) != len(want) {
+		t.Fatalf("Expected %d scores, got %d", len(want), len(scores))
+	}
+	for i, w := range want {
+		if scores[i].FQDN != w.fqdn || scores[i].Score != w.score {
+			t.Errorf("scores[%d] = %s %.2f, want %s %.2f", i, scores[i].FQDN, scores[i].Score, w.fqdn, w.score)
+		}
+	}
+	if len(scores[0].Signals) != 5 {
+		t.Errorf("Expected 5 signals for the live ePDG, got %v", scores[0].Signals)
+	}
+
+	text := FormatEndpointScores(scores)
+	if !strings.Contains(text, "IKE response") || !strings.Contains(text, "gone.example") {
+		t.Errorf("Unexpected table:\n%s", text)
+	}
+}
@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"3gpp-scanner/internal/models"
+	"3gpp-scanner/internal/whois"
+)
+
+// contactFQDNs is the number of FQDNs FormatContacts lists per network
+const contactFQDNs = 5
+
+// SummarizeContacts groups the results' addresses by the most specific
+// registered network containing them. Contacts are sorted by FQDN count,
+// then prefix; addresses outside every network are returned separately.
+func SummarizeContacts(results []models.DNSResult, networks []models.WhoisNetwork) ([]models.AbuseContact, []string) {
+	type members struct {
+		contact   models.AbuseContact
+		operators map[string]bool
+		fqdns     map[string]bool
+		ips       map[string]bool
+	}
+	byPrefix := make(map[string]*members)
+	unknown := make(map[string]bool)
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			network, ok := whois.Find(networks, ip)
+			if !ok {
+				unknown[ip] = true
+				continue
+			}
+			m := byPrefix[network.Prefix]
+			if m == nil {
+				m = &members{
+					contact: models.AbuseContact{
+						Prefix:      network.Prefix,
+						NetName:     network.NetName,
+						Org:         network.Org,
+						Country:     network.Country,
+						AbuseEmails: network.AbuseEmails,
+					},
+					operators: make(map[string]bool),
+					fqdns:     make(map[string]bool),
+					ips:       make(map[string]bool),
+				}
+				byPrefix[network.Prefix] = m
+			}
+			if result.Operator != "" {
+				m.operators[result.Operator] = true
+			}
+			m.fqdns[result.FQDN] = true
+			m.ips[ip] = true
+		}
+	}
+
+	contacts := make([]models.AbuseContact, 0, len(byPrefix))
+	for _, m := range byPrefix {
+		c := m.contact
+		if c.AbuseEmails == nil {
+			c.AbuseEmails = []string{}
+		}
+		c.Operators, c.FQDNs = sortedKeys(m.operators), sortedKeys(m.fqdns)
+		c.IPs = len(m.ips)
+		contacts = append(contacts, c)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if len(contacts[i].FQDNs) != len(contacts[j].FQDNs) {
+			return len(contacts[i].FQDNs) > len(contacts[j].FQDNs)
+		}
+		return contacts[i].Prefix < contacts[j].Prefix
+	})
+	return contacts, sortedKeys(unknown)
+}
+
+// sortedKeys returns the keys of a set, sorted
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatContacts renders the contacts of each network with the operators
+// and the first FQDNs it holds
+func FormatContacts(contacts []models.AbuseContact, unknown int) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== Abuse Contacts ===\n\n")
+	if len(contacts) == 0 {
+		sb.WriteString("No networks known: no addresses, or no registry answered for them.\n")
+	}
+	for _, c := range contacts {
+		holder := c.Org
+		if c.Country != "" {
+			holder = strings.TrimSpace(holder + " (" + c.Country + ")")
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s", c.Prefix, holder))
+		if c.NetName != "" {
+			sb.WriteString("  " + c.NetName)
+		}
+		sb.WriteString("\n")
+		abuse := strings.Join(c.AbuseEmails, ", ")
+		if abuse == "" {
+			abuse = "none registered"
+		}
+		sb.WriteString(fmt.Sprintf("  Abuse:      %s\n", abuse))
+		if len(c.Operators) > 0 {
+			sb.WriteString(fmt.Sprintf("  Operators:  %s\n", strings.Join(c.Operators, ", ")))
+		}
+		fqdns := c.FQDNs
+		if len(fqdns) > contactFQDNs {
+			fqdns = append(fqdns[:contactFQDNs:contactFQDNs], fmt.Sprintf("... %d more", len(c.FQDNs)-contactFQDNs))
+		}
+		sb.WriteString(fmt.Sprintf("  FQDNs (%d):  %s\n\n", len(c.FQDNs), strings.Join(fqdns, ", ")))
+	}
+	if unknown > 0 {
+		sb.WriteString(fmt.Sprintf("%d addresses in no known network\n", unknown))
+	}
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestSummarizeContacts(t *testing.T) {
+	results := []models.DNSResult{
+		{FQDN: "epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{FQDN: "ims.mnc001.mcc262.pub.3gppnetwork.org", Operator: "Telekom", IPs: []string{"192.0.2.200"}},
+		{FQDN: "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org", Operator: "Vodafone", IPs: []string{"192.0.2.3", "203.0.113.1"}},
+	}
+	networks := []models.WhoisNetwork{
+		{Prefix: "192.0.0.0/16", Org: "Example Transit", AbuseEmails: []string{"abuse@transit.example"}},
+		{Prefix: "192.0.2.0/25", Org: "Example Mobile", Country: "DE"},
+	}
+
+	contacts, unknown := SummarizeContacts(results, networks)
+	want := []models.AbuseContact{
+		{Prefix: "192.0.2.0/25", Org: "Example Mobile", Country: "DE", AbuseEmails: []string{}, Operators: []string{"Telekom", "Vodafone"},
+			FQDNs: []string{"epdg.epc.mnc001.mcc262.pub.3gppnetwork.org", "epdg.epc.mnc002.mcc262.pub.3gppnetwork.org"}, IPs: 3},
+		{Prefix: "192.0.0.0/16", Org: "Example Transit", AbuseEmails: []string{"abuse@transit.example"}, Operators: []string{"Telekom"},
+			FQDNs: []string{"ims.mnc001.mcc262.pub.3gppnetwork.org"}, IPs: 1},
+	}
+	if !reflect.DeepEqual(contacts, want) {
+		t.Errorf("SummarizeContacts = %+v\nwant %+v", contacts, want)
+	}
+	if len(unknown) != 1 || unknown[0] != "203.0.113.1" {
+		t.Errorf("Expected 203.0.113.1 outside every network, got %v", unknown)
+	}
+
+	text := FormatContacts(contacts, len(unknown))
+	for _, want := range []string{
+		"192.0.2.0/25  Example Mobile (DE)\n  Abuse:      none registered\n  Operators:  Telekom, Vodafone\n",
+		"  Abuse:      abuse@transit.example\n",
+		"1 addresses in no known network",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("FormatContacts output lacks %q:\n%s", want, text)
+		}
+	}
+}
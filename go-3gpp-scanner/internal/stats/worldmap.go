@@ -0,0 +1,235 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"3gpp-scanner/internal/countries"
+	"3gpp-scanner/internal/models"
+)
+
+// ASCII map grid: 4 degrees of longitude per column, 5 of latitude per
+// row, covering 80N to 60S (Antarctica has no operators)
+const (
+	mapCols   = 90
+	mapRows   = 28
+	mapTopLat = 80.0
+	mapColDeg = 360.0 / mapCols
+	mapRowDeg = 5.0
+)
+
+// mapLevels are the density characters, lowest to highest
+const mapLevels = "-+*#@"
+
+// MCCCountries maps zero-padded MCCs to ISO country codes using an
+// MCC-MNC list. An MCC shared by several territories maps to the code
+// most of its entries use.
+func MCCCountries(entries []models.MCCMNCEntry) map[string]string {
+	votes := make(map[string]map[string]int)
+	for _, entry := range entries {
+		mcc, err := strconv.Atoi(entry.MCC)
+		code := strings.ToUpper(strings.TrimSpace(entry.CountryCode))
+		if err != nil || code == "" {
+			continue
+		}
+		key := fmt.Sprintf("%03d", mcc)
+		if votes[key] == nil {
+			votes[key] = make(map[string]int)
+		}
+		votes[key][code]++
+	}
+
+	result := make(map[string]string, len(votes))
+	for mcc, codes := range votes {
+		best := ""
+		for code, n := range codes {
+			if best == "" || n > codes[best] || (n == codes[best] && code < best) {
+				best = code
+			}
+		}
+		result[mcc] = best
+	}
+	return result
+}
+
+// CountryCounts converts an MCC distribution into FQDN counts per ISO
+// country code. MCCs without a known country are counted under "".
+func CountryCounts(mccDistribution map[string]int, mccCountries map[string]string) map[string]int {
+	counts := make(map[string]int)
+	for mcc, n := range mccDistribution {
+		key := mcc
+		if v, err := strconv.Atoi(mcc); err == nil {
+			key = fmt.Sprintf("%03d", v)
+		}
+		counts[mccCountries[key]] += n
+	}
+	return counts
+}
+
+// FormatWorldMap renders FQDN density per country as a coarse ASCII world
+// map. Every known country centroid is drawn as '.', countries with FQDNs
+// use a log-scaled density character.
+func FormatWorldMap(countryCounts map[string]int) string {
+	grid := make([][]byte, mapRows)
+	cellCounts := make([][]int, mapRows)
+	for r := range grid {
+		grid[r] = []byte(strings.Repeat(" ", mapCols))
+		cellCounts[r] = make([]int, mapCols)
+	}
+
+	for _, c := range countries.All() {
+		if r, col, ok := mapCell(c.Latitude, c.Longitude); ok {
+			grid[r][col] = '.'
+		}
+	}
+
+	maxCount := 0
+	for code, n := range countryCounts {
+		c, ok := countries.Lookup(code)
+		if !ok {
+			continue
+		}
+		if r, col, ok := mapCell(c.Latitude, c.Longitude); ok {
+			cellCounts[r][col] += n
+			if cellCounts[r][col] > maxCount {
+				maxCount = cellCounts[r][col]
+			}
+		}
+	}
+
+	for r := range cellCounts {
+		for col, n := range cellCounts[r] {
+			if n > 0 {
+				grid[r][col] = mapLevels[densityLevel(n, maxCount)]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== FQDN Density by Country ===\n\n")
+	sb.WriteString("+" + strings.Repeat("-", mapCols) + "+\n")
+	for _, row := range grid {
+		sb.WriteString("|" + string(row) + "|\n")
+	}
+	sb.WriteString("+" + strings.Repeat("-", mapCols) + "+\n")
+	sb.WriteString(fmt.Sprintf("Density: %s (low to high, log scale); '.' = country without FQDNs\n\n", mapLevels))
+
+	sb.WriteString("Top countries:\n")
+	for i, pair := range sortMapByValue(countryCounts) {
+		if i >= 10 {
+			break
+		}
+		name := "Unknown MCC"
+		if c, ok := countries.Lookup(pair.Key); ok {
+			name = fmt.Sprintf("%s (%s)", c.Name, c.Code)
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %d\n", name, pair.Value))
+	}
+
+	return sb.String()
+}
+
+// mapCell returns the grid cell of a coordinate
+func mapCell(lat, lon float64) (int, int, bool) {
+	row := int((mapTopLat - lat) / mapRowDeg)
+	col := int((lon + 180) / mapColDeg)
+	if row < 0 || row >= mapRows || col < 0 || col >= mapCols {
+		return 0, 0, false
+	}
+	return row, col, true
+}
+
+// densityLevel maps a count to an index into mapLevels on a log scale
+func densityLevel(n, maxCount int) int {
+	if maxCount <= 1 {
+		return len(mapLevels) - 1
+	}
+	level := int(math.Log(float64(n)) / math.Log(float64(maxCount)) * float64(len(mapLevels)-1))
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(mapLevels) {
+		level = len(mapLevels) - 1
+	}
+	return level
+}
+
+// mapPoint is one country marker of the HTML map
+type mapPoint struct {
+	Code  string  `json:"code"`
+	Name  string  `json:"name"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+	FQDNs int     `json:"fqdns"`
+}
+
+var worldMapTemplate = template.Must(template.New("map").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>3GPP Scanner: FQDN density by country</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var points = {{.Points}};
+var maxCount = {{.Max}};
+var map = L.map('map', {worldCopyJump: true}).setView([20, 0], 2);
+L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+  maxZoom: 8,
+  attribution: '&copy; OpenStreetMap contributors'
+}).addTo(map);
+points.forEach(function (p) {
+  L.circleMarker([p.lat, p.lon], {
+    radius: 4 + 26 * Math.sqrt(p.fqdns / maxCount),
+    color: '#b30000',
+    fillColor: '#e34a33',
+    fillOpacity: 0.6,
+    weight: 1
+  }).bindPopup(p.name + ' (' + p.code + '): ' + p.fqdns + ' FQDNs').addTo(map);
+});
+</script>
+</body>
+</html>
+`))
+
+// WorldMapHTML renders FQDN density per country as a Leaflet web map
+// with one circle per country, sized by FQDN count
+func WorldMapHTML(countryCounts map[string]int) (string, error) {
+	points := []mapPoint{}
+	maxCount := 1
+	for code, n := range countryCounts {
+		c, ok := countries.Lookup(code)
+		if !ok || n == 0 {
+			continue
+		}
+		points = append(points, mapPoint{Code: c.Code, Name: c.Name, Lat: c.Latitude, Lon: c.Longitude, FQDNs: n})
+		if n > maxCount {
+			maxCount = n
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].FQDNs > points[j].FQDNs })
+
+	data, err := json.Marshal(points)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	err = worldMapTemplate.Execute(&sb, struct {
+		Points template.JS
+		Max    int
+	}{template.JS(data), maxCount})
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"3gpp-scanner/internal/models"
+)
+
+func TestMCCCountries(t *testing.T) {
+	entries := []models.MCCMNCEntry{
+		{MCC: "310", MNC: "010", CountryCode: "US"},
+		{MCC: "310", MNC: "032", CountryCode: "GU"},
+		{MCC: "310", MNC: "260", CountryCode: "us"},
+		{MCC: "262", MNC: "01", CountryCode: "DE"},
+		{MCC: "901", MNC: "01"},
+	}
+
+	mccs := MCCCountries(entries)
+	if mccs["310"] != "US" {
+		t.Errorf("Expected majority country US for MCC 310, got %q", mccs["310"])
+	}
+	if mccs["262"] != "DE" {
+		t.Errorf("Expected DE for MCC 262, got %q", mccs["262"])
+	}
+	if _, ok := mccs["901"]; ok {
+		t.Errorf("Expected MCC without country code to be skipped")
+	}
+
+	counts := CountryCounts(map[string]int{"310": 3, "262": 2, "901": 1}, mccs)
+	if counts["US"] != 3 || counts["DE"] != 2 || counts[""] != 1 {
+		t.Errorf("Unexpected country counts: %v", counts)
+	}
+}
+
+func TestFormatWorldMap(t *testing.T) {
+	formatted := FormatWorldMap(map[string]int{"DE": 100, "US": 10, "": 3})
+
+	if !strings.Contains(formatted, "@") {
+		t.Errorf("Expected the densest country to use the top density level")
+	}
+	if !strings.Contains(formatted, "Germany (DE): 100") {
+		t.Errorf("Expected top country legend, got:\n%s", formatted)
+	}
+	if !strings.Contains(formatted, "Unknown MCC: 3") {
+		t.Errorf("Expected unknown MCC count in legend")
+	}
+}
+
+func TestWorldMapHTML(t *testing.T) {
+	page, err := WorldMapHTML(map[string]int{"DE": 5})
+	if err != nil {
+		t.Fatalf("WorldMapHTML failed: %v", err)
+	}
+	if !strings.Contains(page, "leaflet") || !strings.Contains(page, `"code":"DE"`) {
+		t.Errorf("Expected Leaflet page with DE marker")
+	}
+
+	empty, _ := WorldMapHTML(nil)
+	if !strings.Contains(empty, "var points = [];") {
+		t.Errorf("Expected empty point list for no data")
+	}
+}
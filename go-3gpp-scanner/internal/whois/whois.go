@@ -0,0 +1,579 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+// DefaultServer is asked first; it refers to the registry of an address
+const DefaultServer = "whois.iana.org"
+
+// maxReferrals bounds the chain of referrals followed for one address
+const maxReferrals = 3
+
+// maxResponse bounds a whois response
+const maxResponse = 1 << 20
+
+// maxBlocks bounds the CIDR blocks of an unaligned registered range
+const maxBlocks = 32
+
+// Client looks up registered networks over the whois protocol (RFC 3912),
+// following referrals from IANA to the regional registry and from ARIN to
+// the registry holding legacy space
+type Client struct {
+	Server  string // First server asked, host or host:port; DefaultServer if empty
+	Timeout time.Duration
+}
+
+// NewClient creates a client asking DefaultServer first
+func NewClient() *Client {
+	return &Client{Server: DefaultServer, Timeout: 15 * time.Second}
+}
+
+// Lookup returns the most specific registered network of an address, one
+// entry per CIDR block of its range; none if no registry knows it
+func (c *Client) Lookup(ctx context.Context, ip string) ([]models.WhoisNetwork, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", ip)
+	}
+	addr = addr.Unmap()
+
+	server := c.Server
+	if server == "" {
+		server = DefaultServer
+	}
+	for hops := 0; ; hops++ {
+		text, err := c.query(ctx, server, queryFor(server, addr))
+		if err != nil {
+			return nil, err
+		}
+		next := referral(text)
+		if next == "" || next == server || hops == maxReferrals {
+			networks := Parse(text, addr)
+			for i := range networks {
+				networks[i].Server = server
+			}
+			return networks, nil
+		}
+		server = next
+	}
+}
+
+// queryFor returns the query for an address; ARIN needs "n +" to include
+// the organization and its abuse contact
+func queryFor(server string, addr netip.Addr) string {
+	if host, _, err := net.SplitHostPort(server); err == nil {
+		server = host
+	}
+	if strings.EqualFold(server, "whois.arin.net") {
+		return "n + " + addr.String()
+	}
+	return addr.String()
+}
+
+// query sends one query and reads the response until the server closes
+// the connection
+func (c *Client) query(ctx context.Context, server, query string) (string, error) {
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, "43")
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", fmt.Errorf("whois %s: %w", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := io.WriteString(conn, query+"\r\n"); err != nil {
+		return "", fmt.Errorf("whois %s: %w", server, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(conn, maxResponse))
+	if err != nil {
+		return "", fmt.Errorf("whois %s: %w", server, err)
+	}
+	return string(data), nil
+}
+
+// referral returns the whois server a response refers to: "refer:" of
+// IANA, "ReferralServer: whois://..." of ARIN. Referrals to rwhois are
+// not followed.
+func referral(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "refer":
+			return value
+		case "referralserver":
+			if server, ok := strings.CutPrefix(value, "whois://"); ok {
+				return strings.TrimSuffix(server, "/")
+			}
+		}
+	}
+	return ""
+}
+
+// field is a "key: value" line of a response, the key lowercased
+type field struct {
+	key, value string
+}
+
+// abuseComment is the abuse contact the RIPE database adds as a comment
+var abuseComment = regexp.MustCompile(`^% Abuse contact for '[^']*' is '([^']+)'`)
+
+// networkKeys hold the registered range of an address, in RPSL (RIPE,
+// APNIC, AFRINIC, LACNIC) and ARIN responses
+var networkKeys = []string{"inetnum", "inet6num", "netrange", "cidr"}
+
+// Parse reads the most specific network containing addr from a whois
+// response, with the holder and abuse contacts of the whole response. It
+// returns one network per CIDR block of the range, or none.
+func Parse(text string, addr netip.Addr) []models.WhoisNetwork {
+	var blocks [][]field
+	var block []field
+	var abuse []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := abuseComment.FindStringSubmatch(line); m != nil {
+			abuse = appendEmail(abuse, m[1])
+			continue
+		}
+		if strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		// Continuation lines start with a space or +
+		key, value, ok := strings.Cut(line, ":")
+		if ok && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "+") {
+			block = append(block, field{strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)})
+		}
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+
+	// The most specific range containing the address
+	var network []field
+	var prefixes []netip.Prefix
+	var size netip.Addr
+	for _, b := range blocks {
+		p := blockPrefixes(b)
+		if len(p) == 0 || !containsAddr(p, addr) {
+			continue
+		}
+		if s := rangeSize(p); network == nil || s.Less(size) {
+			network, prefixes, size = b, p, s
+		}
+	}
+	if network == nil {
+		return nil
+	}
+
+	w := models.WhoisNetwork{
+		Range:   value(network, "inetnum", "inet6num", "netrange"),
+		NetName: value(network, "netname"),
+		Country: strings.ToUpper(value(network, "country")),
+		Org:     value(network, "orgname", "org-name", "owner"),
+	}
+
+	// The holder referenced by the network: "org: ORG-ID" in RPSL,
+	// "Organization: Name (ORG-ID)" at ARIN. Responses may include the
+	// holders of less specific networks too.
+	var org [][]field
+	if handle := value(network, "org"); handle != "" {
+		org = section(blocks, "organisation", handle)
+	}
+	if name := value(network, "organization"); name != "" {
+		if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+			org = section(blocks, "orgid", name[i+2:len(name)-1])
+			name = name[:i]
+		}
+		if w.Org == "" {
+			w.Org = name
+		}
+	}
+	if len(org) > 0 {
+		if w.Org == "" {
+			w.Org = value(org[0], "orgname", "org-name")
+		}
+		if w.Country == "" {
+			w.Country = strings.ToUpper(value(org[0], "country"))
+		}
+	}
+
+	// Abuse contacts referenced by handle from the network or its holder:
+	// abuse-c (RIPE, APNIC, LACNIC, the latter with e-mail only) and the
+	// IRT of APNIC
+	referencing := [][]field{network}
+	if len(org) > 0 {
+		referencing = append(referencing, org[0])
+	}
+	for _, b := range referencing {
+		for _, f := range b {
+			switch f.key {
+			case "abuse-c":
+				abuse = appendEmail(abuse, value(first(section(blocks, "nic-hdl", f.value)), "abuse-mailbox", "e-mail"))
+			case "mnt-irt":
+				abuse = appendEmail(abuse, value(first(section(blocks, "irt", f.value)), "abuse-mailbox"))
+			}
+		}
+	}
+	if len(abuse) == 0 {
+		searched := blocks
+		if len(org) > 0 {
+			searched = org
+		}
+		for _, b := range searched {
+			for _, f := range b {
+				if f.key == "orgabuseemail" || f.key == "abuse-mailbox" {
+					abuse = appendEmail(abuse, f.value)
+				}
+			}
+		}
+	}
+	if w.Org == "" {
+		for _, b := range blocks {
+			if w.Org = value(b, "orgname", "org-name"); w.Org != "" {
+				break
+			}
+		}
+	}
+	if w.Org == "" {
+		w.Org = value(network, "descr")
+	}
+	w.AbuseEmails = abuse
+
+	networks := make([]models.WhoisNetwork, len(prefixes))
+	for i, prefix := range prefixes {
+		networks[i] = w
+		networks[i].Prefix = prefix.String()
+	}
+	return networks
+}
+
+// sectionEnds start the next network or holder of a response
+var sectionEnds = append([]string{"orgid", "organisation"}, networkKeys...)
+
+// section returns the block whose key has the given value, e.g. the
+// organisation object of a handle, with the blocks following it up to the
+// next network or holder: ARIN lists the contacts of an organization in
+// blocks of their own after it
+func section(blocks [][]field, key, handle string) [][]field {
+	for i, b := range blocks {
+		if !strings.EqualFold(value(b, key), handle) {
+			continue
+		}
+		end := i + 1
+		for end < len(blocks) && value(blocks[end], sectionEnds...) == "" {
+			end++
+		}
+		return blocks[i:end]
+	}
+	return nil
+}
+
+// first returns the first block of a section, or nil
+func first(blocks [][]field) []field {
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[0]
+}
+
+// value returns the first value of the keys in a block
+func value(block []field, keys ...string) string {
+	for _, f := range block {
+		for _, key := range keys {
+			if f.key == key && f.value != "" {
+				return f.value
+			}
+		}
+	}
+	return ""
+}
+
+// appendEmail adds an address unless it is empty or present
+func appendEmail(emails []string, email string) []string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return emails
+	}
+	for _, e := range emails {
+		if e == email {
+			return emails
+		}
+	}
+	return append(emails, email)
+}
+
+// blockPrefixes returns the CIDR blocks of the range a block registers:
+// "a - b" ranges, CIDR notation (also LACNIC's shortened "200.0.0/16")
+// and ARIN's comma-separated CIDR lists
+func blockPrefixes(block []field) []netip.Prefix {
+	for _, key := range networkKeys {
+		v := value(block, key)
+		if v == "" {
+			continue
+		}
+		if first, last, ok := strings.Cut(v, " - "); ok {
+			start, err1 := netip.ParseAddr(strings.TrimSpace(first))
+			end, err2 := netip.ParseAddr(strings.TrimSpace(last))
+			if err1 != nil || err2 != nil || start.Is4() != end.Is4() || end.Less(start) {
+				return nil
+			}
+			return rangePrefixes(start, end)
+		}
+		var prefixes []netip.Prefix
+		for _, s := range strings.Split(v, ",") {
+			if p, ok := parsePrefix(strings.TrimSpace(s)); ok {
+				prefixes = append(prefixes, p)
+			}
+		}
+		return prefixes
+	}
+	return nil
+}
+
+// parsePrefix parses CIDR notation, padding shortened IPv4 addresses
+func parsePrefix(s string) (netip.Prefix, bool) {
+	address, bits, ok := strings.Cut(s, "/")
+	if !ok {
+		return netip.Prefix{}, false
+	}
+	if !strings.Contains(address, ":") {
+		for strings.Count(address, ".") < 3 {
+			address += ".0"
+		}
+	}
+	p, err := netip.ParsePrefix(address + "/" + bits)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return p.Masked(), true
+}
+
+// rangePrefixes splits a range into the fewest CIDR blocks
+func rangePrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for len(prefixes) < maxBlocks {
+		p := netip.PrefixFrom(start, start.BitLen())
+		for bits := start.BitLen() - 1; bits >= 0; bits-- {
+			q := netip.PrefixFrom(start, bits).Masked()
+			if q.Addr() != start || end.Less(lastAddr(q)) {
+				break
+			}
+			p = q
+		}
+		prefixes = append(prefixes, p)
+		last := lastAddr(p)
+		if !last.Less(end) || !last.Next().IsValid() {
+			break
+		}
+		start = last.Next()
+	}
+	return prefixes
+}
+
+// lastAddr returns the last address of a prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := range b {
+		for bit := 0; bit < 8; bit++ {
+			if i*8+bit >= p.Bits() {
+				b[i] |= 0x80 >> bit
+			}
+		}
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// containsAddr reports whether any prefix contains addr
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeSize orders ranges by size: the offset of the last address from
+// the first, compared as an address
+func rangeSize(prefixes []netip.Prefix) netip.Addr {
+	first := prefixes[0].Addr().AsSlice()
+	last := lastAddr(prefixes[len(prefixes)-1]).AsSlice()
+	borrow := 0
+	for i := len(last) - 1; i >= 0; i-- {
+		d := int(last[i]) - int(first[i]) - borrow
+		borrow = 0
+		if d < 0 {
+			d += 256
+			borrow = 1
+		}
+		last[i] = byte(d)
+	}
+	size, _ := netip.AddrFromSlice(last)
+	return size
+}
+
+// Find returns the most specific network containing an address
+func Find(networks []models.WhoisNetwork, ip string) (models.WhoisNetwork, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return models.WhoisNetwork{}, false
+	}
+	addr = addr.Unmap()
+	var best models.WhoisNetwork
+	bestBits := -1
+	for _, n := range networks {
+		p, err := netip.ParsePrefix(n.Prefix)
+		if err == nil && p.Contains(addr) && p.Bits() > bestBits {
+			best, bestBits = n, p.Bits()
+		}
+	}
+	return best, bestBits >= 0
+}
+
+// Looker looks up the registered network of an address; Client
+// implements it
+type Looker interface {
+	Lookup(ctx context.Context, ip string) ([]models.WhoisNetwork, error)
+}
+
+// LookupAll looks up the networks of addresses with parallel workers.
+// Registered ranges are rarely smaller than a /24 (IPv6: /48), so one
+// address per such block is asked first, and the others only if its
+// network does not cover them. It returns the networks sorted by prefix,
+// the number of failed lookups and the first failure.
+func LookupAll(ctx context.Context, looker Looker, ips []string, workers int) ([]models.WhoisNetwork, int, error) {
+	seen := make(map[netip.Prefix]bool)
+	var first, rest []string
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		bits := 24
+		if addr.Is6() {
+			bits = 48
+		}
+		block := netip.PrefixFrom(addr, bits).Masked()
+		if seen[block] {
+			rest = append(rest, ip)
+		} else {
+			seen[block] = true
+			first = append(first, ip)
+		}
+	}
+
+	networks, failed, firstErr := lookupParallel(ctx, looker, first, workers)
+	var uncovered []string
+	for _, ip := range rest {
+		if _, ok := Find(networks, ip); !ok {
+			uncovered = append(uncovered, ip)
+		}
+	}
+	if len(uncovered) > 0 {
+		more, moreFailed, err := lookupParallel(ctx, looker, uncovered, workers)
+		networks = append(networks, more...)
+		failed += moreFailed
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	byPrefix := make(map[string]models.WhoisNetwork)
+	for _, n := range networks {
+		byPrefix[n.Prefix] = n
+	}
+	networks = networks[:0]
+	for _, n := range byPrefix {
+		networks = append(networks, n)
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Prefix < networks[j].Prefix
+	})
+	return networks, failed, firstErr
+}
+
+// lookupParallel looks up addresses with parallel workers, skipping those
+// covered by a network found meanwhile
+func lookupParallel(ctx context.Context, looker Looker, ips []string, workers int) ([]models.WhoisNetwork, int, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, ip := range ips {
+			select {
+			case jobs <- ip:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var networks []models.WhoisNetwork
+	var failed int
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				mu.Lock()
+				_, covered := Find(networks, ip)
+				mu.Unlock()
+				if covered {
+					continue
+				}
+				found, err := looker.Lookup(ctx, ip)
+				mu.Lock()
+				if err != nil {
+					failed++
+					if firstErr == nil {
+						firstErr = err
+					}
+				}
+				networks = append(networks, found...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return networks, failed, firstErr
+}
@@ -0,0 +1,258 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3gpp-scanner/internal/models"
+)
+
+const ripeResponse = `% This is the RIPE Database query service.
+
+% Information related to '193.0.0.0 - 193.0.7.255'
+
+% Abuse contact for '193.0.0.0 - 193.0.7.255' is 'Abuse@ripe.net'
+
+inetnum:        193.0.0.0 - 193.0.7.255
+netname:        RIPE-NCC
+descr:          RIPE Network Coordination Centre
+org:            ORG-RIEN1-RIPE
+country:        NL
+abuse-c:        ops4-ripe
+remarks:        Used for RIPE NCC infrastructure,
+                spanning two lines
+
+organisation:   ORG-RIEN1-RIPE
+org-name:       Reseaux IP Europeens Network Coordination Centre (RIPE NCC)
+country:        NL
+
+role:           RIPE NCC Operations
+nic-hdl:        OPS4-RIPE
+abuse-mailbox:  abuse@ripe.net
+`
+
+const arinResponse = `# ARIN WHOIS data and services are subject to the Terms of Use
+
+NetRange:       192.0.0.0 - 192.0.255.255
+CIDR:           192.0.0.0/16
+NetName:        PARENT-NET
+Organization:   Example Transit (EXT-1)
+
+OrgName:        Example Transit
+OrgId:          EXT-1
+Country:        US
+
+OrgAbuseHandle: ABUSE1-ARIN
+OrgAbuseEmail:  abuse@transit.example
+
+NetRange:       192.0.2.0 - 192.0.2.127
+CIDR:           192.0.2.0/25
+NetName:        EXAMPLE-MOBILE
+Organization:   Example Mobile Inc. (EXM-2)
+
+OrgName:        Example Mobile Inc.
+OrgId:          EXM-2
+Country:        US
+
+OrgTechHandle:  TECH2-ARIN
+OrgTechEmail:   noc@mobile.example
+
+OrgAbuseHandle: ABUSE2-ARIN
+OrgAbuseEmail:  security@mobile.example
+`
+
+const lacnicResponse = `% IP Client: 192.0.2.1
+
+inetnum:     200.160/12
+status:      allocated
+owner:       Operadora Exemplo S.A.
+country:     BR
+abuse-c:     ABC12
+
+nic-hdl:     ABC12
+person:      Abuse Desk
+e-mail:      abuse@exemplo.com.br
+`
+
+const apnicResponse = `inetnum:        203.0.113.0 - 203.0.114.255
+netname:        EXAMPLE-AP
+descr:          Example Telecom
+country:        JP
+mnt-irt:        IRT-EXAMPLE-JP
+
+irt:            IRT-EXAMPLE-JP
+abuse-mailbox:  irt@example.jp
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		ip       string
+		want     []models.WhoisNetwork
+	}{
+		{"ripe", ripeResponse, "193.0.0.1", []models.WhoisNetwork{{
+			Prefix: "193.0.0.0/21", Range: "193.0.0.0 - 193.0.7.255", NetName: "RIPE-NCC",
+			Org: "Reseaux IP Europeens Network Coordination Centre (RIPE NCC)", Country: "NL",
+			AbuseEmails: []string{"abuse@ripe.net"},
+		}}},
+		{"arin most specific", arinResponse, "192.0.2.10", []models.WhoisNetwork{{
+			Prefix: "192.0.2.0/25", Range: "192.0.2.0 - 192.0.2.127", NetName: "EXAMPLE-MOBILE",
+			Org: "Example Mobile Inc.", Country: "US", AbuseEmails: []string{"security@mobile.example"},
+		}}},
+		{"arin parent", arinResponse, "192.0.2.200", []models.WhoisNetwork{{
+			Prefix: "192.0.0.0/16", Range: "192.0.0.0 - 192.0.255.255", NetName: "PARENT-NET",
+			Org: "Example Transit", Country: "US", AbuseEmails: []string{"abuse@transit.example"},
+		}}},
+		{"lacnic", lacnicResponse, "200.160.2.3", []models.WhoisNetwork{{
+			Prefix: "200.160.0.0/12", Range: "200.160/12", Org: "Operadora Exemplo S.A.", Country: "BR",
+			AbuseEmails: []string{"abuse@exemplo.com.br"},
+		}}},
+		{"apnic unaligned", apnicResponse, "203.0.114.9", []models.WhoisNetwork{
+			{Prefix: "203.0.113.0/24", Range: "203.0.113.0 - 203.0.114.255", NetName: "EXAMPLE-AP", Org: "Example Telecom", Country: "JP", AbuseEmails: []string{"irt@example.jp"}},
+			{Prefix: "203.0.114.0/24", Range: "203.0.113.0 - 203.0.114.255", NetName: "EXAMPLE-AP", Org: "Example Telecom", Country: "JP", AbuseEmails: []string{"irt@example.jp"}},
+		}},
+		{"not covered", ripeResponse, "198.51.100.1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.response, netip.MustParseAddr(tt.ip))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangePrefixes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{"192.0.2.0", "192.0.2.255", "192.0.2.0/24"},
+		{"192.0.2.0", "192.0.3.127", "192.0.2.0/24 192.0.3.0/25"},
+		{"192.0.2.1", "192.0.2.2", "192.0.2.1/32 192.0.2.2/32"},
+		{"0.0.0.0", "255.255.255.255", "0.0.0.0/0"},
+		{"2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", "2001:db8::/32"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range rangePrefixes(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)) {
+			got = append(got, p.String())
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("rangePrefixes(%s, %s) = %v, want %s", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+// startServer serves whois queries on a local port
+func startServer(t *testing.T, respond func(query string) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, _ := bufio.NewReader(conn).ReadString('\n')
+				fmt.Fprint(conn, respond(strings.TrimSpace(query)))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestLookupReferral(t *testing.T) {
+	ripe := startServer(t, func(query string) string {
+		if query != "193.0.0.1" {
+			return "%ERROR:101: no entries found\n"
+		}
+		return ripeResponse
+	})
+	iana := startServer(t, func(query string) string {
+		return "% IANA WHOIS server\n\nrefer:        " + ripe + "\n\ninetnum:      193.0.0.0 - 193.255.255.255\norganisation: RIPE NCC\n"
+	})
+
+	client := &Client{Server: iana, Timeout: 5 * time.Second}
+	networks, err := client.Lookup(context.Background(), "193.0.0.1")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if len(networks) != 1 || networks[0].Prefix != "193.0.0.0/21" || networks[0].Server != ripe {
+		t.Errorf("Expected the RIPE network from the referred server, got %+v", networks)
+	}
+
+	networks, err = client.Lookup(context.Background(), "193.1.0.1")
+	if err != nil || len(networks) != 0 {
+		t.Errorf("Expected no network for an unknown address, got %+v, %v", networks, err)
+	}
+	if _, err := client.Lookup(context.Background(), "not-an-ip"); err == nil {
+		t.Error("Expected error for an invalid address")
+	}
+}
+
+func TestQueryFor(t *testing.T) {
+	addr := netip.MustParseAddr("192.0.2.1")
+	if got := queryFor("whois.arin.net", addr); got != "n + 192.0.2.1" {
+		t.Errorf("ARIN query = %q", got)
+	}
+	if got := queryFor("whois.ripe.net:43", addr); got != "192.0.2.1" {
+		t.Errorf("RIPE query = %q", got)
+	}
+}
+
+// fakeLooker answers from ARIN-style responses, counting lookups
+type fakeLooker struct {
+	lookups atomic.Int32
+}
+
+func (f *fakeLooker) Lookup(ctx context.Context, ip string) ([]models.WhoisNetwork, error) {
+	f.lookups.Add(1)
+	if strings.HasPrefix(ip, "203.") {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return Parse(arinResponse, netip.MustParseAddr(ip)), nil
+}
+
+func TestLookupAll(t *testing.T) {
+	looker := &fakeLooker{}
+	ips := []string{"192.0.2.1", "192.0.2.2", "192.0.2.100", "192.0.2.200", "203.0.113.1"}
+	networks, failed, err := LookupAll(context.Background(), looker, ips, 2)
+	if failed != 1 || err == nil {
+		t.Errorf("Expected one failed lookup, got %d (%v)", failed, err)
+	}
+	// 192.0.2.1 covers .2 and .100; .200 is outside its /25
+	if n := looker.lookups.Load(); n != 3 {
+		t.Errorf("Expected 3 lookups, got %d", n)
+	}
+	var prefixes []string
+	for _, n := range networks {
+		prefixes = append(prefixes, n.Prefix)
+	}
+	if strings.Join(prefixes, " ") != "192.0.0.0/16 192.0.2.0/25" {
+		t.Errorf("Unexpected networks: %v", prefixes)
+	}
+
+	if n, ok := Find(networks, "192.0.2.5"); !ok || n.Prefix != "192.0.2.0/25" {
+		t.Errorf("Find(192.0.2.5) = %+v, %v; want the most specific network", n, ok)
+	}
+	if _, ok := Find(networks, "198.51.100.1"); ok {
+		t.Error("Find matched an address outside every network")
+	}
+}
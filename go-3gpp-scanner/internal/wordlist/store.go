@@ -0,0 +1,176 @@
+package wordlist
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the name of the built-in wordlist
+const DefaultName = "default"
+
+// validName restricts wordlist names to safe file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Store manages named wordlists kept as text files in a directory
+type Store struct {
+	Dir string
+}
+
+// Info describes a stored wordlist
+type Info struct {
+	Name    string
+	Labels  int
+	BuiltIn bool // true when the embedded default has not been overridden
+}
+
+// NewStore creates a store rooted at dir. An empty dir selects the user
+// config directory (e.g. ~/.config/3gpp-scanner/wordlists).
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine config directory: %w", err)
+		}
+		dir = filepath.Join(configDir, "3gpp-scanner", "wordlists")
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// List returns all wordlists, including the built-in default
+func (s *Store) List() ([]Info, error) {
+	infos := make(map[string]Info)
+	infos[DefaultName] = Info{Name: DefaultName, Labels: len(Default()), BuiltIn: true}
+
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wordlists: %w", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		labels, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		infos[name] = Info{Name: name, Labels: len(labels)}
+	}
+
+	list := make([]Info, 0, len(infos))
+	for _, info := range infos {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Load returns the labels of a named wordlist. A missing wordlist is an
+// error matching fs.ErrNotExist.
+func (s *Store) Load(name string) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	path := s.path(name)
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return LoadFile(path)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	case name == DefaultName:
+		return Default(), nil
+	}
+	return nil, fmt.Errorf("wordlist %q not found in %s: %w", name, s.Dir, fs.ErrNotExist)
+}
+
+// Add appends labels to a named wordlist, creating it if needed. Adding to
+// "default" first copies the built-in list so it can be extended. A list
+// that exists but cannot be read is left alone.
+func (s *Store) Add(name string, labels []string) (int, error) {
+	existing, err := s.Load(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	merged, added := merge(existing, labels)
+	if err := s.write(name, merged); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// Import replaces (or creates) a named wordlist with the contents of a file
+func (s *Store) Import(name, srcPath string) (int, error) {
+	if err := checkName(name); err != nil {
+		return 0, err
+	}
+
+	labels, err := LoadFile(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.write(name, labels); err != nil {
+		return 0, err
+	}
+	return len(labels), nil
+}
+
+// Resolve loads a wordlist given either a file path or a stored name
+func (s *Store) Resolve(ref string) ([]string, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return LoadFile(ref)
+	}
+	return s.Load(ref)
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.Dir, name+".txt")
+}
+
+// write stores labels one per line
+func (s *Store) write(name string, labels []string) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create wordlist directory: %w", err)
+	}
+
+	data := strings.Join(labels, "\n")
+	if len(labels) > 0 {
+		data += "\n"
+	}
+	if err := os.WriteFile(s.path(name), []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write wordlist: %w", err)
+	}
+	return nil
+}
+
+// merge appends normalized new labels not already present
+func merge(existing, labels []string) ([]string, int) {
+	seen := make(map[string]bool, len(existing))
+	for _, label := range existing {
+		seen[label] = true
+	}
+
+	added := 0
+	for _, label := range labels {
+		label = strings.Trim(strings.ToLower(strings.TrimSpace(label)), ".")
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		existing = append(existing, label)
+		added++
+	}
+	return existing, added
+}
+
+func checkName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid wordlist name %q (use letters, digits, - and _)", name)
+	}
+	return nil
+}
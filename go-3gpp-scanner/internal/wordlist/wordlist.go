@@ -0,0 +1,72 @@
+package wordlist
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed default.txt
+var defaultList string
+
+// Default returns the built-in label list
+func Default() []string {
+	labels, _ := parse(strings.NewReader(defaultList))
+	return labels
+}
+
+// LoadFile reads a wordlist file (one label per line, # comments allowed)
+func LoadFile(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer file.Close()
+
+	labels, err := parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	return labels, nil
+}
+
+// Exclude returns labels with any entries from skip removed
+func Exclude(labels, skip []string) []string {
+	skipSet := make(map[string]bool, len(skip))
+	for _, s := range skip {
+		skipSet[strings.ToLower(s)] = true
+	}
+
+	var kept []string
+	for _, label := range labels {
+		if !skipSet[label] {
+			kept = append(kept, label)
+		}
+	}
+	return kept
+}
+
+// parse reads labels, normalizing case and dropping blanks, comments and duplicates
+func parse(r io.Reader) ([]string, error) {
+	seen := make(map[string]bool)
+	var labels []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		line = strings.Trim(line, ".")
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		labels = append(labels, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
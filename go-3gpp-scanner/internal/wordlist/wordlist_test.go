@@ -0,0 +1,116 @@
+package wordlist
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	labels := Default()
+	if len(labels) == 0 {
+		t.Fatalf("Default wordlist is empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		if label == "" || label[0] == '#' {
+			t.Errorf("Unexpected label %q in default wordlist", label)
+		}
+		if seen[label] {
+			t.Errorf("Duplicate label %q in default wordlist", label)
+		}
+		seen[label] = true
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tmpFile := t.TempDir() + "/words.txt"
+	data := "# comment\nSIP\n\npcscf.ims.\nsip\n"
+	if err := os.WriteFile(tmpFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write wordlist: %v", err)
+	}
+
+	labels, err := LoadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(labels) != 2 || labels[0] != "sip" || labels[1] != "pcscf.ims" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	labels := Exclude([]string{"ims", "sip", "epdg.epc"}, []string{"IMS", "epdg.epc"})
+	if len(labels) != 1 || labels[0] != "sip" {
+		t.Errorf("Expected [sip], got %v", labels)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	// Built-in default is always available
+	labels, err := store.Load(DefaultName)
+	if err != nil || len(labels) != len(Default()) {
+		t.Fatalf("Expected built-in default, got %d labels (err=%v)", len(labels), err)
+	}
+
+	added, err := store.Add("custom", []string{"sip", "SBC", "sip"})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 labels added, got %d", added)
+	}
+
+	srcFile := t.TempDir() + "/import.txt"
+	if err := os.WriteFile(srcFile, []byte("hss\nota\n"), 0644); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+	if n, err := store.Import("imported", srcFile); err != nil || n != 2 {
+		t.Fatalf("Import returned %d, %v", n, err)
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("Expected 3 wordlists, got %d: %+v", len(list), list)
+	}
+
+	// Resolve accepts both file paths and names
+	if labels, err := store.Resolve(srcFile); err != nil || len(labels) != 2 {
+		t.Errorf("Resolve(path) returned %v, %v", labels, err)
+	}
+	if labels, err := store.Resolve("custom"); err != nil || len(labels) != 2 {
+		t.Errorf("Resolve(name) returned %v, %v", labels, err)
+	}
+
+	if _, err := store.Load("../escape"); err == nil {
+		t.Errorf("Expected error for invalid name")
+	}
+	if _, err := store.Load("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing wordlist, got %v", err)
+	}
+
+	// A list that cannot be read is not replaced by the new labels
+	unreadable := filepath.Join(store.Dir, "unreadable.txt")
+	content := []byte("sip\n" + strings.Repeat("x", 70000) + "\n")
+	if err := os.WriteFile(unreadable, content, 0644); err != nil {
+		t.Fatalf("Failed to write wordlist: %v", err)
+	}
+	if _, err := store.Add("unreadable", []string{"ims"}); err == nil {
+		t.Errorf("Expected error adding to an unreadable wordlist")
+	}
+	if data, _ := os.ReadFile(unreadable); string(data) != string(content) {
+		t.Errorf("Unreadable wordlist was overwritten")
+	}
+}